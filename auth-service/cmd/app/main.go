@@ -2,39 +2,119 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
-	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/cleanup"
 	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/config"
 	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/db"
+	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/grpcserver"
 	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/handler"
+	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/hasher"
 	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/logger"
+	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/model"
+	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/notify"
+	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/outbox"
 	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/repository"
+	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/router"
 	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/service"
+	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/storage"
+	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/webhook"
 	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
 )
 
 const configPath = "config/config.yml"
 
+// Спецификация генерируется в docs/ из аннотаций хендлеров: make swagger (или go generate ./...)
+//go:generate go run github.com/swaggo/swag/cmd/swag@v1.16.6 init -d ../.. -g cmd/app/main.go -o ../../docs
+
+// @title                       micro-blog-hub auth-service API
+// @version                     1.0
+// @description                 Регистрация, вход и управление пользователями.
+// @securityDefinitions.apikey  BearerAuth
+// @in                          header
+// @name                        Authorization
+// @description                 "Bearer <jwt>" или "Token <api-token>"; браузер вместо этого шлет cookie token
 func main() {
+	configFile := flag.String("config", configPath, "path to the config file; without it APP_ENV selects config/config.<APP_ENV>.yml")
+	checkConfig := flag.Bool("check-config", false, "validate the config and exit without starting the server")
+	flag.Parse()
+
+	// Явный -config важнее APP_ENV
+	if !flagSet("config") {
+		*configFile = config.ResolvePath(*configFile)
+	}
+
+	if *checkConfig {
+		os.Exit(runCheckConfig(*configFile, os.Stdout))
+	}
+
 	ctx := context.Background()
 
-	if err := run(ctx); err != nil {
+	if err := run(ctx, *configFile); err != nil {
 		log.Fatalf("application error: %v", err)
 	}
 }
 
-func run(ctx context.Context) error {
-	log.Printf("INFO: starting application")
+// flagSet - флаг передан в командной строке, а не остался значением по умолчанию
+func flagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// runCheckConfig - режим -check-config: те же Load и Validate, что при старте,
+// но без логгера, БД и сервера. Возвращает код выхода.
+func runCheckConfig(path string, out io.Writer) int {
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		fmt.Fprintf(out, "WARN: %s not found, checking defaults and environment only\n", path)
+	}
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		fmt.Fprintf(out, "FAIL: %v\n", err)
+		return 1
+	}
+
+	if err := cfg.Validate(); err != nil {
+		errs := []error{err}
+		var joined interface{ Unwrap() []error }
+		if errors.As(err, &joined) {
+			errs = joined.Unwrap()
+		}
+
+		fmt.Fprintf(out, "FAIL: %s has %d problem(s):\n", path, len(errs))
+		for _, e := range errs {
+			fmt.Fprintf(out, "  - %v\n", e)
+		}
+		return 1
+	}
+
+	fmt.Fprintf(out, "OK: %s is valid\n", path)
+	return 0
+}
+
+func run(ctx context.Context, configFile string) (err error) {
+	log.Printf("INFO: starting application with config %s", configFile)
 
-	cfg, err := config.Load(configPath)
+	cfg, err := config.Load(configFile)
 	if err != nil {
 		return err
 	}
@@ -42,115 +122,265 @@ func run(ctx context.Context) error {
 		return err
 	}
 
-	logger, err := logger.New(cfg.Logging.Level, cfg.App.Mode)
+	logger, err := logger.New(cfg.Logging.Level, cfg.App.Mode, logger.Sampling{
+		Initial:    cfg.Logging.Sampling.Initial,
+		Thereafter: cfg.Logging.Sampling.Thereafter,
+	}, logger.File{
+		Path:       cfg.Logging.File.Path,
+		MaxSizeMB:  cfg.Logging.File.MaxSizeMB,
+		MaxBackups: cfg.Logging.File.MaxBackups,
+		MaxAgeDays: cfg.Logging.File.MaxAgeDays,
+	})
 	if err != nil {
 		return err
 	}
 
+	// Sync сбрасывает и stdout, и файл (если он настроен)
 	defer func() {
 		if err := logger.Sync(); err != nil {
 			fmt.Fprintf(os.Stderr, "failed to sync logger: %v\n", err)
 		}
 	}()
 
+	// При security.cookie_check=strict эти же проблемы уже отвергнуты в Validate
+	for _, problem := range cfg.CookieProblems() {
+		logger.Warn("auth cookie misconfiguration: login may work locally but fail in production", zap.Error(problem))
+	}
+
+	// Ресурсы регистрируются по мере запуска и останавливаются в обратном порядке -
+	// и при обычном выходе, и если run упал на старте. Ошибки остановки попадают в err.
+	var shutdown shutdownSequence
+	defer func() {
+		err = errors.Join(err, shutdown.run(cfg.Server.ShutdownTimeout, logger))
+	}()
+
 	// 1️⃣ DB
 	database, err := db.Connect(ctx, cfg, logger)
 	if err != nil {
 		return err
 	}
-	defer database.Pool.Close()
+	// Закрывается последним: до этого его используют HTTP, gRPC и воркеры
+	shutdown.add("database", database.Close)
 
 	// 2️⃣ Repository
-	authRepo := repository.NewAuthRepository(database.Pool, logger)
+	authRepo := repository.NewAuthRepository(database.Pool, database.Replica, logger, cfg.Database.MaxConcurrentQueries, cfg.Database.BusyTimeout)
 
-	// 3️⃣ Service
-	authService := service.NewAuthService(
-		authRepo,
-		logger,
-		cfg.JWT.Secret,
-		time.Duration(cfg.JWT.ExpirationHours),
-	)
+	passwordHasher, err := hasher.New(cfg.Security.HashAlgorithm, cfg.Security.BcryptCost)
+	if err != nil {
+		return err
+	}
 
-	// 4️⃣ Handler
-	h := handler.NewAuthHandler(
-		authService,
-		logger,
-		cfg.App.Mode,
-		cfg.JWT.Secret,
-		time.Duration(cfg.JWT.ExpirationHours),
-	)
+	// Фоновые воркеры останавливаются после HTTP- и gRPC-серверов, run ждет их завершения
+	var workers sync.WaitGroup
+	workerCtx, stopWorkers := context.WithCancel(ctx)
+	shutdown.add("background workers", func(ctx context.Context) error {
+		stopWorkers()
+		done := make(chan struct{})
+		go func() {
+			workers.Wait()
+			close(done)
+		}()
+
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			return fmt.Errorf("workers still running: %w", ctx.Err())
+		}
+	})
 
-	// Устанавливаем режим работы Gin
-	if cfg.App.Mode == "release" {
-		gin.SetMode(gin.ReleaseMode)
-	} else {
-		gin.SetMode(gin.DebugMode)
+	// Webhooks: без URL диспетчер не создается, relay просто помечает события отправленными
+	var events outbox.Publisher
+	if len(cfg.Webhooks.URLs) > 0 {
+		dispatcher := webhook.NewDispatcher(webhook.NewPostgresStore(database.Pool), webhook.Config{
+			URLs:           cfg.Webhooks.URLs,
+			Secret:         cfg.Webhooks.Secret,
+			MaxAttempts:    cfg.Webhooks.MaxAttempts,
+			InitialBackoff: cfg.Webhooks.InitialBackoff,
+			MaxBackoff:     cfg.Webhooks.MaxBackoff,
+			PollInterval:   cfg.Webhooks.PollInterval,
+			BatchSize:      cfg.Webhooks.BatchSize,
+			Timeout:        cfg.Webhooks.Timeout,
+		}, logger)
+		workers.Go(func() { dispatcher.Run(workerCtx) })
+		events = dispatcher
 	}
 
-	// 5️⃣ Router
-	r := gin.New()
-	r.Use(gin.Recovery())
-	r.Use(handler.ZapLogger(logger))
-
-	// ВАЖНО: Добавляем CORS middleware перед роутами
-	corsConfig := cors.DefaultConfig()
-	// Разрешаем запросы с фронтенда (указываем порт Svelte, обычно 5173)
-	if cfg.Frontend.Host == "" {
-		logger.Warn("frontend host is not specified")
-	} else {
-		corsConfig.AllowOrigins = []string{cfg.Frontend.Host}
-		logger.Info("allowed requests", zap.String("host", cfg.Frontend.Host))
-	}
+	// Relay безопасно запускать в каждой реплике: строки outbox берутся через SKIP LOCKED
+	relay := outbox.NewRelay(outbox.NewPostgresStore(database.Pool), events, cfg.Outbox.PollInterval, cfg.Outbox.BatchSize, logger)
+	workers.Go(func() { relay.Run(workerCtx) })
 
-	// Разрешаем нужные методы, включая OPTIONS
-	corsConfig.AllowMethods = config.AllowMethods()
-	corsConfig.AllowHeaders = config.AllowHeaders()
-	// Жизненно важно для credentials: 'include' в api.ts!
-	corsConfig.AllowCredentials = true
+	// Чистка: jitter разводит реплики по времени, advisory lock гарантирует одного исполнителя
+	janitor := cleanup.NewJanitor(cleanup.NewPostgresStore(database.Pool, cleanup.DefaultTargets), cfg.Cleanup.Interval, cfg.Cleanup.Retention, logger)
+	workers.Go(func() { janitor.Run(workerCtx) })
 
-	r.Use(cors.New(corsConfig))
+	// gRPC: пока только grpc.health.v1.Health, SERVING - пока доступна база
+	if cfg.GRPC.Port != "" {
+		lis, err := net.Listen("tcp", ":"+cfg.GRPC.Port)
+		if err != nil {
+			return fmt.Errorf("grpc listen: %w", err)
+		}
 
-	r.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{"status": "ok"})
-	})
+		// Запросы проверяются теми же правилами, что и в HTTP
+		requestValidator := model.NewValidator(cfg.Security.PasswordMaxBytes, cfg.Security.EmailMode, cfg.Security.UsernamePattern)
+		grpcServer := grpc.NewServer(
+			// Recovery внутри логгера: паника попадет в лог вызова как Internal
+			grpc.ChainUnaryInterceptor(
+				grpcserver.UnaryLogger(logger),
+				grpcserver.UnaryRecovery(logger),
+				grpcserver.UnaryErrorCodes(),
+				grpcserver.UnaryValidator(requestValidator),
+			),
+			grpc.ChainStreamInterceptor(
+				grpcserver.StreamRecovery(logger),
+				grpcserver.StreamValidator(requestValidator),
+			),
+			// Без политики сервер считает пинги клиентов чаще раза в 5 минут злоупотреблением
+			grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+				MinTime:             cfg.GRPC.KeepaliveMinTime,
+				PermitWithoutStream: true,
+			}),
+		)
+		healthReporter := grpcserver.NewHealthReporter(authRepo, cfg.GRPC.HealthInterval, logger)
+		healthReporter.Register(grpcServer)
+		workers.Go(func() { healthReporter.Run(workerCtx) })
+
+		go func() {
+			logger.Info("gRPC server started", zap.String("addr", lis.Addr().String()))
+			if err := grpcServer.Serve(lis); err != nil {
+				logger.Error("grpc serve error", zap.Error(err))
+			}
+		}()
+		// Останавливается после HTTP-сервера, но до воркеров
+		shutdown.add("grpc server", func(ctx context.Context) error {
+			stopGRPC(ctx, grpcServer, logger)
+			return nil
+		})
+	}
 
-	auth := r.Group("/auth")
-	{
-		auth.POST("/signup", h.SignUp) // Регистрация
-		auth.POST("/signin", h.SignIn) // Логин
-		auth.POST("/logout", h.Logout)
+	// Уведомления: без smtp.host письма не отправляются
+	var notifier notify.Notifier = notify.Nop{}
+	if cfg.Notify.SMTP.Host != "" {
+		notifier = notify.NewSMTP(notify.SMTPConfig{
+			Host:     cfg.Notify.SMTP.Host,
+			Port:     cfg.Notify.SMTP.Port,
+			Username: cfg.Notify.SMTP.Username,
+			Password: cfg.Notify.SMTP.Password,
+			From:     cfg.Notify.SMTP.From,
+		})
 	}
 
-	users := r.Group("/users")
-	{
-		users.GET("", h.GetUsers)
+	// Блоклист одноразовой почты: nil - проверка выключена
+	var disposable *model.DisposableDomains
+	if cfg.Security.BlockDisposableEmail {
+		disposable, err = model.LoadDisposableDomains(cfg.Security.DisposableEmailFile, cfg.Security.DisposableEmailDomains)
+		if err != nil {
+			return err
+		}
+		logger.Info("disposable email blocklist loaded", zap.Int("domains", disposable.Len()))
 	}
 
-	user := r.Group("/user")
-	user.Use(h.AuthMiddleware)
-	{
-		user.GET("/:id", h.GetByID)
-		user.GET("/search", h.GetByEmail)
-		user.GET("/me", h.GetProfile)
+	// 3️⃣ Service
+	authService := service.NewAuthService(authRepo, passwordHasher, service.Config{
+		JWTSecret:     cfg.JWT.Secret,
+		JWTTTL:        cfg.JWT.TokenTTL(),
+		JWTAudience:   cfg.JWT.Audience,
+		SingleSession: cfg.Auth.SingleSession,
+		Pagination:    service.Pagination{DefaultLimit: cfg.Pagination.DefaultLimit, MaxLimit: cfg.Pagination.MaxLimit},
+		ResetTokenTTL: cfg.Auth.PasswordResetTTL,
+		RehashOnLogin: cfg.Security.RehashOnLogin,
+		Notifier:      notifier,
+		Disposable:    disposable,
+		NotBeforeSkew: cfg.JWT.NotBeforeSkew,
+	}, logger)
+
+	// Хранилище аватаров: local раздается самим сервисом, s3 - по подписанным ссылкам
+	var avatars storage.StorageProvider
+	if cfg.Storage.Backend == storage.BackendS3 {
+		s3Store, err := storage.NewS3(storage.S3Config{
+			Endpoint:     cfg.Storage.S3.Endpoint,
+			Region:       cfg.Storage.S3.Region,
+			Bucket:       cfg.Storage.S3.Bucket,
+			AccessKey:    cfg.Storage.S3.AccessKey,
+			SecretKey:    cfg.Storage.S3.SecretKey,
+			UsePathStyle: cfg.Storage.S3.UsePathStyle,
+		}, &http.Client{Timeout: 30 * time.Second})
+		if err != nil {
+			return err
+		}
+		avatars = s3Store
+	} else {
+		avatars = storage.NewLocal(cfg.Storage.Local.Dir, cfg.App.BasePath+cfg.Storage.Local.URLPrefix)
+	}
 
-		user.PUT("/password", h.ChangePassword)
-		user.PUT("/profile", h.ChangeProfile)
-		user.PUT("/email", h.ChangeEmail)
+	// 4️⃣ Handler
+	h := handler.NewAuthHandler(authService, handler.Config{
+		AppMode:          cfg.App.Mode,
+		Secret:           cfg.JWT.Secret,
+		JWTTTL:           cfg.JWT.TokenTTL(),
+		Audience:         cfg.JWT.Audience,
+		PreviousSecrets:  cfg.JWT.PreviousSecrets,
+		SignupAutoLogin:  cfg.Security.SignupAutoLogin,
+		TokenSource:      cfg.Auth.TokenSource,
+		PasswordMaxBytes: cfg.Security.PasswordMaxBytes,
+		EmailMode:        cfg.Security.EmailMode,
+		Avatars:          avatars,
+		AvatarURLTTL:     cfg.Storage.URLTTL,
+		SingleSession:    cfg.Auth.SingleSession,
+		UsernamePattern:  cfg.Security.UsernamePattern,
+		Leeway:           cfg.JWT.Leeway,
+	}, logger)
 
-		user.DELETE("/delete", h.Delete)
+	// Устанавливаем режим работы Gin
+	if cfg.App.Mode == "release" {
+		gin.SetMode(gin.ReleaseMode)
+	} else {
+		gin.SetMode(gin.DebugMode)
+	}
+
+	// 5️⃣ Router
+	r, err := router.NewRouter(h, cfg, logger, authRepo)
+	if err != nil {
+		return err
 	}
 
 	server := &http.Server{
-		Addr:    ":" + cfg.App.Port,
-		Handler: r,
+		Addr:              ":" + cfg.App.Port,
+		Handler:           r,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
 	}
 
+	conns := &connTracker{}
+	server.ConnState = conns.track
+
 	go func() {
-		log.Printf("INFO: HTTP server started on %s", server.Addr)
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		var err error
+		if tls := cfg.Server.TLS; tls.Enabled() {
+			log.Printf("INFO: HTTPS server started on %s", server.Addr)
+			err = server.ListenAndServeTLS(tls.CertFile, tls.KeyFile)
+		} else {
+			log.Printf("INFO: HTTP server started on %s", server.Addr)
+			err = server.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			logger.Error("server listen error", zap.Error(err))
 		}
 	}()
+	// Первым перестает принимать соединения и дожидается активных запросов
+	shutdown.add("http server", func(ctx context.Context) error {
+		if err := server.Shutdown(ctx); err != nil {
+			if errors.Is(err, context.DeadlineExceeded) {
+				logger.Warn("shutdown timeout reached, dropping in-flight requests",
+					zap.Duration("timeout", cfg.Server.ShutdownTimeout),
+					zap.Int("active_connections", conns.active()))
+			}
+			return fmt.Errorf("server forced to shutdown: %w", err)
+		}
+		return nil
+	})
 
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -158,14 +388,58 @@ func run(ctx context.Context) error {
 
 	logger.Info("Shutting down server...")
 
-	ctxShutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+	return nil
+}
 
-	if err := server.Shutdown(ctxShutdown); err != nil {
-		return fmt.Errorf("server forced to shutdown: %w", err)
+// stopGRPC дает текущим RPC завершиться, но не дольше дедлайна ctx:
+// Watch-стримы health сами не закрываются и держали бы GracefulStop вечно
+func stopGRPC(ctx context.Context, s *grpc.Server, logger *zap.Logger) {
+	done := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		logger.Warn("grpc graceful stop timed out, closing remaining streams")
+		s.Stop()
 	}
+}
 
-	logger.Info("Server exiting")
+// connTracker запоминает состояние каждого соединения, чтобы при shutdown
+// было видно, сколько запросов еще обрабатывалось к моменту дедлайна
+type connTracker struct {
+	mu    sync.Mutex
+	state map[net.Conn]http.ConnState
+}
 
-	return nil
+func (t *connTracker) track(conn net.Conn, state http.ConnState) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.state == nil {
+		t.state = make(map[net.Conn]http.ConnState)
+	}
+
+	switch state {
+	case http.StateHijacked, http.StateClosed:
+		delete(t.state, conn)
+	default:
+		t.state[conn] = state
+	}
+}
+
+func (t *connTracker) active() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	n := 0
+	for _, state := range t.state {
+		if state == http.StateActive {
+			n++
+		}
+	}
+	return n
 }
@@ -1,9 +1,11 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"net/http"
 	"os"
+	"path/filepath"
 	"syscall"
 	"testing"
 	"time"
@@ -37,7 +39,7 @@ func TestRun(t *testing.T) {
 	go func() {
 		// Вызываем твой оригинальный run(ctx).
 		// Он будет искать "config/config.yml" и найдет его!
-		errChan <- run(ctx)
+		errChan <- run(ctx, configPath)
 	}()
 
 	// 4. Ожидание старта (Polling)
@@ -82,3 +84,39 @@ func TestRun(t *testing.T) {
 		}
 	})
 }
+
+func TestRunCheckConfig(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("DB_HOST", "localhost")
+	t.Setenv("DB_PASSWORD", "pass")
+
+	t.Run("Valid config", func(t *testing.T) {
+		path := filepath.Join(dir, "ok.yml")
+		require.NoError(t, os.WriteFile(path, []byte("app:\n  port: 8040\n"), 0644))
+
+		var out bytes.Buffer
+		assert.Equal(t, 0, runCheckConfig(path, &out))
+		assert.Contains(t, out.String(), "OK")
+	})
+
+	t.Run("Every failed rule is listed", func(t *testing.T) {
+		path := filepath.Join(dir, "bad.yml")
+		body := "security:\n  hash_algorithm: md5\nauth:\n  token_source: query\n"
+		require.NoError(t, os.WriteFile(path, []byte(body), 0644))
+
+		var out bytes.Buffer
+		assert.Equal(t, 1, runCheckConfig(path, &out))
+		assert.Contains(t, out.String(), "2 problem(s)")
+		assert.Contains(t, out.String(), "security.hash_algorithm")
+		assert.Contains(t, out.String(), "auth.token_source")
+	})
+
+	t.Run("Malformed file", func(t *testing.T) {
+		path := filepath.Join(dir, "broken.yml")
+		require.NoError(t, os.WriteFile(path, []byte("app: [unclosed"), 0644))
+
+		var out bytes.Buffer
+		assert.Equal(t, 1, runCheckConfig(path, &out))
+		assert.Contains(t, out.String(), "FAIL")
+	})
+}
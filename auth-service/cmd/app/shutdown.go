@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// shutdownSequence - явный порядок остановки ресурсов. Шаги выполняются в обратном
+// порядке регистрации, как defer: последним поднятый HTTP-сервер перестает принимать
+// соединения и дожидается активных запросов первым, пул базы закрывается последним.
+type shutdownSequence struct {
+	steps []shutdownStep
+}
+
+type shutdownStep struct {
+	name string
+	stop func(ctx context.Context) error
+}
+
+// add регистрирует ресурс сразу после того, как он поднят
+func (s *shutdownSequence) add(name string, stop func(ctx context.Context) error) {
+	s.steps = append(s.steps, shutdownStep{name: name, stop: stop})
+}
+
+// run останавливает все ресурсы, даже если какой-то из них вернул ошибку: каждый шаг
+// получает свой timeout, ошибки логируются и возвращаются вместе (errors.Join)
+func (s *shutdownSequence) run(timeout time.Duration, logger *zap.Logger) error {
+	var errs []error
+	for i := len(s.steps) - 1; i >= 0; i-- {
+		step := s.steps[i]
+		start := time.Now()
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		err := step.stop(ctx)
+		cancel()
+
+		if err != nil {
+			logger.Error("failed to stop resource", zap.String("resource", step.name), zap.Error(err))
+			errs = append(errs, fmt.Errorf("stop %s: %w", step.name, err))
+			continue
+		}
+		logger.Info("resource stopped", zap.String("resource", step.name), zap.Duration("took", time.Since(start)))
+	}
+	return errors.Join(errs...)
+}
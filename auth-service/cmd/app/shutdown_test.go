@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestShutdownSequence(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+
+	var order []string
+	step := func(name string, err error) func(ctx context.Context) error {
+		return func(ctx context.Context) error {
+			_, hasDeadline := ctx.Deadline()
+			assert.True(t, hasDeadline, name)
+			order = append(order, name)
+			return err
+		}
+	}
+
+	var s shutdownSequence
+	s.add("database", step("database", errors.New("pool busy")))
+	s.add("workers", step("workers", nil))
+	s.add("http server", step("http server", errors.New("forced")))
+
+	err := s.run(time.Second, zap.New(core))
+
+	// Обратный порядок регистрации, и ошибка одного шага не отменяет остальные
+	assert.Equal(t, []string{"http server", "workers", "database"}, order)
+	assert.EqualError(t, err, "stop http server: forced\nstop database: pool busy")
+	assert.Equal(t, 2, logs.FilterMessage("failed to stop resource").Len())
+	assert.Equal(t, 1, logs.FilterMessage("resource stopped").Len())
+
+	var empty shutdownSequence
+	assert.NoError(t, empty.run(time.Second, zap.NewNop()))
+}
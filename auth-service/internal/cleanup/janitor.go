@@ -0,0 +1,94 @@
+package cleanup
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Janitor периодически удаляет устаревшие строки. Безопасно запускать в каждой реплике:
+// старт и интервалы размазаны случайной задержкой, а саму чистку в один момент
+// выполняет только реплика, взявшая advisory lock.
+type Janitor struct {
+	store     Store
+	interval  time.Duration
+	retention time.Duration
+	logger    *zap.Logger
+	// jitter и now подменяются в тестах
+	jitter func(max time.Duration) time.Duration
+	now    func() time.Time
+}
+
+const (
+	defaultInterval  = time.Hour
+	defaultRetention = 7 * 24 * time.Hour
+)
+
+// NewJanitor создает janitor; нулевые interval и retention заменяются дефолтами
+func NewJanitor(store Store, interval, retention time.Duration, logger *zap.Logger) *Janitor {
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+	if retention <= 0 {
+		retention = defaultRetention
+	}
+	return &Janitor{
+		store:     store,
+		interval:  interval,
+		retention: retention,
+		logger:    logger,
+		jitter:    randomJitter,
+		now:       time.Now,
+	}
+}
+
+// Run чистит таблицы, пока не отменят ctx. Первый запуск - через случайную долю интервала,
+// следующие - через interval плюс до 10% сверху.
+func (j *Janitor) Run(ctx context.Context) {
+	timer := time.NewTimer(j.jitter(j.interval))
+	defer timer.Stop()
+
+	j.logger.Info("cleanup janitor started", zap.Duration("interval", j.interval))
+
+	for {
+		select {
+		case <-ctx.Done():
+			j.logger.Info("cleanup janitor stopped")
+			return
+		case <-timer.C:
+			j.purge(ctx)
+			timer.Reset(j.interval + j.jitter(j.interval/10))
+		}
+	}
+}
+
+func (j *Janitor) purge(ctx context.Context) {
+	purged, ran, err := j.store.Purge(ctx, j.now().Add(-j.retention))
+	if err != nil {
+		if ctx.Err() == nil {
+			j.logger.Error("cleanup failed", zap.Error(err))
+		}
+		return
+	}
+	if !ran {
+		j.logger.Debug("cleanup skipped, another replica holds the lock")
+		return
+	}
+
+	var total int64
+	fields := make([]zap.Field, 0, len(purged)+1)
+	for table, n := range purged {
+		total += n
+		fields = append(fields, zap.Int64(table, n))
+	}
+	j.logger.Info("expired rows purged", append(fields, zap.Int64("total", total))...)
+}
+
+func randomJitter(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	return rand.N(max)
+}
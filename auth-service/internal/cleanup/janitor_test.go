@@ -0,0 +1,108 @@
+package cleanup
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type fakeStore struct {
+	mu      sync.Mutex
+	cutoffs []time.Time
+	purged  map[string]int64
+	ran     bool
+	err     error
+}
+
+func (s *fakeStore) Purge(ctx context.Context, cutoff time.Time) (map[string]int64, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cutoffs = append(s.cutoffs, cutoff)
+	return s.purged, s.ran, s.err
+}
+
+func (s *fakeStore) calls() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.cutoffs)
+}
+
+func newTestJanitor(store Store) (*Janitor, *observer.ObservedLogs) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	j := NewJanitor(store, time.Minute, time.Hour, zap.New(core))
+	j.now = func() time.Time { return time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC) }
+	return j, logs
+}
+
+func TestJanitor_Purge(t *testing.T) {
+	t.Run("Logs purged rows", func(t *testing.T) {
+		store := &fakeStore{purged: map[string]int64{"outbox": 3, "webhook_outbox": 2}, ran: true}
+		j, logs := newTestJanitor(store)
+
+		j.purge(context.Background())
+
+		assert.Equal(t, []time.Time{time.Date(2026, 1, 1, 11, 0, 0, 0, time.UTC)}, store.cutoffs, "now - retention")
+		entries := logs.FilterMessage("expired rows purged").All()
+		if assert.Len(t, entries, 1) {
+			assert.Equal(t, int64(5), entries[0].ContextMap()["total"])
+			assert.Equal(t, int64(3), entries[0].ContextMap()["outbox"])
+		}
+	})
+
+	t.Run("Another replica holds the lock", func(t *testing.T) {
+		j, logs := newTestJanitor(&fakeStore{ran: false})
+
+		j.purge(context.Background())
+
+		assert.Equal(t, 1, logs.FilterMessage("cleanup skipped, another replica holds the lock").Len())
+		assert.Zero(t, logs.FilterMessage("expired rows purged").Len())
+	})
+
+	t.Run("Store error is logged", func(t *testing.T) {
+		j, logs := newTestJanitor(&fakeStore{err: errors.New("connection refused")})
+
+		j.purge(context.Background())
+
+		assert.Equal(t, 1, logs.FilterMessage("cleanup failed").Len())
+	})
+}
+
+func TestJanitor_RunStopsOnCancel(t *testing.T) {
+	store := &fakeStore{ran: true}
+	j, _ := newTestJanitor(store)
+	j.jitter = func(time.Duration) time.Duration { return 0 }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		j.Run(ctx)
+		close(done)
+	}()
+
+	// Первый запуск без задержки, дальше - ждем interval; отмена должна прервать ожидание
+	assert.Eventually(t, func() bool { return store.calls() == 1 }, time.Second, 5*time.Millisecond)
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("janitor did not stop after cancel")
+	}
+}
+
+func TestNewJanitor_Defaults(t *testing.T) {
+	j := NewJanitor(&fakeStore{}, 0, 0, zap.NewNop())
+
+	assert.Equal(t, defaultInterval, j.interval)
+	assert.Equal(t, defaultRetention, j.retention)
+	for range 100 {
+		assert.Less(t, j.jitter(time.Second), time.Second)
+	}
+}
@@ -0,0 +1,73 @@
+package cleanup
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Target - таблица, в которой копятся устаревшие строки. Expired - условие WHERE,
+// $1 в нем - граница по времени (now - retention).
+// Таблицы с собственным сроком жизни (токены, сессии) могут сравнивать с now() и игнорировать $1.
+type Target struct {
+	Table   string
+	Expired string
+}
+
+// DefaultTargets - что чистится сейчас: отправленные события outbox,
+// доставленные/мертвые webhook-доставки и истекшие токены сброса пароля
+var DefaultTargets = []Target{
+	{Table: "outbox", Expired: "sent_at < $1"},
+	{Table: "webhook_outbox", Expired: "status <> 'pending' AND created_at < $1"},
+	{Table: "password_reset_tokens", Expired: "expires_at < $1"},
+}
+
+// Store удаляет устаревшие строки. ran = false - чистку в этот раз выполняет другая реплика.
+type Store interface {
+	Purge(ctx context.Context, cutoff time.Time) (purged map[string]int64, ran bool, err error)
+}
+
+type postgresStore struct {
+	pool    *pgxpool.Pool
+	targets []Target
+}
+
+func NewPostgresStore(pool *pgxpool.Pool, targets []Target) Store {
+	return &postgresStore{pool: pool, targets: targets}
+}
+
+// Purge чистит все таблицы в одной транзакции под advisory lock: если его держит
+// другая реплика, ничего не делает. Lock отпускается сам на commit/rollback.
+func (s *postgresStore) Purge(ctx context.Context, cutoff time.Time) (map[string]int64, bool, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	var locked bool
+	if err := tx.QueryRow(ctx, `SELECT pg_try_advisory_xact_lock(hashtext('auth-service:cleanup'))`).Scan(&locked); err != nil {
+		return nil, false, fmt.Errorf("advisory lock: %w", err)
+	}
+	if !locked {
+		return nil, false, nil
+	}
+
+	purged := make(map[string]int64, len(s.targets))
+	for _, t := range s.targets {
+		tag, err := tx.Exec(ctx, fmt.Sprintf(`DELETE FROM %s WHERE %s`, t.Table, t.Expired), cutoff)
+		if err != nil {
+			return nil, true, fmt.Errorf("purge %s: %w", t.Table, err)
+		}
+		purged[t.Table] = tag.RowsAffected()
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, true, fmt.Errorf("commit: %w", err)
+	}
+	return purged, true, nil
+}
@@ -2,36 +2,107 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
 
 type Config struct {
 	App        AppConfig       `mapstructure:"app"`
+	Server     ServerConfig    `mapstructure:"server"`
 	Database   DatabaseConfig  `mapstructure:"database"`
 	Migrations MigrationConfig `mapstructure:"migrations"`
 	JWT        JWTConfig       `mapstructure:"jwt"`
 	Logging    LoggingConfig   `mapstructure:"logging"`
+	Security   SecurityConfig  `mapstructure:"security"`
+	Auth       AuthConfig      `mapstructure:"auth"`
 	Frontend   FrontendHost    `mapstructure:"frontend"`
-	Test       TestConfig      `mapstructure:"test"`
+	CORS       CORSConfig      `mapstructure:"cors"`
+	Webhooks   WebhooksConfig  `mapstructure:"webhooks"`
+	Outbox     OutboxConfig    `mapstructure:"outbox"`
+	Cleanup    CleanupConfig   `mapstructure:"cleanup"`
+	GRPC       GRPCConfig      `mapstructure:"grpc"`
+	Storage    StorageConfig   `mapstructure:"storage"`
+	Notify     NotifyConfig    `mapstructure:"notify"`
+	Limits     LimitsConfig    `mapstructure:"limits"`
+	// Pagination - лимиты страницы для списков (GET /users); то же, что pagination в post-service
+	Pagination PaginationConfig `mapstructure:"pagination"`
+	// Features - флаги экспериментальных эндпоинтов; выключенная фича не регистрирует маршруты (404)
+	Features map[string]bool `mapstructure:"features"`
+	Test     TestConfig      `mapstructure:"test"`
 }
 
 type AppConfig struct {
 	Port string `mapstructure:"port"`
 	Mode string `mapstructure:"mode"`
+	// BasePath - префикс для всех групп маршрутов (например "/api/v1"); /health остается в корне
+	BasePath string `mapstructure:"base_path"`
+}
+
+// ServerConfig - таймауты http.Server, без них сервис уязвим к slowloris и зависшим соединениям
+type ServerConfig struct {
+	ReadTimeout       time.Duration `mapstructure:"read_timeout"`
+	ReadHeaderTimeout time.Duration `mapstructure:"read_header_timeout"`
+	WriteTimeout      time.Duration `mapstructure:"write_timeout"`
+	IdleTimeout       time.Duration `mapstructure:"idle_timeout"`
+	// TrustedProxies - IP/CIDR балансировщиков, которым верим в X-Forwarded-For.
+	// Пустой список - не доверяем никому, ClientIP() берется из RemoteAddr.
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
+	// TrustedPlatform - платформа, чей заголовок с IP клиента читает ClientIP() (см. TrustedPlatforms).
+	// Пусто - только trusted_proxies; заголовок платформы имеет приоритет над X-Forwarded-For.
+	TrustedPlatform string `mapstructure:"trusted_platform"`
+	// ShutdownTimeout - сколько ждать завершения активных запросов при остановке
+	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout"`
+	// MaxBodyBytes - предел тела запроса; MaxMultipartBytes - отдельный, больший предел
+	// для multipart (загрузка аватаров). 0 - без ограничения.
+	MaxBodyBytes      int64 `mapstructure:"max_body_bytes"`
+	MaxMultipartBytes int64 `mapstructure:"max_multipart_bytes"`
+	// RequestTimeout - дедлайн обработки одного запроса (504 по истечении); 0 - без ограничения
+	RequestTimeout time.Duration `mapstructure:"request_timeout"`
+	TLS            TLSConfig     `mapstructure:"tls"`
+}
+
+// TLSConfig - HTTPS прямо в сервисе (cert_file + key_file) или за балансировщиком, который его завершает
+type TLSConfig struct {
+	CertFile string `mapstructure:"cert_file"`
+	KeyFile  string `mapstructure:"key_file"`
+	// TerminatedByProxy - HTTPS завершается до сервиса; сам сервис слушает HTTP
+	TerminatedByProxy bool `mapstructure:"terminated_by_proxy"`
+}
+
+// Enabled - сервис сам слушает HTTPS
+func (t TLSConfig) Enabled() bool {
+	return t.CertFile != "" && t.KeyFile != ""
 }
 
 type DatabaseConfig struct {
-	Host            string `mapstructure:"host"`
-	Port            int    `mapstructure:"port"`
-	User            string `mapstructure:"user"`
-	Password        string `mapstructure:"password"`
-	Name            string `mapstructure:"name"`
-	SSLMode         string `mapstructure:"sslmode"`
-	MaxConns        int32  `mapstructure:"max_conns"`
-	MinConns        int32  `mapstructure:"min_conns"`
+	Host               string `mapstructure:"host"`
+	ReplicaHost        string `mapstructure:"replica_host"`
+	Port               int    `mapstructure:"port"`
+	User               string `mapstructure:"user"`
+	Password           string `mapstructure:"password"`
+	Name               string `mapstructure:"name"`
+	SSLMode            string `mapstructure:"sslmode"`
+	MaxConns           int32  `mapstructure:"max_conns"`
+	MinConns           int32  `mapstructure:"min_conns"`
+	StatementTimeoutMs int    `mapstructure:"statement_timeout_ms"`
+	// TraceQueries - каждый SQL-запрос в лог на уровне debug; в app.mode=debug включено всегда
+	TraceQueries bool `mapstructure:"trace_queries"`
+	// SlowQueryThreshold - запросы дольше порога пишутся в лог как warn; 0 - выключено
+	SlowQueryThreshold time.Duration `mapstructure:"slow_query_threshold"`
+	// MaxConcurrentQueries - сколько запросов приложение одновременно пускает в базу (поверх max_conns);
+	// остальные ждут busy_timeout и получают 503 "database busy". 0 - без ограничения
+	MaxConcurrentQueries int           `mapstructure:"max_concurrent_queries"`
+	BusyTimeout          time.Duration `mapstructure:"busy_timeout"`
 }
 
 type MigrationConfig struct {
@@ -40,46 +111,311 @@ type MigrationConfig struct {
 }
 
 type JWTConfig struct {
-	Secret          string `mapstructure:"secret"`
-	ExpirationHours int    `mapstructure:"expiration_hours"`
+	Secret string `mapstructure:"secret"`
+	// Expiration - срок жизни токена ("15m", "1h30m"); если задан, перекрывает ExpirationHours
+	Expiration time.Duration `mapstructure:"expiration"`
+	// ExpirationHours - прежний способ задать срок целыми часами, остается для старых конфигов
+	ExpirationHours int `mapstructure:"expiration_hours"`
+	// Audience - для кого выпускается токен (aud); токены для другой аудитории отклоняются.
+	// Пусто - aud не пишется и не проверяется.
+	Audience string `mapstructure:"audience"`
+	// PreviousSecrets - выведенные из оборота ключи: ими больше не подписываем, но токены,
+	// выпущенные до ротации, еще принимаем. Убрать ключ можно через срок жизни токена после ротации.
+	PreviousSecrets []string `mapstructure:"previous_secrets"`
+	// NotBeforeSkew - nbf выпускаемых токенов ставится на столько раньше времени выпуска,
+	// чтобы реплика с отстающими часами не отклонила свежий токен. 0 - nbf не пишется
+	NotBeforeSkew time.Duration `mapstructure:"not_before_skew"`
+	// Leeway - допуск на расхождение часов при проверке exp и nbf; 0 - без допуска
+	Leeway time.Duration `mapstructure:"leeway"`
+}
+
+// TokenTTL - итоговый срок жизни токена: expiration, а без него expiration_hours
+func (c JWTConfig) TokenTTL() time.Duration {
+	if c.Expiration != 0 {
+		return c.Expiration
+	}
+	return time.Duration(c.ExpirationHours) * time.Hour
+}
+
+type SecurityConfig struct {
+	// HashAlgorithm - bcrypt или argon2id; влияет только на новые хеши
+	HashAlgorithm string `mapstructure:"hash_algorithm"`
+	// BcryptCost - cost bcrypt для новых хешей; 0 - bcrypt.DefaultCost
+	BcryptCost int `mapstructure:"bcrypt_cost"`
+	// RehashOnLogin - при входе перехешировать пароль, если хеш слабее текущих алгоритма и параметров
+	RehashOnLogin bool `mapstructure:"rehash_on_login"`
+	// ServiceSecret - общий секрет для внутренних эндпоинтов (/auth/token/introspect)
+	ServiceSecret string `mapstructure:"service_secret"`
+	// SignupAutoLogin - по умолчанию сразу логинить после /auth/signup (перекрывается ?autologin=)
+	SignupAutoLogin bool `mapstructure:"signup_autologin"`
+	// PasswordMaxBytes - предел длины пароля в байтах; для bcrypt не больше 72
+	PasswordMaxBytes int `mapstructure:"password_max_bytes"`
+	// CookieCheck - что делать с CookieProblems на старте: warn - лог, strict - ошибка Validate
+	CookieCheck string `mapstructure:"cookie_check"`
+	// EmailMode - strict или lenient: lenient дополнительно принимает "quoted local"@example.com и user@[192.168.0.1]
+	EmailMode string `mapstructure:"email_mode"`
+	// UsernamePattern - регулярка допустимого username (model.DefaultUsernamePattern); проверяется целиком,
+	// поэтому должна начинаться с ^ и заканчиваться $. Уже существующие username не перепроверяются
+	UsernamePattern string `mapstructure:"username_pattern"`
+	// BlockDisposableEmail - отклонять регистрацию и смену email на адреса одноразовой почты
+	BlockDisposableEmail bool `mapstructure:"block_disposable_email"`
+	// DisposableEmailFile - блоклист доменов, по одному в строке (model.LoadDisposableDomains)
+	DisposableEmailFile string `mapstructure:"disposable_email_file"`
+	// DisposableEmailDomains - домены сверх файла; поддомены блокируются вместе с доменом
+	DisposableEmailDomains []string `mapstructure:"disposable_email_domains"`
+}
+
+// TrustedPlatforms - допустимые значения server.trusted_platform и заголовок с IP клиента
+// у каждой (те же, что gin.Platform*)
+var TrustedPlatforms = map[string]string{
+	"cloudflare":        "CF-Connecting-IP",
+	"google_app_engine": "X-Appengine-Remote-Addr",
+	"fly_io":            "Fly-Client-IP",
+}
+
+// HashAlgorithms - допустимые значения security.hash_algorithm (см. hasher.New)
+var HashAlgorithms = []string{"bcrypt", "argon2id"}
+
+// EmailModes - допустимые значения security.email_mode (см. model.NewValidator)
+var EmailModes = []string{"strict", "lenient"}
+
+// CookieCheckModes - допустимые значения security.cookie_check
+var CookieCheckModes = []string{"warn", "strict"}
+
+// AuthConfig - как хендлеры аутентифицируют запросы
+type AuthConfig struct {
+	// TokenSource - откуда брать токен: cookie, header, both-header-first или both-cookie-first
+	TokenSource string `mapstructure:"token_source"`
+	// SingleSession - у пользователя одна активная сессия: вход отзывает все выданные ранее токены
+	SingleSession bool `mapstructure:"single_session"`
+	// PasswordResetTTL - сколько живет одноразовый токен сброса пароля
+	PasswordResetTTL time.Duration `mapstructure:"password_reset_ttl"`
+	// CookieSameSite - атрибут SameSite cookie с токеном: lax, strict или none
+	CookieSameSite string `mapstructure:"cookie_same_site"`
+}
+
+// CookieSameSites - допустимые значения auth.cookie_same_site
+var CookieSameSites = []string{"lax", "strict", "none"}
+
+// Откуда AuthMiddleware берет токен (auth.token_source)
+const (
+	TokenSourceCookie          = "cookie"
+	TokenSourceHeader          = "header"
+	TokenSourceBothHeaderFirst = "both-header-first"
+	TokenSourceBothCookieFirst = "both-cookie-first"
+)
+
+// TokenSources - допустимые значения auth.token_source
+var TokenSources = []string{TokenSourceCookie, TokenSourceHeader, TokenSourceBothHeaderFirst, TokenSourceBothCookieFirst}
+
+// WebhooksConfig - доставка событий о пользователях внешним подписчикам.
+// Пустой urls - webhooks выключены, события из outbox просто помечаются отправленными.
+type WebhooksConfig struct {
+	URLs           []string      `mapstructure:"urls"`
+	Secret         string        `mapstructure:"secret"`
+	MaxAttempts    int           `mapstructure:"max_attempts"`
+	InitialBackoff time.Duration `mapstructure:"initial_backoff"`
+	MaxBackoff     time.Duration `mapstructure:"max_backoff"`
+	PollInterval   time.Duration `mapstructure:"poll_interval"`
+	BatchSize      int           `mapstructure:"batch_size"`
+	Timeout        time.Duration `mapstructure:"timeout"`
+}
+
+// OutboxConfig - relay, который публикует события, записанные в транзакции с изменением пользователя
+type OutboxConfig struct {
+	PollInterval time.Duration `mapstructure:"poll_interval"`
+	BatchSize    int           `mapstructure:"batch_size"`
+}
+
+// CleanupConfig - фоновая чистка устаревших строк (отправленный outbox, завершенные webhook-доставки)
+type CleanupConfig struct {
+	Interval time.Duration `mapstructure:"interval"`
+	// Retention - сколько хранить строки после того, как они перестали быть нужны
+	Retention time.Duration `mapstructure:"retention"`
+}
+
+// GRPCConfig - gRPC-сервер; пока на нем только grpc.health.v1.Health
+type GRPCConfig struct {
+	// Port - пустой выключает gRPC-сервер
+	Port string `mapstructure:"port"`
+	// HealthInterval - как часто проверять базу для статуса SERVING/NOT_SERVING
+	HealthInterval time.Duration `mapstructure:"health_interval"`
+	// KeepaliveMinTime - как часто клиентам можно пинговать соединение, в том числе без вызовов.
+	// Чаще - сервер рвет соединение с too_many_pings; у клиентов keepalive_time должен быть не меньше
+	KeepaliveMinTime time.Duration `mapstructure:"keepalive_min_time"`
+}
+
+// ClientKeepaliveTimeFloor - меньше этого grpc-go клиенты не пингуют (keepalive_time поднимается до 10s),
+// поэтому grpc.keepalive_min_time не больше него подходит любому клиенту
+const ClientKeepaliveTimeFloor = 10 * time.Second
+
+// StorageConfig - где лежат аватары: local (диск, раздает сам сервис) или s3
+type StorageConfig struct {
+	Backend string `mapstructure:"backend"`
+	// URLTTL - срок жизни подписанной ссылки на аватар; для local не используется
+	URLTTL time.Duration      `mapstructure:"url_ttl"`
+	Local  LocalStorageConfig `mapstructure:"local"`
+	S3     S3StorageConfig    `mapstructure:"s3"`
+}
+
+type LocalStorageConfig struct {
+	Dir string `mapstructure:"dir"`
+	// URLPrefix - путь (внутри app.base_path), по которому раздается Dir
+	URLPrefix string `mapstructure:"url_prefix"`
+}
+
+type S3StorageConfig struct {
+	Endpoint     string `mapstructure:"endpoint"`
+	Region       string `mapstructure:"region"`
+	Bucket       string `mapstructure:"bucket"`
+	AccessKey    string `mapstructure:"access_key"`
+	SecretKey    string `mapstructure:"secret_key"`
+	UsePathStyle bool   `mapstructure:"use_path_style"`
+}
+
+// StorageBackends - допустимые значения storage.backend
+var StorageBackends = []string{"local", "s3"}
+
+// NotifyConfig - письма пользователю о смене email и пароля.
+// Пустой smtp.host - письма не отправляются (локальный запуск, тесты)
+type NotifyConfig struct {
+	SMTP SMTPConfig `mapstructure:"smtp"`
+}
+
+type SMTPConfig struct {
+	Host     string `mapstructure:"host"`
+	Port     int    `mapstructure:"port"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	// From - адрес отправителя, например no-reply@example.com
+	From string `mapstructure:"from"`
+}
+
+type LimitsConfig struct {
+	// AvailabilityPerMinute - сколько проверок /auth/available можно сделать с одного IP в минуту; 0 - без лимита
+	AvailabilityPerMinute int `mapstructure:"availability_per_minute"`
+	// ForceResetPerHour - сколько сбросов пароля (POST /users/:id/force-password-reset) один администратор
+	// может выдать в час; 0 - без лимита
+	ForceResetPerHour int `mapstructure:"force_reset_per_hour"`
+}
+
+// Имена флагов в features
+const (
+	// FeaturePasswordCheck - POST /auth/password/check, индикатор надежности пароля
+	FeaturePasswordCheck = "password_check"
+)
+
+// FeatureDefaults - известные флаги и их значения, если в features ключа нет.
+// Новые экспериментальные фичи добавляются сюда выключенными: код выкатывается, но не виден.
+var FeatureDefaults = map[string]bool{
+	FeaturePasswordCheck: true,
+}
+
+// FeatureEnabled - значение из features, а если его нет - из FeatureDefaults
+func (c *Config) FeatureEnabled(name string) bool {
+	if enabled, ok := c.Features[name]; ok {
+		return enabled
+	}
+	return FeatureDefaults[name]
+}
+
+type PaginationConfig struct {
+	DefaultLimit int `mapstructure:"default_limit"`
+	MaxLimit     int `mapstructure:"max_limit"`
 }
 
 type LoggingConfig struct {
-	Level string `mapstructure:"level"`
+	Level    string         `mapstructure:"level"`
+	Sampling SamplingConfig `mapstructure:"sampling"`
+	File     LogFileConfig  `mapstructure:"file"`
+}
+
+// LogFileConfig - запись логов в файл с ротацией; пустой path - только stdout
+type LogFileConfig struct {
+	Path       string `mapstructure:"path"`
+	MaxSizeMB  int    `mapstructure:"max_size_mb"`
+	MaxBackups int    `mapstructure:"max_backups"`
+	MaxAgeDays int    `mapstructure:"max_age_days"`
+}
+
+// SamplingConfig - семплирование логов в release, в debug не применяется
+type SamplingConfig struct {
+	Initial    int `mapstructure:"initial"`
+	Thereafter int `mapstructure:"thereafter"`
 }
 
 type FrontendHost struct {
 	Host string `mapstructure:"host"`
 }
 
+// CORSConfig - MaxAge уходит в Access-Control-Max-Age ответа на preflight; 0 - браузер не кэширует preflight.
+// Chromium обрезает значение до 2h, Firefox - до 24h
+type CORSConfig struct {
+	MaxAge time.Duration `mapstructure:"max_age"`
+}
+
 type TestConfig struct {
 	DBHost                string `mapstructure:"db_host"`
 	MigrationsPath        string `mapstructure:"migrations_path"`
 	HandlerMigrationsPath string `mapstructure:"handler_migrations_path"`
 }
 
+// ResolvePath - файл окружения рядом с базовым: config/config.yml при APP_ENV=production
+// превращается в config/config.production.yml, если такой есть. Иначе (APP_ENV не задан
+// или файла нет) - сам base. Переменные окружения перекрывают любой из них, как и раньше.
+func ResolvePath(base string) string {
+	env := os.Getenv("APP_ENV")
+	// APP_ENV - только имя окружения, не путь
+	if env == "" || strings.ContainsAny(env, `/\`) {
+		return base
+	}
+
+	ext := filepath.Ext(base)
+	candidate := strings.TrimSuffix(base, ext) + "." + env + ext
+	if _, err := os.Stat(candidate); err != nil {
+		return base
+	}
+	return candidate
+}
+
+// Load читает конфиг из файла и перекрывает значения переменными окружения.
+// Формат определяется по расширению файла (yml, yaml, json, toml, ...).
+// Отсутствие файла не является ошибкой: в этом случае конфиг собирается
+// только из дефолтов и env, а обязательные поля проверяет Validate.
 func Load(path string) (*Config, error) {
 	v := viper.New()
 
-	v.SetConfigFile(path)
-	v.SetConfigType("yaml")
+	setDefaults(v)
 
 	v.AutomaticEnv()
 
 	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 
 	_ = v.BindEnv("app.port", "AUTH_SERVICE_APP_PORT")
+	_ = v.BindEnv("grpc.port", "AUTH_GRPC_PORT")
 	_ = v.BindEnv("database.host", "DB_HOST")
+	_ = v.BindEnv("database.replica_host", "DB_REPLICA_HOST")
 	_ = v.BindEnv("database.port", "DB_PORT")
 	_ = v.BindEnv("database.user", "DB_USER")
 	_ = v.BindEnv("database.password", "DB_PASSWORD")
 	_ = v.BindEnv("database.name", "DB_NAME")
 	_ = v.BindEnv("database.sslmode", "DB_SSLMODE")
 	_ = v.BindEnv("jwt.secret", "JWT_SECRET")
+	_ = v.BindEnv("jwt.audience", "JWT_AUDIENCE")
+	_ = v.BindEnv("jwt.previous_secrets", "JWT_PREVIOUS_SECRETS")
+	_ = v.BindEnv("security.service_secret", "SERVICE_SECRET")
+	_ = v.BindEnv("webhooks.secret", "WEBHOOK_SECRET")
+	_ = v.BindEnv("storage.s3.access_key", "S3_ACCESS_KEY")
+	_ = v.BindEnv("storage.s3.secret_key", "S3_SECRET_KEY")
+	_ = v.BindEnv("notify.smtp.password", "SMTP_PASSWORD")
 	_ = v.BindEnv("frontend.host", "FRONTEND_HOST")
 
-	if err := v.ReadInConfig(); err != nil {
-		return nil, fmt.Errorf("failed to read config file: %w", err)
+	if path != "" {
+		v.SetConfigFile(path)
+
+		if err := v.ReadInConfig(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
 	}
 
 	var cfg Config
@@ -91,14 +427,268 @@ func Load(path string) (*Config, error) {
 	return &cfg, nil
 }
 
+// setDefaults задает значения по умолчанию, чтобы сервис мог стартовать без config.yml.
+// Заодно это регистрирует ключи в viper, и AutomaticEnv начинает их видеть.
+func setDefaults(v *viper.Viper) {
+	v.SetDefault("app.port", "8040")
+	v.SetDefault("app.mode", "release")
+	v.SetDefault("app.base_path", "")
+
+	v.SetDefault("server.read_timeout", "15s")
+	v.SetDefault("server.read_header_timeout", "5s")
+	v.SetDefault("server.write_timeout", "30s")
+	v.SetDefault("server.idle_timeout", "60s")
+	v.SetDefault("server.shutdown_timeout", "5s")
+	v.SetDefault("server.request_timeout", "10s")
+	v.SetDefault("server.max_body_bytes", 1<<20)
+	v.SetDefault("server.max_multipart_bytes", 10<<20)
+
+	v.SetDefault("database.port", 5432)
+	v.SetDefault("database.user", "postgres")
+	v.SetDefault("database.name", "auth_db")
+	v.SetDefault("database.sslmode", "disable")
+	v.SetDefault("database.max_conns", 5)
+	v.SetDefault("database.min_conns", 1)
+	v.SetDefault("database.statement_timeout_ms", 30000)
+	v.SetDefault("database.trace_queries", false)
+	v.SetDefault("database.slow_query_threshold", "0s")
+	v.SetDefault("database.max_concurrent_queries", 0)
+	v.SetDefault("database.busy_timeout", "100ms")
+
+	v.SetDefault("migrations.path", "./migrations")
+	v.SetDefault("migrations.auto", true)
+
+	v.SetDefault("jwt.expiration_hours", 24)
+	v.SetDefault("jwt.not_before_skew", 0)
+	v.SetDefault("jwt.leeway", 0)
+
+	v.SetDefault("logging.level", "info")
+	v.SetDefault("logging.sampling.initial", 100)
+	v.SetDefault("logging.sampling.thereafter", 100)
+	v.SetDefault("logging.file.max_size_mb", 100)
+	v.SetDefault("logging.file.max_backups", 5)
+	v.SetDefault("logging.file.max_age_days", 30)
+
+	v.SetDefault("security.hash_algorithm", "argon2id")
+	v.SetDefault("security.bcrypt_cost", 0)
+	v.SetDefault("security.rehash_on_login", false)
+	v.SetDefault("security.password_max_bytes", 72)
+	v.SetDefault("security.email_mode", "strict")
+	v.SetDefault("security.username_pattern", `^[a-zA-Z0-9_]{2,50}$`)
+	v.SetDefault("security.block_disposable_email", false)
+
+	v.SetDefault("auth.token_source", TokenSourceBothCookieFirst)
+	v.SetDefault("auth.single_session", false)
+	v.SetDefault("auth.password_reset_ttl", time.Hour)
+	v.SetDefault("auth.cookie_same_site", "lax")
+	v.SetDefault("security.cookie_check", "warn")
+
+	v.SetDefault("webhooks.max_attempts", 8)
+	v.SetDefault("webhooks.initial_backoff", "10s")
+	v.SetDefault("webhooks.max_backoff", "1h")
+	v.SetDefault("webhooks.poll_interval", "5s")
+	v.SetDefault("webhooks.batch_size", 20)
+	v.SetDefault("webhooks.timeout", "10s")
+	v.SetDefault("outbox.poll_interval", "1s")
+	v.SetDefault("outbox.batch_size", 100)
+	v.SetDefault("cleanup.interval", "1h")
+	v.SetDefault("cleanup.retention", "168h")
+	v.SetDefault("grpc.port", "50051")
+	v.SetDefault("grpc.health_interval", "10s")
+	v.SetDefault("grpc.keepalive_min_time", "10s")
+	v.SetDefault("storage.backend", "local")
+	v.SetDefault("storage.url_ttl", "15m")
+	v.SetDefault("storage.local.dir", "./data/uploads")
+	v.SetDefault("storage.local.url_prefix", "/static")
+	v.SetDefault("storage.s3.region", "us-east-1")
+	v.SetDefault("notify.smtp.host", "")
+	v.SetDefault("notify.smtp.port", 587)
+
+	v.SetDefault("limits.availability_per_minute", 30)
+	v.SetDefault("limits.force_reset_per_hour", 10)
+	v.SetDefault("pagination.default_limit", 10)
+	v.SetDefault("pagination.max_limit", 100)
+	v.SetDefault("cors.max_age", "2h")
+}
+
+// Validate проверяет конфиг целиком и возвращает все нарушения сразу (errors.Join),
+// чтобы -check-config показал полный список, а не только первое
 func (c *Config) Validate() error {
+	var errs []error
+
 	if c.Database.Password == "" {
-		return fmt.Errorf("DB_PASSWORD is required")
+		errs = append(errs, fmt.Errorf("DB_PASSWORD is required"))
 	}
 	if c.Database.Host == "" {
-		return fmt.Errorf("DB_HOST is required")
+		errs = append(errs, fmt.Errorf("DB_HOST is required"))
+	}
+	if p := c.App.BasePath; p != "" && (!strings.HasPrefix(p, "/") || strings.HasSuffix(p, "/")) {
+		errs = append(errs, fmt.Errorf("app.base_path must start with \"/\" and have no trailing slash"))
+	}
+	if c.JWT.Expiration < 0 || c.JWT.ExpirationHours < 0 {
+		errs = append(errs, fmt.Errorf("jwt.expiration and jwt.expiration_hours must be positive"))
+	}
+	if c.JWT.NotBeforeSkew < 0 || c.JWT.Leeway < 0 {
+		errs = append(errs, fmt.Errorf("jwt.not_before_skew and jwt.leeway must not be negative"))
+	}
+	if c.Database.StatementTimeoutMs < 0 {
+		errs = append(errs, fmt.Errorf("database.statement_timeout_ms must not be negative"))
+	}
+	if c.Database.SlowQueryThreshold < 0 {
+		errs = append(errs, fmt.Errorf("database.slow_query_threshold must not be negative"))
+	}
+	if c.Database.MaxConcurrentQueries < 0 || c.Database.BusyTimeout < 0 {
+		errs = append(errs, fmt.Errorf("database.max_concurrent_queries and busy_timeout must not be negative"))
+	}
+	if len(c.Webhooks.URLs) > 0 {
+		if c.Webhooks.Secret == "" {
+			errs = append(errs, fmt.Errorf("WEBHOOK_SECRET is required when webhooks.urls is set"))
+		}
+		if c.Webhooks.MaxAttempts <= 0 || c.Webhooks.BatchSize <= 0 || c.Webhooks.PollInterval <= 0 {
+			errs = append(errs, fmt.Errorf("webhooks.max_attempts, batch_size and poll_interval must be positive"))
+		}
+	}
+	if c.Outbox.PollInterval < 0 || c.Outbox.BatchSize < 0 {
+		errs = append(errs, fmt.Errorf("outbox.poll_interval and batch_size must not be negative"))
+	}
+	if c.Security.HashAlgorithm != "" && !slices.Contains(HashAlgorithms, c.Security.HashAlgorithm) {
+		errs = append(errs, fmt.Errorf("security.hash_algorithm must be one of: %s", strings.Join(HashAlgorithms, ", ")))
+	}
+	if c.Security.EmailMode != "" && !slices.Contains(EmailModes, c.Security.EmailMode) {
+		errs = append(errs, fmt.Errorf("security.email_mode must be one of: %s", strings.Join(EmailModes, ", ")))
+	}
+	if _, err := regexp.Compile(c.Security.UsernamePattern); err != nil {
+		errs = append(errs, fmt.Errorf("security.username_pattern is not a valid regexp: %w", err))
+	}
+	if c.Security.BlockDisposableEmail && c.Security.DisposableEmailFile == "" && len(c.Security.DisposableEmailDomains) == 0 {
+		errs = append(errs, fmt.Errorf("security.block_disposable_email requires disposable_email_file or disposable_email_domains"))
+	}
+	// Пределы bcrypt.MinCost..bcrypt.MaxCost
+	if cost := c.Security.BcryptCost; cost != 0 && (cost < 4 || cost > 31) {
+		errs = append(errs, fmt.Errorf("security.bcrypt_cost must be between 4 and 31"))
+	}
+	if c.Security.PasswordMaxBytes < 0 {
+		errs = append(errs, fmt.Errorf("security.password_max_bytes must not be negative"))
+	}
+	// bcrypt отвергает пароли длиннее 72 байт - такой предел дал бы 500 на регистрации
+	if c.Security.HashAlgorithm == "bcrypt" && c.Security.PasswordMaxBytes > 72 {
+		errs = append(errs, fmt.Errorf("security.password_max_bytes must not exceed 72 with bcrypt"))
+	}
+	if c.Auth.TokenSource != "" && !slices.Contains(TokenSources, c.Auth.TokenSource) {
+		errs = append(errs, fmt.Errorf("auth.token_source must be one of: %s", strings.Join(TokenSources, ", ")))
+	}
+	if c.Auth.CookieSameSite != "" && !slices.Contains(CookieSameSites, c.Auth.CookieSameSite) {
+		errs = append(errs, fmt.Errorf("auth.cookie_same_site must be one of: %s", strings.Join(CookieSameSites, ", ")))
+	}
+	if c.Security.CookieCheck != "" && !slices.Contains(CookieCheckModes, c.Security.CookieCheck) {
+		errs = append(errs, fmt.Errorf("security.cookie_check must be one of: %s", strings.Join(CookieCheckModes, ", ")))
+	}
+	if (c.Server.TLS.CertFile == "") != (c.Server.TLS.KeyFile == "") {
+		errs = append(errs, fmt.Errorf("server.tls.cert_file and key_file must be set together"))
+	}
+	if c.Security.CookieCheck == "strict" {
+		errs = append(errs, c.CookieProblems()...)
+	}
+	if c.Cleanup.Interval < 0 || c.Cleanup.Retention < 0 {
+		errs = append(errs, fmt.Errorf("cleanup.interval and retention must not be negative"))
+	}
+	if c.GRPC.HealthInterval < 0 {
+		errs = append(errs, fmt.Errorf("grpc.health_interval must not be negative"))
+	}
+	if c.GRPC.KeepaliveMinTime < 0 {
+		errs = append(errs, fmt.Errorf("grpc.keepalive_min_time must not be negative"))
+	} else if c.GRPC.Port != "" && (c.GRPC.KeepaliveMinTime == 0 || c.GRPC.KeepaliveMinTime > ClientKeepaliveTimeFloor) {
+		// 0 grpc подменяет своими 5 минутами, и клиентов с keepalive_time 30s отключает за too_many_pings
+		errs = append(errs, fmt.Errorf("grpc.keepalive_min_time must be greater than 0 and at most %s", ClientKeepaliveTimeFloor))
+	}
+	if p := c.Server.TrustedPlatform; p != "" {
+		if _, ok := TrustedPlatforms[p]; !ok {
+			errs = append(errs, fmt.Errorf("server.trusted_platform must be one of: %s", strings.Join(slices.Sorted(maps.Keys(TrustedPlatforms)), ", ")))
+		}
+	}
+	if c.Storage.Backend != "" && !slices.Contains(StorageBackends, c.Storage.Backend) {
+		errs = append(errs, fmt.Errorf("storage.backend must be one of: %s", strings.Join(StorageBackends, ", ")))
+	}
+	if c.Storage.Backend == "s3" {
+		if c.Storage.S3.Endpoint == "" || c.Storage.S3.Bucket == "" {
+			errs = append(errs, fmt.Errorf("storage.s3.endpoint and bucket are required for the s3 backend"))
+		}
+		if c.Storage.S3.AccessKey == "" || c.Storage.S3.SecretKey == "" {
+			errs = append(errs, fmt.Errorf("S3_ACCESS_KEY and S3_SECRET_KEY are required for the s3 backend"))
+		}
+		// S3 не подписывает ссылки дольше 7 дней
+		if c.Storage.URLTTL <= 0 || c.Storage.URLTTL > 7*24*time.Hour {
+			errs = append(errs, fmt.Errorf("storage.url_ttl must be between 1s and 168h"))
+		}
+	}
+	if p := c.Storage.Local.URLPrefix; c.Storage.Backend == "local" && (!strings.HasPrefix(p, "/") || strings.HasSuffix(p, "/")) {
+		errs = append(errs, fmt.Errorf("storage.local.url_prefix must start with \"/\" and have no trailing slash"))
+	}
+	if c.Notify.SMTP.Host != "" {
+		if c.Notify.SMTP.From == "" {
+			errs = append(errs, fmt.Errorf("notify.smtp.from is required when notify.smtp.host is set"))
+		}
+		if c.Notify.SMTP.Port <= 0 || c.Notify.SMTP.Port > 65535 {
+			errs = append(errs, fmt.Errorf("notify.smtp.port must be between 1 and 65535"))
+		}
+	}
+	if c.Limits.AvailabilityPerMinute < 0 {
+		errs = append(errs, fmt.Errorf("limits.availability_per_minute must not be negative"))
+	}
+	if c.Limits.ForceResetPerHour < 0 {
+		errs = append(errs, fmt.Errorf("limits.force_reset_per_hour must not be negative"))
+	}
+	if c.Auth.PasswordResetTTL < 0 {
+		errs = append(errs, fmt.Errorf("auth.password_reset_ttl must not be negative"))
+	}
+	// Пустой блок - лимиты по умолчанию сервиса; заданный должен быть целиком корректным
+	if p := c.Pagination; p != (PaginationConfig{}) {
+		if p.DefaultLimit <= 0 || p.MaxLimit <= 0 {
+			errs = append(errs, fmt.Errorf("pagination limits must be positive"))
+		} else if p.DefaultLimit > p.MaxLimit {
+			errs = append(errs, fmt.Errorf("pagination.default_limit must not exceed pagination.max_limit"))
+		}
+	}
+	// Опечатка в имени флага иначе молча оставила бы фичу в значении по умолчанию
+	for name := range c.Features {
+		if _, ok := FeatureDefaults[name]; !ok {
+			errs = append(errs, fmt.Errorf("features.%s is not a known feature", name))
+		}
+	}
+	if c.Logging.Sampling.Initial < 0 || c.Logging.Sampling.Thereafter < 0 {
+		errs = append(errs, fmt.Errorf("logging.sampling thresholds must not be negative"))
+	}
+	if c.Logging.File.MaxSizeMB < 0 || c.Logging.File.MaxBackups < 0 || c.Logging.File.MaxAgeDays < 0 {
+		errs = append(errs, fmt.Errorf("logging.file limits must not be negative"))
+	}
+	if c.Server.ReadTimeout < 0 || c.Server.ReadHeaderTimeout < 0 ||
+		c.Server.WriteTimeout < 0 || c.Server.IdleTimeout < 0 || c.Server.ShutdownTimeout < 0 ||
+		c.Server.RequestTimeout < 0 {
+		errs = append(errs, fmt.Errorf("server timeouts must not be negative"))
+	}
+	if c.CORS.MaxAge < 0 {
+		errs = append(errs, fmt.Errorf("cors.max_age must not be negative"))
+	}
+	if c.Server.MaxBodyBytes < 0 || c.Server.MaxMultipartBytes < 0 {
+		errs = append(errs, fmt.Errorf("server body limits must not be negative"))
+	}
+	return errors.Join(errs...)
+}
+
+// CookieProblems - настройки, при которых браузер не примет или не вернет cookie с токеном:
+// "вход работает локально, но не в проде". Secure у cookie ставится только в release (setAuthCookie).
+// Validate возвращает их как ошибки при security.cookie_check=strict, иначе run пишет предупреждения
+func (c *Config) CookieProblems() []error {
+	var errs []error
+	secure := c.App.Mode == "release"
+
+	if secure && !c.Server.TLS.Enabled() && !c.Server.TLS.TerminatedByProxy {
+		errs = append(errs, fmt.Errorf("token cookie is Secure in release mode, but neither server.tls.cert_file/key_file nor server.tls.terminated_by_proxy is set"))
+	}
+	if c.Auth.CookieSameSite == "none" && !secure {
+		errs = append(errs, fmt.Errorf("auth.cookie_same_site=none requires a Secure cookie, which is set only in release mode"))
 	}
-	return nil
+	return errs
 }
 
 // --- Сors Config
@@ -125,6 +715,7 @@ func AllowHeaders() []string {
 		"Content-Length",
 		"Content-Type",
 		"Authorization",
+		"Accept-Timezone",
 	}
 
 	return allowHeaders
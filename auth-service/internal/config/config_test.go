@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -37,6 +38,9 @@ jwt:
 		assert.Equal(t, "8080", cfg.App.Port)
 		assert.Equal(t, "localhost", cfg.Database.Host)
 		assert.Equal(t, "supersecret", cfg.JWT.Secret)
+		assert.Equal(t, 0, cfg.Database.MaxConcurrentQueries)
+		assert.Equal(t, 100*time.Millisecond, cfg.Database.BusyTimeout)
+		assert.Equal(t, 10*time.Second, cfg.GRPC.KeepaliveMinTime)
 	})
 
 	t.Run("Override with Environment Variables", func(t *testing.T) {
@@ -52,13 +56,135 @@ jwt:
 		assert.Equal(t, expectedPort, cfg.App.Port)
 	})
 
-	t.Run("File not found error", func(t *testing.T) {
-		_, err := Load("non_existent.yml")
+	t.Run("Missing file falls back to env", func(t *testing.T) {
+		t.Setenv("DB_HOST", "env-host")
+		t.Setenv("DB_PASSWORD", "env-pass")
+
+		cfg, err := Load(filepath.Join(tmpDir, "non_existent.yml"))
+		require.NoError(t, err)
+		assert.Equal(t, "env-host", cfg.Database.Host)
+		assert.Equal(t, "env-pass", cfg.Database.Password)
+		// Дефолты подставляются, когда файла нет
+		assert.Equal(t, "8040", cfg.App.Port)
+		assert.Equal(t, 5432, cfg.Database.Port)
+		assert.Equal(t, 30000, cfg.Database.StatementTimeoutMs)
+		assert.Equal(t, 5*time.Second, cfg.Server.ReadHeaderTimeout)
+		assert.Equal(t, 30*time.Second, cfg.Server.WriteTimeout)
+		assert.Equal(t, 5*time.Second, cfg.Server.ShutdownTimeout)
+		assert.NoError(t, cfg.Validate())
+	})
+
+	t.Run("Missing file without env fails validation", func(t *testing.T) {
+		t.Setenv("DB_HOST", "")
+		t.Setenv("DB_PASSWORD", "")
+
+		cfg, err := Load(filepath.Join(tmpDir, "non_existent.yml"))
+		require.NoError(t, err)
+		assert.Error(t, cfg.Validate())
+	})
+
+	t.Run("Format detected from extension", func(t *testing.T) {
+		jsonPath := filepath.Join(tmpDir, "config.json")
+		err := os.WriteFile(jsonPath, []byte(`{"app":{"port":"7070"},"database":{"host":"json-host"}}`), 0644)
+		require.NoError(t, err)
+
+		cfg, err := Load(jsonPath)
+		require.NoError(t, err)
+		assert.Equal(t, "7070", cfg.App.Port)
+		assert.Equal(t, "json-host", cfg.Database.Host)
+	})
+
+	t.Run("Trusted proxies list", func(t *testing.T) {
+		proxiesPath := filepath.Join(tmpDir, "proxies.yml")
+		err := os.WriteFile(proxiesPath, []byte("server:\n  trusted_proxies: [\"10.0.0.0/8\", \"127.0.0.1\"]\n"), 0644)
+		require.NoError(t, err)
+
+		cfg, err := Load(proxiesPath)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"10.0.0.0/8", "127.0.0.1"}, cfg.Server.TrustedProxies)
+	})
+
+	t.Run("Previous JWT secrets from env", func(t *testing.T) {
+		t.Setenv("JWT_PREVIOUS_SECRETS", "old-1,old-2")
+
+		cfg, err := Load(filepath.Join(tmpDir, "non_existent.yml"))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"old-1", "old-2"}, cfg.JWT.PreviousSecrets)
+	})
+
+	t.Run("JWT expiration in minutes", func(t *testing.T) {
+		minutesPath := filepath.Join(tmpDir, "minutes.yml")
+		err := os.WriteFile(minutesPath, []byte("jwt:\n  expiration: 15m\n  expiration_hours: 24\n"), 0644)
+		require.NoError(t, err)
+
+		cfg, err := Load(minutesPath)
+		require.NoError(t, err)
+		assert.Equal(t, 15*time.Minute, cfg.JWT.TokenTTL())
+
+		// Старые конфиги только с expiration_hours работают как раньше
+		cfg, err = Load(configPath)
+		require.NoError(t, err)
+		assert.Equal(t, 24*time.Hour, cfg.JWT.TokenTTL())
+	})
+
+	t.Run("CORS max age default", func(t *testing.T) {
+		cfg, err := Load(configPath)
+		require.NoError(t, err)
+		assert.Equal(t, 2*time.Hour, cfg.CORS.MaxAge)
+	})
+
+	t.Run("Malformed file error", func(t *testing.T) {
+		badPath := filepath.Join(tmpDir, "bad.yml")
+		err := os.WriteFile(badPath, []byte("app: [unclosed"), 0644)
+		require.NoError(t, err)
+
+		_, err = Load(badPath)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "failed to read config file")
 	})
 }
 
+func TestResolvePath(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "config.yml")
+	production := filepath.Join(dir, "config.production.yml")
+	require.NoError(t, os.WriteFile(base, []byte("app:\n  port: \"8040\"\n"), 0644))
+	require.NoError(t, os.WriteFile(production, []byte("app:\n  port: \"9000\"\n"), 0644))
+
+	t.Run("No APP_ENV - base file", func(t *testing.T) {
+		t.Setenv("APP_ENV", "")
+		assert.Equal(t, base, ResolvePath(base))
+	})
+
+	t.Run("Environment file exists", func(t *testing.T) {
+		t.Setenv("APP_ENV", "production")
+		assert.Equal(t, production, ResolvePath(base))
+
+		cfg, err := Load(ResolvePath(base))
+		require.NoError(t, err)
+		assert.Equal(t, "9000", cfg.App.Port)
+	})
+
+	t.Run("Env vars still override the environment file", func(t *testing.T) {
+		t.Setenv("APP_ENV", "production")
+		t.Setenv("APP_PORT", "9100")
+
+		cfg, err := Load(ResolvePath(base))
+		require.NoError(t, err)
+		assert.Equal(t, "9100", cfg.App.Port)
+	})
+
+	t.Run("Missing environment file falls back to base", func(t *testing.T) {
+		t.Setenv("APP_ENV", "staging")
+		assert.Equal(t, base, ResolvePath(base))
+	})
+
+	t.Run("Path in APP_ENV is ignored", func(t *testing.T) {
+		t.Setenv("APP_ENV", "../production")
+		assert.Equal(t, base, ResolvePath(base))
+	})
+}
+
 func TestConfig_Validate(t *testing.T) {
 	t.Run("Validation success", func(t *testing.T) {
 		cfg := &Config{
@@ -93,4 +219,286 @@ func TestConfig_Validate(t *testing.T) {
 		assert.Error(t, err)
 		assert.Equal(t, "DB_HOST is required", err.Error())
 	})
+	t.Run("Negative statement timeout error", func(t *testing.T) {
+		cfg := &Config{
+			Database: DatabaseConfig{
+				Host:               "localhost",
+				Password:           "pass",
+				StatementTimeoutMs: -1,
+			},
+		}
+		assert.Error(t, cfg.Validate())
+	})
+
+	t.Run("Negative server timeout error", func(t *testing.T) {
+		cfg := &Config{
+			Server: ServerConfig{WriteTimeout: -time.Second},
+			Database: DatabaseConfig{
+				Host:     "localhost",
+				Password: "pass",
+			},
+		}
+		err := cfg.Validate()
+		assert.Error(t, err)
+		assert.Equal(t, "server timeouts must not be negative", err.Error())
+	})
+
+	t.Run("Negative JWT expiration error", func(t *testing.T) {
+		cfg := &Config{
+			JWT: JWTConfig{Expiration: -time.Minute},
+			Database: DatabaseConfig{
+				Host:     "localhost",
+				Password: "pass",
+			},
+		}
+		err := cfg.Validate()
+		assert.Error(t, err)
+		assert.Equal(t, "jwt.expiration and jwt.expiration_hours must be positive", err.Error())
+	})
+
+	t.Run("Negative JWT skew error", func(t *testing.T) {
+		cfg := &Config{
+			JWT:      JWTConfig{Leeway: -time.Second},
+			Database: DatabaseConfig{Host: "localhost", Password: "pass"},
+		}
+		assert.EqualError(t, cfg.Validate(), "jwt.not_before_skew and jwt.leeway must not be negative")
+
+		cfg.JWT = JWTConfig{NotBeforeSkew: 5 * time.Second, Leeway: 5 * time.Second}
+		assert.NoError(t, cfg.Validate())
+	})
+
+	t.Run("Negative gRPC keepalive error", func(t *testing.T) {
+		cfg := &Config{
+			GRPC:     GRPCConfig{KeepaliveMinTime: -time.Second},
+			Database: DatabaseConfig{Host: "localhost", Password: "pass"},
+		}
+		err := cfg.Validate()
+		assert.Error(t, err)
+		assert.Equal(t, "grpc.keepalive_min_time must not be negative", err.Error())
+	})
+
+	t.Run("gRPC keepalive must fit clients", func(t *testing.T) {
+		for _, minTime := range []time.Duration{0, 30 * time.Second} {
+			cfg := &Config{
+				GRPC:     GRPCConfig{Port: "50051", KeepaliveMinTime: minTime},
+				Database: DatabaseConfig{Host: "localhost", Password: "pass"},
+			}
+			err := cfg.Validate()
+			assert.Error(t, err)
+			assert.Equal(t, "grpc.keepalive_min_time must be greater than 0 and at most 10s", err.Error())
+		}
+
+		cfg := &Config{
+			GRPC:     GRPCConfig{Port: "50051", KeepaliveMinTime: 10 * time.Second},
+			Database: DatabaseConfig{Host: "localhost", Password: "pass"},
+		}
+		assert.NoError(t, cfg.Validate())
+	})
+
+	t.Run("Negative query limit error", func(t *testing.T) {
+		cfg := &Config{
+			Database: DatabaseConfig{
+				Host:                 "localhost",
+				Password:             "pass",
+				MaxConcurrentQueries: -1,
+			},
+		}
+		err := cfg.Validate()
+		assert.Error(t, err)
+		assert.Equal(t, "database.max_concurrent_queries and busy_timeout must not be negative", err.Error())
+	})
+
+	t.Run("Negative CORS max age error", func(t *testing.T) {
+		cfg := &Config{
+			CORS: CORSConfig{MaxAge: -time.Second},
+			Database: DatabaseConfig{
+				Host:     "localhost",
+				Password: "pass",
+			},
+		}
+		err := cfg.Validate()
+		assert.Error(t, err)
+		assert.Equal(t, "cors.max_age must not be negative", err.Error())
+	})
+
+	t.Run("All failures reported at once", func(t *testing.T) {
+		cfg := &Config{
+			Security: SecurityConfig{HashAlgorithm: "sha1"},
+			Server:   ServerConfig{MaxBodyBytes: -1},
+		}
+		err := cfg.Validate()
+		require.Error(t, err)
+		assert.Equal(t, "DB_PASSWORD is required\n"+
+			"DB_HOST is required\n"+
+			"security.hash_algorithm must be one of: bcrypt, argon2id\n"+
+			"server body limits must not be negative", err.Error())
+	})
+
+	t.Run("Password limit over 72 bytes with bcrypt error", func(t *testing.T) {
+		cfg := &Config{
+			Security: SecurityConfig{HashAlgorithm: "bcrypt", PasswordMaxBytes: 100},
+			Database: DatabaseConfig{Host: "localhost", Password: "pass"},
+		}
+		assert.Error(t, cfg.Validate())
+
+		cfg.Security.HashAlgorithm = "argon2id"
+		assert.NoError(t, cfg.Validate())
+	})
+
+	t.Run("Unknown token source error", func(t *testing.T) {
+		cfg := &Config{
+			Auth:     AuthConfig{TokenSource: "query"},
+			Database: DatabaseConfig{Host: "localhost", Password: "pass"},
+		}
+		assert.Error(t, cfg.Validate())
+	})
+
+	t.Run("Bcrypt cost out of range error", func(t *testing.T) {
+		cfg := &Config{
+			Security: SecurityConfig{BcryptCost: 3},
+			Database: DatabaseConfig{
+				Host:     "localhost",
+				Password: "pass",
+			},
+		}
+		err := cfg.Validate()
+		assert.Error(t, err)
+		assert.Equal(t, "security.bcrypt_cost must be between 4 and 31", err.Error())
+	})
+
+	t.Run("Unknown email mode error", func(t *testing.T) {
+		cfg := &Config{
+			Security: SecurityConfig{EmailMode: "rfc5322"},
+			Database: DatabaseConfig{Host: "localhost", Password: "pass"},
+		}
+		assert.EqualError(t, cfg.Validate(), "security.email_mode must be one of: strict, lenient")
+
+		cfg.Security.EmailMode = "lenient"
+		assert.NoError(t, cfg.Validate())
+	})
+
+	t.Run("Invalid username pattern", func(t *testing.T) {
+		cfg := &Config{
+			Security: SecurityConfig{UsernamePattern: `^[a-z+$`},
+			Database: DatabaseConfig{Host: "localhost", Password: "pass"},
+		}
+		assert.ErrorContains(t, cfg.Validate(), "security.username_pattern is not a valid regexp")
+
+		cfg.Security.UsernamePattern = `^[a-z_]{3,20}$`
+		assert.NoError(t, cfg.Validate())
+	})
+
+	t.Run("Disposable email blocklist", func(t *testing.T) {
+		cfg := &Config{
+			Security: SecurityConfig{BlockDisposableEmail: true},
+			Database: DatabaseConfig{Host: "localhost", Password: "pass"},
+		}
+		assert.EqualError(t, cfg.Validate(), "security.block_disposable_email requires disposable_email_file or disposable_email_domains")
+
+		cfg.Security.DisposableEmailDomains = []string{"mailinator.com"}
+		assert.NoError(t, cfg.Validate())
+	})
+
+	t.Run("SMTP notifications", func(t *testing.T) {
+		cfg := &Config{
+			Database: DatabaseConfig{Host: "localhost", Password: "pass"},
+			Notify:   NotifyConfig{SMTP: SMTPConfig{Host: "smtp.example.com"}},
+		}
+		assert.EqualError(t, cfg.Validate(), "notify.smtp.from is required when notify.smtp.host is set\n"+
+			"notify.smtp.port must be between 1 and 65535")
+
+		cfg.Notify.SMTP.From = "no-reply@example.com"
+		cfg.Notify.SMTP.Port = 587
+		assert.NoError(t, cfg.Validate())
+	})
+
+	t.Run("Pagination limits", func(t *testing.T) {
+		for limits, valid := range map[PaginationConfig]bool{
+			{}:                                 true,
+			{DefaultLimit: 10, MaxLimit: 100}:  true,
+			{DefaultLimit: 100, MaxLimit: 100}: true,
+			{DefaultLimit: 0, MaxLimit: 100}:   false,
+			{DefaultLimit: 10, MaxLimit: -1}:   false,
+			{DefaultLimit: 50, MaxLimit: 20}:   false,
+		} {
+			cfg := &Config{
+				Pagination: limits,
+				Database:   DatabaseConfig{Host: "localhost", Password: "pass"},
+			}
+			if valid {
+				assert.NoError(t, cfg.Validate(), limits)
+			} else {
+				assert.Error(t, cfg.Validate(), limits)
+			}
+		}
+	})
+
+	t.Run("Trusted platform", func(t *testing.T) {
+		cfg := &Config{Database: DatabaseConfig{Host: "localhost", Password: "pass"}}
+		cfg.Server.TrustedPlatform = "cloudflare"
+		assert.NoError(t, cfg.Validate())
+
+		cfg.Server.TrustedPlatform = "heroku"
+		assert.EqualError(t, cfg.Validate(), "server.trusted_platform must be one of: cloudflare, fly_io, google_app_engine")
+	})
+
+	t.Run("Cookie problems", func(t *testing.T) {
+		cfg := &Config{Database: DatabaseConfig{Host: "localhost", Password: "pass"}}
+		cfg.App.Mode = "release"
+		// warn по умолчанию: проблема видна, но Validate проходит
+		assert.Len(t, cfg.CookieProblems(), 1)
+		assert.NoError(t, cfg.Validate())
+
+		cfg.Security.CookieCheck = "strict"
+		assert.EqualError(t, cfg.Validate(), "token cookie is Secure in release mode, but neither server.tls.cert_file/key_file nor server.tls.terminated_by_proxy is set")
+
+		cfg.Server.TLS.TerminatedByProxy = true
+		assert.NoError(t, cfg.Validate())
+
+		cfg.Server.TLS = TLSConfig{CertFile: "cert.pem", KeyFile: "key.pem"}
+		assert.NoError(t, cfg.Validate())
+
+		// SameSite=None без Secure браузер отвергает
+		cfg.App.Mode = "debug"
+		cfg.Auth.CookieSameSite = "none"
+		assert.EqualError(t, cfg.Validate(), "auth.cookie_same_site=none requires a Secure cookie, which is set only in release mode")
+	})
+
+	t.Run("TLS cert without key", func(t *testing.T) {
+		cfg := &Config{Database: DatabaseConfig{Host: "localhost", Password: "pass"}}
+		cfg.Server.TLS.CertFile = "cert.pem"
+		assert.EqualError(t, cfg.Validate(), "server.tls.cert_file and key_file must be set together")
+	})
+
+	t.Run("Feature flags", func(t *testing.T) {
+		cfg := &Config{Database: DatabaseConfig{Host: "localhost", Password: "pass"}}
+		assert.True(t, cfg.FeatureEnabled(FeaturePasswordCheck))
+		assert.False(t, cfg.FeatureEnabled("unknown"))
+
+		cfg.Features = map[string]bool{FeaturePasswordCheck: false}
+		assert.NoError(t, cfg.Validate())
+		assert.False(t, cfg.FeatureEnabled(FeaturePasswordCheck))
+
+		cfg.Features["two_factor"] = true
+		assert.EqualError(t, cfg.Validate(), "features.two_factor is not a known feature")
+	})
+
+	t.Run("Base path format", func(t *testing.T) {
+		for path, valid := range map[string]bool{
+			"":        true,
+			"/api/v1": true,
+			"api/v1":  false,
+			"/api/":   false,
+		} {
+			cfg := &Config{
+				App:      AppConfig{BasePath: path},
+				Database: DatabaseConfig{Host: "localhost", Password: "pass"},
+			}
+			if valid {
+				assert.NoError(t, cfg.Validate(), path)
+			} else {
+				assert.Error(t, cfg.Validate(), path)
+			}
+		}
+	})
 }
@@ -4,10 +4,12 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/config"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/pressly/goose/v3"
@@ -16,8 +18,10 @@ import (
 )
 
 // Database wraps the pgxpool.Pool to provide a unified database access point.
+// Replica is nil unless a read replica is configured.
 type Database struct {
-	Pool   *pgxpool.Pool
+	Pool    *pgxpool.Pool
+	Replica *pgxpool.Pool
 }
 
 type dbLogConfig struct {
@@ -36,24 +40,14 @@ var (
 // and automatically executes pending migrations.
 func Connect(ctx context.Context, cfg *config.Config, logger *zap.Logger) (*Database, error) {
 
-	dsn := fmt.Sprintf(
-		"postgres://%s:%s@%s:%d/%s?sslmode=%s",
-		cfg.Database.User,
-		cfg.Database.Password,
-		cfg.Database.Host,
-		cfg.Database.Port,
-		cfg.Database.Name,
-		cfg.Database.SSLMode,
-	)
+	dsn := buildDSN(&cfg.Database, cfg.Database.Host)
 
-	pgcfg, err := pgxpool.ParseConfig(dsn)
+	pgcfg, err := poolConfig(&cfg.Database, dsn)
 	if err != nil {
-		return nil, fmt.Errorf("parse pgx config: %w", err)
+		return nil, err
 	}
-
-	pgcfg.MaxConns = cfg.Database.MaxConns
-	pgcfg.MinConns = cfg.Database.MinConns
-	pgcfg.MaxConnLifetime = time.Hour
+	tracer := newQueryTracer(cfg, logger)
+	pgcfg.ConnConfig.Tracer = tracer
 
 	logger.Info("database config",
 		zap.Object("database", dbLogConfig{
@@ -74,15 +68,103 @@ func Connect(ctx context.Context, cfg *config.Config, logger *zap.Logger) (*Data
 	}
 
 	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
 		return nil, fmt.Errorf("ping database: %w", err)
 	}
 
 	logger.Info("connected to database")
-	return &Database{Pool: pool}, nil
+
+	database := &Database{Pool: pool}
+
+	if cfg.Database.ReplicaHost != "" {
+		replica, err := connectReplica(ctx, &cfg.Database, tracer)
+		if err != nil {
+			pool.Close()
+			return nil, err
+		}
+		database.Replica = replica
+		logger.Info("connected to read replica", zap.String("host", cfg.Database.ReplicaHost))
+	}
+
+	return database, nil
+}
+
+// Close closes the primary pool and the replica pool, if any. pgxpool.Close waits
+// for every acquired connection to be released, so a leaked connection would block
+// shutdown forever; Close gives up when ctx is done and reports it.
+func (d *Database) Close(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		if d.Replica != nil {
+			d.Replica.Close()
+		}
+		d.Pool.Close()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("close database pools: connections still acquired: %w", ctx.Err())
+	}
+}
+
+// connectReplica opens a pool to the read replica. The replica shares credentials,
+// pool settings and the query tracer with the primary; migrations are never run against it.
+func connectReplica(ctx context.Context, cfg *config.DatabaseConfig, tracer pgx.QueryTracer) (*pgxpool.Pool, error) {
+	pgcfg, err := poolConfig(cfg, buildDSN(cfg, cfg.ReplicaHost))
+	if err != nil {
+		return nil, err
+	}
+	pgcfg.ConnConfig.Tracer = tracer
+
+	replica, err := newPoolWithConfig(ctx, pgcfg)
+	if err != nil {
+		return nil, fmt.Errorf("create replica pool: %w", err)
+	}
+
+	if err := replica.Ping(ctx); err != nil {
+		replica.Close()
+		return nil, fmt.Errorf("ping replica: %w", err)
+	}
+
+	return replica, nil
+}
+
+func buildDSN(cfg *config.DatabaseConfig, host string) string {
+	return fmt.Sprintf(
+		"postgres://%s:%s@%s:%d/%s?sslmode=%s",
+		cfg.User,
+		cfg.Password,
+		host,
+		cfg.Port,
+		cfg.Name,
+		cfg.SSLMode,
+	)
+}
+
+func poolConfig(cfg *config.DatabaseConfig, dsn string) (*pgxpool.Config, error) {
+	pgcfg, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("parse pgx config: %w", err)
+	}
+
+	pgcfg.MaxConns = cfg.MaxConns
+	pgcfg.MinConns = cfg.MinConns
+	pgcfg.MaxConnLifetime = time.Hour
+
+	// Postgres сам прервет запрос, даже если клиент забыл про контекст
+	if cfg.StatementTimeoutMs > 0 {
+		pgcfg.ConnConfig.RuntimeParams["statement_timeout"] = strconv.Itoa(cfg.StatementTimeoutMs)
+	}
+
+	return pgcfg, nil
 }
 
 func (d dbLogConfig) MarshalLogObject(enc zapcore.ObjectEncoder) error {
 	enc.AddString("host", d.cfg.Host)
+	enc.AddString("replica_host", d.cfg.ReplicaHost)
 	enc.AddInt("port", d.cfg.Port)
 	enc.AddString("user", d.cfg.User)
 	enc.AddString("password", strings.Repeat("*", len(d.cfg.Password)))
@@ -91,6 +173,9 @@ func (d dbLogConfig) MarshalLogObject(enc zapcore.ObjectEncoder) error {
 	enc.AddInt32("max_conns", d.pgcfg.MaxConns)
 	enc.AddInt32("min_conns", d.pgcfg.MinConns)
 	enc.AddDuration("max_conn_lifetime", d.pgcfg.MaxConnLifetime)
+	enc.AddInt("statement_timeout_ms", d.cfg.StatementTimeoutMs)
+	enc.AddBool("trace_queries", d.cfg.TraceQueries)
+	enc.AddDuration("slow_query_threshold", d.cfg.SlowQueryThreshold)
 	return nil
 }
 
@@ -0,0 +1,84 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/config"
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// queryTracer is a pgx.QueryTracer: it logs every query at debug level and warns about
+// queries slower than slowThreshold, so N+1s and slow paths show up without wrapping
+// each repository method. Query arguments are never logged: they carry password
+// hashes and tokens.
+type queryTracer struct {
+	logger        *zap.Logger
+	logQueries    bool
+	slowThreshold time.Duration
+	// now is replaced in tests
+	now func() time.Time
+}
+
+type traceStartKey struct{}
+
+type traceStart struct {
+	sql string
+	at  time.Time
+}
+
+// newQueryTracer returns nil when there is nothing to trace: outside debug mode
+// without database.trace_queries and database.slow_query_threshold the driver
+// runs with no hooks at all.
+func newQueryTracer(cfg *config.Config, logger *zap.Logger) pgx.QueryTracer {
+	logQueries := cfg.Database.TraceQueries || cfg.App.Mode == "debug"
+	if !logQueries && cfg.Database.SlowQueryThreshold <= 0 {
+		return nil
+	}
+
+	return &queryTracer{
+		logger:        logger,
+		logQueries:    logQueries,
+		slowThreshold: cfg.Database.SlowQueryThreshold,
+		now:           time.Now,
+	}
+}
+
+func (t *queryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	sql := compactSQL(data.SQL)
+	if t.logQueries {
+		t.logger.Debug("query start", zap.String("sql", sql))
+	}
+	return context.WithValue(ctx, traceStartKey{}, traceStart{sql: sql, at: t.now()})
+}
+
+func (t *queryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	start, ok := ctx.Value(traceStartKey{}).(traceStart)
+	if !ok {
+		return
+	}
+	elapsed := t.now().Sub(start.at)
+
+	fields := []zap.Field{
+		zap.String("sql", start.sql),
+		zap.Duration("duration", elapsed),
+		zap.Int64("rows", data.CommandTag.RowsAffected()),
+	}
+	if data.Err != nil {
+		fields = append(fields, zap.Error(data.Err))
+	}
+
+	switch {
+	case t.slowThreshold > 0 && elapsed >= t.slowThreshold:
+		t.logger.Warn("slow query", fields...)
+	case t.logQueries:
+		t.logger.Debug("query end", fields...)
+	}
+}
+
+// compactSQL collapses the indentation of multi-line queries into one log-friendly line
+func compactSQL(sql string) string {
+	return strings.Join(strings.Fields(sql), " ")
+}
@@ -0,0 +1,40 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/repository"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryErrorCodes - аналог abortIfCanceled в HTTP: ошибки хранилища и контекста получают
+// свои коды, а не Unknown. ErrDatabaseBusy - Unavailable, который клиенты gRPC повторяют сами.
+func UnaryErrorCodes() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		resp, err := handler(ctx, req)
+		return resp, statusError(err)
+	}
+}
+
+// statusError оставляет ошибки, у которых уже есть gRPC-статус, как есть
+func statusError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	switch {
+	case errors.Is(err, repository.ErrDatabaseBusy):
+		return status.Error(codes.Unavailable, "database busy")
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, "request timeout")
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, "request canceled")
+	}
+	return err
+}
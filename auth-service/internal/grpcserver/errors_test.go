@@ -0,0 +1,33 @@
+package grpcserver
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryErrorCodes(t *testing.T) {
+	interceptor := UnaryErrorCodes()
+
+	call := func(err error) error {
+		_, got := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req any) (any, error) {
+			return nil, err
+		})
+		return got
+	}
+
+	assert.NoError(t, call(nil))
+	assert.Equal(t, codes.Unavailable, status.Code(call(fmt.Errorf("get user: %w", repository.ErrDatabaseBusy))))
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(call(context.DeadlineExceeded)))
+	assert.Equal(t, codes.Canceled, status.Code(call(context.Canceled)))
+
+	// Готовый статус и прочие ошибки не трогаем
+	assert.Equal(t, codes.NotFound, status.Code(call(status.Error(codes.NotFound, "user not found"))))
+	assert.Equal(t, codes.Unknown, status.Code(call(assert.AnError)))
+}
@@ -0,0 +1,94 @@
+package grpcserver
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// Pinger - то, от чего зависит готовность сервиса (repository.AuthRepository)
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+const (
+	defaultHealthInterval = 10 * time.Second
+	// pingTimeout - проверка не должна висеть дольше, чем оркестратор ждет ответа
+	pingTimeout = 2 * time.Second
+)
+
+// HealthReporter реализует grpc.health.v1.Health: статус SERVING, пока хранилище
+// отвечает на Ping, иначе NOT_SERVING. Пока первая проверка не прошла - NOT_SERVING.
+type HealthReporter struct {
+	pinger   Pinger
+	health   *health.Server
+	interval time.Duration
+	logger   *zap.Logger
+	serving  bool
+}
+
+// NewHealthReporter создает reporter; нулевой interval заменяется дефолтом
+func NewHealthReporter(pinger Pinger, interval time.Duration, logger *zap.Logger) *HealthReporter {
+	if interval <= 0 {
+		interval = defaultHealthInterval
+	}
+
+	srv := health.NewServer()
+	// health.NewServer по умолчанию отвечает SERVING - до проверки базы это неправда
+	srv.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+
+	return &HealthReporter{
+		pinger:   pinger,
+		health:   srv,
+		interval: interval,
+		logger:   logger,
+	}
+}
+
+// Register добавляет сервис grpc.health.v1.Health на gRPC-сервер
+func (r *HealthReporter) Register(s *grpc.Server) {
+	healthpb.RegisterHealthServer(s, r.health)
+}
+
+// Run проверяет хранилище сразу и затем каждые interval, пока не отменят ctx.
+// После остановки статус навсегда NOT_SERVING, чтобы клиенты ушли до закрытия сервера.
+func (r *HealthReporter) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	r.check(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			r.health.Shutdown()
+			return
+		case <-ticker.C:
+			r.check(ctx)
+		}
+	}
+}
+
+func (r *HealthReporter) check(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+
+	err := r.pinger.Ping(ctx)
+	serving := err == nil
+	if serving == r.serving {
+		return
+	}
+	r.serving = serving
+
+	// Логируем только смену статуса, иначе при лежащей базе лог забьется
+	if serving {
+		r.logger.Info("grpc health: serving")
+		r.health.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+		return
+	}
+	r.logger.Warn("grpc health: not serving", zap.Error(err))
+	r.health.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+}
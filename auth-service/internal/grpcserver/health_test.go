@@ -0,0 +1,102 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type fakePinger struct {
+	down atomic.Bool
+}
+
+func (p *fakePinger) Ping(ctx context.Context) error {
+	if p.down.Load() {
+		return errors.New("connection refused")
+	}
+	return nil
+}
+
+// startHealthServer поднимает gRPC-сервер в памяти и возвращает клиента Health
+func startHealthServer(t *testing.T, r *HealthReporter) healthpb.HealthClient {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	r.Register(s)
+	go func() { _ = s.Serve(lis) }()
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return healthpb.NewHealthClient(conn)
+}
+
+func servingStatus(t *testing.T, client healthpb.HealthClient) healthpb.HealthCheckResponse_ServingStatus {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	return resp.GetStatus()
+}
+
+func TestHealthReporter(t *testing.T) {
+	pinger := &fakePinger{}
+	r := NewHealthReporter(pinger, time.Hour, zap.NewNop())
+	client := startHealthServer(t, r)
+
+	// До первой проверки базы сервис не считается готовым
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, servingStatus(t, client))
+
+	r.check(context.Background())
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, servingStatus(t, client))
+
+	pinger.down.Store(true)
+	r.check(context.Background())
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, servingStatus(t, client))
+
+	pinger.down.Store(false)
+	r.check(context.Background())
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, servingStatus(t, client))
+}
+
+func TestHealthReporter_RunStopsServing(t *testing.T) {
+	r := NewHealthReporter(&fakePinger{}, time.Hour, zap.NewNop())
+	client := startHealthServer(t, r)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		r.Run(ctx)
+		close(done)
+	}()
+
+	assert.Eventually(t, func() bool {
+		return servingStatus(t, client) == healthpb.HealthCheckResponse_SERVING
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	<-done
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, servingStatus(t, client))
+}
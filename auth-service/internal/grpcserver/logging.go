@@ -0,0 +1,106 @@
+package grpcserver
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// requestIDKey - X-Request-ID в метаданных gRPC (ключи там всегда в нижнем регистре)
+const requestIDKey = "x-request-id"
+
+// UnaryLogger - аналог handler.ZapLogger: метод, код ответа и длительность каждого вызова.
+// Уровень как в HTTP: Internal и прочие серверные коды - error, ошибки клиента - warn.
+func UnaryLogger(logger *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+
+		resp, err := handler(ctx, req)
+
+		code := status.Code(err)
+		fields := []zap.Field{
+			zap.String("code", code.String()),
+			zap.String("request_id", requestID(ctx)),
+			zap.String("method", info.FullMethod),
+			zap.Duration("latency", time.Since(start)),
+		}
+		if err != nil {
+			fields = append(fields, zap.Error(err))
+		}
+
+		switch code {
+		case codes.OK:
+			logger.Info("rpc processed", fields...)
+		case codes.Canceled:
+			logger.Info("client canceled rpc", fields...)
+		case codes.Internal, codes.Unknown, codes.DataLoss, codes.Unavailable, codes.DeadlineExceeded, codes.Unimplemented:
+			logger.Error("rpc server error", fields...)
+		default:
+			logger.Warn("rpc client error", fields...)
+		}
+
+		return resp, err
+	}
+}
+
+// UnaryRecovery - аналог handler.ZapRecovery: паника в хендлере становится codes.Internal,
+// а не роняет весь процесс вместе с HTTP-сервером
+func UnaryRecovery(logger *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+
+			logger.Error("panic recovered",
+				zap.Any("panic", rec),
+				zap.String("request_id", requestID(ctx)),
+				zap.String("method", info.FullMethod),
+				zap.Stack("stack"),
+			)
+			resp, err = nil, status.Error(codes.Internal, "internal error")
+		}()
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamRecovery - то же для стримов (Health.Watch): паника завершает только свой стрим
+func StreamRecovery(logger *zap.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+
+			logger.Error("panic recovered",
+				zap.Any("panic", rec),
+				zap.String("request_id", requestID(ss.Context())),
+				zap.String("method", info.FullMethod),
+				zap.Stack("stack"),
+			)
+			err = status.Error(codes.Internal, "internal error")
+		}()
+
+		return handler(srv, ss)
+	}
+}
+
+// requestID - X-Request-ID, проброшенный вызывающим сервисом; пусто, если его нет
+func requestID(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if values := md.Get(requestIDKey); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
@@ -0,0 +1,100 @@
+package grpcserver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryLogger(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	interceptor := UnaryLogger(zap.New(core))
+	info := &grpc.UnaryServerInfo{FullMethod: "/auth.v1.Auth/Test"}
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("X-Request-ID", "req-42"))
+
+	cases := []struct {
+		name    string
+		err     error
+		level   zapcore.Level
+		message string
+	}{
+		{"OK", nil, zap.InfoLevel, "rpc processed"},
+		{"Client error", status.Error(codes.InvalidArgument, "bad"), zap.WarnLevel, "rpc client error"},
+		{"Server error", status.Error(codes.Internal, "boom"), zap.ErrorLevel, "rpc server error"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := interceptor(ctx, nil, info, func(ctx context.Context, req any) (any, error) {
+				return nil, tc.err
+			})
+			assert.Equal(t, tc.err, err)
+
+			entries := logs.TakeAll()
+			require.Len(t, entries, 1)
+			assert.Equal(t, tc.level, entries[0].Level)
+			assert.Equal(t, tc.message, entries[0].Message)
+
+			fields := entries[0].ContextMap()
+			assert.Equal(t, "/auth.v1.Auth/Test", fields["method"])
+			assert.Equal(t, status.Code(tc.err).String(), fields["code"])
+			assert.Equal(t, "req-42", fields["request_id"])
+			assert.Contains(t, fields, "latency")
+		})
+	}
+}
+
+func TestUnaryRecovery(t *testing.T) {
+	core, logs := observer.New(zap.ErrorLevel)
+	interceptor := UnaryRecovery(zap.New(core))
+	info := &grpc.UnaryServerInfo{FullMethod: "/auth.v1.Auth/Test"}
+
+	resp, err := interceptor(context.Background(), nil, info, func(ctx context.Context, req any) (any, error) {
+		panic("nil map")
+	})
+
+	assert.Nil(t, resp)
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Equal(t, "internal error", status.Convert(err).Message())
+
+	entries := logs.FilterMessage("panic recovered").All()
+	require.Len(t, entries, 1)
+	assert.Equal(t, "nil map", entries[0].ContextMap()["panic"])
+	assert.Equal(t, "/auth.v1.Auth/Test", entries[0].ContextMap()["method"])
+}
+
+func TestStreamRecovery(t *testing.T) {
+	core, logs := observer.New(zap.ErrorLevel)
+	interceptor := StreamRecovery(zap.New(core))
+	info := &grpc.StreamServerInfo{FullMethod: "/grpc.health.v1.Health/Watch"}
+	ss := &ctxStream{ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs(requestIDKey, "req-1"))}
+
+	err := interceptor(nil, ss, info, func(srv any, ss grpc.ServerStream) error {
+		panic("nil map")
+	})
+
+	assert.Equal(t, codes.Internal, status.Code(err))
+
+	entries := logs.FilterMessage("panic recovered").All()
+	require.Len(t, entries, 1)
+	assert.Equal(t, "nil map", entries[0].ContextMap()["panic"])
+	assert.Equal(t, "req-1", entries[0].ContextMap()["request_id"])
+	assert.Equal(t, "/grpc.health.v1.Health/Watch", entries[0].ContextMap()["method"])
+}
+
+// ctxStream - стрим, у которого есть только контекст
+type ctxStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *ctxStream) Context() context.Context { return s.ctx }
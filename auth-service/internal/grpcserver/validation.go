@@ -0,0 +1,68 @@
+package grpcserver
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/model"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Validatable - запрос, который проверяет себя сам: protoc не переносит validate-теги,
+// поэтому правила для proto-сообщений (непустой токен, UUID) живут в методе Validate
+type Validatable interface {
+	Validate() error
+}
+
+// UnaryValidator проверяет каждый входящий запрос до хендлера, чтобы правила
+// были те же, что в HTTP, и не повторялись в каждом методе. Ошибка - codes.InvalidArgument.
+func UnaryValidator(v *model.Validator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := validateRequest(v, req); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamValidator - то же для стримов: проверяется каждое сообщение клиента
+func StreamValidator(v *model.Validator) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &validatingStream{ServerStream: ss, validator: v})
+	}
+}
+
+type validatingStream struct {
+	grpc.ServerStream
+	validator *model.Validator
+}
+
+func (s *validatingStream) RecvMsg(m any) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	return validateRequest(s.validator, m)
+}
+
+// validateRequest: сначала Validate() запроса, затем validate-теги model.Validator (для Go-структур)
+func validateRequest(v *model.Validator, req any) error {
+	if r, ok := req.(Validatable); ok {
+		if err := r.Validate(); err != nil {
+			return invalidArgument(err)
+		}
+	}
+
+	if rv := reflect.ValueOf(req); rv.Kind() == reflect.Pointer && !rv.IsNil() && rv.Elem().Kind() == reflect.Struct {
+		if err := v.ValidateStruct(req); err != nil {
+			return invalidArgument(err)
+		}
+	}
+	return nil
+}
+
+// invalidArgument - текст как в HTTP, для model.ValidationError: "field 'token' failed on the 'required' rule"
+func invalidArgument(err error) error {
+	return status.Error(codes.InvalidArgument, err.Error())
+}
@@ -0,0 +1,102 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// tokenRequest - как proto-сообщение: правила в Validate, тегов нет
+type tokenRequest struct {
+	Token string
+}
+
+func (r *tokenRequest) Validate() error {
+	if r.Token == "" {
+		return errors.New("token is required")
+	}
+	return nil
+}
+
+// lookupRequest - Go-структура с теми же тегами, что и HTTP-модели
+type lookupRequest struct {
+	UserID string `json:"user_id" validate:"required,uuid"`
+}
+
+func TestUnaryValidator(t *testing.T) {
+	interceptor := UnaryValidator(model.NewValidator(0, "", ""))
+	info := &grpc.UnaryServerInfo{FullMethod: "/auth.v1.Auth/Test"}
+
+	call := func(req any) (bool, error) {
+		called := false
+		_, err := interceptor(context.Background(), req, info, func(ctx context.Context, req any) (any, error) {
+			called = true
+			return nil, nil
+		})
+		return called, err
+	}
+
+	cases := []struct {
+		name    string
+		req     any
+		message string
+	}{
+		{"Validate method", &tokenRequest{}, "token is required"},
+		{"Struct tags", &lookupRequest{UserID: "not-a-uuid"}, "field 'user_id' failed on the 'uuid' rule"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			called, err := call(tc.req)
+			assert.False(t, called, "handler must not run for an invalid request")
+
+			st, ok := status.FromError(err)
+			require.True(t, ok)
+			assert.Equal(t, codes.InvalidArgument, st.Code())
+			assert.Equal(t, tc.message, st.Message())
+		})
+	}
+
+	t.Run("Valid requests reach the handler", func(t *testing.T) {
+		for _, req := range []any{
+			&tokenRequest{Token: "jwt"},
+			&lookupRequest{UserID: "7f8e8c02-35b4-4b8a-9f0c-0b3f5a1b2c3d"},
+			&healthpb.HealthCheckRequest{},
+		} {
+			called, err := call(req)
+			assert.NoError(t, err)
+			assert.True(t, called)
+		}
+	})
+}
+
+// fakeStream отдает одно сообщение из msg
+type fakeStream struct {
+	grpc.ServerStream
+	msg tokenRequest
+}
+
+func (s *fakeStream) RecvMsg(m any) error {
+	*m.(*tokenRequest) = s.msg
+	return nil
+}
+
+func TestStreamValidator(t *testing.T) {
+	interceptor := StreamValidator(model.NewValidator(0, "", ""))
+
+	recv := func(msg tokenRequest) error {
+		return interceptor(nil, &fakeStream{msg: msg}, &grpc.StreamServerInfo{}, func(srv any, ss grpc.ServerStream) error {
+			return ss.RecvMsg(&tokenRequest{})
+		})
+	}
+
+	assert.NoError(t, recv(tokenRequest{Token: "jwt"}))
+	assert.Equal(t, codes.InvalidArgument, status.Code(recv(tokenRequest{})))
+}
@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/model"
+	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/repository"
+	"go.uber.org/zap"
+)
+
+// POST /user/api-tokens — авторизованный пользователь (не API-токеном).
+// Значение токена есть только в этом ответе; в базе хранится его хеш
+//
+// @Summary      Создать API-токен
+// @Tags         user
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      model.CreateAPITokenRequest  true  "название токена"
+// @Success      201      {object}  model.CreatedAPIToken
+// @Failure      400      {object}  ValidationErrorResponse
+// @Failure      401      {object}  ErrorResponse
+// @Failure      403      {object}  ErrorResponse
+// @Failure      500      {object}  ErrorResponse
+// @Router       /user/api-tokens [post]
+func (h *AuthHandler) CreateAPIToken(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req model.CreateAPITokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	if err := h.validator.ValidateStruct(&req); err != nil {
+		respondValidationError(c, err)
+		return
+	}
+
+	created, err := h.service.CreateAPIToken(c.Request.Context(), userID, &req)
+	if err != nil {
+		if h.abortIfCanceled(c, err) {
+			return
+		}
+		h.handlerLogger(c).Error("failed to create api token", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusCreated, created)
+}
+
+// GET /user/api-tokens — авторизованный пользователь; значения токенов не возвращаются
+//
+// @Summary      Список API-токенов
+// @Tags         user
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {array}   model.APIToken
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /user/api-tokens [get]
+func (h *AuthHandler) ListAPITokens(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	tokens, err := h.service.ListAPITokens(c.Request.Context(), userID)
+	if err != nil {
+		if h.abortIfCanceled(c, err) {
+			return
+		}
+		h.handlerLogger(c).Error("failed to list api tokens", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// DELETE /user/api-tokens/:id — авторизованный пользователь отзывает свой токен
+//
+// @Summary      Отозвать API-токен
+// @Tags         user
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "UUID токена"
+// @Success      200  {object}  MessageResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /user/api-tokens/{id} [delete]
+func (h *AuthHandler) RevokeAPIToken(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	tokenID, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.service.RevokeAPIToken(c.Request.Context(), userID, tokenID); err != nil {
+		if h.abortIfCanceled(c, err) {
+			return
+		}
+		// Чужой токен неотличим от несуществующего
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "api token not found"})
+			return
+		}
+		h.handlerLogger(c).Error("failed to revoke api token", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "api token revoked"})
+}
+
+// currentUserID - ID пользователя, которого положил AuthMiddleware
+func currentUserID(c *gin.Context) (uuid.UUID, bool) {
+	value, exists := c.Get("userID")
+	if !exists {
+		return uuid.Nil, false
+	}
+	userID, ok := value.(uuid.UUID)
+	return userID, ok
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/model"
+	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+func TestAuthHandler_APITokens(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockSvc := &mockAuthService{}
+	h := NewAuthHandler(mockSvc, Config{}, zap.NewNop())
+
+	userID := uuid.New()
+	r := gin.New()
+	tokens := r.Group("/user/api-tokens", func(c *gin.Context) { c.Set("userID", userID) })
+	tokens.POST("", h.CreateAPIToken)
+	tokens.GET("", h.ListAPITokens)
+	tokens.DELETE("/:id", h.RevokeAPIToken)
+
+	tokenID := uuid.New()
+	createdAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	t.Run("Create shows the value once", func(t *testing.T) {
+		mockSvc.On("CreateAPIToken", mock.Anything, userID, &model.CreateAPITokenRequest{Label: "ci"}).
+			Return(&model.CreatedAPIToken{
+				APIToken: model.APIToken{ID: tokenID, Label: "ci", CreatedAt: createdAt},
+				Token:    "mbh_secret",
+			}, nil).Once()
+
+		w := performRequest(r, http.MethodPost, "/user/api-tokens", `{"label":"ci"}`, nil)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+		assert.JSONEq(t, `{"id":"`+tokenID.String()+`","label":"ci","created_at":"2026-01-02T03:04:05Z","last_used_at":null,"token":"mbh_secret"}`, w.Body.String())
+	})
+
+	t.Run("Create without label", func(t *testing.T) {
+		w := performRequest(r, http.MethodPost, "/user/api-tokens", `{}`, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("List hides values", func(t *testing.T) {
+		lastUsed := createdAt.Add(time.Hour)
+		mockSvc.On("ListAPITokens", mock.Anything, userID).
+			Return([]*model.APIToken{{ID: tokenID, Label: "ci", CreatedAt: createdAt, LastUsedAt: &lastUsed}}, nil).Once()
+
+		w := performRequest(r, http.MethodGet, "/user/api-tokens", "", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `[{"id":"`+tokenID.String()+`","label":"ci","created_at":"2026-01-02T03:04:05Z","last_used_at":"2026-01-02T04:04:05Z"}]`, w.Body.String())
+	})
+
+	t.Run("Revoke", func(t *testing.T) {
+		mockSvc.On("RevokeAPIToken", mock.Anything, userID, tokenID).Return(nil).Once()
+		w := performRequest(r, http.MethodDelete, "/user/api-tokens/"+tokenID.String(), "", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		missing := uuid.New()
+		mockSvc.On("RevokeAPIToken", mock.Anything, userID, missing).Return(repository.ErrNotFound).Once()
+		w = performRequest(r, http.MethodDelete, "/user/api-tokens/"+missing.String(), "", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w = performRequest(r, http.MethodDelete, "/user/api-tokens/not-a-uuid", "", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	mockSvc.AssertExpectations(t)
+}
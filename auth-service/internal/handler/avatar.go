@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/model"
+	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/repository"
+	"go.uber.org/zap"
+)
+
+// avatarContentTypes - форматы, которые покажет любой браузер. Тип определяется
+// по первым байтам файла, Content-Type от клиента не учитывается.
+var avatarContentTypes = []string{"image/png", "image/jpeg", "image/gif", "image/webp"}
+
+// avatarKey - у пользователя один аватар, новый перезаписывает старый
+func avatarKey(userID uuid.UUID) string {
+	return "avatars/" + userID.String()
+}
+
+// PUT /user/avatar — авторизованный пользователь, multipart с полем avatar
+// Размер ограничен server.max_multipart_bytes (BodyLimit)
+//
+// @Summary      Загрузить аватар
+// @Tags         user
+// @Accept       multipart/form-data
+// @Produce      json
+// @Security     BearerAuth
+// @Param        avatar  formData  file  true  "PNG, JPEG, GIF или WebP"
+// @Success      200     {object}  AvatarResponse
+// @Failure      400     {object}  ErrorResponse
+// @Failure      401     {object}  ErrorResponse
+// @Failure      404     {object}  ErrorResponse
+// @Failure      413     {object}  ErrorResponse
+// @Failure      415     {object}  ErrorResponse
+// @Failure      500     {object}  ErrorResponse
+// @Router       /user/avatar [put]
+func (h *AuthHandler) UploadAvatar(c *gin.Context) {
+	userIDVal, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID := userIDVal.(uuid.UUID)
+
+	fileHeader, err := c.FormFile("avatar")
+	if err != nil {
+		if IsBodyTooLarge(err) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "request body too large"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "avatar file is required"})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		h.handlerLogger(c).Error("failed to open uploaded avatar", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+	defer file.Close()
+
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(file, head)
+	contentType := http.DetectContentType(head[:n])
+	if !slices.Contains(avatarContentTypes, contentType) {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "avatar must be a PNG, JPEG, GIF or WebP image"})
+		return
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		h.handlerLogger(c).Error("failed to rewind uploaded avatar", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	key := avatarKey(userID)
+	if err := h.avatars.Upload(c.Request.Context(), key, file, fileHeader.Size, contentType); err != nil {
+		if h.abortIfCanceled(c, err) {
+			return
+		}
+		h.handlerLogger(c).Error("failed to upload avatar", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to upload avatar"})
+		return
+	}
+
+	if err := h.service.SetAvatar(c.Request.Context(), userID, key); err != nil {
+		if h.abortIfCanceled(c, err) {
+			return
+		}
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to upload avatar"})
+		return
+	}
+
+	c.JSON(http.StatusOK, AvatarResponse{AvatarURL: h.avatarURL(c, key)})
+}
+
+// userResponse - model.ToResponseIn плюс ссылка на аватар
+func (h *AuthHandler) userResponse(c *gin.Context, user *model.User, loc *time.Location) model.UserResponse {
+	resp := model.ToResponseIn(user, loc)
+	resp.AvatarURL = h.avatarURL(c, user.AvatarKey)
+	return resp
+}
+
+// avatarURL подписывает ссылку на каждый ответ: сохраненная в базе протухла бы через url_ttl.
+// Ошибка подписи не ломает ответ - клиент просто покажет аватар по умолчанию.
+func (h *AuthHandler) avatarURL(c *gin.Context, key string) string {
+	if key == "" || h.avatars == nil {
+		return ""
+	}
+
+	url, err := h.avatars.SignedURL(key, h.avatarURLTTL)
+	if err != nil {
+		h.handlerLogger(c).Error("failed to sign avatar url", zap.String("key", key), zap.Error(err))
+		return ""
+	}
+	return url
+}
@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/model"
+	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// pngHeader - сигнатура PNG, по ней http.DetectContentType узнает image/png
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func avatarRequest(t *testing.T, content []byte) *http.Request {
+	t.Helper()
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, err := mw.CreateFormFile("avatar", "me.png")
+	require.NoError(t, err)
+	_, err = part.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, mw.Close())
+
+	req := httptest.NewRequest(http.MethodPut, "/user/avatar", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func TestAuthHandler_UploadAvatar(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	dir := t.TempDir()
+	userID := uuid.New()
+	key := "avatars/" + userID.String()
+
+	newRouter := func(mockSvc *mockAuthService) *gin.Engine {
+		h := NewAuthHandler(mockSvc, Config{Avatars: storage.NewLocal(dir, "/static"), AvatarURLTTL: time.Minute}, zap.NewNop())
+		r := gin.New()
+		r.PUT("/user/avatar", func(c *gin.Context) {
+			c.Set("userID", userID)
+			c.Next()
+		}, h.UploadAvatar)
+		return r
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		mockSvc := &mockAuthService{}
+		mockSvc.On("SetAvatar", mock.Anything, userID, key).Return(nil)
+
+		w := httptest.NewRecorder()
+		newRouter(mockSvc).ServeHTTP(w, avatarRequest(t, pngHeader))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"avatar_url":"/static/`+key+`"}`, w.Body.String())
+
+		stored, err := os.ReadFile(filepath.Join(dir, "avatars", userID.String()))
+		require.NoError(t, err)
+		assert.Equal(t, pngHeader, stored)
+		mockSvc.AssertExpectations(t)
+	})
+
+	t.Run("Not an image", func(t *testing.T) {
+		mockSvc := &mockAuthService{}
+
+		w := httptest.NewRecorder()
+		newRouter(mockSvc).ServeHTTP(w, avatarRequest(t, []byte("<html><script>alert(1)</script></html>")))
+
+		assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+		mockSvc.AssertNotCalled(t, "SetAvatar", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Missing file", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPut, "/user/avatar", nil)
+		w := httptest.NewRecorder()
+		newRouter(&mockAuthService{}).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestAuthHandler_GetProfile_AvatarURL(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	userID := uuid.New()
+	mockSvc := &mockAuthService{}
+	mockSvc.On("GetByID", mock.Anything, userID).Return(&model.User{
+		ID:        userID,
+		Username:  "alice",
+		AvatarKey: "avatars/" + userID.String(),
+	}, nil)
+
+	h := NewAuthHandler(mockSvc, Config{Avatars: storage.NewLocal(t.TempDir(), "/api/static"), AvatarURLTTL: time.Minute}, zap.NewNop())
+	r := gin.New()
+	r.GET("/user/profile", func(c *gin.Context) {
+		c.Set("userID", userID)
+		c.Next()
+	}, h.GetProfile)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/user/profile", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp model.UserResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "/api/static/avatars/"+userID.String(), resp.AvatarURL)
+}
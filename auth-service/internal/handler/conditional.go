@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// respondConditionalJSON отдает body с ETag и Last-Modified, а если у клиента уже та же
+// версия (If-None-Match / If-Modified-Since) - 304 без тела.
+//
+// ETag - хеш готового JSON, поэтому учитывает все, что влияет на ответ (tz, ссылку на аватар).
+// If-Modified-Since проверяется только при allowModifiedSince: updated_at не меняется, когда
+// протухает подписанная ссылка внутри ответа, и 304 оставил бы клиенту нерабочую ссылку.
+func respondConditionalJSON(c *gin.Context, body any, lastModified time.Time, allowModifiedSince bool) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	sum := sha256.Sum256(data)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	// Ответ свой у каждого пользователя: общим кешам нельзя, браузер переспрашивает каждый раз
+	c.Header("Cache-Control", "private, no-cache")
+	c.Header("Vary", "Authorization, Cookie, Accept-Timezone")
+	c.Header("ETag", etag)
+	if !lastModified.IsZero() {
+		c.Header("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if notModified(c.Request, etag, lastModified, allowModifiedSince) {
+		c.Status(http.StatusNotModified)
+		c.Writer.WriteHeaderNow()
+		return
+	}
+
+	c.Data(http.StatusOK, "application/json; charset=utf-8", data)
+}
+
+// notModified - RFC 9110: If-None-Match главнее, If-Modified-Since смотрим только без него
+func notModified(r *http.Request, etag string, lastModified time.Time, allowModifiedSince bool) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimSpace(candidate)
+			// Для GET сравнение слабое: W/"x" совпадает с "x"
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+				return true
+			}
+		}
+		return false
+	}
+
+	if !allowModifiedSince || lastModified.IsZero() {
+		return false
+	}
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	// В заголовке время с точностью до секунды
+	return !lastModified.Truncate(time.Second).After(since)
+}
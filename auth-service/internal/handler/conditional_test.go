@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestAuthHandler_GetProfile_Conditional(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockSvc := &mockAuthService{}
+	h := NewAuthHandler(mockSvc, Config{}, zap.NewNop())
+
+	id := uuid.New()
+	updated := time.Date(2026, 2, 15, 13, 0, 0, 500_000_000, time.UTC)
+	mockSvc.On("GetByID", mock.Anything, id).Return(&model.User{ID: id, Username: "user1", Version: 3, UpdatedAt: updated}, nil)
+
+	get := func(headers map[string]string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/user/profile", nil)
+		for k, v := range headers {
+			c.Request.Header.Set(k, v)
+		}
+		c.Set("userID", id)
+		h.GetProfile(c)
+		return w
+	}
+
+	first := get(nil)
+	require.Equal(t, http.StatusOK, first.Code)
+	etag := first.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+	assert.Equal(t, "Sun, 15 Feb 2026 13:00:00 GMT", first.Header().Get("Last-Modified"))
+	assert.Equal(t, "private, no-cache", first.Header().Get("Cache-Control"))
+	assert.Contains(t, first.Body.String(), "user1")
+
+	t.Run("Matching ETag - 304", func(t *testing.T) {
+		w := get(map[string]string{"If-None-Match": etag})
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Empty(t, w.Body.String())
+		assert.Equal(t, etag, w.Header().Get("ETag"))
+	})
+
+	t.Run("Weak ETag in a list - 304", func(t *testing.T) {
+		w := get(map[string]string{"If-None-Match": `"other", W/` + etag})
+		assert.Equal(t, http.StatusNotModified, w.Code)
+	})
+
+	t.Run("Stale ETag - 200", func(t *testing.T) {
+		w := get(map[string]string{"If-None-Match": `"other"`})
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("ETag wins over If-Modified-Since", func(t *testing.T) {
+		w := get(map[string]string{"If-None-Match": `"other"`, "If-Modified-Since": "Sun, 15 Feb 2026 13:00:00 GMT"})
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Not modified since", func(t *testing.T) {
+		w := get(map[string]string{"If-Modified-Since": "Sun, 15 Feb 2026 13:00:00 GMT"})
+		assert.Equal(t, http.StatusNotModified, w.Code)
+	})
+
+	t.Run("Modified since", func(t *testing.T) {
+		w := get(map[string]string{"If-Modified-Since": "Sun, 15 Feb 2026 12:59:59 GMT"})
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Another timezone - another ETag", func(t *testing.T) {
+		w := get(map[string]string{"If-None-Match": etag, "Accept-Timezone": "Asia/Tokyo"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotEqual(t, etag, w.Header().Get("ETag"))
+	})
+}
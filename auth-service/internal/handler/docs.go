@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/model"
+)
+
+// Схемы ответов для OpenAPI. Хендлеры отвечают через gin.H, эти типы описывают
+// ту же форму JSON, чтобы swag мог сгенерировать спецификацию (make swagger).
+
+// ErrorResponse - общий формат ошибки: {"error": "..."}
+type ErrorResponse struct {
+	Error string `json:"error" example:"user not found"`
+}
+
+// ValidationErrorResponse - 400 от respondValidationError с разбивкой по полям
+type ValidationErrorResponse struct {
+	Error   string             `json:"error" example:"validation failed"`
+	Details string             `json:"details"`
+	Errors  []model.FieldError `json:"errors"`
+}
+
+// MessageResponse - успешная операция без данных
+type MessageResponse struct {
+	Message string `json:"message" example:"profile updated successfully"`
+}
+
+// SignUpResponse - 201 на регистрацию; token есть только при autologin
+type SignUpResponse struct {
+	ID      uuid.UUID `json:"id"`
+	Message string    `json:"message" example:"user registered"`
+	Token   string    `json:"token,omitempty"`
+	// ExpiresIn - срок жизни token в секундах, только вместе с ним
+	ExpiresIn int `json:"expires_in,omitempty" example:"900"`
+}
+
+// AvatarResponse - 200 на загрузку аватара
+type AvatarResponse struct {
+	AvatarURL string `json:"avatar_url" example:"/static/avatars/3fa85f64-5717-4562-b3fc-2c963f66afa6"`
+}
+
+// TokenResponse - JWT после входа
+type TokenResponse struct {
+	Token string `json:"token"`
+	// ExpiresIn - через сколько секунд истекает токен
+	ExpiresIn int `json:"expires_in" example:"900"`
+}
+
+// swaggerUIVersion - точная версия swagger-ui-dist: плавающий тег (@5) подтянул бы
+// с CDN любой новый код без ревью
+const swaggerUIVersion = "5.17.14"
+
+// swaggerUIPage - Swagger UI с CDN, спецификацию берет с /swagger.json.
+// Маршрут регистрируется только в debug (см. router.NewRouter)
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>auth-service API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@` + swaggerUIVersion + `/swagger-ui.css" crossorigin="anonymous" referrerpolicy="no-referrer">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@` + swaggerUIVersion + `/swagger-ui-bundle.js" crossorigin="anonymous" referrerpolicy="no-referrer"></script>
+  <script>
+    window.ui = SwaggerUIBundle({ url: "/swagger.json", dom_id: "#swagger-ui" });
+  </script>
+</body>
+</html>`
+
+// GET /swagger.json — публичный, сгенерированная OpenAPI-спецификация (пакет docs)
+func SwaggerJSON(readDoc func() string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json; charset=utf-8", []byte(readDoc()))
+	}
+}
+
+// GET /docs — только в debug (app.mode), Swagger UI поверх /swagger.json
+func SwaggerUI(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(swaggerUIPage))
+}
@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gsrlabs/micro-blog-hub/auth-service/docs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSwaggerJSON(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.GET("/swagger.json", SwaggerJSON(docs.SwaggerInfo.ReadDoc))
+	r.GET("/docs", SwaggerUI)
+
+	w := performRequest(r, http.MethodGet, "/swagger.json", "", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var spec struct {
+		Paths map[string]map[string]any `json:"paths"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &spec))
+
+	// Если маршрут добавили, а аннотацию забыли - make swagger
+	for path, method := range map[string]string{
+		"/auth/signup":   "post",
+		"/auth/signin":   "post",
+		"/auth/logout":   "post",
+		"/user/profile":  "get",
+		"/users":         "get",
+		"/users/{id}":    "delete",
+		"/user/password": "put",
+	} {
+		assert.Contains(t, spec.Paths[path], method, path)
+	}
+
+	w = performRequest(r, http.MethodGet, "/docs", "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "/swagger.json")
+	assert.Contains(t, w.Body.String(), "swagger-ui-dist@"+swaggerUIVersion+"/")
+	assert.NotContains(t, w.Body.String(), "swagger-ui-dist@5/", "version must be pinned")
+}
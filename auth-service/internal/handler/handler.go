@@ -1,7 +1,9 @@
 package handler
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"time"
@@ -11,56 +13,238 @@ import (
 	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/model"
 	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/repository"
 	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/service"
+	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/storage"
 	"go.uber.org/zap"
 )
 
+// StatusClientClosedRequest - нестандартный код (как в nginx): клиент закрыл соединение, не дождавшись ответа
+const StatusClientClosedRequest = 499
+
 type AuthHandler struct {
-	service            service.AuthService
-	logger             *zap.Logger
-	validator          *model.Validator
-	appMode            string
-	secret             string
-	jwtExpirationHours time.Duration
+	service         service.AuthService
+	logger          *zap.Logger
+	validator       *model.Validator
+	appMode         string
+	secret          string
+	jwtTTL          time.Duration
+	audience        string
+	previousSecrets []string
+	signupAutoLogin bool
+	tokenSource     string
+	avatars         storage.StorageProvider
+	avatarURLTTL    time.Duration
+	singleSession   bool
+	// leeway - допуск на расхождение часов реплик при проверке exp и nbf (jwt.leeway)
+	leeway time.Duration
+}
+
+// Config - настройки AuthHandler из конфига сервиса; нулевые значения - поведение по умолчанию
+type Config struct {
+	AppMode string
+	Secret  string
+	JWTTTL  time.Duration
+	// Audience - aud выпускаемых токенов; пусто - не проверяется
+	Audience string
+	// PreviousSecrets - прежние ключи подписи, которыми еще принимаются токены (ротация)
+	PreviousSecrets []string
+	SignupAutoLogin bool
+	// TokenSource - одно из config.TokenSources; пусто - как both-cookie-first
+	TokenSource      string
+	PasswordMaxBytes int
+	EmailMode        string
+	UsernamePattern  string
+	// Avatars - хранилище аватаров (storage.backend)
+	Avatars       storage.StorageProvider
+	AvatarURLTTL  time.Duration
+	SingleSession bool
+	// Leeway - допуск на расхождение часов реплик при проверке exp и nbf (jwt.leeway)
+	Leeway time.Duration
 }
 
-func NewAuthHandler(
-	s service.AuthService,
-	logger *zap.Logger,
-	appMode string,
-	secret string,
-	jwtExpirationHours time.Duration) *AuthHandler {
+func NewAuthHandler(s service.AuthService, cfg Config, logger *zap.Logger) *AuthHandler {
 	return &AuthHandler{
-		service:            s,
-		logger:             logger,
-		validator:          model.NewValidator(), // Инициализируем
-		appMode:            appMode,
-		secret:             secret,
-		jwtExpirationHours: jwtExpirationHours,
+		service:         s,
+		logger:          logger,
+		validator:       model.NewValidator(cfg.PasswordMaxBytes, cfg.EmailMode, cfg.UsernamePattern), // Инициализируем
+		appMode:         cfg.AppMode,
+		secret:          cfg.Secret,
+		jwtTTL:          cfg.JWTTTL,
+		audience:        cfg.Audience,
+		previousSecrets: cfg.PreviousSecrets,
+		signupAutoLogin: cfg.SignupAutoLogin,
+		tokenSource:     cfg.TokenSource,
+		avatars:         cfg.Avatars,
+		avatarURLTTL:    cfg.AvatarURLTTL,
+		singleSession:   cfg.SingleSession,
+		leeway:          cfg.Leeway,
 	}
 }
 
-// POST /auth/signup
+// POST /auth/token/introspect — только для внутренних сервисов (заголовок X-Service-Secret)
+// Невалидный или просроченный токен - это не ошибка, а {"active": false} с кодом 200
+//
+// @Summary      Проверка токена для внутренних сервисов
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        X-Service-Secret  header  string                    true  "общий секрет сервисов"
+// @Param        request           body    model.IntrospectRequest   true  "токен"
+// @Success      200  {object}  model.IntrospectResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Router       /auth/token/introspect [post]
+func (h *AuthHandler) Introspect(c *gin.Context) {
+	var req model.IntrospectRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "token is required"})
+		return
+	}
+
+	claims, err := h.parseToken(req.Token)
+	if err != nil {
+		c.JSON(http.StatusOK, model.IntrospectResponse{Active: false})
+		return
+	}
+	if err := h.checkSession(c, claims); err != nil {
+		if errors.Is(err, errSessionRevoked) {
+			c.JSON(http.StatusOK, model.IntrospectResponse{Active: false})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	resp := model.IntrospectResponse{
+		Active:   true,
+		UserID:   claims.UserID.String(),
+		Username: claims.Username,
+		Role:     claims.Role,
+	}
+	if claims.ExpiresAt != nil {
+		resp.Exp = claims.ExpiresAt.Unix()
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// POST /auth/users/lookup — только для внутренних сервисов (заголовок X-Service-Secret)
+// Публичные данные пользователей по id и/или username (до model.MaxLookupUsers вместе за раз);
+// неизвестные в ответ не попадают
+//
+// @Summary      Пользователи по списку id или username для внутренних сервисов
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        X-Service-Secret  header  string                    true  "общий секрет сервисов"
+// @Param        request           body    model.LookupUsersRequest  true  "id и/или username пользователей"
+// @Success      200  {object}  model.LookupUsersResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /auth/users/lookup [post]
+func (h *AuthHandler) LookupUsers(c *gin.Context) {
+	var req model.LookupUsersRequest
+	if err := c.ShouldBindJSON(&req); err != nil || len(req.IDs)+len(req.Usernames) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ids or usernames are required"})
+		return
+	}
+	if len(req.IDs)+len(req.Usernames) > model.MaxLookupUsers {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("at most %d ids and usernames per request", model.MaxLookupUsers)})
+		return
+	}
+
+	ids := make([]uuid.UUID, 0, len(req.IDs))
+	for _, raw := range req.IDs {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id format"})
+			return
+		}
+		ids = append(ids, id)
+	}
+
+	users, err := h.service.LookupUsers(c.Request.Context(), ids, req.Usernames)
+	if err != nil {
+		h.handlerLogger(c).Error("failed to look up users", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.LookupUsersResponse{Items: users})
+}
+
+// POST /auth/signup?autologin=true|false — публичный
+// С autologin (по умолчанию - security.signup_autologin) сразу ставит cookie и возвращает токен, как signin
+//
+// @Summary      Регистрация
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        autologin  query     bool                     false  "сразу выполнить вход"
+// @Param        request    body      model.CreateUserRequest  true   "новый пользователь"
+// @Success      201        {object}  SignUpResponse
+// @Header       201        {string}  Location  "адрес созданного пользователя"
+// @Failure      400        {object}  ValidationErrorResponse
+// @Failure      409        {object}  ErrorResponse
+// @Failure      413        {object}  ErrorResponse
+// @Failure      500        {object}  ErrorResponse
+// @Router       /auth/signup [post]
 func (h *AuthHandler) SignUp(c *gin.Context) {
+	autoLogin := h.signupAutoLogin
+	if raw := c.Query("autologin"); raw != "" {
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "autologin must be true or false"})
+			return
+		}
+		autoLogin = parsed
+	}
+
 	var req model.CreateUserRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		// WARN: Ошибка валидации - это не ошибка сервера, это ошибка клиента
-		h.logger.Warn("Failed to bind user JSON",
+		h.handlerLogger(c).Warn("Failed to bind user JSON",
 			zap.String("ip", c.ClientIP()),
 			zap.Error(err),
 		)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		respondBindError(c, err)
 		return
 	}
 
+	req.Normalize()
 	if err := h.validator.ValidateStruct(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "validation failed", "details": err.Error()})
+		respondValidationError(c, err)
 		return
 	}
 
-	id, err := h.service.Register(c.Request.Context(), &req)
+	var (
+		id    uuid.UUID
+		token string
+		err   error
+	)
+	if autoLogin {
+		id, token, err = h.service.RegisterAndLogin(c.Request.Context(), &req)
+	} else {
+		id, err = h.service.Register(c.Request.Context(), &req)
+	}
 	if err != nil {
+		if h.abortIfCanceled(c, err) {
+			return
+		}
+		if errors.Is(err, service.ErrDisposableEmail) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, repository.ErrDuplicateUsername) {
+			c.JSON(http.StatusConflict, gin.H{"error": "username already taken"})
+			return
+		}
+		if errors.Is(err, repository.ErrDuplicateEmail) {
+			c.JSON(http.StatusConflict, gin.H{"error": "email already taken"})
+			return
+		}
 		// ERROR: Что-то сломалось внутри (БД, логика)
-		h.logger.Error("Failed to create user service",
+		h.handlerLogger(c).Error("Failed to create user service",
 			zap.String("username", req.Username), // Логируем контекст!
 			zap.String("email", req.Email),
 			zap.Error(err),
@@ -70,54 +254,137 @@ func (h *AuthHandler) SignUp(c *gin.Context) {
 	}
 
 	// INFO: Успешная операция
-	h.logger.Info("User created successfully",
+	h.handlerLogger(c).Info("User created successfully",
 		zap.String("user_id", id.String()),
 	)
 
+	// Location - где теперь живет созданный ресурс (REST-конвенция для 201)
+	c.Header("Location", c.GetString("basePath")+"/users/"+id.String())
+
+	if autoLogin {
+		h.setAuthCookie(c, token)
+		c.JSON(http.StatusCreated, gin.H{"id": id, "message": "user registered", "token": token, "expires_in": h.tokenTTLSeconds()})
+		return
+	}
+
 	c.JSON(http.StatusCreated, gin.H{"id": id, "message": "user registered"})
 }
 
-// POST /auth/signin
+// GET /auth/available?username=... или ?email=... — публичный, с лимитом на IP
+// Нормализация та же, что при регистрации, поэтому ответ совпадает с тем, что примет signup
+//
+// @Summary      Свободен ли username или email
+// @Tags         auth
+// @Produce      json
+// @Param        username  query     string  false  "username"
+// @Param        email     query     string  false  "email"
+// @Success      200       {object}  model.AvailabilityResponse
+// @Failure      400       {object}  ValidationErrorResponse
+// @Failure      429       {object}  ErrorResponse
+// @Failure      500       {object}  ErrorResponse
+// @Router       /auth/available [get]
+func (h *AuthHandler) Available(c *gin.Context) {
+	var query model.AvailabilityQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid query"})
+		return
+	}
+
+	query.Normalize()
+	if err := h.validator.ValidateStruct(&query); err != nil {
+		respondValidationError(c, err)
+		return
+	}
+
+	available, err := h.service.CheckAvailability(c.Request.Context(), &query)
+	if err != nil {
+		if h.abortIfCanceled(c, err) {
+			return
+		}
+		h.handlerLogger(c).Error("failed to check availability", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.AvailabilityResponse{Available: available})
+}
+
+// POST /auth/signin — публичный
+//
+// @Summary      Вход
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request  body      model.LoginRequest  true  "email и пароль"
+// @Success      200      {object}  TokenResponse
+// @Failure      400      {object}  ValidationErrorResponse
+// @Failure      401      {object}  ErrorResponse
+// @Failure      413      {object}  ErrorResponse
+// @Router       /auth/signin [post]
 func (h *AuthHandler) SignIn(c *gin.Context) {
 	var req model.LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		respondBindError(c, err)
 		return
 	}
 
 	// Валидация тоже нужна, чтобы отсеять пустые email/пароли сразу
+	req.Normalize()
 	if err := h.validator.ValidateStruct(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "validation failed"})
+		respondValidationError(c, err)
 		return
 	}
 
 	token, err := h.service.Login(c.Request.Context(), &req)
 	if err != nil {
+		if h.abortIfCanceled(c, err) {
+			return
+		}
 		// Обрати внимание: мы возвращаем 401 Unauthorized
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid email or password"})
 		return
 	}
 
+	h.setAuthCookie(c, token)
+
+	// Возвращаем токен еще и в JSON (удобно для мобильных приложений)
+	c.JSON(http.StatusOK, gin.H{"token": token, "expires_in": h.tokenTTLSeconds()})
+}
+
+// setAuthCookie кладет токен в cookie - общий путь для signin и signup с autologin
+func (h *AuthHandler) setAuthCookie(c *gin.Context, token string) {
 	// Установка Cookie
 	// HttpOnly: true (JS не имеет доступа, защита от XSS)
 	// Secure: true (только HTTPS, включаем в проде)
 	isSecure := h.appMode == "release"
 
 	c.SetCookie(
-		"token",                   // name
-		token,                     // value
-		int(h.jwtExpirationHours), // maxAge (в секундах)
-		"/",                       // path
-		"",                        // domain (пустой = текущий хост)
-		isSecure,                  // secure
-		true,                      // httpOnly
+		"token",             // name
+		token,               // value
+		h.tokenTTLSeconds(), // maxAge (в секундах)
+		"/",                 // path
+		"",                  // domain (пустой = текущий хост)
+		isSecure,            // secure
+		true,                // httpOnly
 	)
+}
 
-	// Возвращаем токен еще и в JSON (удобно для мобильных приложений)
-	c.JSON(http.StatusOK, gin.H{"token": token})
+// tokenTTLSeconds - срок жизни выдаваемого токена в секундах (cookie maxAge и expires_in)
+func (h *AuthHandler) tokenTTLSeconds() int {
+	ttl := h.jwtTTL
+	if ttl <= 0 {
+		ttl = service.DefaultTokenTTL // то же значение берет сервис
+	}
+	return int(ttl / time.Second)
 }
 
-// POST /auth/logout
+// POST /auth/logout — публичный
+//
+// @Summary      Выход (удаляет cookie с токеном)
+// @Tags         auth
+// @Produce      json
+// @Success      200  {object}  MessageResponse
+// @Router       /auth/logout [post]
 func (h *AuthHandler) Logout(c *gin.Context) {
 	// Чтобы удалить куку, нужно отправить её с тем же именем,
 	// но с MaxAge = -1 (истекшая)
@@ -126,6 +393,47 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "successfully logged out"})
 }
 
+// POST /auth/password/check — публичный
+// Публичный эндпоинт для индикатора надежности пароля. Пароль НИКОГДА не логируем.
+//
+// @Summary      Оценка надежности пароля
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request  body      model.PasswordCheckRequest  true  "пароль и необязательные username/email"
+// @Success      200      {object}  model.PasswordStrength
+// @Failure      400      {object}  ValidationErrorResponse
+// @Router       /auth/password/check [post]
+func (h *AuthHandler) CheckPassword(c *gin.Context) {
+	var req model.PasswordCheckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	if err := h.validator.ValidateStruct(&req); err != nil {
+		respondValidationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, h.validator.PasswordStrength(req.Password, req.Username, req.Email))
+}
+
+// GET /user/profile — авторизованный пользователь, только свой профиль
+//
+// @Summary      Свой профиль
+// @Tags         user
+// @Produce      json
+// @Security     BearerAuth
+// @Param        tz                 query     string  false  "IANA-часовой пояс для дат"
+// @Param        If-None-Match      header    string  false  "ETag из прошлого ответа"
+// @Param        If-Modified-Since  header    string  false  "Last-Modified из прошлого ответа"
+// @Success      200  {object}  model.UserResponse
+// @Success      304  "Профиль не изменился"
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Router       /user/profile [get]
 func (h *AuthHandler) GetProfile(c *gin.Context) {
 	// Достаем ID, который положил Middleware
 	userID, exists := c.Get("userID")
@@ -137,62 +445,130 @@ func (h *AuthHandler) GetProfile(c *gin.Context) {
 	// Приводим интерфейс к типу uuid.UUID
 	id := userID.(uuid.UUID)
 
+	loc, err := responseLocation(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	// Ищем в базе
 	user, err := h.service.GetByID(c.Request.Context(), id)
 	if err != nil {
+		if h.abortIfCanceled(c, err) {
+			return
+		}
 		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
 		return
 	}
 
-	c.JSON(http.StatusOK, model.ToResponse(user))
+	// Фронтенд запрашивает профиль почти на каждой странице: неизмененный отдаем как 304
+	resp := h.userResponse(c, user, loc)
+	respondConditionalJSON(c, resp, user.UpdatedAt, resp.AvatarURL == "")
 }
 
+// GET /users/:id — только admin
+//
+// @Summary      Пользователь по ID
+// @Tags         users
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true   "UUID пользователя"
+// @Param        tz   query     string  false  "IANA-часовой пояс для дат"
+// @Success      200  {object}  model.UserResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /users/{id} [get]
 func (h *AuthHandler) GetByID(c *gin.Context) {
-	idStr := c.Param("id")
+	// 1. Валидация формата UUID
+	uid, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
 
-	// 1. Валидация формата UUID (используем ту же библиотеку, что и в моделях)
-	uid, err := uuid.Parse(idStr)
+	loc, err := responseLocation(c)
 	if err != nil {
-		h.logger.Warn("invalid uuid format", zap.String("id", idStr))
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id format"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
 	// 2. Передаем уже типизированный uuid.UUID в сервис
 	user, err := h.service.GetByID(c.Request.Context(), uid)
 	if err != nil {
+		if h.abortIfCanceled(c, err) {
+			return
+		}
 		// Проверяем, это ошибка "не найдено" или системный сбой
 		if errors.Is(err, repository.ErrNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
 			return
 		}
 
-		h.logger.Error("failed to get user", zap.Error(err))
+		h.handlerLogger(c).Error("failed to get user", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
 		return
 	}
 
-	c.JSON(http.StatusOK, model.ToResponse(user))
+	c.JSON(http.StatusOK, h.userResponse(c, user, loc))
 }
 
+// GET /users/search?email= — только admin, иначе можно проверять существование чужих email
+//
+// @Summary      Пользователь по email
+// @Tags         users
+// @Produce      json
+// @Security     BearerAuth
+// @Param        email  query     string  true   "email"
+// @Param        tz     query     string  false  "IANA-часовой пояс для дат"
+// @Success      200    {object}  model.UserResponse
+// @Failure      400    {object}  ErrorResponse
+// @Failure      401    {object}  ErrorResponse
+// @Failure      403    {object}  ErrorResponse
+// @Failure      404    {object}  ErrorResponse
+// @Router       /users/search [get]
 func (h *AuthHandler) GetByEmail(c *gin.Context) {
-	email := c.Query("email") // Берем email из параметров строки ?email=...
+	email := model.NormalizeEmail(c.Query("email")) // Берем email из параметров строки ?email=...
 	if email == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "email is required"})
 		return
 	}
 
+	loc, err := responseLocation(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	user, err := h.service.GetByEmail(c.Request.Context(), email)
 	if err != nil {
-		h.logger.Warn("user not found", zap.String("email", email), zap.Error(err))
+		if h.abortIfCanceled(c, err) {
+			return
+		}
+		h.handlerLogger(c).Warn("user not found", zap.String("email", email), zap.Error(err))
 		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
 		return
 	}
 
-	c.JSON(http.StatusOK, model.ToResponse(user))
+	c.JSON(http.StatusOK, h.userResponse(c, user, loc))
 }
 
-// PUT /user/profile
+// PUT /user/profile — авторизованный пользователь
+//
+// @Summary      Сменить username
+// @Tags         user
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      model.ChangeProfileRequest  true  "новый username"
+// @Success      200      {object}  MessageResponse
+// @Failure      400      {object}  ValidationErrorResponse
+// @Failure      401      {object}  ErrorResponse
+// @Failure      404      {object}  ErrorResponse
+// @Failure      409      {object}  ErrorResponse
+// @Failure      500      {object}  ErrorResponse
+// @Router       /user/profile [put]
 func (h *AuthHandler) ChangeProfile(c *gin.Context) {
 	userIDVal, exists := c.Get("userID")
 	if !exists {
@@ -203,17 +579,21 @@ func (h *AuthHandler) ChangeProfile(c *gin.Context) {
 
 	var req model.ChangeProfileRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		respondBindError(c, err)
 		return // Добавили return!
 	}
 
+	req.Normalize()
 	if err := h.validator.ValidateStruct(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "validation failed", "details": err.Error()})
+		respondValidationError(c, err)
 		return
 	}
 
 	err := h.service.ChangeProfile(c.Request.Context(), userID, &req)
 	if err != nil {
+		if h.abortIfCanceled(c, err) {
+			return
+		}
 		if errors.Is(err, repository.ErrDuplicateUsername) {
 			c.JSON(http.StatusConflict, gin.H{"error": "username already taken"})
 			return
@@ -222,6 +602,10 @@ func (h *AuthHandler) ChangeProfile(c *gin.Context) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
 			return
 		}
+		if errors.Is(err, repository.ErrVersionConflict) {
+			c.JSON(http.StatusConflict, gin.H{"error": "user was modified concurrently, reload and retry"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to change profile"})
 		return
 	}
@@ -229,7 +613,87 @@ func (h *AuthHandler) ChangeProfile(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "profile updated successfully"})
 }
 
-// PUT /user/email
+// PATCH /user/profile — авторизованный пользователь, меняет только присланные поля
+//
+// @Summary      Частично изменить профиль
+// @Tags         user
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      model.PatchProfileRequest  true  "любое подмножество полей"
+// @Success      200      {object}  MessageResponse
+// @Failure      400      {object}  ValidationErrorResponse
+// @Failure      401      {object}  ErrorResponse
+// @Failure      404      {object}  ErrorResponse
+// @Failure      409      {object}  ErrorResponse
+// @Failure      500      {object}  ErrorResponse
+// @Router       /user/profile [patch]
+func (h *AuthHandler) PatchProfile(c *gin.Context) {
+	userIDVal, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID := userIDVal.(uuid.UUID)
+
+	var req model.PatchProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	if req.Patch().IsEmpty() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "no fields to update"})
+		return
+	}
+
+	req.Normalize()
+	if err := h.validator.ValidateStruct(&req); err != nil {
+		respondValidationError(c, err)
+		return
+	}
+
+	err := h.service.PatchProfile(c.Request.Context(), userID, &req)
+	if err != nil {
+		if h.abortIfCanceled(c, err) {
+			return
+		}
+		if errors.Is(err, repository.ErrDuplicateUsername) {
+			c.JSON(http.StatusConflict, gin.H{"error": "username already taken"})
+			return
+		}
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		}
+		if errors.Is(err, repository.ErrVersionConflict) {
+			c.JSON(http.StatusConflict, gin.H{"error": "user was modified concurrently, reload and retry"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to change profile"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "profile updated successfully"})
+}
+
+// PUT /user/email — авторизованный пользователь
+// Тело: {"new_email": "...", "current_password": "..."} - без верного текущего пароля 403
+//
+// @Summary      Сменить email
+// @Tags         user
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      model.ChangeEmailRequest  true  "новый email и текущий пароль"
+// @Success      200      {object}  MessageResponse
+// @Failure      400      {object}  ValidationErrorResponse
+// @Failure      401      {object}  ErrorResponse
+// @Failure      403      {object}  ErrorResponse
+// @Failure      404      {object}  ErrorResponse
+// @Failure      409      {object}  ErrorResponse
+// @Failure      500      {object}  ErrorResponse
+// @Router       /user/email [put]
 func (h *AuthHandler) ChangeEmail(c *gin.Context) {
 	userIDVal, exists := c.Get("userID")
 	if !exists {
@@ -240,17 +704,29 @@ func (h *AuthHandler) ChangeEmail(c *gin.Context) {
 
 	var req model.ChangeEmailRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		respondBindError(c, err)
 		return // Добавили return!
 	}
 
+	req.Normalize()
 	if err := h.validator.ValidateStruct(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "validation failed", "details": err.Error()})
+		respondValidationError(c, err)
 		return
 	}
 
 	err := h.service.ChangeEmail(c.Request.Context(), userID, &req)
 	if err != nil {
+		if h.abortIfCanceled(c, err) {
+			return
+		}
+		if errors.Is(err, service.ErrDisposableEmail) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, service.ErrWrongPassword) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "wrong password"})
+			return
+		}
 		if errors.Is(err, repository.ErrDuplicateEmail) {
 			c.JSON(http.StatusConflict, gin.H{"error": "email already taken"})
 			return
@@ -259,6 +735,10 @@ func (h *AuthHandler) ChangeEmail(c *gin.Context) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
 			return
 		}
+		if errors.Is(err, repository.ErrVersionConflict) {
+			c.JSON(http.StatusConflict, gin.H{"error": "user was modified concurrently, reload and retry"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to change email"})
 		return
 	}
@@ -266,7 +746,19 @@ func (h *AuthHandler) ChangeEmail(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "email updated successfully"})
 }
 
-// PUT /user/password
+// PUT /user/password — авторизованный пользователь
+//
+// @Summary      Сменить пароль
+// @Tags         user
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      model.ChangePasswordRequest  true  "старый и новый пароль"
+// @Success      200      {object}  MessageResponse
+// @Failure      400      {object}  ValidationErrorResponse
+// @Failure      401      {object}  ErrorResponse
+// @Failure      500      {object}  ErrorResponse
+// @Router       /user/password [put]
 func (h *AuthHandler) ChangePassword(c *gin.Context) {
 	// Достаем ID пользователя из контекста (положил AuthMiddleware)
 	userIDVal, exists := c.Get("userID")
@@ -278,18 +770,21 @@ func (h *AuthHandler) ChangePassword(c *gin.Context) {
 
 	var req model.ChangePasswordRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		respondBindError(c, err)
 		return
 	}
 
 	if err := h.validator.ValidateStruct(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "validation failed", "details": err.Error()})
+		respondValidationError(c, err)
 		return
 	}
 
 	// Вызываем сервис
 	err := h.service.ChangePassword(c.Request.Context(), userID, &req)
 	if err != nil {
+		if h.abortIfCanceled(c, err) {
+			return
+		}
 		if err.Error() == "invalid old password" {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "wrong old password"})
 			return
@@ -301,6 +796,22 @@ func (h *AuthHandler) ChangePassword(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "password updated successfully"})
 }
 
+// DELETE /user — авторизованный пользователь, удаляет свой аккаунт
+// Тело: {"password": "..."} - текущий пароль, иначе 403
+//
+// @Summary      Удалить свой аккаунт
+// @Tags         user
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      model.DeleteAccountRequest  true  "текущий пароль"
+// @Success      200      {object}  MessageResponse
+// @Failure      400      {object}  ValidationErrorResponse
+// @Failure      401      {object}  ErrorResponse
+// @Failure      403      {object}  ErrorResponse
+// @Failure      404      {object}  ErrorResponse
+// @Failure      500      {object}  ErrorResponse
+// @Router       /user [delete]
 func (h *AuthHandler) Delete(c *gin.Context) {
 	// Достаем ID пользователя из контекста (положил AuthMiddleware)
 	userIDVal, exists := c.Get("userID")
@@ -310,15 +821,33 @@ func (h *AuthHandler) Delete(c *gin.Context) {
 	}
 	userID := userIDVal.(uuid.UUID)
 
-	err := h.service.Delete(c.Request.Context(), userID)
+	var req model.DeleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	if err := h.validator.ValidateStruct(&req); err != nil {
+		respondValidationError(c, err)
+		return
+	}
+
+	err := h.service.DeleteSelf(c.Request.Context(), userID, req.Password)
 	if err != nil {
+		if h.abortIfCanceled(c, err) {
+			return
+		}
+		if errors.Is(err, service.ErrWrongPassword) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "wrong password"})
+			return
+		}
 		// Проверяем, это ошибка "не найдено" или системный сбой
 		if errors.Is(err, repository.ErrNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
 			return
 		}
 
-		h.logger.Error("failed to delete user", zap.Error(err))
+		h.handlerLogger(c).Error("failed to delete user", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
 		return
 	}
@@ -326,15 +855,318 @@ func (h *AuthHandler) Delete(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "user has been deleted successfully"})
 }
 
+// DELETE /users/:id — только admin, пароль пользователя не требуется
+//
+// @Summary      Удалить пользователя (admin)
+// @Tags         users
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "UUID пользователя"
+// @Success      200  {object}  MessageResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /users/{id} [delete]
+func (h *AuthHandler) DeleteByID(c *gin.Context) {
+	uid, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.service.Delete(c.Request.Context(), uid); err != nil {
+		if h.abortIfCanceled(c, err) {
+			return
+		}
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		}
+
+		h.handlerLogger(c).Error("failed to delete user", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	adminID, _ := c.Get("userID")
+	h.handlerLogger(c).Info("user deleted by admin", zap.String("user_id", uid.String()), zap.Any("admin_id", adminID))
+	c.JSON(http.StatusOK, gin.H{"message": "user has been deleted successfully"})
+}
+
+// POST /users/:id/force-password-reset — только admin, лимит limits.force_reset_per_hour на администратора.
+// Возвращает одноразовый токен сброса; передать его пользователю - забота администратора
+//
+// @Summary      Выдать токен сброса пароля (admin)
+// @Tags         users
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "UUID пользователя"
+// @Success      201  {object}  model.PasswordResetToken
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /users/{id}/force-password-reset [post]
+func (h *AuthHandler) ForcePasswordReset(c *gin.Context) {
+	uid, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	adminIDVal, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	adminID := adminIDVal.(uuid.UUID)
+
+	reset, err := h.service.IssuePasswordReset(c.Request.Context(), uid, adminID)
+	if err != nil {
+		if h.abortIfCanceled(c, err) {
+			return
+		}
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		}
+
+		h.handlerLogger(c).Error("failed to issue password reset", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	h.handlerLogger(c).Info("password reset forced by admin", zap.String("user_id", uid.String()), zap.String("admin_id", adminID.String()))
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusCreated, reset)
+}
+
+// POST /auth/password/reset — публичный, новый пароль по токену из force-password-reset
+//
+// @Summary      Сбросить пароль по токену
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request  body      model.ResetPasswordRequest  true  "токен и новый пароль"
+// @Success      200      {object}  MessageResponse
+// @Failure      400      {object}  ValidationErrorResponse
+// @Failure      500      {object}  ErrorResponse
+// @Router       /auth/password/reset [post]
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req model.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	if err := h.validator.ValidateStruct(&req); err != nil {
+		respondValidationError(c, err)
+		return
+	}
+
+	if err := h.service.ResetPassword(c.Request.Context(), &req); err != nil {
+		if h.abortIfCanceled(c, err) {
+			return
+		}
+		if errors.Is(err, repository.ErrInvalidResetToken) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or expired reset token"})
+			return
+		}
+
+		h.handlerLogger(c).Error("failed to reset password", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to reset password"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "password has been reset successfully"})
+}
+
+// GET /users?limit=&offset=&from=&to=&sort= — публичный, email видит только admin (токен необязателен)
+// from/to - необязательные границы created_at в формате RFC3339
+// sort - created_at, username или email; префикс "-" означает сортировку по убыванию
+// Ответ несет X-Total-Count и Link с соседними страницами.
+// Accept: application/x-ndjson - все пользователи потоком, по одному на строку (только admin,
+// без фильтров и пагинации)
+//
+// @Summary      Список пользователей
+// @Tags         users
+// @Produce      json
+// @Produce      application/x-ndjson
+// @Param        limit   query     int     false  "размер страницы (по умолчанию pagination.default_limit)"
+// @Param        offset  query     int     false  "смещение"         default(0)
+// @Param        from    query     string  false  "created_at от (RFC3339)"
+// @Param        to      query     string  false  "created_at до (RFC3339)"
+// @Param        sort    query     string  false  "created_at, username или email; '-' - по убыванию"
+// @Param        tz      query     string  false  "IANA-часовой пояс для дат"
+// @Success      200     {array}   model.UsersResponse
+// @Header       200     {integer} X-Total-Count  "всего пользователей под фильтром"
+// @Header       200     {string}  Link           "ссылки first/prev/next/last (RFC 8288)"
+// @Failure      400     {object}  ErrorResponse
+// @Failure      403     {object}  ErrorResponse
+// @Failure      500     {object}  ErrorResponse
+// @Router       /users [get]
 func (h *AuthHandler) GetUsers(c *gin.Context) {
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	// Без limit (0) сервис подставит pagination.default_limit
+	limit, _ := strconv.Atoi(c.Query("limit"))
 	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
 
-	users, err := h.service.GetUsers(c.Request.Context(), limit, offset)
+	filter, err := parseUsersFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	loc, err := responseLocation(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if wantsNDJSON(c) {
+		if filter != (model.UsersFilter{}) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "filters and sorting are not supported for " + mimeNDJSON})
+			return
+		}
+		h.streamUsers(c, loc)
+		return
+	}
+
+	page, err := h.service.ListUsers(c.Request.Context(), filter, limit, offset)
 	if err != nil {
+		if h.abortIfCanceled(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch users"})
 		return
 	}
 
-	c.JSON(http.StatusOK, model.ToUsersResponse(users))
+	resp := model.ToUsersResponseIn(page.Users, loc)
+	if c.GetString("role") != model.RoleAdmin {
+		for i := range resp {
+			resp[i].Email = ""
+		}
+	}
+
+	setPaginationHeaders(c, page)
+	c.JSON(http.StatusOK, resp)
+}
+
+// responseLocation - часовой пояс для дат в ответе: ?tz= или заголовок Accept-Timezone
+// (IANA-имя, например Europe/Moscow). По умолчанию UTC.
+func responseLocation(c *gin.Context) (*time.Location, error) {
+	tz := c.Query("tz")
+	if tz == "" {
+		tz = c.GetHeader("Accept-Timezone")
+	}
+	if tz == "" {
+		return time.UTC, nil
+	}
+
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q", tz)
+	}
+	return loc, nil
+}
+
+// parseUsersFilter разбирает необязательные query-параметры фильтрации списка пользователей
+func parseUsersFilter(c *gin.Context) (model.UsersFilter, error) {
+	var filter model.UsersFilter
+
+	if raw := c.Query("from"); raw != "" {
+		from, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return filter, fmt.Errorf("invalid 'from' timestamp, expected RFC3339")
+		}
+		filter.CreatedFrom = &from
+	}
+
+	if raw := c.Query("to"); raw != "" {
+		to, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return filter, fmt.Errorf("invalid 'to' timestamp, expected RFC3339")
+		}
+		filter.CreatedTo = &to
+	}
+
+	if filter.CreatedFrom != nil && filter.CreatedTo != nil && filter.CreatedFrom.After(*filter.CreatedTo) {
+		return filter, fmt.Errorf("'from' must not be after 'to'")
+	}
+
+	if raw := c.Query("sort"); raw != "" {
+		field, desc, err := model.ParseUsersSort(raw)
+		if err != nil {
+			return filter, err
+		}
+		filter.SortBy = field
+		filter.SortDesc = desc
+	}
+
+	return filter, nil
+}
+
+// respondValidationError отдает 400 с разбивкой по полям:
+// {"error":"validation failed","details":"...","errors":[{"field":"email","rule":"strict_email"}]}.
+// post-service отдает тот же формат (handler/errors.go) - менять его нужно в обоих сервисах
+func respondValidationError(c *gin.Context, err error) {
+	fields := make([]model.FieldError, 0)
+
+	var validationErr *model.ValidationError
+	if errors.As(err, &validationErr) {
+		fields = validationErr.Fields
+	}
+
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error":   "validation failed",
+		"details": err.Error(),
+		"errors":  fields,
+	})
+}
+
+// respondBindError - тело не разобралось: 413, если его обрезал BodyLimit, иначе 400
+func respondBindError(c *gin.Context, err error) {
+	if IsBodyTooLarge(err) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "request body too large"})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+}
+
+// abortIfCanceled проверяет, не отключился ли клиент. Отмена запроса - не ошибка сервера,
+// поэтому вместо 500 и error-лога отдаем 499 и пишем в info.
+// Истекший дедлайн RequestTimeout - тоже не 500, а 504.
+// Заняты все слоты database.max_concurrent_queries - 503 с Retry-After.
+func (h *AuthHandler) abortIfCanceled(c *gin.Context, err error) bool {
+	if errors.Is(err, repository.ErrDatabaseBusy) {
+		h.handlerLogger(c).Warn("database busy",
+			zap.String("method", c.Request.Method),
+			zap.String("path", c.Request.URL.Path),
+		)
+		c.Header("Retry-After", "1")
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "database busy"})
+		return true
+	}
+
+	if isRequestTimeout(c) {
+		h.handlerLogger(c).Warn("request timed out",
+			zap.String("method", c.Request.Method),
+			zap.String("path", c.Request.URL.Path),
+			zap.Error(err),
+		)
+		c.AbortWithStatusJSON(http.StatusGatewayTimeout, gin.H{"error": "request timeout"})
+		return true
+	}
+
+	if !errors.Is(err, context.Canceled) && !errors.Is(c.Request.Context().Err(), context.Canceled) {
+		return false
+	}
+
+	h.handlerLogger(c).Info("client closed request",
+		zap.String("method", c.Request.Method),
+		zap.String("path", c.Request.URL.Path),
+	)
+	c.AbortWithStatus(StatusClientClosedRequest)
+	return true
 }
@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// healthCheckTimeout - health дергается оркестратором часто, долго ждать базу нельзя
+const healthCheckTimeout = 2 * time.Second
+
+// HealthChecker - то, от чего зависит готовность сервиса (repository.AuthRepository)
+type HealthChecker interface {
+	Ping(ctx context.Context) error
+}
+
+// GET /health — публичный, 503 если хранилище недоступно
+func Health(checker HealthChecker, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), healthCheckTimeout)
+		defer cancel()
+
+		if err := checker.Ping(ctx); err != nil {
+			logger.Error("health check failed", zap.Error(err))
+			c.JSON(http.StatusServiceUnavailable, gin.H{"status": "unavailable"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	}
+}
@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/repository/memory"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+type failingChecker struct{}
+
+func (failingChecker) Ping(ctx context.Context) error {
+	return errors.New("connection refused")
+}
+
+func TestHealth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Healthy - 200", func(t *testing.T) {
+		r := gin.New()
+		r.GET("/health", Health(memory.NewAuthRepository(), zap.NewNop()))
+
+		w := performRequest(r, http.MethodGet, "/health", "", nil)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"status":"ok"}`, w.Body.String())
+	})
+
+	t.Run("Storage Down - 503", func(t *testing.T) {
+		r := gin.New()
+		r.GET("/health", Health(failingChecker{}, zap.NewNop()))
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.JSONEq(t, `{"status":"unavailable"}`, w.Body.String())
+	})
+}
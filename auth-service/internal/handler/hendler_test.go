@@ -2,6 +2,7 @@ package handler
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
@@ -11,13 +12,18 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/config"
+	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/hasher"
 	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/model"
 	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/repository"
+	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/repository/memory"
+	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/service"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
 )
 
 // mockAuthService реализует service.AuthService интерфейс
@@ -30,6 +36,11 @@ func (m *mockAuthService) Register(ctx context.Context, req *model.CreateUserReq
 	return args.Get(0).(uuid.UUID), args.Error(1)
 }
 
+func (m *mockAuthService) RegisterAndLogin(ctx context.Context, req *model.CreateUserRequest) (uuid.UUID, string, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(uuid.UUID), args.String(1), args.Error(2)
+}
+
 func (m *mockAuthService) Login(ctx context.Context, req *model.LoginRequest) (string, error) {
 	args := m.Called(ctx, req)
 	return args.String(0), args.Error(1)
@@ -45,16 +56,78 @@ func (m *mockAuthService) GetByEmail(ctx context.Context, email string) (*model.
 	return args.Get(0).(*model.User), args.Error(1)
 }
 
+func (m *mockAuthService) CheckAvailability(ctx context.Context, query *model.AvailabilityQuery) (bool, error) {
+	args := m.Called(ctx, query)
+	return args.Bool(0), args.Error(1)
+}
+
 func (m *mockAuthService) ChangeProfile(ctx context.Context, id uuid.UUID, req *model.ChangeProfileRequest) error {
 	args := m.Called(ctx, id, req)
 	return args.Error(0)
 }
 
+func (m *mockAuthService) PatchProfile(ctx context.Context, id uuid.UUID, req *model.PatchProfileRequest) error {
+	args := m.Called(ctx, id, req)
+	return args.Error(0)
+}
+
 func (m *mockAuthService) ChangeEmail(ctx context.Context, id uuid.UUID, req *model.ChangeEmailRequest) error {
 	args := m.Called(ctx, id, req)
 	return args.Error(0)
 }
 
+func (m *mockAuthService) SetAvatar(ctx context.Context, id uuid.UUID, avatarKey string) error {
+	args := m.Called(ctx, id, avatarKey)
+	return args.Error(0)
+}
+
+func (m *mockAuthService) SessionActive(ctx context.Context, id uuid.UUID, sessionID string) (bool, error) {
+	args := m.Called(ctx, id, sessionID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockAuthService) IssuePasswordReset(ctx context.Context, userID, actorID uuid.UUID) (*model.PasswordResetToken, error) {
+	args := m.Called(ctx, userID, actorID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.PasswordResetToken), args.Error(1)
+}
+
+func (m *mockAuthService) ResetPassword(ctx context.Context, req *model.ResetPasswordRequest) error {
+	args := m.Called(ctx, req)
+	return args.Error(0)
+}
+
+func (m *mockAuthService) CreateAPIToken(ctx context.Context, userID uuid.UUID, req *model.CreateAPITokenRequest) (*model.CreatedAPIToken, error) {
+	args := m.Called(ctx, userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.CreatedAPIToken), args.Error(1)
+}
+
+func (m *mockAuthService) ListAPITokens(ctx context.Context, userID uuid.UUID) ([]*model.APIToken, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.APIToken), args.Error(1)
+}
+
+func (m *mockAuthService) RevokeAPIToken(ctx context.Context, userID, tokenID uuid.UUID) error {
+	args := m.Called(ctx, userID, tokenID)
+	return args.Error(0)
+}
+
+func (m *mockAuthService) AuthenticateAPIToken(ctx context.Context, token string) (*model.User, error) {
+	args := m.Called(ctx, token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.User), args.Error(1)
+}
+
 func (m *mockAuthService) ChangePassword(ctx context.Context, id uuid.UUID, req *model.ChangePasswordRequest) error {
 	args := m.Called(ctx, id, req)
 	return args.Error(0)
@@ -65,9 +138,37 @@ func (m *mockAuthService) Delete(ctx context.Context, id uuid.UUID) error {
 	return args.Error(0)
 }
 
-func (m *mockAuthService) GetUsers(ctx context.Context, limit, offset int) ([]*model.User, error) {
+func (m *mockAuthService) DeleteSelf(ctx context.Context, id uuid.UUID, password string) error {
+	args := m.Called(ctx, id, password)
+	return args.Error(0)
+}
+
+func (m *mockAuthService) GetUsers(ctx context.Context, limit, offset int) ([]*model.UserListItem, error) {
 	args := m.Called(ctx, limit, offset)
-	return args.Get(0).([]*model.User), args.Error(1)
+	return args.Get(0).([]*model.UserListItem), args.Error(1)
+}
+
+func (m *mockAuthService) LookupUsers(ctx context.Context, ids []uuid.UUID, usernames []string) ([]*model.UserSummary, error) {
+	args := m.Called(ctx, ids, usernames)
+	return args.Get(0).([]*model.UserSummary), args.Error(1)
+}
+
+func (m *mockAuthService) GetUsersFiltered(ctx context.Context, filter model.UsersFilter, limit, offset int) ([]*model.UserListItem, error) {
+	args := m.Called(ctx, filter, limit, offset)
+	return args.Get(0).([]*model.UserListItem), args.Error(1)
+}
+
+func (m *mockAuthService) ListUsers(ctx context.Context, filter model.UsersFilter, limit, offset int) (*model.UsersPage, error) {
+	args := m.Called(ctx, filter, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.UsersPage), args.Error(1)
+}
+
+func (m *mockAuthService) StreamUsers(ctx context.Context, fn func(*model.UserListItem) error) error {
+	args := m.Called(ctx, fn)
+	return args.Error(0)
 }
 
 // ----------------- HELPERS -----------------
@@ -88,7 +189,7 @@ func TestAuthHandler_SignUp(t *testing.T) {
 	mockSvc := &mockAuthService{}
 	logger := zap.NewNop()
 
-	h := NewAuthHandler(mockSvc, logger, "", "", 0)
+	h := NewAuthHandler(mockSvc, Config{}, logger)
 
 	r := gin.New()
 	r.POST("/signup", h.SignUp)
@@ -105,22 +206,53 @@ func TestAuthHandler_SignUp(t *testing.T) {
 
 	assert.Equal(t, http.StatusCreated, w.Code)
 	assert.Contains(t, w.Body.String(), "user registered")
+	assert.Equal(t, "/users/"+userID.String(), w.Header().Get("Location"))
 	mockSvc.AssertExpectations(t)
 }
 
+func TestAuthHandler_SignUp_LocationHonorsBasePath(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockSvc := &mockAuthService{}
+	h := NewAuthHandler(mockSvc, Config{}, zap.NewNop())
+
+	r := gin.New()
+	api := r.Group("/api/v1", BasePath("/api/v1"))
+	api.POST("/auth/signup", h.SignUp)
+
+	userID := uuid.New()
+	mockSvc.On("Register", mock.Anything, mock.Anything).Return(userID, nil)
+
+	body := `{"username":"test","email":"test@test.com","password":"password123"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/signup", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "/api/v1/users/"+userID.String(), w.Header().Get("Location"))
+}
+
 func TestAuthHandler_SignIn(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	mockSvc := &mockAuthService{}
 	logger := zap.NewNop()
 	cfg := &config.Config{JWT: config.JWTConfig{Secret: "secret", ExpirationHours: 1}}
-	h := NewAuthHandler(
-		mockSvc, 
-		logger, 
-		"", 
-		cfg.JWT.Secret,  
-		time.Duration(cfg.JWT.ExpirationHours),
-	)
+	h := NewAuthHandler(mockSvc, Config{
+		Secret:           cfg.JWT.Secret,
+		JWTTTL:           cfg.JWT.TokenTTL(),
+		Audience:         cfg.JWT.Audience,
+		PreviousSecrets:  cfg.JWT.PreviousSecrets,
+		SignupAutoLogin:  cfg.Security.SignupAutoLogin,
+		TokenSource:      cfg.Auth.TokenSource,
+		PasswordMaxBytes: cfg.Security.PasswordMaxBytes,
+		EmailMode:        cfg.Security.EmailMode,
+		SingleSession:    cfg.Auth.SingleSession,
+		UsernamePattern:  cfg.Security.UsernamePattern,
+		Leeway:           cfg.JWT.Leeway,
+	}, logger)
 
 	r := gin.New()
 	r.POST("/signin", h.SignIn)
@@ -136,15 +268,38 @@ func TestAuthHandler_SignIn(t *testing.T) {
 
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.Contains(t, w.Body.String(), "token")
+	assert.Contains(t, w.Body.String(), `"expires_in":3600`)
 	mockSvc.AssertExpectations(t)
 }
 
+func TestAuthHandler_SignIn_MinuteExpiration(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockSvc := &mockAuthService{}
+	h := NewAuthHandler(mockSvc, Config{Secret: "secret", JWTTTL: 15 * time.Minute}, zap.NewNop())
+
+	r := gin.New()
+	r.POST("/signin", h.SignIn)
+
+	mockSvc.On("Login", mock.Anything, mock.Anything).Return("token123", nil)
+
+	w := performRequest(r, http.MethodPost, "/signin", `{"email":"test@test.com","password":"pass"}`, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"token":"token123","expires_in":900}`, w.Body.String())
+
+	// Cookie живет ровно столько же, сколько токен
+	cookies := w.Result().Cookies()
+	if assert.Len(t, cookies, 1) {
+		assert.Equal(t, 900, cookies[0].MaxAge)
+	}
+}
+
 func TestAuthHandler_GetProfile(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	mockSvc := &mockAuthService{}
 	logger := zap.NewNop()
-	h := NewAuthHandler(mockSvc, logger, ""," ", 0)
+	h := NewAuthHandler(mockSvc, Config{Secret: " "}, logger)
 
 	r := gin.New()
 	r.GET("/profile", h.GetProfile)
@@ -172,7 +327,7 @@ func TestAuthHandler_ChangeProfile(t *testing.T) {
 
 	mockSvc := &mockAuthService{}
 	logger := zap.NewNop()
-	h := NewAuthHandler(mockSvc, logger, "", "", 0)
+	h := NewAuthHandler(mockSvc, Config{}, logger)
 
 	id := uuid.New()
 
@@ -197,17 +352,59 @@ func TestAuthHandler_ChangeProfile(t *testing.T) {
 	mockSvc.AssertExpectations(t)
 }
 
+func TestAuthHandler_PatchProfile(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockSvc := &mockAuthService{}
+	h := NewAuthHandler(mockSvc, Config{}, zap.NewNop())
+
+	id := uuid.New()
+	r := gin.New()
+	r.PATCH("/user/profile", func(c *gin.Context) {
+		c.Set("userID", id)
+		h.PatchProfile(c)
+	})
+
+	t.Run("Only given fields are passed", func(t *testing.T) {
+		mockSvc.On("PatchProfile", mock.Anything, id, mock.MatchedBy(func(req *model.PatchProfileRequest) bool {
+			return req.Username == nil && req.Bio == nil && req.DisplayName != nil && *req.DisplayName == "John"
+		})).Return(nil).Once()
+
+		w := performRequest(r, http.MethodPatch, "/user/profile", `{"display_name":"  John  "}`, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockSvc.AssertExpectations(t)
+	})
+
+	t.Run("Empty patch", func(t *testing.T) {
+		w := performRequest(r, http.MethodPatch, "/user/profile", `{}`, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "no fields to update")
+	})
+
+	t.Run("Username cannot be cleared", func(t *testing.T) {
+		w := performRequest(r, http.MethodPatch, "/user/profile", `{"username":""}`, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Bio too long", func(t *testing.T) {
+		w := performRequest(r, http.MethodPatch, "/user/profile", `{"bio":"`+strings.Repeat("a", 501)+`"}`, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
 func TestAuthHandler_GetUsers(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	mockSvc := &mockAuthService{}
 	logger := zap.NewNop()
-	h := NewAuthHandler(mockSvc, logger, "", "", 0)
+	h := NewAuthHandler(mockSvc, Config{}, logger)
 
-	users := []*model.User{
+	users := []*model.UserListItem{
 		{ID: uuid.New(), Username: "u1", Email: "e1@test.com"},
 		{ID: uuid.New(), Username: "u2", Email: "e2@test.com"},
 	}
-	mockSvc.On("GetUsers", mock.Anything, 10, 0).Return(users, nil)
+	// Без ?limit= хендлер передает 0: pagination.default_limit подставляет сервис
+	mockSvc.On("ListUsers", mock.Anything, model.UsersFilter{}, 0, 0).
+		Return(&model.UsersPage{Users: users, Total: 2, Limit: 10}, nil)
 
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
@@ -218,6 +415,8 @@ func TestAuthHandler_GetUsers(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.Contains(t, w.Body.String(), "u1")
 	assert.Contains(t, w.Body.String(), "u2")
+	assert.Equal(t, "2", w.Header().Get(TotalCountHeader))
+	assert.Equal(t, `</users?limit=10&offset=0>; rel="first", </users?limit=10&offset=0>; rel="last"`, w.Header().Get("Link"))
 	mockSvc.AssertExpectations(t)
 }
 
@@ -226,13 +425,13 @@ func TestAuthHandler_ChangeEmail(t *testing.T) {
 
 	mockSvc := &mockAuthService{}
 	logger := zap.NewNop()
-	h := NewAuthHandler(mockSvc, logger, "", "", 0)// ✅ через конструктор
+	h := NewAuthHandler(mockSvc, Config{}, logger)// ✅ через конструктор
 
 	id := uuid.New()
 	mockSvc.On("ChangeEmail", mock.Anything, id, mock.Anything).Return(nil)
 
 	// Ключ должен совпадать с тегом `json:"new_email"` в ChangeEmailRequest
-	body := `{"new_email":"new@test.com"}`
+	body := `{"new_email":"new@test.com","current_password":"current"}`
 	req := httptest.NewRequest(http.MethodPut, "/user/email", strings.NewReader(body))
 	req.Header.Set("Content-Type", "application/json")
 
@@ -253,7 +452,7 @@ func TestAuthHandler_ChangePassword(t *testing.T) {
 
 	mockSvc := &mockAuthService{}
 	logger := zap.NewNop()
-	h := NewAuthHandler(mockSvc, logger, "", "", 0)
+	h := NewAuthHandler(mockSvc, Config{}, logger)
 
 	id := uuid.New()
 	mockSvc.On("ChangePassword", mock.Anything, id, mock.Anything).Return(nil)
@@ -278,12 +477,13 @@ func TestAuthHandler_Delete(t *testing.T) {
 
 	mockSvc := &mockAuthService{}
 	logger := zap.NewNop()
-	h := NewAuthHandler(mockSvc, logger, "", "", 0)
+	h := NewAuthHandler(mockSvc, Config{}, logger)
 
 	id := uuid.New()
-	mockSvc.On("Delete", mock.Anything, id).Return(nil)
+	mockSvc.On("DeleteSelf", mock.Anything, id, "current-password").Return(nil)
 
-	req := httptest.NewRequest(http.MethodDelete, "/user", nil)
+	req := httptest.NewRequest(http.MethodDelete, "/user", strings.NewReader(`{"password":"current-password"}`))
+	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
 	c.Request = req
@@ -296,12 +496,134 @@ func TestAuthHandler_Delete(t *testing.T) {
 	mockSvc.AssertExpectations(t)
 }
 
+func TestAuthHandler_Delete_Confirmation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockSvc := &mockAuthService{}
+	h := NewAuthHandler(mockSvc, Config{}, zap.NewNop())
+
+	id := uuid.New()
+	mockSvc.On("DeleteSelf", mock.Anything, id, "wrong").Return(service.ErrWrongPassword)
+
+	run := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodDelete, "/user", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		c.Set("userID", id)
+
+		h.Delete(c)
+		return w
+	}
+
+	t.Run("Wrong Password - 403", func(t *testing.T) {
+		w := run(`{"password":"wrong"}`)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), "wrong password")
+	})
+
+	t.Run("Missing Password - 400", func(t *testing.T) {
+		w := run(`{}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockSvc.AssertNotCalled(t, "DeleteSelf", mock.Anything, id, "")
+	})
+}
+
+func TestAuthHandler_DeleteByID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockSvc := &mockAuthService{}
+	h := NewAuthHandler(mockSvc, Config{}, zap.NewNop())
+
+	r := gin.New()
+	r.DELETE("/users/:id", h.DeleteByID)
+
+	id := uuid.New()
+	mockSvc.On("Delete", mock.Anything, id).Return(nil).Once()
+
+	w := performRequest(r, http.MethodDelete, "/users/"+id.String(), "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	missing := uuid.New()
+	mockSvc.On("Delete", mock.Anything, missing).Return(repository.ErrNotFound).Once()
+
+	w = performRequest(r, http.MethodDelete, "/users/"+missing.String(), "", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = performRequest(r, http.MethodDelete, "/users/not-a-uuid", "", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	mockSvc.AssertExpectations(t)
+}
+
+func TestAuthHandler_ForcePasswordReset(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockSvc := &mockAuthService{}
+	h := NewAuthHandler(mockSvc, Config{}, zap.NewNop())
+
+	adminID := uuid.New()
+	r := gin.New()
+	r.POST("/users/:id/force-password-reset", func(c *gin.Context) {
+		c.Set("userID", adminID)
+	}, h.ForcePasswordReset)
+
+	id := uuid.New()
+	expiresAt := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	mockSvc.On("IssuePasswordReset", mock.Anything, id, adminID).
+		Return(&model.PasswordResetToken{Token: "one-time", ExpiresAt: expiresAt}, nil).Once()
+
+	w := performRequest(r, http.MethodPost, "/users/"+id.String()+"/force-password-reset", "", nil)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+	assert.JSONEq(t, `{"reset_token":"one-time","expires_at":"2030-01-01T12:00:00Z"}`, w.Body.String())
+
+	missing := uuid.New()
+	mockSvc.On("IssuePasswordReset", mock.Anything, missing, adminID).Return(nil, repository.ErrNotFound).Once()
+
+	w = performRequest(r, http.MethodPost, "/users/"+missing.String()+"/force-password-reset", "", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = performRequest(r, http.MethodPost, "/users/not-a-uuid/force-password-reset", "", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	mockSvc.AssertExpectations(t)
+}
+
+func TestAuthHandler_ResetPassword(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockSvc := &mockAuthService{}
+	h := NewAuthHandler(mockSvc, Config{}, zap.NewNop())
+
+	r := gin.New()
+	r.POST("/auth/password/reset", h.ResetPassword)
+
+	mockSvc.On("ResetPassword", mock.Anything, &model.ResetPasswordRequest{Token: "good", NewPassword: "new-password"}).Return(nil).Once()
+	mockSvc.On("ResetPassword", mock.Anything, &model.ResetPasswordRequest{Token: "used", NewPassword: "new-password"}).
+		Return(repository.ErrInvalidResetToken).Once()
+
+	w := performRequest(r, http.MethodPost, "/auth/password/reset", `{"reset_token":"good","new_password":"new-password"}`, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = performRequest(r, http.MethodPost, "/auth/password/reset", `{"reset_token":"used","new_password":"new-password"}`, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid or expired reset token")
+
+	// Короткий пароль отсекается до сервиса
+	w = performRequest(r, http.MethodPost, "/auth/password/reset", `{"reset_token":"good","new_password":"short"}`, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	mockSvc.AssertExpectations(t)
+}
+
 func TestAuthHandler_GetByID(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	mockSvc := &mockAuthService{}
 	logger := zap.NewNop()
-	h := NewAuthHandler(mockSvc, logger, "", "", 0)
+	h := NewAuthHandler(mockSvc, Config{}, logger)
 
 	id := uuid.New()
 	user := &model.User{ID: id, Username: "user1", Email: "email@test.com"}
@@ -325,7 +647,7 @@ func TestAuthHandler_GetByEmail(t *testing.T) {
 
 	mockSvc := &mockAuthService{}
 	logger := zap.NewNop()
-	h := NewAuthHandler(mockSvc, logger, "", "", 0)
+	h := NewAuthHandler(mockSvc, Config{}, logger)
 
 	email := "email@test.com"
 	user := &model.User{ID: uuid.New(), Username: "user1", Email: email}
@@ -347,7 +669,7 @@ func TestAuthHandler_SignUp_Errors(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	mockSvc := &mockAuthService{}
 	logger := zap.NewNop()
-	h := NewAuthHandler(mockSvc, logger, "", "", 0)
+	h := NewAuthHandler(mockSvc, Config{}, logger)
 
 	r := gin.New()
 	r.POST("/signup", h.SignUp)
@@ -368,12 +690,59 @@ func TestAuthHandler_SignUp_Errors(t *testing.T) {
 	mockSvc.AssertExpectations(t)
 }
 
+func TestAuthHandler_SignUp_Duplicate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	body := `{"username":"testuser","email":"test@test.com","password":"password123"}`
+
+	tests := []struct {
+		name    string
+		err     error
+		message string
+	}{
+		{"Duplicate Username", repository.ErrDuplicateUsername, "username already taken"},
+		{"Duplicate Email", repository.ErrDuplicateEmail, "email already taken"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := &mockAuthService{}
+			h := NewAuthHandler(mockSvc, Config{}, zap.NewNop())
+
+			r := gin.New()
+			r.POST("/signup", h.SignUp)
+
+			mockSvc.On("Register", mock.Anything, mock.Anything).Return(uuid.Nil, tt.err)
+
+			w := performRequest(r, "POST", "/signup", body, nil)
+			assert.Equal(t, http.StatusConflict, w.Code)
+			assert.Contains(t, w.Body.String(), tt.message)
+			mockSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAuthHandler_SignUp_DisposableEmail(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSvc := &mockAuthService{}
+	h := NewAuthHandler(mockSvc, Config{}, zap.NewNop())
+
+	r := gin.New()
+	r.POST("/signup", h.SignUp)
+
+	mockSvc.On("Register", mock.Anything, mock.Anything).Return(uuid.Nil, service.ErrDisposableEmail)
+
+	w := performRequest(r, "POST", "/signup", `{"username":"testuser","email":"test@mailinator.com","password":"password123"}`, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{"error":"disposable email addresses are not allowed"}`, w.Body.String())
+	mockSvc.AssertExpectations(t)
+}
+
 func TestAuthHandler_SignIn_Errors(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	mockSvc := &mockAuthService{}
 	logger := zap.NewNop()
 
-	h := NewAuthHandler(mockSvc, logger, "release", "", 0)
+	h := NewAuthHandler(mockSvc, Config{AppMode: "release"}, logger)
 
 	r := gin.New()
 	r.POST("/signin", h.SignIn)
@@ -398,7 +767,7 @@ func TestAuthHandler_GetByID_Errors(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	mockSvc := &mockAuthService{}
 	logger := zap.NewNop()
-	h := NewAuthHandler(mockSvc, logger, "", "", 0)
+	h := NewAuthHandler(mockSvc, Config{}, logger)
 
 	r := gin.New()
 	r.GET("/users/:id", h.GetByID)
@@ -412,6 +781,14 @@ func TestAuthHandler_GetByID_Errors(t *testing.T) {
 	mockSvc.On("GetByID", mock.Anything, id).Return(&model.User{}, errors.New("not found"))
 	w = performRequest(r, "GET", "/users/"+id.String(), "", nil)
 	assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+	// database busy
+	busyID := uuid.New()
+	mockSvc.On("GetByID", mock.Anything, busyID).Return((*model.User)(nil), fmt.Errorf("get user: %w", repository.ErrDatabaseBusy))
+	w = performRequest(r, "GET", "/users/"+busyID.String(), "", nil)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+	assert.JSONEq(t, `{"error":"database busy"}`, w.Body.String())
 	mockSvc.AssertExpectations(t)
 }
 
@@ -449,7 +826,7 @@ func TestAuthHandler_ChangeProfile_Validation(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	mockSvc := &mockAuthService{}
 	logger := zap.NewNop()
-	h := NewAuthHandler(mockSvc, logger, "", "", 0)
+	h := NewAuthHandler(mockSvc, Config{}, logger)
 
 	r := gin.New()
 	id := uuid.New()
@@ -473,7 +850,7 @@ func TestAuthHandler_ChangeProfile_Errors(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	mockSvc := &mockAuthService{}
 	logger := zap.NewNop()
-	h := NewAuthHandler(mockSvc, logger, "", "", 0)
+	h := NewAuthHandler(mockSvc, Config{}, logger)
 	id := uuid.New()
 
 	t.Run("Unauthorized", func(t *testing.T) {
@@ -501,7 +878,7 @@ func TestAuthHandler_ChangeProfile_Errors(t *testing.T) {
 
 	t.Run("Duplicate Username", func(t *testing.T) {
 		mockSvc := &mockAuthService{} // новый мок
-		h := NewAuthHandler(mockSvc, logger, "", "", 0)
+		h := NewAuthHandler(mockSvc, Config{}, logger)
 		mockSvc.On("ChangeProfile", mock.Anything, id, mock.Anything).Return(repository.ErrDuplicateUsername)
 
 		w := httptest.NewRecorder()
@@ -520,7 +897,7 @@ func TestAuthHandler_ChangeProfile_Errors(t *testing.T) {
 
 	t.Run("User Not Found", func(t *testing.T) {
 		mockSvc := &mockAuthService{} // снова новый мок
-		h := NewAuthHandler(mockSvc, logger, "", "", 0)
+		h := NewAuthHandler(mockSvc, Config{}, logger)
 		mockSvc.On("ChangeProfile", mock.Anything, id, mock.Anything).Return(repository.ErrNotFound)
 
 		w := httptest.NewRecorder()
@@ -534,12 +911,31 @@ func TestAuthHandler_ChangeProfile_Errors(t *testing.T) {
 		h.ChangeProfile(c)
 		assert.Equal(t, http.StatusNotFound, w.Code)
 	})
+
+	t.Run("Stale Version", func(t *testing.T) {
+		mockSvc := &mockAuthService{}
+		h := NewAuthHandler(mockSvc, Config{}, logger)
+		mockSvc.On("ChangeProfile", mock.Anything, id, &model.ChangeProfileRequest{NewUsername: "okname", Version: 3}).
+			Return(repository.ErrVersionConflict)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Set("userID", id)
+		body := `{"new_username":"okname","version":3}`
+		req := httptest.NewRequest(http.MethodPut, "/user/profile", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		c.Request = req
+
+		h.ChangeProfile(c)
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), "modified concurrently")
+	})
 }
 
 func TestAuthHandler_ChangeEmail_Errors(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	mockSvc := &mockAuthService{}
-	h := NewAuthHandler(mockSvc, zap.NewNop(), "", "", 0)
+	h := NewAuthHandler(mockSvc, Config{}, zap.NewNop())
 	id := uuid.New()
 
 	t.Run("Validation Failed", func(t *testing.T) {
@@ -561,7 +957,7 @@ func TestAuthHandler_ChangeEmail_Errors(t *testing.T) {
 		w := httptest.NewRecorder()
 		c, _ := gin.CreateTestContext(w)
 		c.Set("userID", id)
-		req := httptest.NewRequest(http.MethodPut, "/user/email", strings.NewReader(`{"new_email":"taken@test.com"}`))
+		req := httptest.NewRequest(http.MethodPut, "/user/email", strings.NewReader(`{"new_email":"taken@test.com","current_password":"current"}`))
 		req.Header.Set("Content-Type", "application/json")
 		c.Request = req
 
@@ -569,12 +965,82 @@ func TestAuthHandler_ChangeEmail_Errors(t *testing.T) {
 		assert.Equal(t, http.StatusConflict, w.Code)
 		assert.Contains(t, w.Body.String(), "email already taken")
 	})
+
+	t.Run("Missing Current Password", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Set("userID", id)
+		req := httptest.NewRequest(http.MethodPut, "/user/email", strings.NewReader(`{"new_email":"new@test.com"}`))
+		req.Header.Set("Content-Type", "application/json")
+		c.Request = req
+
+		h.ChangeEmail(c)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "current_password")
+	})
+
+	t.Run("Wrong Password - 403", func(t *testing.T) {
+		mockSvc := &mockAuthService{}
+		h := NewAuthHandler(mockSvc, Config{}, zap.NewNop())
+		mockSvc.On("ChangeEmail", mock.Anything, id, mock.MatchedBy(func(r *model.ChangeEmailRequest) bool {
+			return r.CurrentPassword == "wrong"
+		})).Return(service.ErrWrongPassword)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Set("userID", id)
+		req := httptest.NewRequest(http.MethodPut, "/user/email", strings.NewReader(`{"new_email":"new@test.com","current_password":"wrong"}`))
+		req.Header.Set("Content-Type", "application/json")
+		c.Request = req
+
+		h.ChangeEmail(c)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), "wrong password")
+		mockSvc.AssertExpectations(t)
+	})
+
+	t.Run("Disposable Email - 400", func(t *testing.T) {
+		mockSvc := &mockAuthService{}
+		h := NewAuthHandler(mockSvc, Config{}, zap.NewNop())
+		mockSvc.On("ChangeEmail", mock.Anything, id, mock.Anything).Return(service.ErrDisposableEmail)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Set("userID", id)
+		req := httptest.NewRequest(http.MethodPut, "/user/email", strings.NewReader(`{"new_email":"new@mailinator.com","current_password":"current"}`))
+		req.Header.Set("Content-Type", "application/json")
+		c.Request = req
+
+		h.ChangeEmail(c)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.JSONEq(t, `{"error":"disposable email addresses are not allowed"}`, w.Body.String())
+		mockSvc.AssertExpectations(t)
+	})
+}
+
+// TestAuthHandler_ChangeEmail_DeletedUser - без мока: ошибку отдает настоящий репозиторий,
+// так что 404 проверяется на той ошибке, которую вернет база, а не на подставленной
+func TestAuthHandler_ChangeEmail_DeletedUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	svc := service.NewAuthService(memory.NewAuthRepository(), hasher.NewBcrypt(bcrypt.MinCost), service.Config{JWTSecret: "secret", JWTTTL: time.Hour}, zap.NewNop())
+	h := NewAuthHandler(svc, Config{}, zap.NewNop())
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Set("userID", uuid.New())
+	req := httptest.NewRequest(http.MethodPut, "/user/email", strings.NewReader(`{"new_email":"new@test.com","current_password":"current"}`))
+	req.Header.Set("Content-Type", "application/json")
+	c.Request = req
+
+	h.ChangeEmail(c)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "user not found")
 }
 
 func TestAuthHandler_ChangePassword_Errors(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	mockSvc := &mockAuthService{}
-	h := NewAuthHandler(mockSvc, zap.NewNop(), "", "", 0)
+	h := NewAuthHandler(mockSvc, Config{}, zap.NewNop())
 	id := uuid.New()
 
 	t.Run("Validation Failed", func(t *testing.T) {
@@ -605,3 +1071,506 @@ func TestAuthHandler_ChangePassword_Errors(t *testing.T) {
 		assert.Contains(t, w.Body.String(), "wrong old password")
 	})
 }
+
+func TestAuthHandler_CheckPassword(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewAuthHandler(&mockAuthService{}, Config{}, zap.NewNop())
+
+	r := gin.New()
+	r.POST("/auth/password/check", h.CheckPassword)
+
+	t.Run("Weak password", func(t *testing.T) {
+		w := performRequest(r, "POST", "/auth/password/check", `{"password":"tester1","username":"tester"}`, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"valid":false`)
+		assert.Contains(t, w.Body.String(), model.RuleMinLength)
+		assert.Contains(t, w.Body.String(), model.RuleNotSimilar)
+	})
+
+	t.Run("Strong password", func(t *testing.T) {
+		w := performRequest(r, "POST", "/auth/password/check", `{"password":"Correct-Horse-42"}`, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"valid":true`)
+		assert.Contains(t, w.Body.String(), `"failed_rules":[]`)
+	})
+
+	t.Run("Missing password", func(t *testing.T) {
+		w := performRequest(r, "POST", "/auth/password/check", `{}`, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestAuthHandler_GetUsers_DateFilter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Valid range", func(t *testing.T) {
+		mockSvc := &mockAuthService{}
+		h := NewAuthHandler(mockSvc, Config{}, zap.NewNop())
+
+		from := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+		to := time.Date(2026, 2, 8, 0, 0, 0, 0, time.UTC)
+		users := []*model.UserListItem{{ID: uuid.New(), Username: "weekly"}}
+
+		mockSvc.On("ListUsers", mock.Anything, mock.MatchedBy(func(f model.UsersFilter) bool {
+			return f.CreatedFrom != nil && f.CreatedFrom.Equal(from) &&
+				f.CreatedTo != nil && f.CreatedTo.Equal(to)
+		}), 5, 0).Return(&model.UsersPage{Users: users, Total: 1, Limit: 5}, nil)
+
+		r := gin.New()
+		r.GET("/users", h.GetUsers)
+
+		w := performRequest(r, "GET", "/users?limit=5&from=2026-02-01T00:00:00Z&to=2026-02-08T00:00:00Z", "", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "weekly")
+		mockSvc.AssertExpectations(t)
+	})
+
+	t.Run("Invalid timestamps", func(t *testing.T) {
+		mockSvc := &mockAuthService{}
+		h := NewAuthHandler(mockSvc, Config{}, zap.NewNop())
+
+		r := gin.New()
+		r.GET("/users", h.GetUsers)
+
+		w := performRequest(r, "GET", "/users?from=yesterday", "", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "from")
+
+		w = performRequest(r, "GET", "/users?to=2026-13-01", "", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = performRequest(r, "GET", "/users?from=2026-02-08T00:00:00Z&to=2026-02-01T00:00:00Z", "", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		mockSvc.AssertNotCalled(t, "ListUsers", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestAuthHandler_GetUsers_Sort(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSvc := &mockAuthService{}
+	h := NewAuthHandler(mockSvc, Config{}, zap.NewNop())
+
+	r := gin.New()
+	r.GET("/users", h.GetUsers)
+
+	mockSvc.On("ListUsers", mock.Anything, model.UsersFilter{SortBy: "username", SortDesc: true}, 0, 0).
+		Return(&model.UsersPage{Users: []*model.UserListItem{{ID: uuid.New(), Username: "zed"}}, Total: 1, Limit: 10}, nil)
+
+	w := performRequest(r, "GET", "/users?sort=-username", "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "zed")
+
+	// Поле вне allowlist (попытка инъекции) отклоняется до похода в сервис
+	w = performRequest(r, "GET", "/users?sort=password_hash", "", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = performRequest(r, "GET", "/users?sort=username%3BDROP%20TABLE%20users", "", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	mockSvc.AssertExpectations(t)
+}
+
+func TestAuthHandler_ValidationErrorsByField(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewAuthHandler(&mockAuthService{}, Config{}, zap.NewNop())
+
+	r := gin.New()
+	r.POST("/signup", h.SignUp)
+	r.POST("/signin", h.SignIn)
+
+	t.Run("SignUp", func(t *testing.T) {
+		w := performRequest(r, "POST", "/signup", `{"username":"ok_name","email":"bad-email","password":"short"}`, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		var body struct {
+			Error   string             `json:"error"`
+			Details string             `json:"details"`
+			Errors  []model.FieldError `json:"errors"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, "validation failed", body.Error)
+		assert.NotEmpty(t, body.Details)
+		assert.Contains(t, body.Errors, model.FieldError{Field: "email", Rule: "strict_email"})
+		assert.Contains(t, body.Errors, model.FieldError{Field: "password", Rule: "min"})
+	})
+
+	t.Run("SignIn", func(t *testing.T) {
+		w := performRequest(r, "POST", "/signin", `{"email":"test@test.com"}`, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `{"field":"password","rule":"required"}`)
+	})
+}
+
+func TestAuthHandler_ClientCanceled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Service returns context.Canceled", func(t *testing.T) {
+		mockSvc := &mockAuthService{}
+		h := NewAuthHandler(mockSvc, Config{}, zap.NewNop())
+
+		r := gin.New()
+		r.GET("/users", h.GetUsers)
+
+		mockSvc.On("ListUsers", mock.Anything, model.UsersFilter{}, 0, 0).Return(nil, context.Canceled)
+
+		w := performRequest(r, "GET", "/users", "", nil)
+		assert.Equal(t, StatusClientClosedRequest, w.Code)
+		mockSvc.AssertExpectations(t)
+	})
+
+	t.Run("Request context already canceled", func(t *testing.T) {
+		mockSvc := &mockAuthService{}
+		h := NewAuthHandler(mockSvc, Config{}, zap.NewNop())
+
+		r := gin.New()
+		r.POST("/signin", h.SignIn)
+
+		// Сервис маскирует причину, но контекст запроса уже отменен
+		mockSvc.On("Login", mock.Anything, mock.Anything).Return("", errors.New("invalid credentials"))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		req := httptest.NewRequest(http.MethodPost, "/signin", strings.NewReader(`{"email":"test@test.com","password":"pass"}`)).WithContext(ctx)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, StatusClientClosedRequest, w.Code)
+	})
+}
+
+func TestAuthHandler_Introspect(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	secret := "test-secret"
+	h := &AuthHandler{secret: secret, logger: zap.NewNop()}
+
+	r := gin.New()
+	r.POST("/auth/token/introspect", h.Introspect)
+
+	userID := uuid.New()
+
+	t.Run("Active Token", func(t *testing.T) {
+		token := generateTestToken(userID, "tester", secret, false)
+		w := performRequest(r, "POST", "/auth/token/introspect", `{"token":"`+token+`"}`, nil)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var resp model.IntrospectResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.True(t, resp.Active)
+		assert.Equal(t, userID.String(), resp.UserID)
+		assert.Equal(t, "tester", resp.Username)
+		assert.NotZero(t, resp.Exp)
+	})
+
+	t.Run("Expired Token Is Inactive", func(t *testing.T) {
+		token := generateTestToken(userID, "tester", secret, true)
+		w := performRequest(r, "POST", "/auth/token/introspect", `{"token":"`+token+`"}`, nil)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"active":false}`, w.Body.String())
+	})
+
+	t.Run("Foreign Signature Is Inactive", func(t *testing.T) {
+		token := generateTestToken(userID, "tester", "other-secret", false)
+		w := performRequest(r, "POST", "/auth/token/introspect", `{"token":"`+token+`"}`, nil)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"active":false}`, w.Body.String())
+	})
+
+	t.Run("Missing Token", func(t *testing.T) {
+		w := performRequest(r, "POST", "/auth/token/introspect", `{}`, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+// TestAuthHandler_LookupUsers - без мока: пользователей находит настоящий репозиторий в памяти
+func TestAuthHandler_LookupUsers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repo := memory.NewAuthRepository()
+	svc := service.NewAuthService(repo, hasher.NewBcrypt(bcrypt.MinCost), service.Config{JWTSecret: "secret", JWTTTL: time.Hour}, zap.NewNop())
+	h := NewAuthHandler(svc, Config{}, zap.NewNop())
+
+	r := gin.New()
+	r.POST("/auth/users/lookup", h.LookupUsers)
+
+	id, err := repo.Create(context.Background(), &model.User{Username: "alice", Email: "alice@test.com", Password: "hash", Role: "user"})
+	assert.NoError(t, err)
+
+	t.Run("Known and unknown ids", func(t *testing.T) {
+		w := performRequest(r, "POST", "/auth/users/lookup", `{"ids":["`+id.String()+`","`+uuid.NewString()+`"]}`, nil)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"items":[{"id":"`+id.String()+`","username":"alice"}]}`, w.Body.String())
+	})
+
+	t.Run("By username", func(t *testing.T) {
+		w := performRequest(r, "POST", "/auth/users/lookup", `{"usernames":["alice","Alice","ghost"]}`, nil)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"items":[{"id":"`+id.String()+`","username":"alice"}]}`, w.Body.String())
+	})
+
+	t.Run("Found by id and username at once", func(t *testing.T) {
+		w := performRequest(r, "POST", "/auth/users/lookup", `{"ids":["`+id.String()+`"],"usernames":["alice"]}`, nil)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"items":[{"id":"`+id.String()+`","username":"alice"}]}`, w.Body.String())
+	})
+
+	t.Run("Malformed id", func(t *testing.T) {
+		w := performRequest(r, "POST", "/auth/users/lookup", `{"ids":["u1"]}`, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Too many ids", func(t *testing.T) {
+		ids := make([]string, model.MaxLookupUsers+1)
+		for i := range ids {
+			ids[i] = `"` + uuid.NewString() + `"`
+		}
+		w := performRequest(r, "POST", "/auth/users/lookup", `{"ids":[`+strings.Join(ids, ",")+`]}`, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Too many ids and usernames together", func(t *testing.T) {
+		names := make([]string, model.MaxLookupUsers)
+		for i := range names {
+			names[i] = fmt.Sprintf(`"user_%d"`, i)
+		}
+		w := performRequest(r, "POST", "/auth/users/lookup",
+			`{"ids":["`+id.String()+`"],"usernames":[`+strings.Join(names, ",")+`]}`, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Missing ids and usernames", func(t *testing.T) {
+		w := performRequest(r, "POST", "/auth/users/lookup", `{}`, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestAuthHandler_GetProfile_Timezone(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockSvc := &mockAuthService{}
+	h := NewAuthHandler(mockSvc, Config{}, zap.NewNop())
+
+	id := uuid.New()
+	created := time.Date(2026, 2, 15, 13, 0, 0, 0, time.UTC)
+	mockSvc.On("GetByID", mock.Anything, id).Return(&model.User{ID: id, Username: "tz", CreatedAt: created, UpdatedAt: created}, nil)
+
+	tests := []struct {
+		name     string
+		target   string
+		header   string
+		status   int
+		expected string
+	}{
+		{"Default UTC", "/user/profile", "", http.StatusOK, "15.02.2026 13:00:00"},
+		{"Query Param", "/user/profile?tz=Asia/Tokyo", "", http.StatusOK, "15.02.2026 22:00:00"},
+		{"Accept-Timezone Header", "/user/profile", "America/New_York", http.StatusOK, "15.02.2026 08:00:00"},
+		{"Invalid Timezone", "/user/profile?tz=Mars/Olympus", "", http.StatusBadRequest, "invalid timezone"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.header != "" {
+				c.Request.Header.Set("Accept-Timezone", tt.header)
+			}
+			c.Set("userID", id)
+
+			h.GetProfile(c)
+
+			assert.Equal(t, tt.status, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expected)
+		})
+	}
+}
+
+func TestAuthHandler_Available(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSvc := &mockAuthService{}
+	h := NewAuthHandler(mockSvc, Config{}, zap.NewNop())
+
+	r := gin.New()
+	r.GET("/available", h.Available)
+
+	// Email нормализуется так же, как при регистрации
+	mockSvc.On("CheckAvailability", mock.Anything, &model.AvailabilityQuery{Email: "john@example.com"}).Return(false, nil).Once()
+	w := performRequest(r, "GET", "/available?email=%20John@Example.COM%20", "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"available":false}`, w.Body.String())
+
+	mockSvc.On("CheckAvailability", mock.Anything, &model.AvailabilityQuery{Username: "john"}).Return(true, nil).Once()
+	w = performRequest(r, "GET", "/available?username=john", "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"available":true}`, w.Body.String())
+
+	// Ни одного параметра, оба сразу и невалидный email - 400 без обращения к сервису
+	for _, query := range []string{"", "?username=john&email=john@example.com", "?email=not-an-email", "?username=%20a%20"} {
+		w = performRequest(r, "GET", "/available"+query, "", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+
+	mockSvc.On("CheckAvailability", mock.Anything, mock.Anything).Return(false, errors.New("db error")).Once()
+	w = performRequest(r, "GET", "/available?username=john", "", nil)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+	mockSvc.AssertExpectations(t)
+}
+
+func TestAuthHandler_SignUp_Normalizes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSvc := &mockAuthService{}
+	h := NewAuthHandler(mockSvc, Config{}, zap.NewNop())
+
+	r := gin.New()
+	r.POST("/signup", h.SignUp)
+
+	mockSvc.On("Register", mock.Anything, &model.CreateUserRequest{
+		Username: "john",
+		Email:    "john@example.com",
+		Password: "Str0ng!Passw0rd",
+	}).Return(uuid.New(), nil).Once()
+
+	body := `{"username":" john ","email":"John@Example.com ","password":"Str0ng!Passw0rd"}`
+	w := performRequest(r, "POST", "/signup", body, nil)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	mockSvc.AssertExpectations(t)
+}
+
+func TestAuthHandler_BodyLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSvc := &mockAuthService{}
+	h := NewAuthHandler(mockSvc, Config{}, zap.NewNop())
+
+	r := gin.New()
+	r.Use(BodyLimit(64, 1024))
+	r.POST("/signup", h.SignUp)
+
+	body := `{"username":"` + strings.Repeat("a", 100) + `","email":"a@test.com","password":"password123"}`
+
+	// Заявленная длина больше лимита - отказ до bind
+	w := performRequest(r, "POST", "/signup", body, nil)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+
+	// Chunked-тело без Content-Length обрезается при bind, хендлер тоже отвечает 413
+	req := httptest.NewRequest(http.MethodPost, "/signup", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.ContentLength = -1
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+
+	mockSvc.AssertNotCalled(t, "Register", mock.Anything, mock.Anything)
+}
+
+func TestAuthHandler_GetUsers_JSONShape(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSvc := &mockAuthService{}
+	secret := "secret"
+	h := NewAuthHandler(mockSvc, Config{Secret: secret}, zap.NewNop())
+
+	r := gin.New()
+	r.GET("/users", h.OptionalAuth, h.GetUsers)
+
+	created := time.Date(2026, 2, 15, 13, 0, 0, 0, time.UTC)
+	users := []*model.UserListItem{
+		{ID: uuid.New(), Username: "u1", Email: "e1@test.com", Role: model.RoleAdmin, CreatedAt: created, UpdatedAt: created},
+	}
+	mockSvc.On("ListUsers", mock.Anything, model.UsersFilter{}, 0, 0).
+		Return(&model.UsersPage{Users: users, Total: 1, Limit: 10}, nil)
+
+	decode := func(w *httptest.ResponseRecorder) map[string]any {
+		var list []map[string]any
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+		assert.Len(t, list, 1)
+		return list[0]
+	}
+
+	// Аноним: без email и, конечно, без пароля
+	w := performRequest(r, "GET", "/users", "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	item := decode(w)
+	assert.ElementsMatch(t, []string{"id", "username", "role", "created_at", "updated_at"}, mapKeys(item))
+	assert.Equal(t, model.RoleAdmin, item["role"])
+	assert.Equal(t, "15.02.2026 13:00:00", item["created_at"])
+
+	// Невалидный токен не ломает публичный список
+	w = performRequest(r, "GET", "/users", "", []*http.Cookie{{Name: "token", Value: "garbage"}})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, decode(w), "email")
+
+	// Админ видит email
+	adminToken, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, &model.UserClaims{
+		UserID: uuid.New(),
+		Role:   model.RoleAdmin,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	}).SignedString([]byte(secret))
+	w = performRequest(r, "GET", "/users", "", []*http.Cookie{{Name: "token", Value: adminToken}})
+	item = decode(w)
+	assert.Equal(t, "e1@test.com", item["email"])
+	assert.NotContains(t, item, "password")
+	assert.NotContains(t, w.Body.String(), "hash")
+}
+
+func mapKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func TestAuthHandler_SignUp_AutoLogin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	body := `{"username":"test","email":"test@test.com","password":"password123"}`
+
+	t.Run("Query enables autologin", func(t *testing.T) {
+		mockSvc := &mockAuthService{}
+		h := NewAuthHandler(mockSvc, Config{}, zap.NewNop())
+		r := gin.New()
+		r.POST("/signup", h.SignUp)
+
+		id := uuid.New()
+		mockSvc.On("RegisterAndLogin", mock.Anything, mock.Anything).Return(id, "jwt-token", nil).Once()
+
+		w := performRequest(r, "POST", "/signup?autologin=true", body, nil)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.JSONEq(t, fmt.Sprintf(`{"id":%q,"message":"user registered","token":"jwt-token","expires_in":86400}`, id), w.Body.String())
+		assert.Contains(t, w.Header().Get("Set-Cookie"), "token=jwt-token")
+		mockSvc.AssertExpectations(t)
+	})
+
+	t.Run("Query disables config default", func(t *testing.T) {
+		mockSvc := &mockAuthService{}
+		h := NewAuthHandler(mockSvc, Config{SignupAutoLogin: true}, zap.NewNop())
+		r := gin.New()
+		r.POST("/signup", h.SignUp)
+
+		mockSvc.On("Register", mock.Anything, mock.Anything).Return(uuid.New(), nil).Once()
+
+		w := performRequest(r, "POST", "/signup?autologin=false", body, nil)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.NotContains(t, w.Body.String(), "token")
+		assert.Empty(t, w.Header().Get("Set-Cookie"))
+		mockSvc.AssertExpectations(t)
+	})
+
+	t.Run("Invalid flag", func(t *testing.T) {
+		mockSvc := &mockAuthService{}
+		h := NewAuthHandler(mockSvc, Config{}, zap.NewNop())
+		r := gin.New()
+		r.POST("/signup", h.SignUp)
+
+		w := performRequest(r, "POST", "/signup?autologin=maybe", body, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
@@ -1,61 +1,307 @@
 package handler
 
 import (
+	"crypto/subtle"
+	"errors"
 	"fmt"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/config"
 	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/model"
+	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/service"
 	"go.uber.org/zap"
 )
 
-// AuthMiddleware проверяет валидность JWT
+// AuthMiddleware проверяет валидность JWT или API-токена (Authorization: Token <value>)
 func (h *AuthHandler) AuthMiddleware(c *gin.Context) {
-	tokenString, err := c.Cookie("token")
-	if err != nil {
-		// Если нет в куках, пробуем достать из заголовка Authorization: Bearer <token>
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "authorization required"})
+	if apiToken, ok := apiTokenFromHeader(c); ok {
+		claims, err := h.apiTokenClaims(c, apiToken)
+		if err != nil {
+			if errors.Is(err, service.ErrInvalidAPIToken) {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+				return
+			}
+			h.handlerLogger(c).Error("failed to check api token", zap.Error(err))
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
 			return
 		}
-		// Убираем "Bearer "
-		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
-			tokenString = authHeader[7:]
-		} else {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid auth header"})
+
+		h.setClaims(c, claims)
+		c.Set("authMethod", AuthMethodAPIToken)
+		c.Next()
+		return
+	}
+
+	tokenString, err := h.tokenFromRequest(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	claims, err := h.parseToken(tokenString)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.checkSession(c, claims); err != nil {
+		if errors.Is(err, errSessionRevoked) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 			return
 		}
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	h.setClaims(c, claims)
+	c.Next()
+}
+
+// OptionalAuth - для публичных маршрутов, ответ которых зависит от того, кто спрашивает.
+// Валидный токен кладет claims в контекст, как AuthMiddleware; без токена или с невалидным
+// запрос идет дальше анонимно, а не получает 401.
+func (h *AuthHandler) OptionalAuth(c *gin.Context) {
+	if apiToken, ok := apiTokenFromHeader(c); ok {
+		if claims, err := h.apiTokenClaims(c, apiToken); err == nil {
+			h.setClaims(c, claims)
+			c.Set("authMethod", AuthMethodAPIToken)
+		}
+		c.Next()
+		return
+	}
+
+	if tokenString, err := h.tokenFromRequest(c); err == nil {
+		if claims, err := h.parseToken(tokenString); err == nil && h.checkSession(c, claims) == nil {
+			h.setClaims(c, claims)
+		}
+	}
+	c.Next()
+}
+
+// AuthMethodAPIToken - значение "authMethod" в контексте, если запрос пришел с API-токеном
+// (у JWT ключа нет)
+const AuthMethodAPIToken = "api_token"
+
+// apiTokenFromHeader - значение из Authorization: Token <value>; ok=false - другая схема
+func apiTokenFromHeader(c *gin.Context) (string, bool) {
+	value, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Token ")
+	return value, ok && value != ""
+}
+
+// apiTokenClaims - claims для запроса с API-токеном: те же поля, что в JWT, но без
+// срока действия и sid - токен живет до отзыва и к сессиям single_session не относится
+func (h *AuthHandler) apiTokenClaims(c *gin.Context, token string) (*model.UserClaims, error) {
+	user, err := h.service.AuthenticateAPIToken(c.Request.Context(), token)
+	if err != nil {
+		return nil, err
+	}
+	return &model.UserClaims{UserID: user.ID, Username: user.Username, Role: user.Role}, nil
+}
+
+// RequireSessionAuth не пускает запросы с API-токеном: утекший токен не должен
+// позволять выпускать новые. Ставится после AuthMiddleware.
+func RequireSessionAuth(c *gin.Context) {
+	if c.GetString("authMethod") == AuthMethodAPIToken {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "not allowed with an api token"})
+		return
+	}
+	c.Next()
+}
+
+// errSessionRevoked - токен вытеснен более новым входом (auth.single_session)
+var errSessionRevoked = errors.New("session ended by a newer login")
+
+// checkSession в режиме single_session сверяет sid токена с текущей сессией пользователя.
+// Ошибка хранилища логируется и возвращается как есть - вызывающий отвечает 500.
+func (h *AuthHandler) checkSession(c *gin.Context, claims *model.UserClaims) error {
+	if !h.singleSession {
+		return nil
+	}
+
+	active, err := h.service.SessionActive(c.Request.Context(), claims.UserID, claims.SessionID)
+	if err != nil {
+		h.handlerLogger(c).Error("failed to check session", zap.String("user_id", claims.UserID.String()), zap.Error(err))
+		return err
+	}
+	if !active {
+		return errSessionRevoked
+	}
+	return nil
+}
+
+// tokenFromRequest достает токен из cookie и/или заголовка Authorization: Bearer <token>
+// в порядке, заданном tokenSource. Пустое значение - как both-cookie-first.
+func (h *AuthHandler) tokenFromRequest(c *gin.Context) (string, error) {
+	switch h.tokenSource {
+	case config.TokenSourceCookie:
+		if tokenString, err := c.Cookie("token"); err == nil {
+			return tokenString, nil
+		}
+		return "", fmt.Errorf("authorization required")
+	case config.TokenSourceHeader:
+		return tokenFromHeader(c)
+	case config.TokenSourceBothHeaderFirst:
+		// Заголовок есть - используем только его: протухшая cookie не должна подменять свежий bearer
+		if c.GetHeader("Authorization") != "" {
+			return tokenFromHeader(c)
+		}
+		if tokenString, err := c.Cookie("token"); err == nil {
+			return tokenString, nil
+		}
+		return "", fmt.Errorf("authorization required")
+	default:
+		if tokenString, err := c.Cookie("token"); err == nil {
+			return tokenString, nil
+		}
+		return tokenFromHeader(c)
+	}
+}
+
+// tokenFromHeader достает токен из Authorization: Bearer <token>
+func tokenFromHeader(c *gin.Context) (string, error) {
+	authHeader := c.GetHeader("Authorization")
+	if authHeader == "" {
+		return "", fmt.Errorf("authorization required")
+	}
+	// Убираем "Bearer "
+	if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+		return authHeader[7:], nil
+	}
+	return "", fmt.Errorf("invalid auth header")
+}
+
+func (h *AuthHandler) setClaims(c *gin.Context, claims *model.UserClaims) {
+	// ВАЖНО: Кладем UserID в контекст, чтобы следующие хендлеры знали, кто делает запрос
+	c.Set("userID", claims.UserID)
+	c.Set("username", claims.Username)
+	c.Set("role", claims.Role)
+	c.Set("claims", claims)
+	// Дальше все логи запроса знают, чей он (если в цепочке есть RequestLogger)
+	if _, ok := c.Get("logger"); ok {
+		c.Set("logger", h.handlerLogger(c).With(zap.String("user_id", claims.UserID.String())))
+	}
+
+	// Сколько осталось жить токену - клиент может заранее обновить его
+	if claims.ExpiresAt != nil {
+		expiresIn := time.Until(claims.ExpiresAt.Time)
+		c.Set("tokenExpiresIn", expiresIn)
+		c.Header(TokenExpiresInHeader, strconv.Itoa(int(expiresIn.Seconds())))
+	}
+}
+
+// BasePath запоминает префикс группы маршрутов (app.base_path), чтобы ссылки в ответах,
+// например Location после регистрации, указывали на реальный адрес ресурса
+func BasePath(prefix string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("basePath", prefix)
+		c.Next()
+	}
+}
+
+// TokenExpiresInHeader - через сколько секунд истекает токен, которым подписан запрос
+const TokenExpiresInHeader = "X-Token-Expires-In"
+
+// parseToken проверяет подпись, срок действия, nbf и (если настроена) аудиторию токена и возвращает его claims.
+// exp и nbf сверяются с допуском leeway - часы реплик, выпустившей и проверяющей токен, могут расходиться.
+// Общий путь для AuthMiddleware и /auth/token/introspect.
+func (h *AuthHandler) parseToken(tokenString string) (*model.UserClaims, error) {
+	var opts []jwt.ParserOption
+	if h.audience != "" {
+		opts = append(opts, jwt.WithAudience(h.audience))
+	}
+	if h.leeway > 0 {
+		opts = append(opts, jwt.WithLeeway(h.leeway))
 	}
 
-	// Парсим токен
 	token, err := jwt.ParseWithClaims(tokenString, &model.UserClaims{}, func(token *jwt.Token) (interface{}, error) {
 		// Проверяем метод подписи
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
-		return []byte(h.secret), nil
-	})
+		return h.verificationKeys(), nil
+	}, opts...)
 
+	// Чужая аудитория - отдельное сообщение, чтобы было понятно, что токен выпущен для другого приложения
+	if errors.Is(err, jwt.ErrTokenInvalidAudience) {
+		return nil, fmt.Errorf("token is not intended for this audience")
+	}
 	if err != nil || !token.Valid {
-		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
-		return
+		return nil, fmt.Errorf("invalid token")
 	}
 
-	// Если токен валиден, достаем claims
 	claims, ok := token.Claims.(*model.UserClaims)
 	if !ok {
-		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token claims"})
-		return
+		return nil, fmt.Errorf("invalid token claims")
 	}
 
-	// ВАЖНО: Кладем UserID в контекст, чтобы следующие хендлеры знали, кто делает запрос
-	c.Set("userID", claims.UserID)
-	c.Set("username", claims.Username)
+	return claims, nil
+}
 
-	c.Next()
+// verificationKeys - текущий секрет и секреты до ротации; jwt пробует их по очереди
+func (h *AuthHandler) verificationKeys() jwt.VerificationKeySet {
+	keys := make([]jwt.VerificationKey, 0, len(h.previousSecrets)+1)
+	keys = append(keys, []byte(h.secret))
+	for _, secret := range h.previousSecrets {
+		if secret != "" {
+			keys = append(keys, []byte(secret))
+		}
+	}
+	return jwt.VerificationKeySet{Keys: keys}
+}
+
+// ServiceSecretHeader - заголовок, которым внутренние сервисы подтверждают, что они свои
+const ServiceSecretHeader = "X-Service-Secret"
+
+// RequireServiceSecret пропускает только запросы с правильным общим секретом сервисов.
+// Пустой secret закрывает доступ полностью, чтобы незаданная переменная не открыла эндпоинт.
+func RequireServiceSecret(secret string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		provided := c.GetHeader(ServiceSecretHeader)
+		if secret == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(secret)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid service credentials"})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// CookieSameSite задает атрибут SameSite для cookie, которые ставят хендлеры (токен при входе и выходе):
+// lax, strict или none (auth.cookie_same_site). Пустая строка - без атрибута, как было раньше
+func CookieSameSite(mode string) gin.HandlerFunc {
+	sameSite := map[string]http.SameSite{
+		"lax":    http.SameSiteLaxMode,
+		"strict": http.SameSiteStrictMode,
+		"none":   http.SameSiteNoneMode,
+	}[mode]
+
+	return func(c *gin.Context) {
+		if sameSite != 0 {
+			c.SetSameSite(sameSite)
+		}
+		c.Next()
+	}
+}
+
+// RequireRole пропускает запрос, только если роль из токена входит в roles.
+// Ставится после AuthMiddleware.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("role")
+		if !slices.Contains(roles, role) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+			return
+		}
+
+		c.Next()
+	}
 }
 
 // ZapLogger — это middleware, который заменяет стандартный логгер Gin на наш Zap
@@ -75,6 +321,7 @@ func ZapLogger(logger *zap.Logger) gin.HandlerFunc {
 		// Формируем структурированный лог
 		fields := []zap.Field{
 			zap.Int("status", status),
+			zap.String("request_id", requestID(c)),
 			zap.String("method", c.Request.Method),
 			zap.String("path", path),
 			zap.String("query", query),
@@ -88,6 +335,8 @@ func ZapLogger(logger *zap.Logger) gin.HandlerFunc {
 			for _, e := range c.Errors.Errors() {
 				logger.Error(e, fields...)
 			}
+		} else if status == StatusClientClosedRequest {
+			logger.Info("client closed request", fields...)
 		} else if status >= 500 {
 			logger.Error("server error", fields...)
 		} else if status >= 400 {
@@ -97,3 +346,76 @@ func ZapLogger(logger *zap.Logger) gin.HandlerFunc {
 		}
 	}
 }
+
+// RequestIDHeader — заголовок с ID запроса, который проставляет прокси/gateway
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength - длиннее из заголовка не берем, чтобы клиент не раздувал логи
+const maxRequestIDLength = 128
+
+// RequestLogger кладет в контекст дочерний логгер с request_id, AuthMiddleware добавляет к нему user_id.
+// ID берется из X-Request-ID (его ставит gateway) или генерируется; клиент получает его тем же заголовком.
+func RequestLogger(logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if requestID == "" || len(requestID) > maxRequestIDLength {
+			requestID = uuid.NewString()
+		}
+
+		c.Set("requestID", requestID)
+		c.Header(RequestIDHeader, requestID)
+		c.Set("logger", logger.With(zap.String("request_id", requestID)))
+		c.Next()
+	}
+}
+
+// handlerLogger - логгер текущего запроса из RequestLogger; без middleware (например, в тестах) - h.logger
+func (h *AuthHandler) handlerLogger(c *gin.Context) *zap.Logger {
+	if l, ok := c.Get("logger"); ok {
+		if logger, ok := l.(*zap.Logger); ok {
+			return logger
+		}
+	}
+	return h.logger
+}
+
+// requestID - ID из RequestLogger, а если его нет в цепочке - из заголовка
+func requestID(c *gin.Context) string {
+	if id := c.GetString("requestID"); id != "" {
+		return id
+	}
+	return c.GetHeader(RequestIDHeader)
+}
+
+// ZapRecovery заменяет gin.Recovery(): перехватывает панику, пишет ее в zap
+// вместе с ID запроса и стеком и отдает клиенту 500 без деталей.
+func ZapRecovery(logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+
+			fields := []zap.Field{
+				zap.Any("panic", rec),
+				zap.String("request_id", requestID(c)),
+				zap.String("method", c.Request.Method),
+				zap.String("path", c.Request.URL.Path),
+				zap.Stack("stack"),
+			}
+
+			// Клиент оборвал соединение — писать ответ уже некуда
+			if err, ok := rec.(error); ok && (errors.Is(err, syscall.EPIPE) || errors.Is(err, syscall.ECONNRESET)) {
+				logger.Warn("connection broken", fields...)
+				c.Abort()
+				return
+			}
+
+			logger.Error("panic recovered", fields...)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		}()
+
+		c.Next()
+	}
+}
@@ -1,16 +1,21 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/config"
 	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/model"
+	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/service"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zaptest/observer"
 )
@@ -70,6 +75,13 @@ func TestAuthMiddleware(t *testing.T) {
 			// Проверяем, что userID попал в контекст
 			id, _ := ctx.Get("userID")
 			assert.Equal(t, userID, id)
+
+			claims, ok := ctx.Get("claims")
+			assert.True(t, ok)
+			assert.Equal(t, username, claims.(*model.UserClaims).Username)
+
+			expiresIn := ctx.GetDuration("tokenExpiresIn")
+			assert.True(t, expiresIn > 59*time.Minute && expiresIn <= time.Hour)
 			ctx.Status(http.StatusOK)
 		})
 
@@ -78,6 +90,9 @@ func TestAuthMiddleware(t *testing.T) {
 		r.ServeHTTP(w, c.Request)
 
 		assert.Equal(t, http.StatusOK, w.Code)
+		expiresIn, err := strconv.Atoi(w.Header().Get(TokenExpiresInHeader))
+		assert.NoError(t, err)
+		assert.InDelta(t, 3600, expiresIn, 5)
 	})
 
 	t.Run("Expired Token - 401", func(t *testing.T) {
@@ -98,6 +113,369 @@ func TestAuthMiddleware(t *testing.T) {
 	})
 }
 
+func TestAuthMiddleware_Audience(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	secret := "test-secret"
+	h := &AuthHandler{secret: secret, audience: "web"}
+
+	tokenFor := func(audience ...string) string {
+		claims := &model.UserClaims{
+			UserID: uuid.New(),
+			RegisteredClaims: jwt.RegisteredClaims{
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+				Audience:  audience,
+			},
+		}
+		token, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+		return token
+	}
+
+	r := gin.New()
+	r.Use(h.AuthMiddleware)
+	r.GET("/test", func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+
+	do := func(token string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/test", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, do(tokenFor("web")).Code)
+
+	w := do(tokenFor("mobile"))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "not intended for this audience")
+
+	// Токен без aud тоже не подходит, если аудитория настроена
+	assert.Equal(t, http.StatusUnauthorized, do(tokenFor()).Code)
+}
+
+func TestAuthMiddleware_NotBefore(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	secret := "test-secret"
+
+	// nbf в будущем - так токен видит реплика, чьи часы отстают от выпустившей
+	tokenNotBefore := func(nbf time.Time) string {
+		claims := &model.UserClaims{
+			UserID: uuid.New(),
+			RegisteredClaims: jwt.RegisteredClaims{
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+				NotBefore: jwt.NewNumericDate(nbf),
+			},
+		}
+		token, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+		return token
+	}
+
+	do := func(leeway time.Duration, token string) int {
+		h := &AuthHandler{secret: secret, leeway: leeway}
+		r := gin.New()
+		r.Use(h.AuthMiddleware)
+		r.GET("/test", func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/test", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	now := time.Now()
+
+	t.Run("Without leeway", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, do(0, tokenNotBefore(now)), "nbf at now is valid")
+		assert.Equal(t, http.StatusUnauthorized, do(0, tokenNotBefore(now.Add(3*time.Second))))
+	})
+
+	t.Run("With leeway", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, do(5*time.Second, tokenNotBefore(now.Add(3*time.Second))))
+		assert.Equal(t, http.StatusUnauthorized, do(5*time.Second, tokenNotBefore(now.Add(30*time.Second))),
+			"leeway does not cover a larger skew")
+	})
+
+	t.Run("Leeway covers exp too", func(t *testing.T) {
+		claims := &model.UserClaims{
+			UserID: uuid.New(),
+			RegisteredClaims: jwt.RegisteredClaims{
+				ExpiresAt: jwt.NewNumericDate(now.Add(-2 * time.Second)),
+			},
+		}
+		expired, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+
+		assert.Equal(t, http.StatusUnauthorized, do(0, expired))
+		assert.Equal(t, http.StatusOK, do(5*time.Second, expired))
+	})
+}
+
+func TestAuthMiddleware_PreviousSecrets(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := &AuthHandler{secret: "new-secret", previousSecrets: []string{"old-secret"}}
+
+	r := gin.New()
+	r.Use(h.AuthMiddleware)
+	r.GET("/test", func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+
+	do := func(token string) int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/test", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	userID := uuid.New()
+
+	// Подписанные текущим ключом и ключом до ротации принимаются
+	assert.Equal(t, http.StatusOK, do(generateTestToken(userID, "user", "new-secret", false)))
+	assert.Equal(t, http.StatusOK, do(generateTestToken(userID, "user", "old-secret", false)))
+
+	// Незнакомый ключ и просроченный токен со старым ключом - нет
+	assert.Equal(t, http.StatusUnauthorized, do(generateTestToken(userID, "user", "unknown-secret", false)))
+	assert.Equal(t, http.StatusUnauthorized, do(generateTestToken(userID, "user", "old-secret", true)))
+
+	// Старый ключ убран из конфига - токены им больше не проходят
+	h.previousSecrets = nil
+	assert.Equal(t, http.StatusUnauthorized, do(generateTestToken(userID, "user", "old-secret", false)))
+}
+
+func TestAuthMiddleware_TokenSource(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	secret := "test-secret"
+	fresh := generateTestToken(uuid.New(), "user", secret, false)
+	stale := generateTestToken(uuid.New(), "user", secret, true)
+
+	do := func(source, cookie, bearer string) int {
+		h := &AuthHandler{secret: secret, tokenSource: source}
+		r := gin.New()
+		r.Use(h.AuthMiddleware)
+		r.GET("/test", func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/test", nil)
+		if cookie != "" {
+			req.AddCookie(&http.Cookie{Name: "token", Value: cookie})
+		}
+		if bearer != "" {
+			req.Header.Set("Authorization", "Bearer "+bearer)
+		}
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Протухшая cookie + свежий bearer: выигрывает то, что стоит первым
+	assert.Equal(t, http.StatusUnauthorized, do("", stale, fresh), "по умолчанию cookie первой")
+	assert.Equal(t, http.StatusUnauthorized, do(config.TokenSourceBothCookieFirst, stale, fresh))
+	assert.Equal(t, http.StatusOK, do(config.TokenSourceBothHeaderFirst, stale, fresh))
+	assert.Equal(t, http.StatusOK, do(config.TokenSourceHeader, stale, fresh))
+
+	// Фолбэк на второй источник
+	assert.Equal(t, http.StatusOK, do(config.TokenSourceBothHeaderFirst, fresh, ""))
+	assert.Equal(t, http.StatusOK, do(config.TokenSourceBothCookieFirst, "", fresh))
+
+	// Одиночные источники другой не смотрят
+	assert.Equal(t, http.StatusUnauthorized, do(config.TokenSourceCookie, "", fresh))
+	assert.Equal(t, http.StatusUnauthorized, do(config.TokenSourceHeader, fresh, ""))
+}
+
+func TestAuthMiddleware_SingleSession(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	secret := "test-secret"
+	userID := uuid.New()
+
+	tokenWithSession := func(sessionID string) string {
+		claims := &model.UserClaims{
+			UserID:    userID,
+			SessionID: sessionID,
+			RegisteredClaims: jwt.RegisteredClaims{
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+			},
+		}
+		token, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+		return token
+	}
+
+	mockSvc := &mockAuthService{}
+	mockSvc.On("SessionActive", mock.Anything, userID, "current").Return(true, nil)
+	mockSvc.On("SessionActive", mock.Anything, userID, "old").Return(false, nil)
+	mockSvc.On("SessionActive", mock.Anything, userID, "broken").Return(false, errors.New("db down"))
+	h := &AuthHandler{service: mockSvc, logger: zap.NewNop(), secret: secret, singleSession: true}
+
+	r := gin.New()
+	r.Use(h.AuthMiddleware)
+	r.GET("/test", func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+
+	do := func(token string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/test", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, do(tokenWithSession("current")).Code)
+
+	w := do(tokenWithSession("old"))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "session ended by a newer login")
+
+	assert.Equal(t, http.StatusInternalServerError, do(tokenWithSession("broken")).Code)
+
+	// Режим выключен - сессия не проверяется вовсе
+	h.singleSession = false
+	assert.Equal(t, http.StatusOK, do(tokenWithSession("old")).Code)
+}
+
+func TestRequireRole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	secret := "test-secret"
+	h := &AuthHandler{
+		secret: secret,
+	}
+
+	newRouter := func() *gin.Engine {
+		r := gin.New()
+		r.GET("/admin", h.AuthMiddleware, RequireRole(model.RoleAdmin), func(ctx *gin.Context) {
+			ctx.Status(http.StatusOK)
+		})
+		return r
+	}
+
+	tokenWithRole := func(role string) string {
+		claims := &model.UserClaims{
+			UserID:   uuid.New(),
+			Username: "testuser",
+			Role:     role,
+			RegisteredClaims: jwt.RegisteredClaims{
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+			},
+		}
+		tString, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+		return tString
+	}
+
+	tests := []struct {
+		name     string
+		token    string
+		expected int
+	}{
+		{"Admin - 200", tokenWithRole(model.RoleAdmin), http.StatusOK},
+		{"Regular User - 403", tokenWithRole(model.RoleUser), http.StatusForbidden},
+		{"Token Without Role - 403", generateTestToken(uuid.New(), "testuser", secret, false), http.StatusForbidden},
+		{"No Token - 401", "", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, "/admin", nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+
+			newRouter().ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expected, w.Code)
+		})
+	}
+}
+
+func TestCookieSameSite(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cookie := func(mode string) string {
+		r := gin.New()
+		r.Use(CookieSameSite(mode))
+		r.GET("/", func(c *gin.Context) {
+			c.SetCookie("token", "v", 60, "/", "", true, true)
+		})
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		return w.Header().Get("Set-Cookie")
+	}
+
+	assert.Contains(t, cookie("lax"), "SameSite=Lax")
+	assert.Contains(t, cookie("strict"), "SameSite=Strict")
+	assert.Contains(t, cookie("none"), "SameSite=None")
+	assert.NotContains(t, cookie(""), "SameSite")
+}
+
+func TestAuthMiddleware_APIToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockSvc := &mockAuthService{}
+	h := &AuthHandler{service: mockSvc, secret: "test-secret", logger: zap.NewNop()}
+
+	userID := uuid.New()
+	mockSvc.On("AuthenticateAPIToken", mock.Anything, "mbh_good").
+		Return(&model.User{ID: userID, Username: "script", Role: model.RoleUser}, nil)
+	mockSvc.On("AuthenticateAPIToken", mock.Anything, "mbh_revoked").Return(nil, service.ErrInvalidAPIToken)
+	mockSvc.On("AuthenticateAPIToken", mock.Anything, "mbh_broken").Return(nil, errors.New("db down"))
+
+	r := gin.New()
+	r.GET("/me", h.AuthMiddleware, func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"user_id": c.MustGet("userID"), "method": c.GetString("authMethod")})
+	})
+	r.GET("/tokens", h.AuthMiddleware, RequireSessionAuth, func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	do := func(path, authorization string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", authorization)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do("/me", "Token mbh_good")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"user_id":"`+userID.String()+`","method":"api_token"}`, w.Body.String())
+	// У API-токена нет срока действия
+	assert.Empty(t, w.Header().Get(TokenExpiresInHeader))
+
+	assert.Equal(t, http.StatusUnauthorized, do("/me", "Token mbh_revoked").Code)
+	assert.Equal(t, http.StatusInternalServerError, do("/me", "Token mbh_broken").Code)
+	assert.Equal(t, http.StatusUnauthorized, do("/me", "Token ").Code)
+
+	// Управлять токенами с самим API-токеном нельзя, с JWT - можно
+	assert.Equal(t, http.StatusForbidden, do("/tokens", "Token mbh_good").Code)
+	jwtToken := generateTestToken(userID, "script", "test-secret", false)
+	assert.Equal(t, http.StatusOK, do("/tokens", "Bearer "+jwtToken).Code)
+}
+
+func TestRequireServiceSecret(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		secret   string
+		header   string
+		expected int
+	}{
+		{"Valid Secret - 200", "svc-secret", "svc-secret", http.StatusOK},
+		{"Wrong Secret - 401", "svc-secret", "other", http.StatusUnauthorized},
+		{"Missing Header - 401", "svc-secret", "", http.StatusUnauthorized},
+		{"Secret Not Configured - 401", "", "", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			_, r := gin.CreateTestContext(w)
+
+			r.POST("/test", RequireServiceSecret(tt.secret), func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+
+			req, _ := http.NewRequest(http.MethodPost, "/test", nil)
+			if tt.header != "" {
+				req.Header.Set(ServiceSecretHeader, tt.header)
+			}
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expected, w.Code)
+		})
+	}
+}
+
 func TestAuthMiddleware_EdgeCases(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	secret := "test-secret"
@@ -183,4 +561,111 @@ func TestZapLogger(t *testing.T) {
 		assert.Equal(t, zap.WarnLevel, logEntry.Level)
 		assert.Equal(t, "client error", logEntry.Message)
 	})
+
+	t.Run("Log Client Closed Request", func(t *testing.T) {
+		recorded.TakeAll()
+		w := httptest.NewRecorder()
+		_, r := gin.CreateTestContext(w)
+
+		r.Use(ZapLogger(logger))
+		r.GET("/slow", func(c *gin.Context) {
+			c.Status(StatusClientClosedRequest)
+		})
+
+		req, _ := http.NewRequest(http.MethodGet, "/slow", nil)
+		r.ServeHTTP(w, req)
+
+		logEntry := recorded.All()[0]
+		assert.Equal(t, zap.InfoLevel, logEntry.Level)
+		assert.Equal(t, "client closed request", logEntry.Message)
+	})
+}
+
+func TestZapRecovery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	core, recorded := observer.New(zap.InfoLevel)
+	logger := zap.New(core)
+
+	w := httptest.NewRecorder()
+	_, r := gin.CreateTestContext(w)
+
+	r.Use(ZapRecovery(logger))
+	r.GET("/panic", func(c *gin.Context) {
+		panic("boom")
+	})
+
+	req, _ := http.NewRequest(http.MethodGet, "/panic", nil)
+	req.Header.Set(RequestIDHeader, "req-123")
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.JSONEq(t, `{"error":"internal error"}`, w.Body.String())
+
+	// Паника должна попасть в лог как ошибка с ID запроса и стеком
+	if assert.Equal(t, 1, recorded.Len()) {
+		logEntry := recorded.All()[0]
+		fields := logEntry.ContextMap()
+		assert.Equal(t, zap.ErrorLevel, logEntry.Level)
+		assert.Equal(t, "panic recovered", logEntry.Message)
+		assert.Equal(t, "boom", fields["panic"])
+		assert.Equal(t, "req-123", fields["request_id"])
+		assert.NotEmpty(t, fields["stack"])
+	}
+}
+
+func TestRequestLogger(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	core, recorded := observer.New(zap.InfoLevel)
+	secret := "test-secret"
+	h := &AuthHandler{secret: secret, logger: zap.NewNop()}
+	userID := uuid.New()
+
+	r := gin.New()
+	r.Use(RequestLogger(zap.New(core)))
+	r.GET("/me", h.AuthMiddleware, func(c *gin.Context) {
+		h.handlerLogger(c).Info("inside handler")
+		c.Status(http.StatusOK)
+	})
+
+	t.Run("Incoming request ID and user are attached", func(t *testing.T) {
+		recorded.TakeAll()
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		req.Header.Set("Authorization", "Bearer "+generateTestToken(userID, "alice", secret, false))
+		req.Header.Set(RequestIDHeader, "req-abc")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "req-abc", w.Header().Get(RequestIDHeader))
+
+		entries := recorded.FilterMessage("inside handler").All()
+		if assert.Len(t, entries, 1) {
+			fields := entries[0].ContextMap()
+			assert.Equal(t, "req-abc", fields["request_id"])
+			assert.Equal(t, userID.String(), fields["user_id"])
+		}
+	})
+
+	t.Run("Missing request ID is generated", func(t *testing.T) {
+		recorded.TakeAll()
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		req.Header.Set("Authorization", "Bearer "+generateTestToken(userID, "alice", secret, false))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		generated := w.Header().Get(RequestIDHeader)
+		_, err := uuid.Parse(generated)
+		assert.NoError(t, err)
+		assert.Equal(t, generated, recorded.FilterMessage("inside handler").All()[0].ContextMap()["request_id"])
+	})
+}
+
+func TestHandlerLogger_FallsBackToHandlerLogger(t *testing.T) {
+	logger := zap.NewNop()
+	h := &AuthHandler{logger: logger}
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+
+	assert.Same(t, logger, h.handlerLogger(c))
 }
@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNoRouteNoMethod(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.HandleMethodNotAllowed = true
+	r.NoMethod(NoMethod)
+	r.NoRoute(NoRoute)
+	r.DELETE("/user/:id", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/user/1", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.Equal(t, "DELETE", w.Header().Get("Allow"))
+	assert.JSONEq(t, `{"error":"method not allowed"}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/nope", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	// Тот же ответ, что у post-service: формат ошибок у сервисов общий
+	assert.JSONEq(t, `{"error":"not found"}`, w.Body.String())
+}
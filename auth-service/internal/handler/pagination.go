@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/model"
+)
+
+// TotalCountHeader - сколько всего элементов под фильтром, без учета limit/offset
+const TotalCountHeader = "X-Total-Count"
+
+// setPaginationHeaders - X-Total-Count и Link (RFC 8288) со ссылками first/prev/next/last,
+// чтобы клиенты листали список, не собирая URL сами. prev и next - только если такие страницы есть.
+func setPaginationHeaders(c *gin.Context, page *model.UsersPage) {
+	c.Header(TotalCountHeader, strconv.Itoa(page.Total))
+	if page.Limit <= 0 {
+		return
+	}
+	c.Header("Link", paginationLinks(c.Request.URL, page.Total, page.Limit, page.Offset))
+}
+
+// paginationLinks строит ссылки от текущего URL: остальные параметры (фильтры, sort, tz) сохраняются
+func paginationLinks(current *url.URL, total, limit, offset int) string {
+	link := func(rel string, offset int) string {
+		u := *current
+		q := u.Query()
+		q.Set("limit", strconv.Itoa(limit))
+		q.Set("offset", strconv.Itoa(offset))
+		u.RawQuery = q.Encode()
+		// Относительная ссылка: схема и хост за прокси известны клиенту лучше, чем сервису
+		u.Scheme, u.Host = "", ""
+		return fmt.Sprintf(`<%s>; rel="%s"`, u.String(), rel)
+	}
+
+	last := 0
+	if total > 0 {
+		last = (total - 1) / limit * limit
+	}
+
+	links := []string{link("first", 0)}
+	if offset > 0 {
+		links = append(links, link("prev", max(offset-limit, 0)))
+	}
+	if offset+limit < total {
+		links = append(links, link("next", offset+limit))
+	}
+	links = append(links, link("last", last))
+	return strings.Join(links, ", ")
+}
@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPaginationLinks(t *testing.T) {
+	current, _ := url.Parse("http://example.com/api/v1/users?sort=-username&limit=10&offset=20")
+
+	tests := []struct {
+		name                 string
+		total, limit, offset int
+		want                 string
+	}{
+		{
+			name: "Middle page", total: 45, limit: 10, offset: 20,
+			want: `</api/v1/users?limit=10&offset=0&sort=-username>; rel="first", ` +
+				`</api/v1/users?limit=10&offset=10&sort=-username>; rel="prev", ` +
+				`</api/v1/users?limit=10&offset=30&sort=-username>; rel="next", ` +
+				`</api/v1/users?limit=10&offset=40&sort=-username>; rel="last"`,
+		},
+		{
+			name: "First page has no prev", total: 25, limit: 10, offset: 0,
+			want: `</api/v1/users?limit=10&offset=0&sort=-username>; rel="first", ` +
+				`</api/v1/users?limit=10&offset=10&sort=-username>; rel="next", ` +
+				`</api/v1/users?limit=10&offset=20&sort=-username>; rel="last"`,
+		},
+		{
+			name: "Last page has no next", total: 20, limit: 10, offset: 10,
+			want: `</api/v1/users?limit=10&offset=0&sort=-username>; rel="first", ` +
+				`</api/v1/users?limit=10&offset=0&sort=-username>; rel="prev", ` +
+				`</api/v1/users?limit=10&offset=10&sort=-username>; rel="last"`,
+		},
+		{
+			name: "Unaligned offset clamps prev to zero", total: 30, limit: 10, offset: 5,
+			want: `</api/v1/users?limit=10&offset=0&sort=-username>; rel="first", ` +
+				`</api/v1/users?limit=10&offset=0&sort=-username>; rel="prev", ` +
+				`</api/v1/users?limit=10&offset=15&sort=-username>; rel="next", ` +
+				`</api/v1/users?limit=10&offset=20&sort=-username>; rel="last"`,
+		},
+		{
+			name: "Empty list", total: 0, limit: 10, offset: 0,
+			want: `</api/v1/users?limit=10&offset=0&sort=-username>; rel="first", ` +
+				`</api/v1/users?limit=10&offset=0&sort=-username>; rel="last"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, paginationLinks(current, tt.total, tt.limit, tt.offset))
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// parseUUIDParam разбирает UUID из параметра пути name. На невалидном значении сам отвечает
+// 400 {"error":"invalid <name> format"} и возвращает false - у всех :id маршрутов один и тот же ответ
+func parseUUIDParam(c *gin.Context, name string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param(name))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + name + " format"})
+		return uuid.Nil, false
+	}
+	return id, true
+}
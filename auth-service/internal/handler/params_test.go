@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseUUIDParam(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.GET("/users/:id", func(c *gin.Context) {
+		id, ok := parseUUIDParam(c, "id")
+		if !ok {
+			return
+		}
+		c.String(http.StatusOK, id.String())
+	})
+
+	t.Run("Valid UUID", func(t *testing.T) {
+		id := uuid.New()
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/"+id.String(), nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, id.String(), w.Body.String())
+	})
+
+	t.Run("Malformed UUID - 400", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/not-a-uuid", nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.JSONEq(t, `{"error":"invalid id format"}`, w.Body.String())
+	})
+}
@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// windowCounter - число запросов с одного IP в текущем окне
+type windowCounter struct {
+	start time.Time
+	count int
+}
+
+// ipRateLimiter - фиксированное окно на IP (или другой ключ) в памяти процесса.
+// Лимит действует на каждую реплику отдельно - для защиты от перебора этого достаточно.
+type ipRateLimiter struct {
+	mu        sync.Mutex
+	limit     int
+	window    time.Duration
+	counters  map[string]*windowCounter
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func newIPRateLimiter(limit int, window time.Duration) *ipRateLimiter {
+	return &ipRateLimiter{
+		limit:    limit,
+		window:   window,
+		counters: make(map[string]*windowCounter),
+		now:      time.Now,
+	}
+}
+
+// allow учитывает запрос и возвращает, сколько ждать до нового окна, если лимит исчерпан
+func (l *ipRateLimiter) allow(ip string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+
+	// Раз в окно выкидываем устаревшие счетчики, чтобы map не росла бесконечно
+	if now.Sub(l.lastSweep) >= l.window {
+		for key, counter := range l.counters {
+			if now.Sub(counter.start) >= l.window {
+				delete(l.counters, key)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	counter, ok := l.counters[ip]
+	if !ok || now.Sub(counter.start) >= l.window {
+		l.counters[ip] = &windowCounter{start: now, count: 1}
+		return true, 0
+	}
+
+	if counter.count >= l.limit {
+		return false, counter.start.Add(l.window).Sub(now)
+	}
+	counter.count++
+	return true, 0
+}
+
+// RateLimitByIP ограничивает число запросов с одного IP за window. limit <= 0 выключает лимит.
+func RateLimitByIP(limit int, window time.Duration) gin.HandlerFunc {
+	return rateLimit(limit, window, func(c *gin.Context) string { return c.ClientIP() })
+}
+
+// RateLimitByUser - то же по userID из AuthMiddleware (ставится после него): лимит
+// идет за пользователем, а не за адресом, с которого он пришел
+func RateLimitByUser(limit int, window time.Duration) gin.HandlerFunc {
+	return rateLimit(limit, window, func(c *gin.Context) string {
+		userID, _ := c.Get("userID")
+		return fmt.Sprint(userID)
+	})
+}
+
+func rateLimit(limit int, window time.Duration, key func(*gin.Context) string) gin.HandlerFunc {
+	if limit <= 0 || window <= 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	limiter := newIPRateLimiter(limit, window)
+
+	return func(c *gin.Context) {
+		ok, retryAfter := limiter.allow(key(c))
+		if !ok {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "too many requests"})
+			return
+		}
+		c.Next()
+	}
+}
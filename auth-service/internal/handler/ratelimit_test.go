@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIPRateLimiter(t *testing.T) {
+	now := time.Now()
+	limiter := newIPRateLimiter(2, time.Minute)
+	limiter.now = func() time.Time { return now }
+
+	ok, _ := limiter.allow("1.1.1.1")
+	assert.True(t, ok)
+	ok, _ = limiter.allow("1.1.1.1")
+	assert.True(t, ok)
+
+	ok, retryAfter := limiter.allow("1.1.1.1")
+	assert.False(t, ok)
+	assert.Equal(t, time.Minute, retryAfter)
+
+	// Другие IP считаются отдельно
+	ok, _ = limiter.allow("2.2.2.2")
+	assert.True(t, ok)
+
+	// Новое окно - счетчик сброшен, устаревшие записи вычищены
+	now = now.Add(time.Minute)
+	ok, _ = limiter.allow("1.1.1.1")
+	assert.True(t, ok)
+	assert.Len(t, limiter.counters, 1)
+}
+
+func TestRateLimitByIP(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.GET("/limited", RateLimitByIP(1, time.Minute), func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/unlimited", RateLimitByIP(0, time.Minute), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	do := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, do("/limited").Code)
+	w := do("/limited")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+
+	for range 3 {
+		assert.Equal(t, http.StatusOK, do("/unlimited").Code)
+	}
+}
+
+func TestRateLimitByUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.POST("/limited", func(c *gin.Context) {
+		c.Set("userID", c.GetHeader("X-User"))
+	}, RateLimitByUser(1, time.Hour), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	do := func(user string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/limited", nil)
+		req.Header.Set("X-User", user)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, do("admin-1").Code)
+	w := do("admin-1")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "3600", w.Header().Get("Retry-After"))
+
+	// Тот же IP, другой пользователь - свой счетчик
+	assert.Equal(t, http.StatusOK, do("admin-2").Code)
+}
@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestTimeout ограничивает время обработки запроса: контекст запроса получает дедлайн,
+// и запросы в базу отменяются вместе с ним. Хендлер, упавший на дедлайне, отвечает 504
+// через abortIfCanceled; если он так ничего и не записал, 504 отдает сам middleware.
+// skipPaths - шаблоны маршрутов (c.FullPath()) долгих эндпоинтов вроде потоковых выгрузок,
+// для которых дедлайн не ставится. streamRoutes - маршруты вида "GET /users", которые
+// остаются без дедлайна только в режиме ND-JSON (Accept: application/x-ndjson);
+// на остальных маршрутах этот заголовок ничего не меняет.
+// timeout <= 0 - без ограничения.
+func RequestTimeout(timeout time.Duration, streamRoutes []string, skipPaths ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if timeout <= 0 || slices.Contains(skipPaths, c.FullPath()) ||
+			(wantsNDJSON(c) && slices.Contains(streamRoutes, c.Request.Method+" "+c.FullPath())) {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) && !c.Writer.Written() {
+			c.AbortWithStatusJSON(http.StatusGatewayTimeout, gin.H{"error": "request timeout"})
+		}
+	}
+}
+
+// isRequestTimeout - истек дедлайн RequestTimeout, а не клиент ушел
+func isRequestTimeout(c *gin.Context) bool {
+	return errors.Is(c.Request.Context().Err(), context.DeadlineExceeded)
+}
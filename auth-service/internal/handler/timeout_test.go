@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+func TestRequestTimeout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// slow ждет отмены контекста, как запрос в базу
+	slow := func(c *gin.Context) {
+		select {
+		case <-c.Request.Context().Done():
+		case <-time.After(100 * time.Millisecond):
+			c.Status(http.StatusOK)
+		}
+	}
+
+	r := gin.New()
+	r.Use(RequestTimeout(20*time.Millisecond, nil, "/export"))
+	r.GET("/slow", slow)
+	r.GET("/export", slow)
+	r.GET("/fast", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	do := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	w := do("/slow")
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+	assert.JSONEq(t, `{"error":"request timeout"}`, w.Body.String())
+
+	assert.Equal(t, http.StatusNoContent, do("/fast").Code)
+
+	// Маршрут из skipPaths дедлайна не получает
+	assert.Equal(t, http.StatusOK, do("/export").Code)
+
+	t.Run("ND-JSON skips the deadline only on stream routes", func(t *testing.T) {
+		r := gin.New()
+		r.Use(RequestTimeout(20*time.Millisecond, []string{"GET /users"}))
+		r.GET("/users", slow)
+		r.POST("/users", slow)
+		r.GET("/slow", slow)
+
+		do := func(method, path string) int {
+			req := httptest.NewRequest(method, path, nil)
+			req.Header.Set("Accept", "application/x-ndjson")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			return w.Code
+		}
+
+		assert.Equal(t, http.StatusOK, do(http.MethodGet, "/users"))
+		assert.Equal(t, http.StatusGatewayTimeout, do(http.MethodPost, "/users"))
+		assert.Equal(t, http.StatusGatewayTimeout, do(http.MethodGet, "/slow"))
+	})
+
+	t.Run("Zero timeout disables the middleware", func(t *testing.T) {
+		r := gin.New()
+		r.Use(RequestTimeout(0, nil))
+		r.GET("/check", func(c *gin.Context) {
+			_, hasDeadline := c.Request.Context().Deadline()
+			assert.False(t, hasDeadline)
+			c.Status(http.StatusOK)
+		})
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/check", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Handler error on deadline becomes 504, not 500", func(t *testing.T) {
+		mockSvc := &mockAuthService{}
+		mockSvc.On("ListUsers", mock.Anything, model.UsersFilter{}, 0, 0).
+			Run(func(args mock.Arguments) {
+				<-args.Get(0).(context.Context).Done()
+			}).
+			Return(nil, assert.AnError)
+		h := NewAuthHandler(mockSvc, Config{}, zap.NewNop())
+
+		r := gin.New()
+		r.Use(RequestTimeout(20*time.Millisecond, nil))
+		r.GET("/users", h.GetUsers)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users", nil))
+		assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+	})
+}
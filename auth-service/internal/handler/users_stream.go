@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/model"
+	"go.uber.org/zap"
+)
+
+// mimeNDJSON - один JSON-объект на строку, клиент может рисовать список по мере получения
+const mimeNDJSON = "application/x-ndjson"
+
+// wantsNDJSON - клиент явно предпочел ND-JSON; */* и отсутствие Accept дают обычный JSON
+func wantsNDJSON(c *gin.Context) bool {
+	return c.NegotiateFormat(gin.MIMEJSON, mimeNDJSON) == mimeNDJSON
+}
+
+// streamUsers - GET /users с Accept: application/x-ndjson, только для администратора.
+// Всех пользователей по одному через StreamUsers, каждая строка сразу сбрасывается клиенту.
+// После первой строки статус уже отправлен: ошибка только логируется и обрывает поток.
+func (h *AuthHandler) streamUsers(c *gin.Context, loc *time.Location) {
+	if c.GetString("role") != model.RoleAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "ndjson stream is available to admins only"})
+		return
+	}
+
+	enc := json.NewEncoder(c.Writer)
+	started := false
+	start := func() {
+		if !started {
+			c.Header("Content-Type", mimeNDJSON)
+			c.Status(http.StatusOK)
+			c.Writer.WriteHeaderNow()
+			started = true
+		}
+	}
+
+	err := h.service.StreamUsers(c.Request.Context(), func(u *model.UserListItem) error {
+		start()
+		if err := enc.Encode(model.ToUsersResponseIn([]*model.UserListItem{u}, loc)[0]); err != nil {
+			return err
+		}
+		c.Writer.Flush()
+		return nil
+	})
+	if err != nil {
+		if !started {
+			if h.abortIfCanceled(c, err) {
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch users"})
+			return
+		}
+		h.handlerLogger(c).Warn("users stream interrupted", zap.Error(err))
+		return
+	}
+
+	// Пустая база - 200 без строк
+	start()
+}
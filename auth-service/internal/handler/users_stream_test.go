@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestAuthHandler_GetUsers_NDJSON(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	created := time.Date(2026, 2, 15, 13, 0, 0, 0, time.UTC)
+	users := []*model.UserListItem{
+		{ID: uuid.New(), Username: "u1", Email: "e1@test.com", CreatedAt: created, UpdatedAt: created},
+		{ID: uuid.New(), Username: "u2", Email: "e2@test.com", CreatedAt: created, UpdatedAt: created},
+	}
+
+	newRouter := func(svc *mockAuthService, role string) *gin.Engine {
+		h := NewAuthHandler(svc, Config{}, zap.NewNop())
+		r := gin.New()
+		r.Use(RequestTimeout(time.Second, []string{"GET /users"}), func(c *gin.Context) { c.Set("role", role) })
+		r.GET("/users", h.GetUsers)
+		return r
+	}
+
+	do := func(r *gin.Engine, path, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept", accept)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Streams one user per line", func(t *testing.T) {
+		mockSvc := &mockAuthService{}
+		mockSvc.On("StreamUsers", mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) {
+				// Поток не получает дедлайн RequestTimeout
+				_, hasDeadline := args.Get(0).(context.Context).Deadline()
+				assert.False(t, hasDeadline)
+
+				fn := args.Get(1).(func(*model.UserListItem) error)
+				for _, u := range users {
+					require.NoError(t, fn(u))
+				}
+			}).
+			Return(nil)
+
+		w := do(newRouter(mockSvc, model.RoleAdmin), "/users", "application/x-ndjson")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+		assert.True(t, w.Flushed)
+
+		var lines []model.UsersResponse
+		scanner := bufio.NewScanner(strings.NewReader(w.Body.String()))
+		for scanner.Scan() {
+			var u model.UsersResponse
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &u))
+			lines = append(lines, u)
+		}
+		require.Len(t, lines, 2)
+		assert.Equal(t, "u1", lines[0].Username)
+		assert.Equal(t, "e2@test.com", lines[1].Email)
+		mockSvc.AssertExpectations(t)
+	})
+
+	t.Run("Empty stream", func(t *testing.T) {
+		mockSvc := &mockAuthService{}
+		mockSvc.On("StreamUsers", mock.Anything, mock.Anything).Return(nil)
+
+		w := do(newRouter(mockSvc, model.RoleAdmin), "/users", "application/x-ndjson")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Body.String())
+	})
+
+	t.Run("Error before first line - 500", func(t *testing.T) {
+		mockSvc := &mockAuthService{}
+		mockSvc.On("StreamUsers", mock.Anything, mock.Anything).Return(assert.AnError)
+
+		w := do(newRouter(mockSvc, model.RoleAdmin), "/users", "application/x-ndjson")
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Contains(t, w.Body.String(), "failed to fetch users")
+	})
+
+	t.Run("Admins only", func(t *testing.T) {
+		mockSvc := &mockAuthService{}
+
+		w := do(newRouter(mockSvc, model.RoleUser), "/users", "application/x-ndjson")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		mockSvc.AssertNotCalled(t, "StreamUsers", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Filters rejected", func(t *testing.T) {
+		mockSvc := &mockAuthService{}
+
+		w := do(newRouter(mockSvc, model.RoleAdmin), "/users?sort=username", "application/x-ndjson")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockSvc.AssertNotCalled(t, "StreamUsers", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Default Accept keeps JSON array", func(t *testing.T) {
+		mockSvc := &mockAuthService{}
+		mockSvc.On("ListUsers", mock.Anything, model.UsersFilter{}, 0, 0).
+			Return(&model.UsersPage{Users: users, Total: 2, Limit: 10}, nil)
+
+		w := do(newRouter(mockSvc, model.RoleAdmin), "/users", "*/*")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.True(t, strings.HasPrefix(w.Body.String(), "["))
+		mockSvc.AssertNotCalled(t, "StreamUsers", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,134 @@
+package hasher
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const argon2idPrefix = "$argon2id$"
+
+// Argon2Params - параметры argon2id, сохраняются в самом хеше
+type Argon2Params struct {
+	Memory     uint32 // KiB
+	Iterations uint32
+	Threads    uint8
+	SaltLength uint32
+	KeyLength  uint32
+}
+
+// DefaultArgon2Params - рекомендация OWASP для argon2id (m=64MiB, t=1)
+var DefaultArgon2Params = Argon2Params{
+	Memory:     64 * 1024,
+	Iterations: 1,
+	Threads:    4,
+	SaltLength: 16,
+	KeyLength:  32,
+}
+
+type argon2Hasher struct {
+	params Argon2Params
+}
+
+func NewArgon2id(params Argon2Params) PasswordHasher {
+	return &argon2Hasher{params: params}
+}
+
+// Hash возвращает хеш в PHC-формате:
+// $argon2id$v=19$m=65536,t=1,p=4$<salt>$<key>
+func (h *argon2Hasher) Hash(plain string) (string, error) {
+	salt := make([]byte, h.params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("generate salt: %w", err)
+	}
+
+	key := argon2.IDKey([]byte(plain), salt, h.params.Iterations, h.params.Memory, h.params.Threads, h.params.KeyLength)
+
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix,
+		argon2.Version,
+		h.params.Memory,
+		h.params.Iterations,
+		h.params.Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// Compare берет параметры из самого хеша, поэтому смена DefaultArgon2Params
+// не ломает проверку старых паролей
+func (h *argon2Hasher) Compare(hash, plain string) error {
+	params, salt, key, err := decodeArgon2Hash(hash)
+	if err != nil {
+		return err
+	}
+
+	other := argon2.IDKey([]byte(plain), salt, params.Iterations, params.Memory, params.Threads, uint32(len(key)))
+
+	if subtle.ConstantTimeCompare(key, other) != 1 {
+		return ErrMismatch
+	}
+	return nil
+}
+
+// NeedsRehash - не argon2id или любой из параметров слабее текущих
+func (h *argon2Hasher) NeedsRehash(hash string) bool {
+	params, _, _, err := decodeArgon2Hash(hash)
+	if err != nil {
+		return true
+	}
+	return params.Memory < h.params.Memory ||
+		params.Iterations < h.params.Iterations ||
+		params.Threads < h.params.Threads ||
+		params.SaltLength < h.params.SaltLength ||
+		params.KeyLength < h.params.KeyLength
+}
+
+func decodeArgon2Hash(hash string) (Argon2Params, []byte, []byte, error) {
+	var params Argon2Params
+
+	// "", "argon2id", "v=19", "m=...,t=...,p=...", salt, key
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return params, nil, nil, ErrUnknownHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return params, nil, nil, fmt.Errorf("parse argon2 version: %w", err)
+	}
+	if version != argon2.Version {
+		return params, nil, nil, fmt.Errorf("unsupported argon2 version %d", version)
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Threads); err != nil {
+		return params, nil, nil, fmt.Errorf("parse argon2 params: %w", err)
+	}
+	// t=0 и p=0 argon2.IDKey не принимает (p=0 - паника)
+	if params.Iterations == 0 || params.Threads == 0 {
+		return params, nil, nil, fmt.Errorf("argon2 iterations and threads must be positive")
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, fmt.Errorf("decode argon2 salt: %w", err)
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return params, nil, nil, fmt.Errorf("decode argon2 key: %w", err)
+	}
+	// Пустой ключ совпал бы с IDKey нулевой длины для любого пароля
+	if len(salt) == 0 || len(key) == 0 {
+		return params, nil, nil, fmt.Errorf("argon2 hash has empty salt or key")
+	}
+
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+
+	return params, salt, key, nil
+}
@@ -0,0 +1,74 @@
+package hasher
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Поддерживаемые алгоритмы (значения config.Security.HashAlgorithm)
+const (
+	Bcrypt   = "bcrypt"
+	Argon2id = "argon2id"
+)
+
+var (
+	ErrMismatch    = errors.New("hash and password mismatch")
+	ErrUnknownHash = errors.New("unknown hash format")
+)
+
+// PasswordHasher хеширует пароли и сверяет их с сохраненным хешем
+type PasswordHasher interface {
+	Hash(plain string) (string, error)
+	Compare(hash, plain string) error
+	// NeedsRehash - хеш сделан другим алгоритмом или более слабыми параметрами, чем Hash сделал бы сейчас
+	NeedsRehash(hash string) bool
+}
+
+// multiHasher хеширует выбранным алгоритмом, а сверяет тем алгоритмом,
+// который определен по префиксу хеша. Так старые bcrypt-хеши продолжают
+// работать после смены алгоритма по умолчанию.
+type multiHasher struct {
+	primary PasswordHasher
+	bcrypt  PasswordHasher
+	argon2  PasswordHasher
+}
+
+// New возвращает хешер для алгоритма из конфига; bcryptCost <= 0 - bcrypt.DefaultCost
+func New(algorithm string, bcryptCost int) (PasswordHasher, error) {
+	h := &multiHasher{
+		bcrypt: NewBcrypt(bcryptCost),
+		argon2: NewArgon2id(DefaultArgon2Params),
+	}
+
+	switch algorithm {
+	case Bcrypt:
+		h.primary = h.bcrypt
+	case Argon2id:
+		h.primary = h.argon2
+	default:
+		return nil, fmt.Errorf("unsupported hash algorithm %q", algorithm)
+	}
+
+	return h, nil
+}
+
+func (h *multiHasher) Hash(plain string) (string, error) {
+	return h.primary.Hash(plain)
+}
+
+func (h *multiHasher) Compare(hash, plain string) error {
+	switch {
+	case strings.HasPrefix(hash, argon2idPrefix):
+		return h.argon2.Compare(hash, plain)
+	case isBcryptHash(hash):
+		return h.bcrypt.Compare(hash, plain)
+	default:
+		return ErrUnknownHash
+	}
+}
+
+// NeedsRehash решает основной хешер: хеш другого алгоритма для него всегда устаревший
+func (h *multiHasher) NeedsRehash(hash string) bool {
+	return h.primary.NeedsRehash(hash)
+}
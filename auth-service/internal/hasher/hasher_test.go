@@ -0,0 +1,141 @@
+package hasher
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Дешевые параметры, чтобы тесты не тратили 64MiB на каждый хеш
+var testArgon2Params = Argon2Params{Memory: 1024, Iterations: 1, Threads: 1, SaltLength: 16, KeyLength: 32}
+
+func TestHashers(t *testing.T) {
+	hashers := map[string]PasswordHasher{
+		Bcrypt:   NewBcrypt(bcrypt.MinCost),
+		Argon2id: NewArgon2id(testArgon2Params),
+	}
+
+	for name, h := range hashers {
+		t.Run(name, func(t *testing.T) {
+			hash, err := h.Hash("password123")
+			require.NoError(t, err)
+			assert.NotEqual(t, "password123", hash)
+
+			assert.NoError(t, h.Compare(hash, "password123"))
+			assert.ErrorIs(t, h.Compare(hash, "wrong"), ErrMismatch)
+
+			// Соль случайная - одинаковые пароли дают разные хеши
+			other, err := h.Hash("password123")
+			require.NoError(t, err)
+			assert.NotEqual(t, hash, other)
+		})
+	}
+}
+
+// bcrypt видит только 72 байта пароля; x/crypto отказывается хешировать больше,
+// поэтому длина пароля валидируется в байтах (model.DefaultPasswordMaxBytes)
+func TestBcrypt_72ByteLimit(t *testing.T) {
+	h := NewBcrypt(bcrypt.MinCost)
+
+	// 37 символов кириллицы - 74 байта
+	_, err := h.Hash(strings.Repeat("ж", 37))
+	assert.ErrorIs(t, err, bcrypt.ErrPasswordTooLong)
+
+	// При сравнении bcrypt смотрит только на первые 72 байта: все, что дальше, молча теряется
+	hash, err := h.Hash(strings.Repeat("ж", 36))
+	require.NoError(t, err)
+	assert.NoError(t, h.Compare(hash, strings.Repeat("ж", 36)+"anything"))
+
+	// argon2id ограничения нет
+	a := NewArgon2id(testArgon2Params)
+	long, err := a.Hash(strings.Repeat("ж", 37))
+	require.NoError(t, err)
+	assert.ErrorIs(t, a.Compare(long, strings.Repeat("ж", 36)), ErrMismatch)
+}
+
+func TestArgon2id_Format(t *testing.T) {
+	hash, err := NewArgon2id(testArgon2Params).Hash("password123")
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$"), hash)
+
+	// Параметры читаются из хеша, а не из хешера
+	assert.NoError(t, NewArgon2id(DefaultArgon2Params).Compare(hash, "password123"))
+}
+
+func TestArgon2id_MalformedHash(t *testing.T) {
+	h := NewArgon2id(testArgon2Params)
+
+	assert.ErrorIs(t, h.Compare("$argon2id$broken", "x"), ErrUnknownHash)
+	assert.Error(t, h.Compare("$argon2id$v=19$m=x,t=1,p=1$c2FsdA$a2V5", "x"))
+	assert.Error(t, h.Compare("$argon2id$v=19$m=1024,t=1,p=1$!!!$a2V5", "x"))
+	assert.Error(t, h.Compare("$argon2id$v=19$m=1024,t=0,p=0$c2FsdA$a2V5", "x"))
+
+	// Пустые соль или ключ - испорченный хеш, а не совпадение с любым паролем
+	assert.Error(t, h.Compare("$argon2id$v=19$m=1024,t=1,p=1$c2FsdA$", "anything"))
+	assert.Error(t, h.Compare("$argon2id$v=19$m=1024,t=1,p=1$$a2V5", "anything"))
+	assert.True(t, h.NeedsRehash("$argon2id$v=19$m=1024,t=1,p=1$c2FsdA$"))
+}
+
+func TestNew(t *testing.T) {
+	t.Run("Unsupported algorithm", func(t *testing.T) {
+		_, err := New("md5", 0)
+		assert.Error(t, err)
+	})
+
+	t.Run("Bcrypt hashes still verify after switching to argon2id", func(t *testing.T) {
+		legacy, err := NewBcrypt(bcrypt.MinCost).Hash("password123")
+		require.NoError(t, err)
+
+		h, err := New(Argon2id, 0)
+		require.NoError(t, err)
+
+		assert.NoError(t, h.Compare(legacy, "password123"))
+		assert.ErrorIs(t, h.Compare(legacy, "wrong"), ErrMismatch)
+
+		fresh, err := h.Hash("password123")
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(fresh, "$argon2id$"))
+		assert.NoError(t, h.Compare(fresh, "password123"))
+	})
+
+	t.Run("Unknown hash prefix", func(t *testing.T) {
+		h, err := New(Bcrypt, 0)
+		require.NoError(t, err)
+		assert.ErrorIs(t, h.Compare("plaintext", "plaintext"), ErrUnknownHash)
+	})
+}
+
+func TestNeedsRehash(t *testing.T) {
+	weakBcrypt, err := NewBcrypt(bcrypt.MinCost).Hash("password123")
+	require.NoError(t, err)
+	weakArgon2, err := NewArgon2id(testArgon2Params).Hash("password123")
+	require.NoError(t, err)
+
+	t.Run("Bcrypt cost raised", func(t *testing.T) {
+		assert.False(t, NewBcrypt(bcrypt.MinCost).NeedsRehash(weakBcrypt))
+		assert.True(t, NewBcrypt(bcrypt.MinCost+1).NeedsRehash(weakBcrypt))
+	})
+
+	t.Run("Argon2id params raised", func(t *testing.T) {
+		assert.False(t, NewArgon2id(testArgon2Params).NeedsRehash(weakArgon2))
+
+		stronger := testArgon2Params
+		stronger.Iterations = 2
+		assert.True(t, NewArgon2id(stronger).NeedsRehash(weakArgon2))
+	})
+
+	t.Run("Algorithm switched", func(t *testing.T) {
+		h, err := New(Argon2id, 0)
+		require.NoError(t, err)
+		assert.True(t, h.NeedsRehash(weakBcrypt))
+
+		h, err = New(Bcrypt, bcrypt.MinCost)
+		require.NoError(t, err)
+		assert.True(t, h.NeedsRehash(weakArgon2))
+		assert.False(t, h.NeedsRehash(weakBcrypt))
+	})
+}
@@ -1,6 +1,8 @@
 package logger
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -22,7 +24,7 @@ func TestNewLogger(t *testing.T) {
 		}
 
 		for _, tt := range tests {
-			l, err := New(tt.levelStr, "prod")
+			l, err := New(tt.levelStr, "prod", Sampling{}, File{})
 			assert.NoError(t, err)
 			assert.NotNil(t, l)
 
@@ -39,7 +41,7 @@ func TestNewLogger(t *testing.T) {
 	t.Run("Check Production Mode (JSON)", func(t *testing.T) {
 		// В zapcore сложно напрямую вытащить тип энкодера из ядра,
 		// но мы можем проверить конфигурацию через инициализацию.
-		l, err := New("info", "prod")
+		l, err := New("info", "prod", Sampling{}, File{})
 		assert.NoError(t, err)
 		assert.NotNil(t, l)
 
@@ -49,10 +51,41 @@ func TestNewLogger(t *testing.T) {
 	})
 
 	t.Run("Check Debug Mode (Console)", func(t *testing.T) {
-		l, err := New("debug", "debug")
+		l, err := New("debug", "debug", Sampling{Initial: 1, Thereafter: 100}, File{})
 		assert.NoError(t, err)
 		assert.NotNil(t, l)
 
 		assert.True(t, l.Core().Enabled(zap.DebugLevel))
 	})
+	t.Run("Sampling In Release Mode", func(t *testing.T) {
+		l, err := New("info", "release", Sampling{Initial: 1, Thereafter: 1000}, File{})
+		assert.NoError(t, err)
+
+		// Первая запись проходит, повтор в ту же секунду отбрасывается семплером
+		assert.NotNil(t, l.Check(zap.InfoLevel, "burst"))
+		l.Info("burst")
+		assert.Nil(t, l.Check(zap.InfoLevel, "burst"))
+	})
+
+	t.Run("No Sampling In Debug Mode", func(t *testing.T) {
+		l, err := New("debug", "debug", Sampling{Initial: 1, Thereafter: 1000}, File{})
+		assert.NoError(t, err)
+
+		l.Debug("burst")
+		assert.NotNil(t, l.Check(zap.DebugLevel, "burst"))
+	})
+	t.Run("Tee To File", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "auth.log")
+
+		l, err := New("info", "debug", Sampling{}, File{Path: path, MaxSizeMB: 1})
+		assert.NoError(t, err)
+
+		l.Info("written to file")
+		// Sync stdout в тестах может вернуть EINVAL, файл от этого не зависит
+		_ = l.Sync()
+
+		data, err := os.ReadFile(path)
+		assert.NoError(t, err)
+		assert.Contains(t, string(data), `"msg":"written to file"`)
+	})
 }
@@ -0,0 +1,26 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APIToken - долгоживущий токен пользователя для скриптов (Authorization: Token <value>).
+// Само значение в базе не хранится, только его хеш
+type APIToken struct {
+	ID         uuid.UUID  `json:"id"`
+	Label      string     `json:"label"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"` // nil - токеном еще не пользовались
+}
+
+// CreatedAPIToken - ответ на создание; Token показывается только здесь, один раз
+type CreatedAPIToken struct {
+	APIToken
+	Token string `json:"token"`
+}
+
+type CreateAPITokenRequest struct {
+	Label string `json:"label" validate:"required,max=100"`
+}
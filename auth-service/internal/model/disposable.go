@@ -0,0 +1,91 @@
+package model
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// DisposableDomains - блоклист доменов одноразовой почты (security.disposable_email).
+// Домен блокирует и свои поддомены: mailinator.com закрывает и eu.mailinator.com.
+// nil - блоклист выключен, Blocked всегда false.
+type DisposableDomains struct {
+	domains map[string]struct{}
+}
+
+// NewDisposableDomains строит блоклист; пустые строки пропускаются
+func NewDisposableDomains(domains []string) *DisposableDomains {
+	d := &DisposableDomains{domains: make(map[string]struct{}, len(domains))}
+	for _, domain := range domains {
+		if domain = normalizeDomain(domain); domain != "" {
+			d.domains[domain] = struct{}{}
+		}
+	}
+	return d
+}
+
+// LoadDisposableDomains читает файл блоклиста: по домену в строке, # - комментарий до конца строки.
+// extra добавляется к доменам из файла; пустой path - только extra
+func LoadDisposableDomains(path string, extra []string) (*DisposableDomains, error) {
+	domains := append([]string(nil), extra...)
+	if path == "" {
+		return NewDisposableDomains(domains), nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("disposable email blocklist: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		domains = append(domains, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("disposable email blocklist %s: %w", path, err)
+	}
+
+	return NewDisposableDomains(domains), nil
+}
+
+// Len - число доменов в блоклисте, для лога на старте
+func (d *DisposableDomains) Len() int {
+	if d == nil {
+		return 0
+	}
+	return len(d.domains)
+}
+
+// Blocked - домен email или один из его родительских доменов в блоклисте.
+// email ожидается после NormalizeEmail, но домен нормализуется и здесь
+func (d *DisposableDomains) Blocked(email string) bool {
+	if d == nil || len(d.domains) == 0 {
+		return false
+	}
+
+	at := strings.LastIndexByte(email, '@')
+	if at < 0 {
+		return false
+	}
+
+	domain := normalizeDomain(email[at+1:])
+	for domain != "" {
+		if _, ok := d.domains[domain]; ok {
+			return true
+		}
+		_, parent, found := strings.Cut(domain, ".")
+		if !found {
+			break
+		}
+		domain = parent
+	}
+	return false
+}
+
+// normalizeDomain - нижний регистр, без пробелов и точки в конце (example.com. - тот же домен)
+func normalizeDomain(domain string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+}
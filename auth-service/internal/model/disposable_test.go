@@ -0,0 +1,56 @@
+package model
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDisposableDomains(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "disposable.txt")
+	blocklist := "# sample blocklist\nmailinator.com\n\n  Guerrillamail.COM  # with comment\ntrash.example.org.\n"
+	require.NoError(t, os.WriteFile(path, []byte(blocklist), 0o600))
+
+	d, err := LoadDisposableDomains(path, []string{"yopmail.com"})
+	require.NoError(t, err)
+	assert.Equal(t, 4, d.Len())
+
+	tests := []struct {
+		email   string
+		blocked bool
+	}{
+		{"user@mailinator.com", true},
+		{"user@eu.mailinator.com", true},
+		{"user@a.b.mailinator.com", true},
+		{"user@GuerrillaMail.com", true},
+		{"user@mailinator.com.", true},
+		{"user@trash.example.org", true},
+		{"user@yopmail.com", true},
+		{"user@notmailinator.com", false},
+		{"user@mailinator.com.evil.net", false},
+		{"user@example.org", false},
+		{"user@gmail.com", false},
+		{"\"a@mailinator.com\"@example.com", false},
+		{"no-at-sign", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.email, func(t *testing.T) {
+			assert.Equal(t, tt.blocked, d.Blocked(tt.email))
+		})
+	}
+
+	t.Run("Disabled", func(t *testing.T) {
+		var off *DisposableDomains
+		assert.False(t, off.Blocked("user@mailinator.com"))
+		assert.Zero(t, off.Len())
+	})
+
+	t.Run("Missing file", func(t *testing.T) {
+		_, err := LoadDisposableDomains(filepath.Join(t.TempDir(), "missing.txt"), nil)
+		assert.Error(t, err)
+	})
+}
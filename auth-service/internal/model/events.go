@@ -0,0 +1,21 @@
+package model
+
+// Типы событий о пользователях, которые уходят внешним подписчикам
+const (
+	EventUserRegistered   = "user.registered"
+	EventUserUpdated      = "user.updated"
+	EventUserEmailChanged = "user.email_changed"
+	EventUserDeleted      = "user.deleted"
+	// EventUserPasswordResetIssued - администратор выдал токен сброса пароля (ActorID - кто)
+	EventUserPasswordResetIssued = "user.password_reset_issued"
+	EventUserPasswordReset       = "user.password_reset"
+)
+
+// UserEvent - данные события; хеш пароля сюда не попадает никогда
+type UserEvent struct {
+	UserID   string `json:"user_id"`
+	Username string `json:"username,omitempty"`
+	Email    string `json:"email,omitempty"`
+	// ActorID - кто совершил действие, если не сам пользователь (администратор)
+	ActorID string `json:"actor_id,omitempty"`
+}
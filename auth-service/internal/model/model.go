@@ -1,42 +1,115 @@
 package model
 
 import (
+	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
 
+// Роли пользователей (колонка users.role)
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// User - полная модель. Password (хеш) заполняют только GetCredentials*: он нужен
+// лишь для проверки пароля. Списки пользователей возвращают UserListItem
 type User struct {
+	ID          uuid.UUID
+	Username    string
+	Email       string
+	Password    string
+	Role        string
+	Version     int    // растет при каждом изменении строки (optimistic locking)
+	AvatarKey   string // ключ в storage.StorageProvider; "" - аватара нет
+	DisplayName string
+	Bio         string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// UserListItem - строка списка пользователей (GET /users, выгрузка): только то,
+// что нужно UsersResponse, без хеша пароля и полей профиля
+type UserListItem struct {
 	ID        uuid.UUID
 	Username  string
 	Email     string
-	Password  string
+	Role      string
+	Version   int
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
 
 type CreateUserRequest struct {
-	Username string `json:"username" validate:"required,min=2,max=50"`
+	Username string `json:"username" validate:"required,min=2,max=50,username"`
 	Email    string `json:"email" validate:"required,strict_email"`
-	Password string `json:"password" validate:"required,min=8,max=72"`
+	Password string `json:"password" validate:"required,min=8,password_bytes,strong_password"`
 }
 
 type UserResponse struct {
-	ID        uuid.UUID `json:"id"`
-	Username  string    `json:"username"`
-	Email     string    `json:"email"`
-	CreatedAt string    `json:"created_at"`
-	UpdatedAt string    `json:"updated_at"`
+	ID          uuid.UUID `json:"id"`
+	Username    string    `json:"username"`
+	Email       string    `json:"email"`
+	Role        string    `json:"role"`
+	Version     int       `json:"version"`
+	DisplayName string    `json:"display_name"`
+	Bio         string    `json:"bio"`
+	AvatarURL   string    `json:"avatar_url,omitempty"` // заполняет хендлер; для S3 - свежая подписанная ссылка
+	CreatedAt   string    `json:"created_at"`
+	UpdatedAt   string    `json:"updated_at"`
 }
 
+// UsersResponse - элемент списка пользователей. Email заполняется только для
+// администратора (см. GET /users), для остальных поле в JSON отсутствует.
 type UsersResponse struct {
 	ID        uuid.UUID `json:"id"`
 	Username  string    `json:"username"`
+	Email     string    `json:"email,omitempty"`
+	Role      string    `json:"role"`
 	CreatedAt string    `json:"created_at"`
 	UpdatedAt string    `json:"updated_at"`
 }
 
+// UsersPage - страница списка пользователей: Limit и Offset уже нормализованы сервисом,
+// Total - сколько всего подходит под фильтр (для X-Total-Count и Link)
+type UsersPage struct {
+	Users  []*UserListItem
+	Total  int
+	Limit  int
+	Offset int
+}
+
+// UsersFilter - необязательные фильтры для списка пользователей (nil = без ограничения)
+type UsersFilter struct {
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	// SortBy - одно из UsersSortFields; пустое значение = created_at DESC
+	SortBy   string
+	SortDesc bool
+}
+
+// UsersSortFields - поля, по которым разрешено сортировать список пользователей
+var UsersSortFields = []string{"created_at", "username", "email"}
+
+// ParseUsersSort разбирает параметр sort вида "username" или "-created_at" (минус = по убыванию)
+func ParseUsersSort(raw string) (field string, desc bool, err error) {
+	field = raw
+	if strings.HasPrefix(field, "-") {
+		field = field[1:]
+		desc = true
+	}
+
+	if !slices.Contains(UsersSortFields, field) {
+		return "", false, fmt.Errorf("invalid sort field %q, allowed: %s", field, strings.Join(UsersSortFields, ", "))
+	}
+
+	return field, desc, nil
+}
+
 // LoginRequest - то, что шлет клиент
 type LoginRequest struct {
 	Email    string `json:"email" validate:"required,strict_email"`
@@ -47,18 +120,125 @@ type LoginRequest struct {
 type UserClaims struct {
 	UserID   uuid.UUID `json:"user_id"`
 	Username string    `json:"username"`
+	Role     string    `json:"role"`
+	// SessionID - только в режиме auth.single_session: токен действителен, пока sid совпадает с users.session_id
+	SessionID string `json:"sid,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// UserSummary - публичные данные пользователя для других сервисов (авторы постов в post-service)
+type UserSummary struct {
+	ID          uuid.UUID `json:"id"`
+	Username    string    `json:"username"`
+	DisplayName string    `json:"display_name,omitempty"`
+}
+
+// MaxLookupUsers - сколько id и username вместе можно запросить в одном POST /auth/users/lookup
+const MaxLookupUsers = 100
+
+// LookupUsersRequest - тело POST /auth/users/lookup: по id (авторы постов) и/или по username
+// (@-упоминания в post-service); нужен хотя бы один из списков
+type LookupUsersRequest struct {
+	IDs       []string `json:"ids"`
+	Usernames []string `json:"usernames"`
+}
+
+// LookupUsersResponse - найденные пользователи, каждый один раз; неизвестных id и username в ответе нет
+type LookupUsersResponse struct {
+	Items []*UserSummary `json:"items"`
+}
+
+// IntrospectRequest - тело POST /auth/token/introspect (RFC 7662 допускает и form, и json)
+type IntrospectRequest struct {
+	Token string `json:"token" form:"token" binding:"required"`
+}
+
+// IntrospectResponse - ответ в стиле RFC 7662; для неактивного токена заполнено только Active
+type IntrospectResponse struct {
+	Active   bool   `json:"active"`
+	UserID   string `json:"user_id,omitempty"`
+	Username string `json:"username,omitempty"`
+	Role     string `json:"role,omitempty"`
+	Exp      int64  `json:"exp,omitempty"`
+}
+
+// DeleteAccountRequest - подтверждение удаления своего аккаунта текущим паролем
+type DeleteAccountRequest struct {
+	Password string `json:"password" validate:"required"`
+}
+
 type ChangePasswordRequest struct {
 	OldPassword string `json:"old_password" validate:"required"`
-	NewPassword string `json:"new_password" validate:"required,min=8,max=72"`
+	NewPassword string `json:"new_password" validate:"required,min=8,password_bytes"`
 }
 
+// PasswordResetToken - выданный администратором одноразовый токен сброса пароля.
+// Token возвращается только в ответе на выдачу, в базе хранится его хеш
+type PasswordResetToken struct {
+	Token     string    `json:"reset_token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// ResetPasswordRequest - новый пароль по токену из PasswordResetToken
+type ResetPasswordRequest struct {
+	Token       string `json:"reset_token" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required,min=8,password_bytes"`
+}
+
+// Version в запросах на изменение - версия из UserResponse, которую видел клиент.
+// Если ее успели изменить, ответ будет 409; 0 или отсутствие поля - без проверки.
 type ChangeProfileRequest struct {
-	NewUsername string `json:"new_username" validate:"required,min=2,max=50"`
+	NewUsername string `json:"new_username" validate:"required,min=2,max=50,username"`
+	Version     int    `json:"version,omitempty" validate:"gte=0"`
 }
 
+// PatchProfileRequest - PATCH /user/profile: меняются только присланные поля, отсутствующее (nil) не трогается.
+// Пустая строка в display_name или bio очищает поле; username очистить нельзя.
+type PatchProfileRequest struct {
+	Username    *string `json:"username" validate:"omitnil,min=2,max=50,username"`
+	DisplayName *string `json:"display_name" validate:"omitnil,max=100"`
+	Bio         *string `json:"bio" validate:"omitnil,max=500"`
+	Version     int     `json:"version,omitempty" validate:"gte=0"`
+}
+
+// ProfilePatch - набор изменений профиля для репозитория; nil - поле не меняется
+type ProfilePatch struct {
+	Username    *string
+	DisplayName *string
+	Bio         *string
+}
+
+// Patch - изменения из запроса без служебного version
+func (r *PatchProfileRequest) Patch() ProfilePatch {
+	return ProfilePatch{Username: r.Username, DisplayName: r.DisplayName, Bio: r.Bio}
+}
+
+// IsEmpty - ни одного поля не прислали
+func (p ProfilePatch) IsEmpty() bool {
+	return p.Username == nil && p.DisplayName == nil && p.Bio == nil
+}
+
+// PasswordCheckRequest - запрос индикатора надежности пароля (username/email опциональны)
+type PasswordCheckRequest struct {
+	Password string `json:"password" validate:"required"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+// ChangeEmailRequest - смена email подтверждается текущим паролем, как и удаление аккаунта
 type ChangeEmailRequest struct {
-	NewEmail string `json:"new_email" validate:"required,strict_email"`
+	NewEmail        string `json:"new_email" validate:"required,strict_email"`
+	CurrentPassword string `json:"current_password" validate:"required"`
+	Version         int    `json:"version,omitempty" validate:"gte=0"`
+}
+
+// AvailabilityQuery - GET /auth/available: ровно одно из полей, с теми же правилами, что и при регистрации
+type AvailabilityQuery struct {
+	Username string `form:"username" json:"username" validate:"required_without=Email,excluded_with=Email,omitempty,min=2,max=50,username"`
+	Email    string `form:"email" json:"email" validate:"required_without=Username,omitempty,strict_email"`
+}
+
+// AvailabilityResponse - свободен ли username/email для регистрации
+type AvailabilityResponse struct {
+	Available bool `json:"available"`
 }
@@ -0,0 +1,48 @@
+package model
+
+import "strings"
+
+// NormalizeUsername - username хранится как ввели, но без пробелов по краям
+func NormalizeUsername(username string) string {
+	return strings.TrimSpace(username)
+}
+
+// NormalizeEmail - email сравнивается без учета регистра, поэтому храним его в нижнем регистре
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// Normalize приводит поля к тому виду, в котором они попадут в БД. Вызывается до валидации.
+func (r *CreateUserRequest) Normalize() {
+	r.Username = NormalizeUsername(r.Username)
+	r.Email = NormalizeEmail(r.Email)
+}
+
+func (r *LoginRequest) Normalize() {
+	r.Email = NormalizeEmail(r.Email)
+}
+
+func (r *ChangeProfileRequest) Normalize() {
+	r.NewUsername = NormalizeUsername(r.NewUsername)
+}
+
+func (r *PatchProfileRequest) Normalize() {
+	if r.Username != nil {
+		*r.Username = NormalizeUsername(*r.Username)
+	}
+	if r.DisplayName != nil {
+		*r.DisplayName = strings.TrimSpace(*r.DisplayName)
+	}
+	if r.Bio != nil {
+		*r.Bio = strings.TrimSpace(*r.Bio)
+	}
+}
+
+func (r *ChangeEmailRequest) Normalize() {
+	r.NewEmail = NormalizeEmail(r.NewEmail)
+}
+
+func (q *AvailabilityQuery) Normalize() {
+	q.Username = NormalizeUsername(q.Username)
+	q.Email = NormalizeEmail(q.Email)
+}
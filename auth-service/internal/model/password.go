@@ -0,0 +1,130 @@
+package model
+
+import (
+	"strings"
+	"unicode"
+)
+
+const (
+	passwordMinLength  = 8
+	passwordLongLength = 12
+)
+
+// DefaultPasswordMaxBytes - bcrypt учитывает только первые 72 байта пароля, поэтому
+// длина ограничивается в байтах, а не в символах: 20 эмодзи - это уже 80 байт
+const DefaultPasswordMaxBytes = 72
+
+// Правила, без которых пароль не пройдет валидацию strong_password
+const (
+	RuleMinLength  = "min_length"
+	RuleMaxLength  = "max_length"
+	RuleHasLetter  = "has_letter"
+	RuleHasDigit   = "has_digit"
+	RuleNotSimilar = "not_similar"
+)
+
+// Рекомендательные правила — влияют только на score
+const (
+	RuleMixedCase  = "mixed_case"
+	RuleHasSpecial = "has_special"
+	RuleLong       = "long"
+)
+
+var requiredPasswordRules = []string{RuleMinLength, RuleMaxLength, RuleHasLetter, RuleHasDigit, RuleNotSimilar}
+
+var recommendedPasswordRules = []string{RuleMixedCase, RuleHasSpecial, RuleLong}
+
+// PasswordStrength - результат проверки пароля для индикатора на фронтенде
+type PasswordStrength struct {
+	Score       int      `json:"score"`
+	MaxScore    int      `json:"max_score"`
+	Valid       bool     `json:"valid"`
+	FailedRules []string `json:"failed_rules"`
+}
+
+// CheckPasswordStrength прогоняет пароль по тем же правилам, что и strong_password.
+// username и email опциональны и нужны для проверки на схожесть.
+func CheckPasswordStrength(password, username, email string) PasswordStrength {
+	return checkPasswordStrength(password, username, email, DefaultPasswordMaxBytes)
+}
+
+func checkPasswordStrength(password, username, email string, maxBytes int) PasswordStrength {
+	var hasLetter, hasDigit, hasUpper, hasLower, hasSpecial bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLetter(r):
+			hasLetter = true
+			if unicode.IsUpper(r) {
+				hasUpper = true
+			}
+			if unicode.IsLower(r) {
+				hasLower = true
+			}
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case !unicode.IsSpace(r):
+			hasSpecial = true
+		}
+	}
+
+	length := len([]rune(password))
+
+	passed := map[string]bool{
+		RuleMinLength:  length >= passwordMinLength,
+		RuleMaxLength:  len(password) <= maxBytes,
+		RuleHasLetter:  hasLetter,
+		RuleHasDigit:   hasDigit,
+		RuleNotSimilar: !isSimilarToIdentity(password, username, email),
+		RuleMixedCase:  hasUpper && hasLower,
+		RuleHasSpecial: hasSpecial,
+		RuleLong:       length >= passwordLongLength,
+	}
+
+	result := PasswordStrength{
+		MaxScore:    len(requiredPasswordRules) + len(recommendedPasswordRules),
+		Valid:       true,
+		FailedRules: make([]string, 0),
+	}
+
+	for _, rule := range requiredPasswordRules {
+		if passed[rule] {
+			result.Score++
+			continue
+		}
+		result.Valid = false
+		result.FailedRules = append(result.FailedRules, rule)
+	}
+
+	for _, rule := range recommendedPasswordRules {
+		if passed[rule] {
+			result.Score++
+			continue
+		}
+		result.FailedRules = append(result.FailedRules, rule)
+	}
+
+	return result
+}
+
+// isSimilarToIdentity - пароль не должен содержать имя пользователя или локальную часть email
+func isSimilarToIdentity(password, username, email string) bool {
+	lowered := strings.ToLower(password)
+
+	candidates := []string{username}
+	if at := strings.LastIndex(email, "@"); at > 0 {
+		candidates = append(candidates, email[:at])
+	}
+
+	for _, c := range candidates {
+		c = strings.ToLower(strings.TrimSpace(c))
+		// Слишком короткие идентификаторы дают ложные срабатывания
+		if len(c) < 3 {
+			continue
+		}
+		if strings.Contains(lowered, c) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,63 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckPasswordStrength(t *testing.T) {
+	t.Run("Strong password", func(t *testing.T) {
+		res := CheckPasswordStrength("Correct-Horse-42", "tester", "test@example.com")
+		assert.True(t, res.Valid)
+		assert.Equal(t, res.MaxScore, res.Score)
+		assert.Empty(t, res.FailedRules)
+	})
+
+	t.Run("Weak but valid password", func(t *testing.T) {
+		res := CheckPasswordStrength("password123", "", "")
+		assert.True(t, res.Valid)
+		assert.Contains(t, res.FailedRules, RuleMixedCase)
+		assert.Contains(t, res.FailedRules, RuleHasSpecial)
+		assert.Contains(t, res.FailedRules, RuleLong)
+		assert.Less(t, res.Score, res.MaxScore)
+	})
+
+	t.Run("Failed required rules", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			password string
+			rule     string
+		}{
+			{"Too short", "ab1", RuleMinLength},
+			{"No digit", "onlyletters", RuleHasDigit},
+			{"No letter", "1234567890", RuleHasLetter},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				res := CheckPasswordStrength(tt.password, "", "")
+				assert.False(t, res.Valid)
+				assert.Contains(t, res.FailedRules, tt.rule)
+			})
+		}
+	})
+
+	t.Run("Multibyte password over 72 bytes", func(t *testing.T) {
+		// 22 символа, но 82 байта - по числу символов проходил бы
+		res := CheckPasswordStrength(strings.Repeat("🔑", 20)+"a1", "", "")
+		assert.False(t, res.Valid)
+		assert.Contains(t, res.FailedRules, RuleMaxLength)
+	})
+
+	t.Run("Similar to username or email", func(t *testing.T) {
+		res := CheckPasswordStrength("Johnny2024!", "johnny", "")
+		assert.False(t, res.Valid)
+		assert.Contains(t, res.FailedRules, RuleNotSimilar)
+
+		res = CheckPasswordStrength("xMailbox99x", "", "mailbox@example.com")
+		assert.False(t, res.Valid)
+		assert.Contains(t, res.FailedRules, RuleNotSimilar)
+	})
+}
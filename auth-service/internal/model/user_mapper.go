@@ -13,32 +13,48 @@ func ToDomain(req CreateUserRequest) (*User, error) {
 	}, nil
 }
 
+// ToResponse отдает даты в UTC, чтобы ответ не зависел от часового пояса сервера
 func ToResponse(user *User) UserResponse {
+	return ToResponseIn(user, time.UTC)
+}
+
+// ToResponseIn - то же, что ToResponse, но даты в часовом поясе loc
+func ToResponseIn(user *User, loc *time.Location) UserResponse {
 
-	createdAt := dateFormating(user.CreatedAt)
-	updatedAt := dateFormating(user.UpdatedAt)
+	createdAt := dateFormating(user.CreatedAt, loc)
+	updatedAt := dateFormating(user.UpdatedAt, loc)
 
 	return UserResponse{
-		ID:        user.ID,
-		Username:  user.Username,
-		Email:     user.Email,
-		CreatedAt: createdAt,
-		UpdatedAt: updatedAt,
+		ID:          user.ID,
+		Username:    user.Username,
+		Email:       user.Email,
+		Role:        user.Role,
+		Version:     user.Version,
+		DisplayName: user.DisplayName,
+		Bio:         user.Bio,
+		CreatedAt:   createdAt,
+		UpdatedAt:   updatedAt,
 	}
 }
 
-func ToUsersResponse(users []*User) []UsersResponse {
+func ToUsersResponse(users []*UserListItem) []UsersResponse {
+	return ToUsersResponseIn(users, time.UTC)
+}
+
+func ToUsersResponseIn(users []*UserListItem, loc *time.Location) []UsersResponse {
 
 	result := make([]UsersResponse, 0, len(users))
 
 	for _, u := range users {
 
-		createdAt := dateFormating(u.CreatedAt)
-		updatedAt := dateFormating(u.UpdatedAt)
+		createdAt := dateFormating(u.CreatedAt, loc)
+		updatedAt := dateFormating(u.UpdatedAt, loc)
 
 		user := UsersResponse{
 			ID:        u.ID,
 			Username:  u.Username,
+			Email:     u.Email,
+			Role:      u.Role,
 			CreatedAt: createdAt,
 			UpdatedAt: updatedAt,
 		}
@@ -48,6 +64,6 @@ func ToUsersResponse(users []*User) []UsersResponse {
 	return result
 }
 
-func dateFormating(date time.Time) string {
-	return date.Local().Format(dateFormat)
+func dateFormating(date time.Time, loc *time.Location) string {
+	return date.In(loc).Format(dateFormat)
 }
@@ -37,12 +37,20 @@ func TestMappers(t *testing.T) {
 		resp := ToResponse(user)
 		assert.Equal(t, id, resp.ID)
 		assert.Equal(t, user.Username, resp.Username)
-		// Проверяем формат даты (02.01.2006 15:04:05)
-		assert.Equal(t, now.Local().Format(dateFormat), resp.CreatedAt)
+		// Проверяем формат даты (02.01.2006 15:04:05) - всегда UTC, независимо от TZ сервера
+		assert.Equal(t, "15.02.2026 13:00:00", resp.CreatedAt)
+	})
+
+	t.Run("ToResponseIn", func(t *testing.T) {
+		moscow := time.FixedZone("MSK", 3*60*60)
+		user := &User{CreatedAt: time.Date(2026, 2, 15, 13, 0, 0, 0, time.UTC)}
+
+		resp := ToResponseIn(user, moscow)
+		assert.Equal(t, "15.02.2026 16:00:00", resp.CreatedAt)
 	})
 
 	t.Run("ToUsersResponse", func(t *testing.T) {
-		users := []*User{
+		users := []*UserListItem{
 			{ID: uuid.New(), Username: "user1", CreatedAt: time.Now()},
 			{ID: uuid.New(), Username: "user2", CreatedAt: time.Now()},
 		}
@@ -52,4 +60,18 @@ func TestMappers(t *testing.T) {
 		assert.Equal(t, users[0].Username, resp[0].Username)
 		assert.Equal(t, users[1].Username, resp[1].Username)
 	})
+
+	t.Run("Role and email", func(t *testing.T) {
+		user := &User{ID: uuid.New(), Username: "admin", Email: "admin@test.com", Password: "hash", Role: RoleAdmin}
+
+		assert.Equal(t, RoleAdmin, ToResponse(user).Role)
+
+		list := ToUsersResponse([]*UserListItem{{ID: user.ID, Username: user.Username, Email: user.Email, Role: user.Role}})
+		assert.Equal(t, RoleAdmin, list[0].Role)
+		assert.Equal(t, "admin@test.com", list[0].Email)
+	})
+
+	t.Run("Version", func(t *testing.T) {
+		assert.Equal(t, 4, ToResponse(&User{ID: uuid.New(), Version: 4}).Version)
+	})
 }
@@ -1,6 +1,10 @@
 package model
 
 import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"reflect"
 	"regexp"
 	"strings"
 
@@ -11,25 +15,139 @@ import (
 // MustCompile вызовет панику при старте, если регулярка кривая (это хорошо для отлова ошибок).
 var emailRegex = regexp.MustCompile(`^(?P<local>[a-zA-Z0-9._%+\-]+)@(?P<domain>([a-zA-Z0-9\-]+\.)+[a-zA-Z]{2,})$`)
 
+// Части emailRegex по отдельности - для lenient-режима, где у каждой части есть альтернатива
+var (
+	emailLocalRegex  = regexp.MustCompile(`^[a-zA-Z0-9._%+\-]+$`)
+	emailDomainRegex = regexp.MustCompile(`^([a-zA-Z0-9\-]+\.)+[a-zA-Z]{2,}$`)
+)
+
+// Режимы проверки email (security.email_mode). Тег в обоих режимах - strict_email,
+// чтобы правило в ошибках валидации не зависело от конфига.
+const (
+	// EmailModeStrict - только dot-atom и доменные имена
+	EmailModeStrict = "strict"
+	// EmailModeLenient - дополнительно локальная часть в кавычках ("john doe"@example.com)
+	// и IP-литерал вместо домена (user@[192.168.0.1], user@[IPv6:2001:db8::1])
+	EmailModeLenient = "lenient"
+)
+
+// DefaultUsernamePattern - допустимый username, если security.username_pattern не задан:
+// латиница, цифры и подчеркивание - такой handle безопасен в URL и в будущих @-упоминаниях
+const DefaultUsernamePattern = `^[a-zA-Z0-9_]{2,50}$`
+
+// defaultUsernameMessage - пояснение к правилу username для DefaultUsernamePattern
+const defaultUsernameMessage = "username must be 2-50 characters long and contain only latin letters, digits and underscores"
+
 // Validator - обертка над библиотекой валидации
 type Validator struct {
-	validate *validator.Validate
+	validate         *validator.Validate
+	passwordMaxBytes int
+	usernameRegex    *regexp.Regexp
+	usernameMessage  string
 }
 
-// NewValidator создает новый экземпляр
-func NewValidator() *Validator {
+// NewValidator создает новый экземпляр. passwordMaxBytes - предел длины пароля
+// в байтах (security.password_max_bytes), 0 - DefaultPasswordMaxBytes.
+// emailMode - EmailModeStrict или EmailModeLenient; пустая строка - strict.
+// usernamePattern - регулярка для правила username, пустая строка - DefaultUsernamePattern;
+// кривая регулярка - паника, конфиг проверяет ее раньше (Config.Validate).
+func NewValidator(passwordMaxBytes int, emailMode, usernamePattern string) *Validator {
+	if passwordMaxBytes <= 0 {
+		passwordMaxBytes = DefaultPasswordMaxBytes
+	}
+	usernameMessage := defaultUsernameMessage
+	if usernamePattern == "" || usernamePattern == DefaultUsernamePattern {
+		usernamePattern = DefaultUsernamePattern
+	} else {
+		usernameMessage = fmt.Sprintf("username must match %s", usernamePattern)
+	}
+
 	v := validator.New()
+	result := &Validator{
+		validate:         v,
+		passwordMaxBytes: passwordMaxBytes,
+		usernameRegex:    regexp.MustCompile(usernamePattern),
+		usernameMessage:  usernameMessage,
+	}
 
 	// Регистрируем наш кастомный валидатор
 	// Назовем его "strict_email", чтобы отличать от встроенного
-	_ = v.RegisterValidation("strict_email", validateEmail)
+	emailFunc := validateEmail
+	if emailMode == EmailModeLenient {
+		emailFunc = validateEmailLenient
+	}
+	_ = v.RegisterValidation("strict_email", emailFunc)
+	_ = v.RegisterValidation("strong_password", result.validateStrongPassword)
+	_ = v.RegisterValidation("password_bytes", result.validatePasswordBytes)
+	_ = v.RegisterValidation("username", result.validateUsername)
+
+	// В ошибках используем имена полей из json-тегов - именно их видит фронтенд
+	v.RegisterTagNameFunc(jsonFieldName)
+
+	return result
+}
+
+// PasswordStrength - CheckPasswordStrength с пределом длины этого валидатора
+func (v *Validator) PasswordStrength(password, username, email string) PasswordStrength {
+	return checkPasswordStrength(password, username, email, v.passwordMaxBytes)
+}
 
-	return &Validator{validate: v}
+// FieldError - одно нарушенное правило конкретного поля (имя поля берется из json-тега).
+// Message - пояснение для правил, по имени которых не понять, что исправить (username)
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message,omitempty"`
 }
 
-// ValidateStruct - метод для проверки структур
+// ValidationError - типизированная ошибка валидации, которую хендлер может отрисовать по полям
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// Error возвращает человекочитаемую сводку для клиентов без UI
+func (e *ValidationError) Error() string {
+	parts := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		part := fmt.Sprintf("field '%s' failed on the '%s' rule", f.Field, f.Rule)
+		if f.Message != "" {
+			part += ": " + f.Message
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// ValidateStruct - метод для проверки структур.
+// Ошибки правил возвращаются как *ValidationError.
 func (v *Validator) ValidateStruct(s interface{}) error {
-	return v.validate.Struct(s)
+	err := v.validate.Struct(s)
+	if err == nil {
+		return nil
+	}
+
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return err
+	}
+
+	result := &ValidationError{Fields: make([]FieldError, 0, len(validationErrors))}
+	for _, fe := range validationErrors {
+		field := FieldError{
+			Field: fe.Field(),
+			Rule:  fe.Tag(),
+		}
+		if fe.Tag() == "username" {
+			field.Message = v.usernameMessage
+		}
+		result.Fields = append(result.Fields, field)
+	}
+	return result
+}
+
+// validateUsername - username целиком подходит под security.username_pattern
+func (v *Validator) validateUsername(fl validator.FieldLevel) bool {
+	return v.usernameRegex.MatchString(fl.Field().String())
 }
 
 func validateEmail(fl validator.FieldLevel) bool {
@@ -58,3 +176,102 @@ func validateEmail(fl validator.FieldLevel) bool {
 
 	return true
 }
+
+// validateEmailLenient - как validateEmail, но принимает кавычки в локальной части и IP-литерал
+// в домене (RFC 5321). Комментарии, folding whitespace и прочее из RFC 5322 по-прежнему не проходят.
+func validateEmailLenient(fl validator.FieldLevel) bool {
+	email := fl.Field().String()
+	if len(email) < 3 || len(email) > 254 {
+		return false
+	}
+
+	// В кавычках может встретиться @, поэтому домен - все после последней
+	at := strings.LastIndex(email, "@")
+	if at <= 0 {
+		return false
+	}
+	local, domain := email[:at], email[at+1:]
+
+	if len(local) > 64 || !(isDotAtomLocal(local) || isQuotedLocal(local)) {
+		return false
+	}
+	return emailDomainRegex.MatchString(domain) || isIPLiteral(domain)
+}
+
+func isDotAtomLocal(local string) bool {
+	return emailLocalRegex.MatchString(local) &&
+		!strings.Contains(local, "..") && !strings.HasPrefix(local, ".") && !strings.HasSuffix(local, ".")
+}
+
+// isQuotedLocal - "..." из печатных ASCII; кавычка и обратный слеш внутри только с экранированием
+func isQuotedLocal(local string) bool {
+	if len(local) < 3 || local[0] != '"' || local[len(local)-1] != '"' {
+		return false
+	}
+
+	inner := local[1 : len(local)-1]
+	for i := 0; i < len(inner); i++ {
+		c := inner[i]
+		if c < ' ' || c > '~' {
+			return false
+		}
+		switch c {
+		case '\\':
+			i++
+			if i == len(inner) || inner[i] < ' ' || inner[i] > '~' {
+				return false
+			}
+		case '"':
+			return false
+		}
+	}
+	return true
+}
+
+// isIPLiteral - [192.168.0.1] или [IPv6:2001:db8::1]
+func isIPLiteral(domain string) bool {
+	if len(domain) < 3 || domain[0] != '[' || domain[len(domain)-1] != ']' {
+		return false
+	}
+
+	literal := domain[1 : len(domain)-1]
+	if v6, ok := strings.CutPrefix(literal, "IPv6:"); ok {
+		addr, err := netip.ParseAddr(v6)
+		return err == nil && addr.Is6() && addr.Zone() == ""
+	}
+	addr, err := netip.ParseAddr(literal)
+	return err == nil && addr.Is4()
+}
+
+// validatePasswordBytes - встроенный max считает символы, а не байты,
+// и пропустил бы многобайтовый пароль длиннее, чем примет bcrypt
+func (v *Validator) validatePasswordBytes(fl validator.FieldLevel) bool {
+	return len(fl.Field().String()) <= v.passwordMaxBytes
+}
+
+func (v *Validator) validateStrongPassword(fl validator.FieldLevel) bool {
+	// Для проверки на схожесть берем username/email из той же структуры, если они там есть
+	var username, email string
+	parent := fl.Parent()
+	if parent.Kind() == reflect.Struct {
+		if f := parent.FieldByName("Username"); f.IsValid() && f.Kind() == reflect.String {
+			username = f.String()
+		}
+		if f := parent.FieldByName("Email"); f.IsValid() && f.Kind() == reflect.String {
+			email = f.String()
+		}
+	}
+
+	return v.PasswordStrength(fl.Field().String(), username, email).Valid
+}
+
+func jsonFieldName(fld reflect.StructField) string {
+	name := strings.SplitN(fld.Tag.Get("json"), ",", 2)[0]
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return fld.Name
+	}
+	return name
+}
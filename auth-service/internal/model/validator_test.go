@@ -12,8 +12,13 @@ type testEmailStruct struct {
 	Email string `validate:"strict_email"`
 }
 
+type testPasswordStruct struct {
+	Username string
+	Password string `validate:"strong_password"`
+}
+
 func TestValidator(t *testing.T) {
-	v := NewValidator()
+	v := NewValidator(0, "", "")
 
 	t.Run("Strict Email Validation", func(t *testing.T) {
 		tests := []struct {
@@ -68,4 +73,143 @@ func TestValidator(t *testing.T) {
 			})
 		}
 	})
+	t.Run("Email Modes", func(t *testing.T) {
+		strict := NewValidator(0, EmailModeStrict, "")
+		lenient := NewValidator(0, EmailModeLenient, "")
+
+		tests := []struct {
+			name    string
+			email   string
+			strict  bool
+			lenient bool
+		}{
+			{"Plain address", "test@example.com", true, true},
+			{"Quoted local part", `"john doe"@example.com`, false, true},
+			{"Quoted local part with @", `"a@b"@example.com`, false, true},
+			{"Quoted with escaped quote", `"say \"hi\""@example.com`, false, true},
+			{"IPv4 literal", "user@[192.168.0.1]", false, true},
+			{"IPv6 literal", "user@[IPv6:2001:db8::1]", false, true},
+			{"Quoted and IP literal", `"john doe"@[10.0.0.1]`, false, true},
+
+			// lenient расширяет strict, но не до произвольного RFC 5322
+			{"Unescaped quote inside", `"a"b"@example.com`, false, false},
+			{"Unterminated quote", `"john doe@example.com`, false, false},
+			{"Empty quotes", `""@example.com`, false, false},
+			{"Control char in quotes", "\"a\tb\"@example.com", false, false},
+			{"Bad IPv4 literal", "user@[999.1.1.1]", false, false},
+			{"IPv6 without tag", "user@[2001:db8::1]", false, false},
+			{"IPv4 with IPv6 tag", "user@[IPv6:192.168.0.1]", false, false},
+			{"Unbracketed IP", "user@192.168.0.1", false, false},
+			{"Spaces without quotes", "john doe@example.com", false, false},
+			{"Double dot still invalid", "test..user@example.com", false, false},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				s := testEmailStruct{Email: tt.email}
+				assert.Equal(t, tt.strict, strict.ValidateStruct(s) == nil, "strict: %s", tt.email)
+				assert.Equal(t, tt.lenient, lenient.ValidateStruct(s) == nil, "lenient: %s", tt.email)
+			})
+		}
+	})
+	t.Run("Strong Password Validation", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			username string
+			password string
+			isValid  bool
+		}{
+			{"Valid letters and digits", "tester", "password123", true},
+			{"Invalid no digits", "tester", "newpassword", false},
+			{"Invalid too short", "tester", "pass1", false},
+			{"Invalid contains username", "tester", "tester12345", false},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				err := v.ValidateStruct(testPasswordStruct{Username: tt.username, Password: tt.password})
+				if tt.isValid {
+					assert.NoError(t, err)
+				} else {
+					assert.Error(t, err)
+				}
+			})
+		}
+	})
+	t.Run("Typed Validation Error", func(t *testing.T) {
+		err := v.ValidateStruct(&CreateUserRequest{Username: "u", Email: "bad", Password: "password123"})
+
+		var validationErr *ValidationError
+		assert.ErrorAs(t, err, &validationErr)
+		assert.Contains(t, validationErr.Fields, FieldError{Field: "username", Rule: "min"})
+		assert.Contains(t, validationErr.Fields, FieldError{Field: "email", Rule: "strict_email"})
+		assert.Contains(t, err.Error(), "field 'email' failed on the 'strict_email' rule")
+	})
+	t.Run("Password Length Counted In Bytes", func(t *testing.T) {
+		// 38 символов, но 74 байта: встроенный max=72 такой пароль пропускал,
+		// а bcrypt его не примет
+		password := strings.Repeat("ж", 36) + "1a"
+		err := v.ValidateStruct(&ChangePasswordRequest{OldPassword: "old", NewPassword: password})
+
+		var validationErr *ValidationError
+		assert.ErrorAs(t, err, &validationErr)
+		assert.Contains(t, validationErr.Fields, FieldError{Field: "new_password", Rule: "password_bytes"})
+
+		// Ровно 72 байта - можно
+		assert.NoError(t, v.ValidateStruct(&ChangePasswordRequest{OldPassword: "old", NewPassword: strings.Repeat("ж", 35) + "1a"}))
+
+		// Предел настраивается (например, для argon2id)
+		assert.NoError(t, NewValidator(128, "", "").ValidateStruct(&ChangePasswordRequest{OldPassword: "old", NewPassword: password}))
+	})
+	t.Run("Username Charset", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			username string
+			isValid  bool
+		}{
+			{"Valid letters", "alice", true},
+			{"Valid underscore", "alice_bob", true},
+			{"Valid leading digit", "1alice", true},
+			{"Valid digits only", "42", true},
+			{"Invalid space", "alice bob", false},
+			{"Invalid hyphen", "alice-bob", false},
+			{"Invalid dot", "alice.bob", false},
+			{"Invalid cyrillic", "алиса", false},
+			{"Invalid emoji", "alice🚀", false},
+			{"Invalid at sign", "@alice", false},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				create := &CreateUserRequest{Username: tt.username, Email: "alice@example.com", Password: "Password123"}
+				change := &ChangeProfileRequest{NewUsername: tt.username}
+				if tt.isValid {
+					assert.NoError(t, v.ValidateStruct(create))
+					assert.NoError(t, v.ValidateStruct(change))
+				} else {
+					assert.Error(t, v.ValidateStruct(create))
+					assert.Error(t, v.ValidateStruct(change))
+				}
+			})
+		}
+	})
+	t.Run("Username Error Message", func(t *testing.T) {
+		err := v.ValidateStruct(&ChangeProfileRequest{NewUsername: "alice bob"})
+
+		var validationErr *ValidationError
+		assert.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, []FieldError{{Field: "new_username", Rule: "username", Message: defaultUsernameMessage}}, validationErr.Fields)
+		assert.Contains(t, err.Error(), "contain only latin letters, digits and underscores")
+	})
+	t.Run("Username Pattern Configurable", func(t *testing.T) {
+		// Например, запретить цифру в начале и разрешить дефис
+		custom := NewValidator(0, "", `^[a-z][a-z0-9_-]{1,49}$`)
+
+		assert.NoError(t, custom.ValidateStruct(&ChangeProfileRequest{NewUsername: "alice-bob"}))
+
+		err := custom.ValidateStruct(&ChangeProfileRequest{NewUsername: "1alice"})
+		var validationErr *ValidationError
+		assert.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, "username must match ^[a-z][a-z0-9_-]{1,49}$", validationErr.Fields[0].Message)
+	})
 }
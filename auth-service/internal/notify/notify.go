@@ -0,0 +1,21 @@
+package notify
+
+import "context"
+
+// Message - письмо пользователю: один адресат, тема и текст без разметки
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Notifier отправляет пользователю служебные письма (смена email, смена пароля).
+// Реализации: SMTP для продакшена и Nop для тестов и локального запуска без почтового сервера.
+type Notifier interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Nop ничего не отправляет; используется, когда notify.smtp.host не задан
+type Nop struct{}
+
+func (Nop) Send(ctx context.Context, msg Message) error { return nil }
@@ -0,0 +1,118 @@
+package notify
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SMTPConfig - почтовый сервер (587 со STARTTLS или локальный relay на 25)
+type SMTPConfig struct {
+	Host string
+	Port int
+	// Username - пустой отправляет без AUTH (локальный relay)
+	Username string
+	Password string
+	From     string
+}
+
+// SMTP отправляет письма через net/smtp. Соединение ограничено дедлайном ctx:
+// SendMail из стандартной библиотеки контекст не принимает и может зависнуть на медленном сервере
+type SMTP struct {
+	cfg SMTPConfig
+	// deliver подменяется в тестах, чтобы проверять письмо без сервера
+	deliver func(ctx context.Context, from, to string, msg []byte) error
+	// now подменяется в тестах
+	now func() time.Time
+}
+
+func NewSMTP(cfg SMTPConfig) *SMTP {
+	s := &SMTP{cfg: cfg, now: time.Now}
+	s.deliver = s.dial
+	return s
+}
+
+func (s *SMTP) Send(ctx context.Context, msg Message) error {
+	if msg.To == "" {
+		return fmt.Errorf("notify: empty recipient")
+	}
+	if err := s.deliver(ctx, s.cfg.From, msg.To, s.compose(msg)); err != nil {
+		return fmt.Errorf("notify: send to smtp %s: %w", s.cfg.Host, err)
+	}
+	return nil
+}
+
+// compose собирает письмо в формате RFC 5322. CR/LF из заголовков вырезаются:
+// адрес и тема приходят из пользовательских данных, иначе через них можно дописать заголовок
+func (s *SMTP) compose(msg Message) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + headerValue(s.cfg.From) + "\r\n")
+	b.WriteString("To: " + headerValue(msg.To) + "\r\n")
+	b.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", headerValue(msg.Subject)) + "\r\n")
+	b.WriteString("Date: " + s.now().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(msg.Body, "\r\n", "\n"), "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+func headerValue(v string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(v)
+}
+
+func (s *SMTP) dial(ctx context.Context, from, to string, msg []byte) error {
+	addr := net.JoinHostPort(s.cfg.Host, strconv.Itoa(s.cfg.Port))
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	c, err := smtp.NewClient(conn, s.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: s.cfg.Host}); err != nil {
+			return err
+		}
+	}
+	// PlainAuth сам откажется передавать пароль без TLS (кроме localhost)
+	if s.cfg.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	if err := c.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
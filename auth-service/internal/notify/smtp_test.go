@@ -0,0 +1,68 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSMTP_Send(t *testing.T) {
+	s := NewSMTP(SMTPConfig{Host: "smtp.example.com", Port: 587, From: "no-reply@example.com"})
+	s.now = func() time.Time { return time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC) }
+
+	var gotFrom, gotTo string
+	var gotMsg []byte
+	s.deliver = func(ctx context.Context, from, to string, msg []byte) error {
+		gotFrom, gotTo, gotMsg = from, to, msg
+		return nil
+	}
+
+	err := s.Send(context.Background(), Message{
+		To:      "old@example.com",
+		Subject: "Email changed",
+		Body:    "line one\nline two",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "no-reply@example.com", gotFrom)
+	assert.Equal(t, "old@example.com", gotTo)
+	assert.Equal(t, "From: no-reply@example.com\r\n"+
+		"To: old@example.com\r\n"+
+		"Subject: Email changed\r\n"+
+		"Date: Fri, 01 Mar 2024 12:00:00 +0000\r\n"+
+		"MIME-Version: 1.0\r\n"+
+		"Content-Type: text/plain; charset=utf-8\r\n"+
+		"\r\n"+
+		"line one\r\nline two", string(gotMsg))
+}
+
+func TestSMTP_Send_HeaderInjection(t *testing.T) {
+	s := NewSMTP(SMTPConfig{Host: "smtp.example.com", Port: 587, From: "no-reply@example.com"})
+
+	var gotMsg []byte
+	s.deliver = func(ctx context.Context, from, to string, msg []byte) error {
+		gotMsg = msg
+		return nil
+	}
+
+	err := s.Send(context.Background(), Message{To: "a@example.com\r\nBcc: victim@example.com", Subject: "x"})
+	require.NoError(t, err)
+
+	headers, _, _ := strings.Cut(string(gotMsg), "\r\n\r\n")
+	assert.NotContains(t, headers, "\r\nBcc:")
+}
+
+func TestSMTP_Send_Errors(t *testing.T) {
+	s := NewSMTP(SMTPConfig{Host: "smtp.example.com", Port: 587, From: "no-reply@example.com"})
+	s.deliver = func(ctx context.Context, from, to string, msg []byte) error {
+		return errors.New("connection refused")
+	}
+
+	assert.EqualError(t, s.Send(context.Background(), Message{}), "notify: empty recipient")
+	assert.ErrorContains(t, s.Send(context.Background(), Message{To: "a@example.com"}), "connection refused")
+}
@@ -0,0 +1,93 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Publisher - куда relay отправляет события (например, webhook.WebhookDispatcher)
+type Publisher interface {
+	Publish(ctx context.Context, eventType string, data any) error
+}
+
+// noopPublisher - подписчиков нет, события просто помечаются отправленными
+type noopPublisher struct{}
+
+func (noopPublisher) Publish(context.Context, string, any) error { return nil }
+
+// Relay периодически разбирает outbox и публикует события.
+// Можно запускать в нескольких репликах одновременно.
+type Relay struct {
+	store        Store
+	publisher    Publisher
+	pollInterval time.Duration
+	batchSize    int
+	logger       *zap.Logger
+}
+
+const (
+	defaultPollInterval = time.Second
+	defaultBatchSize    = 100
+)
+
+// NewRelay создает relay; publisher может быть nil, нулевые интервал и размер батча заменяются дефолтами
+func NewRelay(store Store, publisher Publisher, pollInterval time.Duration, batchSize int, logger *zap.Logger) *Relay {
+	if publisher == nil {
+		publisher = noopPublisher{}
+	}
+	if pollInterval <= 0 {
+		pollInterval = defaultPollInterval
+	}
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+	return &Relay{
+		store:        store,
+		publisher:    publisher,
+		pollInterval: pollInterval,
+		batchSize:    batchSize,
+		logger:       logger,
+	}
+}
+
+// Run публикует события, пока не отменят ctx
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.pollInterval)
+	defer ticker.Stop()
+
+	r.logger.Info("outbox relay started")
+
+	for {
+		select {
+		case <-ctx.Done():
+			r.logger.Info("outbox relay stopped")
+			return
+		case <-ticker.C:
+			r.drain(ctx)
+		}
+	}
+}
+
+// drain забирает батчи, пока они приходят полными, чтобы накопившийся хвост
+// не ждал следующего тика
+func (r *Relay) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		n, err := r.store.ProcessBatch(ctx, r.batchSize, r.publish)
+		if err != nil {
+			if ctx.Err() == nil {
+				r.logger.Error("outbox batch failed", zap.Int("published", n), zap.Error(err))
+			}
+			return
+		}
+		if n < r.batchSize {
+			return
+		}
+	}
+}
+
+func (r *Relay) publish(ctx context.Context, event Event) error {
+	// Payload уже JSON - передаем как есть, чтобы не сериализовать повторно
+	return r.publisher.Publish(ctx, event.Type, event.Payload)
+}
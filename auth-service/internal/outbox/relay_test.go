@@ -0,0 +1,110 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// fakeStore - outbox в памяти с той же семантикой ProcessBatch, что и у Postgres
+type fakeStore struct {
+	events []Event
+	sent   map[int64]bool
+}
+
+func newFakeStore(n int) *fakeStore {
+	s := &fakeStore{sent: make(map[int64]bool)}
+	for i := 1; i <= n; i++ {
+		s.events = append(s.events, Event{
+			ID:      int64(i),
+			Type:    "user.updated",
+			Payload: json.RawMessage(fmt.Sprintf(`{"user_id":"%d"}`, i)),
+		})
+	}
+	return s
+}
+
+func (s *fakeStore) ProcessBatch(ctx context.Context, limit int, handle func(ctx context.Context, event Event) error) (int, error) {
+	n := 0
+	for _, e := range s.events {
+		if s.sent[e.ID] {
+			continue
+		}
+		if n == limit {
+			break
+		}
+		if err := handle(ctx, e); err != nil {
+			return n, err
+		}
+		s.sent[e.ID] = true
+		n++
+	}
+	return n, nil
+}
+
+type recordingPublisher struct {
+	published []int
+	failOn    int
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, eventType string, data any) error {
+	var payload struct {
+		UserID string `json:"user_id"`
+	}
+	if err := json.Unmarshal(data.(json.RawMessage), &payload); err != nil {
+		return err
+	}
+	id, err := strconv.Atoi(payload.UserID)
+	if err != nil {
+		return err
+	}
+	if id == p.failOn {
+		return errors.New("subscriber unavailable")
+	}
+	p.published = append(p.published, id)
+	return nil
+}
+
+func TestRelay_DrainsAllBatches(t *testing.T) {
+	store := newFakeStore(5)
+	pub := &recordingPublisher{}
+	relay := NewRelay(store, pub, 0, 2, zap.NewNop())
+
+	relay.drain(context.Background())
+
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, pub.published)
+	assert.Len(t, store.sent, 5)
+}
+
+func TestRelay_StopsOnPublishError(t *testing.T) {
+	store := newFakeStore(4)
+	pub := &recordingPublisher{failOn: 3}
+	relay := NewRelay(store, pub, 0, 10, zap.NewNop())
+
+	relay.drain(context.Background())
+
+	// Событие 3 и все после него остаются в outbox до следующего тика - порядок не нарушается
+	assert.Equal(t, []int{1, 2}, pub.published)
+	assert.True(t, store.sent[2])
+	assert.False(t, store.sent[3])
+	assert.False(t, store.sent[4])
+
+	pub.failOn = 0
+	relay.drain(context.Background())
+	assert.Equal(t, []int{1, 2, 3, 4}, pub.published)
+}
+
+func TestRelay_NilPublisherMarksSent(t *testing.T) {
+	store := newFakeStore(3)
+	relay := NewRelay(store, nil, 0, 10, zap.NewNop())
+
+	relay.drain(context.Background())
+
+	assert.Len(t, store.sent, 3)
+}
@@ -0,0 +1,90 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Event - запись outbox, которую репозиторий сохранил вместе с изменением пользователя
+type Event struct {
+	ID      int64
+	Type    string
+	Payload json.RawMessage
+}
+
+// Store выдает неотправленные события. ProcessBatch вызывает handle по порядку
+// и помечает отправленными только те события, которые handle принял.
+type Store interface {
+	ProcessBatch(ctx context.Context, limit int, handle func(ctx context.Context, event Event) error) (int, error)
+}
+
+type postgresStore struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresStore(pool *pgxpool.Pool) Store {
+	return &postgresStore{pool: pool}
+}
+
+// ProcessBatch держит строки заблокированными до commit: FOR UPDATE SKIP LOCKED
+// не дает двум репликам relay отправить одно и то же событие.
+// Первая ошибка handle останавливает батч, чтобы не нарушать порядок событий.
+func (s *postgresStore) ProcessBatch(ctx context.Context, limit int, handle func(ctx context.Context, event Event) error) (int, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	query := `
+		SELECT id, event_type, payload
+		FROM outbox
+		WHERE sent_at IS NULL
+		ORDER BY id
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED
+	`
+
+	rows, err := tx.Query(ctx, query, limit)
+	if err != nil {
+		return 0, fmt.Errorf("select outbox: %w", err)
+	}
+	events, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Event, error) {
+		var e Event
+		err := row.Scan(&e.ID, &e.Type, &e.Payload)
+		return e, err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("scan outbox: %w", err)
+	}
+
+	sent := make([]int64, 0, len(events))
+	var handleErr error
+	for _, e := range events {
+		if handleErr = handle(ctx, e); handleErr != nil {
+			break
+		}
+		sent = append(sent, e.ID)
+	}
+
+	if len(sent) > 0 {
+		if _, err := tx.Exec(ctx, `UPDATE outbox SET sent_at = NOW() WHERE id = ANY($1)`, sent); err != nil {
+			return 0, fmt.Errorf("mark outbox sent: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("commit outbox: %w", err)
+	}
+
+	if handleErr != nil {
+		return len(sent), fmt.Errorf("publish event %d: %w", events[len(sent)].ID, handleErr)
+	}
+	return len(sent), nil
+}
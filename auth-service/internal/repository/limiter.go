@@ -0,0 +1,190 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ErrDatabaseBusy - все слоты database.max_concurrent_queries заняты дольше database.busy_timeout
+var ErrDatabaseBusy = errors.New("database busy")
+
+// dbPool - методы pgxpool.Pool, которыми пользуется репозиторий; за ним может стоять limitedPool
+type dbPool interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Ping(ctx context.Context) error
+}
+
+// querySlots - семафор на одновременные обращения к базе. В отличие от max_conns пула,
+// который держит запросы в очереди до их дедлайна, здесь всплеск ждет недолго и получает
+// ErrDatabaseBusy (503) - Postgres не захлебывается, а клиент может повторить позже
+type querySlots struct {
+	slots   chan struct{}
+	timeout time.Duration
+}
+
+// newQuerySlots - nil при limit <= 0: ограничения нет
+func newQuerySlots(limit int, timeout time.Duration) *querySlots {
+	if limit <= 0 {
+		return nil
+	}
+	return &querySlots{slots: make(chan struct{}, limit), timeout: timeout}
+}
+
+// acquire занимает слот; release безопасно вызывать повторно
+func (s *querySlots) acquire(ctx context.Context) (release func(), err error) {
+	select {
+	case s.slots <- struct{}{}:
+	default:
+		timer := time.NewTimer(s.timeout)
+		defer timer.Stop()
+
+		select {
+		case s.slots <- struct{}{}:
+		case <-timer.C:
+			return nil, ErrDatabaseBusy
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	var once sync.Once
+	return func() { once.Do(func() { <-s.slots }) }, nil
+}
+
+// limitedPool держит слот, пока запрос пользуется соединением: Exec - до ответа,
+// QueryRow - до Scan, Query - до конца или Close строк (потоки - только до отправки, см. queryStream),
+// Begin - до Commit/Rollback.
+// Ping не ограничивается: health-check должен видеть живую базу и под нагрузкой
+type limitedPool struct {
+	pool  dbPool
+	slots *querySlots
+}
+
+func (p *limitedPool) Begin(ctx context.Context) (pgx.Tx, error) {
+	release, err := p.slots.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	return &limitedTx{Tx: tx, release: release}, nil
+}
+
+func (p *limitedPool) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	release, err := p.slots.acquire(ctx)
+	if err != nil {
+		return pgconn.CommandTag{}, err
+	}
+	defer release()
+
+	return p.pool.Exec(ctx, sql, args...)
+}
+
+func (p *limitedPool) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	release, err := p.slots.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := p.pool.Query(ctx, sql, args...)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	return &limitedRows{Rows: rows, release: release}, nil
+}
+
+// queryStream - Query для долгих потоков (экспорт пользователей): слот нужен только на отправку
+// запроса и отдается сразу, иначе медленный клиент ND-JSON держал бы его до конца выгрузки
+// и вытеснял обычные запросы. Соединение поток по-прежнему держит, его ограничивает max_conns пула
+func queryStream(ctx context.Context, p dbPool, sql string, args ...any) (pgx.Rows, error) {
+	limited, ok := p.(*limitedPool)
+	if !ok {
+		return p.Query(ctx, sql, args...)
+	}
+
+	release, err := limited.slots.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	return limited.pool.Query(ctx, sql, args...)
+}
+
+func (p *limitedPool) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	release, err := p.slots.acquire(ctx)
+	if err != nil {
+		return errRow{err: err}
+	}
+	return &limitedRow{row: p.pool.QueryRow(ctx, sql, args...), release: release}
+}
+
+func (p *limitedPool) Ping(ctx context.Context) error {
+	return p.pool.Ping(ctx)
+}
+
+type limitedTx struct {
+	pgx.Tx
+	release func()
+}
+
+func (t *limitedTx) Commit(ctx context.Context) error {
+	defer t.release()
+	return t.Tx.Commit(ctx)
+}
+
+func (t *limitedTx) Rollback(ctx context.Context) error {
+	defer t.release()
+	return t.Tx.Rollback(ctx)
+}
+
+// limitedRows отдает слот и на Close, и когда Next дочитал строки: pgx закрывает их сам
+type limitedRows struct {
+	pgx.Rows
+	release func()
+}
+
+func (r *limitedRows) Next() bool {
+	if r.Rows.Next() {
+		return true
+	}
+	r.release()
+	return false
+}
+
+func (r *limitedRows) Close() {
+	r.Rows.Close()
+	r.release()
+}
+
+type limitedRow struct {
+	row     pgx.Row
+	release func()
+}
+
+func (r *limitedRow) Scan(dest ...any) error {
+	defer r.release()
+	return r.row.Scan(dest...)
+}
+
+// errRow - QueryRow, которому не досталось слота
+type errRow struct {
+	err error
+}
+
+func (r errRow) Scan(dest ...any) error {
+	return r.err
+}
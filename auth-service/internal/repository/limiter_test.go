@@ -0,0 +1,238 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuerySlots(t *testing.T) {
+	t.Run("Zero limit disables the semaphore", func(t *testing.T) {
+		assert.Nil(t, newQuerySlots(0, time.Second))
+	})
+
+	t.Run("Busy after timeout", func(t *testing.T) {
+		slots := newQuerySlots(1, 10*time.Millisecond)
+
+		release, err := slots.acquire(context.Background())
+		require.NoError(t, err)
+
+		_, err = slots.acquire(context.Background())
+		assert.ErrorIs(t, err, ErrDatabaseBusy)
+
+		// Повторный release не освобождает чужой слот
+		release()
+		release()
+
+		second, err := slots.acquire(context.Background())
+		require.NoError(t, err)
+		_, err = slots.acquire(context.Background())
+		assert.ErrorIs(t, err, ErrDatabaseBusy)
+		second()
+	})
+
+	t.Run("Waits for a slot released in time", func(t *testing.T) {
+		slots := newQuerySlots(1, time.Second)
+
+		release, err := slots.acquire(context.Background())
+		require.NoError(t, err)
+		time.AfterFunc(10*time.Millisecond, release)
+
+		next, err := slots.acquire(context.Background())
+		require.NoError(t, err)
+		next()
+	})
+
+	t.Run("Canceled context", func(t *testing.T) {
+		slots := newQuerySlots(1, time.Second)
+
+		release, err := slots.acquire(context.Background())
+		require.NoError(t, err)
+		defer release()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err = slots.acquire(ctx)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+// fakePool - dbPool без базы: строки, строка и транзакция - заглушки
+type fakePool struct {
+	err error
+}
+
+func (p *fakePool) Begin(ctx context.Context) (pgx.Tx, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &fakeTx{}, nil
+}
+
+func (p *fakePool) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	return pgconn.CommandTag{}, p.err
+}
+
+func (p *fakePool) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &fakeRows{left: 2}, nil
+}
+
+func (p *fakePool) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return errRow{err: p.err}
+}
+
+func (p *fakePool) Ping(ctx context.Context) error { return nil }
+
+// fakeRows отдает left строк
+type fakeRows struct {
+	pgx.Rows
+	left int
+}
+
+func (r *fakeRows) Next() bool {
+	if r.left == 0 {
+		return false
+	}
+	r.left--
+	return true
+}
+
+func (r *fakeRows) Close() {}
+
+type fakeTx struct {
+	pgx.Tx
+}
+
+func (t *fakeTx) Commit(ctx context.Context) error   { return nil }
+func (t *fakeTx) Rollback(ctx context.Context) error { return nil }
+
+func TestLimitedPool_Release(t *testing.T) {
+	ctx := context.Background()
+	newPool := func(err error) (*limitedPool, *querySlots) {
+		slots := newQuerySlots(1, time.Millisecond)
+		return &limitedPool{pool: &fakePool{err: err}, slots: slots}, slots
+	}
+
+	t.Run("Rows hold the slot until exhausted", func(t *testing.T) {
+		pool, slots := newPool(nil)
+
+		rows, err := pool.Query(ctx, "SELECT")
+		require.NoError(t, err)
+		assert.Len(t, slots.slots, 1)
+
+		assert.True(t, rows.Next())
+		assert.True(t, rows.Next())
+		assert.Len(t, slots.slots, 1)
+		assert.False(t, rows.Next())
+		assert.Empty(t, slots.slots)
+
+		// Close после дочитывания не отдает слот второй раз
+		rows.Close()
+		assert.Empty(t, slots.slots)
+	})
+
+	t.Run("Rows closed early release the slot", func(t *testing.T) {
+		pool, slots := newPool(nil)
+
+		rows, err := pool.Query(ctx, "SELECT")
+		require.NoError(t, err)
+		assert.True(t, rows.Next())
+		rows.Close()
+		assert.Empty(t, slots.slots)
+	})
+
+	t.Run("Failed query releases the slot", func(t *testing.T) {
+		pool, slots := newPool(assert.AnError)
+
+		_, err := pool.Query(ctx, "SELECT")
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.Empty(t, slots.slots)
+
+		_, err = pool.Begin(ctx)
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.Empty(t, slots.slots)
+
+		_, err = pool.Exec(ctx, "UPDATE")
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.Empty(t, slots.slots)
+	})
+
+	t.Run("Row holds the slot until Scan", func(t *testing.T) {
+		pool, slots := newPool(nil)
+
+		row := pool.QueryRow(ctx, "SELECT")
+		assert.Len(t, slots.slots, 1)
+		require.NoError(t, row.Scan())
+		assert.Empty(t, slots.slots)
+	})
+
+	t.Run("Row without a slot is ErrDatabaseBusy", func(t *testing.T) {
+		pool, slots := newPool(nil)
+
+		held := pool.QueryRow(ctx, "SELECT")
+		assert.ErrorIs(t, pool.QueryRow(ctx, "SELECT").Scan(), ErrDatabaseBusy)
+		require.NoError(t, held.Scan())
+		assert.Empty(t, slots.slots)
+	})
+
+	t.Run("Tx holds the slot until Commit or Rollback", func(t *testing.T) {
+		pool, slots := newPool(nil)
+
+		tx, err := pool.Begin(ctx)
+		require.NoError(t, err)
+		assert.Len(t, slots.slots, 1)
+		require.NoError(t, tx.Commit(ctx))
+		assert.Empty(t, slots.slots)
+
+		tx, err = pool.Begin(ctx)
+		require.NoError(t, err)
+		require.NoError(t, tx.Rollback(ctx))
+		assert.Empty(t, slots.slots)
+
+		// Rollback после Commit (defer tx.Rollback) не отдает чужой слот
+		tx, err = pool.Begin(ctx)
+		require.NoError(t, err)
+		require.NoError(t, tx.Commit(ctx))
+		other, err := pool.Begin(ctx)
+		require.NoError(t, err)
+		require.NoError(t, tx.Rollback(ctx))
+		assert.Len(t, slots.slots, 1)
+		require.NoError(t, other.Rollback(ctx))
+	})
+
+	t.Run("Stream releases the slot once the query is sent", func(t *testing.T) {
+		pool, slots := newPool(nil)
+
+		rows, err := queryStream(ctx, pool, "SELECT")
+		require.NoError(t, err)
+		defer rows.Close()
+		assert.Empty(t, slots.slots)
+
+		// Пока поток читается, обычные запросы проходят
+		require.NoError(t, pool.QueryRow(ctx, "SELECT").Scan())
+		assert.True(t, rows.Next())
+	})
+
+	t.Run("Stream without a slot is ErrDatabaseBusy", func(t *testing.T) {
+		pool, _ := newPool(nil)
+
+		held := pool.QueryRow(ctx, "SELECT")
+		_, err := queryStream(ctx, pool, "SELECT")
+		assert.ErrorIs(t, err, ErrDatabaseBusy)
+		require.NoError(t, held.Scan())
+	})
+
+	t.Run("Unlimited pool streams directly", func(t *testing.T) {
+		rows, err := queryStream(ctx, &fakePool{}, "SELECT")
+		require.NoError(t, err)
+		assert.True(t, rows.Next())
+	})
+}
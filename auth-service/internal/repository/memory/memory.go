@@ -0,0 +1,560 @@
+// Package memory - AuthRepository в памяти для тестов хендлеров и сервиса без Docker.
+// Повторяет поведение SQL-реализации: уникальность username/email, ErrNotFound/ErrDuplicate*
+// на изменениях и чтении, limit/offset и сортировку по created_at DESC.
+package memory
+
+import (
+	"context"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/model"
+	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/repository"
+)
+
+var _ repository.AuthRepository = (*AuthRepository)(nil)
+
+type AuthRepository struct {
+	mu    sync.RWMutex
+	users map[uuid.UUID]*row
+	seq   int64
+	// resetTokens - хеш токена сброса пароля -> токен
+	resetTokens map[string]*resetToken
+	// apiTokens - хеш API-токена -> токен
+	apiTokens map[string]*apiToken
+	// now подменяется в тестах, чтобы управлять created_at
+	now func() time.Time
+}
+
+// row - пользователь плюс порядковый номер вставки: при равном created_at
+// он делает сортировку детерминированной
+type row struct {
+	user      model.User
+	seq       int64
+	sessionID string
+}
+
+type apiToken struct {
+	token  model.APIToken
+	userID uuid.UUID
+	seq    int64
+}
+
+type resetToken struct {
+	userID    uuid.UUID
+	expiresAt time.Time
+	used      bool
+}
+
+func NewAuthRepository() *AuthRepository {
+	return &AuthRepository{
+		users:       make(map[uuid.UUID]*row),
+		resetTokens: make(map[string]*resetToken),
+		apiTokens:   make(map[string]*apiToken),
+		now:         time.Now,
+	}
+}
+
+// Ping - хранилище в памяти доступно всегда
+func (r *AuthRepository) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (r *AuthRepository) Create(ctx context.Context, user *model.User) (uuid.UUID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.checkUnique(uuid.Nil, user.Username, user.Email); err != nil {
+		return uuid.Nil, err
+	}
+
+	now := r.now()
+	r.seq++
+
+	stored := model.User{
+		ID:        uuid.New(),
+		Username:  user.Username,
+		Email:     user.Email,
+		Password:  user.Password,
+		Role:      model.RoleUser, // DEFAULT 'user' из миграции
+		Version:   1,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	r.users[stored.ID] = &row{user: stored, seq: r.seq}
+
+	return stored.ID, nil
+}
+
+func (r *AuthRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.User, error) {
+	user, err := r.GetCredentialsByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	user.Password = ""
+	return user, nil
+}
+
+func (r *AuthRepository) GetByEmail(ctx context.Context, email string) (*model.User, error) {
+	user, err := r.GetCredentialsByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	user.Password = ""
+	return user, nil
+}
+
+func (r *AuthRepository) GetCredentialsByID(ctx context.Context, id uuid.UUID) (*model.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stored, ok := r.users[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	user := stored.user
+	return &user, nil
+}
+
+func (r *AuthRepository) GetCredentialsByEmail(ctx context.Context, email string) (*model.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, stored := range r.users {
+		if stored.user.Email == email {
+			user := stored.user
+			return &user, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *AuthRepository) UsernameExists(ctx context.Context, username string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, stored := range r.users {
+		if stored.user.Username == username {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *AuthRepository) EmailExists(ctx context.Context, email string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, stored := range r.users {
+		if stored.user.Email == email {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *AuthRepository) UpdateProfile(ctx context.Context, id uuid.UUID, username string, expectedVersion int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.users[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if expectedVersion != 0 && stored.user.Version != expectedVersion {
+		return repository.ErrVersionConflict
+	}
+	if err := r.checkUnique(id, username, ""); err != nil {
+		return err
+	}
+
+	stored.user.Username = username
+	stored.user.UpdatedAt = r.now()
+	stored.user.Version++
+	return nil
+}
+
+func (r *AuthRepository) PatchProfile(ctx context.Context, id uuid.UUID, patch model.ProfilePatch, expectedVersion int) error {
+	if patch.IsEmpty() {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.users[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if expectedVersion != 0 && stored.user.Version != expectedVersion {
+		return repository.ErrVersionConflict
+	}
+	if patch.Username != nil {
+		if err := r.checkUnique(id, *patch.Username, ""); err != nil {
+			return err
+		}
+		stored.user.Username = *patch.Username
+	}
+	if patch.DisplayName != nil {
+		stored.user.DisplayName = *patch.DisplayName
+	}
+	if patch.Bio != nil {
+		stored.user.Bio = *patch.Bio
+	}
+
+	stored.user.UpdatedAt = r.now()
+	stored.user.Version++
+	return nil
+}
+
+func (r *AuthRepository) UpdateEmail(ctx context.Context, id uuid.UUID, email string, expectedVersion int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.users[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if expectedVersion != 0 && stored.user.Version != expectedVersion {
+		return repository.ErrVersionConflict
+	}
+	if err := r.checkUnique(id, "", email); err != nil {
+		return err
+	}
+
+	stored.user.Email = email
+	stored.user.UpdatedAt = r.now()
+	stored.user.Version++
+	return nil
+}
+
+func (r *AuthRepository) UpdatePassword(ctx context.Context, userID uuid.UUID, newHash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.users[userID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+
+	stored.user.Password = newHash
+	stored.user.UpdatedAt = r.now()
+	stored.user.Version++
+	return nil
+}
+
+func (r *AuthRepository) UpdateAvatar(ctx context.Context, id uuid.UUID, avatarKey string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.users[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+
+	stored.user.AvatarKey = avatarKey
+	stored.user.UpdatedAt = r.now()
+	stored.user.Version++
+	return nil
+}
+
+func (r *AuthRepository) CreateResetToken(ctx context.Context, userID, actorID uuid.UUID, tokenHash string, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[userID]; !ok {
+		return repository.ErrNotFound
+	}
+
+	for hash, t := range r.resetTokens {
+		if t.userID == userID && !t.used {
+			delete(r.resetTokens, hash)
+		}
+	}
+	r.resetTokens[tokenHash] = &resetToken{userID: userID, expiresAt: expiresAt}
+	return nil
+}
+
+func (r *AuthRepository) ResetPassword(ctx context.Context, tokenHash, newHash string) (uuid.UUID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, ok := r.resetTokens[tokenHash]
+	if !ok || t.used || !r.now().Before(t.expiresAt) {
+		return uuid.Nil, repository.ErrInvalidResetToken
+	}
+	stored, ok := r.users[t.userID]
+	if !ok {
+		return uuid.Nil, repository.ErrInvalidResetToken
+	}
+
+	t.used = true
+	stored.user.Password = newHash
+	stored.user.UpdatedAt = r.now()
+	stored.user.Version++
+	return t.userID, nil
+}
+
+func (r *AuthRepository) CreateAPIToken(ctx context.Context, userID uuid.UUID, label, tokenHash string) (*model.APIToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[userID]; !ok {
+		return nil, repository.ErrNotFound
+	}
+
+	r.seq++
+	stored := &apiToken{
+		token:  model.APIToken{ID: uuid.New(), Label: label, CreatedAt: r.now()},
+		userID: userID,
+		seq:    r.seq,
+	}
+	r.apiTokens[tokenHash] = stored
+
+	t := stored.token
+	return &t, nil
+}
+
+func (r *AuthRepository) ListAPITokens(ctx context.Context, userID uuid.UUID) ([]*model.APIToken, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	owned := make([]*apiToken, 0)
+	for _, t := range r.apiTokens {
+		if t.userID == userID {
+			owned = append(owned, t)
+		}
+	}
+	slices.SortFunc(owned, func(a, b *apiToken) int {
+		if c := b.token.CreatedAt.Compare(a.token.CreatedAt); c != 0 {
+			return c
+		}
+		return int(b.seq - a.seq)
+	})
+
+	result := make([]*model.APIToken, 0, len(owned))
+	for _, t := range owned {
+		token := t.token
+		result = append(result, &token)
+	}
+	return result, nil
+}
+
+func (r *AuthRepository) DeleteAPIToken(ctx context.Context, userID, tokenID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for hash, t := range r.apiTokens {
+		if t.token.ID == tokenID && t.userID == userID {
+			delete(r.apiTokens, hash)
+			return nil
+		}
+	}
+	return repository.ErrNotFound
+}
+
+func (r *AuthRepository) UseAPIToken(ctx context.Context, tokenHash string) (*model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, ok := r.apiTokens[tokenHash]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	stored, ok := r.users[t.userID]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+
+	now := r.now()
+	t.token.LastUsedAt = &now
+
+	return &model.User{ID: stored.user.ID, Username: stored.user.Username, Role: stored.user.Role}, nil
+}
+
+func (r *AuthRepository) ReplaceSession(ctx context.Context, id uuid.UUID, sessionID string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.users[id]
+	if !ok {
+		return "", repository.ErrNotFound
+	}
+
+	previous := stored.sessionID
+	stored.sessionID = sessionID
+	return previous, nil
+}
+
+func (r *AuthRepository) GetSessionID(ctx context.Context, id uuid.UUID) (string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stored, ok := r.users[id]
+	if !ok {
+		return "", repository.ErrNotFound
+	}
+	return stored.sessionID, nil
+}
+
+func (r *AuthRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[id]; !ok {
+		return repository.ErrNotFound
+	}
+	delete(r.users, id)
+	for hash, t := range r.resetTokens {
+		if t.userID == id {
+			delete(r.resetTokens, hash) // ON DELETE CASCADE
+		}
+	}
+	for hash, t := range r.apiTokens {
+		if t.userID == id {
+			delete(r.apiTokens, hash)
+		}
+	}
+	return nil
+}
+
+func (r *AuthRepository) GetUsers(ctx context.Context, limit, offset int) ([]*model.UserListItem, error) {
+	return r.GetUsersFiltered(ctx, model.UsersFilter{}, limit, offset)
+}
+
+func (r *AuthRepository) LookupUsers(ctx context.Context, ids []uuid.UUID, usernames []string) ([]*model.UserSummary, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]*model.UserSummary, 0, len(ids)+len(usernames))
+	for _, stored := range r.users {
+		if !slices.Contains(ids, stored.user.ID) && !slices.Contains(usernames, stored.user.Username) {
+			continue
+		}
+		result = append(result, &model.UserSummary{
+			ID:          stored.user.ID,
+			Username:    stored.user.Username,
+			DisplayName: stored.user.DisplayName,
+		})
+	}
+	return result, nil
+}
+
+func (r *AuthRepository) GetUsersFiltered(ctx context.Context, filter model.UsersFilter, limit, offset int) ([]*model.UserListItem, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	rows := make([]*row, 0, len(r.users))
+	for _, stored := range r.users {
+		if matchesFilter(stored, filter) {
+			rows = append(rows, stored)
+		}
+	}
+
+	slices.SortFunc(rows, func(a, b *row) int {
+		return compareRows(a, b, filter)
+	})
+
+	result := make([]*model.UserListItem, 0)
+	for i := offset; i < len(rows) && len(result) < limit; i++ {
+		u := rows[i].user
+		result = append(result, &model.UserListItem{
+			ID:        u.ID,
+			Username:  u.Username,
+			Email:     u.Email,
+			Role:      u.Role,
+			Version:   u.Version,
+			CreatedAt: u.CreatedAt,
+			UpdatedAt: u.UpdatedAt,
+		})
+	}
+	return result, nil
+}
+
+func (r *AuthRepository) CountUsers(ctx context.Context, filter model.UsersFilter) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	count := 0
+	for _, stored := range r.users {
+		if matchesFilter(stored, filter) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// matchesFilter - границы created_at из фильтра списка, как WHERE в SQL-версии
+func matchesFilter(stored *row, filter model.UsersFilter) bool {
+	if filter.CreatedFrom != nil && stored.user.CreatedAt.Before(*filter.CreatedFrom) {
+		return false
+	}
+	if filter.CreatedTo != nil && stored.user.CreatedAt.After(*filter.CreatedTo) {
+		return false
+	}
+	return true
+}
+
+// StreamUsers отдает снимок, сделанный под блокировкой, чтобы fn могла сама обращаться к репозиторию
+func (r *AuthRepository) StreamUsers(ctx context.Context, fn func(*model.UserListItem) error) error {
+	users, err := r.GetUsers(ctx, math.MaxInt, 0)
+	if err != nil {
+		return err
+	}
+	for _, u := range users {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(u); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compareRows повторяет usersOrderBy: неизвестное поле - created_at DESC
+func compareRows(a, b *row, filter model.UsersFilter) int {
+	var cmp int
+	desc := filter.SortDesc
+
+	switch filter.SortBy {
+	case "username":
+		cmp = strings.Compare(a.user.Username, b.user.Username)
+	case "email":
+		cmp = strings.Compare(a.user.Email, b.user.Email)
+	case "created_at":
+		cmp = a.user.CreatedAt.Compare(b.user.CreatedAt)
+	default:
+		cmp = a.user.CreatedAt.Compare(b.user.CreatedAt)
+		desc = true
+	}
+
+	if cmp == 0 {
+		cmp = int(a.seq - b.seq)
+	}
+	if desc {
+		return -cmp
+	}
+	return cmp
+}
+
+// checkUnique - аналог UNIQUE(username) и UNIQUE(email); пустые значения не проверяются.
+// self исключается, чтобы обновление на то же значение не считалось конфликтом.
+func (r *AuthRepository) checkUnique(self uuid.UUID, username, email string) error {
+	for id, stored := range r.users {
+		if id == self {
+			continue
+		}
+		if username != "" && stored.user.Username == username {
+			return repository.ErrDuplicateUsername
+		}
+		if email != "" && stored.user.Email == email {
+			return repository.ErrDuplicateEmail
+		}
+	}
+	return nil
+}
@@ -0,0 +1,272 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/model"
+	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryRepo_CRUD(t *testing.T) {
+	repo := NewAuthRepository()
+	ctx := context.Background()
+
+	id, err := repo.Create(ctx, &model.User{Username: "john_doe", Email: "john@example.com", Password: "hash"})
+	require.NoError(t, err)
+	require.NotEqual(t, uuid.Nil, id)
+
+	t.Run("GetByID hides hash", func(t *testing.T) {
+		fetched, err := repo.GetByID(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, "john_doe", fetched.Username)
+		assert.Equal(t, model.RoleUser, fetched.Role)
+		assert.Empty(t, fetched.Password)
+		assert.False(t, fetched.CreatedAt.IsZero())
+	})
+
+	t.Run("GetCredentials returns hash", func(t *testing.T) {
+		fetched, err := repo.GetCredentialsByEmail(ctx, "john@example.com")
+		require.NoError(t, err)
+		assert.Equal(t, id, fetched.ID)
+		assert.Equal(t, "hash", fetched.Password)
+	})
+
+	t.Run("LookupUsers skips unknown ids", func(t *testing.T) {
+		users, err := repo.LookupUsers(ctx, []uuid.UUID{uuid.New(), id}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []*model.UserSummary{{ID: id, Username: "john_doe"}}, users)
+	})
+
+	t.Run("LookupUsers by username is case-sensitive", func(t *testing.T) {
+		users, err := repo.LookupUsers(ctx, nil, []string{"john_doe", "John_Doe", "ghost"})
+		require.NoError(t, err)
+		assert.Equal(t, []*model.UserSummary{{ID: id, Username: "john_doe"}}, users)
+	})
+
+	t.Run("Exists", func(t *testing.T) {
+		exists, err := repo.UsernameExists(ctx, "john_doe")
+		require.NoError(t, err)
+		assert.True(t, exists)
+
+		exists, err = repo.EmailExists(ctx, "nobody@example.com")
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("Returned user is a copy", func(t *testing.T) {
+		fetched, _ := repo.GetCredentialsByID(ctx, id)
+		fetched.Username = "mutated"
+
+		again, _ := repo.GetByID(ctx, id)
+		assert.Equal(t, "john_doe", again.Username)
+	})
+
+	t.Run("Duplicates", func(t *testing.T) {
+		_, err := repo.Create(ctx, &model.User{Username: "john_doe", Email: "other@example.com"})
+		assert.ErrorIs(t, err, repository.ErrDuplicateUsername)
+
+		_, err = repo.Create(ctx, &model.User{Username: "other", Email: "john@example.com"})
+		assert.ErrorIs(t, err, repository.ErrDuplicateEmail)
+
+		otherID, err := repo.Create(ctx, &model.User{Username: "jane", Email: "jane@example.com"})
+		require.NoError(t, err)
+		assert.ErrorIs(t, repo.UpdateProfile(ctx, otherID, "john_doe", 0), repository.ErrDuplicateUsername)
+		assert.ErrorIs(t, repo.UpdateEmail(ctx, otherID, "john@example.com", 0), repository.ErrDuplicateEmail)
+
+		// Обновление на собственное значение конфликтом не считается
+		assert.NoError(t, repo.UpdateProfile(ctx, otherID, "jane", 0))
+	})
+
+	t.Run("Updates", func(t *testing.T) {
+		require.NoError(t, repo.UpdateProfile(ctx, id, "john_new", 0))
+		require.NoError(t, repo.UpdateEmail(ctx, id, "new@example.com", 0))
+		require.NoError(t, repo.UpdatePassword(ctx, id, "new_hash"))
+
+		fetched, err := repo.GetCredentialsByID(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, "john_new", fetched.Username)
+		assert.Equal(t, "new@example.com", fetched.Email)
+		assert.Equal(t, "new_hash", fetched.Password)
+	})
+
+	t.Run("Patch touches only given fields", func(t *testing.T) {
+		bio := "gopher"
+		require.NoError(t, repo.PatchProfile(ctx, id, model.ProfilePatch{Bio: &bio}, 0))
+
+		fetched, err := repo.GetByID(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, "john_new", fetched.Username)
+		assert.Equal(t, "gopher", fetched.Bio)
+		assert.Empty(t, fetched.DisplayName)
+
+		name, empty := "John", ""
+		require.NoError(t, repo.PatchProfile(ctx, id, model.ProfilePatch{DisplayName: &name, Bio: &empty}, fetched.Version))
+		fetched, err = repo.GetByID(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, "John", fetched.DisplayName)
+		assert.Empty(t, fetched.Bio)
+
+		taken := "jane"
+		assert.ErrorIs(t, repo.PatchProfile(ctx, id, model.ProfilePatch{Username: &taken}, 0), repository.ErrDuplicateUsername)
+	})
+
+	t.Run("Version conflict", func(t *testing.T) {
+		before, err := repo.GetByID(ctx, id)
+		require.NoError(t, err)
+
+		// Первая вкладка сохраняет с актуальной версией - версия растет
+		require.NoError(t, repo.UpdateProfile(ctx, id, "tab_one", before.Version))
+		after, err := repo.GetByID(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, before.Version+1, after.Version)
+
+		// Вторая вкладка со старой версией не перетирает изменения
+		assert.ErrorIs(t, repo.UpdateProfile(ctx, id, "tab_two", before.Version), repository.ErrVersionConflict)
+		assert.ErrorIs(t, repo.UpdateEmail(ctx, id, "tab_two@example.com", before.Version), repository.ErrVersionConflict)
+
+		// Несуществующий пользователь - по-прежнему not found, а не конфликт
+		assert.ErrorIs(t, repo.UpdateProfile(ctx, uuid.New(), "ghost", 1), repository.ErrNotFound)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		fakeID := uuid.New()
+		assert.ErrorIs(t, repo.UpdateProfile(ctx, fakeID, "ghost", 0), repository.ErrNotFound)
+		assert.ErrorIs(t, repo.UpdateEmail(ctx, fakeID, "ghost@ghost.com", 0), repository.ErrNotFound)
+		assert.ErrorIs(t, repo.UpdatePassword(ctx, fakeID, "ghost"), repository.ErrNotFound)
+		assert.ErrorIs(t, repo.Delete(ctx, fakeID), repository.ErrNotFound)
+
+		// Чтение, как и в SQL-реализации, тоже возвращает ErrNotFound
+		_, err := repo.GetByID(ctx, fakeID)
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+		_, err = repo.GetByEmail(ctx, "ghost@ghost.com")
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+		_, err = repo.GetCredentialsByID(ctx, fakeID)
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+		_, err = repo.GetSessionID(ctx, fakeID)
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		require.NoError(t, repo.Delete(ctx, id))
+		assert.ErrorIs(t, repo.Delete(ctx, id), repository.ErrNotFound)
+	})
+}
+
+func TestMemoryRepo_GetUsers(t *testing.T) {
+	repo := NewAuthRepository()
+	ctx := context.Background()
+
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, name := range []string{"u1", "u2", "u3"} {
+		created := base.Add(time.Duration(i) * time.Hour)
+		repo.now = func() time.Time { return created }
+
+		_, err := repo.Create(ctx, &model.User{Username: name, Email: name + "@example.com", Password: "p"})
+		require.NoError(t, err)
+	}
+
+	t.Run("Limit and Offset, created_at DESC", func(t *testing.T) {
+		list, err := repo.GetUsers(ctx, 2, 0)
+		require.NoError(t, err)
+		require.Len(t, list, 2)
+		assert.Equal(t, "u3", list[0].Username)
+		assert.Equal(t, "u2", list[1].Username)
+		assert.Equal(t, 1, list[0].Version)
+
+		list, err = repo.GetUsers(ctx, 2, 2)
+		require.NoError(t, err)
+		require.Len(t, list, 1)
+		assert.Equal(t, "u1", list[0].Username)
+	})
+
+	t.Run("Empty Result", func(t *testing.T) {
+		list, err := repo.GetUsers(ctx, 10, 100)
+		require.NoError(t, err)
+		assert.NotNil(t, list)
+		assert.Len(t, list, 0)
+	})
+
+	t.Run("Created range", func(t *testing.T) {
+		from := base.Add(30 * time.Minute)
+		to := base.Add(90 * time.Minute)
+
+		list, err := repo.GetUsersFiltered(ctx, model.UsersFilter{CreatedFrom: &from, CreatedTo: &to}, 10, 0)
+		require.NoError(t, err)
+		require.Len(t, list, 1)
+		assert.Equal(t, "u2", list[0].Username)
+
+		count, err := repo.CountUsers(ctx, model.UsersFilter{CreatedFrom: &from, CreatedTo: &to})
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+
+		count, err = repo.CountUsers(ctx, model.UsersFilter{})
+		require.NoError(t, err)
+		assert.Equal(t, 3, count)
+	})
+
+	t.Run("Sort by username ASC", func(t *testing.T) {
+		list, err := repo.GetUsersFiltered(ctx, model.UsersFilter{SortBy: "username"}, 10, 0)
+		require.NoError(t, err)
+		require.Len(t, list, 3)
+		assert.Equal(t, []string{"u1", "u2", "u3"}, []string{list[0].Username, list[1].Username, list[2].Username})
+	})
+
+	t.Run("Stream all users", func(t *testing.T) {
+		var names []string
+		err := repo.StreamUsers(ctx, func(u *model.UserListItem) error {
+			names = append(names, u.Username)
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"u3", "u2", "u1"}, names)
+	})
+
+	t.Run("Stream stops on callback error", func(t *testing.T) {
+		stop := errors.New("stop")
+		calls := 0
+		err := repo.StreamUsers(ctx, func(u *model.UserListItem) error {
+			calls++
+			return stop
+		})
+		assert.ErrorIs(t, err, stop)
+		assert.Equal(t, 1, calls)
+	})
+}
+
+func TestMemoryRepo_ResetTokens(t *testing.T) {
+	repo := NewAuthRepository()
+	now := time.Now()
+	repo.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	id, err := repo.Create(ctx, &model.User{Username: "reset", Email: "reset@example.com", Password: "hash"})
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, repo.CreateResetToken(ctx, uuid.New(), uuid.New(), "h0", now.Add(time.Hour)), repository.ErrNotFound)
+
+	require.NoError(t, repo.CreateResetToken(ctx, id, uuid.New(), "h1", now.Add(time.Hour)))
+
+	// Истекший токен не принимается
+	now = now.Add(time.Hour)
+	_, err = repo.ResetPassword(ctx, "h1", "new-hash")
+	assert.ErrorIs(t, err, repository.ErrInvalidResetToken)
+
+	require.NoError(t, repo.CreateResetToken(ctx, id, uuid.New(), "h2", now.Add(time.Hour)))
+	got, err := repo.ResetPassword(ctx, "h2", "new-hash")
+	require.NoError(t, err)
+	assert.Equal(t, id, got)
+
+	creds, err := repo.GetCredentialsByID(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, "new-hash", creds.Password)
+	assert.Equal(t, 2, creds.Version)
+
+	_, err = repo.ResetPassword(ctx, "h2", "other-hash")
+	assert.ErrorIs(t, err, repository.ErrInvalidResetToken)
+}
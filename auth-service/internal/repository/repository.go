@@ -2,11 +2,15 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/model"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"go.uber.org/zap"
@@ -14,29 +18,150 @@ import (
 
 type AuthRepository interface {
 	Create(ctx context.Context, user *model.User) (uuid.UUID, error)
+	// GetByEmail и GetByID - для отображения, password_hash не выбирают
 	GetByEmail(ctx context.Context, email string) (*model.User, error)
 	GetByID(ctx context.Context, id uuid.UUID) (*model.User, error)
-	UpdateProfile(ctx context.Context, id uuid.UUID, username string) error
-	UpdateEmail(ctx context.Context, id uuid.UUID, email string) error
+	// GetCredentialsBy* загружают password_hash - только для входа и смены пароля
+	GetCredentialsByEmail(ctx context.Context, email string) (*model.User, error)
+	GetCredentialsByID(ctx context.Context, id uuid.UUID) (*model.User, error)
+	// UsernameExists и EmailExists - дешевая проверка занятости без загрузки строки
+	UsernameExists(ctx context.Context, username string) (bool, error)
+	EmailExists(ctx context.Context, email string) (bool, error)
+	// UpdateProfile и UpdateEmail увеличивают version. expectedVersion != 0 - optimistic locking:
+	// если версия в базе другая, возвращается ErrVersionConflict; 0 - обновить без проверки
+	UpdateProfile(ctx context.Context, id uuid.UUID, username string, expectedVersion int) error
+	UpdateEmail(ctx context.Context, id uuid.UUID, email string, expectedVersion int) error
+	// PatchProfile меняет только поля patch, которые не nil; version - как в UpdateProfile
+	PatchProfile(ctx context.Context, id uuid.UUID, patch model.ProfilePatch, expectedVersion int) error
 	UpdatePassword(ctx context.Context, userID uuid.UUID, newHash string) error
+	UpdateAvatar(ctx context.Context, id uuid.UUID, avatarKey string) error
+	// CreateResetToken сохраняет хеш одноразового токена сброса пароля; прежние неиспользованные
+	// токены пользователя отзываются. actorID (выдавший администратор) попадает в событие outbox
+	CreateResetToken(ctx context.Context, userID, actorID uuid.UUID, tokenHash string, expiresAt time.Time) error
+	// ResetPassword гасит токен и ставит новый хеш пароля; ErrInvalidResetToken - токена нет,
+	// он истек или уже использован
+	ResetPassword(ctx context.Context, tokenHash, newHash string) (uuid.UUID, error)
+	// CreateAPIToken сохраняет хеш нового API-токена пользователя
+	CreateAPIToken(ctx context.Context, userID uuid.UUID, label, tokenHash string) (*model.APIToken, error)
+	// ListAPITokens - токены пользователя, новые первыми
+	ListAPITokens(ctx context.Context, userID uuid.UUID) ([]*model.APIToken, error)
+	// DeleteAPIToken отзывает токен; ErrNotFound - нет такого токена у этого пользователя
+	DeleteAPIToken(ctx context.Context, userID, tokenID uuid.UUID) error
+	// UseAPIToken находит владельца токена по хешу и отмечает last_used_at;
+	// ErrNotFound - токена нет (отозван или никогда не существовал)
+	UseAPIToken(ctx context.Context, tokenHash string) (*model.User, error)
+	// ReplaceSession записывает новый sid и возвращает прежний ("" - сессии не было)
+	ReplaceSession(ctx context.Context, id uuid.UUID, sessionID string) (string, error)
+	// GetSessionID - текущий sid; читается с primary, чтобы только что выданный токен не отклонило отставание реплики
+	GetSessionID(ctx context.Context, id uuid.UUID) (string, error)
 	Delete(ctx context.Context, id uuid.UUID) error
-	GetUsers(ctx context.Context, limit, offset int) ([]*model.User, error)
+	GetUsers(ctx context.Context, limit, offset int) ([]*model.UserListItem, error)
+	// LookupUsers - публичные данные пользователей по id или username (с учетом регистра);
+	// неизвестные пропускаются, найденный обоими способами пользователь возвращается один раз
+	LookupUsers(ctx context.Context, ids []uuid.UUID, usernames []string) ([]*model.UserSummary, error)
+	GetUsersFiltered(ctx context.Context, filter model.UsersFilter, limit, offset int) ([]*model.UserListItem, error)
+	// CountUsers - сколько пользователей подходит под фильтр (сортировка не учитывается)
+	CountUsers(ctx context.Context, filter model.UsersFilter) (int, error)
+	// StreamUsers обходит всех пользователей (created_at DESC) без накопления в памяти - для экспорта.
+	// Ошибка fn останавливает обход и возвращается как есть.
+	StreamUsers(ctx context.Context, fn func(*model.UserListItem) error) error
+	// Ping - доступность хранилища для health check
+	Ping(ctx context.Context) error
 }
 
 type authRepo struct {
-	pool   *pgxpool.Pool
-	logger *zap.Logger
+	pool    dbPool
+	replica dbPool
+	logger  *zap.Logger
 }
 
 var (
 	ErrNotFound          = errors.New("user not found")
 	ErrDuplicateUsername = errors.New("username already taken")
 	ErrDuplicateEmail    = errors.New("email already taken")
+	// ErrVersionConflict - пользователя изменили после того, как клиент его прочитал
+	ErrVersionConflict = errors.New("user was modified concurrently")
+	// ErrInvalidResetToken - токен сброса пароля не найден, истек или уже использован
+	ErrInvalidResetToken = errors.New("invalid or expired reset token")
 )
 
-func NewAuthRepository(pool *pgxpool.Pool, logger *zap.Logger) AuthRepository {
-	return &authRepo{pool: pool, logger: logger}
+// Имена UNIQUE-ограничений из миграции 0001_init_users.sql
+const (
+	constraintUsersUsername = "users_username_key"
+	constraintUsersEmail    = "users_email_key"
+)
+
+// NewAuthRepository создает репозиторий. replica может быть nil -
+// тогда читающие запросы идут в основной пул.
+// maxConcurrent > 0 ограничивает одновременные запросы (общий лимит на оба пула):
+// не дождавшись слота за busyTimeout, метод возвращает ErrDatabaseBusy.
+func NewAuthRepository(pool, replica *pgxpool.Pool, logger *zap.Logger, maxConcurrent int, busyTimeout time.Duration) AuthRepository {
+	repo := &authRepo{pool: pool, logger: logger}
+	// Без проверки в интерфейс попал бы типизированный nil, и reader() выбрал бы его
+	if replica != nil {
+		repo.replica = replica
+	}
+
+	if slots := newQuerySlots(maxConcurrent, busyTimeout); slots != nil {
+		repo.pool = &limitedPool{pool: repo.pool, slots: slots}
+		if repo.replica != nil {
+			repo.replica = &limitedPool{pool: repo.replica, slots: slots}
+		}
+	}
+	return repo
+}
+
+// reader возвращает пул для read-only запросов
+func (r *authRepo) reader() dbPool {
+	if r.replica != nil {
+		return r.replica
+	}
+	return r.pool
+}
+
+// Ping проверяет основной пул и реплику, если она есть
+func (r *authRepo) Ping(ctx context.Context) error {
+	if err := r.pool.Ping(ctx); err != nil {
+		return fmt.Errorf("ping primary: %w", err)
+	}
+	if r.replica != nil {
+		if err := r.replica.Ping(ctx); err != nil {
+			return fmt.Errorf("ping replica: %w", err)
+		}
+	}
+	return nil
+}
+
+// withTx выполняет fn в транзакции на основном пуле: commit, если fn вернула nil, иначе rollback
+func (r *authRepo) withTx(ctx context.Context, fn func(tx pgx.Tx) error) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx) // после Commit ничего не делает
+	}()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
 
+	return tx.Commit(ctx)
+}
+
+// writeEvent кладет событие в outbox в той же транзакции, что и само изменение:
+// либо сохраняется и то и другое, либо ничего
+func writeEvent(ctx context.Context, tx pgx.Tx, eventType string, event model.UserEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshal event: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `INSERT INTO outbox (event_type, payload) VALUES ($1, $2)`, eventType, payload)
+	if err != nil {
+		return fmt.Errorf("insert outbox event: %w", err)
+	}
+	return nil
 }
 
 func (r *authRepo) Create(ctx context.Context, user *model.User) (uuid.UUID, error) {
@@ -47,8 +172,26 @@ func (r *authRepo) Create(ctx context.Context, user *model.User) (uuid.UUID, err
 	`
 
 	var id uuid.UUID
-	err := r.pool.QueryRow(ctx, query, user.Username, user.Email, user.Password).Scan(&id)
+	err := r.withTx(ctx, func(tx pgx.Tx) error {
+		if err := tx.QueryRow(ctx, query, user.Username, user.Email, user.Password).Scan(&id); err != nil {
+			return err
+		}
+		return writeEvent(ctx, tx, model.EventUserRegistered, model.UserEvent{
+			UserID:   id.String(),
+			Username: user.Username,
+			Email:    user.Email,
+		})
+	})
 	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			switch pgErr.ConstraintName {
+			case constraintUsersUsername:
+				return uuid.Nil, ErrDuplicateUsername
+			case constraintUsersEmail:
+				return uuid.Nil, ErrDuplicateEmail
+			}
+		}
 		r.logger.Error("failed to insert user", zap.Error(err), zap.String("email", user.Email))
 		return uuid.Nil, fmt.Errorf("insert user: %w", err)
 	}
@@ -58,15 +201,18 @@ func (r *authRepo) Create(ctx context.Context, user *model.User) (uuid.UUID, err
 
 func (r *authRepo) GetByID(ctx context.Context, id uuid.UUID) (*model.User, error) {
 	query := `
-		SELECT id, username, email, password_hash, created_at, updated_at 
+		SELECT id, username, email, role, version, avatar_key, display_name, bio, created_at, updated_at 
 		FROM users 
 		WHERE id = $1
 	`
 
 	user := &model.User{}
-	err := r.pool.QueryRow(ctx, query, id).Scan(
-		&user.ID, &user.Username, &user.Email, &user.Password, &user.CreatedAt, &user.UpdatedAt,
+	err := r.reader().QueryRow(ctx, query, id).Scan(
+		&user.ID, &user.Username, &user.Email, &user.Role, &user.Version, &user.AvatarKey, &user.DisplayName, &user.Bio, &user.CreatedAt, &user.UpdatedAt,
 	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -75,61 +221,224 @@ func (r *authRepo) GetByID(ctx context.Context, id uuid.UUID) (*model.User, erro
 
 func (r *authRepo) GetByEmail(ctx context.Context, email string) (*model.User, error) {
 	query := `
-		SELECT id, username, email, password_hash, created_at, updated_at 
+		SELECT id, username, email, role, version, avatar_key, display_name, bio, created_at, updated_at 
+		FROM users 
+		WHERE email = $1
+	`
+
+	user := &model.User{}
+	err := r.reader().QueryRow(ctx, query, email).Scan(
+		&user.ID, &user.Username, &user.Email, &user.Role, &user.Version, &user.AvatarKey, &user.DisplayName, &user.Bio, &user.CreatedAt, &user.UpdatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// GetCredentialsByID читает с основного пула: после смены пароля реплика
+// может еще отдавать старый хеш
+func (r *authRepo) GetCredentialsByID(ctx context.Context, id uuid.UUID) (*model.User, error) {
+	query := `
+		SELECT id, username, email, password_hash, role, version, avatar_key, display_name, bio, created_at, updated_at 
+		FROM users 
+		WHERE id = $1
+	`
+
+	user := &model.User{}
+	err := r.pool.QueryRow(ctx, query, id).Scan(
+		&user.ID, &user.Username, &user.Email, &user.Password, &user.Role, &user.Version, &user.AvatarKey, &user.DisplayName, &user.Bio, &user.CreatedAt, &user.UpdatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (r *authRepo) GetCredentialsByEmail(ctx context.Context, email string) (*model.User, error) {
+	query := `
+		SELECT id, username, email, password_hash, role, version, avatar_key, display_name, bio, created_at, updated_at 
 		FROM users 
 		WHERE email = $1
 	`
 
 	user := &model.User{}
 	err := r.pool.QueryRow(ctx, query, email).Scan(
-		&user.ID, &user.Username, &user.Email, &user.Password, &user.CreatedAt, &user.UpdatedAt,
+		&user.ID, &user.Username, &user.Email, &user.Password, &user.Role, &user.Version, &user.AvatarKey, &user.DisplayName, &user.Bio, &user.CreatedAt, &user.UpdatedAt,
 	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
 	if err != nil {
-		return nil, err // Тут можно проверить на pgx.ErrNoRows
+		return nil, err
 	}
 	return user, nil
 }
 
-func (r *authRepo) UpdateProfile(ctx context.Context, id uuid.UUID, username string) error {
-	query := `UPDATE users SET username = $1, updated_at = NOW() WHERE id = $2`
+func (r *authRepo) UsernameExists(ctx context.Context, username string) (bool, error) {
+	var exists bool
+	err := r.reader().QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM users WHERE username = $1)`, username).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("db username exists: %w", err)
+	}
+	return exists, nil
+}
+
+func (r *authRepo) EmailExists(ctx context.Context, email string) (bool, error) {
+	var exists bool
+	err := r.reader().QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM users WHERE email = $1)`, email).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("db email exists: %w", err)
+	}
+	return exists, nil
+}
+
+func (r *authRepo) UpdateProfile(ctx context.Context, id uuid.UUID, username string, expectedVersion int) error {
+	query := `
+		UPDATE users SET username = $1, updated_at = NOW(), version = version + 1
+		WHERE id = $2 AND ($3::int = 0 OR version = $3)
+	`
 
-	cmd, err := r.pool.Exec(ctx, query, username, id)
+	err := r.withTx(ctx, func(tx pgx.Tx) error {
+		cmd, err := tx.Exec(ctx, query, username, id, expectedVersion)
+		if err != nil {
+			return err
+		}
+		if cmd.RowsAffected() == 0 {
+			return notUpdatedReason(ctx, tx, id, expectedVersion)
+		}
+		return writeEvent(ctx, tx, model.EventUserUpdated, model.UserEvent{UserID: id.String(), Username: username})
+	})
 	if err != nil {
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
 			return ErrDuplicateUsername
 		}
+		if errors.Is(err, ErrNotFound) || errors.Is(err, ErrVersionConflict) {
+			return err
+		}
 		return fmt.Errorf("db update profile: %w", err)
 	}
 
-	if cmd.RowsAffected() == 0 {
-		return ErrNotFound
+	return nil
+}
+
+// profileSetClause собирает SET для PatchProfile. Имена колонок - только литералы отсюда,
+// значения идут параметрами, поэтому из запроса в текст SQL ничего не попадает
+func profileSetClause(patch model.ProfilePatch) ([]string, []any) {
+	var (
+		sets []string
+		args []any
+	)
+	add := func(column string, value *string) {
+		if value == nil {
+			return
+		}
+		args = append(args, *value)
+		sets = append(sets, fmt.Sprintf("%s = $%d", column, len(args)))
+	}
+
+	add("username", patch.Username)
+	add("display_name", patch.DisplayName)
+	add("bio", patch.Bio)
+	return sets, args
+}
+
+func (r *authRepo) PatchProfile(ctx context.Context, id uuid.UUID, patch model.ProfilePatch, expectedVersion int) error {
+	sets, args := profileSetClause(patch)
+	if len(sets) == 0 {
+		return nil
+	}
+
+	args = append(args, id, expectedVersion)
+	query := fmt.Sprintf(`
+		UPDATE users SET %s, updated_at = NOW(), version = version + 1
+		WHERE id = $%d AND ($%d::int = 0 OR version = $%d)
+	`, strings.Join(sets, ", "), len(args)-1, len(args), len(args))
+
+	event := model.UserEvent{UserID: id.String()}
+	if patch.Username != nil {
+		event.Username = *patch.Username
+	}
+
+	err := r.withTx(ctx, func(tx pgx.Tx) error {
+		cmd, err := tx.Exec(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		if cmd.RowsAffected() == 0 {
+			return notUpdatedReason(ctx, tx, id, expectedVersion)
+		}
+		return writeEvent(ctx, tx, model.EventUserUpdated, event)
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return ErrDuplicateUsername
+		}
+		if errors.Is(err, ErrNotFound) || errors.Is(err, ErrVersionConflict) {
+			return err
+		}
+		return fmt.Errorf("db patch profile: %w", err)
 	}
 
 	return nil
 }
 
-func (r *authRepo) UpdateEmail(ctx context.Context, id uuid.UUID, email string) error {
-	query := `UPDATE users SET email = $1, updated_at = NOW() WHERE id = $2`
+func (r *authRepo) UpdateEmail(ctx context.Context, id uuid.UUID, email string, expectedVersion int) error {
+	query := `
+		UPDATE users SET email = $1, updated_at = NOW(), version = version + 1
+		WHERE id = $2 AND ($3::int = 0 OR version = $3)
+	`
 
-	cmd, err := r.pool.Exec(ctx, query, email, id)
+	err := r.withTx(ctx, func(tx pgx.Tx) error {
+		cmd, err := tx.Exec(ctx, query, email, id, expectedVersion)
+		if err != nil {
+			return err
+		}
+		if cmd.RowsAffected() == 0 {
+			return notUpdatedReason(ctx, tx, id, expectedVersion)
+		}
+		return writeEvent(ctx, tx, model.EventUserEmailChanged, model.UserEvent{UserID: id.String(), Email: email})
+	})
 	if err != nil {
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
 			return ErrDuplicateEmail
 		}
+		if errors.Is(err, ErrNotFound) || errors.Is(err, ErrVersionConflict) {
+			return err
+		}
 		return fmt.Errorf("db update email: %w", err)
 	}
 
-	if cmd.RowsAffected() == 0 {
+	return nil
+}
+
+// notUpdatedReason - UPDATE не затронул строку: либо пользователя нет, либо не совпала версия
+func notUpdatedReason(ctx context.Context, tx pgx.Tx, id uuid.UUID, expectedVersion int) error {
+	if expectedVersion == 0 {
 		return ErrNotFound
 	}
 
-	return nil
+	var exists bool
+	if err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM users WHERE id = $1)`, id).Scan(&exists); err != nil {
+		return err
+	}
+	if exists {
+		return ErrVersionConflict
+	}
+	return ErrNotFound
 }
 
 func (r *authRepo) UpdatePassword(ctx context.Context, userID uuid.UUID, newHash string) error {
-	query := `UPDATE users SET password_hash = $1, updated_at = NOW() WHERE id = $2`
+	query := `UPDATE users SET password_hash = $1, updated_at = NOW(), version = version + 1 WHERE id = $2`
 
 	cmd, err := r.pool.Exec(ctx, query, newHash, userID)
 	if err != nil {
@@ -143,40 +452,364 @@ func (r *authRepo) UpdatePassword(ctx context.Context, userID uuid.UUID, newHash
 	return nil
 }
 
-func (r *authRepo) Delete(ctx context.Context, id uuid.UUID) error {
-	query := `DELETE FROM users WHERE id = $1`
+func (r *authRepo) UpdateAvatar(ctx context.Context, id uuid.UUID, avatarKey string) error {
+	query := `UPDATE users SET avatar_key = $1, updated_at = NOW(), version = version + 1 WHERE id = $2`
 
-	cmd, err := r.pool.Exec(ctx, query, id)
+	cmd, err := r.pool.Exec(ctx, query, avatarKey, id)
 	if err != nil {
 		return err
 	}
+
 	if cmd.RowsAffected() == 0 {
 		return ErrNotFound
 	}
+
 	return nil
 }
 
-func (r *authRepo) GetUsers(ctx context.Context, limit, offset int) ([]*model.User, error) {
+func (r *authRepo) ReplaceSession(ctx context.Context, id uuid.UUID, sessionID string) (string, error) {
+	// RETURNING отдает новые значения, поэтому старый sid берем из подзапроса под FOR UPDATE
 	query := `
-		SELECT id, username, email, created_at, updated_at 
+		UPDATE users u SET session_id = $1
+		FROM (SELECT session_id FROM users WHERE id = $2 FOR UPDATE) prev
+		WHERE u.id = $2
+		RETURNING prev.session_id
+	`
+
+	var previous string
+	err := r.pool.QueryRow(ctx, query, sessionID, id).Scan(&previous)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return previous, nil
+}
+
+func (r *authRepo) GetSessionID(ctx context.Context, id uuid.UUID) (string, error) {
+	var sessionID string
+	err := r.pool.QueryRow(ctx, `SELECT session_id FROM users WHERE id = $1`, id).Scan(&sessionID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return sessionID, nil
+}
+
+func (r *authRepo) CreateResetToken(ctx context.Context, userID, actorID uuid.UUID, tokenHash string, expiresAt time.Time) error {
+	return r.withTx(ctx, func(tx pgx.Tx) error {
+		var username string
+		err := tx.QueryRow(ctx, `SELECT username FROM users WHERE id = $1 FOR UPDATE`, userID).Scan(&username)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(ctx, `DELETE FROM password_reset_tokens WHERE user_id = $1 AND used_at IS NULL`, userID); err != nil {
+			return fmt.Errorf("revoke reset tokens: %w", err)
+		}
+
+		_, err = tx.Exec(ctx, `
+			INSERT INTO password_reset_tokens (token_hash, user_id, created_by, expires_at)
+			VALUES ($1, $2, $3, $4)
+		`, tokenHash, userID, actorID, expiresAt)
+		if err != nil {
+			return fmt.Errorf("insert reset token: %w", err)
+		}
+
+		return writeEvent(ctx, tx, model.EventUserPasswordResetIssued, model.UserEvent{
+			UserID:   userID.String(),
+			Username: username,
+			ActorID:  actorID.String(),
+		})
+	})
+}
+
+func (r *authRepo) ResetPassword(ctx context.Context, tokenHash, newHash string) (uuid.UUID, error) {
+	var userID uuid.UUID
+
+	err := r.withTx(ctx, func(tx pgx.Tx) error {
+		err := tx.QueryRow(ctx, `
+			UPDATE password_reset_tokens SET used_at = NOW()
+			WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+			RETURNING user_id
+		`, tokenHash).Scan(&userID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrInvalidResetToken
+		}
+		if err != nil {
+			return err
+		}
+
+		query := `UPDATE users SET password_hash = $1, updated_at = NOW(), version = version + 1 WHERE id = $2`
+		cmd, err := tx.Exec(ctx, query, newHash, userID)
+		if err != nil {
+			return err
+		}
+		if cmd.RowsAffected() == 0 {
+			return ErrInvalidResetToken
+		}
+
+		return writeEvent(ctx, tx, model.EventUserPasswordReset, model.UserEvent{UserID: userID.String()})
+	})
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return userID, nil
+}
+
+func (r *authRepo) CreateAPIToken(ctx context.Context, userID uuid.UUID, label, tokenHash string) (*model.APIToken, error) {
+	query := `
+		INSERT INTO api_tokens (user_id, token_hash, label)
+		VALUES ($1, $2, $3)
+		RETURNING id, label, created_at
+	`
+
+	var t model.APIToken
+	err := r.pool.QueryRow(ctx, query, userID, tokenHash, label).Scan(&t.ID, &t.Label, &t.CreatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		// Пользователя удалили между проверкой токена и запросом
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *authRepo) ListAPITokens(ctx context.Context, userID uuid.UUID) ([]*model.APIToken, error) {
+	query := `
+		SELECT id, label, created_at, last_used_at
+		FROM api_tokens
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+	`
+
+	rows, err := r.pool.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]*model.APIToken, 0)
+	for rows.Next() {
+		var t model.APIToken
+		if err := rows.Scan(&t.ID, &t.Label, &t.CreatedAt, &t.LastUsedAt); err != nil {
+			return nil, err
+		}
+		result = append(result, &t)
+	}
+	return result, rows.Err()
+}
+
+func (r *authRepo) DeleteAPIToken(ctx context.Context, userID, tokenID uuid.UUID) error {
+	cmd, err := r.pool.Exec(ctx, `DELETE FROM api_tokens WHERE id = $1 AND user_id = $2`, tokenID, userID)
+	if err != nil {
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// UseAPIToken пишет в primary на каждый запрос с токеном: last_used_at нужен для аудита
+func (r *authRepo) UseAPIToken(ctx context.Context, tokenHash string) (*model.User, error) {
+	query := `
+		WITH used AS (
+			UPDATE api_tokens SET last_used_at = NOW()
+			WHERE token_hash = $1
+			RETURNING user_id
+		)
+		SELECT u.id, u.username, u.role
+		FROM users u JOIN used ON u.id = used.user_id
+	`
+
+	var u model.User
+	err := r.pool.QueryRow(ctx, query, tokenHash).Scan(&u.ID, &u.Username, &u.Role)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (r *authRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	query := `DELETE FROM users WHERE id = $1`
+
+	return r.withTx(ctx, func(tx pgx.Tx) error {
+		cmd, err := tx.Exec(ctx, query, id)
+		if err != nil {
+			return err
+		}
+		if cmd.RowsAffected() == 0 {
+			return ErrNotFound
+		}
+		return writeEvent(ctx, tx, model.EventUserDeleted, model.UserEvent{UserID: id.String()})
+	})
+}
+
+func (r *authRepo) GetUsers(ctx context.Context, limit, offset int) ([]*model.UserListItem, error) {
+	query := `
+		SELECT id, username, email, role, version, created_at, updated_at 
 		FROM users
 		ORDER BY created_at DESC
 		LIMIT $1 OFFSET $2
 	`
 
-	rows, err := r.pool.Query(ctx, query, limit, offset)
+	rows, err := r.reader().Query(ctx, query, limit, offset)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	result := make([]*model.User, 0)
+	result := make([]*model.UserListItem, 0)
 	for rows.Next() {
-		var u model.User
-		if err := rows.Scan(&u.ID, &u.Username, &u.Email, &u.CreatedAt, &u.UpdatedAt); err != nil {
+		var u model.UserListItem
+		if err := rows.Scan(&u.ID, &u.Username, &u.Email, &u.Role, &u.Version, &u.CreatedAt, &u.UpdatedAt); err != nil {
 			return nil, err
 		}
 		result = append(result, &u)
 	}
 	return result, nil
 }
+
+func (r *authRepo) LookupUsers(ctx context.Context, ids []uuid.UUID, usernames []string) ([]*model.UserSummary, error) {
+	query := `
+		SELECT id, username, display_name
+		FROM users
+		WHERE id = ANY($1) OR username = ANY($2)
+	`
+
+	rows, err := r.reader().Query(ctx, query, ids, usernames)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]*model.UserSummary, 0, len(ids)+len(usernames))
+	for rows.Next() {
+		var u model.UserSummary
+		if err := rows.Scan(&u.ID, &u.Username, &u.DisplayName); err != nil {
+			return nil, err
+		}
+		result = append(result, &u)
+	}
+	return result, rows.Err()
+}
+
+func (r *authRepo) GetUsersFiltered(ctx context.Context, filter model.UsersFilter, limit, offset int) ([]*model.UserListItem, error) {
+	where, args := usersWhere(filter)
+
+	args = append(args, limit, offset)
+	query := fmt.Sprintf(`
+		SELECT id, username, email, role, version, created_at, updated_at
+		FROM users
+		%s
+		ORDER BY %s
+		LIMIT $%d OFFSET $%d
+	`, where, usersOrderBy(filter), len(args)-1, len(args))
+
+	rows, err := r.reader().Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]*model.UserListItem, 0)
+	for rows.Next() {
+		var u model.UserListItem
+		if err := rows.Scan(&u.ID, &u.Username, &u.Email, &u.Role, &u.Version, &u.CreatedAt, &u.UpdatedAt); err != nil {
+			return nil, err
+		}
+		result = append(result, &u)
+	}
+	return result, rows.Err()
+}
+
+func (r *authRepo) CountUsers(ctx context.Context, filter model.UsersFilter) (int, error) {
+	where, args := usersWhere(filter)
+
+	var count int
+	err := r.reader().QueryRow(ctx, "SELECT count(*) FROM users "+where, args...).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// usersWhere - WHERE по фильтру списка пользователей и его аргументы ($1, $2, ...).
+// Значения передаем только через плейсхолдеры, в текст запроса попадают лишь наши константы.
+func usersWhere(filter model.UsersFilter) (string, []any) {
+	conditions := make([]string, 0, 2)
+	args := make([]any, 0, 4)
+
+	if filter.CreatedFrom != nil {
+		args = append(args, *filter.CreatedFrom)
+		conditions = append(conditions, fmt.Sprintf("created_at >= $%d", len(args)))
+	}
+	if filter.CreatedTo != nil {
+		args = append(args, *filter.CreatedTo)
+		conditions = append(conditions, fmt.Sprintf("created_at <= $%d", len(args)))
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+	return "WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// StreamUsers читает строки по одной: в памяти всегда один пользователь, сколько бы их ни было.
+// Указатель переиспользуется между вызовами - fn не должна его сохранять.
+func (r *authRepo) StreamUsers(ctx context.Context, fn func(*model.UserListItem) error) error {
+	query := `
+		SELECT id, username, email, role, version, created_at, updated_at
+		FROM users
+		ORDER BY created_at DESC
+	`
+
+	rows, err := queryStream(ctx, r.reader(), query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var u model.UserListItem
+	for rows.Next() {
+		if err := rows.Scan(&u.ID, &u.Username, &u.Email, &u.Role, &u.Version, &u.CreatedAt, &u.UpdatedAt); err != nil {
+			return err
+		}
+		if err := fn(&u); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// usersSortColumns - allowlist колонок для ORDER BY. Имена колонок нельзя передать плейсхолдером,
+// поэтому в запрос попадает только значение из этой мапы, а не пользовательский ввод.
+var usersSortColumns = map[string]string{
+	"created_at": "created_at",
+	"username":   "username",
+	"email":      "email",
+}
+
+func usersOrderBy(filter model.UsersFilter) string {
+	column, ok := usersSortColumns[filter.SortBy]
+	if !ok {
+		return "created_at DESC"
+	}
+
+	if filter.SortDesc {
+		return column + " DESC"
+	}
+	return column + " ASC"
+}
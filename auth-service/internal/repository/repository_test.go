@@ -2,15 +2,17 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"log"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/config"
 	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/db"
 	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/model"
-	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/joho/godotenv"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -46,9 +48,13 @@ func getTestConfig() *config.Config {
 	}
 
 	var cfg *config.Config
-	var err error
+	err := os.ErrNotExist
 
 	for _, p := range configPaths {
+		// config.Load без файла не ошибается (env-only режим), а тестам нужен именно config.yml
+		if _, statErr := os.Stat(p); statErr != nil {
+			continue
+		}
 		cfg, err = config.Load(p)
 		if err == nil {
 			log.Printf("INFO: loaded config from %s", p)
@@ -86,12 +92,12 @@ func setupTestDB(t *testing.T) (AuthRepository, func()) {
 	database, err := db.Connect(ctx, cfg, logger)
 	require.NoError(t, err, "failed to connect to db")
 
-	repo := NewAuthRepository(database.Pool, logger)
+	repo := NewAuthRepository(database.Pool, database.Replica, logger, 0, 0)
 
 	// Функция очистки (вызывается через defer в самом тесте)
 	cleanup := func() {
 		// Очищаем таблицу users. CASCADE нужен, если появятся связанные таблицы.
-		_, err := database.Pool.Exec(ctx, "TRUNCATE users RESTART IDENTITY CASCADE")
+		_, err := database.Pool.Exec(ctx, "TRUNCATE users, outbox RESTART IDENTITY CASCADE")
 		if err != nil {
 			log.Printf("failed to truncate table users: %v", err)
 		}
@@ -131,7 +137,8 @@ func TestAuthRepo_Lifecycle(t *testing.T) {
 		assert.Equal(t, savedID, fetched.ID)
 		assert.Equal(t, "john_doe", fetched.Username)
 		assert.Equal(t, "john@example.com", fetched.Email)
-		assert.Equal(t, "hashed_password_123", fetched.Password)
+		assert.Empty(t, fetched.Password, "хеш не должен попадать в read-only выборки")
+		assert.Equal(t, model.RoleUser, fetched.Role, "роль по умолчанию из миграции")
 		assert.False(t, fetched.CreatedAt.IsZero(), "CreatedAt должен быть заполнен базой")
 	})
 
@@ -141,6 +148,28 @@ func TestAuthRepo_Lifecycle(t *testing.T) {
 		require.NoError(t, err)
 		assert.Equal(t, savedID, fetched.ID)
 		assert.Equal(t, "john_doe", fetched.Username)
+		assert.Empty(t, fetched.Password)
+	})
+
+	t.Run("GetCredentials", func(t *testing.T) {
+		byID, err := repo.GetCredentialsByID(ctx, savedID)
+		require.NoError(t, err)
+		assert.Equal(t, "hashed_password_123", byID.Password)
+
+		byEmail, err := repo.GetCredentialsByEmail(ctx, "john@example.com")
+		require.NoError(t, err)
+		assert.Equal(t, savedID, byEmail.ID)
+		assert.Equal(t, "hashed_password_123", byEmail.Password)
+	})
+
+	t.Run("Exists", func(t *testing.T) {
+		exists, err := repo.UsernameExists(ctx, "john_doe")
+		require.NoError(t, err)
+		assert.True(t, exists)
+
+		exists, err = repo.EmailExists(ctx, "nobody@example.com")
+		require.NoError(t, err)
+		assert.False(t, exists)
 	})
 
 	// 4. DELETE
@@ -154,7 +183,11 @@ func TestAuthRepo_Lifecycle(t *testing.T) {
 
 		// Проверяем, что получить пользователя больше нельзя
 		_, err = repo.GetByID(ctx, savedID)
-		assert.ErrorIs(t, err, pgx.ErrNoRows) // Твой код возвращает оригинальную ошибку pgx, если не нашел строку
+		assert.ErrorIs(t, err, ErrNotFound)
+		_, err = repo.GetCredentialsByID(ctx, savedID)
+		assert.ErrorIs(t, err, ErrNotFound)
+		_, err = repo.GetSessionID(ctx, savedID)
+		assert.ErrorIs(t, err, ErrNotFound)
 	})
 }
 
@@ -175,21 +208,29 @@ func TestAuthRepo_Updates(t *testing.T) {
 	require.NoError(t, err)
 
 	t.Run("UpdateProfile_Success", func(t *testing.T) {
-		err := repo.UpdateProfile(ctx, id1, "new_user1_name")
+		err := repo.UpdateProfile(ctx, id1, "new_user1_name", 0)
 		assert.NoError(t, err)
 
 		fetched, _ := repo.GetByID(ctx, id1)
 		assert.Equal(t, "new_user1_name", fetched.Username)
 	})
 
+	t.Run("Create_Duplicate", func(t *testing.T) {
+		_, err := repo.Create(ctx, &model.User{Username: user2.Username, Email: "other_" + user2.Email, Password: "hash"})
+		assert.ErrorIs(t, err, ErrDuplicateUsername)
+
+		_, err = repo.Create(ctx, &model.User{Username: "other_" + user2.Username, Email: user2.Email, Password: "hash"})
+		assert.ErrorIs(t, err, ErrDuplicateEmail)
+	})
+
 	t.Run("UpdateProfile_Duplicate", func(t *testing.T) {
 		// Пытаемся занять имя второго пользователя
-		err := repo.UpdateProfile(ctx, id1, "user2")
+		err := repo.UpdateProfile(ctx, id1, "user2", 0)
 		assert.ErrorIs(t, err, ErrDuplicateUsername)
 	})
 
 	t.Run("UpdateEmail_Success", func(t *testing.T) {
-		err := repo.UpdateEmail(ctx, id1, "new@example.com")
+		err := repo.UpdateEmail(ctx, id1, "new@example.com", 0)
 		assert.NoError(t, err)
 
 		fetched, _ := repo.GetByID(ctx, id1)
@@ -198,7 +239,7 @@ func TestAuthRepo_Updates(t *testing.T) {
 
 	t.Run("UpdateEmail_Duplicate", func(t *testing.T) {
 		// Пытаемся занять email второго пользователя
-		err := repo.UpdateEmail(ctx, id1, "user2@example.com")
+		err := repo.UpdateEmail(ctx, id1, "user2@example.com", 0)
 		assert.ErrorIs(t, err, ErrDuplicateEmail)
 	})
 
@@ -206,16 +247,34 @@ func TestAuthRepo_Updates(t *testing.T) {
 		err := repo.UpdatePassword(ctx, id1, "new_hashed_pwd")
 		assert.NoError(t, err)
 
-		fetched, _ := repo.GetByID(ctx, id1)
+		fetched, _ := repo.GetCredentialsByID(ctx, id1)
 		assert.Equal(t, "new_hashed_pwd", fetched.Password)
 	})
 
+	t.Run("Version conflict", func(t *testing.T) {
+		before, err := repo.GetByID(ctx, id1)
+		require.NoError(t, err)
+
+		// Первая вкладка сохраняет с актуальной версией - версия растет
+		require.NoError(t, repo.UpdateProfile(ctx, id1, "tab_one", before.Version))
+		after, err := repo.GetByID(ctx, id1)
+		require.NoError(t, err)
+		assert.Equal(t, before.Version+1, after.Version)
+
+		// Вторая вкладка со старой версией не перетирает изменения
+		assert.ErrorIs(t, repo.UpdateProfile(ctx, id1, "tab_two", before.Version), ErrVersionConflict)
+		assert.ErrorIs(t, repo.UpdateEmail(ctx, id1, "tab_two@example.com", before.Version), ErrVersionConflict)
+
+		// Несуществующий пользователь - по-прежнему not found, а не конфликт
+		assert.ErrorIs(t, repo.UpdateProfile(ctx, uuid.New(), "ghost", 1), ErrNotFound)
+	})
+
 	t.Run("Updates_NotFound", func(t *testing.T) {
 		fakeID := uuid.New()
-		errProfile := repo.UpdateProfile(ctx, fakeID, "ghost")
+		errProfile := repo.UpdateProfile(ctx, fakeID, "ghost", 0)
 		assert.ErrorIs(t, errProfile, ErrNotFound)
 
-		errEmail := repo.UpdateEmail(ctx, fakeID, "ghost@ghost.com")
+		errEmail := repo.UpdateEmail(ctx, fakeID, "ghost@ghost.com", 0)
 		assert.ErrorIs(t, errEmail, ErrNotFound)
 
 		errPwd := repo.UpdatePassword(ctx, fakeID, "ghost_pwd")
@@ -237,11 +296,35 @@ func TestAuthRepo_GetUsers(t *testing.T) {
 		{Username: "u3", Email: "u3@example.com", Password: "p"},
 	}
 
+	ids := make([]uuid.UUID, 0, len(users))
 	for _, u := range users {
-		_, err := repo.Create(ctx, u)
+		id, err := repo.Create(ctx, u)
 		require.NoError(t, err)
+		ids = append(ids, id)
 	}
 
+	t.Run("Lookup by ids", func(t *testing.T) {
+		list, err := repo.LookupUsers(ctx, []uuid.UUID{ids[0], uuid.New(), ids[2]}, nil)
+		require.NoError(t, err)
+
+		var names []string
+		for _, u := range list {
+			names = append(names, u.Username)
+		}
+		assert.ElementsMatch(t, []string{"u1", "u3"}, names)
+	})
+
+	t.Run("Lookup by usernames and ids together", func(t *testing.T) {
+		list, err := repo.LookupUsers(ctx, []uuid.UUID{ids[0]}, []string{"u1", "U2", "u3", "ghost"})
+		require.NoError(t, err)
+
+		var names []string
+		for _, u := range list {
+			names = append(names, u.Username)
+		}
+		assert.ElementsMatch(t, []string{"u1", "u3"}, names)
+	})
+
 	t.Run("Limit and Offset", func(t *testing.T) {
 		// Берем 2 пользователей, пропуская 0 (должны получить u3 и u2)
 		list, err := repo.GetUsers(ctx, 2, 0)
@@ -264,4 +347,191 @@ func TestAuthRepo_GetUsers(t *testing.T) {
 		assert.NotNil(t, list, "Слайс должен быть инициализирован, а не nil")
 		assert.Len(t, list, 0)
 	})
+
+	t.Run("Stream all users", func(t *testing.T) {
+		var names []string
+		err := repo.StreamUsers(ctx, func(u *model.UserListItem) error {
+			names = append(names, u.Username)
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"u3", "u2", "u1"}, names)
+	})
+
+	t.Run("Stream stops on callback error", func(t *testing.T) {
+		stop := errors.New("stop")
+		calls := 0
+		err := repo.StreamUsers(ctx, func(u *model.UserListItem) error {
+			calls++
+			return stop
+		})
+		assert.ErrorIs(t, err, stop)
+		assert.Equal(t, 1, calls)
+	})
+}
+
+// TestAuthRepo_GetUsersFiltered проверяет фильтрацию списка по диапазону created_at.
+func TestAuthRepo_GetUsersFiltered(t *testing.T) {
+	repo, cleanup := setupTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	for _, name := range []string{"f1", "f2", "f3"} {
+		_, err := repo.Create(ctx, &model.User{Username: name, Email: name + "@example.com", Password: "p"})
+		require.NoError(t, err)
+	}
+
+	all, err := repo.GetUsers(ctx, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+
+	t.Run("No filter matches GetUsers", func(t *testing.T) {
+		list, err := repo.GetUsersFiltered(ctx, model.UsersFilter{}, 10, 0)
+		require.NoError(t, err)
+		assert.Len(t, list, 3)
+	})
+
+	t.Run("Range in the future is empty", func(t *testing.T) {
+		from := time.Now().Add(time.Hour)
+		list, err := repo.GetUsersFiltered(ctx, model.UsersFilter{CreatedFrom: &from}, 10, 0)
+		require.NoError(t, err)
+		assert.NotNil(t, list)
+		assert.Len(t, list, 0)
+	})
+
+	t.Run("Range with limit and offset", func(t *testing.T) {
+		from := time.Now().Add(-time.Hour)
+		to := time.Now().Add(time.Hour)
+		filter := model.UsersFilter{CreatedFrom: &from, CreatedTo: &to}
+
+		list, err := repo.GetUsersFiltered(ctx, filter, 2, 0)
+		require.NoError(t, err)
+		assert.Len(t, list, 2)
+		assert.Equal(t, "f3", list[0].Username)
+
+		list, err = repo.GetUsersFiltered(ctx, filter, 2, 2)
+		require.NoError(t, err)
+		assert.Len(t, list, 1)
+		assert.Equal(t, "f1", list[0].Username)
+	})
+
+	t.Run("Count ignores limit and sort", func(t *testing.T) {
+		count, err := repo.CountUsers(ctx, model.UsersFilter{SortBy: "username"})
+		require.NoError(t, err)
+		assert.Equal(t, 3, count)
+
+		from := time.Now().Add(time.Hour)
+		count, err = repo.CountUsers(ctx, model.UsersFilter{CreatedFrom: &from})
+		require.NoError(t, err)
+		assert.Equal(t, 0, count)
+	})
+}
+
+// TestUsersOrderBy проверяет, что ORDER BY собирается только из allowlist.
+func TestUsersOrderBy(t *testing.T) {
+	tests := []struct {
+		name     string
+		filter   model.UsersFilter
+		expected string
+	}{
+		{"Default", model.UsersFilter{}, "created_at DESC"},
+		{"Username asc", model.UsersFilter{SortBy: "username"}, "username ASC"},
+		{"Email desc", model.UsersFilter{SortBy: "email", SortDesc: true}, "email DESC"},
+		{"Unknown falls back to default", model.UsersFilter{SortBy: "password_hash"}, "created_at DESC"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, usersOrderBy(tt.filter))
+		})
+	}
+}
+
+// TestAuthRepo_GetUsersSorted проверяет сортировку списка по username.
+func TestAuthRepo_GetUsersSorted(t *testing.T) {
+	repo, cleanup := setupTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	for _, name := range []string{"bravo", "alpha", "charlie"} {
+		_, err := repo.Create(ctx, &model.User{Username: name, Email: name + "@example.com", Password: "p"})
+		require.NoError(t, err)
+	}
+
+	list, err := repo.GetUsersFiltered(ctx, model.UsersFilter{SortBy: "username"}, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, list, 3)
+	assert.Equal(t, "alpha", list[0].Username)
+	assert.Equal(t, "charlie", list[2].Username)
+
+	list, err = repo.GetUsersFiltered(ctx, model.UsersFilter{SortBy: "username", SortDesc: true}, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "charlie", list[0].Username)
+}
+
+// TestAuthRepo_Ping проверяет доступность базы через репозиторий.
+func TestAuthRepo_Ping(t *testing.T) {
+	repo, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	assert.NoError(t, repo.Ping(context.Background()))
+}
+
+// TestAuthRepo_Outbox проверяет, что событие пишется вместе с изменением и не пишется при его откате.
+func TestAuthRepo_Outbox(t *testing.T) {
+	repo, cleanup := setupTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+	pool := repo.(*authRepo).pool
+
+	countEvents := func(eventType string) int {
+		var n int
+		err := pool.QueryRow(ctx, "SELECT COUNT(*) FROM outbox WHERE event_type = $1 AND sent_at IS NULL", eventType).Scan(&n)
+		require.NoError(t, err)
+		return n
+	}
+
+	id, err := repo.Create(ctx, &model.User{Username: "outbox", Email: "outbox@example.com", Password: "hash"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, countEvents(model.EventUserRegistered))
+
+	var payload model.UserEvent
+	err = pool.QueryRow(ctx, "SELECT payload FROM outbox WHERE event_type = $1", model.EventUserRegistered).Scan(&payload)
+	require.NoError(t, err)
+	assert.Equal(t, model.UserEvent{UserID: id.String(), Username: "outbox", Email: "outbox@example.com"}, payload)
+
+	// Дубликат откатывает транзакцию целиком - события в outbox не появляется
+	_, err = repo.Create(ctx, &model.User{Username: "outbox", Email: "other@example.com", Password: "hash"})
+	assert.ErrorIs(t, err, ErrDuplicateUsername)
+	assert.Equal(t, 1, countEvents(model.EventUserRegistered))
+
+	require.NoError(t, repo.UpdateEmail(ctx, id, "new-outbox@example.com", 0))
+	assert.Equal(t, 1, countEvents(model.EventUserEmailChanged))
+
+	assert.ErrorIs(t, repo.Delete(ctx, uuid.New()), ErrNotFound)
+	require.NoError(t, repo.Delete(ctx, id))
+	assert.Equal(t, 1, countEvents(model.EventUserDeleted))
+}
+
+// TestAuthRepo_Reader проверяет выбор пула для чтения без подключения к БД.
+func TestAuthRepo_Reader(t *testing.T) {
+	primary, replica := &pgxpool.Pool{}, &pgxpool.Pool{}
+
+	withoutReplica := NewAuthRepository(primary, nil, zap.NewNop(), 0, 0).(*authRepo)
+	assert.Same(t, primary, withoutReplica.reader())
+
+	withReplica := NewAuthRepository(primary, replica, zap.NewNop(), 0, 0).(*authRepo)
+	assert.Same(t, replica, withReplica.reader())
+}
+
+func TestProfileSetClause(t *testing.T) {
+	name, bio := "John", ""
+
+	sets, args := profileSetClause(model.ProfilePatch{DisplayName: &name, Bio: &bio})
+	assert.Equal(t, []string{"display_name = $1", "bio = $2"}, sets)
+	assert.Equal(t, []any{"John", ""}, args)
+
+	sets, args = profileSetClause(model.ProfilePatch{})
+	assert.Empty(t, sets)
+	assert.Empty(t, args)
 }
@@ -0,0 +1,150 @@
+// Package router собирает gin.Engine целиком: middleware, CORS и все группы маршрутов.
+// Его используют и run(), и интеграционные тесты, чтобы таблица маршрутов была одна.
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+	"github.com/gsrlabs/micro-blog-hub/auth-service/docs"
+	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/config"
+	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/handler"
+	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/model"
+	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/storage"
+	"go.uber.org/zap"
+)
+
+// NewRouter - health проверяет checker (обычно репозиторий).
+// Ошибка только на невалидных server.trusted_proxies и server.trusted_platform.
+func NewRouter(h *handler.AuthHandler, cfg *config.Config, logger *zap.Logger, checker handler.HealthChecker) (*gin.Engine, error) {
+	r := gin.New()
+	// GET /auth/signup - 405 с Allow, а не 404: клиенту видно, что ошибся методом, а не путем
+	r.HandleMethodNotAllowed = true
+	r.NoMethod(handler.NoMethod)
+	r.NoRoute(handler.NoRoute)
+	// Без этого за балансировщиком ClientIP() в логах и лимитерах - это IP прокси
+	if err := r.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("invalid server.trusted_proxies: %w", err)
+	}
+	if cfg.Server.TrustedPlatform != "" {
+		header, ok := config.TrustedPlatforms[cfg.Server.TrustedPlatform]
+		if !ok {
+			return nil, fmt.Errorf("unknown server.trusted_platform %q", cfg.Server.TrustedPlatform)
+		}
+		r.TrustedPlatform = header
+	}
+	r.Use(handler.ZapRecovery(logger))
+	r.Use(handler.RequestLogger(logger))
+	r.Use(handler.ZapLogger(logger))
+	r.Use(handler.BodyLimit(cfg.Server.MaxBodyBytes, cfg.Server.MaxMultipartBytes))
+	// Долгие маршруты (потоковые выгрузки) перечисляются после streamRoutes, чтобы дедлайн их не обрывал.
+	// Список пользователей в ND-JSON идет потоком, в обычном JSON - с дедлайном
+	streamRoutes := []string{http.MethodGet + " " + path.Join("/", cfg.App.BasePath, "/users")}
+	r.Use(handler.RequestTimeout(cfg.Server.RequestTimeout, streamRoutes))
+
+	// ВАЖНО: Добавляем CORS middleware перед роутами
+	corsConfig := cors.DefaultConfig()
+	// Разрешаем запросы с фронтенда (указываем порт Svelte, обычно 5173)
+	if cfg.Frontend.Host == "" {
+		logger.Warn("frontend host is not specified")
+	} else {
+		corsConfig.AllowOrigins = []string{cfg.Frontend.Host}
+		logger.Info("allowed requests", zap.String("host", cfg.Frontend.Host))
+	}
+
+	// Разрешаем нужные методы, включая OPTIONS
+	corsConfig.AllowMethods = config.AllowMethods()
+	corsConfig.AllowHeaders = config.AllowHeaders()
+	// Без этого браузер не отдаст фронтенду X-Token-Expires-In, Location после регистрации, X-Request-ID для обращений
+	// в поддержку и пагинацию списка пользователей (X-Total-Count, Link)
+	corsConfig.ExposeHeaders = []string{handler.TokenExpiresInHeader, "Location", handler.RequestIDHeader, handler.TotalCountHeader, "Link"}
+	// Жизненно важно для credentials: 'include' в api.ts!
+	corsConfig.AllowCredentials = true
+	// Кэш preflight у браузера отдельный для запросов с credentials и без, поэтому
+	// Access-Control-Allow-Credentials отдается и на OPTIONS вместе с Max-Age
+	corsConfig.MaxAge = cfg.CORS.MaxAge
+
+	r.Use(cors.New(corsConfig))
+	r.Use(handler.CookieSameSite(cfg.Auth.CookieSameSite))
+
+	// Health остается в корне, чтобы пробы не зависели от app.base_path
+	r.GET("/health", handler.Health(checker, logger))
+
+	// Спецификация описывает маршруты относительно app.base_path
+	docs.SwaggerInfo.BasePath = cfg.App.BasePath
+	r.GET("/swagger.json", handler.SwaggerJSON(docs.SwaggerInfo.ReadDoc))
+	// UI грузит скрипты со стороннего CDN - в проде не отдаем, спецификация остается на /swagger.json
+	if cfg.App.Mode == "debug" {
+		r.GET("/docs", handler.SwaggerUI)
+	}
+
+	api := r.Group(cfg.App.BasePath, handler.BasePath(cfg.App.BasePath))
+
+	if cfg.Storage.Backend != storage.BackendS3 {
+		api.Static(cfg.Storage.Local.URLPrefix, cfg.Storage.Local.Dir)
+	}
+
+	auth := api.Group("/auth")
+	{
+		auth.POST("/signup", h.SignUp) // Регистрация
+		auth.POST("/signin", h.SignIn) // Логин
+		auth.POST("/logout", h.Logout)
+		auth.POST("/password/reset", h.ResetPassword) // по токену из /users/:id/force-password-reset
+		withFeature(cfg, logger, config.FeaturePasswordCheck, func() {
+			auth.POST("/password/check", h.CheckPassword)
+		})
+		auth.GET("/available", handler.RateLimitByIP(cfg.Limits.AvailabilityPerMinute, time.Minute), h.Available)
+
+		// Проверка токена другими сервисами по HTTP
+		auth.POST("/token/introspect", handler.RequireServiceSecret(cfg.Security.ServiceSecret), h.Introspect)
+		auth.POST("/users/lookup", handler.RequireServiceSecret(cfg.Security.ServiceSecret), h.LookupUsers)
+	}
+
+	users := api.Group("/users")
+	{
+		users.GET("", h.OptionalAuth, h.GetUsers)
+
+		// Поиск конкретного пользователя раскрывает email - только для админов
+		admin := users.Group("", h.AuthMiddleware, handler.RequireRole(model.RoleAdmin))
+		admin.GET("/:id", h.GetByID)
+		admin.GET("/search", h.GetByEmail)
+		admin.DELETE("/:id", h.DeleteByID)
+		admin.POST("/:id/force-password-reset", handler.RateLimitByUser(cfg.Limits.ForceResetPerHour, time.Hour), h.ForcePasswordReset)
+	}
+
+	user := api.Group("/user")
+	user.Use(h.AuthMiddleware)
+	{
+		user.GET("/profile", h.GetProfile)
+
+		user.PUT("/password", h.ChangePassword)
+		user.PUT("/profile", h.ChangeProfile)
+		user.PATCH("/profile", h.PatchProfile)
+		user.PUT("/email", h.ChangeEmail)
+		user.PUT("/avatar", h.UploadAvatar)
+
+		user.DELETE("", h.Delete)
+
+		// Управлять API-токенами можно только после входа по паролю
+		tokens := user.Group("/api-tokens", handler.RequireSessionAuth)
+		tokens.POST("", h.CreateAPIToken)
+		tokens.GET("", h.ListAPITokens)
+		tokens.DELETE("/:id", h.RevokeAPIToken)
+	}
+
+	return r, nil
+}
+
+// withFeature регистрирует маршруты или middleware фичи, только если флаг включен;
+// иначе их нет в engine, и запросы получают обычный 404
+func withFeature(cfg *config.Config, logger *zap.Logger, name string, register func()) {
+	if !cfg.FeatureEnabled(name) {
+		logger.Info("feature disabled", zap.String("feature", name))
+		return
+	}
+	register()
+}
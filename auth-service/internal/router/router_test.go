@@ -0,0 +1,203 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/config"
+	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/handler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type okChecker struct{}
+
+func (okChecker) Ping(ctx context.Context) error { return nil }
+
+func testConfig() *config.Config {
+	cfg := &config.Config{}
+	cfg.App.BasePath = "/api/v1"
+	cfg.Frontend.Host = "http://localhost:5173"
+	cfg.CORS.MaxAge = 10 * time.Minute
+	cfg.Storage.Backend = "local"
+	cfg.Storage.Local.Dir = "./testdata"
+	cfg.Storage.Local.URLPrefix = "/static"
+	return cfg
+}
+
+func TestNewRouter_Routes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	h := handler.NewAuthHandler(nil, handler.Config{Secret: "secret", JWTTTL: 1}, zap.NewNop())
+	r, err := NewRouter(h, testConfig(), zap.NewNop(), okChecker{})
+	require.NoError(t, err)
+
+	var got []string
+	for _, route := range r.Routes() {
+		got = append(got, route.Method+" "+route.Path)
+	}
+
+	assert.ElementsMatch(t, []string{
+		"GET /health",
+		"GET /swagger.json",
+		"GET /api/v1/static/*filepath",
+		"HEAD /api/v1/static/*filepath",
+		"POST /api/v1/auth/signup",
+		"POST /api/v1/auth/signin",
+		"POST /api/v1/auth/logout",
+		"POST /api/v1/auth/password/reset",
+		"POST /api/v1/auth/password/check",
+		"GET /api/v1/auth/available",
+		"POST /api/v1/auth/token/introspect",
+		"POST /api/v1/auth/users/lookup",
+		"GET /api/v1/users",
+		"GET /api/v1/users/:id",
+		"GET /api/v1/users/search",
+		"DELETE /api/v1/users/:id",
+		"POST /api/v1/users/:id/force-password-reset",
+		"GET /api/v1/user/profile",
+		"PUT /api/v1/user/password",
+		"PUT /api/v1/user/profile",
+		"PATCH /api/v1/user/profile",
+		"PUT /api/v1/user/email",
+		"PUT /api/v1/user/avatar",
+		"DELETE /api/v1/user",
+		"POST /api/v1/user/api-tokens",
+		"GET /api/v1/user/api-tokens",
+		"DELETE /api/v1/user/api-tokens/:id",
+	}, got)
+
+	t.Run("Protected routes require a token", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/user/profile", nil))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("Wrong method - 405 with Allow", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/auth/signup", nil))
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+		assert.Equal(t, "POST", w.Header().Get("Allow"))
+		assert.JSONEq(t, `{"error":"method not allowed"}`, w.Body.String())
+
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/user/profile", nil))
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+		assert.ElementsMatch(t, []string{"GET", "PUT", "PATCH"}, strings.Split(w.Header().Get("Allow"), ", "))
+	})
+
+	t.Run("CORS preflight is not a 405", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodOptions, "/api/v1/auth/signup", nil)
+		req.Header.Set("Origin", "http://localhost:5173")
+		req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, "600", w.Header().Get("Access-Control-Max-Age"))
+		assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+		assert.Equal(t, "http://localhost:5173", w.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("Unknown route - JSON 404", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/nope", nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.JSONEq(t, `{"error":"not found"}`, w.Body.String())
+	})
+
+	t.Run("Health stays at the root", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotEmpty(t, w.Header().Get(handler.RequestIDHeader))
+	})
+}
+
+func TestNewRouter_S3HasNoStaticRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := testConfig()
+	cfg.Storage.Backend = "s3"
+	h := handler.NewAuthHandler(nil, handler.Config{Secret: "secret", JWTTTL: 1}, zap.NewNop())
+	r, err := NewRouter(h, cfg, zap.NewNop(), okChecker{})
+	require.NoError(t, err)
+
+	for _, route := range r.Routes() {
+		assert.NotContains(t, route.Path, "/static")
+	}
+}
+
+func TestNewRouter_DocsOnlyInDebug(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := handler.NewAuthHandler(nil, handler.Config{Secret: "secret", JWTTTL: 1}, zap.NewNop())
+
+	for mode, want := range map[string]int{"debug": http.StatusOK, "release": http.StatusNotFound} {
+		cfg := testConfig()
+		cfg.App.Mode = mode
+		r, err := NewRouter(h, cfg, zap.NewNop(), okChecker{})
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/docs", nil))
+		assert.Equal(t, want, w.Code, mode)
+
+		// Спецификация нужна клиентам и в проде
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/swagger.json", nil))
+		assert.Equal(t, http.StatusOK, w.Code, mode)
+	}
+}
+
+func TestNewRouter_DisabledFeature(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := testConfig()
+	cfg.Features = map[string]bool{config.FeaturePasswordCheck: false}
+	h := handler.NewAuthHandler(nil, handler.Config{Secret: "secret", JWTTTL: 1}, zap.NewNop())
+	r, err := NewRouter(h, cfg, zap.NewNop(), okChecker{})
+	require.NoError(t, err)
+
+	for _, route := range r.Routes() {
+		assert.NotEqual(t, "/api/v1/auth/password/check", route.Path)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/auth/password/check", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestNewRouter_TrustedPlatform(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := testConfig()
+	cfg.Server.TrustedPlatform = "cloudflare"
+	r, err := NewRouter(&handler.AuthHandler{}, cfg, zap.NewNop(), okChecker{})
+	require.NoError(t, err)
+
+	var clientIP string
+	r.GET("/ip", func(c *gin.Context) { clientIP = c.ClientIP() })
+
+	req := httptest.NewRequest(http.MethodGet, "/ip", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("CF-Connecting-IP", "203.0.113.7")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, "203.0.113.7", clientIP)
+
+	cfg.Server.TrustedPlatform = "heroku"
+	_, err = NewRouter(&handler.AuthHandler{}, cfg, zap.NewNop(), okChecker{})
+	assert.ErrorContains(t, err, "server.trusted_platform")
+}
+
+func TestNewRouter_InvalidTrustedProxies(t *testing.T) {
+	cfg := testConfig()
+	cfg.Server.TrustedProxies = []string{"not-an-ip"}
+
+	_, err := NewRouter(&handler.AuthHandler{}, cfg, zap.NewNop(), okChecker{})
+	assert.ErrorContains(t, err, "server.trusted_proxies")
+}
@@ -2,55 +2,206 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/hasher"
 	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/model"
+	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/notify"
 	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/repository"
 	"go.uber.org/zap"
-	"golang.org/x/crypto/bcrypt"
 )
 
+// ErrWrongPassword - текущий пароль не подтвержден при опасной операции
+var ErrWrongPassword = errors.New("wrong password")
+
+// ErrInvalidAPIToken - API-токен неизвестен: отозван или никогда не выдавался
+var ErrInvalidAPIToken = errors.New("invalid api token")
+
+// ErrDisposableEmail - домен адреса в блоклисте одноразовой почты (security.block_disposable_email)
+var ErrDisposableEmail = errors.New("disposable email addresses are not allowed")
+
+// APITokenPrefix отличает API-токены от JWT и помогает сканерам секретов находить их в коде
+const APITokenPrefix = "mbh_"
+
 type AuthService interface {
 	Register(ctx context.Context, req *model.CreateUserRequest) (uuid.UUID, error)
+	// RegisterAndLogin регистрирует и сразу выпускает токен, как Login, без повторной проверки пароля
+	RegisterAndLogin(ctx context.Context, req *model.CreateUserRequest) (uuid.UUID, string, error)
 	Login(ctx context.Context, req *model.LoginRequest) (string, error)
 	GetByID(ctx context.Context, id uuid.UUID) (*model.User, error)
 	GetByEmail(ctx context.Context, email string) (*model.User, error)
+	// CheckAvailability - свободен ли username (или email, если username пустой) для регистрации
+	CheckAvailability(ctx context.Context, query *model.AvailabilityQuery) (bool, error)
 	ChangeProfile(ctx context.Context, userID uuid.UUID, req *model.ChangeProfileRequest) error
+	// PatchProfile меняет только присланные поля профиля
+	PatchProfile(ctx context.Context, userID uuid.UUID, req *model.PatchProfileRequest) error
 	ChangeEmail(ctx context.Context, userID uuid.UUID, req *model.ChangeEmailRequest) error
 	ChangePassword(ctx context.Context, userID uuid.UUID, req *model.ChangePasswordRequest) error
+	// IssuePasswordReset выдает одноразовый токен сброса пароля пользователя; actorID - администратор
+	IssuePasswordReset(ctx context.Context, userID, actorID uuid.UUID) (*model.PasswordResetToken, error)
+	// ResetPassword ставит новый пароль по токену; repository.ErrInvalidResetToken - токен недействителен
+	ResetPassword(ctx context.Context, req *model.ResetPasswordRequest) error
+	// CreateAPIToken выпускает API-токен; его значение возвращается только здесь
+	CreateAPIToken(ctx context.Context, userID uuid.UUID, req *model.CreateAPITokenRequest) (*model.CreatedAPIToken, error)
+	ListAPITokens(ctx context.Context, userID uuid.UUID) ([]*model.APIToken, error)
+	// RevokeAPIToken - repository.ErrNotFound, если у пользователя нет такого токена
+	RevokeAPIToken(ctx context.Context, userID, tokenID uuid.UUID) error
+	// AuthenticateAPIToken возвращает владельца токена (ID, username, role) и отмечает его
+	// использование; ErrInvalidAPIToken - токен не принят
+	AuthenticateAPIToken(ctx context.Context, token string) (*model.User, error)
+	// SetAvatar запоминает ключ уже загруженного в хранилище аватара
+	SetAvatar(ctx context.Context, userID uuid.UUID, avatarKey string) error
+	// Delete удаляет без проверок - для администратора
 	Delete(ctx context.Context, userID uuid.UUID) error
-	GetUsers(ctx context.Context, limit, offset int) ([]*model.User, error)
+	// DeleteSelf удаляет свой аккаунт только после проверки текущего пароля
+	DeleteSelf(ctx context.Context, userID uuid.UUID, password string) error
+	GetUsers(ctx context.Context, limit, offset int) ([]*model.UserListItem, error)
+	// LookupUsers - публичные данные пользователей для других сервисов по id или username;
+	// неизвестные пропускаются
+	LookupUsers(ctx context.Context, ids []uuid.UUID, usernames []string) ([]*model.UserSummary, error)
+	GetUsersFiltered(ctx context.Context, filter model.UsersFilter, limit, offset int) ([]*model.UserListItem, error)
+	// ListUsers - страница списка вместе с общим числом пользователей под фильтром
+	ListUsers(ctx context.Context, filter model.UsersFilter, limit, offset int) (*model.UsersPage, error)
+	// StreamUsers - все пользователи без пагинации, по одному, для экспорта
+	StreamUsers(ctx context.Context, fn func(*model.UserListItem) error) error
+	// SessionActive - не вытеснена ли сессия токена более новым входом (auth.single_session).
+	// При выключенном режиме всегда true
+	SessionActive(ctx context.Context, userID uuid.UUID, sessionID string) (bool, error)
 }
 
 type authService struct {
 	repo   repository.AuthRepository
+	hasher hasher.PasswordHasher
 	logger *zap.Logger
 	jwtSecret    string
-	jwtExpirationHours time.Duration
+	jwtTTL       time.Duration
+	jwtAudience        string
+	// singleSession - новый Login отзывает все прежние токены пользователя
+	singleSession bool
+	pagination    Pagination
+	// resetTokenTTL - срок жизни токена сброса пароля
+	resetTokenTTL time.Duration
+	// rehashOnLogin - после успешного входа обновлять устаревший хеш пароля
+	rehashOnLogin bool
+	// clock подменяется в тестах, чтобы проверять сроки жизни точно, а не WithinDuration
+	clock Clock
+	// notifier - письма о смене email и пароля; без SMTP - notify.Nop
+	notifier notify.Notifier
+	// notifying - письма, которые еще отправляются в фоне; тесты дожидаются их через Wait
+	notifying sync.WaitGroup
+	// disposable - блоклист одноразовой почты; nil - проверка выключена
+	disposable *model.DisposableDomains
+	// notBeforeSkew - nbf = время выпуска минус skew, чтобы реплика с отстающими часами не отклонила свежий токен;
+	// 0 - nbf не пишется
+	notBeforeSkew time.Duration
+}
+
+// Clock - источник текущего времени для сроков жизни токенов
+type Clock interface {
+	Now() time.Time
+}
+
+// realClock - системное время, используется по умолчанию
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+// Pagination - лимиты страницы для списков (pagination.* в конфиге)
+type Pagination struct {
+	DefaultLimit int
+	MaxLimit     int
 }
 
-func NewAuthService(
-	repo repository.AuthRepository,
-	logger *zap.Logger,
-	jwtSecret string, 
-	jwtExpirationHours time.Duration,
-) AuthService {
+// DefaultPagination - прежние захардкоженные значения; берутся, если лимиты не заданы
+var DefaultPagination = Pagination{DefaultLimit: 10, MaxLimit: 100}
+
+// DefaultTokenTTL - срок жизни JWT, если не задан
+const DefaultTokenTTL = 24 * time.Hour
+
+// DefaultResetTokenTTL - срок жизни токена сброса пароля, если не задан
+const DefaultResetTokenTTL = time.Hour
+
+// notifyTimeout - сколько ждем почтовый сервер: письмо уходит уже после ответа клиенту,
+// поэтому дедлайн запроса к нему не относится
+const notifyTimeout = 30 * time.Second
+
+// Config - настройки authService из конфига сервиса; нулевые значения заменяются дефолтами
+type Config struct {
+	JWTSecret string
+	// JWTTTL - срок жизни JWT; 0 - DefaultTokenTTL
+	JWTTTL      time.Duration
+	JWTAudience string
+	// SingleSession - новый Login отзывает все прежние токены пользователя
+	SingleSession bool
+	// Pagination - лимиты списков; нулевые - DefaultPagination
+	Pagination Pagination
+	// ResetTokenTTL - срок жизни токена сброса пароля; 0 - DefaultResetTokenTTL
+	ResetTokenTTL time.Duration
+	RehashOnLogin bool
+	// Notifier - письма о смене email и пароля; nil - notify.Nop
+	Notifier notify.Notifier
+	// Disposable - блоклист одноразовой почты; nil - проверка выключена
+	Disposable    *model.DisposableDomains
+	NotBeforeSkew time.Duration
+}
+
+func NewAuthService(repo repository.AuthRepository, hasher hasher.PasswordHasher, cfg Config, logger *zap.Logger) AuthService {
+	pagination := cfg.Pagination
+	if pagination.DefaultLimit <= 0 || pagination.MaxLimit <= 0 {
+		pagination = DefaultPagination
+	}
+	jwtTTL := cfg.JWTTTL
+	if jwtTTL <= 0 {
+		jwtTTL = DefaultTokenTTL
+	}
+	resetTokenTTL := cfg.ResetTokenTTL
+	if resetTokenTTL <= 0 {
+		resetTokenTTL = DefaultResetTokenTTL
+	}
+	notifier := cfg.Notifier
+	if notifier == nil {
+		notifier = notify.Nop{}
+	}
 	return &authService{
-		repo: repo, 
-		logger: logger, 
-		jwtSecret: jwtSecret, 
-		jwtExpirationHours: 
-		jwtExpirationHours,
+		repo:          repo,
+		hasher:        hasher,
+		logger:        logger,
+		jwtSecret:     cfg.JWTSecret,
+		jwtTTL:        jwtTTL,
+		jwtAudience:   cfg.JWTAudience,
+		singleSession: cfg.SingleSession,
+		pagination:    pagination,
+		resetTokenTTL: resetTokenTTL,
+		rehashOnLogin: cfg.RehashOnLogin,
+		clock:         realClock{},
+		notifier:      notifier,
+		disposable:    cfg.Disposable,
+		notBeforeSkew: cfg.NotBeforeSkew,
 	}
 }
 
 func (s *authService) Register(ctx context.Context, req *model.CreateUserRequest) (uuid.UUID, error) {
+	// Хеширование дорогое - не тратим его на запрос, который уже никому не нужен
+	if err := ctx.Err(); err != nil {
+		return uuid.Nil, err
+	}
+
+	if s.disposable.Blocked(req.Email) {
+		return uuid.Nil, ErrDisposableEmail
+	}
+
 	// 1. Хешируем пароль
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	hashedPassword, err := s.hasher.Hash(req.Password)
 	if err != nil {
 		return uuid.Nil, fmt.Errorf("hash password: %w", err)
 	}
@@ -59,7 +210,7 @@ func (s *authService) Register(ctx context.Context, req *model.CreateUserRequest
 	user := &model.User{
 		Username: req.Username,
 		Email:    req.Email,
-		Password: string(hashedPassword),
+		Password: hashedPassword,
 	}
 
 	// 3. Сохраняем в БД
@@ -74,37 +225,142 @@ func (s *authService) Register(ctx context.Context, req *model.CreateUserRequest
 
 func (s *authService) Login(ctx context.Context, req *model.LoginRequest) (string, error) {
 	// 1. Ищем пользователя по email
-	user, err := s.repo.GetByEmail(ctx, req.Email)
+	user, err := s.repo.GetCredentialsByEmail(ctx, req.Email)
 	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return "", ctxErr
+		}
+		// Перегруженная база - не повод отвечать 401: клиент решит, что пароль неверный
+		if errors.Is(err, repository.ErrDatabaseBusy) {
+			return "", err
+		}
 		// Специально возвращаем общую ошибку, чтобы не подсказывать хакерам (есть такой юзер или нет)
 		s.logger.Warn("login failed: user not found", zap.String("email", req.Email))
 		return "", fmt.Errorf("invalid credentials")
 	}
-	
-	if s.jwtSecret == "" {
-		s.logger.Error("jwt secret is empty")
-		return "", fmt.Errorf("failed to generate token")
-	}
 
 	// 2. Проверяем пароль (сравниваем хеш из БД и присланный пароль)
-	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password))
+	err = s.hasher.Compare(user.Password, req.Password)
 	if err != nil {
 		s.logger.Warn("login failed: invalid password", zap.String("email", req.Email))
 		return "", fmt.Errorf("invalid credentials")
 	}
 
-	// 3. Генерируем JWT токен
-	expirationTime := time.Now().Add(time.Duration(s.jwtExpirationHours) * time.Hour)
+	if s.rehashOnLogin && s.hasher.NeedsRehash(user.Password) {
+		s.rehashPassword(ctx, user.ID, req.Password)
+	}
+
+	// 3. В режиме одной сессии новый sid вытесняет прежний - старые токены перестают проходить проверку
+	var sessionID string
+	if s.singleSession {
+		sessionID, err = s.startSession(ctx, user.ID)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	// 4. Генерируем JWT токен
+	tokenString, err := s.issueToken(user, sessionID)
+	if err != nil {
+		return "", err
+	}
+
+	s.logger.Info("user logged in", zap.String("user_id", user.ID.String()))
+	return tokenString, nil
+}
+
+// rehashPassword перехеширует пароль текущими алгоритмом и параметрами. Best-effort:
+// ошибка только логируется, вход от нее не падает - попробуем при следующем
+func (s *authService) rehashPassword(ctx context.Context, userID uuid.UUID, password string) {
+	newHash, err := s.hasher.Hash(password)
+	if err != nil {
+		s.logger.Warn("password rehash failed", zap.String("user_id", userID.String()), zap.Error(err))
+		return
+	}
+	if err := s.repo.UpdatePassword(ctx, userID, newHash); err != nil {
+		s.logger.Warn("password rehash failed", zap.String("user_id", userID.String()), zap.Error(err))
+		return
+	}
+	s.logger.Info("password rehashed on login", zap.String("user_id", userID.String()))
+}
+
+func (s *authService) RegisterAndLogin(ctx context.Context, req *model.CreateUserRequest) (uuid.UUID, string, error) {
+	id, err := s.Register(ctx, req)
+	if err != nil {
+		return uuid.Nil, "", err
+	}
+
+	// Роль у нового пользователя всегда по умолчанию (DEFAULT 'user' в миграции)
+	token, err := s.issueToken(&model.User{ID: id, Username: req.Username, Role: model.RoleUser}, "")
+	if err != nil {
+		return id, "", err
+	}
+
+	s.logger.Info("user logged in after signup", zap.String("user_id", id.String()))
+	return id, token, nil
+}
+
+// startSession выдает новый sid и сообщает о вытесненной сессии
+func (s *authService) startSession(ctx context.Context, userID uuid.UUID) (string, error) {
+	sessionID := uuid.NewString()
+	previous, err := s.repo.ReplaceSession(ctx, userID, sessionID)
+	if err != nil {
+		return "", fmt.Errorf("replace session: %w", err)
+	}
+
+	if previous != "" {
+		s.logger.Info("previous session revoked by new login",
+			zap.String("user_id", userID.String()),
+			zap.String("revoked_sid", previous),
+		)
+	}
+	return sessionID, nil
+}
+
+func (s *authService) SessionActive(ctx context.Context, userID uuid.UUID, sessionID string) (bool, error) {
+	if !s.singleSession {
+		return true, nil
+	}
+
+	current, err := s.repo.GetSessionID(ctx, userID)
+	if err != nil {
+		// Пользователя удалили - его токены больше не действуют
+		if errors.Is(err, repository.ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	// Токены, выданные до включения режима (sid пустой), живут до первого нового входа
+	return current == sessionID, nil
+}
+
+// issueToken подписывает JWT для пользователя - общий путь для Login и RegisterAndLogin
+func (s *authService) issueToken(user *model.User, sessionID string) (string, error) {
+	if s.jwtSecret == "" {
+		s.logger.Error("jwt secret is empty")
+		return "", fmt.Errorf("failed to generate token")
+	}
+
+	now := s.clock.Now()
+	expirationTime := now.Add(s.jwtTTL)
 
 	claims := &model.UserClaims{
-		UserID:   user.ID,
-		Username: user.Username,
+		UserID:    user.ID,
+		Username:  user.Username,
+		Role:      user.Role,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			IssuedAt:  jwt.NewNumericDate(now),
 			Issuer:    "auth-service",
 		},
 	}
+	if s.jwtAudience != "" {
+		claims.Audience = jwt.ClaimStrings{s.jwtAudience}
+	}
+	if s.notBeforeSkew > 0 {
+		claims.NotBefore = jwt.NewNumericDate(now.Add(-s.notBeforeSkew))
+	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 
@@ -115,7 +371,6 @@ func (s *authService) Login(ctx context.Context, req *model.LoginRequest) (strin
 		return "", fmt.Errorf("failed to generate token")
 	}
 
-	s.logger.Info("user logged in", zap.String("user_id", user.ID.String()))
 	return tokenString, nil
 }
 
@@ -129,6 +384,10 @@ func (s *authService) GetByID(ctx context.Context, id uuid.UUID) (*model.User, e
 	return user, nil
 }
 
+func (s *authService) LookupUsers(ctx context.Context, ids []uuid.UUID, usernames []string) ([]*model.UserSummary, error) {
+	return s.repo.LookupUsers(ctx, ids, usernames)
+}
+
 func (s *authService) GetByEmail(ctx context.Context, email string) (*model.User, error) {
 	user, err := s.repo.GetByEmail(ctx, email)
 	if err != nil {
@@ -139,14 +398,31 @@ func (s *authService) GetByEmail(ctx context.Context, email string) (*model.User
 	return user, nil
 }
 
+func (s *authService) CheckAvailability(ctx context.Context, query *model.AvailabilityQuery) (bool, error) {
+	var (
+		exists bool
+		err    error
+	)
+	if query.Username != "" {
+		exists, err = s.repo.UsernameExists(ctx, query.Username)
+	} else {
+		exists, err = s.repo.EmailExists(ctx, query.Email)
+	}
+	if err != nil {
+		return false, err
+	}
+	return !exists, nil
+}
+
 func (s *authService) ChangeProfile(ctx context.Context, userID uuid.UUID, req *model.ChangeProfileRequest) error {
 	// Вызываем правильный метод репозитория
-	err := s.repo.UpdateProfile(ctx, userID, req.NewUsername)
+	err := s.repo.UpdateProfile(ctx, userID, req.NewUsername, req.Version)
 	if err != nil {
 		if errors.Is(err, repository.ErrDuplicateUsername) {
 			return err
 		}
-		if errors.Is(err, repository.ErrNotFound) {
+		if errors.Is(err, repository.ErrNotFound) || errors.Is(err, repository.ErrVersionConflict) ||
+			errors.Is(err, repository.ErrDatabaseBusy) {
 			return err
 		}
 		s.logger.Error("failed to update profile in db", zap.Error(err))
@@ -157,14 +433,45 @@ func (s *authService) ChangeProfile(ctx context.Context, userID uuid.UUID, req *
 	return nil
 }
 
+func (s *authService) PatchProfile(ctx context.Context, userID uuid.UUID, req *model.PatchProfileRequest) error {
+	err := s.repo.PatchProfile(ctx, userID, req.Patch(), req.Version)
+	if err != nil {
+		if errors.Is(err, repository.ErrDuplicateUsername) ||
+			errors.Is(err, repository.ErrNotFound) || errors.Is(err, repository.ErrVersionConflict) ||
+			errors.Is(err, repository.ErrDatabaseBusy) {
+			return err
+		}
+		s.logger.Error("failed to patch profile in db", zap.Error(err))
+		return fmt.Errorf("internal error")
+	}
+
+	s.logger.Info("profile patched successfully", zap.String("user_id", userID.String()))
+	return nil
+}
+
 func (s *authService) ChangeEmail(ctx context.Context, userID uuid.UUID, req *model.ChangeEmailRequest) error {
-	// Вызываем правильный метод репозитория
-	err := s.repo.UpdateEmail(ctx, userID, req.NewEmail)
+	if s.disposable.Blocked(req.NewEmail) {
+		return ErrDisposableEmail
+	}
+
+	// Повторная проверка пароля: через email восстанавливается доступ, угнанной сессии его менять нельзя
+	user, err := s.repo.GetCredentialsByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.hasher.Compare(user.Password, req.CurrentPassword); err != nil {
+		s.logger.Warn("change email failed: wrong password", zap.String("user_id", userID.String()))
+		return ErrWrongPassword
+	}
+
+	err = s.repo.UpdateEmail(ctx, userID, req.NewEmail, req.Version)
 	if err != nil {
 		if errors.Is(err, repository.ErrDuplicateEmail) {
 			return err
 		}
-		if errors.Is(err, repository.ErrNotFound) {
+		if errors.Is(err, repository.ErrNotFound) || errors.Is(err, repository.ErrVersionConflict) ||
+			errors.Is(err, repository.ErrDatabaseBusy) {
 			return err
 		}
 		s.logger.Error("failed to update email in db", zap.Error(err))
@@ -172,41 +479,198 @@ func (s *authService) ChangeEmail(ctx context.Context, userID uuid.UUID, req *mo
 	}
 
 	s.logger.Info("email changed successfully", zap.String("user_id", userID.String()), zap.String("new_email", req.NewEmail))
+
+	// Пишем на старый адрес: если email сменил не владелец, узнать об этом он может только там
+	s.notify(ctx, userID, notify.Message{
+		To:      user.Email,
+		Subject: "Your email address was changed",
+		Body: fmt.Sprintf("The email address of your account %s was changed to %s.\n\n"+
+			"If you did not do this, contact support immediately.", user.Username, req.NewEmail),
+	})
+	return nil
+}
+
+func (s *authService) SetAvatar(ctx context.Context, userID uuid.UUID, avatarKey string) error {
+	if err := s.repo.UpdateAvatar(ctx, userID, avatarKey); err != nil {
+		if errors.Is(err, repository.ErrNotFound) || errors.Is(err, repository.ErrDatabaseBusy) {
+			return err
+		}
+		s.logger.Error("failed to update avatar in db", zap.Error(err))
+		return fmt.Errorf("internal error")
+	}
+
+	s.logger.Info("avatar changed successfully", zap.String("user_id", userID.String()))
 	return nil
 }
 
 func (s *authService) ChangePassword(ctx context.Context, userID uuid.UUID, req *model.ChangePasswordRequest) error {
 	// 1. Получаем текущего пользователя из базы
-	user, err := s.repo.GetByID(ctx, userID)
+	user, err := s.repo.GetCredentialsByID(ctx, userID)
 	if err != nil {
 		return err
 	}
 
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	// 2. Проверяем, правильно ли введен СТАРЫЙ пароль
-	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.OldPassword))
+	err = s.hasher.Compare(user.Password, req.OldPassword)
 	if err != nil {
 		s.logger.Warn("change password failed: wrong old password", zap.String("user_id", userID.String()))
 		return fmt.Errorf("invalid old password")
 	}
 
 	// 3. Хешируем НОВЫЙ пароль
-	newHash, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	newHash, err := s.hasher.Hash(req.NewPassword)
 	if err != nil {
 		s.logger.Error("failed to hash new password", zap.Error(err))
 		return fmt.Errorf("internal error")
 	}
 
 	// 4. Сохраняем новый хеш в базу
-	err = s.repo.UpdatePassword(ctx, userID, string(newHash))
+	err = s.repo.UpdatePassword(ctx, userID, newHash)
 	if err != nil {
+		if errors.Is(err, repository.ErrDatabaseBusy) {
+			return err
+		}
 		s.logger.Error("failed to update password in db", zap.Error(err))
 		return fmt.Errorf("internal error")
 	}
 
 	s.logger.Info("password changed successfully", zap.String("user_id", userID.String()))
+
+	s.notify(ctx, userID, notify.Message{
+		To:      user.Email,
+		Subject: "Your password was changed",
+		Body: fmt.Sprintf("The password of your account %s was changed.\n\n"+
+			"If you did not do this, reset your password and contact support immediately.", user.Username),
+	})
+	return nil
+}
+
+// notify - письмо пользователю без влияния на результат: изменение уже сохранено,
+// недоступный почтовый сервер не должен превращать его в 500 или задерживать ответ.
+// Отправка идет в фоне: отмена запроса ее не прерывает, ограничивает только notifyTimeout
+func (s *authService) notify(ctx context.Context, userID uuid.UUID, msg notify.Message) {
+	ctx = context.WithoutCancel(ctx)
+	s.notifying.Add(1)
+	go func() {
+		defer s.notifying.Done()
+
+		ctx, cancel := context.WithTimeout(ctx, notifyTimeout)
+		defer cancel()
+
+		if err := s.notifier.Send(ctx, msg); err != nil {
+			s.logger.Warn("failed to send notification",
+				zap.String("user_id", userID.String()),
+				zap.String("subject", msg.Subject),
+				zap.Error(err),
+			)
+		}
+	}()
+}
+
+func (s *authService) IssuePasswordReset(ctx context.Context, userID, actorID uuid.UUID) (*model.PasswordResetToken, error) {
+	token, err := randomToken()
+	if err != nil {
+		s.logger.Error("failed to generate reset token", zap.Error(err))
+		return nil, fmt.Errorf("internal error")
+	}
+	expiresAt := s.clock.Now().Add(s.resetTokenTTL)
+
+	// В базу - только хеш: утечка таблицы не дает сбросить чужой пароль
+	if err := s.repo.CreateResetToken(ctx, userID, actorID, hashToken(token), expiresAt); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("password reset issued by admin",
+		zap.String("user_id", userID.String()),
+		zap.String("admin_id", actorID.String()),
+		zap.Time("expires_at", expiresAt),
+	)
+	return &model.PasswordResetToken{Token: token, ExpiresAt: expiresAt}, nil
+}
+
+func (s *authService) ResetPassword(ctx context.Context, req *model.ResetPasswordRequest) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	newHash, err := s.hasher.Hash(req.NewPassword)
+	if err != nil {
+		s.logger.Error("failed to hash new password", zap.Error(err))
+		return fmt.Errorf("internal error")
+	}
+
+	userID, err := s.repo.ResetPassword(ctx, hashToken(req.Token), newHash)
+	if err != nil {
+		return err
+	}
+
+	s.logger.Info("password reset by token", zap.String("user_id", userID.String()))
+
+	// Пароль уже сменен: без адреса письмо просто не уходит, сброс при этом успешен
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		s.logger.Warn("failed to load user for reset notification", zap.String("user_id", userID.String()), zap.Error(err))
+		return nil
+	}
+	s.notify(ctx, userID, notify.Message{
+		To:      user.Email,
+		Subject: "Your password was reset",
+		Body: fmt.Sprintf("The password of your account %s was reset with a one-time link.\n\n"+
+			"If you did not do this, contact support immediately.", user.Username),
+	})
+	return nil
+}
+
+func (s *authService) CreateAPIToken(ctx context.Context, userID uuid.UUID, req *model.CreateAPITokenRequest) (*model.CreatedAPIToken, error) {
+	random, err := randomToken()
+	if err != nil {
+		s.logger.Error("failed to generate api token", zap.Error(err))
+		return nil, fmt.Errorf("internal error")
+	}
+	token := APITokenPrefix + random
+
+	created, err := s.repo.CreateAPIToken(ctx, userID, req.Label, hashToken(token))
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("api token created", zap.String("user_id", userID.String()), zap.String("token_id", created.ID.String()))
+	return &model.CreatedAPIToken{APIToken: *created, Token: token}, nil
+}
+
+func (s *authService) ListAPITokens(ctx context.Context, userID uuid.UUID) ([]*model.APIToken, error) {
+	return s.repo.ListAPITokens(ctx, userID)
+}
+
+func (s *authService) RevokeAPIToken(ctx context.Context, userID, tokenID uuid.UUID) error {
+	if err := s.repo.DeleteAPIToken(ctx, userID, tokenID); err != nil {
+		return err
+	}
+
+	s.logger.Info("api token revoked", zap.String("user_id", userID.String()), zap.String("token_id", tokenID.String()))
 	return nil
 }
 
+func (s *authService) AuthenticateAPIToken(ctx context.Context, token string) (*model.User, error) {
+	// Без префикса это точно не наш токен - не ходим в базу
+	if !strings.HasPrefix(token, APITokenPrefix) {
+		return nil, ErrInvalidAPIToken
+	}
+
+	user, err := s.repo.UseAPIToken(ctx, hashToken(token))
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrInvalidAPIToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
 func (s *authService) Delete(ctx context.Context, userID uuid.UUID) error {
 	err := s.repo.Delete(ctx, userID)
 	if err != nil {
@@ -217,18 +681,93 @@ func (s *authService) Delete(ctx context.Context, userID uuid.UUID) error {
 	return nil
 }
 
-func (s *authService) GetUsers(ctx context.Context, limit, offset int) ([]*model.User, error) {
-	// Правила по умолчанию живут здесь
-	if limit <= 0 || limit > 100 {
-		limit = 10
+func (s *authService) DeleteSelf(ctx context.Context, userID uuid.UUID, password string) error {
+	// Повторная проверка пароля: угнанная сессия не должна позволять удалить аккаунт
+	user, err := s.repo.GetCredentialsByID(ctx, userID)
+	if err != nil {
+		return err
 	}
-	if offset < 0 {
-		offset = 0
+
+	if err := s.hasher.Compare(user.Password, password); err != nil {
+		s.logger.Warn("delete account failed: wrong password", zap.String("user_id", userID.String()))
+		return ErrWrongPassword
 	}
 
+	return s.Delete(ctx, userID)
+}
+
+func (s *authService) GetUsers(ctx context.Context, limit, offset int) ([]*model.UserListItem, error) {
+	limit, offset = s.pagination.normalize(limit, offset)
+
 	users, err := s.repo.GetUsers(ctx, limit, offset)
 	if err != nil {
 		return nil, err
 	}
 	return users, nil
 }
+
+func (s *authService) GetUsersFiltered(ctx context.Context, filter model.UsersFilter, limit, offset int) ([]*model.UserListItem, error) {
+	limit, offset = s.pagination.normalize(limit, offset)
+
+	users, err := s.repo.GetUsersFiltered(ctx, filter, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func (s *authService) ListUsers(ctx context.Context, filter model.UsersFilter, limit, offset int) (*model.UsersPage, error) {
+	limit, offset = s.pagination.normalize(limit, offset)
+
+	var (
+		users []*model.UserListItem
+		err   error
+	)
+	if filter == (model.UsersFilter{}) {
+		users, err = s.repo.GetUsers(ctx, limit, offset)
+	} else {
+		users, err = s.repo.GetUsersFiltered(ctx, filter, limit, offset)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := s.repo.CountUsers(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.UsersPage{Users: users, Total: total, Limit: limit, Offset: offset}, nil
+}
+
+// StreamUsers не накапливает пользователей: память не зависит от размера базы.
+// Пароли не отдаются - репозиторий их не выбирает.
+func (s *authService) StreamUsers(ctx context.Context, fn func(*model.UserListItem) error) error {
+	return s.repo.StreamUsers(ctx, fn)
+}
+
+// normalize - правила пагинации живут здесь: limit вне (0, MaxLimit] заменяется на DefaultLimit
+func (p Pagination) normalize(limit, offset int) (int, int) {
+	if limit <= 0 || limit > p.MaxLimit {
+		limit = p.DefaultLimit
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return limit, offset
+}
+
+// randomToken - 32 случайных байта в base64url: угадать перебором нереально
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken - в базе токены (сброса пароля, API) лежат только так
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
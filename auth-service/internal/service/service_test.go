@@ -3,16 +3,22 @@ package service
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/hasher"
 	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/model"
+	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/notify"
 	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/repository"
+	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/repository/memory"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -41,13 +47,44 @@ func (m *MockAuthRepository) GetByEmail(ctx context.Context, email string) (*mod
 	return args.Get(0).(*model.User), args.Error(1)
 }
 
-func (m *MockAuthRepository) UpdateProfile(ctx context.Context, id uuid.UUID, username string) error {
-	args := m.Called(ctx, id, username)
+func (m *MockAuthRepository) GetCredentialsByID(ctx context.Context, id uuid.UUID) (*model.User, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.User), args.Error(1)
+}
+
+func (m *MockAuthRepository) GetCredentialsByEmail(ctx context.Context, email string) (*model.User, error) {
+	args := m.Called(ctx, email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.User), args.Error(1)
+}
+
+func (m *MockAuthRepository) UsernameExists(ctx context.Context, username string) (bool, error) {
+	args := m.Called(ctx, username)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockAuthRepository) EmailExists(ctx context.Context, email string) (bool, error) {
+	args := m.Called(ctx, email)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockAuthRepository) Ping(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func (m *MockAuthRepository) UpdateProfile(ctx context.Context, id uuid.UUID, username string, expectedVersion int) error {
+	args := m.Called(ctx, id, username, expectedVersion)
 	return args.Error(0)
 }
 
-func (m *MockAuthRepository) UpdateEmail(ctx context.Context, id uuid.UUID, email string) error {
-	args := m.Called(ctx, id, email)
+func (m *MockAuthRepository) UpdateEmail(ctx context.Context, id uuid.UUID, email string, expectedVersion int) error {
+	args := m.Called(ctx, id, email, expectedVersion)
 	return args.Error(0)
 }
 
@@ -56,25 +93,126 @@ func (m *MockAuthRepository) UpdatePassword(ctx context.Context, id uuid.UUID, h
 	return args.Error(0)
 }
 
+func (m *MockAuthRepository) CreateResetToken(ctx context.Context, userID, actorID uuid.UUID, tokenHash string, expiresAt time.Time) error {
+	args := m.Called(ctx, userID, actorID, tokenHash, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockAuthRepository) ResetPassword(ctx context.Context, tokenHash, newHash string) (uuid.UUID, error) {
+	args := m.Called(ctx, tokenHash, newHash)
+	return args.Get(0).(uuid.UUID), args.Error(1)
+}
+
+func (m *MockAuthRepository) CreateAPIToken(ctx context.Context, userID uuid.UUID, label, tokenHash string) (*model.APIToken, error) {
+	args := m.Called(ctx, userID, label, tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.APIToken), args.Error(1)
+}
+
+func (m *MockAuthRepository) ListAPITokens(ctx context.Context, userID uuid.UUID) ([]*model.APIToken, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.APIToken), args.Error(1)
+}
+
+func (m *MockAuthRepository) DeleteAPIToken(ctx context.Context, userID, tokenID uuid.UUID) error {
+	args := m.Called(ctx, userID, tokenID)
+	return args.Error(0)
+}
+
+func (m *MockAuthRepository) UseAPIToken(ctx context.Context, tokenHash string) (*model.User, error) {
+	args := m.Called(ctx, tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.User), args.Error(1)
+}
+
+func (m *MockAuthRepository) PatchProfile(ctx context.Context, id uuid.UUID, patch model.ProfilePatch, expectedVersion int) error {
+	args := m.Called(ctx, id, patch, expectedVersion)
+	return args.Error(0)
+}
+
+func (m *MockAuthRepository) UpdateAvatar(ctx context.Context, id uuid.UUID, avatarKey string) error {
+	args := m.Called(ctx, id, avatarKey)
+	return args.Error(0)
+}
+
+func (m *MockAuthRepository) ReplaceSession(ctx context.Context, id uuid.UUID, sessionID string) (string, error) {
+	args := m.Called(ctx, id, sessionID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockAuthRepository) GetSessionID(ctx context.Context, id uuid.UUID) (string, error) {
+	args := m.Called(ctx, id)
+	return args.String(0), args.Error(1)
+}
+
 func (m *MockAuthRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
 }
 
-func (m *MockAuthRepository) GetUsers(ctx context.Context, limit, offset int) ([]*model.User, error) {
+func (m *MockAuthRepository) LookupUsers(ctx context.Context, ids []uuid.UUID, usernames []string) ([]*model.UserSummary, error) {
+	args := m.Called(ctx, ids, usernames)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.UserSummary), args.Error(1)
+}
+
+func (m *MockAuthRepository) GetUsers(ctx context.Context, limit, offset int) ([]*model.UserListItem, error) {
 	args := m.Called(ctx, limit, offset)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).([]*model.User), args.Error(1)
+	return args.Get(0).([]*model.UserListItem), args.Error(1)
+}
+
+func (m *MockAuthRepository) GetUsersFiltered(ctx context.Context, filter model.UsersFilter, limit, offset int) ([]*model.UserListItem, error) {
+	args := m.Called(ctx, filter, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.UserListItem), args.Error(1)
+}
+
+func (m *MockAuthRepository) CountUsers(ctx context.Context, filter model.UsersFilter) (int, error) {
+	args := m.Called(ctx, filter)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockAuthRepository) StreamUsers(ctx context.Context, fn func(*model.UserListItem) error) error {
+	args := m.Called(ctx, fn)
+	return args.Error(0)
+}
+
+// fixedClock - Clock, который всегда возвращает одно и то же время
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time { return time.Time(c) }
+
+// recordingNotifier запоминает отправленные письма
+type recordingNotifier struct {
+	sent []notify.Message
+	err  error
+}
+
+func (n *recordingNotifier) Send(ctx context.Context, msg notify.Message) error {
+	n.sent = append(n.sent, msg)
+	return n.err
 }
 
 func setup(t *testing.T) (*authService, *MockAuthRepository) {
 	mockRepo := new(MockAuthRepository)
 	logger := zap.NewNop()
 	secret := "test-secret"
-	jwtExpirationHours := time.Duration(24)
-	svc := NewAuthService(mockRepo, logger, secret, jwtExpirationHours).(*authService)
+	jwtTTL := 24 * time.Hour
+	svc := NewAuthService(mockRepo, hasher.NewBcrypt(bcrypt.DefaultCost), Config{JWTSecret: secret, JWTTTL: jwtTTL}, logger).(*authService)
 	return svc, mockRepo
 }
 
@@ -107,6 +245,21 @@ func TestRegister(t *testing.T) {
 	repo.AssertExpectations(t)
 }
 
+func TestRegister_ContextCanceled(t *testing.T) {
+	svc, repo := setup(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	id, err := svc.Register(ctx, &model.CreateUserRequest{
+		Username: "u", Email: "e", Password: "p",
+	})
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, uuid.Nil, id)
+	// До хеширования и БД дело не дошло
+	repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
 func TestRegister_RepoError(t *testing.T) {
 	svc, repo := setup(t)
 	ctx := context.Background()
@@ -129,6 +282,8 @@ func TestRegister_RepoError(t *testing.T) {
 func TestLogin(t *testing.T) {
 	svc, repo := setup(t)
 	ctx := context.Background()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	svc.clock = fixedClock(now)
 
 	hash, _ := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.DefaultCost)
 	user := &model.User{
@@ -136,9 +291,10 @@ func TestLogin(t *testing.T) {
 		Username: "john",
 		Email:    "john@test.com",
 		Password: string(hash),
+		Role:     model.RoleAdmin,
 	}
 
-	repo.On("GetByEmail", ctx, user.Email).
+	repo.On("GetCredentialsByEmail", ctx, user.Email).
 		Return(user, nil).Once()
 
 	token, err := svc.Login(ctx, &model.LoginRequest{
@@ -152,19 +308,155 @@ func TestLogin(t *testing.T) {
 	parsed, err := jwt.ParseWithClaims(token, &model.UserClaims{},
 		func(token *jwt.Token) (interface{}, error) {
 			return []byte("test-secret"), nil
-		})
+		}, jwt.WithTimeFunc(func() time.Time { return now }))
 
 	assert.NoError(t, err)
 	claims := parsed.Claims.(*model.UserClaims)
 
 	assert.Equal(t, user.ID, claims.UserID)
 	assert.Equal(t, user.Username, claims.Username)
+	assert.Equal(t, model.RoleAdmin, claims.Role)
 	assert.Equal(t, "auth-service", claims.Issuer)
-	assert.WithinDuration(t,
-		time.Now().Add(24*time.Hour),
-		claims.ExpiresAt.Time,
-		time.Minute,
-	)
+	assert.Empty(t, claims.Audience)
+	assert.True(t, now.Equal(claims.IssuedAt.Time))
+	assert.True(t, now.Add(24*time.Hour).Equal(claims.ExpiresAt.Time))
+	assert.Nil(t, claims.NotBefore, "nbf is not written without jwt.not_before_skew")
+}
+
+func TestLogin_NotBeforeSkew(t *testing.T) {
+	svc, repo := setup(t)
+	ctx := context.Background()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	svc.clock = fixedClock(now)
+	svc.notBeforeSkew = 5 * time.Second
+
+	hash, _ := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	repo.On("GetCredentialsByEmail", ctx, "john@test.com").
+		Return(&model.User{ID: uuid.New(), Email: "john@test.com", Password: string(hash)}, nil).Once()
+
+	token, err := svc.Login(ctx, &model.LoginRequest{Email: "john@test.com", Password: "secret"})
+	assert.NoError(t, err)
+
+	// Реплика, чьи часы отстают на 3 секунды, принимает токен сразу после выпуска
+	parsed, err := jwt.ParseWithClaims(token, &model.UserClaims{},
+		func(token *jwt.Token) (interface{}, error) {
+			return []byte("test-secret"), nil
+		}, jwt.WithTimeFunc(func() time.Time { return now.Add(-3 * time.Second) }))
+	assert.NoError(t, err)
+
+	claims := parsed.Claims.(*model.UserClaims)
+	assert.True(t, now.Add(-5*time.Second).Equal(claims.NotBefore.Time))
+	assert.True(t, now.Equal(claims.IssuedAt.Time), "iat stays the real issue time")
+}
+
+func TestLogin_DatabaseBusy(t *testing.T) {
+	svc, repo := setup(t)
+	ctx := context.Background()
+
+	repo.On("GetCredentialsByEmail", ctx, "john@test.com").
+		Return((*model.User)(nil), repository.ErrDatabaseBusy).Once()
+
+	// Перегрузка базы не маскируется под неверный пароль
+	_, err := svc.Login(ctx, &model.LoginRequest{Email: "john@test.com", Password: "secret"})
+	assert.ErrorIs(t, err, repository.ErrDatabaseBusy)
+}
+
+func TestLogin_Audience(t *testing.T) {
+	svc, repo := setup(t)
+	svc.jwtAudience = "mobile"
+	ctx := context.Background()
+
+	hash, _ := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	user := &model.User{ID: uuid.New(), Username: "john", Email: "john@test.com", Password: string(hash)}
+	repo.On("GetCredentialsByEmail", ctx, user.Email).Return(user, nil).Once()
+
+	token, err := svc.Login(ctx, &model.LoginRequest{Email: user.Email, Password: "secret"})
+	assert.NoError(t, err)
+
+	parsed, err := jwt.ParseWithClaims(token, &model.UserClaims{},
+		func(token *jwt.Token) (interface{}, error) {
+			return []byte("test-secret"), nil
+		}, jwt.WithAudience("mobile"))
+	assert.NoError(t, err)
+	assert.Equal(t, jwt.ClaimStrings{"mobile"}, parsed.Claims.(*model.UserClaims).Audience)
+}
+
+// TestLogin_LegacyBcryptHash - после перехода на argon2id старые bcrypt-хеши продолжают работать
+func TestLogin_LegacyBcryptHash(t *testing.T) {
+	svc, repo := setup(t)
+	ctx := context.Background()
+
+	h, err := hasher.New(hasher.Argon2id, 0)
+	assert.NoError(t, err)
+	svc.hasher = h
+
+	hash, _ := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	user := &model.User{ID: uuid.New(), Username: "john", Email: "john@test.com", Password: string(hash)}
+
+	repo.On("GetCredentialsByEmail", ctx, user.Email).Return(user, nil).Once()
+
+	token, err := svc.Login(ctx, &model.LoginRequest{Email: user.Email, Password: "secret"})
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, token)
+	repo.AssertExpectations(t)
+}
+
+// TestLogin_RehashOnLogin - устаревший хеш обновляется при входе, но только если это включено
+func TestLogin_RehashOnLogin(t *testing.T) {
+	ctx := context.Background()
+	hash, _ := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	newUser := func() *model.User {
+		return &model.User{ID: uuid.New(), Username: "john", Email: "john@test.com", Password: string(hash)}
+	}
+	// cost выше, чем у сохраненного хеша
+	newService := func(rehash bool) (*authService, *MockAuthRepository) {
+		svc, repo := setup(t)
+		svc.hasher = hasher.NewBcrypt(bcrypt.MinCost + 1)
+		svc.rehashOnLogin = rehash
+		return svc, repo
+	}
+	isStronger := mock.MatchedBy(func(h string) bool {
+		cost, err := bcrypt.Cost([]byte(h))
+		return err == nil && cost == bcrypt.MinCost+1
+	})
+
+	t.Run("Weak hash is upgraded", func(t *testing.T) {
+		svc, repo := newService(true)
+		user := newUser()
+		repo.On("GetCredentialsByEmail", ctx, user.Email).Return(user, nil).Once()
+		repo.On("UpdatePassword", ctx, user.ID, isStronger).Return(nil).Once()
+
+		token, err := svc.Login(ctx, &model.LoginRequest{Email: user.Email, Password: "secret"})
+
+		assert.NoError(t, err)
+		assert.NotEmpty(t, token)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Write failure does not fail login", func(t *testing.T) {
+		svc, repo := newService(true)
+		user := newUser()
+		repo.On("GetCredentialsByEmail", ctx, user.Email).Return(user, nil).Once()
+		repo.On("UpdatePassword", ctx, user.ID, isStronger).Return(errors.New("db down")).Once()
+
+		token, err := svc.Login(ctx, &model.LoginRequest{Email: user.Email, Password: "secret"})
+
+		assert.NoError(t, err)
+		assert.NotEmpty(t, token)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Disabled by default", func(t *testing.T) {
+		svc, repo := newService(false)
+		user := newUser()
+		repo.On("GetCredentialsByEmail", ctx, user.Email).Return(user, nil).Once()
+
+		_, err := svc.Login(ctx, &model.LoginRequest{Email: user.Email, Password: "secret"})
+
+		assert.NoError(t, err)
+		repo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+	})
 }
 
 func TestLogin_InvalidPassword(t *testing.T) {
@@ -174,7 +466,7 @@ func TestLogin_InvalidPassword(t *testing.T) {
 	hash, _ := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.DefaultCost)
 	user := &model.User{ID: uuid.New(), Email: "e", Password: string(hash)}
 
-	repo.On("GetByEmail", ctx, "e").
+	repo.On("GetCredentialsByEmail", ctx, "e").
 		Return(user, nil).Once()
 
 	token, err := svc.Login(ctx, &model.LoginRequest{
@@ -189,7 +481,7 @@ func TestLogin_UserNotFound(t *testing.T) {
 	svc, repo := setup(t)
 	ctx := context.Background()
 
-	repo.On("GetByEmail", ctx, "x").
+	repo.On("GetCredentialsByEmail", ctx, "x").
 		Return(nil, errors.New("not found")).Once()
 
 	token, err := svc.Login(ctx, &model.LoginRequest{
@@ -214,7 +506,7 @@ func TestLogin_TokenSignError(t *testing.T) {
 		Password: string(hash),
 	}
 
-	mockRepo.On("GetByEmail", ctx, user.Email).Return(user, nil).Once()
+	mockRepo.On("GetCredentialsByEmail", ctx, user.Email).Return(user, nil).Once()
 
 	token, err := svc.Login(ctx, &model.LoginRequest{
 		Email:    user.Email,
@@ -298,7 +590,7 @@ func TestChangeProfile(t *testing.T) {
 	ctx := context.Background()
 	id := uuid.New()
 
-	repo.On("UpdateProfile", ctx, id, "new").
+	repo.On("UpdateProfile", ctx, id, "new", 0).
 		Return(nil).Once()
 
 	err := svc.ChangeProfile(ctx, id,
@@ -312,21 +604,21 @@ func TestChangeProfile_Errors(t *testing.T) {
 	ctx := context.Background()
 	id := uuid.New()
 
-	repo.On("UpdateProfile", ctx, id, "dup").
+	repo.On("UpdateProfile", ctx, id, "dup", 0).
 		Return(repository.ErrDuplicateUsername).Once()
 
 	err := svc.ChangeProfile(ctx, id,
 		&model.ChangeProfileRequest{NewUsername: "dup"})
 	assert.ErrorIs(t, err, repository.ErrDuplicateUsername)
 
-	repo.On("UpdateProfile", ctx, id, "x").
+	repo.On("UpdateProfile", ctx, id, "x", 0).
 		Return(errors.New("db crash")).Once()
 
 	err = svc.ChangeProfile(ctx, id,
 		&model.ChangeProfileRequest{NewUsername: "x"})
 	assert.Equal(t, "internal error", err.Error())
 
-	repo.On("UpdateProfile", ctx, id, "nf").
+	repo.On("UpdateProfile", ctx, id, "nf", 0).
 		Return(repository.ErrNotFound).Once()
 
 	err = svc.ChangeProfile(ctx, id,
@@ -334,6 +626,39 @@ func TestChangeProfile_Errors(t *testing.T) {
 	assert.ErrorIs(t, err, repository.ErrNotFound)
 }
 
+func TestPatchProfile(t *testing.T) {
+	svc, repo := setup(t)
+	ctx := context.Background()
+	id := uuid.New()
+	bio := "hello"
+	req := &model.PatchProfileRequest{Bio: &bio, Version: 3}
+
+	repo.On("PatchProfile", ctx, id, model.ProfilePatch{Bio: &bio}, 3).Return(nil).Once()
+	assert.NoError(t, svc.PatchProfile(ctx, id, req))
+
+	repo.On("PatchProfile", ctx, id, model.ProfilePatch{Bio: &bio}, 3).Return(repository.ErrVersionConflict).Once()
+	assert.ErrorIs(t, svc.PatchProfile(ctx, id, req), repository.ErrVersionConflict)
+
+	repo.On("PatchProfile", ctx, id, model.ProfilePatch{Bio: &bio}, 3).Return(errors.New("db crash")).Once()
+	assert.Equal(t, "internal error", svc.PatchProfile(ctx, id, req).Error())
+}
+
+func TestSetAvatar(t *testing.T) {
+	svc, repo := setup(t)
+	ctx := context.Background()
+	id := uuid.New()
+	key := "avatars/" + id.String()
+
+	repo.On("UpdateAvatar", ctx, id, key).Return(nil).Once()
+	assert.NoError(t, svc.SetAvatar(ctx, id, key))
+
+	repo.On("UpdateAvatar", ctx, id, key).Return(repository.ErrNotFound).Once()
+	assert.ErrorIs(t, svc.SetAvatar(ctx, id, key), repository.ErrNotFound)
+
+	repo.On("UpdateAvatar", ctx, id, key).Return(errors.New("db crash")).Once()
+	assert.Equal(t, "internal error", svc.SetAvatar(ctx, id, key).Error())
+}
+
 ////////////////////////////////////////////////////////////
 //////////////////// CHANGE EMAIL //////////////////////////
 ////////////////////////////////////////////////////////////
@@ -343,36 +668,99 @@ func TestChangeEmail(t *testing.T) {
 	ctx := context.Background()
 	id := uuid.New()
 
-	repo.On("UpdateEmail", ctx, id, "e").
+	hash, _ := bcrypt.GenerateFromPassword([]byte("current"), bcrypt.MinCost)
+	user := &model.User{ID: id, Password: string(hash)}
+	repo.On("GetCredentialsByID", ctx, id).Return(user, nil)
+
+	repo.On("UpdateEmail", ctx, id, "e", 0).
 		Return(nil).Once()
 
 	err := svc.ChangeEmail(ctx, id,
-		&model.ChangeEmailRequest{NewEmail: "e"})
+		&model.ChangeEmailRequest{NewEmail: "e", CurrentPassword: "current"})
 
 	assert.NoError(t, err)
 
-	repo.On("UpdateEmail", ctx, id, "dup").
+	repo.On("UpdateEmail", ctx, id, "dup", 0).
 		Return(repository.ErrDuplicateEmail).Once()
 
 	err = svc.ChangeEmail(ctx, id,
-		&model.ChangeEmailRequest{NewEmail: "dup"})
+		&model.ChangeEmailRequest{NewEmail: "dup", CurrentPassword: "current"})
 	assert.ErrorIs(t, err, repository.ErrDuplicateEmail)
 
-	repo.On("UpdateEmail", ctx, id, "nf").
+	repo.On("UpdateEmail", ctx, id, "nf", 0).
 		Return(repository.ErrNotFound).Once()
 
 	err = svc.ChangeEmail(ctx, id,
-		&model.ChangeEmailRequest{NewEmail: "nf"})
+		&model.ChangeEmailRequest{NewEmail: "nf", CurrentPassword: "current"})
 	assert.ErrorIs(t, err, repository.ErrNotFound)
 
-	repo.On("UpdateEmail", ctx, id, "x").
+	repo.On("UpdateEmail", ctx, id, "x", 0).
 		Return(errors.New("db")).Once()
 
 	err = svc.ChangeEmail(ctx, id,
-		&model.ChangeEmailRequest{NewEmail: "x"})
+		&model.ChangeEmailRequest{NewEmail: "x", CurrentPassword: "current"})
 	assert.Equal(t, "internal error", err.Error())
 }
 
+func TestChangeEmail_NotifiesOldAddress(t *testing.T) {
+	svc, repo := setup(t)
+	notifier := &recordingNotifier{}
+	svc.notifier = notifier
+	ctx := context.Background()
+	id := uuid.New()
+
+	hash, _ := bcrypt.GenerateFromPassword([]byte("current"), bcrypt.MinCost)
+	repo.On("GetCredentialsByID", ctx, id).
+		Return(&model.User{ID: id, Username: "alice", Email: "old@example.com", Password: string(hash)}, nil)
+	repo.On("UpdateEmail", ctx, id, "new@example.com", 0).Return(nil).Once()
+
+	err := svc.ChangeEmail(ctx, id,
+		&model.ChangeEmailRequest{NewEmail: "new@example.com", CurrentPassword: "current"})
+	require.NoError(t, err)
+	svc.notifying.Wait()
+	require.Len(t, notifier.sent, 1)
+	assert.Equal(t, "old@example.com", notifier.sent[0].To)
+	assert.Contains(t, notifier.sent[0].Body, "new@example.com")
+
+	// Ошибка почты не отменяет уже сохраненную смену
+	notifier.err = errors.New("smtp down")
+	repo.On("UpdateEmail", ctx, id, "other@example.com", 0).Return(nil).Once()
+	err = svc.ChangeEmail(ctx, id,
+		&model.ChangeEmailRequest{NewEmail: "other@example.com", CurrentPassword: "current"})
+	assert.NoError(t, err)
+	svc.notifying.Wait()
+
+	// Неудачная смена - писем нет
+	notifier.sent = nil
+	repo.On("UpdateEmail", ctx, id, "dup@example.com", 0).Return(repository.ErrDuplicateEmail).Once()
+	err = svc.ChangeEmail(ctx, id,
+		&model.ChangeEmailRequest{NewEmail: "dup@example.com", CurrentPassword: "current"})
+	assert.ErrorIs(t, err, repository.ErrDuplicateEmail)
+	svc.notifying.Wait()
+	assert.Empty(t, notifier.sent)
+}
+
+func TestChangeEmail_WrongPassword(t *testing.T) {
+	svc, repo := setup(t)
+	ctx := context.Background()
+	id := uuid.New()
+
+	hash, _ := bcrypt.GenerateFromPassword([]byte("current"), bcrypt.MinCost)
+	repo.On("GetCredentialsByID", ctx, id).Return(&model.User{ID: id, Password: string(hash)}, nil).Once()
+
+	err := svc.ChangeEmail(ctx, id,
+		&model.ChangeEmailRequest{NewEmail: "new@example.com", CurrentPassword: "wrong"})
+	assert.ErrorIs(t, err, ErrWrongPassword)
+	// До смены email дело доходить не должно
+	repo.AssertNotCalled(t, "UpdateEmail", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+
+	repo.On("GetCredentialsByID", ctx, id).Return(nil, repository.ErrNotFound).Once()
+
+	err = svc.ChangeEmail(ctx, id,
+		&model.ChangeEmailRequest{NewEmail: "new@example.com", CurrentPassword: "current"})
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+}
+
 ////////////////////////////////////////////////////////////
 //////////////////// CHANGE PASSWORD ///////////////////////
 ////////////////////////////////////////////////////////////
@@ -385,7 +773,7 @@ func TestChangePassword(t *testing.T) {
 	hash, _ := bcrypt.GenerateFromPassword([]byte("old"), bcrypt.DefaultCost)
 	user := &model.User{ID: id, Password: string(hash)}
 
-	repo.On("GetByID", ctx, id).
+	repo.On("GetCredentialsByID", ctx, id).
 		Return(user, nil).Once()
 
 	repo.On("UpdatePassword", ctx, id, mock.MatchedBy(func(h string) bool {
@@ -400,7 +788,7 @@ func TestChangePassword(t *testing.T) {
 
 	assert.NoError(t, err)
 
-	repo.On("GetByID", ctx, id).
+	repo.On("GetCredentialsByID", ctx, id).
 		Return(nil, errors.New("db")).Once()
 
 	err = svc.ChangePassword(ctx, id,
@@ -412,6 +800,55 @@ func TestChangePassword(t *testing.T) {
 
 }
 
+func TestChangePassword_Notifies(t *testing.T) {
+	svc, repo := setup(t)
+	notifier := &recordingNotifier{}
+	svc.notifier = notifier
+	ctx := context.Background()
+	id := uuid.New()
+
+	hash, _ := bcrypt.GenerateFromPassword([]byte("old"), bcrypt.MinCost)
+	repo.On("GetCredentialsByID", ctx, id).
+		Return(&model.User{ID: id, Email: "alice@example.com", Password: string(hash)}, nil).Once()
+	repo.On("UpdatePassword", ctx, id, mock.Anything).Return(nil).Once()
+
+	err := svc.ChangePassword(ctx, id, &model.ChangePasswordRequest{OldPassword: "old", NewPassword: "new"})
+	require.NoError(t, err)
+	svc.notifying.Wait()
+	require.Len(t, notifier.sent, 1)
+	assert.Equal(t, "alice@example.com", notifier.sent[0].To)
+	assert.Equal(t, "Your password was changed", notifier.sent[0].Subject)
+}
+
+// ctxNotifier запоминает состояние контекста в момент отправки
+type ctxNotifier struct {
+	err         error
+	deadline    time.Time
+	hasDeadline bool
+}
+
+func (n *ctxNotifier) Send(ctx context.Context, msg notify.Message) error {
+	n.err = ctx.Err()
+	n.deadline, n.hasDeadline = ctx.Deadline()
+	return nil
+}
+
+func TestNotify_OutlivesRequest(t *testing.T) {
+	svc, _ := setup(t)
+	notifier := &ctxNotifier{}
+	svc.notifier = notifier
+
+	// Запрос уже завершен - письмо все равно уходит, но с собственным дедлайном
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	svc.notify(ctx, uuid.New(), notify.Message{To: "alice@example.com"})
+	svc.notifying.Wait()
+
+	require.True(t, notifier.hasDeadline)
+	assert.NoError(t, notifier.err)
+	assert.WithinDuration(t, time.Now().Add(notifyTimeout), notifier.deadline, 5*time.Second)
+}
+
 func TestChangePassword_WrongOldPassword(t *testing.T) {
 	svc, repo := setup(t)
 	ctx := context.Background()
@@ -420,7 +857,7 @@ func TestChangePassword_WrongOldPassword(t *testing.T) {
 	hash, _ := bcrypt.GenerateFromPassword([]byte("correct"), bcrypt.DefaultCost)
 	user := &model.User{ID: id, Password: string(hash)}
 
-	repo.On("GetByID", ctx, id).
+	repo.On("GetCredentialsByID", ctx, id).
 		Return(user, nil).Once()
 
 	err := svc.ChangePassword(ctx, id,
@@ -440,7 +877,7 @@ func TestChangePassword_UpdateError(t *testing.T) {
 	hash, _ := bcrypt.GenerateFromPassword([]byte("old"), bcrypt.DefaultCost)
 	user := &model.User{ID: id, Password: string(hash)}
 
-	repo.On("GetByID", ctx, id).
+	repo.On("GetCredentialsByID", ctx, id).
 		Return(user, nil).Once()
 
 	repo.On("UpdatePassword", ctx, id, mock.Anything).
@@ -471,6 +908,40 @@ func TestDelete(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestDeleteSelf(t *testing.T) {
+	svc, repo := setup(t)
+	ctx := context.Background()
+	id := uuid.New()
+
+	hash, _ := bcrypt.GenerateFromPassword([]byte("current"), bcrypt.MinCost)
+	user := &model.User{ID: id, Password: string(hash)}
+
+	t.Run("Correct Password", func(t *testing.T) {
+		repo.On("GetCredentialsByID", ctx, id).Return(user, nil).Once()
+		repo.On("Delete", ctx, id).Return(nil).Once()
+
+		err := svc.DeleteSelf(ctx, id, "current")
+		assert.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Wrong Password", func(t *testing.T) {
+		repo.On("GetCredentialsByID", ctx, id).Return(user, nil).Once()
+
+		err := svc.DeleteSelf(ctx, id, "wrong")
+		assert.ErrorIs(t, err, ErrWrongPassword)
+		// До удаления дело доходить не должно
+		repo.AssertNumberOfCalls(t, "Delete", 1)
+	})
+
+	t.Run("User Not Found", func(t *testing.T) {
+		repo.On("GetCredentialsByID", ctx, id).Return(nil, repository.ErrNotFound).Once()
+
+		err := svc.DeleteSelf(ctx, id, "current")
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+}
+
 func TestDelete_Error(t *testing.T) {
 	svc, repo := setup(t)
 	ctx := context.Background()
@@ -491,7 +962,7 @@ func TestGetUsers(t *testing.T) {
 	svc, repo := setup(t)
 	ctx := context.Background()
 
-	users := []*model.User{{ID: uuid.New()}}
+	users := []*model.UserListItem{{ID: uuid.New()}}
 
 	repo.On("GetUsers", ctx, 10, 0).
 		Return(users, nil).Once()
@@ -515,3 +986,329 @@ func TestGetUsers_Error(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, res)
 }
+
+func TestGetUsersFiltered(t *testing.T) {
+	svc, repo := setup(t)
+	ctx := context.Background()
+
+	from := time.Now().Add(-7 * 24 * time.Hour)
+	filter := model.UsersFilter{CreatedFrom: &from}
+	users := []*model.UserListItem{{ID: uuid.New()}}
+
+	// Пагинация нормализуется так же, как и в GetUsers
+	repo.On("GetUsersFiltered", ctx, filter, 10, 0).
+		Return(users, nil).Once()
+
+	res, err := svc.GetUsersFiltered(ctx, filter, 1000, -5)
+
+	assert.NoError(t, err)
+	assert.Equal(t, users, res)
+	repo.AssertExpectations(t)
+}
+
+func TestListUsers(t *testing.T) {
+	svc, repo := setup(t)
+	ctx := context.Background()
+
+	users := []*model.UserListItem{{ID: uuid.New()}}
+	repo.On("GetUsers", ctx, 10, 20).Return(users, nil).Once()
+	repo.On("CountUsers", ctx, model.UsersFilter{}).Return(42, nil).Once()
+
+	page, err := svc.ListUsers(ctx, model.UsersFilter{}, 0, 20)
+	assert.NoError(t, err)
+	assert.Equal(t, &model.UsersPage{Users: users, Total: 42, Limit: 10, Offset: 20}, page)
+
+	filter := model.UsersFilter{SortBy: "username"}
+	repo.On("GetUsersFiltered", ctx, filter, 10, 0).Return(users, nil).Once()
+	repo.On("CountUsers", ctx, filter).Return(0, errors.New("db")).Once()
+
+	page, err = svc.ListUsers(ctx, filter, 1000, -5)
+	assert.Error(t, err)
+	assert.Nil(t, page)
+	repo.AssertExpectations(t)
+}
+
+func TestGetUsers_ConfiguredPagination(t *testing.T) {
+	repo := new(MockAuthRepository)
+	svc := NewAuthService(repo, hasher.NewBcrypt(bcrypt.MinCost), Config{
+		JWTSecret:  "test-secret",
+		JWTTTL:     24 * time.Hour,
+		Pagination: Pagination{DefaultLimit: 25, MaxLimit: 50},
+	}, zap.NewNop())
+	ctx := context.Background()
+
+	repo.On("GetUsers", ctx, 25, 0).Return([]*model.UserListItem{}, nil).Twice()
+	repo.On("GetUsers", ctx, 50, 10).Return([]*model.UserListItem{}, nil).Once()
+
+	// Без limit и с limit больше максимума - default_limit
+	_, err := svc.GetUsers(ctx, 0, 0)
+	assert.NoError(t, err)
+	_, err = svc.GetUsers(ctx, 51, 0)
+	assert.NoError(t, err)
+	_, err = svc.GetUsers(ctx, 50, 10)
+	assert.NoError(t, err)
+
+	repo.AssertExpectations(t)
+}
+
+// TestAuthService_MemoryRepository - сквозной сценарий на репозитории в памяти, без Postgres
+func TestAuthService_MemoryRepository(t *testing.T) {
+	svc := NewAuthService(memory.NewAuthRepository(), hasher.NewBcrypt(bcrypt.MinCost), Config{JWTSecret: "test-secret", JWTTTL: 24 * time.Hour}, zap.NewNop())
+	ctx := context.Background()
+
+	id, err := svc.Register(ctx, &model.CreateUserRequest{Username: "mem", Email: "mem@test.com", Password: "password"})
+	assert.NoError(t, err)
+
+	_, err = svc.Register(ctx, &model.CreateUserRequest{Username: "mem", Email: "other@test.com", Password: "password"})
+	assert.ErrorIs(t, err, repository.ErrDuplicateUsername)
+
+	token, err := svc.Login(ctx, &model.LoginRequest{Email: "mem@test.com", Password: "password"})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, token)
+
+	err = svc.ChangePassword(ctx, id, &model.ChangePasswordRequest{OldPassword: "password", NewPassword: "new-password"})
+	assert.NoError(t, err)
+
+	_, err = svc.Login(ctx, &model.LoginRequest{Email: "mem@test.com", Password: "password"})
+	assert.Error(t, err)
+
+	user, err := svc.GetByID(ctx, id)
+	assert.NoError(t, err)
+	assert.Equal(t, "mem", user.Username)
+	assert.Empty(t, user.Password)
+}
+
+// TestAuthService_MissingUser - удаленный пользователь с еще живым токеном: репозиторий отдает
+// ErrNotFound, а не pgx.ErrNoRows, и хендлер отвечает 404, а не 500
+func TestAuthService_MissingUser(t *testing.T) {
+	svc := NewAuthService(memory.NewAuthRepository(), hasher.NewBcrypt(bcrypt.MinCost), Config{JWTSecret: "test-secret", JWTTTL: 24 * time.Hour}, zap.NewNop())
+	ctx := context.Background()
+	id := uuid.New()
+
+	assert.ErrorIs(t, svc.DeleteSelf(ctx, id, "password"), repository.ErrNotFound)
+	assert.ErrorIs(t, svc.ChangeEmail(ctx, id, &model.ChangeEmailRequest{NewEmail: "new@example.com", CurrentPassword: "password"}),
+		repository.ErrNotFound)
+}
+
+func TestAuthService_DisposableEmail(t *testing.T) {
+	disposable := model.NewDisposableDomains([]string{"mailinator.com"})
+	svc := NewAuthService(memory.NewAuthRepository(), hasher.NewBcrypt(bcrypt.MinCost), Config{
+		JWTSecret:  "test-secret",
+		JWTTTL:     24 * time.Hour,
+		Disposable: disposable,
+	}, zap.NewNop())
+	ctx := context.Background()
+
+	_, err := svc.Register(ctx, &model.CreateUserRequest{Username: "burner", Email: "x@eu.mailinator.com", Password: "password"})
+	assert.ErrorIs(t, err, ErrDisposableEmail)
+
+	id, err := svc.Register(ctx, &model.CreateUserRequest{Username: "real", Email: "real@example.com", Password: "password"})
+	assert.NoError(t, err)
+
+	// Блоклист проверяется до пароля: неверный пароль не маскирует отказ
+	err = svc.ChangeEmail(ctx, id, &model.ChangeEmailRequest{NewEmail: "real@mailinator.com", CurrentPassword: "wrong"})
+	assert.ErrorIs(t, err, ErrDisposableEmail)
+
+	user, err := svc.GetByID(ctx, id)
+	assert.NoError(t, err)
+	assert.Equal(t, "real@example.com", user.Email)
+}
+
+func TestAuthService_PasswordReset(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	svc := NewAuthService(memory.NewAuthRepository(), hasher.NewBcrypt(bcrypt.MinCost), Config{
+		JWTSecret:     "test-secret",
+		JWTTTL:        24 * time.Hour,
+		ResetTokenTTL: time.Hour,
+	}, zap.New(core))
+	// Репозиторий сверяет срок с системным временем, поэтому фиксируем текущий момент
+	now := time.Now()
+	svc.(*authService).clock = fixedClock(now)
+	notifier := &recordingNotifier{}
+	svc.(*authService).notifier = notifier
+	ctx := context.Background()
+
+	id, err := svc.Register(ctx, &model.CreateUserRequest{Username: "reset", Email: "reset@test.com", Password: "password"})
+	assert.NoError(t, err)
+	adminID := uuid.New()
+
+	_, err = svc.IssuePasswordReset(ctx, uuid.New(), adminID)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	first, err := svc.IssuePasswordReset(ctx, id, adminID)
+	assert.NoError(t, err)
+	second, err := svc.IssuePasswordReset(ctx, id, adminID)
+	assert.NoError(t, err)
+	assert.NotEqual(t, first.Token, second.Token)
+	assert.Equal(t, now.Add(time.Hour), second.ExpiresAt)
+
+	issued := logs.FilterMessage("password reset issued by admin").All()
+	if assert.Len(t, issued, 2) {
+		assert.Equal(t, adminID.String(), issued[0].ContextMap()["admin_id"])
+	}
+
+	// Новый токен отзывает прежний
+	err = svc.ResetPassword(ctx, &model.ResetPasswordRequest{Token: first.Token, NewPassword: "new-password"})
+	assert.ErrorIs(t, err, repository.ErrInvalidResetToken)
+
+	err = svc.ResetPassword(ctx, &model.ResetPasswordRequest{Token: second.Token, NewPassword: "new-password"})
+	assert.NoError(t, err)
+
+	// Владелец узнает о сбросе письмом
+	svc.(*authService).notifying.Wait()
+	if assert.Len(t, notifier.sent, 1) {
+		assert.Equal(t, "reset@test.com", notifier.sent[0].To)
+		assert.Equal(t, "Your password was reset", notifier.sent[0].Subject)
+	}
+
+	// Токен одноразовый
+	err = svc.ResetPassword(ctx, &model.ResetPasswordRequest{Token: second.Token, NewPassword: "other-password"})
+	assert.ErrorIs(t, err, repository.ErrInvalidResetToken)
+
+	_, err = svc.Login(ctx, &model.LoginRequest{Email: "reset@test.com", Password: "new-password"})
+	assert.NoError(t, err)
+}
+
+func TestAuthService_APITokens(t *testing.T) {
+	svc := NewAuthService(memory.NewAuthRepository(), hasher.NewBcrypt(bcrypt.MinCost), Config{JWTSecret: "test-secret", JWTTTL: 24 * time.Hour}, zap.NewNop())
+	ctx := context.Background()
+
+	id, err := svc.Register(ctx, &model.CreateUserRequest{Username: "script", Email: "script@test.com", Password: "password"})
+	require.NoError(t, err)
+	otherID, err := svc.Register(ctx, &model.CreateUserRequest{Username: "other", Email: "other@test.com", Password: "password"})
+	require.NoError(t, err)
+
+	created, err := svc.CreateAPIToken(ctx, id, &model.CreateAPITokenRequest{Label: "ci"})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(created.Token, APITokenPrefix))
+	assert.Equal(t, "ci", created.Label)
+
+	user, err := svc.AuthenticateAPIToken(ctx, created.Token)
+	require.NoError(t, err)
+	assert.Equal(t, id, user.ID)
+	assert.Equal(t, "script", user.Username)
+
+	tokens, err := svc.ListAPITokens(ctx, id)
+	require.NoError(t, err)
+	if assert.Len(t, tokens, 1) {
+		assert.Equal(t, created.ID, tokens[0].ID)
+		assert.NotNil(t, tokens[0].LastUsedAt)
+	}
+
+	_, err = svc.AuthenticateAPIToken(ctx, APITokenPrefix+"unknown")
+	assert.ErrorIs(t, err, ErrInvalidAPIToken)
+	_, err = svc.AuthenticateAPIToken(ctx, strings.TrimPrefix(created.Token, APITokenPrefix))
+	assert.ErrorIs(t, err, ErrInvalidAPIToken)
+
+	// Чужой токен отозвать нельзя
+	assert.ErrorIs(t, svc.RevokeAPIToken(ctx, otherID, created.ID), repository.ErrNotFound)
+
+	require.NoError(t, svc.RevokeAPIToken(ctx, id, created.ID))
+	_, err = svc.AuthenticateAPIToken(ctx, created.Token)
+	assert.ErrorIs(t, err, ErrInvalidAPIToken)
+}
+
+func TestAuthService_SingleSession(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	svc := NewAuthService(memory.NewAuthRepository(), hasher.NewBcrypt(bcrypt.MinCost), Config{
+		JWTSecret:     "test-secret",
+		JWTTTL:        24 * time.Hour,
+		SingleSession: true,
+	}, zap.New(core))
+	ctx := context.Background()
+
+	id, err := svc.Register(ctx, &model.CreateUserRequest{Username: "solo", Email: "solo@test.com", Password: "password"})
+	assert.NoError(t, err)
+
+	sessionOf := func(token string) string {
+		parsed, err := jwt.ParseWithClaims(token, &model.UserClaims{}, func(token *jwt.Token) (interface{}, error) {
+			return []byte("test-secret"), nil
+		})
+		assert.NoError(t, err)
+		return parsed.Claims.(*model.UserClaims).SessionID
+	}
+
+	login := func() string {
+		token, err := svc.Login(ctx, &model.LoginRequest{Email: "solo@test.com", Password: "password"})
+		assert.NoError(t, err)
+		return sessionOf(token)
+	}
+
+	first := login()
+	assert.NotEmpty(t, first)
+	assert.Zero(t, logs.FilterMessage("previous session revoked by new login").Len(), "first login revokes nothing")
+
+	active, err := svc.SessionActive(ctx, id, first)
+	assert.NoError(t, err)
+	assert.True(t, active)
+
+	second := login()
+	assert.NotEqual(t, first, second)
+
+	revoked := logs.FilterMessage("previous session revoked by new login").All()
+	if assert.Len(t, revoked, 1) {
+		assert.Equal(t, first, revoked[0].ContextMap()["revoked_sid"])
+	}
+
+	active, err = svc.SessionActive(ctx, id, first)
+	assert.NoError(t, err)
+	assert.False(t, active, "older session is kicked")
+
+	active, err = svc.SessionActive(ctx, id, second)
+	assert.NoError(t, err)
+	assert.True(t, active)
+
+	t.Run("Deleted user has no active session", func(t *testing.T) {
+		assert.NoError(t, svc.Delete(ctx, id))
+		active, err := svc.SessionActive(ctx, id, second)
+		assert.NoError(t, err)
+		assert.False(t, active)
+	})
+
+	t.Run("Disabled mode does not touch sessions", func(t *testing.T) {
+		svc, repo := setup(t)
+		active, err := svc.SessionActive(ctx, uuid.New(), "")
+		assert.NoError(t, err)
+		assert.True(t, active)
+		repo.AssertNotCalled(t, "GetSessionID", mock.Anything, mock.Anything)
+	})
+}
+
+func TestCheckAvailability(t *testing.T) {
+	svc, repo := setup(t)
+	ctx := context.Background()
+
+	repo.On("UsernameExists", ctx, "taken").Return(true, nil).Once()
+	available, err := svc.CheckAvailability(ctx, &model.AvailabilityQuery{Username: "taken"})
+	assert.NoError(t, err)
+	assert.False(t, available)
+
+	repo.On("EmailExists", ctx, "free@test.com").Return(false, nil).Once()
+	available, err = svc.CheckAvailability(ctx, &model.AvailabilityQuery{Email: "free@test.com"})
+	assert.NoError(t, err)
+	assert.True(t, available)
+
+	repo.AssertExpectations(t)
+}
+
+func TestRegisterAndLogin(t *testing.T) {
+	svc, repo := setup(t)
+	ctx := context.Background()
+
+	id := uuid.New()
+	repo.On("Create", ctx, mock.Anything).Return(id, nil).Once()
+
+	gotID, token, err := svc.RegisterAndLogin(ctx, &model.CreateUserRequest{Username: "new", Email: "new@test.com", Password: "password"})
+	assert.NoError(t, err)
+	assert.Equal(t, id, gotID)
+
+	parsed, err := jwt.ParseWithClaims(token, &model.UserClaims{}, func(token *jwt.Token) (interface{}, error) {
+		return []byte("test-secret"), nil
+	})
+	assert.NoError(t, err)
+	claims := parsed.Claims.(*model.UserClaims)
+	assert.Equal(t, id, claims.UserID)
+	assert.Equal(t, "new", claims.Username)
+	assert.Equal(t, model.RoleUser, claims.Role)
+	repo.AssertExpectations(t)
+}
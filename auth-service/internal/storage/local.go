@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Local хранит файлы на диске; отдает их сам сервис как статику (см. URLPrefix)
+type Local struct {
+	dir       string
+	urlPrefix string
+}
+
+// NewLocal - dir: каталог с файлами, urlPrefix: путь, по которому роутер раздает dir
+func NewLocal(dir, urlPrefix string) *Local {
+	return &Local{dir: dir, urlPrefix: urlPrefix}
+}
+
+func (l *Local) Upload(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+
+	dst := filepath.Join(l.dir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return fmt.Errorf("create storage dir: %w", err)
+	}
+
+	// Пишем во временный файл и переименовываем, чтобы читатель не увидел половину аватара
+	tmp, err := os.CreateTemp(filepath.Dir(dst), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write %s: %w", key, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write %s: %w", key, err)
+	}
+
+	return os.Rename(tmp.Name(), dst)
+}
+
+// SignedURL для диска подписи нет - это просто статический путь
+func (l *Local) SignedURL(key string, ttl time.Duration) (string, error) {
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+	return l.urlPrefix + "/" + key, nil
+}
@@ -0,0 +1,34 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+// Поддерживаемые значения storage.backend
+const (
+	BackendLocal = "local"
+	BackendS3    = "s3"
+)
+
+// StorageProvider хранит пользовательские файлы (аватары) и выдает ссылки на них.
+// Ключ - путь внутри хранилища вида "avatars/<user id>".
+type StorageProvider interface {
+	// Upload записывает объект; size - длина body в байтах (S3 не принимает PUT без Content-Length)
+	Upload(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	// SignedURL - ссылка для скачивания, действительная ttl. Хранилища без подписи
+	// (локальный диск) возвращают постоянный статический путь и ttl игнорируют.
+	SignedURL(key string, ttl time.Duration) (string, error)
+}
+
+// validateKey не пускает ключи, выходящие за пределы хранилища ("../", абсолютные пути)
+func validateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || path.Clean(key) != key || strings.HasPrefix(key, "..") {
+		return fmt.Errorf("invalid storage key %q", key)
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Заголовки, которые получает подписчик
+const (
+	SignatureHeader = "X-Webhook-Signature" // sha256=<hex HMAC тела>
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+)
+
+type Config struct {
+	URLs   []string
+	Secret string
+	// MaxAttempts - после стольких неудачных попыток доставка помечается dead
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	PollInterval   time.Duration
+	BatchSize      int
+	Timeout        time.Duration
+}
+
+// Envelope - тело, которое уходит подписчику
+type Envelope struct {
+	ID         string    `json:"id"`
+	Type       string    `json:"type"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Data       any       `json:"data"`
+}
+
+// WebhookDispatcher кладет события в outbox и в фоне рассылает их
+// подписанными POST-запросами с экспоненциальными повторами
+type WebhookDispatcher struct {
+	store  Store
+	client *http.Client
+	cfg    Config
+	logger *zap.Logger
+	now    func() time.Time
+}
+
+func NewDispatcher(store Store, cfg Config, logger *zap.Logger) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		store:  store,
+		client: &http.Client{Timeout: cfg.Timeout},
+		cfg:    cfg,
+		logger: logger,
+		now:    time.Now,
+	}
+}
+
+// Publish сохраняет событие для доставки на все endpoint'ы. Без URL ничего не делает.
+func (d *WebhookDispatcher) Publish(ctx context.Context, eventType string, data any) error {
+	if len(d.cfg.URLs) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(Envelope{
+		ID:         uuid.NewString(),
+		Type:       eventType,
+		OccurredAt: d.now().UTC(),
+		Data:       data,
+	})
+	if err != nil {
+		return fmt.Errorf("marshal webhook payload: %w", err)
+	}
+
+	if err := d.store.Enqueue(ctx, d.cfg.URLs, eventType, payload); err != nil {
+		return fmt.Errorf("enqueue webhook: %w", err)
+	}
+	return nil
+}
+
+// Run разбирает outbox, пока не отменят ctx
+func (d *WebhookDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.cfg.PollInterval)
+	defer ticker.Stop()
+
+	d.logger.Info("webhook dispatcher started", zap.Strings("urls", d.cfg.URLs))
+
+	for {
+		select {
+		case <-ctx.Done():
+			d.logger.Info("webhook dispatcher stopped")
+			return
+		case <-ticker.C:
+			if err := d.processBatch(ctx); err != nil && ctx.Err() == nil {
+				d.logger.Error("webhook batch failed", zap.Error(err))
+			}
+		}
+	}
+}
+
+func (d *WebhookDispatcher) processBatch(ctx context.Context) error {
+	// lease с запасом больше таймаута запроса: пока идет отправка, строку не возьмет никто другой
+	lease := d.cfg.Timeout*time.Duration(d.cfg.BatchSize) + d.cfg.PollInterval
+
+	deliveries, err := d.store.ClaimDue(ctx, d.cfg.BatchSize, lease)
+	if err != nil {
+		return fmt.Errorf("claim deliveries: %w", err)
+	}
+
+	for _, delivery := range deliveries {
+		d.handle(ctx, delivery)
+	}
+	return nil
+}
+
+func (d *WebhookDispatcher) handle(ctx context.Context, delivery Delivery) {
+	err := d.send(ctx, delivery)
+	if err == nil {
+		if err := d.store.MarkDelivered(ctx, delivery.ID); err != nil {
+			d.logger.Error("failed to mark webhook delivered", zap.Int64("delivery_id", delivery.ID), zap.Error(err))
+		}
+		return
+	}
+
+	attempts := delivery.Attempts + 1
+	fields := []zap.Field{
+		zap.Int64("delivery_id", delivery.ID),
+		zap.String("endpoint", delivery.Endpoint),
+		zap.String("event", delivery.EventType),
+		zap.Int("attempt", attempts),
+		zap.Error(err),
+	}
+
+	if attempts >= d.cfg.MaxAttempts {
+		d.logger.Error("webhook delivery is dead", fields...)
+		if err := d.store.MarkDead(ctx, delivery.ID, err.Error()); err != nil {
+			d.logger.Error("failed to mark webhook dead", zap.Int64("delivery_id", delivery.ID), zap.Error(err))
+		}
+		return
+	}
+
+	d.logger.Warn("webhook delivery failed, will retry", fields...)
+	next := d.now().Add(d.backoff(attempts))
+	if err := d.store.MarkRetry(ctx, delivery.ID, next, err.Error()); err != nil {
+		d.logger.Error("failed to schedule webhook retry", zap.Int64("delivery_id", delivery.ID), zap.Error(err))
+	}
+}
+
+func (d *WebhookDispatcher) send(ctx context.Context, delivery Delivery) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Endpoint, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, delivery.EventType)
+	req.Header.Set(DeliveryHeader, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(SignatureHeader, Sign(d.cfg.Secret, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// backoff - InitialBackoff * 2^(attempt-1), но не больше MaxBackoff
+func (d *WebhookDispatcher) backoff(attempt int) time.Duration {
+	wait := d.cfg.InitialBackoff
+	for i := 1; i < attempt; i++ {
+		wait *= 2
+		if wait >= d.cfg.MaxBackoff {
+			return d.cfg.MaxBackoff
+		}
+	}
+	return wait
+}
+
+// Sign - подпись тела для SignatureHeader. Подписчик считает то же самое своим секретом
+// и сравнивает через hmac.Equal.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,153 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fakeStore - Store в памяти, запоминает, чем закончилась каждая доставка
+type fakeStore struct {
+	mu        sync.Mutex
+	pending   []Delivery
+	delivered []int64
+	retried   map[int64]time.Time
+	dead      []int64
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{retried: make(map[int64]time.Time)}
+}
+
+func (s *fakeStore) Enqueue(ctx context.Context, endpoints []string, eventType string, payload []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, endpoint := range endpoints {
+		s.pending = append(s.pending, Delivery{
+			ID:        int64(len(s.pending) + 1),
+			Endpoint:  endpoint,
+			EventType: eventType,
+			Payload:   payload,
+		})
+	}
+	return nil
+}
+
+func (s *fakeStore) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]Delivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	claimed := s.pending
+	s.pending = nil
+	return claimed, nil
+}
+
+func (s *fakeStore) MarkDelivered(ctx context.Context, id int64) error {
+	s.delivered = append(s.delivered, id)
+	return nil
+}
+
+func (s *fakeStore) MarkRetry(ctx context.Context, id int64, nextAttempt time.Time, lastErr string) error {
+	s.retried[id] = nextAttempt
+	return nil
+}
+
+func (s *fakeStore) MarkDead(ctx context.Context, id int64, lastErr string) error {
+	s.dead = append(s.dead, id)
+	return nil
+}
+
+func testConfig(urls ...string) Config {
+	return Config{
+		URLs:           urls,
+		Secret:         "webhook-secret",
+		MaxAttempts:    3,
+		InitialBackoff: time.Second,
+		MaxBackoff:     10 * time.Second,
+		PollInterval:   time.Second,
+		BatchSize:      10,
+		Timeout:        time.Second,
+	}
+}
+
+func TestDispatcher_DeliversSignedPayload(t *testing.T) {
+	var gotBody []byte
+	var gotHeaders http.Header
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotBody, _ = io.ReadAll(r.Body)
+		gotHeaders = r.Header.Clone()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	store := newFakeStore()
+	d := NewDispatcher(store, testConfig(srv.URL), zap.NewNop())
+	ctx := context.Background()
+
+	require.NoError(t, d.Publish(ctx, "user.registered", map[string]string{"user_id": "42"}))
+	require.NoError(t, d.processBatch(ctx))
+
+	assert.Equal(t, []int64{1}, store.delivered)
+	assert.Equal(t, "user.registered", gotHeaders.Get(EventHeader))
+	assert.Equal(t, Sign("webhook-secret", gotBody), gotHeaders.Get(SignatureHeader))
+
+	var envelope Envelope
+	require.NoError(t, json.Unmarshal(gotBody, &envelope))
+	assert.Equal(t, "user.registered", envelope.Type)
+	assert.NotEmpty(t, envelope.ID)
+}
+
+func TestDispatcher_RetryAndDead(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	store := newFakeStore()
+	d := NewDispatcher(store, testConfig(srv.URL), zap.NewNop())
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	d.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	t.Run("First failure is retried with backoff", func(t *testing.T) {
+		store.pending = []Delivery{{ID: 1, Endpoint: srv.URL, Payload: []byte(`{}`), Attempts: 0}}
+		require.NoError(t, d.processBatch(ctx))
+
+		assert.Equal(t, now.Add(time.Second), store.retried[1])
+		assert.Empty(t, store.dead)
+	})
+
+	t.Run("Last attempt marks delivery dead", func(t *testing.T) {
+		store.pending = []Delivery{{ID: 2, Endpoint: srv.URL, Payload: []byte(`{}`), Attempts: 2}}
+		require.NoError(t, d.processBatch(ctx))
+
+		assert.Equal(t, []int64{2}, store.dead)
+		assert.NotContains(t, store.retried, int64(2))
+	})
+}
+
+func TestDispatcher_InertWithoutURLs(t *testing.T) {
+	store := newFakeStore()
+	d := NewDispatcher(store, testConfig(), zap.NewNop())
+
+	require.NoError(t, d.Publish(context.Background(), "user.deleted", nil))
+	assert.Empty(t, store.pending)
+}
+
+func TestDispatcher_Backoff(t *testing.T) {
+	d := NewDispatcher(newFakeStore(), testConfig(), zap.NewNop())
+
+	assert.Equal(t, time.Second, d.backoff(1))
+	assert.Equal(t, 2*time.Second, d.backoff(2))
+	assert.Equal(t, 8*time.Second, d.backoff(4))
+	assert.Equal(t, 10*time.Second, d.backoff(5), "ограничено MaxBackoff")
+}
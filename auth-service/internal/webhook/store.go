@@ -0,0 +1,114 @@
+package webhook
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Delivery - одна попытка доставить событие на один endpoint (строка webhook_outbox)
+type Delivery struct {
+	ID        int64
+	Endpoint  string
+	EventType string
+	Payload   []byte
+	Attempts  int
+}
+
+// Store - персистентная очередь доставок. Запись в нее переживает рестарт,
+// поэтому доставка получается at-least-once.
+type Store interface {
+	Enqueue(ctx context.Context, endpoints []string, eventType string, payload []byte) error
+	// ClaimDue забирает до limit готовых к отправке доставок и откладывает их на lease,
+	// чтобы другие реплики не взяли те же строки, пока эта их отправляет
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]Delivery, error)
+	MarkDelivered(ctx context.Context, id int64) error
+	MarkRetry(ctx context.Context, id int64, nextAttempt time.Time, lastErr string) error
+	MarkDead(ctx context.Context, id int64, lastErr string) error
+}
+
+type postgresStore struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresStore(pool *pgxpool.Pool) Store {
+	return &postgresStore{pool: pool}
+}
+
+func (s *postgresStore) Enqueue(ctx context.Context, endpoints []string, eventType string, payload []byte) error {
+	query := `
+		INSERT INTO webhook_outbox (endpoint, event_type, payload)
+		SELECT unnest($1::text[]), $2, $3
+	`
+
+	_, err := s.pool.Exec(ctx, query, endpoints, eventType, payload)
+	return err
+}
+
+func (s *postgresStore) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]Delivery, error) {
+	// SKIP LOCKED - несколько реплик разбирают очередь, не мешая друг другу
+	query := `
+		WITH due AS (
+			SELECT id FROM webhook_outbox
+			WHERE status = 'pending' AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE webhook_outbox w
+		SET next_attempt_at = NOW() + make_interval(secs => $2)
+		FROM due
+		WHERE w.id = due.id
+		RETURNING w.id, w.endpoint, w.event_type, w.payload, w.attempts
+	`
+
+	rows, err := s.pool.Query(ctx, query, limit, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]Delivery, 0)
+	for rows.Next() {
+		var d Delivery
+		if err := rows.Scan(&d.ID, &d.Endpoint, &d.EventType, &d.Payload, &d.Attempts); err != nil {
+			return nil, err
+		}
+		result = append(result, d)
+	}
+	return result, rows.Err()
+}
+
+func (s *postgresStore) MarkDelivered(ctx context.Context, id int64) error {
+	query := `
+		UPDATE webhook_outbox
+		SET status = 'delivered', attempts = attempts + 1, delivered_at = NOW(), last_error = NULL
+		WHERE id = $1
+	`
+
+	_, err := s.pool.Exec(ctx, query, id)
+	return err
+}
+
+func (s *postgresStore) MarkRetry(ctx context.Context, id int64, nextAttempt time.Time, lastErr string) error {
+	query := `
+		UPDATE webhook_outbox
+		SET attempts = attempts + 1, next_attempt_at = $2, last_error = $3
+		WHERE id = $1
+	`
+
+	_, err := s.pool.Exec(ctx, query, id, nextAttempt, lastErr)
+	return err
+}
+
+func (s *postgresStore) MarkDead(ctx context.Context, id int64, lastErr string) error {
+	query := `
+		UPDATE webhook_outbox
+		SET status = 'dead', attempts = attempts + 1, last_error = $2
+		WHERE id = $1
+	`
+
+	_, err := s.pool.Exec(ctx, query, id, lastErr)
+	return err
+}
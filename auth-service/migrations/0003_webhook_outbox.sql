@@ -0,0 +1,22 @@
+-- migrations/0003_webhook_outbox.sql
+-- +goose Up
+
+-- Одна строка - одна доставка события на один endpoint
+CREATE TABLE webhook_outbox (
+    id BIGSERIAL PRIMARY KEY,
+    endpoint TEXT NOT NULL,
+    event_type VARCHAR(50) NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(10) NOT NULL DEFAULT 'pending', -- pending, delivered, dead
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    delivered_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Воркер выбирает только ожидающие доставки, остальные строки индекс не раздувают
+CREATE INDEX webhook_outbox_due_idx ON webhook_outbox (next_attempt_at) WHERE status = 'pending';
+
+-- +goose Down
+DROP TABLE IF EXISTS webhook_outbox;
@@ -0,0 +1,16 @@
+-- migrations/0004_outbox.sql
+-- +goose Up
+
+-- События пишутся в одной транзакции с изменением пользователя, relay потом их публикует
+CREATE TABLE outbox (
+    id BIGSERIAL PRIMARY KEY,
+    event_type VARCHAR(50) NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    sent_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX outbox_unsent_idx ON outbox (id) WHERE sent_at IS NULL;
+
+-- +goose Down
+DROP TABLE IF EXISTS outbox;
@@ -0,0 +1,8 @@
+-- migrations/0005_user_version.sql
+-- +goose Up
+
+-- Версия строки для optimistic locking: каждое изменение пользователя увеличивает ее на 1
+ALTER TABLE users ADD COLUMN version INT NOT NULL DEFAULT 1;
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN IF EXISTS version;
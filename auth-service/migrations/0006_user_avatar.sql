@@ -0,0 +1,9 @@
+-- migrations/0006_user_avatar.sql
+-- +goose Up
+
+-- Ключ аватара в хранилище (storage.backend); пустая строка - аватара нет.
+-- Ссылку на файл строит сервис при каждом ответе, чтобы подписанные URL не протухали в базе
+ALTER TABLE users ADD COLUMN avatar_key TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN IF EXISTS avatar_key;
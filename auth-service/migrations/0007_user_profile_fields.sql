@@ -0,0 +1,10 @@
+-- migrations/0007_user_profile_fields.sql
+-- +goose Up
+
+-- Необязательные поля профиля, меняются через PATCH /user/profile
+ALTER TABLE users ADD COLUMN display_name TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN bio TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN IF EXISTS bio;
+ALTER TABLE users DROP COLUMN IF EXISTS display_name;
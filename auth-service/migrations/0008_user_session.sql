@@ -0,0 +1,9 @@
+-- migrations/0008_user_session.sql
+-- +goose Up
+
+-- Идентификатор последней сессии для auth.single_session: токен с другим sid отклоняется.
+-- Пустая строка - сессия еще не выдавалась (или режим выключен)
+ALTER TABLE users ADD COLUMN session_id TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN IF EXISTS session_id;
@@ -0,0 +1,18 @@
+-- migrations/0009_password_reset_tokens.sql
+-- +goose Up
+
+-- Одноразовые токены сброса пароля. Хранится только sha256 токена: сам токен отдается
+-- один раз при выдаче. created_by - администратор, выдавший токен (NULL - сам пользователь)
+CREATE TABLE password_reset_tokens (
+    token_hash TEXT PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_by UUID,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX password_reset_tokens_user_idx ON password_reset_tokens (user_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS password_reset_tokens;
@@ -0,0 +1,18 @@
+-- migrations/0010_api_tokens.sql
+-- +goose Up
+
+-- Долгоживущие токены для скриптов (Authorization: Token <value>). Хранится только sha256:
+-- сам токен показывается один раз при создании
+CREATE TABLE api_tokens (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash TEXT NOT NULL UNIQUE,
+    label VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX api_tokens_user_idx ON api_tokens (user_id, created_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS api_tokens;
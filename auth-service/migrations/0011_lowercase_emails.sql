@@ -0,0 +1,45 @@
+-- migrations/0011_lowercase_emails.sql
+-- +goose Up
+
+-- Email сравнивается без учета регистра (model.NormalizeEmail), а поиск идет по email = $1:
+-- старые адреса со заглавными буквами приводим к нижнему регистру.
+-- Адреса, которые различались только регистром, становятся дублями. Адрес остается у самого
+-- старого аккаунта, остальные получают заглушку, а исходный адрес сохраняется здесь для ручного разбора
+CREATE TABLE email_conflicts (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    original_email VARCHAR(100) NOT NULL,
+    kept_by UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+WITH ranked AS (
+    SELECT id, email,
+           first_value(id) OVER w AS keeper,
+           row_number() OVER w AS rn
+    FROM users
+    WINDOW w AS (PARTITION BY lower(email) ORDER BY created_at, id)
+)
+INSERT INTO email_conflicts (user_id, original_email, kept_by)
+SELECT id, email, keeper FROM ranked WHERE rn > 1;
+
+-- .invalid зарезервирован (RFC 2606): на заглушку ничего не уйдет
+UPDATE users u
+SET email = 'conflict+' || u.id || '@invalid', version = u.version + 1, updated_at = CURRENT_TIMESTAMP
+FROM email_conflicts c
+WHERE c.user_id = u.id;
+
+UPDATE users SET email = lower(email) WHERE email <> lower(email);
+
+-- Новые адреса приходят уже нормализованными; ограничение ловит запись в обход NormalizeEmail
+ALTER TABLE users ADD CONSTRAINT users_email_lowercase CHECK (email = lower(email));
+
+-- +goose Down
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_email_lowercase;
+
+-- Регистр остальных адресов не восстановить, но дубли возвращаются на свои аккаунты
+UPDATE users u
+SET email = c.original_email
+FROM email_conflicts c
+WHERE c.user_id = u.id;
+
+DROP TABLE IF EXISTS email_conflicts;
@@ -6,7 +6,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
-	"time"
 
 	"io"
 
@@ -26,7 +25,9 @@ import (
 	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/config"
 	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/db"
 	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/handler"
+	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/hasher"
 	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/repository"
+	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/router"
 	"github.com/gsrlabs/micro-blog-hub/auth-service/internal/service"
 )
 
@@ -66,9 +67,13 @@ func getTestConfig() *config.Config {
 	}
 
 	var cfg *config.Config
-	var err error
+	err := os.ErrNotExist
 
 	for _, p := range configPaths {
+		// config.Load без файла не ошибается (env-only режим), а тестам нужен именно config.yml
+		if _, statErr := os.Stat(p); statErr != nil {
+			continue
+		}
 		cfg, err = config.Load(p)
 		if err == nil {
 			log.Printf("INFO: loaded config from %s", p)
@@ -113,37 +118,22 @@ func setupTestServer(t *testing.T) (*httptest.Server, func()) {
 	_, err = database.Pool.Exec(ctx, "TRUNCATE users RESTART IDENTITY CASCADE")
 	require.NoError(t, err)
 
-	repo := repository.NewAuthRepository(database.Pool, logger)
-	svc := service.NewAuthService(repo, logger, cfg.JWT.Secret, time.Duration(cfg.JWT.ExpirationHours))
-	h := handler.NewAuthHandler(svc, logger, cfg.App.Mode, cfg.JWT.Secret, time.Duration(cfg.JWT.ExpirationHours))
-
-	r := gin.Default()
-
-	// 1. Публичные маршруты авторизации
-	auth := r.Group("/auth")
-	{
-		auth.POST("/signup", h.SignUp)
-		auth.POST("/signin", h.SignIn)
-		auth.POST("/logout", h.Logout)
-	}
-
-	// 2. Маршруты пользователей (пагинация и поиск)
-	// ВАЖНО: Проверь, чтобы эти пути совпадали с теми, что ты вызываешь в тестах!
-	r.GET("/users", h.GetUsers)
-	r.GET("/users/:id", h.GetByID)
-	r.GET("/users/search", h.GetByEmail)
-
-	// 3. Защищенные маршруты (профиль)
-	// Здесь нужен твой middleware. Если его нет, закомментируй .Use()
-	protected := r.Group("/user")
-	protected.Use(h.AuthMiddleware)
-	{
-		protected.GET("/profile", h.GetProfile)
-		protected.PUT("/profile", h.ChangeProfile)
-		protected.PUT("/email", h.ChangeEmail)
-		protected.PUT("/password", h.ChangePassword)
-		protected.DELETE("", h.Delete)
-	}
+	repo := repository.NewAuthRepository(database.Pool, database.Replica, logger, 0, 0)
+	passwordHasher, err := hasher.New(cfg.Security.HashAlgorithm, cfg.Security.BcryptCost)
+	require.NoError(t, err)
+	svc := service.NewAuthService(repo, passwordHasher, service.Config{JWTSecret: cfg.JWT.Secret, JWTTTL: cfg.JWT.TokenTTL()}, logger)
+	h := handler.NewAuthHandler(svc, handler.Config{
+		AppMode:          cfg.App.Mode,
+		Secret:           cfg.JWT.Secret,
+		JWTTTL:           cfg.JWT.TokenTTL(),
+		TokenSource:      cfg.Auth.TokenSource,
+		PasswordMaxBytes: cfg.Security.PasswordMaxBytes,
+		EmailMode:        cfg.Security.EmailMode,
+	}, logger)
+
+	// Те же маршруты и middleware, что и в проде
+	r, err := router.NewRouter(h, cfg, logger, repo)
+	require.NoError(t, err)
 
 	ts := httptest.NewServer(r)
 
@@ -250,8 +240,20 @@ func TestAuth_GetUsers_Pagination(t *testing.T) {
 		assert.Len(t, users, 2)
 	})
 
-	t.Run("Get By ID Invalid Format", func(t *testing.T) {
+	t.Run("Get By ID Requires Auth", func(t *testing.T) {
 		_, status, _ := request(t, ts.URL+"/users/not-a-uuid", "GET", nil, nil)
-		assert.Equal(t, http.StatusBadRequest, status)
+		assert.Equal(t, http.StatusUnauthorized, status)
+	})
+
+	t.Run("Get By ID Forbidden For Regular User", func(t *testing.T) {
+		payload := map[string]string{"email": "user1@test.com", "password": "password123"}
+		_, status, cookies := request(t, ts.URL+"/auth/signin", "POST", payload, nil)
+		require.Equal(t, http.StatusOK, status)
+
+		_, status, _ = request(t, ts.URL+"/users/not-a-uuid", "GET", nil, cookies)
+		assert.Equal(t, http.StatusForbidden, status)
+
+		_, status, _ = request(t, ts.URL+"/users/search?email=user2@test.com", "GET", nil, cookies)
+		assert.Equal(t, http.StatusForbidden, status)
 	})
 }
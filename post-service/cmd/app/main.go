@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -11,12 +12,22 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/authclient"
 	"github.com/gsrlabs/micro-blog-hub/post-service/internal/cache"
 	"github.com/gsrlabs/micro-blog-hub/post-service/internal/config"
 	"github.com/gsrlabs/micro-blog-hub/post-service/internal/db"
 	"github.com/gsrlabs/micro-blog-hub/post-service/internal/handler"
 	"github.com/gsrlabs/micro-blog-hub/post-service/internal/logger"
-	//"github.com/gsrlabs/micro-blog-hub/post-service/internal/repository"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/mention"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/metrics"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/repository"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/retry"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/router"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/sanitize"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/service"
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.uber.org/zap"
 )
 
@@ -41,26 +52,57 @@ func run(ctx context.Context) error {
 		return fmt.Errorf("validate config failed: %w", err)
 	}
 
-	logger, err := logger.New(cfg.Logging.Level, cfg.App.Mode)
+	logger, err := logger.New(cfg.Logging.Level, cfg.App.Mode, logger.Sampling{
+		Initial:    cfg.Logging.Sampling.Initial,
+		Thereafter: cfg.Logging.Sampling.Thereafter,
+	}, logger.File{
+		Path:       cfg.Logging.File.Path,
+		MaxSizeMB:  cfg.Logging.File.MaxSizeMB,
+		MaxBackups: cfg.Logging.File.MaxBackups,
+		MaxAgeDays: cfg.Logging.File.MaxAgeDays,
+	})
 	if err != nil {
 		log.Fatalf("%v", err)
 		return err
 	}
+	// Sync сбрасывает и stdout, и файл (если он настроен)
 	defer func() {
 		if err := logger.Sync(); err != nil {
 			fmt.Fprintf(os.Stderr, "failed to sync logger: %v\n", err)
 		}
 	}()
 
-	//Mongo
-	database, err := db.NewMongoCLient(ctx,
-		logger,
-		cfg.Mongo.Host,
-		cfg.Mongo.Port,
+	startupPolicy := retry.Policy{MaxAttempts: 1}
+	if cfg.Startup.WaitDependencies {
+		startupPolicy = retry.Policy{
+			MaxAttempts:    cfg.Startup.MaxAttempts,
+			InitialBackoff: cfg.Startup.InitialBackoff,
+			MaxBackoff:     cfg.Startup.MaxBackoff,
+		}
+	}
+
+	// Metrics: мониторы подключаются к клиентам Mongo и Redis при создании
+	var (
+		storeMetrics *metrics.Metrics
+		mongoOpts    []*options.ClientOptions
+		redisHooks   []redis.Hook
 	)
+	if cfg.Metrics.Enabled {
+		storeMetrics = metrics.New()
+		mongoOpts = append(mongoOpts, options.Client().
+			SetMonitor(storeMetrics.MongoCommandMonitor()).
+			SetPoolMonitor(storeMetrics.MongoPoolMonitor()))
+		redisHooks = append(redisHooks, storeMetrics.RedisHook())
+	}
 
+	//Mongo
+	var database *mongo.Client
+	err = retry.Do(ctx, logger, "mongo", startupPolicy, func(ctx context.Context) error {
+		database, err = db.NewMongoCLient(ctx, logger, cfg.Mongo.Host, cfg.Mongo.Port, mongoOpts...)
+		return err
+	})
 	if err != nil {
-		log.Fatalf("Mongo connection failed: %v", err)
+		return fmt.Errorf("startup: %w", err)
 	}
 
 	defer func() {
@@ -69,38 +111,128 @@ func run(ctx context.Context) error {
 		}
 	}()
 
-	//Redis
-	redisClient, err := cache.NewRedisClient(
-		ctx,
-		logger,
-		cfg.Redis.Host,
-		cfg.Redis.Port,
-	)
+	//Redis - необязательный ускоритель: не поднялся - работаем из Mongo и переподключаемся в фоне
+	connectRedis := func(ctx context.Context) (*redis.Client, error) {
+		return cache.NewRedisClient(ctx, logger, cfg.Redis.Host, cfg.Redis.Port, redisHooks...)
+	}
+	// Без retry.Do: ждать необязательную зависимость на старте незачем, дальше подключит Reconnect
+	redisClient, err := connectRedis(ctx)
 	if err != nil {
-		log.Fatalf("Redis connection failed: %v", err)
+		logger.Warn("redis unavailable, serving without cache", zap.Error(err))
 	}
+	redisConn := cache.NewConn(redisClient)
 
 	defer func() {
-		if err := redisClient.Close(); err != nil {
+		if err := redisConn.Close(); err != nil {
 			fmt.Fprintf(os.Stderr, "failed to disconnect redis: %v\n", err)
 		}
 	}()
 
+	// Останавливается раньше Close (defer в обратном порядке)
+	reconnectCtx, stopReconnect := context.WithCancel(ctx)
+	defer stopReconnect()
+	go redisConn.Reconnect(reconnectCtx, logger, cfg.Redis.ReconnectInterval, connectRedis)
+
+	// Auth gRPC: auth-service доступен, только когда его grpc.health.v1.Health отвечает SERVING
+	authConn, err := authclient.Dial(net.JoinHostPort(cfg.GRPС.AuthHost, cfg.GRPС.AuthPort), authclient.Options{
+		KeepaliveTime:                cfg.GRPС.KeepaliveTime,
+		KeepaliveTimeout:             cfg.GRPС.KeepaliveTimeout,
+		KeepalivePermitWithoutStream: cfg.GRPС.KeepalivePermitWithoutStream,
+		RetryMaxAttempts:             cfg.GRPС.Retry.MaxAttempts,
+		RetryInitialBackoff:          cfg.GRPС.Retry.InitialBackoff,
+		RetryMaxBackoff:              cfg.GRPС.Retry.MaxBackoff,
+	})
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := authConn.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to close auth grpc connection: %v\n", err)
+		}
+	}()
+
+	err = retry.Do(ctx, logger, "auth grpc", startupPolicy, func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		return authclient.CheckHealth(ctx, authConn)
+	})
+	if err != nil {
+		return fmt.Errorf("startup: %w", err)
+	}
+
 	// Repository
-	//postRepo := repository.NewPostRepository(database, cfg.Mongo.DB, logger)
+	postRepo := repository.NewPostRepository(database, cfg.Mongo.DB, cfg.Posts.MaxRevisions, cfg.Mongo.OpTimeout, logger)
+	bookmarkRepo := repository.NewBookmarkRepository(database, cfg.Mongo.DB, logger)
+	followRepo := repository.NewFollowRepository(database, cfg.Mongo.DB, logger)
+	reportRepo := repository.NewReportRepository(database, cfg.Mongo.DB, logger)
+	commentRepo := repository.NewCommentRepository(database, cfg.Mongo.DB, logger)
+	outboxRepo := repository.NewOutboxRepository(database, cfg.Mongo.DB, logger)
+
+	pagination := handler.PaginationLimits{DefaultLimit: cfg.Pagination.DefaultLimit, MaxLimit: cfg.Pagination.MaxLimit}
+	postCreateLimiter := cache.NewSlidingWindowLimiter(redisConn, "ratelimit:posts:", cfg.Limits.PostsPerHour, time.Hour)
+
+	// Service
+	sanitizer, err := sanitize.New(cfg.Posts.ContentMode, cfg.Posts.MaxContentLength, cfg.Posts.MaxTags)
+	if err != nil {
+		return fmt.Errorf("sanitizer: %w", err)
+	}
+	userDirectory := authclient.NewUserDirectory(cfg.Auth.URL, cfg.Auth.ServiceSecret, cfg.Auth.Timeout)
+	feedService := service.NewFeedService(postRepo, followRepo, userDirectory,
+		cache.NewFeedCache(redisConn, cfg.Feed.CacheTTL), int64(cfg.Pagination.DefaultLimit), logger)
+	mentionParser, err := mention.NewParser(cfg.Security.UsernamePattern)
+	if err != nil {
+		return fmt.Errorf("mentions: %w", err)
+	}
+	mentions := service.NewMentions(mentionParser, userDirectory, outboxRepo, logger)
+	postService := service.NewPostService(postRepo, sanitizer,
+		cache.NewPostCache(redisConn, cfg.Posts.CacheTTL),
+		cache.NewPostCountCache(redisConn, cfg.Posts.CountCacheTTL),
+		cache.NewRelatedCache(redisConn, cfg.Posts.RelatedCacheTTL),
+		service.PostOptions{
+			RelatedLimit:  cfg.Posts.RelatedLimit,
+			PreviewLength: cfg.Posts.PreviewLength,
+			Feed:          feedService,
+			Mentions:      mentions,
+		},
+		logger,
+	)
+	moderationService := service.NewModerationService(reportRepo, postService, logger)
+	bookmarkService := service.NewBookmarkService(bookmarkRepo, postRepo, userDirectory, logger)
+	commentService := service.NewCommentService(commentRepo, postRepo, sanitizer, mentions, logger)
+	// Фоновая публикация запланированных постов; останавливается раньше закрытия Redis и Mongo
+	jobsCtx, stopJobs := context.WithCancel(ctx)
+	defer stopJobs()
+	publisher := service.NewScheduledPublisher(postService, cache.NewLocker(redisConn), cfg.Posts.PublishInterval, logger)
+	go publisher.Run(jobsCtx)
 
 	//HTTP
-	r := gin.New()
-	r.Use(gin.Recovery())
-	r.Use(handler.ZapLogger(logger))
+	r, err := router.NewRouter(router.Handlers{
+		Post:       handler.NewPostHandler(postService, logger),
+		Moderation: handler.NewModerationHandler(moderationService, pagination, logger),
+		Bookmark:   handler.NewBookmarkHandler(bookmarkService, pagination, logger),
+		Comment:    handler.NewCommentHandler(commentService, logger),
+		Feed:       handler.NewFeedHandler(feedService, pagination, logger),
+		Health:     handler.Health(redisConn),
 
-	r.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{"status": "ok"})
-	})
+		PostCreateLimiter: postCreateLimiter,
+	}, authclient.NewIntrospector(cfg.Auth.URL, cfg.Auth.ServiceSecret, cfg.Auth.Timeout), cfg, logger)
+	if err != nil {
+		return err
+	}
+
+	if storeMetrics != nil {
+		storeMetrics.ObserveRedisPool(redisConn.PoolStats)
+		r.GET(cfg.Metrics.Path, gin.WrapH(storeMetrics.Handler()))
+	}
 
 	server := &http.Server{
-		Addr:    ":" + cfg.App.Port,
-		Handler: r,
+		Addr:              ":" + cfg.App.Port,
+		Handler:           r,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
 	}
 
 	go func() {
@@ -0,0 +1,125 @@
+package authclient
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/status"
+)
+
+// Options - настройки соединения с auth-service
+type Options struct {
+	// KeepaliveTime - пинг после такого простоя соединения; 0 - без пингов.
+	// gRPC не пингует чаще раза в 10 секунд, меньшее значение поднимается до 10s
+	KeepaliveTime time.Duration
+	// KeepaliveTimeout - сколько ждать ответа на пинг, прежде чем считать соединение мертвым
+	KeepaliveTimeout time.Duration
+	// KeepalivePermitWithoutStream - пинговать и без активных вызовов: иначе мертвое
+	// соединение обнаружится только на первом запросе после простоя
+	KeepalivePermitWithoutStream bool
+
+	// RetryMaxAttempts - сколько раз всего пробовать идемпотентный unary-вызов (retryableMethods),
+	// который вернул Unavailable; <= 1 - без повторов
+	RetryMaxAttempts int
+	// RetryInitialBackoff - пауза перед первым повтором, дальше удваивается до RetryMaxBackoff
+	RetryInitialBackoff time.Duration
+	RetryMaxBackoff     time.Duration
+}
+
+// Dial создает соединение с gRPC auth-service. Подключение ленивое:
+// доступность проверяет CheckHealth, а не сам Dial. extra добавляется к опциям
+// из opts (в тестах - dialer на bufconn).
+func Dial(addr string, opts Options, extra ...grpc.DialOption) (*grpc.ClientConn, error) {
+	if err := opts.validate(); err != nil {
+		return nil, fmt.Errorf("auth grpc client: %w", err)
+	}
+
+	dialOpts := []grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithChainUnaryInterceptor(retryUnavailable(opts)),
+	}
+	if opts.KeepaliveTime > 0 {
+		dialOpts = append(dialOpts, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                opts.KeepaliveTime,
+			Timeout:             opts.KeepaliveTimeout,
+			PermitWithoutStream: opts.KeepalivePermitWithoutStream,
+		}))
+	}
+
+	conn, err := grpc.NewClient(addr, append(dialOpts, extra...)...)
+	if err != nil {
+		return nil, fmt.Errorf("auth grpc client: %w", err)
+	}
+	return conn, nil
+}
+
+// validate - с повторами пауза должна быть положительной и не больше потолка:
+// при RetryMaxBackoff = 0 min(backoff*2, 0) дал бы повторы без пауз
+func (o Options) validate() error {
+	if o.RetryMaxAttempts <= 1 {
+		return nil
+	}
+	if o.RetryInitialBackoff <= 0 {
+		return fmt.Errorf("retry initial backoff must be positive")
+	}
+	if o.RetryMaxBackoff < o.RetryInitialBackoff {
+		return fmt.Errorf("retry max backoff must not be less than initial backoff")
+	}
+	return nil
+}
+
+// retryableMethods - вызовы, которые можно безопасно повторить: Unavailable не гарантирует,
+// что сервер запрос не обработал, поэтому повторяются только идемпотентные методы без побочных эффектов
+var retryableMethods = map[string]bool{
+	healthpb.Health_Check_FullMethodName: true,
+}
+
+// retryUnavailable повторяет вызовы из retryableMethods, которые вернули Unavailable: соединение
+// после простоя оборвано или auth-service перезапускается. Остальные коды и методы возвращаются сразу.
+func retryUnavailable(opts Options) grpc.UnaryClientInterceptor {
+	attempts := max(opts.RetryMaxAttempts, 1)
+
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, callOpts ...grpc.CallOption) error {
+		if !retryableMethods[method] {
+			return invoker(ctx, method, req, reply, cc, callOpts...)
+		}
+
+		backoff := opts.RetryInitialBackoff
+
+		var err error
+		for attempt := 1; attempt <= attempts; attempt++ {
+			err = invoker(ctx, method, req, reply, cc, callOpts...)
+			if status.Code(err) != codes.Unavailable || attempt == attempts {
+				return err
+			}
+
+			select {
+			case <-ctx.Done():
+				return err
+			case <-time.After(backoff):
+			}
+
+			backoff = min(backoff*2, opts.RetryMaxBackoff)
+		}
+		return err
+	}
+}
+
+// CheckHealth спрашивает grpc.health.v1.Health у auth-service. Открытого порта мало:
+// auth-service отвечает SERVING, только пока у него доступна база.
+func CheckHealth(ctx context.Context, conn grpc.ClientConnInterface) error {
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		return fmt.Errorf("auth health check: %w", err)
+	}
+	if status := resp.GetStatus(); status != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("auth-service is %s", status)
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package authclient
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestCheckHealth(t *testing.T) {
+	lis := bufconn.Listen(1 << 20)
+	srv := health.NewServer()
+	s := grpc.NewServer()
+	healthpb.RegisterHealthServer(s, srv)
+	go func() { _ = s.Serve(lis) }()
+	defer s.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	t.Run("Serving", func(t *testing.T) {
+		srv.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+		assert.NoError(t, CheckHealth(ctx, conn))
+	})
+
+	t.Run("Not serving", func(t *testing.T) {
+		srv.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+		err := CheckHealth(ctx, conn)
+		assert.ErrorContains(t, err, "NOT_SERVING")
+	})
+}
+
+// flakyHealth отвечает code на первые fails вызовов, потом SERVING
+type flakyHealth struct {
+	healthpb.UnimplementedHealthServer
+	code  codes.Code
+	fails int32
+	calls atomic.Int32
+}
+
+func (h *flakyHealth) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	if h.calls.Add(1) <= h.fails {
+		return nil, status.Error(h.code, "flaky")
+	}
+	return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING}, nil
+}
+
+func TestDial_RetriesUnavailable(t *testing.T) {
+	dial := func(t *testing.T, srv *flakyHealth) *grpc.ClientConn {
+		lis := bufconn.Listen(1 << 20)
+		s := grpc.NewServer()
+		healthpb.RegisterHealthServer(s, srv)
+		go func() { _ = s.Serve(lis) }()
+		t.Cleanup(s.Stop)
+
+		conn, err := Dial("passthrough:///bufnet", Options{
+			KeepaliveTime:                10 * time.Second,
+			KeepaliveTimeout:             time.Second,
+			KeepalivePermitWithoutStream: true,
+			RetryMaxAttempts:             3,
+			RetryInitialBackoff:          time.Millisecond,
+			RetryMaxBackoff:              5 * time.Millisecond,
+		}, grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}))
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = conn.Close() })
+		return conn
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	t.Run("Transient Unavailable is retried", func(t *testing.T) {
+		srv := &flakyHealth{code: codes.Unavailable, fails: 2}
+		assert.NoError(t, CheckHealth(ctx, dial(t, srv)))
+		assert.Equal(t, int32(3), srv.calls.Load())
+	})
+
+	t.Run("Gives up after max attempts", func(t *testing.T) {
+		srv := &flakyHealth{code: codes.Unavailable, fails: 5}
+		err := CheckHealth(ctx, dial(t, srv))
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+		assert.Equal(t, int32(3), srv.calls.Load())
+	})
+
+	t.Run("Other codes are not retried", func(t *testing.T) {
+		srv := &flakyHealth{code: codes.PermissionDenied, fails: 1}
+		assert.Error(t, CheckHealth(ctx, dial(t, srv)))
+		assert.Equal(t, int32(1), srv.calls.Load())
+	})
+}
+
+func TestRetryUnavailable_OnlyIdempotentMethods(t *testing.T) {
+	interceptor := retryUnavailable(Options{RetryMaxAttempts: 3, RetryInitialBackoff: time.Millisecond, RetryMaxBackoff: time.Millisecond})
+
+	call := func(method string) int {
+		calls := 0
+		_ = interceptor(context.Background(), method, nil, nil, nil,
+			func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+				calls++
+				return status.Error(codes.Unavailable, "connection reset")
+			})
+		return calls
+	}
+
+	assert.Equal(t, 3, call(healthpb.Health_Check_FullMethodName))
+	// Вызов мог дойти до сервера - повтор выполнил бы его дважды
+	assert.Equal(t, 1, call("/auth.v1.Auth/RevokeSessions"))
+}
+
+func TestDial_InvalidRetryBackoff(t *testing.T) {
+	for _, opts := range []Options{
+		{RetryMaxAttempts: 3},
+		{RetryMaxAttempts: 3, RetryInitialBackoff: 100 * time.Millisecond},
+		{RetryMaxAttempts: 3, RetryInitialBackoff: time.Second, RetryMaxBackoff: 100 * time.Millisecond},
+	} {
+		_, err := Dial("passthrough:///bufnet", opts)
+		assert.Error(t, err)
+	}
+
+	// Без повторов паузы не нужны
+	conn, err := Dial("passthrough:///bufnet", Options{RetryMaxAttempts: 1})
+	require.NoError(t, err)
+	_ = conn.Close()
+}
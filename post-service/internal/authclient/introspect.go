@@ -0,0 +1,92 @@
+package authclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ServiceSecretHeader - заголовок, которым auth-service узнает внутренний сервис
+const ServiceSecretHeader = "X-Service-Secret"
+
+// ErrInactiveToken - токен не прошел проверку: подпись, срок действия или отозванная сессия
+var ErrInactiveToken = errors.New("token is not active")
+
+// Identity - кто делает запрос, по ответу auth-service
+type Identity struct {
+	UserID   string
+	Username string
+	Role     string
+}
+
+// Introspector проверяет токены через POST /auth/token/introspect auth-service. Секрет JWT
+// остается только в auth-service, и отозванные сессии post-service видит сразу.
+type Introspector struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+// NewIntrospector - baseURL включает app.base_path auth-service; timeout ограничивает один вызов
+func NewIntrospector(baseURL, secret string, timeout time.Duration) *Introspector {
+	return &Introspector{
+		url:    strings.TrimRight(baseURL, "/") + "/auth/token/introspect",
+		secret: secret,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+type introspectResponse struct {
+	Active   bool   `json:"active"`
+	UserID   string `json:"user_id"`
+	Username string `json:"username"`
+	Role     string `json:"role"`
+}
+
+// Introspect возвращает владельца токена или ErrInactiveToken; другие ошибки значат,
+// что auth-service недоступен или ответил неожиданно
+func (i *Introspector) Introspect(ctx context.Context, token string) (*Identity, error) {
+	var result introspectResponse
+	if err := postJSON(ctx, i.client, i.url, i.secret, map[string]string{"token": token}, &result); err != nil {
+		return nil, fmt.Errorf("introspect: %w", err)
+	}
+	if !result.Active || result.UserID == "" {
+		return nil, ErrInactiveToken
+	}
+
+	return &Identity{UserID: result.UserID, Username: result.Username, Role: result.Role}, nil
+}
+
+// postJSON - POST внутреннего эндпоинта auth-service с общим секретом; ответ не 200 - ошибка
+func postJSON(ctx context.Context, client *http.Client, url, secret string, in, out any) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(ServiceSecretHeader, secret)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
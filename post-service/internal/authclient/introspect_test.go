@@ -0,0 +1,54 @@
+package authclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIntrospector_Introspect(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/auth/token/introspect", r.URL.Path)
+		if r.Header.Get(ServiceSecretHeader) != "service-secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var req struct {
+			Token string `json:"token"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		if req.Token != "good" {
+			_, _ = w.Write([]byte(`{"active":false}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"active":true,"user_id":"u1","username":"alice","role":"admin","exp":1}`))
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	i := NewIntrospector(srv.URL+"/api/v1/", "service-secret", time.Second)
+
+	t.Run("Active token", func(t *testing.T) {
+		identity, err := i.Introspect(ctx, "good")
+		require.NoError(t, err)
+		assert.Equal(t, &Identity{UserID: "u1", Username: "alice", Role: "admin"}, identity)
+	})
+
+	t.Run("Inactive token", func(t *testing.T) {
+		_, err := i.Introspect(ctx, "expired")
+		assert.ErrorIs(t, err, ErrInactiveToken)
+	})
+
+	t.Run("Wrong service secret is not an inactive token", func(t *testing.T) {
+		_, err := NewIntrospector(srv.URL+"/api/v1", "wrong", time.Second).Introspect(ctx, "good")
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, ErrInactiveToken)
+	})
+}
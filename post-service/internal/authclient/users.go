@@ -0,0 +1,64 @@
+package authclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxLookupUsers - предел auth-service на один POST /auth/users/lookup
+const maxLookupUsers = 100
+
+// User - публичные данные пользователя из auth-service
+type User struct {
+	ID          string `json:"id"`
+	Username    string `json:"username"`
+	DisplayName string `json:"display_name"`
+}
+
+// UserDirectory получает данные пользователей через POST /auth/users/lookup auth-service
+type UserDirectory struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+// NewUserDirectory - параметры те же, что у NewIntrospector
+func NewUserDirectory(baseURL, secret string, timeout time.Duration) *UserDirectory {
+	return &UserDirectory{
+		url:    strings.TrimRight(baseURL, "/") + "/auth/users/lookup",
+		secret: secret,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// LookupUsers возвращает найденных пользователей; неизвестных id в ответе нет.
+// Больше maxLookupUsers id запрашиваются несколькими вызовами.
+func (d *UserDirectory) LookupUsers(ctx context.Context, ids []string) ([]*User, error) {
+	return d.lookup(ctx, "ids", ids)
+}
+
+// LookupUsernames - то же по username (с учетом регистра), для @-упоминаний
+func (d *UserDirectory) LookupUsernames(ctx context.Context, usernames []string) ([]*User, error) {
+	return d.lookup(ctx, "usernames", usernames)
+}
+
+// lookup - field - поле тела запроса: ids или usernames
+func (d *UserDirectory) lookup(ctx context.Context, field string, values []string) ([]*User, error) {
+	users := make([]*User, 0, len(values))
+	for start := 0; start < len(values); start += maxLookupUsers {
+		batch := values[start:min(start+maxLookupUsers, len(values))]
+
+		var result struct {
+			Items []*User `json:"items"`
+		}
+		if err := postJSON(ctx, d.client, d.url, d.secret, map[string][]string{field: batch}, &result); err != nil {
+			return nil, fmt.Errorf("lookup users: %w", err)
+		}
+		users = append(users, result.Items...)
+	}
+
+	return users, nil
+}
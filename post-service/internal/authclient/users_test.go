@@ -0,0 +1,83 @@
+package authclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserDirectory_LookupUsers(t *testing.T) {
+	var batches []int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/auth/users/lookup", r.URL.Path)
+		assert.Equal(t, "service-secret", r.Header.Get(ServiceSecretHeader))
+
+		var req struct {
+			IDs []string `json:"ids"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		batches = append(batches, len(req.IDs))
+
+		// Знает только четные id
+		items := []*User{}
+		for _, id := range req.IDs {
+			var n int
+			_, _ = fmt.Sscanf(id, "u%d", &n)
+			if n%2 == 0 {
+				items = append(items, &User{ID: id, Username: "user_" + id})
+			}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"items": items})
+	}))
+	defer srv.Close()
+
+	ids := make([]string, 150)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("u%d", i)
+	}
+
+	users, err := NewUserDirectory(srv.URL, "service-secret", time.Second).LookupUsers(context.Background(), ids)
+	require.NoError(t, err)
+	assert.Len(t, users, 75)
+	assert.Equal(t, "user_u0", users[0].Username)
+	assert.Equal(t, []int{100, 50}, batches)
+
+	t.Run("Auth service error", func(t *testing.T) {
+		failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer failing.Close()
+
+		_, err := NewUserDirectory(failing.URL, "service-secret", time.Second).LookupUsers(context.Background(), ids[:1])
+		assert.Error(t, err)
+	})
+}
+
+func TestUserDirectory_LookupUsernames(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string][]string
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.NotContains(t, req, "ids")
+
+		items := []*User{}
+		for _, name := range req["usernames"] {
+			if name == "alice" {
+				items = append(items, &User{ID: "alice-id", Username: name})
+			}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"items": items})
+	}))
+	defer srv.Close()
+
+	users, err := NewUserDirectory(srv.URL, "service-secret", time.Second).
+		LookupUsernames(context.Background(), []string{"alice", "ghost"})
+	require.NoError(t, err)
+	assert.Equal(t, []*User{{ID: "alice-id", Username: "alice"}}, users)
+}
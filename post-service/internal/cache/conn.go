@@ -0,0 +1,117 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// ErrUnavailable - Redis не подключен; операции, которым без него нельзя (блокировка, лимиты), возвращают эту ошибку
+var ErrUnavailable = errors.New("redis unavailable")
+
+// Conn - подключение к Redis, которого может не быть. Redis для сервиса - ускоритель, а не
+// источник данных: пока клиента нет, кеши работают как no-op (чтение - промах, запись пропускается),
+// и запросы обслуживаются из Mongo. Клиент подставляется на лету, когда Reconnect дозвонится.
+//
+// Если Redis пропал уже после подключения, клиент остается: go-redis сам переподключается,
+// а ошибки команд кеши и так переживают.
+type Conn struct {
+	client atomic.Pointer[redis.Client]
+	// mu и closed не дают Reconnect подставить клиент после Close: его бы уже никто не закрыл
+	mu     sync.Mutex
+	closed bool
+}
+
+// NewConn - подключение с клиентом; nil - сразу в деградированном режиме
+func NewConn(client *redis.Client) *Conn {
+	c := &Conn{}
+	if client != nil {
+		c.client.Store(client)
+	}
+	return c
+}
+
+// Client - текущий клиент или nil, если Redis не подключен
+func (c *Conn) Client() *redis.Client {
+	return c.client.Load()
+}
+
+// Ping - проверка для /health: ErrUnavailable без клиента, иначе ответ самого Redis
+func (c *Conn) Ping(ctx context.Context) error {
+	client := c.Client()
+	if client == nil {
+		return ErrUnavailable
+	}
+	return client.Ping(ctx).Err()
+}
+
+// PoolStats - статистика пула для метрик; без клиента - нули
+func (c *Conn) PoolStats() *redis.PoolStats {
+	client := c.Client()
+	if client == nil {
+		return &redis.PoolStats{}
+	}
+	return client.PoolStats()
+}
+
+// Reconnect пытается подключиться каждые interval, пока не получится или не отменят ctx.
+// Ничего не делает, если клиент уже есть.
+func (c *Conn) Reconnect(ctx context.Context, logger *zap.Logger, interval time.Duration, connect func(ctx context.Context) (*redis.Client, error)) {
+	if c.Client() != nil {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		client, err := connect(ctx)
+		if err != nil {
+			logger.Debug("redis still unavailable", zap.Error(err))
+			continue
+		}
+
+		if !c.store(client) {
+			_ = client.Close()
+			return
+		}
+		logger.Info("redis connected, cache enabled")
+		return
+	}
+}
+
+// store подставляет клиент, если Conn еще не закрыт
+func (c *Conn) store(client *redis.Client) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return false
+	}
+	c.client.Store(client)
+	return true
+}
+
+// Close закрывает клиент, если он есть. Клиент, до которого Reconnect дозвонится позже, сразу закрывается
+func (c *Conn) Close() error {
+	c.mu.Lock()
+	c.closed = true
+	client := c.client.Swap(nil)
+	c.mu.Unlock()
+
+	if client == nil {
+		return nil
+	}
+	return client.Close()
+}
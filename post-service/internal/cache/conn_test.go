@@ -0,0 +1,101 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestConn_WithoutRedis(t *testing.T) {
+	ctx := context.Background()
+	conn := NewConn(nil)
+
+	assert.ErrorIs(t, conn.Ping(ctx), ErrUnavailable)
+	assert.Equal(t, &redis.PoolStats{}, conn.PoolStats())
+	assert.NoError(t, conn.Close())
+
+	// Кеши - no-op: промах и тихо пропущенная запись
+	posts := NewPostCache(conn, time.Minute)
+	_, ok, err := posts.Get(ctx, "p1")
+	assert.False(t, ok)
+	assert.NoError(t, err)
+	assert.NoError(t, posts.Set(ctx, "p1", &CachedPost{ETag: `"x"`}))
+	assert.NoError(t, posts.Invalidate(ctx, "p1"))
+
+	counts := NewPostCountCache(conn, time.Minute)
+	_, ok, err = counts.Get(ctx, "a1", false)
+	assert.False(t, ok)
+	assert.NoError(t, err)
+	assert.NoError(t, counts.Invalidate(ctx, "a1"))
+
+	// Блокировку и лимит без Redis не посчитать - решает вызывающий
+	_, acquired, err := NewLocker(conn).Lock(ctx, "job", time.Minute)
+	assert.False(t, acquired)
+	assert.ErrorIs(t, err, ErrUnavailable)
+
+	_, _, err = NewSlidingWindowLimiter(conn, "rl:", 1, time.Minute).Allow(ctx, "u1")
+	assert.ErrorIs(t, err, ErrUnavailable)
+}
+
+func TestConn_Reconnect(t *testing.T) {
+	conn := NewConn(nil)
+	// Клиент go-redis не подключается при создании - для подмены сервер не нужен
+	client := redis.NewClient(&redis.Options{Addr: "localhost:0"})
+	t.Cleanup(func() { _ = conn.Close() })
+
+	attempts := 0
+	connect := func(ctx context.Context) (*redis.Client, error) {
+		attempts++
+		if attempts < 3 {
+			return nil, errors.New("connection refused")
+		}
+		return client, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn.Reconnect(ctx, zap.NewNop(), time.Millisecond, connect)
+
+	require.Same(t, client, conn.Client())
+	assert.Equal(t, 3, attempts)
+
+	// Клиент уже есть - Reconnect сразу выходит
+	conn.Reconnect(ctx, zap.NewNop(), time.Millisecond, func(ctx context.Context) (*redis.Client, error) {
+		t.Fatal("unexpected connect")
+		return nil, nil
+	})
+}
+
+func TestConn_ReconnectStopsOnCancel(t *testing.T) {
+	conn := NewConn(nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	conn.Reconnect(ctx, zap.NewNop(), time.Hour, func(ctx context.Context) (*redis.Client, error) {
+		return nil, errors.New("unreachable")
+	})
+	assert.Nil(t, conn.Client())
+}
+
+func TestConn_ReconnectAfterClose(t *testing.T) {
+	conn := NewConn(nil)
+	client := redis.NewClient(&redis.Options{Addr: "localhost:0"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Сервис остановился, пока Reconnect дозванивался
+	conn.Reconnect(ctx, zap.NewNop(), time.Millisecond, func(ctx context.Context) (*redis.Client, error) {
+		require.NoError(t, conn.Close())
+		return client, nil
+	})
+
+	assert.Nil(t, conn.Client())
+	assert.ErrorIs(t, client.Ping(ctx).Err(), redis.ErrClosed)
+}
@@ -0,0 +1,69 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// PostCountCache - число постов автора для шапки профиля. Владелец видит счетчик
+// вместе с черновиками, остальные - без, поэтому на автора два ключа.
+// Без подключенного Redis - no-op, как PostCache.
+type PostCountCache struct {
+	conn *Conn
+	ttl  time.Duration
+}
+
+func NewPostCountCache(conn *Conn, ttl time.Duration) *PostCountCache {
+	return &PostCountCache{conn: conn, ttl: ttl}
+}
+
+func postCountKey(authorID string, includeDrafts bool) string {
+	if includeDrafts {
+		return "posts:count:" + authorID + ":all"
+	}
+	return "posts:count:" + authorID + ":published"
+}
+
+// Get возвращает закешированный счетчик; ok=false, если в кеше пусто
+func (c *PostCountCache) Get(ctx context.Context, authorID string, includeDrafts bool) (int64, bool, error) {
+	client := c.conn.Client()
+	if client == nil {
+		return 0, false, nil
+	}
+
+	data, err := client.Get(ctx, postCountKey(authorID, includeDrafts)).Result()
+	if errors.Is(err, redis.Nil) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+
+	count, err := strconv.ParseInt(data, 10, 64)
+	if err != nil {
+		return 0, false, err
+	}
+
+	return count, true, nil
+}
+
+func (c *PostCountCache) Set(ctx context.Context, authorID string, includeDrafts bool, count int64) error {
+	client := c.conn.Client()
+	if client == nil {
+		return nil
+	}
+	return client.Set(ctx, postCountKey(authorID, includeDrafts), count, c.ttl).Err()
+}
+
+// Invalidate сбрасывает оба счетчика автора
+func (c *PostCountCache) Invalidate(ctx context.Context, authorID string) error {
+	client := c.conn.Client()
+	if client == nil {
+		return nil
+	}
+	return client.Del(ctx, postCountKey(authorID, true), postCountKey(authorID, false)).Err()
+}
@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/model"
+	"github.com/redis/go-redis/v9"
+)
+
+// FeedCache хранит только первую страницу ленты - ее запрашивают чаще всего,
+// а остальные страницы идут по курсору и кешировать их нет смысла.
+// Без подключенного Redis - no-op, как PostCache.
+type FeedCache struct {
+	conn *Conn
+	ttl  time.Duration
+}
+
+func NewFeedCache(conn *Conn, ttl time.Duration) *FeedCache {
+	return &FeedCache{conn: conn, ttl: ttl}
+}
+
+func feedKey(userID string) string {
+	return "feed:" + userID
+}
+
+// Get возвращает закешированную страницу; ok=false, если в кеше пусто
+func (c *FeedCache) Get(ctx context.Context, userID string) (*model.FeedPage, bool, error) {
+	client := c.conn.Client()
+	if client == nil {
+		return nil, false, nil
+	}
+
+	data, err := client.Get(ctx, feedKey(userID)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	var page model.FeedPage
+	if err := json.Unmarshal(data, &page); err != nil {
+		return nil, false, err
+	}
+
+	return &page, true, nil
+}
+
+func (c *FeedCache) Set(ctx context.Context, userID string, page *model.FeedPage) error {
+	client := c.conn.Client()
+	if client == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(page)
+	if err != nil {
+		return err
+	}
+
+	return client.Set(ctx, feedKey(userID), data, c.ttl).Err()
+}
+
+// Invalidate сбрасывает ленты пользователей
+func (c *FeedCache) Invalidate(ctx context.Context, userIDs ...string) error {
+	client := c.conn.Client()
+	if len(userIDs) == 0 || client == nil {
+		return nil
+	}
+
+	keys := make([]string, 0, len(userIDs))
+	for _, id := range userIDs {
+		keys = append(keys, feedKey(id))
+	}
+
+	return client.Del(ctx, keys...).Err()
+}
@@ -0,0 +1,82 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// releaseTimeout - сколько ждать DEL при снятии блокировки; не успели - ключ истечет по ttl
+const releaseTimeout = 2 * time.Second
+
+// releaseScript удаляет ключ, только если в нем все еще наш токен: блокировку,
+// которая истекла и досталась другой реплике, снимать нельзя
+var releaseScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// Locker - распределенная блокировка на SET NX PX: в каждый момент ее держит одна реплика.
+// Для фоновых задач, которые должна выполнять только одна копия сервиса.
+// Без подключенного Redis блокировку не получить: Lock возвращает ErrUnavailable.
+type Locker struct {
+	conn *Conn
+}
+
+func NewLocker(conn *Conn) *Locker {
+	return &Locker{conn: conn}
+}
+
+func lockKey(key string) string {
+	return "lock:" + key
+}
+
+// Lock пытается взять блокировку key на ttl, не дожидаясь ее освобождения.
+// acquired=false - блокировку держит кто-то другой. release снимает только свою
+// блокировку и безопасен при повторном вызове; работа дольше ttl блокировку теряет.
+func (l *Locker) Lock(ctx context.Context, key string, ttl time.Duration) (release func(), acquired bool, err error) {
+	client := l.conn.Client()
+	if client == nil {
+		return func() {}, false, ErrUnavailable
+	}
+
+	token, err := lockToken()
+	if err != nil {
+		return nil, false, err
+	}
+
+	acquired, err = client.SetNX(ctx, lockKey(key), token, ttl).Result()
+	if err != nil || !acquired {
+		return func() {}, false, err
+	}
+
+	released := false
+	release = func() {
+		if released {
+			return
+		}
+		released = true
+
+		// Контекст задачи к этому моменту может быть уже отменен
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), releaseTimeout)
+		defer cancel()
+		// Ошибку игнорируем: ключ все равно истечет по ttl
+		_ = releaseScript.Run(ctx, client, []string{lockKey(key)}, token).Err()
+	}
+
+	return release, true, nil
+}
+
+// lockToken - случайное значение ключа, по которому release узнает свою блокировку
+func lockToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
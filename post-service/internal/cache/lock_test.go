@@ -0,0 +1,80 @@
+package cache
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testRedis - клиент к Redis из REDIS_ADDR; без него тест пропускается
+func testRedis(t *testing.T) *redis.Client {
+	t.Helper()
+
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		t.Skip("REDIS_ADDR is not set")
+	}
+
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	t.Cleanup(func() { _ = client.Close() })
+	require.NoError(t, client.Ping(context.Background()).Err())
+	return client
+}
+
+func TestLocker(t *testing.T) {
+	client := testRedis(t)
+	ctx := context.Background()
+	key := "test:" + t.Name() + ":" + time.Now().Format(time.RFC3339Nano)
+	t.Cleanup(func() { client.Del(ctx, lockKey(key)) })
+
+	first := NewLocker(NewConn(client))
+	second := NewLocker(NewConn(client))
+
+	release, acquired, err := first.Lock(ctx, key, time.Minute)
+	require.NoError(t, err)
+	require.True(t, acquired)
+
+	// Пока блокировка взята, вторая реплика ее не получает
+	_, acquired, err = second.Lock(ctx, key, time.Minute)
+	require.NoError(t, err)
+	assert.False(t, acquired)
+
+	release()
+	release() // повторный вызов ничего не ломает
+
+	secondRelease, acquired, err := second.Lock(ctx, key, time.Minute)
+	require.NoError(t, err)
+	require.True(t, acquired)
+	defer secondRelease()
+
+	// Чужую блокировку release не снимает, даже если своя уже истекла
+	release()
+	exists, err := client.Exists(ctx, lockKey(key)).Result()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), exists)
+}
+
+func TestLocker_Expires(t *testing.T) {
+	client := testRedis(t)
+	ctx := context.Background()
+	key := "test:" + t.Name() + ":" + time.Now().Format(time.RFC3339Nano)
+	t.Cleanup(func() { client.Del(ctx, lockKey(key)) })
+
+	locker := NewLocker(NewConn(client))
+
+	_, acquired, err := locker.Lock(ctx, key, 50*time.Millisecond)
+	require.NoError(t, err)
+	require.True(t, acquired)
+
+	time.Sleep(100 * time.Millisecond)
+
+	release, acquired, err := locker.Lock(ctx, key, time.Minute)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+	release()
+}
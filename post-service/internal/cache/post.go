@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/model"
+	"github.com/redis/go-redis/v9"
+)
+
+// CachedPost - готовый JSON поста вместе с его ETag, чтобы не пересчитывать хеш на каждый запрос.
+// Status и AuthorID нужны, чтобы проверить права читателя, не разбирая Body
+type CachedPost struct {
+	ETag     string           `json:"etag"`
+	Body     json.RawMessage  `json:"body"`
+	Status   model.PostStatus `json:"status"`
+	AuthorID string           `json:"author_id"`
+}
+
+// PostCache без подключенного Redis - no-op: промах на чтение, запись пропускается
+type PostCache struct {
+	conn *Conn
+	ttl  time.Duration
+}
+
+func NewPostCache(conn *Conn, ttl time.Duration) *PostCache {
+	return &PostCache{conn: conn, ttl: ttl}
+}
+
+func postKey(postID string) string {
+	return "post:" + postID
+}
+
+// Get возвращает закешированный пост; ok=false, если в кеше пусто
+func (c *PostCache) Get(ctx context.Context, postID string) (*CachedPost, bool, error) {
+	client := c.conn.Client()
+	if client == nil {
+		return nil, false, nil
+	}
+
+	data, err := client.Get(ctx, postKey(postID)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	var cached CachedPost
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return nil, false, err
+	}
+
+	return &cached, true, nil
+}
+
+func (c *PostCache) Set(ctx context.Context, postID string, cached *CachedPost) error {
+	client := c.conn.Client()
+	if client == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(cached)
+	if err != nil {
+		return err
+	}
+
+	return client.Set(ctx, postKey(postID), data, c.ttl).Err()
+}
+
+func (c *PostCache) Invalidate(ctx context.Context, postID string) error {
+	client := c.conn.Client()
+	if client == nil {
+		return nil
+	}
+	return client.Del(ctx, postKey(postID)).Err()
+}
@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// slidingWindowScript атомарно чистит старые отметки, считает оставшиеся
+// и либо добавляет новую, либо возвращает, сколько ждать до освобождения слота.
+// Время в миллисекундах.
+var slidingWindowScript = redis.NewScript(`
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+
+redis.call('ZREMRANGEBYSCORE', key, 0, now - window)
+
+if redis.call('ZCARD', key) < limit then
+	redis.call('ZADD', key, now, ARGV[4])
+	redis.call('PEXPIRE', key, window)
+	return {1, 0}
+end
+
+local oldest = redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')
+return {0, tonumber(oldest[2]) + window - now}
+`)
+
+// SlidingWindowLimiter - скользящее окно на sorted set: не больше limit событий за window.
+// Без подключенного Redis Allow возвращает ErrUnavailable - пропускать ли запрос, решает вызывающий
+type SlidingWindowLimiter struct {
+	conn   *Conn
+	prefix string
+	limit  int
+	window time.Duration
+	now    func() time.Time
+}
+
+func NewSlidingWindowLimiter(conn *Conn, prefix string, limit int, window time.Duration) *SlidingWindowLimiter {
+	return &SlidingWindowLimiter{
+		conn:   conn,
+		prefix: prefix,
+		limit:  limit,
+		window: window,
+		now:    time.Now,
+	}
+}
+
+// Allow регистрирует событие для key. Если лимит исчерпан, событие не засчитывается,
+// а retryAfter показывает, когда освободится ближайший слот.
+func (l *SlidingWindowLimiter) Allow(ctx context.Context, key string) (bool, time.Duration, error) {
+	client := l.conn.Client()
+	if client == nil {
+		return false, 0, ErrUnavailable
+	}
+
+	now := l.now()
+
+	res, err := slidingWindowScript.Run(ctx, client,
+		[]string{l.prefix + key},
+		now.UnixMilli(),
+		l.window.Milliseconds(),
+		l.limit,
+		// уникальный member, иначе два события в одну миллисекунду схлопнутся
+		fmt.Sprintf("%d", now.UnixNano()),
+	).Int64Slice()
+	if err != nil {
+		return false, 0, err
+	}
+
+	if res[0] == 1 {
+		return true, 0, nil
+	}
+
+	return false, time.Duration(res[1]) * time.Millisecond, nil
+}
@@ -9,7 +9,8 @@ import (
 	"go.uber.org/zap"
 )
 
-func NewRedisClient(parent context.Context, logger *zap.Logger, host, port string) (*redis.Client, error) {
+// NewRedisClient подключается к Redis; hooks добавляются до первого Ping (например, метрики)
+func NewRedisClient(parent context.Context, logger *zap.Logger, host, port string, hooks ...redis.Hook) (*redis.Client, error) {
 
 	ctx, cancel := context.WithTimeout(parent, 5*time.Second)
     defer cancel()
@@ -17,8 +18,12 @@ func NewRedisClient(parent context.Context, logger *zap.Logger, host, port strin
 	rdb := redis.NewClient(&redis.Options{
 		Addr: fmt.Sprintf("%s:%s", host, port),
 	})
+	for _, hook := range hooks {
+		rdb.AddHook(hook)
+	}
 
 	if err := rdb.Ping(ctx).Err(); err != nil {
+		_ = rdb.Close()
 		return nil, err
 	}
 	
@@ -0,0 +1,64 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/model"
+	"github.com/redis/go-redis/v9"
+)
+
+// RelatedCache - похожие посты для страницы поста. Агрегация по тегам дорогая, а точность
+// не важна, поэтому ключи не сбрасываются при изменениях: короткого ttl достаточно.
+// Без подключенного Redis - no-op, как PostCache.
+type RelatedCache struct {
+	conn *Conn
+	ttl  time.Duration
+}
+
+func NewRelatedCache(conn *Conn, ttl time.Duration) *RelatedCache {
+	return &RelatedCache{conn: conn, ttl: ttl}
+}
+
+func relatedKey(postID string) string {
+	return "posts:related:" + postID
+}
+
+// Get возвращает закешированный список; ok=false, если в кеше пусто
+func (c *RelatedCache) Get(ctx context.Context, postID string) ([]*model.Post, bool, error) {
+	client := c.conn.Client()
+	if client == nil {
+		return nil, false, nil
+	}
+
+	data, err := client.Get(ctx, relatedKey(postID)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	var posts []*model.Post
+	if err := json.Unmarshal(data, &posts); err != nil {
+		return nil, false, err
+	}
+
+	return posts, true, nil
+}
+
+func (c *RelatedCache) Set(ctx context.Context, postID string, posts []*model.Post) error {
+	client := c.conn.Client()
+	if client == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(posts)
+	if err != nil {
+		return err
+	}
+
+	return client.Set(ctx, relatedKey(postID), data, c.ttl).Err()
+}
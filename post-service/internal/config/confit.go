@@ -2,17 +2,30 @@ package config
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
 
 type Config struct {
 	App     AppConfig     `mapstructure:"app"`
+	Server  ServerConfig  `mapstructure:"server"`
 	Mongo   MongoConfig   `mapstructure:"mongo"`
 	Redis   RedisConfig   `mapstructure:"redis"`
 	GRPС    GRPCConfig    `mapstructure:"grpc"`
+	Auth    AuthConfig    `mapstructure:"auth"`
 	Logging LoggingConfig `mapstructure:"logging"`
+	Posts   PostsConfig   `mapstructure:"posts"`
+	Feed    FeedConfig    `mapstructure:"feed"`
+	Limits  LimitsConfig  `mapstructure:"limits"`
+	Startup StartupConfig `mapstructure:"startup"`
+	Metrics MetricsConfig `mapstructure:"metrics"`
+	// Pagination - общие лимиты для списков (посты, комментарии, поиск, лента)
+	Pagination PaginationConfig `mapstructure:"pagination"`
+	// Security - правила, общие с auth-service
+	Security SecurityConfig `mapstructure:"security"`
 }
 
 type AppConfig struct {
@@ -20,24 +33,144 @@ type AppConfig struct {
 	Mode string `mapstructure:"mode"`
 }
 
+// ServerConfig - таймауты http.Server, без них сервис уязвим к slowloris и зависшим соединениям
+type ServerConfig struct {
+	ReadTimeout       time.Duration `mapstructure:"read_timeout"`
+	ReadHeaderTimeout time.Duration `mapstructure:"read_header_timeout"`
+	WriteTimeout      time.Duration `mapstructure:"write_timeout"`
+	IdleTimeout       time.Duration `mapstructure:"idle_timeout"`
+	// TrustedProxies - IP/CIDR балансировщиков, которым верим в X-Forwarded-For.
+	// Пустой список - не доверяем никому, ClientIP() берется из RemoteAddr.
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
+	// MaxBodyBytes - предел тела запроса (JSON постов и комментариев); MaxMultipartBytes -
+	// отдельный предел для multipart-форм. 0 - без ограничения.
+	MaxBodyBytes      int64 `mapstructure:"max_body_bytes"`
+	MaxMultipartBytes int64 `mapstructure:"max_multipart_bytes"`
+}
+
 type MongoConfig struct {
 	Host string `mapstructure:"host"`
 	Port string `mapstructure:"port"`
 	DB   string `mapstructure:"db"`
+	// OpTimeout - предел на одну операцию репозитория постов
+	OpTimeout time.Duration `mapstructure:"op_timeout"`
 }
 
 type RedisConfig struct {
 	Host string `mapstructure:"host"`
 	Port string `mapstructure:"port"`
+	// ReconnectInterval - как часто пробовать подключиться, если на старте Redis не ответил
+	// (сервис тем временем работает без кеша)
+	ReconnectInterval time.Duration `mapstructure:"reconnect_interval"`
 }
 
 type GRPCConfig struct {
 	AuthHost string `mapstructure:"auth_host"`
 	AuthPort string `mapstructure:"auth_port"`
+	// KeepaliveTime - пинг соединения с auth-service после такого простоя, 0 - без пингов;
+	// KeepaliveTimeout - сколько ждать ответа, прежде чем переподключиться.
+	// auth-service должен разрешать такие пинги (grpc.keepalive_min_time там)
+	KeepaliveTime                time.Duration `mapstructure:"keepalive_time"`
+	KeepaliveTimeout             time.Duration `mapstructure:"keepalive_timeout"`
+	KeepalivePermitWithoutStream bool          `mapstructure:"keepalive_permit_without_stream"`
+	// Retry - повторы вызовов, вернувших Unavailable
+	Retry GRPCRetryConfig `mapstructure:"retry"`
+}
+
+// AuthConfig - внутренние HTTP-эндпоинты auth-service: проверка токенов и данные авторов
+type AuthConfig struct {
+	// URL - адрес auth-service вместе с его app.base_path
+	URL string `mapstructure:"url"`
+	// ServiceSecret - общий секрет сервисов, тот же SERVICE_SECRET, что у auth-service
+	ServiceSecret string `mapstructure:"service_secret"`
+	// Timeout - предел на один вызов auth-service
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+type GRPCRetryConfig struct {
+	MaxAttempts    int           `mapstructure:"max_attempts"`
+	InitialBackoff time.Duration `mapstructure:"initial_backoff"`
+	MaxBackoff     time.Duration `mapstructure:"max_backoff"`
+}
+
+type PostsConfig struct {
+	// MaxRevisions - сколько последних правок хранить на пост, 0 - не хранить историю
+	MaxRevisions int `mapstructure:"max_revisions"`
+	// ContentMode - plain (вся разметка вырезается) или basic_html (базовое форматирование)
+	ContentMode string `mapstructure:"content_mode"`
+	// MaxContentLength - максимальная длина тела поста в символах
+	MaxContentLength int `mapstructure:"max_content_length"`
+	// MaxTags - сколько тегов (после удаления дублей) можно поставить посту
+	MaxTags int `mapstructure:"max_tags"`
+	// CacheTTL - сколько пост с ETag живет в Redis
+	CacheTTL time.Duration `mapstructure:"cache_ttl"`
+	// CountCacheTTL - сколько живет счетчик постов автора (сбрасывается и при создании/удалении)
+	CountCacheTTL time.Duration `mapstructure:"count_cache_ttl"`
+	// PublishInterval - как часто фоновая задача публикует запланированные посты
+	PublishInterval time.Duration `mapstructure:"publish_interval"`
+	// RelatedLimit - сколько похожих постов отдает GET /posts/:id/related;
+	// RelatedCacheTTL - сколько список живет в Redis (при изменениях постов не сбрасывается)
+	RelatedLimit    int           `mapstructure:"related_limit"`
+	RelatedCacheTTL time.Duration `mapstructure:"related_cache_ttl"`
+	// PreviewLength - сколько видимых символов тела отдает GET /posts/:id?preview=true
+	PreviewLength int `mapstructure:"preview_length"`
+}
+
+type FeedConfig struct {
+	// CacheTTL - сколько живет закешированная первая страница ленты
+	CacheTTL time.Duration `mapstructure:"cache_ttl"`
+}
+
+type PaginationConfig struct {
+	DefaultLimit int `mapstructure:"default_limit"`
+	MaxLimit     int `mapstructure:"max_limit"`
+}
+
+// MetricsConfig - Prometheus-метрики (задержки Mongo/Redis, пулы соединений)
+type MetricsConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Path    string `mapstructure:"path"`
+}
+
+type LimitsConfig struct {
+	// PostsPerHour - сколько постов пользователь может создать за скользящий час
+	PostsPerHour int `mapstructure:"posts_per_hour"`
+}
+
+// SecurityConfig - должен совпадать с одноименными настройками auth-service
+type SecurityConfig struct {
+	// UsernamePattern - security.username_pattern auth-service: по нему в постах ищутся @-упоминания
+	UsernamePattern string `mapstructure:"username_pattern"`
+}
+
+// StartupConfig - ожидание Mongo и auth gRPC при старте, чтобы не падать,
+// если оркестратор поднял сервис раньше зависимостей. Redis не ждем: без него сервис стартует без кеша
+type StartupConfig struct {
+	// WaitDependencies - false: одна попытка, как раньше
+	WaitDependencies bool          `mapstructure:"wait_dependencies"`
+	MaxAttempts      int           `mapstructure:"max_attempts"`
+	InitialBackoff   time.Duration `mapstructure:"initial_backoff"`
+	MaxBackoff       time.Duration `mapstructure:"max_backoff"`
 }
 
 type LoggingConfig struct {
-	Level string `mapstructure:"level"`
+	Level    string         `mapstructure:"level"`
+	Sampling SamplingConfig `mapstructure:"sampling"`
+	File     LogFileConfig  `mapstructure:"file"`
+}
+
+// LogFileConfig - запись логов в файл с ротацией; пустой path - только stdout
+type LogFileConfig struct {
+	Path       string `mapstructure:"path"`
+	MaxSizeMB  int    `mapstructure:"max_size_mb"`
+	MaxBackups int    `mapstructure:"max_backups"`
+	MaxAgeDays int    `mapstructure:"max_age_days"`
+}
+
+// SamplingConfig - семплирование логов в release, в debug не применяется
+type SamplingConfig struct {
+	Initial    int `mapstructure:"initial"`
+	Thereafter int `mapstructure:"thereafter"`
 }
 
 func Load(path string) (*Config, error) {
@@ -46,6 +179,52 @@ func Load(path string) (*Config, error) {
 	v.SetConfigFile(path)
 	v.SetConfigType("yaml")
 
+	v.SetDefault("server.read_timeout", "15s")
+	v.SetDefault("server.read_header_timeout", "5s")
+	v.SetDefault("server.write_timeout", "30s")
+	v.SetDefault("server.idle_timeout", "60s")
+	v.SetDefault("server.max_body_bytes", 1<<20)
+	v.SetDefault("server.max_multipart_bytes", 10<<20)
+
+	v.SetDefault("mongo.op_timeout", "5s")
+
+	v.SetDefault("redis.reconnect_interval", "10s")
+
+	v.SetDefault("grpc.keepalive_time", "30s")
+	v.SetDefault("grpc.keepalive_timeout", "10s")
+	v.SetDefault("grpc.keepalive_permit_without_stream", true)
+	v.SetDefault("grpc.retry.max_attempts", 3)
+	v.SetDefault("grpc.retry.initial_backoff", "100ms")
+	v.SetDefault("grpc.retry.max_backoff", "1s")
+
+	v.SetDefault("auth.timeout", "3s")
+
+	v.SetDefault("posts.count_cache_ttl", "30s")
+	v.SetDefault("posts.max_tags", 10)
+	v.SetDefault("posts.publish_interval", "30s")
+	v.SetDefault("posts.related_limit", 5)
+	v.SetDefault("posts.related_cache_ttl", "1m")
+	v.SetDefault("posts.preview_length", 300)
+
+	v.SetDefault("metrics.enabled", false)
+	v.SetDefault("metrics.path", "/metrics")
+
+	v.SetDefault("security.username_pattern", `^[a-zA-Z0-9_]{2,50}$`)
+
+	v.SetDefault("pagination.default_limit", 20)
+	v.SetDefault("pagination.max_limit", 100)
+
+	v.SetDefault("startup.wait_dependencies", true)
+	v.SetDefault("startup.max_attempts", 10)
+	v.SetDefault("startup.initial_backoff", "500ms")
+	v.SetDefault("startup.max_backoff", "10s")
+
+	v.SetDefault("logging.sampling.initial", 100)
+	v.SetDefault("logging.sampling.thereafter", 100)
+	v.SetDefault("logging.file.max_size_mb", 100)
+	v.SetDefault("logging.file.max_backups", 5)
+	v.SetDefault("logging.file.max_age_days", 30)
+
 	v.AutomaticEnv()
 
 	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
@@ -62,6 +241,9 @@ func Load(path string) (*Config, error) {
 	_ = v.BindEnv("grpc.auth_host", "AUTH_GRPC_HOST")
 	_ = v.BindEnv("grpc.auth_port", "AUTH_GRPC_PORT")
 
+	_ = v.BindEnv("auth.url", "AUTH_HTTP_URL")
+	_ = v.BindEnv("auth.service_secret", "SERVICE_SECRET")
+
 	if err := v.ReadInConfig(); err != nil {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
@@ -104,5 +286,109 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("AUTH_GRPC_PORT is required")
 	}
 
+	if c.Auth.URL == "" {
+		return fmt.Errorf("AUTH_HTTP_URL is required")
+	}
+	if c.Auth.ServiceSecret == "" {
+		return fmt.Errorf("SERVICE_SECRET is required")
+	}
+	if c.Auth.Timeout <= 0 {
+		return fmt.Errorf("auth.timeout must be positive")
+	}
+
+	// Меньше 10s gRPC все равно не пингует - значение скорее ошибка, чем намерение
+	if c.GRPС.KeepaliveTime != 0 && c.GRPС.KeepaliveTime < 10*time.Second {
+		return fmt.Errorf("grpc.keepalive_time must be 0 or at least 10s")
+	}
+	if c.GRPС.KeepaliveTimeout < 0 {
+		return fmt.Errorf("grpc.keepalive_timeout must not be negative")
+	}
+	if c.GRPС.Retry.MaxAttempts < 0 || c.GRPС.Retry.InitialBackoff < 0 || c.GRPС.Retry.MaxBackoff < 0 {
+		return fmt.Errorf("grpc.retry settings must not be negative")
+	}
+	if c.GRPС.Retry.MaxAttempts > 1 {
+		if c.GRPС.Retry.InitialBackoff <= 0 {
+			return fmt.Errorf("grpc.retry.initial_backoff must be positive")
+		}
+		if c.GRPС.Retry.MaxBackoff < c.GRPС.Retry.InitialBackoff {
+			return fmt.Errorf("grpc.retry.max_backoff must not be less than grpc.retry.initial_backoff")
+		}
+	}
+
+	if c.Server.ReadTimeout < 0 || c.Server.ReadHeaderTimeout < 0 ||
+		c.Server.WriteTimeout < 0 || c.Server.IdleTimeout < 0 {
+		return fmt.Errorf("server timeouts must not be negative")
+	}
+	if c.Server.MaxBodyBytes < 0 || c.Server.MaxMultipartBytes < 0 {
+		return fmt.Errorf("server body limits must not be negative")
+	}
+	if c.Mongo.OpTimeout < 0 {
+		return fmt.Errorf("mongo.op_timeout must not be negative")
+	}
+	if c.Redis.ReconnectInterval <= 0 {
+		return fmt.Errorf("redis.reconnect_interval must be positive")
+	}
+
+	if c.Logging.Sampling.Initial < 0 || c.Logging.Sampling.Thereafter < 0 {
+		return fmt.Errorf("logging.sampling thresholds must not be negative")
+	}
+	if c.Logging.File.MaxSizeMB < 0 || c.Logging.File.MaxBackups < 0 || c.Logging.File.MaxAgeDays < 0 {
+		return fmt.Errorf("logging.file limits must not be negative")
+	}
+
+	if c.Startup.MaxAttempts < 0 || c.Startup.InitialBackoff < 0 || c.Startup.MaxBackoff < 0 {
+		return fmt.Errorf("startup retry settings must not be negative")
+	}
+	if c.Startup.WaitDependencies && c.Startup.MaxAttempts > 1 {
+		if c.Startup.InitialBackoff <= 0 {
+			return fmt.Errorf("startup.initial_backoff must be positive")
+		}
+		if c.Startup.MaxBackoff < c.Startup.InitialBackoff {
+			return fmt.Errorf("startup.max_backoff must not be less than startup.initial_backoff")
+		}
+	}
+
+	if c.Pagination.DefaultLimit <= 0 || c.Pagination.MaxLimit <= 0 {
+		return fmt.Errorf("pagination limits must be positive")
+	}
+	if c.Pagination.DefaultLimit > c.Pagination.MaxLimit {
+		return fmt.Errorf("pagination.default_limit must not exceed pagination.max_limit")
+	}
+
+	if c.Limits.PostsPerHour <= 0 {
+		return fmt.Errorf("limits.posts_per_hour must be positive")
+	}
+
+	if _, err := regexp.Compile(c.Security.UsernamePattern); err != nil {
+		return fmt.Errorf("security.username_pattern is not a valid regexp: %w", err)
+	}
+
+	if c.Posts.ContentMode == "" {
+		return fmt.Errorf("posts.content_mode is required")
+	}
+	if c.Posts.MaxContentLength <= 0 {
+		return fmt.Errorf("posts.max_content_length must be positive")
+	}
+	if c.Posts.MaxTags <= 0 {
+		return fmt.Errorf("posts.max_tags must be positive")
+	}
+
+	if c.Posts.MaxRevisions < 0 {
+		return fmt.Errorf("posts.max_revisions must not be negative")
+	}
+	if c.Posts.PublishInterval <= 0 {
+		return fmt.Errorf("posts.publish_interval must be positive")
+	}
+	if c.Posts.RelatedLimit <= 0 || c.Posts.RelatedLimit > 50 {
+		return fmt.Errorf("posts.related_limit must be between 1 and 50")
+	}
+	// Кеш похожих постов не сбрасывается, поэтому без ttl (0 в Redis) он бы не обновлялся никогда
+	if c.Posts.RelatedCacheTTL <= 0 {
+		return fmt.Errorf("posts.related_cache_ttl must be positive")
+	}
+	if c.Posts.PreviewLength <= 0 {
+		return fmt.Errorf("posts.preview_length must be positive")
+	}
+
 	return nil
 }
@@ -10,19 +10,22 @@ import (
 	"go.uber.org/zap"
 )
 
-func NewMongoCLient(parent context.Context, logger *zap.Logger, host, port string) (*mongo.Client, error) {
+// NewMongoCLient подключается к Mongo; extra - дополнительные опции клиента (например, мониторы метрик)
+func NewMongoCLient(parent context.Context, logger *zap.Logger, host, port string, extra ...*options.ClientOptions) (*mongo.Client, error) {
 
 	ctx, cancel := context.WithTimeout(parent, 5*time.Second)
 	defer cancel()
 
 	uri := fmt.Sprintf("mongodb://%s:%s", host, port)
 
-	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	opts := append([]*options.ClientOptions{options.Client().ApplyURI(uri)}, extra...)
+	client, err := mongo.Connect(ctx, opts...)
 	if err != nil {
 		return nil, err
 	}
 
 	if err := client.Ping(ctx, nil); err != nil {
+		_ = client.Disconnect(context.Background())
 		return nil, err
 	}
 
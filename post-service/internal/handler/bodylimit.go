@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BodyLimit ограничивает размер тела запроса, чтобы огромный JSON поста или комментария не съел
+// память при bind. Для multipart действует отдельный maxMultipartBytes: сейчас таких маршрутов нет,
+// и лимит держит крупные формы в тех же рамках, что и JSON. Лимит <= 0 - без ограничения.
+// Заявленный Content-Length сверх лимита отклоняется сразу с 413; тело без длины (chunked)
+// обрезается MaxBytesReader, и хендлер узнает об этом по IsBodyTooLarge.
+func BodyLimit(maxBytes, maxMultipartBytes int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit := maxBytes
+		if strings.HasPrefix(c.ContentType(), "multipart/") {
+			limit = maxMultipartBytes
+		}
+
+		if limit <= 0 || c.Request.Body == nil || c.Request.Body == http.NoBody {
+			c.Next()
+			return
+		}
+
+		if c.Request.ContentLength > limit {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": "request body too large"})
+			return
+		}
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		c.Next()
+	}
+}
+
+// IsBodyTooLarge - bind упал, потому что тело превысило лимит BodyLimit
+func IsBodyTooLarge(err error) bool {
+	var maxBytesErr *http.MaxBytesError
+	return errors.As(err, &maxBytesErr)
+}
@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBodyLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(BodyLimit(10, 100))
+	r.POST("/", func(c *gin.Context) {
+		if _, err := io.ReadAll(c.Request.Body); err != nil {
+			if IsBodyTooLarge(err) {
+				c.Status(http.StatusRequestEntityTooLarge)
+				return
+			}
+			c.Status(http.StatusBadRequest)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	do := func(body, contentType string, chunked bool) int {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		if chunked {
+			// Без Content-Length лимит срабатывает уже при чтении тела
+			req.ContentLength = -1
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	large := strings.Repeat("x", 50)
+
+	assert.Equal(t, http.StatusOK, do(`{"a":1}`, "application/json", false))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, do(large, "application/json", false))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, do(large, "application/json", true))
+
+	// Multipart получает свой, больший лимит
+	assert.Equal(t, http.StatusOK, do(large, "multipart/form-data; boundary=x", false))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, do(strings.Repeat("x", 150), "multipart/form-data; boundary=x", false))
+}
@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/repository"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/service"
+	"go.uber.org/zap"
+)
+
+type BookmarkHandler struct {
+	service service.BookmarkService
+	limits  PaginationLimits
+	logger  *zap.Logger
+}
+
+func NewBookmarkHandler(s service.BookmarkService, limits PaginationLimits, logger *zap.Logger) *BookmarkHandler {
+	return &BookmarkHandler{service: s, limits: limits, logger: logger}
+}
+
+// POST /posts/:id/bookmark — авторизованный пользователь. Повторная закладка - тот же 204
+func (h *BookmarkHandler) Add(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	postID, ok := parseObjectIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	err := h.service.Add(c.Request.Context(), postID.Hex(), userID)
+	switch {
+	case err == nil:
+		c.Status(http.StatusNoContent)
+	case AbortIfTimeout(c, err):
+	case errors.Is(err, repository.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "post not found"})
+	default:
+		h.logger.Error("failed to add bookmark", zap.String("post_id", postID.Hex()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+}
+
+// DELETE /posts/:id/bookmark — авторизованный пользователь. Закладки не было - тоже 204
+func (h *BookmarkHandler) Remove(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	postID, ok := parseObjectIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.service.Remove(c.Request.Context(), postID.Hex(), userID); err != nil {
+		if AbortIfTimeout(c, err) {
+			return
+		}
+		h.logger.Error("failed to remove bookmark", zap.String("post_id", postID.Hex()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GET /user/bookmarks?limit=&offset= — свои закладки, недавно добавленные первыми, с авторами постов;
+// author == null, если auth-service не ответил
+func (h *BookmarkHandler) List(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	limit, offset, ok := parsePagination(c, h.limits)
+	if !ok {
+		return
+	}
+
+	page, err := h.service.List(c.Request.Context(), userID, int64(limit), int64(offset))
+	if err != nil {
+		if AbortIfTimeout(c, err) {
+			return
+		}
+		h.logger.Error("failed to list bookmarks", zap.String("user_id", userID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/model"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// bookmarkService записывает аргументы вызовов и отдает заранее заданную ошибку
+type bookmarkService struct {
+	err       error
+	called    string
+	gotUser   string
+	gotLimit  int64
+	gotOffset int64
+}
+
+func (s *bookmarkService) Add(ctx context.Context, postID, userID string) error {
+	s.called, s.gotUser = "Add", userID
+	return s.err
+}
+
+func (s *bookmarkService) Remove(ctx context.Context, postID, userID string) error {
+	s.called, s.gotUser = "Remove", userID
+	return s.err
+}
+
+func (s *bookmarkService) List(ctx context.Context, userID string, limit, offset int64) (*model.PaginatedBookmarks, error) {
+	s.called, s.gotUser, s.gotLimit, s.gotOffset = "List", userID, limit, offset
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &model.PaginatedBookmarks{
+		Items: []*model.BookmarkedPost{{Post: &model.Post{Title: "saved"}, Author: &model.Author{ID: "a1", Username: "alice"}}},
+		Total: 1, Limit: limit, Offset: offset,
+	}, nil
+}
+
+func TestBookmarkHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	const postID = "65f1a2b3c4d5e6f7a8b9c0d1"
+
+	do := func(svc *bookmarkService, userID, method, target string) *httptest.ResponseRecorder {
+		h := NewBookmarkHandler(svc, PaginationLimits{DefaultLimit: 20, MaxLimit: 100}, zap.NewNop())
+		r := gin.New()
+		auth := r.Group("", func(c *gin.Context) {
+			if userID != "" {
+				c.Set("userID", userID)
+			}
+		})
+		auth.POST("/posts/:id/bookmark", h.Add)
+		auth.DELETE("/posts/:id/bookmark", h.Remove)
+		auth.GET("/user/bookmarks", h.List)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(method, target, nil))
+		return w
+	}
+
+	t.Run("Add", func(t *testing.T) {
+		svc := &bookmarkService{}
+		w := do(svc, "u1", http.MethodPost, "/posts/"+postID+"/bookmark")
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, "Add", svc.called)
+		assert.Equal(t, "u1", svc.gotUser)
+	})
+
+	t.Run("Add to a missing post", func(t *testing.T) {
+		svc := &bookmarkService{err: repository.ErrNotFound}
+		assert.Equal(t, http.StatusNotFound, do(svc, "u1", http.MethodPost, "/posts/"+postID+"/bookmark").Code)
+	})
+
+	t.Run("Remove", func(t *testing.T) {
+		svc := &bookmarkService{}
+		assert.Equal(t, http.StatusNoContent, do(svc, "u1", http.MethodDelete, "/posts/"+postID+"/bookmark").Code)
+		assert.Equal(t, "Remove", svc.called)
+	})
+
+	t.Run("List", func(t *testing.T) {
+		svc := &bookmarkService{}
+		w := do(svc, "u1", http.MethodGet, "/user/bookmarks?limit=5&offset=10")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"author":{"id":"a1","username":"alice"}`)
+		assert.Equal(t, int64(5), svc.gotLimit)
+		assert.Equal(t, int64(10), svc.gotOffset)
+	})
+
+	t.Run("Storage error", func(t *testing.T) {
+		svc := &bookmarkService{err: errors.New("boom")}
+		assert.Equal(t, http.StatusInternalServerError, do(svc, "u1", http.MethodGet, "/user/bookmarks").Code)
+	})
+
+	t.Run("Anonymous", func(t *testing.T) {
+		svc := &bookmarkService{}
+		assert.Equal(t, http.StatusUnauthorized, do(svc, "", http.MethodPost, "/posts/"+postID+"/bookmark").Code)
+		assert.Equal(t, http.StatusUnauthorized, do(svc, "", http.MethodGet, "/user/bookmarks").Code)
+		assert.Empty(t, svc.called)
+	})
+
+	t.Run("Malformed post id", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, do(&bookmarkService{}, "u1", http.MethodDelete, "/posts/p1/bookmark").Code)
+	})
+}
@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/repository"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/service"
+	"go.uber.org/zap"
+)
+
+type CommentHandler struct {
+	service service.CommentService
+	logger  *zap.Logger
+}
+
+func NewCommentHandler(s service.CommentService, logger *zap.Logger) *CommentHandler {
+	return &CommentHandler{service: s, logger: logger}
+}
+
+type createCommentRequest struct {
+	Content string `json:"content"`
+}
+
+// POST /posts/:id/comments — авторизованный пользователь, пост должен быть ему виден.
+// В ответе mentions - id пользователей, упомянутых через @username
+func (h *CommentHandler) Create(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	postID, ok := parseObjectIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req createCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	comment, err := h.service.Create(c.Request.Context(), postID.Hex(), userID, req.Content, c.GetString("role") == RoleAdmin)
+	switch {
+	case err == nil:
+		c.JSON(http.StatusCreated, gin.H{
+			"id":         comment.ID.Hex(),
+			"content":    comment.Content,
+			"mentions":   comment.Mentions,
+			"created_at": comment.CreatedAt,
+		})
+	case AbortIfTimeout(c, err):
+	case respondInvalidPost(c, err):
+	case errors.Is(err, repository.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "post not found"})
+	default:
+		h.logger.Error("failed to create comment", zap.String("post_id", postID.Hex()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+}
@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/model"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/repository"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/sanitize"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// commentService записывает аргументы вызова и отдает заранее заданную ошибку
+type commentService struct {
+	err        error
+	gotAuthor  string
+	gotContent string
+	gotAdmin   bool
+}
+
+func (s *commentService) Create(ctx context.Context, postID, authorID, content string, isAdmin bool) (*model.Comment, error) {
+	s.gotAuthor, s.gotContent, s.gotAdmin = authorID, content, isAdmin
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &model.Comment{ID: primitive.NewObjectID(), AuthorID: authorID, Content: content, Mentions: []string{"alice-id"}}, nil
+}
+
+func TestCommentHandler_Create(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	const postID = "65f1a2b3c4d5e6f7a8b9c0d1"
+
+	do := func(svc *commentService, userID, role, target, body string) *httptest.ResponseRecorder {
+		h := NewCommentHandler(svc, zap.NewNop())
+		r := gin.New()
+		r.POST("/posts/:id/comments", func(c *gin.Context) {
+			if userID != "" {
+				c.Set("userID", userID)
+				c.Set("role", role)
+			}
+		}, h.Create)
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		return w
+	}
+	target := "/posts/" + postID + "/comments"
+
+	t.Run("Created with mentions", func(t *testing.T) {
+		svc := &commentService{}
+		w := do(svc, "u1", "", target, `{"content":"hi @alice"}`)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Contains(t, w.Body.String(), `"mentions":["alice-id"]`)
+		assert.Equal(t, "u1", svc.gotAuthor)
+		assert.Equal(t, "hi @alice", svc.gotContent)
+		assert.False(t, svc.gotAdmin)
+	})
+
+	t.Run("Admin role is passed through", func(t *testing.T) {
+		svc := &commentService{}
+		do(svc, "admin-1", RoleAdmin, target, `{"content":"hi"}`)
+		assert.True(t, svc.gotAdmin)
+	})
+
+	t.Run("Unauthorized", func(t *testing.T) {
+		w := do(&commentService{}, "", "", target, `{"content":"hi"}`)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("Invalid post id", func(t *testing.T) {
+		w := do(&commentService{}, "u1", "", "/posts/nope/comments", `{"content":"hi"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Invalid body", func(t *testing.T) {
+		w := do(&commentService{}, "u1", "", target, `{`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Empty content", func(t *testing.T) {
+		w := do(&commentService{err: sanitize.ErrEmptyContent}, "u1", "", target, `{"content":""}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `"content"`)
+	})
+
+	t.Run("Post not found", func(t *testing.T) {
+		w := do(&commentService{err: repository.ErrNotFound}, "u1", "", target, `{"content":"hi"}`)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Ошибки отдаются в том же формате, что и в auth-service, - клиент разбирает ответы обоих
+// сервисов одинаково: {"error":"..."}, а для ошибок валидации
+// {"error":"validation failed","details":"...","errors":[{"field":"...","rule":"..."}]}.
+// Сервисы собираются как отдельные модули, поэтому контракт продублирован, а не вынесен в общий пакет.
+
+// FieldError - поле, не прошедшее проверку; повторяет model.FieldError из auth-service
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message,omitempty"`
+}
+
+// respondValidationError - 400 с разбивкой по полям, как respondValidationError в auth-service
+func respondValidationError(c *gin.Context, err error, fields ...FieldError) {
+	if fields == nil {
+		fields = []FieldError{}
+	}
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error":   "validation failed",
+		"details": err.Error(),
+		"errors":  fields,
+	})
+}
+
+// AbortIfTimeout отвечает 504, если хранилище не уложилось в дедлайн операции
+// (mongo.op_timeout в репозитории). Возвращает true, если ответ уже отправлен.
+func AbortIfTimeout(c *gin.Context, err error) bool {
+	if !errors.Is(err, context.DeadlineExceeded) && !mongo.IsTimeout(err) {
+		return false
+	}
+	c.AbortWithStatusJSON(http.StatusGatewayTimeout, gin.H{"error": "storage timeout"})
+	return true
+}
@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAbortIfTimeout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cases := []struct {
+		name    string
+		err     error
+		aborted bool
+	}{
+		{"deadline", fmt.Errorf("find post: %w", context.DeadlineExceeded), true},
+		{"other error", errors.New("boom"), false},
+		{"canceled", context.Canceled, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+
+			assert.Equal(t, tc.aborted, AbortIfTimeout(c, tc.err))
+			if tc.aborted {
+				assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+				assert.Contains(t, w.Body.String(), "storage timeout")
+			}
+		})
+	}
+}
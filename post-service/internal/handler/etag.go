@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ServeWithETag отдает body с ETag, а если клиент прислал совпадающий
+// If-None-Match - только 304 без тела
+func ServeWithETag(c *gin.Context, etag string, body []byte) {
+	c.Header("ETag", etag)
+	// Без этого прокси может отдать одному пользователю ответ для другого
+	c.Header("Cache-Control", "private, no-cache")
+
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+}
+
+// etagMatches - слабое сравнение из RFC 9110: W/ не учитывается, поддерживаются список и "*"
+func etagMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == etag {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServeWithETag(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	const etag = `"v1"`
+	body := []byte(`{"Title":"hello"}`)
+
+	do := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		r := gin.New()
+		r.GET("/post", func(c *gin.Context) { ServeWithETag(c, etag, body) })
+
+		req := httptest.NewRequest(http.MethodGet, "/post", nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("No If-None-Match", func(t *testing.T) {
+		w := do("")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, etag, w.Header().Get("ETag"))
+		assert.Equal(t, "private, no-cache", w.Header().Get("Cache-Control"))
+		assert.JSONEq(t, string(body), w.Body.String())
+	})
+
+	t.Run("Matching ETag is 304 without body", func(t *testing.T) {
+		for _, header := range []string{`"v1"`, `W/"v1"`, `"v0", "v1"`, `*`} {
+			w := do(header)
+			assert.Equal(t, http.StatusNotModified, w.Code, header)
+			assert.Equal(t, etag, w.Header().Get("ETag"), header)
+			assert.Empty(t, w.Body.String(), header)
+		}
+	})
+
+	t.Run("Mismatched ETag gets the body", func(t *testing.T) {
+		for _, header := range []string{`"v0"`, `"v0", W/"v2"`, `v1`} {
+			w := do(header)
+			assert.Equal(t, http.StatusOK, w.Code, header)
+			assert.Equal(t, etag, w.Header().Get("ETag"), header)
+			assert.JSONEq(t, string(body), w.Body.String(), header)
+		}
+	})
+}
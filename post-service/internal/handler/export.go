@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/model"
+	"go.uber.org/zap"
+)
+
+// mimeNDJSON - один JSON-объект на строку
+const mimeNDJSON = "application/x-ndjson"
+
+// GET /user/posts/export — авторизованный пользователь выгружает все свои посты (с черновиками)
+// файлом ND-JSON. Посты идут из курсора Mongo и сразу сбрасываются клиенту.
+// После первой строки статус уже отправлен: ошибка только логируется и обрывает поток.
+func (h *PostHandler) ExportMine(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	enc := json.NewEncoder(c.Writer)
+	started := false
+	start := func() {
+		if !started {
+			c.Header("Content-Type", mimeNDJSON)
+			c.Header("Content-Disposition", `attachment; filename="posts.ndjson"`)
+			c.Header("Cache-Control", "no-store")
+			c.Status(http.StatusOK)
+			c.Writer.WriteHeaderNow()
+			started = true
+		}
+	}
+
+	err := h.service.ExportByAuthor(c.Request.Context(), userID, func(post *model.Post) error {
+		start()
+		if err := enc.Encode(post); err != nil {
+			return err
+		}
+		c.Writer.Flush()
+		return nil
+	})
+	if err != nil {
+		if !started {
+			if AbortIfTimeout(c, err) {
+				return
+			}
+			h.logger.Error("failed to export posts", zap.String("user_id", userID), zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+			return
+		}
+		h.logger.Warn("posts export interrupted", zap.String("user_id", userID), zap.Error(err))
+		return
+	}
+
+	// Постов нет - пустой файл
+	start()
+}
@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/model"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/service"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// exportService - PostService, в котором реализован только ExportByAuthor
+type exportService struct {
+	service.PostService
+	posts     []*model.Post
+	err       error
+	gotAuthor string
+}
+
+func (s *exportService) ExportByAuthor(ctx context.Context, authorID string, fn func(*model.Post) error) error {
+	s.gotAuthor = authorID
+	for _, p := range s.posts {
+		if err := fn(p); err != nil {
+			return err
+		}
+	}
+	return s.err
+}
+
+func TestPostHandler_ExportMine(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	do := func(svc *exportService, userID string) *httptest.ResponseRecorder {
+		h := NewPostHandler(svc, zap.NewNop())
+		r := gin.New()
+		r.GET("/user/posts/export", func(c *gin.Context) {
+			if userID != "" {
+				c.Set("userID", userID)
+			}
+		}, h.ExportMine)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/user/posts/export", nil))
+		return w
+	}
+
+	t.Run("Streams own posts including drafts", func(t *testing.T) {
+		svc := &exportService{posts: []*model.Post{
+			{AuthorID: "u1", Title: "published", Status: model.PostStatusPublished},
+			{AuthorID: "u1", Title: "draft", Status: model.PostStatusDraft},
+		}}
+		w := do(svc, "u1")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "u1", svc.gotAuthor)
+		assert.Equal(t, mimeNDJSON, w.Header().Get("Content-Type"))
+		assert.Equal(t, `attachment; filename="posts.ndjson"`, w.Header().Get("Content-Disposition"))
+
+		lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+		if assert.Len(t, lines, 2) {
+			assert.Contains(t, lines[0], `"Title":"published"`)
+			assert.Contains(t, lines[1], `"Status":"draft"`)
+		}
+	})
+
+	t.Run("No posts - empty file", func(t *testing.T) {
+		w := do(&exportService{}, "u1")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Body.String())
+	})
+
+	t.Run("Error before the first post", func(t *testing.T) {
+		w := do(&exportService{err: errors.New("mongo down")}, "u1")
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+
+	t.Run("Anonymous", func(t *testing.T) {
+		svc := &exportService{}
+		assert.Equal(t, http.StatusUnauthorized, do(svc, "").Code)
+		assert.Empty(t, svc.gotAuthor)
+	})
+}
@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/model"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/repository"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/service"
+	"go.uber.org/zap"
+)
+
+type FeedHandler struct {
+	service service.FeedService
+	limits  PaginationLimits
+	logger  *zap.Logger
+}
+
+func NewFeedHandler(s service.FeedService, limits PaginationLimits, logger *zap.Logger) *FeedHandler {
+	return &FeedHandler{service: s, limits: limits, logger: logger}
+}
+
+// POST /users/:id/follow — авторизованный пользователь подписывается на автора.
+// Повторная подписка - тот же 204; автора, которого не знает auth-service, - 404
+func (h *FeedHandler) Follow(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	authorID, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	err := h.service.Follow(c.Request.Context(), userID, authorID.String())
+	switch {
+	case err == nil:
+		c.Status(http.StatusNoContent)
+	case AbortIfTimeout(c, err):
+	case errors.Is(err, repository.ErrSelfFollow):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "cannot follow yourself"})
+	case errors.Is(err, repository.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+	default:
+		h.logger.Error("failed to follow", zap.String("author_id", authorID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+}
+
+// DELETE /users/:id/follow — отписка. Подписки не было - тоже 204
+func (h *FeedHandler) Unfollow(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	authorID, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.service.Unfollow(c.Request.Context(), userID, authorID.String()); err != nil {
+		if AbortIfTimeout(c, err) {
+			return
+		}
+		h.logger.Error("failed to unfollow", zap.String("author_id", authorID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GET /feed?limit=&cursor= — опубликованные посты авторов из подписок, новые первыми.
+// Следующая страница - cursor из next_cursor; пустой next_cursor - постов больше нет
+func (h *FeedHandler) Feed(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	limit, ok := parseLimit(c, h.limits)
+	if !ok {
+		return
+	}
+
+	page, err := h.service.GetFeed(c.Request.Context(), userID, c.Query("cursor"), int64(limit))
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, page)
+	case AbortIfTimeout(c, err):
+	case errors.Is(err, model.ErrInvalidCursor):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid cursor"})
+	default:
+		h.logger.Error("failed to get feed", zap.String("user_id", userID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+}
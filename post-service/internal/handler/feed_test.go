@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/model"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// feedService записывает аргументы вызовов и отдает заранее заданную ошибку
+type feedService struct {
+	err       error
+	called    string
+	gotUser   string
+	gotAuthor string
+	gotCursor string
+	gotLimit  int64
+}
+
+func (s *feedService) Follow(ctx context.Context, followerID, authorID string) error {
+	s.called, s.gotUser, s.gotAuthor = "Follow", followerID, authorID
+	return s.err
+}
+
+func (s *feedService) Unfollow(ctx context.Context, followerID, authorID string) error {
+	s.called, s.gotUser, s.gotAuthor = "Unfollow", followerID, authorID
+	return s.err
+}
+
+func (s *feedService) GetFeed(ctx context.Context, userID, cursor string, limit int64) (*model.FeedPage, error) {
+	s.called, s.gotUser, s.gotCursor, s.gotLimit = "GetFeed", userID, cursor, limit
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &model.FeedPage{Items: []*model.Post{{Title: "fresh"}}}, nil
+}
+
+func (s *feedService) OnPostPublished(ctx context.Context, authorID string) error {
+	return nil
+}
+
+func TestFeedHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	const authorID = "7c9e6679-7425-40de-944b-e07fc1f90ae7"
+
+	do := func(svc *feedService, userID, method, target string) *httptest.ResponseRecorder {
+		h := NewFeedHandler(svc, PaginationLimits{DefaultLimit: 20, MaxLimit: 100}, zap.NewNop())
+		r := gin.New()
+		auth := r.Group("", func(c *gin.Context) {
+			if userID != "" {
+				c.Set("userID", userID)
+			}
+		})
+		auth.POST("/users/:id/follow", h.Follow)
+		auth.DELETE("/users/:id/follow", h.Unfollow)
+		auth.GET("/feed", h.Feed)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(method, target, nil))
+		return w
+	}
+
+	t.Run("Follow", func(t *testing.T) {
+		svc := &feedService{}
+		w := do(svc, "u1", http.MethodPost, "/users/"+authorID+"/follow")
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, "Follow", svc.called)
+		assert.Equal(t, "u1", svc.gotUser)
+		assert.Equal(t, authorID, svc.gotAuthor)
+	})
+
+	t.Run("Follow with a malformed id", func(t *testing.T) {
+		svc := &feedService{}
+		assert.Equal(t, http.StatusBadRequest, do(svc, "u1", http.MethodPost, "/users/bad/follow").Code)
+		assert.Empty(t, svc.called)
+	})
+
+	t.Run("Follow yourself", func(t *testing.T) {
+		svc := &feedService{err: repository.ErrSelfFollow}
+		assert.Equal(t, http.StatusBadRequest, do(svc, "u1", http.MethodPost, "/users/"+authorID+"/follow").Code)
+	})
+
+	t.Run("Follow an unknown user", func(t *testing.T) {
+		svc := &feedService{err: repository.ErrNotFound}
+		assert.Equal(t, http.StatusNotFound, do(svc, "u1", http.MethodPost, "/users/"+authorID+"/follow").Code)
+	})
+
+	t.Run("Unfollow", func(t *testing.T) {
+		svc := &feedService{}
+		assert.Equal(t, http.StatusNoContent, do(svc, "u1", http.MethodDelete, "/users/"+authorID+"/follow").Code)
+		assert.Equal(t, "Unfollow", svc.called)
+	})
+
+	t.Run("Feed uses the default limit and passes the cursor", func(t *testing.T) {
+		svc := &feedService{}
+		w := do(svc, "u1", http.MethodGet, "/feed?cursor=abc")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "fresh")
+		assert.Equal(t, "abc", svc.gotCursor)
+		assert.Equal(t, int64(20), svc.gotLimit)
+	})
+
+	t.Run("Feed clamps the limit", func(t *testing.T) {
+		svc := &feedService{}
+		do(svc, "u1", http.MethodGet, "/feed?limit=1000")
+		assert.Equal(t, int64(100), svc.gotLimit)
+	})
+
+	t.Run("Feed with an invalid cursor", func(t *testing.T) {
+		svc := &feedService{err: model.ErrInvalidCursor}
+		assert.Equal(t, http.StatusBadRequest, do(svc, "u1", http.MethodGet, "/feed?cursor=zzz").Code)
+	})
+
+	t.Run("Feed fails", func(t *testing.T) {
+		svc := &feedService{err: errors.New("mongo down")}
+		assert.Equal(t, http.StatusInternalServerError, do(svc, "u1", http.MethodGet, "/feed").Code)
+	})
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		svc := &feedService{}
+		assert.Equal(t, http.StatusUnauthorized, do(svc, "", http.MethodGet, "/feed").Code)
+		assert.Equal(t, http.StatusUnauthorized, do(svc, "", http.MethodPost, "/users/"+authorID+"/follow").Code)
+		assert.Empty(t, svc.called)
+	})
+}
@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// healthPingTimeout - /health не должен висеть вместе с Redis
+const healthPingTimeout = time.Second
+
+// Pinger - необязательная зависимость, состояние которой показывает /health (cache.Conn)
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Health - liveness: 200, пока процесс жив. Без Redis сервис работает из Mongo,
+// поэтому его недоступность видна как "redis":"degraded", но код ответа не меняет
+func Health(redis Pinger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), healthPingTimeout)
+		defer cancel()
+
+		redisStatus := "ok"
+		if err := redis.Ping(ctx); err != nil {
+			redisStatus = "degraded"
+		}
+
+		c.JSON(http.StatusOK, gin.H{"status": "ok", "redis": redisStatus})
+	}
+}
@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/cache"
+	"github.com/stretchr/testify/assert"
+)
+
+type pingerFunc func(ctx context.Context) error
+
+func (f pingerFunc) Ping(ctx context.Context) error { return f(ctx) }
+
+func TestHealth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	do := func(redis Pinger) *httptest.ResponseRecorder {
+		r := gin.New()
+		r.GET("/health", Health(redis))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+		return w
+	}
+
+	t.Run("Redis available", func(t *testing.T) {
+		w := do(pingerFunc(func(ctx context.Context) error { return nil }))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"status":"ok","redis":"ok"}`, w.Body.String())
+	})
+
+	t.Run("Redis not connected - degraded, still live", func(t *testing.T) {
+		w := do(cache.NewConn(nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"status":"ok","redis":"degraded"}`, w.Body.String())
+	})
+}
@@ -2,9 +2,15 @@ package handler
 
 import (
 	//"fmt"
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/authclient"
 	"go.uber.org/zap"
 )
 
@@ -45,3 +51,87 @@ func ZapLogger(logger *zap.Logger) gin.HandlerFunc {
 		}
 	}
 }
+
+// RequireRole пропускает запрос, только если роль из токена входит в roles (как в auth-service).
+// Ставится после auth middleware, которое кладет "role" в контекст.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("role")
+		if !slices.Contains(roles, role) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// TokenIntrospector проверяет токен в auth-service (см. authclient.Introspector)
+type TokenIntrospector interface {
+	Introspect(ctx context.Context, token string) (*authclient.Identity, error)
+}
+
+// Authenticate пропускает только запросы с действующим токеном и кладет в контекст
+// "userID", "username" и "role" - как AuthMiddleware в auth-service.
+// Недоступный auth-service - 503: без проверки токена пускать нельзя.
+func Authenticate(introspector TokenIntrospector, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := tokenFromRequest(c)
+		if token == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "authorization required"})
+			return
+		}
+
+		identity, err := introspector.Introspect(c.Request.Context(), token)
+		if errors.Is(err, authclient.ErrInactiveToken) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+			return
+		}
+		if err != nil {
+			logger.Error("token introspection failed", zap.Error(err))
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "auth service unavailable"})
+			return
+		}
+
+		setIdentity(c, identity)
+		c.Next()
+	}
+}
+
+// OptionalAuth - для публичных маршрутов, где автор и админ видят больше (черновики):
+// с действующим токеном кладет пользователя в контекст, иначе запрос идет анонимным
+func OptionalAuth(introspector TokenIntrospector, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token := tokenFromRequest(c); token != "" {
+			identity, err := introspector.Introspect(c.Request.Context(), token)
+			if err == nil {
+				setIdentity(c, identity)
+			} else if !errors.Is(err, authclient.ErrInactiveToken) {
+				logger.Warn("token introspection failed, serving anonymously", zap.Error(err))
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// tokenFromRequest - Authorization: Bearer <token>, а без заголовка - cookie token,
+// которую ставит auth-service при входе; пустая строка - токена нет
+func tokenFromRequest(c *gin.Context) string {
+	if header := c.GetHeader("Authorization"); header != "" {
+		token, _ := strings.CutPrefix(header, "Bearer ")
+		if token == header {
+			return ""
+		}
+		return token
+	}
+
+	token, _ := c.Cookie("token")
+	return token
+}
+
+func setIdentity(c *gin.Context, identity *authclient.Identity) {
+	c.Set("userID", identity.UserID)
+	c.Set("username", identity.Username)
+	c.Set("role", identity.Role)
+}
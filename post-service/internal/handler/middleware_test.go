@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/authclient"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// introspector знает один действующий токен "good"; down - auth-service недоступен
+type introspector struct {
+	down bool
+}
+
+func (i introspector) Introspect(ctx context.Context, token string) (*authclient.Identity, error) {
+	if i.down {
+		return nil, errors.New("connection refused")
+	}
+	if token != "good" {
+		return nil, authclient.ErrInactiveToken
+	}
+	return &authclient.Identity{UserID: "u1", Username: "alice", Role: RoleAdmin}, nil
+}
+
+func TestAuthenticate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	do := func(middleware gin.HandlerFunc, setup func(*http.Request)) *httptest.ResponseRecorder {
+		r := gin.New()
+		r.GET("/", middleware, func(c *gin.Context) {
+			c.String(http.StatusOK, c.GetString("userID")+"|"+c.GetString("role"))
+		})
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if setup != nil {
+			setup(req)
+		}
+		r.ServeHTTP(w, req)
+		return w
+	}
+	bearer := func(token string) func(*http.Request) {
+		return func(req *http.Request) { req.Header.Set("Authorization", "Bearer "+token) }
+	}
+
+	required := Authenticate(introspector{}, zap.NewNop())
+	optional := OptionalAuth(introspector{}, zap.NewNop())
+
+	t.Run("Bearer token", func(t *testing.T) {
+		w := do(required, bearer("good"))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "u1|admin", w.Body.String())
+	})
+
+	t.Run("Cookie token", func(t *testing.T) {
+		w := do(required, func(req *http.Request) { req.AddCookie(&http.Cookie{Name: "token", Value: "good"}) })
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Missing token", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, do(required, nil).Code)
+		assert.Equal(t, http.StatusUnauthorized, do(required, func(req *http.Request) {
+			req.Header.Set("Authorization", "Basic dTpw")
+		}).Code)
+	})
+
+	t.Run("Inactive token", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, do(required, bearer("expired")).Code)
+	})
+
+	t.Run("Auth service down", func(t *testing.T) {
+		w := do(Authenticate(introspector{down: true}, zap.NewNop()), bearer("good"))
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	})
+
+	t.Run("Optional auth sets the user", func(t *testing.T) {
+		w := do(optional, bearer("good"))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "u1|admin", w.Body.String())
+	})
+
+	t.Run("Optional auth serves anonymously", func(t *testing.T) {
+		for _, w := range []*httptest.ResponseRecorder{
+			do(optional, nil),
+			do(optional, bearer("expired")),
+			do(OptionalAuth(introspector{down: true}, zap.NewNop()), bearer("good")),
+		} {
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, "|", w.Body.String())
+		}
+	})
+}
@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/repository"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/service"
+	"go.uber.org/zap"
+)
+
+type ModerationHandler struct {
+	service service.ModerationService
+	limits  PaginationLimits
+	logger  *zap.Logger
+}
+
+func NewModerationHandler(s service.ModerationService, limits PaginationLimits, logger *zap.Logger) *ModerationHandler {
+	return &ModerationHandler{service: s, limits: limits, logger: logger}
+}
+
+type reportRequest struct {
+	Reason string `json:"reason"`
+}
+
+type resolveRequest struct {
+	HidePost bool `json:"hide_post"`
+}
+
+// POST /posts/:id/report — авторизованный пользователь жалуется на пост.
+// Повторная жалоба на тот же пост, пока первая не разобрана, - тот же 202 без дубля
+func (h *ModerationHandler) Report(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	postID, ok := parseObjectIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req reportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	err := h.service.Report(c.Request.Context(), postID.Hex(), userID, req.Reason)
+	switch {
+	case err == nil:
+		c.JSON(http.StatusAccepted, gin.H{"message": "report received"})
+	case AbortIfTimeout(c, err):
+	case errors.Is(err, service.ErrInvalidReportReason):
+		respondValidationError(c, err, FieldError{Field: "reason", Rule: "length"})
+	case errors.Is(err, repository.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "post not found"})
+	default:
+		h.logger.Error("failed to report post", zap.String("post_id", postID.Hex()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+}
+
+// GET /moderation/reports?limit=&offset= — только admin (RequireRole). Открытые жалобы,
+// старые первыми, вместе с постами; post == null, если пост уже удален
+func (h *ModerationHandler) ListReports(c *gin.Context) {
+	limit, offset, ok := parsePagination(c, h.limits)
+	if !ok {
+		return
+	}
+
+	page, err := h.service.ListOpenReports(c.Request.Context(), int64(limit), int64(offset))
+	if err != nil {
+		if AbortIfTimeout(c, err) {
+			return
+		}
+		h.logger.Error("failed to list reports", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// POST /moderation/reports/:id/resolve — только admin (RequireRole).
+// Тело необязательно: {"hide_post": true} заодно скрывает пост
+func (h *ModerationHandler) ResolveReport(c *gin.Context) {
+	reportID, ok := parseObjectIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req resolveRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+			return
+		}
+	}
+
+	report, err := h.service.Resolve(c.Request.Context(), reportID.Hex(), c.GetString("userID"), req.HidePost)
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, report)
+	case AbortIfTimeout(c, err):
+	case errors.Is(err, repository.ErrReportNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "open report not found"})
+	default:
+		h.logger.Error("failed to resolve report", zap.String("report_id", reportID.Hex()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+}
@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/model"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/repository"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/service"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// moderationService записывает аргументы вызовов и отдает заранее заданные ответы
+type moderationService struct {
+	err          error
+	page         *model.PaginatedReports
+	gotPostID    string
+	gotReporter  string
+	gotReason    string
+	gotLimit     int64
+	gotOffset    int64
+	gotResolver  string
+	gotHidePost  bool
+	resolveCalls int
+}
+
+func (s *moderationService) Report(ctx context.Context, postID, reporterID, reason string) error {
+	s.gotPostID, s.gotReporter, s.gotReason = postID, reporterID, reason
+	return s.err
+}
+
+func (s *moderationService) ListOpenReports(ctx context.Context, limit, offset int64) (*model.PaginatedReports, error) {
+	s.gotLimit, s.gotOffset = limit, offset
+	return s.page, s.err
+}
+
+func (s *moderationService) Resolve(ctx context.Context, reportID, resolverID string, hidePost bool) (*model.Report, error) {
+	s.resolveCalls++
+	s.gotResolver, s.gotHidePost = resolverID, hidePost
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &model.Report{Status: model.ReportStatusResolved, ResolvedBy: resolverID, PostHidden: hidePost}, nil
+}
+
+func TestModerationHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	const postID = "65f1a2b3c4d5e6f7a8b9c0d1"
+	const reportID = "65f1a2b3c4d5e6f7a8b9c0d2"
+
+	do := func(svc *moderationService, userID, role, method, target, body string) *httptest.ResponseRecorder {
+		h := NewModerationHandler(svc, PaginationLimits{DefaultLimit: 20, MaxLimit: 100}, zap.NewNop())
+		r := gin.New()
+		auth := r.Group("", func(c *gin.Context) {
+			if userID != "" {
+				c.Set("userID", userID)
+				c.Set("role", role)
+			}
+		})
+		auth.POST("/posts/:id/report", h.Report)
+		moderation := auth.Group("/moderation", RequireRole(RoleAdmin))
+		moderation.GET("/reports", h.ListReports)
+		moderation.POST("/reports/:id/resolve", h.ResolveReport)
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Report accepted", func(t *testing.T) {
+		svc := &moderationService{}
+		w := do(svc, "u1", "user", http.MethodPost, "/posts/"+postID+"/report", `{"reason":"spam"}`)
+
+		assert.Equal(t, http.StatusAccepted, w.Code)
+		assert.Equal(t, postID, svc.gotPostID)
+		assert.Equal(t, "u1", svc.gotReporter)
+		assert.Equal(t, "spam", svc.gotReason)
+	})
+
+	t.Run("Report requires authorization", func(t *testing.T) {
+		w := do(&moderationService{}, "", "", http.MethodPost, "/posts/"+postID+"/report", `{"reason":"spam"}`)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("Report with invalid reason", func(t *testing.T) {
+		svc := &moderationService{err: service.ErrInvalidReportReason}
+		w := do(svc, "u1", "user", http.MethodPost, "/posts/"+postID+"/report", `{"reason":""}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.JSONEq(t, `{"error":"validation failed","details":"report reason must be 1-500 characters","errors":[{"field":"reason","rule":"length"}]}`, w.Body.String())
+	})
+
+	t.Run("Report on a missing post", func(t *testing.T) {
+		svc := &moderationService{err: repository.ErrNotFound}
+		w := do(svc, "u1", "user", http.MethodPost, "/posts/"+postID+"/report", `{"reason":"spam"}`)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Queue is admin only", func(t *testing.T) {
+		w := do(&moderationService{}, "u1", "user", http.MethodGet, "/moderation/reports", "")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.JSONEq(t, `{"error":"forbidden"}`, w.Body.String())
+	})
+
+	t.Run("Queue with pagination", func(t *testing.T) {
+		svc := &moderationService{page: &model.PaginatedReports{Items: []*model.ReportWithPost{}, Total: 3, Limit: 2, Offset: 1}}
+		w := do(svc, "admin", RoleAdmin, http.MethodGet, "/moderation/reports?limit=2&offset=1", "")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"items":[],"total":3,"limit":2,"offset":1}`, w.Body.String())
+		assert.Equal(t, int64(2), svc.gotLimit)
+		assert.Equal(t, int64(1), svc.gotOffset)
+	})
+
+	t.Run("Resolve and hide the post", func(t *testing.T) {
+		svc := &moderationService{}
+		w := do(svc, "admin", RoleAdmin, http.MethodPost, "/moderation/reports/"+reportID+"/resolve", `{"hide_post":true}`)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "admin", svc.gotResolver)
+		assert.True(t, svc.gotHidePost)
+	})
+
+	t.Run("Resolve without body keeps the post", func(t *testing.T) {
+		svc := &moderationService{}
+		w := do(svc, "admin", RoleAdmin, http.MethodPost, "/moderation/reports/"+reportID+"/resolve", "")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.False(t, svc.gotHidePost)
+	})
+
+	t.Run("Resolve of a closed report", func(t *testing.T) {
+		svc := &moderationService{err: repository.ErrReportNotFound}
+		w := do(svc, "admin", RoleAdmin, http.MethodPost, "/moderation/reports/"+reportID+"/resolve", "")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Resolve by non-admin", func(t *testing.T) {
+		svc := &moderationService{}
+		w := do(svc, "u1", "user", http.MethodPost, "/moderation/reports/"+reportID+"/resolve", "")
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Zero(t, svc.resolveCalls)
+	})
+
+	t.Run("Storage error", func(t *testing.T) {
+		svc := &moderationService{err: errors.New("boom")}
+		w := do(svc, "admin", RoleAdmin, http.MethodGet, "/moderation/reports", "")
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
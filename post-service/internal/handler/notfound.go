@@ -0,0 +1,18 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NoRoute - engine.NoRoute: JSON вместо текстового "404 page not found" gin
+func NoRoute(c *gin.Context) {
+	c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+}
+
+// NoMethod - engine.NoMethod при HandleMethodNotAllowed = true: путь есть, но с другим методом.
+// Заголовок Allow со списком разрешенных методов gin выставляет сам до вызова хендлера.
+func NoMethod(c *gin.Context) {
+	c.JSON(http.StatusMethodNotAllowed, gin.H{"error": "method not allowed"})
+}
@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PaginationLimits - лимиты для всех списочных эндпоинтов (посты, комментарии, поиск, лента)
+type PaginationLimits struct {
+	DefaultLimit int
+	MaxLimit     int
+}
+
+// parsePagination читает ?limit=&offset=. limit прижимается к [1, MaxLimit],
+// без параметра берется DefaultLimit. Нечисловые значения и отрицательный offset -
+// 400, ответ уже записан, и хендлеру остается только выйти при ok == false.
+func parsePagination(c *gin.Context, limits PaginationLimits) (limit, offset int, ok bool) {
+	limit, ok = parseLimit(c, limits)
+	if !ok {
+		return 0, 0, false
+	}
+
+	if raw := c.Query("offset"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "offset must be an integer"})
+			return 0, 0, false
+		}
+		if parsed < 0 {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "offset must not be negative"})
+			return 0, 0, false
+		}
+		offset = parsed
+	}
+
+	return limit, offset, true
+}
+
+// parseLimit - только ?limit= из parsePagination, для списков с курсором вместо offset
+func parseLimit(c *gin.Context, limits PaginationLimits) (int, bool) {
+	limit := limits.DefaultLimit
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "limit must be an integer"})
+			return 0, false
+		}
+		limit = parsed
+	}
+	return min(max(limit, 1), limits.MaxLimit), true
+}
@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePagination(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	limits := PaginationLimits{DefaultLimit: 20, MaxLimit: 100}
+
+	tests := []struct {
+		name       string
+		query      string
+		wantOK     bool
+		wantLimit  int
+		wantOffset int
+	}{
+		{"Defaults", "", true, 20, 0},
+		{"Explicit Values", "?limit=5&offset=10", true, 5, 10},
+		{"Limit Clamped To Max", "?limit=1000", true, 100, 0},
+		{"Zero Limit Clamped To One", "?limit=0", true, 1, 0},
+		{"Negative Limit Clamped To One", "?limit=-5", true, 1, 0},
+		{"Non-Integer Limit", "?limit=abc", false, 0, 0},
+		{"Non-Integer Offset", "?offset=1.5", false, 0, 0},
+		{"Negative Offset", "?offset=-1", false, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/posts"+tt.query, nil)
+
+			limit, offset, ok := parsePagination(c, limits)
+
+			assert.Equal(t, tt.wantOK, ok)
+			if !tt.wantOK {
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				return
+			}
+			assert.Equal(t, tt.wantLimit, limit)
+			assert.Equal(t, tt.wantOffset, offset)
+		})
+	}
+}
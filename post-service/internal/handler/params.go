@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// parseUUIDParam - ID пользователя (UUID из auth-service) из параметра пути name.
+// На невалидном значении сам отвечает 400 {"error":"invalid <name> format"} и возвращает false
+func parseUUIDParam(c *gin.Context, name string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param(name))
+	if err != nil {
+		respondInvalidParam(c, name)
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// parseObjectIDParam - то же для ID постов (ObjectID в hex): битый ID - 400, а не 404 из репозитория
+func parseObjectIDParam(c *gin.Context, name string) (primitive.ObjectID, bool) {
+	id, err := primitive.ObjectIDFromHex(c.Param(name))
+	if err != nil {
+		respondInvalidParam(c, name)
+		return primitive.NilObjectID, false
+	}
+	return id, true
+}
+
+// respondInvalidParam - тот же ответ, что и в auth-service, чтобы клиент разбирал его одинаково
+func respondInvalidParam(c *gin.Context, name string) {
+	c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + name + " format"})
+}
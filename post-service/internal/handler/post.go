@@ -0,0 +1,402 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/model"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/repository"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/sanitize"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/service"
+	"go.uber.org/zap"
+)
+
+// RoleAdmin - роль из claims auth-service; auth middleware кладет ее в контекст как "role" рядом с "userID"
+const RoleAdmin = "admin"
+
+type PostHandler struct {
+	service service.PostService
+	logger  *zap.Logger
+}
+
+func NewPostHandler(s service.PostService, logger *zap.Logger) *PostHandler {
+	return &PostHandler{service: s, logger: logger}
+}
+
+var (
+	errTitleRequired = errors.New("title is required")
+	errInvalidStatus = errors.New("status must be draft or published")
+)
+
+type createPostRequest struct {
+	Title   string   `json:"title"`
+	Content string   `json:"content"`
+	Topic   string   `json:"topic"`
+	Tags    []string `json:"tags"`
+	// Status - draft или published (по умолчанию)
+	Status model.PostStatus `json:"status"`
+}
+
+// POST /posts — авторизованный пользователь; число постов в час ограничено (limits.posts_per_hour,
+// 429 с Retry-After). Slug строится из заголовка
+func (h *PostHandler) Create(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req createPostRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+	if strings.TrimSpace(req.Title) == "" {
+		respondValidationError(c, errTitleRequired, FieldError{Field: "title", Rule: "required"})
+		return
+	}
+	switch req.Status {
+	case "":
+		req.Status = model.PostStatusPublished
+	case model.PostStatusDraft, model.PostStatusPublished:
+	default:
+		respondValidationError(c, errInvalidStatus, FieldError{Field: "status", Rule: "oneof"})
+		return
+	}
+
+	post := &model.Post{
+		AuthorID: userID,
+		Title:    req.Title,
+		Content:  req.Content,
+		Topic:    req.Topic,
+		Tags:     req.Tags,
+		Status:   req.Status,
+	}
+
+	err := h.service.Create(c.Request.Context(), post)
+	switch {
+	case err == nil:
+		c.JSON(http.StatusCreated, gin.H{"id": post.ID.Hex(), "slug": post.Slug, "status": post.Status})
+	case respondInvalidPost(c, err):
+	default:
+		h.respondPostError(c, err, "failed to create post")
+	}
+}
+
+var errNothingToUpdate = errors.New("nothing to update")
+
+// updatePostRequest - отсутствующее поле не меняется
+type updatePostRequest struct {
+	Title   *string   `json:"title"`
+	Content *string   `json:"content"`
+	Topic   *string   `json:"topic"`
+	Tags    *[]string `json:"tags"`
+	// RegenerateSlug - построить slug из нового заголовка; старый продолжит работать через редирект
+	RegenerateSlug bool `json:"regenerate_slug"`
+}
+
+// PATCH /posts/:id — автор или администратор. Меняет только переданные поля;
+// прежние заголовок и тело сохраняются в истории правок (GET /posts/:id/revisions).
+// regenerate_slug=true пересобирает slug из заголовка, старый отдает 301 на новый
+func (h *PostHandler) Update(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	postID, ok := parseObjectIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req updatePostRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+	if req.Title == nil && req.Content == nil && req.Topic == nil && req.Tags == nil && !req.RegenerateSlug {
+		respondValidationError(c, errNothingToUpdate)
+		return
+	}
+	if req.Title != nil && strings.TrimSpace(*req.Title) == "" {
+		respondValidationError(c, errTitleRequired, FieldError{Field: "title", Rule: "required"})
+		return
+	}
+
+	post, err := h.service.Edit(c.Request.Context(), postID.Hex(), userID, c.GetString("role") == RoleAdmin, service.PostChanges{
+		Title:   req.Title,
+		Content: req.Content,
+		Topic:   req.Topic,
+		Tags:    req.Tags,
+
+		RegenerateSlug: req.RegenerateSlug,
+	})
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, post)
+	case respondInvalidPost(c, err):
+	default:
+		h.respondPostError(c, err, "failed to update post")
+	}
+}
+
+// respondInvalidPost - 400/409 на содержимое поста, отклоненное при сохранении.
+// Возвращает true, если ответ уже отправлен
+func respondInvalidPost(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, sanitize.ErrEmptyContent):
+		respondValidationError(c, err, FieldError{Field: "content", Rule: "required"})
+	case errors.Is(err, sanitize.ErrContentTooLong):
+		respondValidationError(c, err, FieldError{Field: "content", Rule: "max"})
+	case errors.Is(err, sanitize.ErrTooManyTags):
+		respondValidationError(c, err, FieldError{Field: "tags", Rule: "max"})
+	case errors.Is(err, sanitize.ErrInvalidTag):
+		respondValidationError(c, err, FieldError{Field: "tags", Rule: "tag"})
+	case errors.Is(err, repository.ErrSlugExists):
+		c.JSON(http.StatusConflict, gin.H{"error": "slug already exists"})
+	default:
+		return false
+	}
+	return true
+}
+
+// GET /users/:id/posts/count — публичный; автор (userID из auth middleware) видит счетчик вместе с черновиками
+func (h *PostHandler) CountByAuthor(c *gin.Context) {
+	authorUUID, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	authorID := authorUUID.String()
+	viewerID := c.GetString("userID")
+
+	count, err := h.service.CountByAuthor(c.Request.Context(), authorID, viewerID)
+	if err != nil {
+		if AbortIfTimeout(c, err) {
+			return
+		}
+		h.logger.Error("failed to count posts", zap.String("author_id", authorID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"author_id": authorID, "count": count})
+}
+
+// GET /posts/:id?preview=true|false — публичный. По умолчанию пост целиком;
+// preview=true - тело обрезано до posts.preview_length символов по границе слова
+// (разметка не рвется), плюс truncated и full_length. Оба варианта с ETag.
+// Неопубликованный пост - 404 для всех, кроме автора и администратора (userID и role из auth middleware)
+func (h *PostHandler) Get(c *gin.Context) {
+	preview := false
+	if raw := c.Query("preview"); raw != "" {
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "preview must be true or false"})
+			return
+		}
+		preview = parsed
+	}
+
+	postID, ok := parseObjectIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	get := h.service.GetCached
+	if preview {
+		get = h.service.GetPreview
+	}
+
+	cached, err := get(c.Request.Context(), postID.Hex(), c.GetString("userID"), c.GetString("role") == RoleAdmin)
+	if err != nil {
+		h.respondPostError(c, err, "failed to get post")
+		return
+	}
+
+	ServeWithETag(c, cached.ETag, cached.Body)
+}
+
+// GET /posts/slug/:slug — публичный, видимость как у GET /posts/:id.
+// Старый slug (пост переименовали) - 301 на текущий, чтобы старые ссылки не ломались
+func (h *PostHandler) GetBySlug(c *gin.Context) {
+	requested := c.Param("slug")
+
+	post, err := h.service.GetBySlug(c.Request.Context(), requested, c.GetString("userID"), c.GetString("role") == RoleAdmin)
+	if err != nil {
+		h.respondPostError(c, err, "failed to get post by slug")
+		return
+	}
+
+	if post.Slug != requested {
+		// Адрес из шаблона маршрута, чтобы редирект учитывал префикс группы
+		c.Redirect(http.StatusMovedPermanently, strings.Replace(c.FullPath(), ":slug", url.PathEscape(post.Slug), 1))
+		return
+	}
+
+	c.JSON(http.StatusOK, post)
+}
+
+// GET /posts/:id/related — публичный. Опубликованные посты с общими тегами, больше общих - выше;
+// у поста без тегов список пустой
+func (h *PostHandler) Related(c *gin.Context) {
+	postID, ok := parseObjectIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	posts, err := h.service.Related(c.Request.Context(), postID.Hex())
+	if err != nil {
+		h.respondPostError(c, err, "failed to list related posts")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": posts})
+}
+
+// GET /posts/:id/revisions — автор или администратор. История правок, новые первыми
+func (h *PostHandler) Revisions(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	postID, ok := parseObjectIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	revisions, err := h.service.ListRevisions(c.Request.Context(), postID.Hex(), userID, c.GetString("role") == RoleAdmin)
+	if err != nil {
+		h.respondPostError(c, err, "failed to list post revisions")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": revisions})
+}
+
+// DELETE /posts/:id?hard=true|false — автор или администратор
+// По умолчанию мягкое удаление (восстанавливается через restore); hard=true - навсегда, только администратор
+func (h *PostHandler) Delete(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	isAdmin := c.GetString("role") == RoleAdmin
+
+	hard := false
+	if raw := c.Query("hard"); raw != "" {
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "hard must be true or false"})
+			return
+		}
+		hard = parsed
+	}
+
+	postID, ok := parseObjectIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var err error
+	if hard {
+		if !isAdmin {
+			c.JSON(http.StatusForbidden, gin.H{"error": "permanent deletion requires admin role"})
+			return
+		}
+		err = h.service.HardDelete(c.Request.Context(), postID.Hex())
+	} else {
+		err = h.service.Delete(c.Request.Context(), postID.Hex(), userID, isAdmin)
+	}
+	if err != nil {
+		h.respondPostError(c, err, "failed to delete post")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// POST /posts/:id/restore — автор или администратор
+func (h *PostHandler) Restore(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	postID, ok := parseObjectIDParam(c, "id")
+	if !ok {
+		return
+	}
+	if err := h.service.Restore(c.Request.Context(), postID.Hex(), userID, c.GetString("role") == RoleAdmin); err != nil {
+		h.respondPostError(c, err, "failed to restore post")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+var errPublishAtRequired = errors.New("publish_at is required")
+
+type scheduleRequest struct {
+	PublishAt time.Time `json:"publish_at"`
+}
+
+// POST /posts/:id/schedule — автор или администратор. Черновик станет опубликованным в publish_at
+// (RFC 3339, в будущем); до этого его не видно публично. Повторный вызов переносит срок
+func (h *PostHandler) Schedule(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	postID, ok := parseObjectIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req scheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+	if req.PublishAt.IsZero() {
+		respondValidationError(c, errPublishAtRequired, FieldError{Field: "publish_at", Rule: "required"})
+		return
+	}
+
+	post, err := h.service.Schedule(c.Request.Context(), postID.Hex(), userID, c.GetString("role") == RoleAdmin, req.PublishAt)
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, gin.H{"id": post.ID.Hex(), "status": post.Status, "publish_at": post.PublishAt})
+	case errors.Is(err, service.ErrPublishAtInPast):
+		respondValidationError(c, err, FieldError{Field: "publish_at", Rule: "future"})
+	case errors.Is(err, repository.ErrNotDraft):
+		c.JSON(http.StatusConflict, gin.H{"error": "only drafts can be scheduled"})
+	default:
+		h.respondPostError(c, err, "failed to schedule post")
+	}
+}
+
+// respondPostError - общие ответы на ошибки изменения поста; msg - сообщение для лога при 500
+func (h *PostHandler) respondPostError(c *gin.Context, err error, msg string) {
+	switch {
+	case AbortIfTimeout(c, err):
+	case errors.Is(err, repository.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "post not found"})
+	case errors.Is(err, service.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to modify this post"})
+	default:
+		h.logger.Error(msg, zap.String("post_id", c.Param("id")), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+}
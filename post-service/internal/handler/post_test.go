@@ -0,0 +1,747 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/cache"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/model"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/repository"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/sanitize"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/service"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// countService - PostService, в котором реализован только CountByAuthor
+type countService struct {
+	service.PostService
+	count     int64
+	err       error
+	gotAuthor string
+	gotViewer string
+}
+
+func (s *countService) CountByAuthor(ctx context.Context, authorID, viewerID string) (int64, error) {
+	s.gotAuthor, s.gotViewer = authorID, viewerID
+	return s.count, s.err
+}
+
+func TestPostHandler_CountByAuthor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newRouter := func(svc *countService, viewerID string) *gin.Engine {
+		h := NewPostHandler(svc, zap.NewNop())
+		r := gin.New()
+		r.GET("/users/:id/posts/count", func(c *gin.Context) {
+			if viewerID != "" {
+				c.Set("userID", viewerID)
+			}
+		}, h.CountByAuthor)
+		return r
+	}
+
+	const authorID = "0b4b6a4e-6d1f-4c55-9a7e-3f1c2d5e8a90"
+	do := func(r *gin.Engine) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/"+authorID+"/posts/count", nil))
+		return w
+	}
+
+	t.Run("Anonymous viewer", func(t *testing.T) {
+		svc := &countService{count: 42}
+		w := do(newRouter(svc, ""))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"author_id":"`+authorID+`","count":42}`, w.Body.String())
+		assert.Equal(t, authorID, svc.gotAuthor)
+		assert.Empty(t, svc.gotViewer)
+	})
+
+	t.Run("Viewer is passed to the service", func(t *testing.T) {
+		svc := &countService{count: 45}
+		w := do(newRouter(svc, "author-1"))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "author-1", svc.gotViewer)
+	})
+
+	t.Run("Malformed author id", func(t *testing.T) {
+		svc := &countService{}
+		w := httptest.NewRecorder()
+		newRouter(svc, "").ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/author-1/posts/count", nil))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.JSONEq(t, `{"error":"invalid id format"}`, w.Body.String())
+		assert.Empty(t, svc.gotAuthor)
+	})
+
+	t.Run("Storage timeout", func(t *testing.T) {
+		svc := &countService{err: fmt.Errorf("count: %w", context.DeadlineExceeded)}
+		assert.Equal(t, http.StatusGatewayTimeout, do(newRouter(svc, "")).Code)
+	})
+
+	t.Run("Storage error", func(t *testing.T) {
+		svc := &countService{err: errors.New("boom")}
+		assert.Equal(t, http.StatusInternalServerError, do(newRouter(svc, "")).Code)
+	})
+}
+
+// deleteService записывает, какой из методов удаления вызван и с какими правами
+type deleteService struct {
+	service.PostService
+	err     error
+	called  string
+	actorID string
+	isAdmin bool
+}
+
+func (s *deleteService) Delete(ctx context.Context, id, actorID string, isAdmin bool) error {
+	s.called, s.actorID, s.isAdmin = "Delete", actorID, isAdmin
+	return s.err
+}
+
+func (s *deleteService) Restore(ctx context.Context, id, actorID string, isAdmin bool) error {
+	s.called, s.actorID, s.isAdmin = "Restore", actorID, isAdmin
+	return s.err
+}
+
+func (s *deleteService) HardDelete(ctx context.Context, id string) error {
+	s.called = "HardDelete"
+	return s.err
+}
+
+func TestPostHandler_DeleteAndRestore(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	const postID = "65f1a2b3c4d5e6f7a8b9c0d1"
+
+	do := func(svc *deleteService, userID, role, method, target string) *httptest.ResponseRecorder {
+		h := NewPostHandler(svc, zap.NewNop())
+		r := gin.New()
+		auth := r.Group("", func(c *gin.Context) {
+			if userID != "" {
+				c.Set("userID", userID)
+				c.Set("role", role)
+			}
+		})
+		auth.DELETE("/posts/:id", h.Delete)
+		auth.POST("/posts/:id/restore", h.Restore)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(method, target, nil))
+		return w
+	}
+
+	t.Run("Soft delete by author", func(t *testing.T) {
+		svc := &deleteService{}
+		w := do(svc, "u1", "user", http.MethodDelete, "/posts/"+postID)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, "Delete", svc.called)
+		assert.Equal(t, "u1", svc.actorID)
+		assert.False(t, svc.isAdmin)
+	})
+
+	t.Run("Someone else's post", func(t *testing.T) {
+		svc := &deleteService{err: service.ErrForbidden}
+		assert.Equal(t, http.StatusForbidden, do(svc, "u2", "user", http.MethodDelete, "/posts/"+postID).Code)
+	})
+
+	t.Run("Hard delete requires admin", func(t *testing.T) {
+		svc := &deleteService{}
+		assert.Equal(t, http.StatusForbidden, do(svc, "u1", "user", http.MethodDelete, "/posts/"+postID+"?hard=true").Code)
+		assert.Empty(t, svc.called)
+
+		assert.Equal(t, http.StatusNoContent, do(svc, "admin", RoleAdmin, http.MethodDelete, "/posts/"+postID+"?hard=true").Code)
+		assert.Equal(t, "HardDelete", svc.called)
+	})
+
+	t.Run("Invalid hard flag", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, do(&deleteService{}, "u1", "user", http.MethodDelete, "/posts/"+postID+"?hard=maybe").Code)
+	})
+
+	t.Run("Restore by admin", func(t *testing.T) {
+		svc := &deleteService{}
+		w := do(svc, "admin", RoleAdmin, http.MethodPost, "/posts/"+postID+"/restore")
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, "Restore", svc.called)
+		assert.True(t, svc.isAdmin)
+	})
+
+	t.Run("Restore of a missing post", func(t *testing.T) {
+		svc := &deleteService{err: repository.ErrNotFound}
+		assert.Equal(t, http.StatusNotFound, do(svc, "u1", "user", http.MethodPost, "/posts/"+postID+"/restore").Code)
+	})
+
+	t.Run("Malformed post id", func(t *testing.T) {
+		svc := &deleteService{}
+		w := do(svc, "u1", "user", http.MethodDelete, "/posts/p1")
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.JSONEq(t, `{"error":"invalid id format"}`, w.Body.String())
+		assert.Empty(t, svc.called)
+
+		assert.Equal(t, http.StatusBadRequest, do(svc, "u1", "user", http.MethodPost, "/posts/p1/restore").Code)
+	})
+
+	t.Run("Anonymous", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, do(&deleteService{}, "", "", http.MethodDelete, "/posts/"+postID).Code)
+		assert.Equal(t, http.StatusUnauthorized, do(&deleteService{}, "", "", http.MethodPost, "/posts/"+postID+"/restore").Code)
+	})
+}
+
+// scheduleService возвращает запланированный пост или err
+type scheduleService struct {
+	service.PostService
+	err          error
+	gotPublishAt time.Time
+}
+
+func (s *scheduleService) Schedule(ctx context.Context, id, actorID string, isAdmin bool, publishAt time.Time) (*model.Post, error) {
+	s.gotPublishAt = publishAt
+	if s.err != nil {
+		return nil, s.err
+	}
+	objectID, _ := primitive.ObjectIDFromHex(id)
+	return &model.Post{ID: objectID, Status: model.PostStatusScheduled, PublishAt: &publishAt}, nil
+}
+
+func TestPostHandler_Schedule(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	const postID = "65f1a2b3c4d5e6f7a8b9c0d1"
+
+	do := func(svc *scheduleService, userID, body string) *httptest.ResponseRecorder {
+		h := NewPostHandler(svc, zap.NewNop())
+		r := gin.New()
+		r.POST("/posts/:id/schedule", func(c *gin.Context) {
+			if userID != "" {
+				c.Set("userID", userID)
+			}
+		}, h.Schedule)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/posts/"+postID+"/schedule", strings.NewReader(body)))
+		return w
+	}
+
+	t.Run("Scheduled", func(t *testing.T) {
+		svc := &scheduleService{}
+		w := do(svc, "u1", `{"publish_at":"2030-01-02T10:00:00+03:00"}`)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"id":"`+postID+`","status":"scheduled","publish_at":"2030-01-02T10:00:00+03:00"}`, w.Body.String())
+		assert.True(t, svc.gotPublishAt.Equal(time.Date(2030, 1, 2, 7, 0, 0, 0, time.UTC)))
+	})
+
+	t.Run("Publish time in the past", func(t *testing.T) {
+		w := do(&scheduleService{err: service.ErrPublishAtInPast}, "u1", `{"publish_at":"2020-01-01T00:00:00Z"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.JSONEq(t, `{"error":"validation failed","details":"publish_at must be in the future","errors":[{"field":"publish_at","rule":"future"}]}`, w.Body.String())
+	})
+
+	t.Run("Already published", func(t *testing.T) {
+		w := do(&scheduleService{err: repository.ErrNotDraft}, "u1", `{"publish_at":"2030-01-01T00:00:00Z"}`)
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("Someone else's post", func(t *testing.T) {
+		w := do(&scheduleService{err: service.ErrForbidden}, "u2", `{"publish_at":"2030-01-01T00:00:00Z"}`)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Missing publish_at", func(t *testing.T) {
+		w := do(&scheduleService{}, "u1", `{}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.JSONEq(t, `{"error":"validation failed","details":"publish_at is required","errors":[{"field":"publish_at","rule":"required"}]}`, w.Body.String())
+	})
+
+	t.Run("Malformed publish_at", func(t *testing.T) {
+		w := do(&scheduleService{}, "u1", `{"publish_at":"tomorrow"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.JSONEq(t, `{"error":"invalid request body"}`, w.Body.String())
+	})
+
+	t.Run("Anonymous", func(t *testing.T) {
+		w := do(&scheduleService{}, "", `{"publish_at":"2030-01-01T00:00:00Z"}`)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.JSONEq(t, `{"error":"unauthorized"}`, w.Body.String())
+	})
+}
+
+// relatedService - PostService, в котором реализован только Related
+type relatedService struct {
+	service.PostService
+	posts []*model.Post
+	err   error
+}
+
+func (s *relatedService) Related(ctx context.Context, id string) ([]*model.Post, error) {
+	return s.posts, s.err
+}
+
+func TestPostHandler_Related(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	const postID = "65f1a2b3c4d5e6f7a8b9c0d1"
+
+	do := func(svc *relatedService, id string) *httptest.ResponseRecorder {
+		h := NewPostHandler(svc, zap.NewNop())
+		r := gin.New()
+		r.GET("/posts/:id/related", h.Related)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/posts/"+id+"/related", nil))
+		return w
+	}
+
+	t.Run("Related posts", func(t *testing.T) {
+		svc := &relatedService{posts: []*model.Post{{Title: "first"}, {Title: "second"}}}
+		w := do(svc, postID)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"first"`)
+		assert.Contains(t, w.Body.String(), `"second"`)
+	})
+
+	t.Run("Nothing related", func(t *testing.T) {
+		w := do(&relatedService{posts: []*model.Post{}}, postID)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"items":[]}`, w.Body.String())
+	})
+
+	t.Run("Missing post", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, do(&relatedService{err: repository.ErrNotFound}, postID).Code)
+	})
+
+	t.Run("Malformed post id", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, do(&relatedService{}, "p1").Code)
+	})
+}
+
+// revisionsService - PostService, в котором реализован только ListRevisions
+type revisionsService struct {
+	service.PostService
+	revisions []*model.PostRevision
+	err       error
+	actorID   string
+	isAdmin   bool
+}
+
+func (s *revisionsService) ListRevisions(ctx context.Context, id, actorID string, isAdmin bool) ([]*model.PostRevision, error) {
+	s.actorID, s.isAdmin = actorID, isAdmin
+	return s.revisions, s.err
+}
+
+func TestPostHandler_Revisions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	const postID = "65f1a2b3c4d5e6f7a8b9c0d1"
+
+	do := func(svc *revisionsService, userID, role string) *httptest.ResponseRecorder {
+		h := NewPostHandler(svc, zap.NewNop())
+		r := gin.New()
+		r.GET("/posts/:id/revisions", func(c *gin.Context) {
+			if userID != "" {
+				c.Set("userID", userID)
+				c.Set("role", role)
+			}
+		}, h.Revisions)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/posts/"+postID+"/revisions", nil))
+		return w
+	}
+
+	t.Run("Author sees the history", func(t *testing.T) {
+		svc := &revisionsService{revisions: []*model.PostRevision{{Title: "second"}, {Title: "first"}}}
+		w := do(svc, "u1", "user")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"title":"second"`)
+		assert.Equal(t, "u1", svc.actorID)
+		assert.False(t, svc.isAdmin)
+	})
+
+	t.Run("Admin flag is passed", func(t *testing.T) {
+		svc := &revisionsService{revisions: []*model.PostRevision{}}
+		w := do(svc, "a1", RoleAdmin)
+
+		assert.JSONEq(t, `{"items":[]}`, w.Body.String())
+		assert.True(t, svc.isAdmin)
+	})
+
+	t.Run("Someone else's post", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, do(&revisionsService{err: service.ErrForbidden}, "u2", "user").Code)
+	})
+
+	t.Run("Anonymous", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, do(&revisionsService{}, "", "").Code)
+	})
+}
+
+// getService отдает разные тела для полного поста и превью
+type getService struct {
+	service.PostService
+	err       error
+	gotViewer string
+	gotAdmin  bool
+}
+
+func (s *getService) GetCached(ctx context.Context, id, viewerID string, isAdmin bool) (*cache.CachedPost, error) {
+	s.gotViewer, s.gotAdmin = viewerID, isAdmin
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &cache.CachedPost{ETag: `"full"`, Body: []byte(`{"Content":"long body"}`)}, nil
+}
+
+func (s *getService) GetPreview(ctx context.Context, id, viewerID string, isAdmin bool) (*cache.CachedPost, error) {
+	s.gotViewer, s.gotAdmin = viewerID, isAdmin
+	return &cache.CachedPost{ETag: `"full-preview"`, Body: []byte(`{"Content":"long","truncated":true,"full_length":9}`)}, nil
+}
+
+func TestPostHandler_Get(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	const postID = "65f1a2b3c4d5e6f7a8b9c0d1"
+
+	do := func(svc *getService, target string) *httptest.ResponseRecorder {
+		h := NewPostHandler(svc, zap.NewNop())
+		r := gin.New()
+		r.GET("/posts/:id", h.Get)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		return w
+	}
+
+	t.Run("Full post by default", func(t *testing.T) {
+		w := do(&getService{}, "/posts/"+postID)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `"full"`, w.Header().Get("ETag"))
+		assert.JSONEq(t, `{"Content":"long body"}`, w.Body.String())
+	})
+
+	t.Run("Preview", func(t *testing.T) {
+		w := do(&getService{}, "/posts/"+postID+"?preview=true")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `"full-preview"`, w.Header().Get("ETag"))
+		assert.JSONEq(t, `{"Content":"long","truncated":true,"full_length":9}`, w.Body.String())
+	})
+
+	t.Run("Explicit full post", func(t *testing.T) {
+		assert.Equal(t, `"full"`, do(&getService{}, "/posts/"+postID+"?preview=false").Header().Get("ETag"))
+	})
+
+	t.Run("Invalid preview flag", func(t *testing.T) {
+		w := do(&getService{}, "/posts/"+postID+"?preview=maybe")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.JSONEq(t, `{"error":"preview must be true or false"}`, w.Body.String())
+	})
+
+	t.Run("If-None-Match", func(t *testing.T) {
+		h := NewPostHandler(&getService{}, zap.NewNop())
+		r := gin.New()
+		r.GET("/posts/:id", h.Get)
+
+		get := func(target, ifNoneMatch string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodGet, target, nil)
+			req.Header.Set("If-None-Match", ifNoneMatch)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			return w
+		}
+
+		w := get("/posts/"+postID, `"full"`)
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Empty(t, w.Body.String())
+
+		// ETag полного поста не подходит к превью и наоборот
+		w = get("/posts/"+postID+"?preview=true", `"full"`)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `"full-preview"`, w.Header().Get("ETag"))
+
+		w = get("/posts/"+postID, `"full-preview"`)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"Content":"long body"}`, w.Body.String())
+	})
+
+	t.Run("Missing post", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, do(&getService{err: repository.ErrNotFound}, "/posts/"+postID).Code)
+	})
+
+	t.Run("Viewer is passed to the service", func(t *testing.T) {
+		svc := &getService{}
+		h := NewPostHandler(svc, zap.NewNop())
+		r := gin.New()
+		r.GET("/posts/:id", func(c *gin.Context) {
+			c.Set("userID", "u1")
+			c.Set("role", RoleAdmin)
+		}, h.Get)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/posts/"+postID, nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "u1", svc.gotViewer)
+		assert.True(t, svc.gotAdmin)
+	})
+}
+
+// slugService - PostService, в котором реализован только GetBySlug
+type slugService struct {
+	service.PostService
+	post     *model.Post
+	err      error
+	gotSlug  string
+	gotActor string
+}
+
+func (s *slugService) GetBySlug(ctx context.Context, slug, viewerID string, isAdmin bool) (*model.Post, error) {
+	s.gotSlug, s.gotActor = slug, viewerID
+	return s.post, s.err
+}
+
+func TestPostHandler_GetBySlug(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	do := func(svc *slugService, viewerID, slug string) *httptest.ResponseRecorder {
+		h := NewPostHandler(svc, zap.NewNop())
+		r := gin.New()
+		r.GET("/posts/slug/:slug", func(c *gin.Context) {
+			if viewerID != "" {
+				c.Set("userID", viewerID)
+			}
+		}, h.GetBySlug)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/posts/slug/"+slug, nil))
+		return w
+	}
+
+	t.Run("Current slug", func(t *testing.T) {
+		svc := &slugService{post: &model.Post{Title: "Hello World", Slug: "hello-world"}}
+		w := do(svc, "u1", "hello-world")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"Hello World"`)
+		assert.Equal(t, "hello-world", svc.gotSlug)
+		assert.Equal(t, "u1", svc.gotActor)
+	})
+
+	t.Run("Old slug redirects to the current one", func(t *testing.T) {
+		svc := &slugService{post: &model.Post{Title: "Hello Go", Slug: "hello-go"}}
+		w := do(svc, "", "hello-world")
+
+		assert.Equal(t, http.StatusMovedPermanently, w.Code)
+		assert.Equal(t, "/posts/slug/hello-go", w.Header().Get("Location"))
+	})
+
+	t.Run("Redirect keeps the route prefix", func(t *testing.T) {
+		h := NewPostHandler(&slugService{post: &model.Post{Slug: "hello-go"}}, zap.NewNop())
+		r := gin.New()
+		r.Group("/api/v1").GET("/posts/slug/:slug", h.GetBySlug)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/posts/slug/hello-world", nil))
+
+		assert.Equal(t, http.StatusMovedPermanently, w.Code)
+		assert.Equal(t, "/api/v1/posts/slug/hello-go", w.Header().Get("Location"))
+	})
+
+	t.Run("Missing post", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, do(&slugService{err: repository.ErrNotFound}, "", "nope").Code)
+	})
+}
+
+// createService - PostService, в котором реализован только Create
+type createService struct {
+	service.PostService
+	err error
+	got *model.Post
+}
+
+func (s *createService) Create(ctx context.Context, post *model.Post) error {
+	s.got = post
+	if s.err != nil {
+		return s.err
+	}
+	post.ID = primitive.NewObjectID()
+	post.Slug = "hello-world"
+	return nil
+}
+
+func TestPostHandler_Create(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	do := func(svc *createService, userID, body string) *httptest.ResponseRecorder {
+		h := NewPostHandler(svc, zap.NewNop())
+		r := gin.New()
+		r.POST("/posts", func(c *gin.Context) {
+			if userID != "" {
+				c.Set("userID", userID)
+			}
+		}, h.Create)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/posts", strings.NewReader(body)))
+		return w
+	}
+
+	t.Run("Created as published by default", func(t *testing.T) {
+		svc := &createService{}
+		w := do(svc, "u1", `{"title":"Hello World","content":"body","tags":["go"]}`)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Contains(t, w.Body.String(), `"slug":"hello-world"`)
+		assert.Equal(t, "u1", svc.got.AuthorID)
+		assert.Equal(t, model.PostStatusPublished, svc.got.Status)
+		assert.Equal(t, []string{"go"}, svc.got.Tags)
+	})
+
+	t.Run("Draft", func(t *testing.T) {
+		svc := &createService{}
+		assert.Equal(t, http.StatusCreated, do(svc, "u1", `{"title":"t","content":"c","status":"draft"}`).Code)
+		assert.Equal(t, model.PostStatusDraft, svc.got.Status)
+	})
+
+	t.Run("Validation", func(t *testing.T) {
+		for name, body := range map[string]string{
+			"missing title":  `{"content":"c"}`,
+			"blank title":    `{"title":"  ","content":"c"}`,
+			"unknown status": `{"title":"t","content":"c","status":"hidden"}`,
+		} {
+			t.Run(name, func(t *testing.T) {
+				svc := &createService{}
+				w := do(svc, "u1", body)
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.Contains(t, w.Body.String(), "validation failed")
+				assert.Nil(t, svc.got)
+			})
+		}
+	})
+
+	t.Run("Rejected by the sanitizer", func(t *testing.T) {
+		w := do(&createService{err: sanitize.ErrTooManyTags}, "u1", `{"title":"t","content":"c"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `"field":"tags"`)
+	})
+
+	t.Run("Malformed body", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, do(&createService{}, "u1", `{`).Code)
+	})
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		svc := &createService{}
+		assert.Equal(t, http.StatusUnauthorized, do(svc, "", `{"title":"t"}`).Code)
+		assert.Nil(t, svc.got)
+	})
+}
+
+// editService - PostService, в котором реализован только Edit
+type editService struct {
+	service.PostService
+	err     error
+	changes *service.PostChanges
+	actorID string
+	isAdmin bool
+}
+
+func (s *editService) Edit(ctx context.Context, id, actorID string, isAdmin bool, changes service.PostChanges) (*model.Post, error) {
+	s.changes, s.actorID, s.isAdmin = &changes, actorID, isAdmin
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &model.Post{Title: "edited"}, nil
+}
+
+func TestPostHandler_Update(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	const postID = "65f1a2b3c4d5e6f7a8b9c0d1"
+
+	do := func(svc *editService, userID, role, body string) *httptest.ResponseRecorder {
+		h := NewPostHandler(svc, zap.NewNop())
+		r := gin.New()
+		r.PATCH("/posts/:id", func(c *gin.Context) {
+			if userID != "" {
+				c.Set("userID", userID)
+				c.Set("role", role)
+			}
+		}, h.Update)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPatch, "/posts/"+postID, strings.NewReader(body)))
+		return w
+	}
+
+	t.Run("Only the sent fields are changed", func(t *testing.T) {
+		svc := &editService{}
+		w := do(svc, "u1", "user", `{"title":"New title","tags":[]}`)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"edited"`)
+		assert.Equal(t, "New title", *svc.changes.Title)
+		assert.Nil(t, svc.changes.Content)
+		assert.Nil(t, svc.changes.Topic)
+		assert.Equal(t, []string{}, *svc.changes.Tags)
+		assert.Equal(t, "u1", svc.actorID)
+		assert.False(t, svc.isAdmin)
+	})
+
+	t.Run("Only the slug is regenerated", func(t *testing.T) {
+		svc := &editService{}
+		assert.Equal(t, http.StatusOK, do(svc, "u1", "user", `{"regenerate_slug":true}`).Code)
+		assert.True(t, svc.changes.RegenerateSlug)
+		assert.Nil(t, svc.changes.Title)
+	})
+
+	t.Run("Admin", func(t *testing.T) {
+		svc := &editService{}
+		assert.Equal(t, http.StatusOK, do(svc, "admin-1", RoleAdmin, `{"content":"c"}`).Code)
+		assert.True(t, svc.isAdmin)
+	})
+
+	t.Run("Validation", func(t *testing.T) {
+		for name, body := range map[string]string{
+			"empty body":  `{}`,
+			"blank title": `{"title":" "}`,
+		} {
+			t.Run(name, func(t *testing.T) {
+				svc := &editService{}
+				assert.Equal(t, http.StatusBadRequest, do(svc, "u1", "user", body).Code)
+				assert.Nil(t, svc.changes)
+			})
+		}
+	})
+
+	t.Run("Rejected by the sanitizer", func(t *testing.T) {
+		w := do(&editService{err: sanitize.ErrInvalidTag}, "u1", "user", `{"tags":["a b"]}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `"field":"tags"`)
+	})
+
+	t.Run("Someone else's post", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, do(&editService{err: service.ErrForbidden}, "u2", "user", `{"title":"t"}`).Code)
+	})
+
+	t.Run("Missing post", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, do(&editService{err: repository.ErrNotFound}, "u1", "user", `{"title":"t"}`).Code)
+	})
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		svc := &editService{}
+		assert.Equal(t, http.StatusUnauthorized, do(svc, "", "", `{"title":"t"}`).Code)
+		assert.Nil(t, svc.changes)
+	})
+}
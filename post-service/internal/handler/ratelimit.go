@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// RateLimiter - счетчик событий по ключу (см. cache.SlidingWindowLimiter)
+type RateLimiter interface {
+	Allow(ctx context.Context, key string) (allowed bool, retryAfter time.Duration, err error)
+}
+
+// RateLimitByUser ограничивает запросы по userID из контекста (кладет auth middleware),
+// а не по IP - иначе страдают пользователи за общим NAT.
+// При недоступном Redis запрос пропускается: лучше немного спама, чем лежащий сервис.
+func RateLimitByUser(limiter RateLimiter, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetString("userID")
+		if userID == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+
+		allowed, retryAfter, err := limiter.Allow(c.Request.Context(), userID)
+		if err != nil {
+			logger.Error("rate limiter failed", zap.String("user_id", userID), zap.Error(err))
+			c.Next()
+			return
+		}
+
+		if !allowed {
+			seconds := int(math.Ceil(retryAfter.Seconds()))
+			if seconds < 1 {
+				seconds = 1
+			}
+
+			logger.Warn("rate limit exceeded", zap.String("user_id", userID))
+			c.Header("Retry-After", strconv.Itoa(seconds))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "too many requests"})
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+type mockRateLimiter struct {
+	mock.Mock
+}
+
+func (m *mockRateLimiter) Allow(ctx context.Context, key string) (bool, time.Duration, error) {
+	args := m.Called(ctx, key)
+	return args.Bool(0), args.Get(1).(time.Duration), args.Error(2)
+}
+
+func newRateLimitRouter(limiter RateLimiter, userID string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.POST("/posts",
+		func(c *gin.Context) {
+			// вместо auth middleware
+			if userID != "" {
+				c.Set("userID", userID)
+			}
+		},
+		RateLimitByUser(limiter, zap.NewNop()),
+		func(c *gin.Context) { c.Status(http.StatusCreated) },
+	)
+	return r
+}
+
+func TestRateLimitByUser(t *testing.T) {
+	t.Run("Allowed", func(t *testing.T) {
+		limiter := &mockRateLimiter{}
+		limiter.On("Allow", mock.Anything, "user-1").Return(true, time.Duration(0), nil).Once()
+
+		w := httptest.NewRecorder()
+		newRateLimitRouter(limiter, "user-1").ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/posts", nil))
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		limiter.AssertExpectations(t)
+	})
+
+	t.Run("Exceeded - 429 with Retry-After", func(t *testing.T) {
+		limiter := &mockRateLimiter{}
+		limiter.On("Allow", mock.Anything, "user-1").Return(false, 90*time.Second+time.Millisecond, nil).Once()
+
+		w := httptest.NewRecorder()
+		newRateLimitRouter(limiter, "user-1").ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/posts", nil))
+
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "91", w.Header().Get("Retry-After"))
+	})
+
+	t.Run("Keyed by user, not IP", func(t *testing.T) {
+		limiter := &mockRateLimiter{}
+		limiter.On("Allow", mock.Anything, "user-1").Return(false, time.Minute, nil).Once()
+		limiter.On("Allow", mock.Anything, "user-2").Return(true, time.Duration(0), nil).Once()
+
+		// Один и тот же IP (httptest всегда 192.0.2.1), разные пользователи
+		w1 := httptest.NewRecorder()
+		newRateLimitRouter(limiter, "user-1").ServeHTTP(w1, httptest.NewRequest(http.MethodPost, "/posts", nil))
+		w2 := httptest.NewRecorder()
+		newRateLimitRouter(limiter, "user-2").ServeHTTP(w2, httptest.NewRequest(http.MethodPost, "/posts", nil))
+
+		assert.Equal(t, http.StatusTooManyRequests, w1.Code)
+		assert.Equal(t, http.StatusCreated, w2.Code)
+		limiter.AssertExpectations(t)
+	})
+
+	t.Run("No user - 401", func(t *testing.T) {
+		limiter := &mockRateLimiter{}
+
+		w := httptest.NewRecorder()
+		newRateLimitRouter(limiter, "").ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/posts", nil))
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		limiter.AssertNotCalled(t, "Allow", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Limiter error - fail open", func(t *testing.T) {
+		limiter := &mockRateLimiter{}
+		limiter.On("Allow", mock.Anything, "user-1").Return(false, time.Duration(0), assert.AnError).Once()
+
+		w := httptest.NewRecorder()
+		newRateLimitRouter(limiter, "user-1").ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/posts", nil))
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+	})
+}
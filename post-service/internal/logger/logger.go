@@ -2,12 +2,29 @@ package logger
 
 import (
 	"os"
+	"time"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
 )
 
-func New(level, mode string) (*zap.Logger, error) {
+// Sampling - пороги семплирования в release: в пределах секунды первые Initial
+// одинаковых записей пишутся все, дальше - каждая Thereafter-я. Initial <= 0 выключает семплирование.
+type Sampling struct {
+	Initial    int
+	Thereafter int
+}
+
+// File - необязательный вывод в файл с ротацией. Пустой Path - пишем только в stdout.
+type File struct {
+	Path       string
+	MaxSizeMB  int
+	MaxBackups int
+	MaxAgeDays int
+}
+
+func New(level, mode string, sampling Sampling, file File) (*zap.Logger, error) {
 
 	var zapLevel zapcore.Level
 
@@ -29,6 +46,24 @@ func New(level, mode string) (*zap.Logger, error) {
 
 	core := zapcore.NewCore(encoder, zapcore.AddSync(os.Stdout), zapLevel)
 
+	// Файл всегда в JSON без цветов: его читают парсеры, а не человек в терминале
+	if file.Path != "" {
+		fileEncoderConfig := zap.NewProductionEncoderConfig()
+		fileEncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+
+		rotator := &lumberjack.Logger{
+			Filename:   file.Path,
+			MaxSize:    file.MaxSizeMB,
+			MaxBackups: file.MaxBackups,
+			MaxAge:     file.MaxAgeDays,
+		}
+		core = zapcore.NewTee(core, zapcore.NewCore(zapcore.NewJSONEncoder(fileEncoderConfig), zapcore.AddSync(rotator), zapLevel))
+	}
+
+	if mode != "debug" && sampling.Initial > 0 {
+		core = zapcore.NewSamplerWithOptions(core, time.Second, sampling.Initial, sampling.Thereafter)
+	}
+
 	logger := zap.New(core,
 		zap.AddCaller(),
 		zap.AddStacktrace(zapcore.ErrorLevel),
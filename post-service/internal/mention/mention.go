@@ -0,0 +1,89 @@
+package mention
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultUsernamePattern - security.username_pattern auth-service по умолчанию:
+// латиница, цифры и подчеркивание, 2-50 символов
+const DefaultUsernamePattern = `^[a-zA-Z0-9_]{2,50}$`
+
+// MaxPerText - сколько разных упоминаний берется из одного текста; остальные игнорируются,
+// чтобы один пост не превращался в рассылку
+const MaxPerText = 20
+
+// Parser находит @username по тому же правилу, по которому auth-service принимает username
+// при регистрации (security.username_pattern), - иначе часть пользователей нельзя было бы упомянуть
+type Parser struct {
+	username *regexp.Regexp
+}
+
+// NewParser - pattern - security.username_pattern auth-service, пустая строка - DefaultUsernamePattern
+func NewParser(pattern string) (*Parser, error) {
+	if pattern == "" {
+		pattern = DefaultUsernamePattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid username pattern: %w", err)
+	}
+	return &Parser{username: re}, nil
+}
+
+// Parse находит @username в тексте поста или комментария и возвращает handle без @,
+// без повторов и в порядке появления. Упоминанием считается @, перед которым начало
+// текста или не буква/цифра (так email user@example.com не упоминание), а после -
+// слово из букв, цифр, '_', '.' и '-', целиком подходящее под username_pattern.
+// Точки и дефисы в конце слова - пунктуация предложения, а не часть имени.
+// Более длинное слово или handle, слитый с кириллицей, - не упоминание целиком.
+// Регистр сохраняется: username в auth-service чувствителен к регистру.
+// Существование пользователей Parse не проверяет - неизвестные handle отсеивает вызывающий.
+func (p *Parser) Parse(text string) []string {
+	var (
+		handles []string
+		seen    = make(map[string]bool)
+	)
+
+	for i := 0; i < len(text); i++ {
+		if text[i] != '@' || (i > 0 && isWordRune(lastRune(text[:i]))) {
+			continue
+		}
+
+		end := i + 1
+		for end < len(text) {
+			r, size := utf8.DecodeRuneInString(text[end:])
+			if !isWordRune(r) && r != '.' && r != '-' {
+				break
+			}
+			end += size
+		}
+
+		handle := strings.TrimRight(text[i+1:end], ".-")
+		i = end - 1
+
+		if handle == "" || seen[handle] || !p.username.MatchString(handle) {
+			continue
+		}
+		seen[handle] = true
+		handles = append(handles, handle)
+
+		if len(handles) == MaxPerText {
+			break
+		}
+	}
+
+	return handles
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func lastRune(s string) rune {
+	r, _ := utf8.DecodeLastRuneInString(s)
+	return r
+}
@@ -0,0 +1,65 @@
+package mention
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParser_Parse(t *testing.T) {
+	parser, err := NewParser("")
+	require.NoError(t, err)
+
+	cases := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"single", "hi @alice!", []string{"alice"}},
+		{"start of text", "@bob_2 look", []string{"bob_2"}},
+		{"order and duplicates", "@bob, @alice and @bob again", []string{"bob", "alice"}},
+		{"case is kept", "@Alice @alice", []string{"Alice", "alice"}},
+		{"email is not a mention", "write to user@example.com", nil},
+		{"too short", "@a", nil},
+		{"too long", "@" + strings.Repeat("x", 51), nil},
+		{"max length", "@" + strings.Repeat("x", 50), []string{strings.Repeat("x", 50)}},
+		{"inside html", "<p>thanks @carol</p>", []string{"carol"}},
+		{"glued to cyrillic", "@ivan_иванов и привет@alice", nil},
+		{"cyrillic around", "спасибо, @alice.", []string{"alice"}},
+		{"double at", "@@dave", []string{"dave"}},
+		{"lone at", "a @ b", nil},
+		{"trailing hyphen", "ask @alice- she knows", []string{"alice"}},
+		{"dot is not in the default charset", "@john.doe", nil},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, parser.Parse(tc.text))
+		})
+	}
+
+	t.Run("Limit per text", func(t *testing.T) {
+		var b strings.Builder
+		for i := 0; i < MaxPerText+5; i++ {
+			b.WriteString(" @user")
+			b.WriteByte(byte('a' + i))
+		}
+		assert.Len(t, parser.Parse(b.String()), MaxPerText)
+	})
+}
+
+func TestParser_CustomPattern(t *testing.T) {
+	// username с точками и дефисами, как бывает при импорте из других систем
+	parser, err := NewParser(`^[a-z][a-z0-9.-]{2,29}$`)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"john.doe", "mary-ann"}, parser.Parse("cc @john.doe, @mary-ann. and @Bob"))
+	assert.Nil(t, parser.Parse("@ab is too short"))
+}
+
+func TestNewParser_InvalidPattern(t *testing.T) {
+	_, err := NewParser("[a-z")
+	assert.Error(t, err)
+}
@@ -0,0 +1,164 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+// Бакеты от 0.5ms до ~4s: обращения к Mongo и Redis обычно укладываются в миллисекунды,
+// стандартные бакеты (от 5ms) схлопнули бы их все в первый
+var storeBuckets = prometheus.ExponentialBuckets(0.0005, 2, 14)
+
+// Metrics - Prometheus-метрики хранилищ post-service
+type Metrics struct {
+	registry *prometheus.Registry
+
+	mongoDuration  *prometheus.HistogramVec
+	mongoPoolConns *prometheus.GaugeVec
+	redisDuration  *prometheus.HistogramVec
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		mongoDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "mongo_command_duration_seconds",
+			Help:    "Duration of MongoDB commands by command name and outcome.",
+			Buckets: storeBuckets,
+		}, []string{"operation", "status"}),
+		mongoPoolConns: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "mongo_pool_connections",
+			Help: "MongoDB driver pool connections: open (created and not closed) and in_use (checked out).",
+		}, []string{"state"}),
+		redisDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "redis_command_duration_seconds",
+			Help:    "Duration of Redis commands by command name and outcome.",
+			Buckets: storeBuckets,
+		}, []string{"operation", "status"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.mongoDuration,
+		m.mongoPoolConns,
+		m.redisDuration,
+	)
+
+	return m
+}
+
+// Handler отдает метрики в формате Prometheus
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// MongoCommandMonitor меряет каждую команду драйвера - это покрывает все вызовы репозиториев
+func (m *Metrics) MongoCommandMonitor() *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Succeeded: func(_ context.Context, e *event.CommandSucceededEvent) {
+			m.mongoDuration.WithLabelValues(e.CommandName, "ok").Observe(e.Duration.Seconds())
+		},
+		Failed: func(_ context.Context, e *event.CommandFailedEvent) {
+			m.mongoDuration.WithLabelValues(e.CommandName, "error").Observe(e.Duration.Seconds())
+		},
+	}
+}
+
+// MongoPoolMonitor ведет счетчики открытых и занятых соединений пула
+func (m *Metrics) MongoPoolMonitor() *event.PoolMonitor {
+	open := m.mongoPoolConns.WithLabelValues("open")
+	inUse := m.mongoPoolConns.WithLabelValues("in_use")
+
+	return &event.PoolMonitor{
+		Event: func(e *event.PoolEvent) {
+			switch e.Type {
+			case event.ConnectionCreated:
+				open.Inc()
+			case event.ConnectionClosed:
+				open.Dec()
+			case event.GetSucceeded:
+				inUse.Inc()
+			case event.ConnectionReturned:
+				inUse.Dec()
+			}
+		},
+	}
+}
+
+// RedisHook меряет команды и пайплайны go-redis
+func (m *Metrics) RedisHook() redis.Hook {
+	return redisHook{duration: m.redisDuration}
+}
+
+// ObserveRedisPool экспортирует статистику пула; stats вызывается в момент scrape,
+// поэтому подходит и клиент, которого на старте еще нет (cache.Conn.PoolStats)
+func (m *Metrics) ObserveRedisPool(stats func() *redis.PoolStats) {
+	m.registry.MustRegister(
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "redis_pool_connections_total",
+			Help: "Redis pool connections, idle and in use.",
+		}, func() float64 { return float64(stats().TotalConns) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "redis_pool_connections_idle",
+			Help: "Idle Redis pool connections.",
+		}, func() float64 { return float64(stats().IdleConns) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "redis_pool_hits_total",
+			Help: "Times a free connection was found in the Redis pool.",
+		}, func() float64 { return float64(stats().Hits) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "redis_pool_misses_total",
+			Help: "Times a free connection was not found in the Redis pool.",
+		}, func() float64 { return float64(stats().Misses) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "redis_pool_timeouts_total",
+			Help: "Times waiting for a Redis pool connection timed out.",
+		}, func() float64 { return float64(stats().Timeouts) }),
+	)
+}
+
+type redisHook struct {
+	duration *prometheus.HistogramVec
+}
+
+func (h redisHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+func (h redisHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmd)
+		h.duration.WithLabelValues(cmd.Name(), status(err)).Observe(time.Since(start).Seconds())
+		return err
+	}
+}
+
+func (h redisHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmds)
+		h.duration.WithLabelValues("pipeline", status(err)).Observe(time.Since(start).Seconds())
+		return err
+	}
+}
+
+// status - промах кеша (redis.Nil) ошибкой не считаем
+func status(err error) string {
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return "error"
+	}
+	return "ok"
+}
@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+func TestMongoMonitors(t *testing.T) {
+	m := New()
+
+	cmd := m.MongoCommandMonitor()
+	cmd.Succeeded(context.Background(), &event.CommandSucceededEvent{
+		CommandFinishedEvent: event.CommandFinishedEvent{CommandName: "find", Duration: 3 * time.Millisecond},
+	})
+	cmd.Failed(context.Background(), &event.CommandFailedEvent{
+		CommandFinishedEvent: event.CommandFinishedEvent{CommandName: "insert", Duration: time.Millisecond},
+	})
+	assert.Equal(t, 2, testutil.CollectAndCount(m.mongoDuration))
+
+	pool := m.MongoPoolMonitor()
+	for _, typ := range []string{event.ConnectionCreated, event.ConnectionCreated, event.GetSucceeded, event.ConnectionReturned, event.GetSucceeded, event.ConnectionClosed} {
+		pool.Event(&event.PoolEvent{Type: typ})
+	}
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.mongoPoolConns.WithLabelValues("open")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.mongoPoolConns.WithLabelValues("in_use")))
+}
+
+func TestRedisHook(t *testing.T) {
+	m := New()
+	hook := m.RedisHook()
+
+	process := hook.ProcessHook(func(ctx context.Context, cmd redis.Cmder) error {
+		if cmd.Name() == "set" {
+			return errors.New("READONLY")
+		}
+		return redis.Nil
+	})
+	_ = process(context.Background(), redis.NewStringCmd(context.Background(), "get", "post:1"))
+	_ = process(context.Background(), redis.NewStatusCmd(context.Background(), "set", "post:1", "x"))
+
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	// Промах кеша - не ошибка
+	assert.Contains(t, w.Body.String(), `redis_command_duration_seconds_count{operation="get",status="ok"} 1`)
+	assert.Contains(t, w.Body.String(), `redis_command_duration_seconds_count{operation="set",status="error"} 1`)
+}
+
+func TestHandler_ExposesPoolStats(t *testing.T) {
+	m := New()
+	client := redis.NewClient(&redis.Options{Addr: "localhost:0"})
+	defer client.Close()
+	m.ObserveRedisPool(client.PoolStats)
+
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "redis_pool_connections_total")
+	assert.Contains(t, w.Body.String(), "redis_pool_timeouts_total")
+}
@@ -1,6 +1,11 @@
 package model
 
 import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -11,6 +16,8 @@ type PostStatus string
 const (
 	PostStatusDraft     PostStatus = "draft"
 	PostStatusPublished PostStatus = "published"
+	// PostStatusScheduled - черновик с publish_at: фоновая задача опубликует его в срок
+	PostStatusScheduled PostStatus = "scheduled"
 	PostStatusHidden    PostStatus = "hidden"
 	PostStatusDeleted   PostStatus = "deleted"
 )
@@ -26,10 +33,41 @@ type Post struct {
 	Views         int64              `bson:"views"`
 	CommentsCount int64              `bson:"comments_count"`
 	Slug          string             `bson:"slug"`
-	CreatedAt     time.Time          `bson:"created_at"`
-	UpdatedAt     time.Time          `bson:"updated_at"`
-	DeletedAt     *time.Time         `bson:"deleted_at,omitempty"`
-	Status        PostStatus         `bson:"status"`
+	SlugAliases   []string           `bson:"slug_aliases,omitempty"`
+	// Mentions - id пользователей, упомянутых в теле через @username (только существующие в auth-service)
+	Mentions  []string   `bson:"mentions,omitempty"`
+	CreatedAt time.Time  `bson:"created_at"`
+	UpdatedAt time.Time  `bson:"updated_at"`
+	DeletedAt *time.Time `bson:"deleted_at,omitempty"`
+	Status    PostStatus `bson:"status"`
+	// PublishAt - когда опубликовать запланированный пост; после публикации остается как есть
+	PublishAt *time.Time `bson:"publish_at,omitempty"`
+	// StatusBeforeDelete - статус до мягкого удаления, его возвращает restore
+	StatusBeforeDelete PostStatus `bson:"status_before_delete,omitempty" json:"-"`
+}
+
+// PostRevision - снимок поста до очередного редактирования
+type PostRevision struct {
+	ID       primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	PostID   primitive.ObjectID `bson:"post_id" json:"post_id"`
+	Title    string             `bson:"title" json:"title"`
+	Content  string             `bson:"content" json:"content"`
+	EditorID string             `bson:"editor_id" json:"editor_id"`
+	EditedAt time.Time          `bson:"edited_at" json:"edited_at"`
+}
+
+// ETag - хеш содержимого и времени изменения поста (в кавычках, как требует HTTP)
+func (p *Post) ETag() string {
+	h := sha256.New()
+	h.Write([]byte(p.ID.Hex()))
+	h.Write([]byte{0})
+	h.Write([]byte(p.Title))
+	h.Write([]byte{0})
+	h.Write([]byte(p.Content))
+	h.Write([]byte{0})
+	h.Write([]byte(p.UpdatedAt.UTC().Format(time.RFC3339Nano)))
+
+	return `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
 }
 
 type PaginatedPosts struct {
@@ -58,14 +96,148 @@ type PostLike struct {
 	CreatedAt time.Time          `bson:"created_at"`
 }
 
+type Follow struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty"`
+	FollowerID string             `bson:"follower_id"`
+	AuthorID   string             `bson:"author_id"`
+	CreatedAt  time.Time          `bson:"created_at"`
+}
+
+// FeedPage - страница ленты; NextCursor пустой, если дальше постов нет
+type FeedPage struct {
+	Items      []*Post `json:"items"`
+	NextCursor string  `json:"next_cursor"`
+}
+
+var ErrInvalidCursor = errors.New("invalid feed cursor")
+
+// FeedCursor - позиция в ленте для keyset-пагинации по (created_at, _id)
+type FeedCursor struct {
+	CreatedAt time.Time
+	ID        primitive.ObjectID
+}
+
+// Encode упаковывает курсор в непрозрачную для клиента строку
+func (c FeedCursor) Encode() string {
+	raw := c.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + c.ID.Hex()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// ParseFeedCursor - обратная операция к Encode
+func ParseFeedCursor(s string) (FeedCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return FeedCursor{}, ErrInvalidCursor
+	}
+
+	createdAt, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return FeedCursor{}, ErrInvalidCursor
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return FeedCursor{}, ErrInvalidCursor
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return FeedCursor{}, ErrInvalidCursor
+	}
+
+	return FeedCursor{CreatedAt: t, ID: objectID}, nil
+}
+
+type Bookmark struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	PostID    primitive.ObjectID `bson:"post_id"`
+	UserID    string             `bson:"user_id"`
+	CreatedAt time.Time          `bson:"created_at"`
+}
+
+// Author - публичные данные автора из auth-service
+type Author struct {
+	ID          string `json:"id"`
+	Username    string `json:"username"`
+	DisplayName string `json:"display_name,omitempty"`
+}
+
+// BookmarkedPost - пост из закладок; Author == nil, если auth-service не ответил или автора уже нет
+type BookmarkedPost struct {
+	Post   *Post   `json:"post"`
+	Author *Author `json:"author"`
+}
+
+// PaginatedBookmarks - закладки пользователя, недавно добавленные первыми
+type PaginatedBookmarks struct {
+	Items  []*BookmarkedPost `json:"items"`
+	Total  int64             `json:"total"`
+	Limit  int64             `json:"limit"`
+	Offset int64             `json:"offset"`
+}
+
+type ReportStatus string
+
+const (
+	ReportStatusOpen     ReportStatus = "open"
+	ReportStatusResolved ReportStatus = "resolved"
+)
+
+// Report - жалоба пользователя на пост. Открытая жалоба на пару (reporter, post) одна:
+// повторная не создает дубль, а после закрытия на пост можно пожаловаться снова
+type Report struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	PostID     primitive.ObjectID `bson:"post_id" json:"post_id"`
+	ReporterID string             `bson:"reporter_id" json:"reporter_id"`
+	Reason     string             `bson:"reason" json:"reason"`
+	Status     ReportStatus       `bson:"status" json:"status"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+	ResolvedAt *time.Time         `bson:"resolved_at,omitempty" json:"resolved_at,omitempty"`
+	ResolvedBy string             `bson:"resolved_by,omitempty" json:"resolved_by,omitempty"`
+	// PostHidden - при закрытии жалобы пост скрыт (status=hidden)
+	PostHidden bool `bson:"post_hidden,omitempty" json:"post_hidden,omitempty"`
+}
+
+// ReportWithPost - жалоба в очереди модерации; Post == nil, если пост уже удален
+type ReportWithPost struct {
+	Report *Report `json:"report"`
+	Post   *Post   `json:"post"`
+}
+
+// PaginatedReports - страница очереди модерации, старые жалобы первыми
+type PaginatedReports struct {
+	Items  []*ReportWithPost `json:"items"`
+	Total  int64             `json:"total"`
+	Limit  int64             `json:"limit"`
+	Offset int64             `json:"offset"`
+}
+
 type Comment struct {
 	ID         primitive.ObjectID `bson:"_id,omitempty"`
 	PostID     primitive.ObjectID `bson:"post_id"`
 	AuthorID   string             `bson:"author_id"`
 	Content    string             `bson:"content"`
 	LikesCount int64              `bson:"likes_count"`
-	CreatedAt  time.Time          `bson:"created_at"`
-	UpdatedAt  time.Time          `bson:"updated_at"`
+	// Mentions - id пользователей, упомянутых через @username, как у Post
+	Mentions  []string  `bson:"mentions,omitempty"`
+	CreatedAt time.Time `bson:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at"`
+}
+
+// EventTypeMention - тип события outbox о новом @-упоминании
+const EventTypeMention = "mention"
+
+// MentionEvent - запись outbox: пользователя UserID упомянули в посте PostID (или в комментарии
+// CommentID к нему). PublishedAt пустой, пока событие не забрала система уведомлений
+type MentionEvent struct {
+	ID          primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	Type        string              `bson:"type" json:"type"`
+	UserID      string              `bson:"user_id" json:"user_id"`
+	AuthorID    string              `bson:"author_id" json:"author_id"`
+	PostID      primitive.ObjectID  `bson:"post_id" json:"post_id"`
+	CommentID   *primitive.ObjectID `bson:"comment_id,omitempty" json:"comment_id,omitempty"`
+	CreatedAt   time.Time           `bson:"created_at" json:"created_at"`
+	PublishedAt *time.Time          `bson:"published_at,omitempty" json:"published_at,omitempty"`
 }
 
 type ListCommentsResult struct {
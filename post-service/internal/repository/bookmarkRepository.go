@@ -0,0 +1,256 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+type BookmarkRepository interface {
+	AddBookmark(ctx context.Context, postID, userID string) error
+	RemoveBookmark(ctx context.Context, postID, userID string) error
+	// ListBookmarkedPosts - посты из закладок, недавно добавленные первыми; Author не заполняется
+	ListBookmarkedPosts(ctx context.Context, userID string, limit, offset int64) (*model.PaginatedBookmarks, error)
+}
+
+type bookmarkRepo struct {
+	mongoClient *mongo.Client
+	dbName      string
+	logger      *zap.Logger
+}
+
+func NewBookmarkRepository(client *mongo.Client, dbName string, logger *zap.Logger) BookmarkRepository {
+	repo := &bookmarkRepo{
+		mongoClient: client,
+		dbName:      dbName,
+		logger:      logger,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := repo.ensureIndexes(ctx); err != nil {
+		logger.Fatal("failed to create bookmark indexes", zap.Error(err))
+	}
+
+	return repo
+}
+
+func (r *bookmarkRepo) bookmarksCollection() *mongo.Collection {
+	return r.mongoClient.Database(r.dbName).Collection("bookmarks")
+}
+
+func (r *bookmarkRepo) postsCollection() *mongo.Collection {
+	return r.mongoClient.Database(r.dbName).Collection("posts")
+}
+
+func (r *bookmarkRepo) ensureIndexes(ctx context.Context) error {
+
+	bookmarkIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "user_id", Value: 1},
+				{Key: "post_id", Value: 1},
+			},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{
+				{Key: "user_id", Value: 1},
+				{Key: "created_at", Value: -1},
+			},
+		},
+	}
+
+	_, err := r.bookmarksCollection().Indexes().CreateMany(ctx, bookmarkIndexes)
+	return err
+}
+
+// AddBookmark сохраняет пост в закладки. Повторное добавление - не ошибка.
+func (r *bookmarkRepo) AddBookmark(ctx context.Context, postID, userID string) error {
+
+	objectID, err := primitive.ObjectIDFromHex(postID)
+	if err != nil {
+		r.logger.Warn("invalid post id format",
+			zap.String("post_id", postID),
+		)
+		return ErrNotFound
+	}
+
+	// 1️⃣ пост должен существовать и не быть удаленным
+	postFilter := bson.M{
+		"_id":        objectID,
+		"deleted_at": bson.M{"$eq": nil},
+	}
+
+	err = r.postsCollection().FindOne(ctx, postFilter, options.FindOne().SetProjection(bson.M{"_id": 1})).Err()
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrNotFound
+		}
+		r.logger.Error("failed to check post before bookmark",
+			zap.Error(err),
+			zap.String("post_id", postID),
+		)
+		return err
+	}
+
+	bookmark := &model.Bookmark{
+		ID:        primitive.NewObjectID(),
+		PostID:    objectID,
+		UserID:    userID,
+		CreatedAt: time.Now(),
+	}
+
+	// 2️⃣ вставляем закладку
+	_, err = r.bookmarksCollection().InsertOne(ctx, bookmark)
+	if err != nil {
+
+		if mongo.IsDuplicateKeyError(err) {
+			// уже в закладках — просто выходим
+			return nil
+		}
+
+		r.logger.Error("failed to insert bookmark",
+			zap.Error(err),
+			zap.String("post_id", postID),
+			zap.String("user_id", userID),
+		)
+		return err
+	}
+
+	r.logger.Info("bookmark added",
+		zap.String("post_id", postID),
+		zap.String("user_id", userID),
+	)
+
+	return nil
+}
+
+func (r *bookmarkRepo) RemoveBookmark(ctx context.Context, postID, userID string) error {
+
+	objectID, err := primitive.ObjectIDFromHex(postID)
+	if err != nil {
+		return ErrNotFound
+	}
+
+	filter := bson.M{
+		"post_id": objectID,
+		"user_id": userID,
+	}
+
+	result, err := r.bookmarksCollection().DeleteOne(ctx, filter)
+	if err != nil {
+		r.logger.Error("failed to delete bookmark",
+			zap.Error(err),
+			zap.String("post_id", postID),
+			zap.String("user_id", userID),
+		)
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		r.logger.Warn("bookmark not found for delete",
+			zap.String("post_id", postID),
+			zap.String("user_id", userID),
+		)
+		return ErrNotFound
+	}
+
+	r.logger.Info("bookmark removed",
+		zap.String("post_id", postID),
+		zap.String("user_id", userID),
+	)
+
+	return nil
+}
+
+// ListBookmarkedPosts возвращает посты из закладок пользователя, недавно добавленные первыми.
+// Удаленные посты и чужие неопубликованные (черновик, скрытый модератором) пропускаются,
+// но учитываются в Total, пока закладка существует.
+func (r *bookmarkRepo) ListBookmarkedPosts(ctx context.Context, userID string, limit, offset int64) (*model.PaginatedBookmarks, error) {
+
+	filter := bson.M{"user_id": userID}
+
+	// 1️⃣ считаем total
+	total, err := r.bookmarksCollection().CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip(offset).
+		SetLimit(limit)
+
+	cursor, err := r.bookmarksCollection().Find(ctx, filter, opts)
+	if err != nil {
+		r.logger.Error("failed to list bookmarks",
+			zap.Error(err),
+			zap.String("user_id", userID),
+		)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var bookmarks []*model.Bookmark
+	if err := cursor.All(ctx, &bookmarks); err != nil {
+		return nil, err
+	}
+
+	page := &model.PaginatedBookmarks{
+		Items:  make([]*model.BookmarkedPost, 0, len(bookmarks)),
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	}
+	if len(bookmarks) == 0 {
+		return page, nil
+	}
+
+	// 2️⃣ достаем посты одним запросом
+	postIDs := make([]primitive.ObjectID, 0, len(bookmarks))
+	for _, b := range bookmarks {
+		postIDs = append(postIDs, b.PostID)
+	}
+
+	postsFilter := bson.M{
+		"_id":        bson.M{"$in": postIDs},
+		"deleted_at": bson.M{"$eq": nil},
+		"$or": bson.A{
+			bson.M{"status": model.PostStatusPublished},
+			bson.M{"author_id": userID},
+		},
+	}
+
+	postsCursor, err := r.postsCollection().Find(ctx, postsFilter)
+	if err != nil {
+		return nil, err
+	}
+	defer postsCursor.Close(ctx)
+
+	postsByID := make(map[primitive.ObjectID]*model.Post, len(bookmarks))
+	for postsCursor.Next(ctx) {
+		var post model.Post
+		if err := postsCursor.Decode(&post); err != nil {
+			return nil, err
+		}
+		postsByID[post.ID] = &post
+	}
+
+	// 3️⃣ сохраняем порядок закладок
+	for _, b := range bookmarks {
+		if post, ok := postsByID[b.PostID]; ok {
+			page.Items = append(page.Items, &model.BookmarkedPost{Post: post})
+		}
+	}
+
+	return page, nil
+}
@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+var ErrSelfFollow = errors.New("cannot follow yourself")
+
+type FollowRepository interface {
+	Follow(ctx context.Context, followerID, authorID string) error
+	Unfollow(ctx context.Context, followerID, authorID string) error
+	ListFollowing(ctx context.Context, followerID string) ([]string, error)
+	ListFollowers(ctx context.Context, authorID string) ([]string, error)
+}
+
+type followRepo struct {
+	mongoClient *mongo.Client
+	dbName      string
+	logger      *zap.Logger
+}
+
+func NewFollowRepository(client *mongo.Client, dbName string, logger *zap.Logger) FollowRepository {
+	repo := &followRepo{
+		mongoClient: client,
+		dbName:      dbName,
+		logger:      logger,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := repo.ensureIndexes(ctx); err != nil {
+		logger.Fatal("failed to create follow indexes", zap.Error(err))
+	}
+
+	return repo
+}
+
+func (r *followRepo) followsCollection() *mongo.Collection {
+	return r.mongoClient.Database(r.dbName).Collection("follows")
+}
+
+func (r *followRepo) ensureIndexes(ctx context.Context) error {
+
+	followIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "follower_id", Value: 1},
+				{Key: "author_id", Value: 1},
+			},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			// для инвалидации лент подписчиков при новом посте
+			Keys: bson.M{"author_id": 1},
+		},
+	}
+
+	_, err := r.followsCollection().Indexes().CreateMany(ctx, followIndexes)
+	return err
+}
+
+// Follow подписывает followerID на authorID. Повторная подписка - не ошибка.
+func (r *followRepo) Follow(ctx context.Context, followerID, authorID string) error {
+
+	if followerID == authorID {
+		return ErrSelfFollow
+	}
+
+	follow := &model.Follow{
+		ID:         primitive.NewObjectID(),
+		FollowerID: followerID,
+		AuthorID:   authorID,
+		CreatedAt:  time.Now(),
+	}
+
+	_, err := r.followsCollection().InsertOne(ctx, follow)
+	if err != nil {
+
+		if mongo.IsDuplicateKeyError(err) {
+			return nil
+		}
+
+		r.logger.Error("failed to insert follow",
+			zap.Error(err),
+			zap.String("follower_id", followerID),
+			zap.String("author_id", authorID),
+		)
+		return err
+	}
+
+	r.logger.Info("follow added",
+		zap.String("follower_id", followerID),
+		zap.String("author_id", authorID),
+	)
+
+	return nil
+}
+
+func (r *followRepo) Unfollow(ctx context.Context, followerID, authorID string) error {
+
+	filter := bson.M{
+		"follower_id": followerID,
+		"author_id":   authorID,
+	}
+
+	result, err := r.followsCollection().DeleteOne(ctx, filter)
+	if err != nil {
+		r.logger.Error("failed to delete follow",
+			zap.Error(err),
+			zap.String("follower_id", followerID),
+			zap.String("author_id", authorID),
+		)
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+
+	r.logger.Info("follow removed",
+		zap.String("follower_id", followerID),
+		zap.String("author_id", authorID),
+	)
+
+	return nil
+}
+
+// ListFollowing - на кого подписан пользователь
+func (r *followRepo) ListFollowing(ctx context.Context, followerID string) ([]string, error) {
+	return r.distinct(ctx, "author_id", bson.M{"follower_id": followerID})
+}
+
+// ListFollowers - кто подписан на автора
+func (r *followRepo) ListFollowers(ctx context.Context, authorID string) ([]string, error) {
+	return r.distinct(ctx, "follower_id", bson.M{"author_id": authorID})
+}
+
+func (r *followRepo) distinct(ctx context.Context, field string, filter bson.M) ([]string, error) {
+
+	values, err := r.followsCollection().Distinct(ctx, field, filter)
+	if err != nil {
+		r.logger.Error("failed to list follows",
+			zap.Error(err),
+			zap.String("field", field),
+		)
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(values))
+	for _, v := range values {
+		if id, ok := v.(string); ok {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// OutboxRepository - события для других сервисов (коллекция outbox). Отправкой занимается
+// тот, кто их читает: неотправленные - с пустым published_at, старые первыми
+type OutboxRepository interface {
+	AddMentionEvents(ctx context.Context, events []*model.MentionEvent) error
+}
+
+type outboxRepo struct {
+	mongoClient *mongo.Client
+	dbName      string
+	logger      *zap.Logger
+}
+
+func NewOutboxRepository(client *mongo.Client, dbName string, logger *zap.Logger) OutboxRepository {
+	repo := &outboxRepo{
+		mongoClient: client,
+		dbName:      dbName,
+		logger:      logger,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := repo.ensureIndexes(ctx); err != nil {
+		logger.Fatal("failed to create outbox indexes", zap.Error(err))
+	}
+
+	return repo
+}
+
+func (r *outboxRepo) outboxCollection() *mongo.Collection {
+	return r.mongoClient.Database(r.dbName).Collection("outbox")
+}
+
+func (r *outboxRepo) ensureIndexes(ctx context.Context) error {
+
+	outboxIndexes := []mongo.IndexModel{
+		{
+			// выборка неотправленных событий по порядку
+			Keys: bson.D{
+				{Key: "published_at", Value: 1},
+				{Key: "created_at", Value: 1},
+			},
+		},
+	}
+
+	_, err := r.outboxCollection().Indexes().CreateMany(ctx, outboxIndexes)
+	return err
+}
+
+// AddMentionEvents сохраняет события одной вставкой; ID и CreatedAt заполняются здесь
+func (r *outboxRepo) AddMentionEvents(ctx context.Context, events []*model.MentionEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	docs := make([]any, 0, len(events))
+	for _, event := range events {
+		event.ID = primitive.NewObjectID()
+		event.Type = model.EventTypeMention
+		event.CreatedAt = now
+		docs = append(docs, event)
+	}
+
+	if _, err := r.outboxCollection().InsertMany(ctx, docs); err != nil {
+		r.logger.Error("failed to insert mention events",
+			zap.Error(err),
+			zap.Int("events", len(events)),
+		)
+		return err
+	}
+
+	return nil
+}
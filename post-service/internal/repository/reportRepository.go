@@ -0,0 +1,276 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+// ErrReportNotFound - открытой жалобы с таким ID нет (не было или уже закрыта)
+var ErrReportNotFound = errors.New("report not found")
+
+type ReportRepository interface {
+	// CreateReport сохраняет жалобу на неудаленный пост. Повторная жалоба пользователя
+	// на пост, по которому у него уже есть открытая, - не ошибка и не дубль
+	CreateReport(ctx context.Context, report *model.Report) error
+	// GetOpenReport - открытая жалоба по ID, иначе ErrReportNotFound
+	GetOpenReport(ctx context.Context, id string) (*model.Report, error)
+	// ListOpenReports - очередь модерации (старые первыми) вместе с постами
+	ListOpenReports(ctx context.Context, limit, offset int64) (*model.PaginatedReports, error)
+	// ResolveReport закрывает открытую жалобу, иначе ErrReportNotFound
+	ResolveReport(ctx context.Context, id, resolverID string, postHidden bool) (*model.Report, error)
+}
+
+type reportRepo struct {
+	mongoClient *mongo.Client
+	dbName      string
+	logger      *zap.Logger
+}
+
+func NewReportRepository(client *mongo.Client, dbName string, logger *zap.Logger) ReportRepository {
+	repo := &reportRepo{
+		mongoClient: client,
+		dbName:      dbName,
+		logger:      logger,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := repo.ensureIndexes(ctx); err != nil {
+		logger.Fatal("failed to create report indexes", zap.Error(err))
+	}
+
+	return repo
+}
+
+func (r *reportRepo) reportsCollection() *mongo.Collection {
+	return r.mongoClient.Database(r.dbName).Collection("reports")
+}
+
+func (r *reportRepo) postsCollection() *mongo.Collection {
+	return r.mongoClient.Database(r.dbName).Collection("posts")
+}
+
+func (r *reportRepo) ensureIndexes(ctx context.Context) error {
+
+	reportIndexes := []mongo.IndexModel{
+		{
+			// Уникальность только среди открытых: закрытые жалобы не мешают пожаловаться снова
+			Keys: bson.D{
+				{Key: "reporter_id", Value: 1},
+				{Key: "post_id", Value: 1},
+			},
+			Options: options.Index().
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"status": model.ReportStatusOpen}),
+		},
+		{
+			Keys: bson.D{
+				{Key: "status", Value: 1},
+				{Key: "created_at", Value: 1},
+			},
+		},
+	}
+
+	_, err := r.reportsCollection().Indexes().CreateMany(ctx, reportIndexes)
+	return err
+}
+
+func (r *reportRepo) CreateReport(ctx context.Context, report *model.Report) error {
+
+	// 1️⃣ пост должен существовать и не быть удаленным
+	postFilter := bson.M{
+		"_id":        report.PostID,
+		"deleted_at": bson.M{"$eq": nil},
+	}
+
+	err := r.postsCollection().FindOne(ctx, postFilter, options.FindOne().SetProjection(bson.M{"_id": 1})).Err()
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrNotFound
+		}
+		r.logger.Error("failed to check post before report",
+			zap.Error(err),
+			zap.String("post_id", report.PostID.Hex()),
+		)
+		return err
+	}
+
+	report.ID = primitive.NewObjectID()
+	report.Status = model.ReportStatusOpen
+	report.CreatedAt = time.Now()
+
+	// 2️⃣ вставляем жалобу
+	_, err = r.reportsCollection().InsertOne(ctx, report)
+	if err != nil {
+
+		if mongo.IsDuplicateKeyError(err) {
+			// уже есть открытая жалоба этого пользователя — просто выходим
+			return nil
+		}
+
+		r.logger.Error("failed to insert report",
+			zap.Error(err),
+			zap.String("post_id", report.PostID.Hex()),
+			zap.String("reporter_id", report.ReporterID),
+		)
+		return err
+	}
+
+	r.logger.Info("post reported",
+		zap.String("report_id", report.ID.Hex()),
+		zap.String("post_id", report.PostID.Hex()),
+		zap.String("reporter_id", report.ReporterID),
+	)
+
+	return nil
+}
+
+func (r *reportRepo) GetOpenReport(ctx context.Context, id string) (*model.Report, error) {
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrReportNotFound
+	}
+
+	filter := bson.M{
+		"_id":    objectID,
+		"status": model.ReportStatusOpen,
+	}
+
+	var report model.Report
+	err = r.reportsCollection().FindOne(ctx, filter).Decode(&report)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrReportNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &report, nil
+}
+
+// ListOpenReports достает жалобы и их посты двумя запросами, как ListBookmarkedPosts
+func (r *reportRepo) ListOpenReports(ctx context.Context, limit, offset int64) (*model.PaginatedReports, error) {
+
+	filter := bson.M{"status": model.ReportStatusOpen}
+
+	// 1️⃣ считаем total
+	total, err := r.reportsCollection().CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}}).
+		SetSkip(offset).
+		SetLimit(limit)
+
+	cursor, err := r.reportsCollection().Find(ctx, filter, opts)
+	if err != nil {
+		r.logger.Error("failed to list reports", zap.Error(err))
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var reports []*model.Report
+	if err := cursor.All(ctx, &reports); err != nil {
+		return nil, err
+	}
+
+	page := &model.PaginatedReports{
+		Items:  make([]*model.ReportWithPost, 0, len(reports)),
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	}
+	if len(reports) == 0 {
+		return page, nil
+	}
+
+	// 2️⃣ достаем посты одним запросом; скрытые тоже нужны модератору
+	postIDs := make([]primitive.ObjectID, 0, len(reports))
+	for _, report := range reports {
+		postIDs = append(postIDs, report.PostID)
+	}
+
+	postsFilter := bson.M{
+		"_id":        bson.M{"$in": postIDs},
+		"deleted_at": bson.M{"$eq": nil},
+	}
+
+	postsCursor, err := r.postsCollection().Find(ctx, postsFilter)
+	if err != nil {
+		return nil, err
+	}
+	defer postsCursor.Close(ctx)
+
+	postsByID := make(map[primitive.ObjectID]*model.Post, len(reports))
+	for postsCursor.Next(ctx) {
+		var post model.Post
+		if err := postsCursor.Decode(&post); err != nil {
+			return nil, err
+		}
+		postsByID[post.ID] = &post
+	}
+	if err := postsCursor.Err(); err != nil {
+		return nil, err
+	}
+
+	// 3️⃣ сохраняем порядок очереди
+	for _, report := range reports {
+		page.Items = append(page.Items, &model.ReportWithPost{Report: report, Post: postsByID[report.PostID]})
+	}
+
+	return page, nil
+}
+
+func (r *reportRepo) ResolveReport(ctx context.Context, id, resolverID string, postHidden bool) (*model.Report, error) {
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrReportNotFound
+	}
+
+	filter := bson.M{
+		"_id":    objectID,
+		"status": model.ReportStatusOpen,
+	}
+	update := bson.M{"$set": bson.M{
+		"status":      model.ReportStatusResolved,
+		"resolved_at": time.Now(),
+		"resolved_by": resolverID,
+		"post_hidden": postHidden,
+	}}
+
+	var report model.Report
+	err = r.reportsCollection().FindOneAndUpdate(ctx, filter, update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&report)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrReportNotFound
+	}
+	if err != nil {
+		r.logger.Error("failed to resolve report",
+			zap.Error(err),
+			zap.String("report_id", id),
+		)
+		return nil, err
+	}
+
+	r.logger.Info("report resolved",
+		zap.String("report_id", id),
+		zap.String("resolver_id", resolverID),
+		zap.Bool("post_hidden", postHidden),
+	)
+
+	return &report, nil
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/gsrlabs/micro-blog-hub/post-service/internal/model"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/slug"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -16,15 +17,29 @@ import (
 var (
 	ErrNotFound   = errors.New("post not found")
 	ErrSlugExists = errors.New("slug already exists")
+	// ErrNotDraft - запланировать можно только черновик (или перенести уже запланированный пост)
+	ErrNotDraft = errors.New("post is not a draft")
 )
 
 type PostRepository interface {
 	Create(ctx context.Context, post *model.Post) error
 	GetByID(ctx context.Context, id string) (*model.Post, error)
 	GetBySlug(ctx context.Context, slug string) (*model.Post, error)
-	Update(ctx context.Context, post *model.Post) error
+	Update(ctx context.Context, post *model.Post, editorID string) error
+	RegenerateSlug(ctx context.Context, id string) (string, error)
+	ListRevisions(ctx context.Context, postID string) ([]*model.PostRevision, error)
+	// MarkAsDeleted - мягкое удаление: пост пропадает из всех чтений, но остается в базе
 	MarkAsDeleted(ctx context.Context, id string) error
+	// Restore отменяет мягкое удаление; authorID != "" - только если пост этого автора
+	Restore(ctx context.Context, id, authorID string) (*model.Post, error)
+	// Hide скрывает неудаленный пост (status=hidden) по решению модератора
+	Hide(ctx context.Context, id string) (*model.Post, error)
+	// Delete удаляет пост навсегда вместе с лайками, закладками и ревизиями
 	Delete(ctx context.Context, id string) error
+	// Schedule переводит черновик в scheduled с publish_at; ErrNotDraft, если пост уже не черновик
+	Schedule(ctx context.Context, id string, publishAt time.Time) (*model.Post, error)
+	// PublishDue публикует до limit запланированных постов с publish_at <= now и возвращает их
+	PublishDue(ctx context.Context, now time.Time, limit int64) ([]*model.Post, error)
 	ListPostsAdvanced(
 		ctx context.Context,
 		userID string,
@@ -38,19 +53,33 @@ type PostRepository interface {
 	AddLike(ctx context.Context, id, user string) error
 	RemoveLike(ctx context.Context, id, user string) error
 	IsLikedByUser(ctx context.Context, id, userID string) (bool, error)
+	ListFeed(ctx context.Context, authorIDs []string, cursor *model.FeedCursor, limit int64) (*model.FeedPage, error)
+	// ListRelated - до limit опубликованных постов с общими с post тегами, больше общих - выше
+	ListRelated(ctx context.Context, post *model.Post, limit int64) ([]*model.Post, error)
+	// CountByAuthor - число неудаленных постов автора; без includeDrafts - только опубликованные
+	CountByAuthor(ctx context.Context, authorID string, includeDrafts bool) (int64, error)
+	// StreamByAuthor обходит все неудаленные посты автора (любой статус, created_at DESC)
+	// курсором, не загружая их в память. Ошибка fn останавливает обход и возвращается как есть.
+	StreamByAuthor(ctx context.Context, authorID string, fn func(*model.Post) error) error
 }
 
 type postRepo struct {
-	mongoClient *mongo.Client
-	dbName      string
-	logger      *zap.Logger
+	mongoClient  *mongo.Client
+	dbName       string
+	maxRevisions int
+	opTimeout    time.Duration
+	logger       *zap.Logger
 }
 
-func NewPostRepository(client *mongo.Client, dbName string, logger *zap.Logger) PostRepository {
+// NewPostRepository создает репозиторий. opTimeout ограничивает каждый вызов метода,
+// чтобы медленная Mongo не вешала запрос; 0 - без ограничения сверх контекста запроса.
+func NewPostRepository(client *mongo.Client, dbName string, maxRevisions int, opTimeout time.Duration, logger *zap.Logger) PostRepository {
 	repo := &postRepo{
-		mongoClient: client,
-		dbName:      dbName,
-		logger:      logger,
+		mongoClient:  client,
+		dbName:       dbName,
+		maxRevisions: maxRevisions,
+		opTimeout:    opTimeout,
+		logger:       logger,
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -63,6 +92,15 @@ func NewPostRepository(client *mongo.Client, dbName string, logger *zap.Logger)
 	return repo
 }
 
+// withTimeout - дедлайн на одну операцию репозитория. Более ранний дедлайн запроса сохраняется.
+// По истечении драйвер вернет ошибку с context.DeadlineExceeded - хендлер отдает на нее 504.
+func (r *postRepo) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if r.opTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, r.opTimeout)
+}
+
 func (r *postRepo) PostCollection() *mongo.Collection {
 	return r.mongoClient.Database(r.dbName).Collection("posts")
 }
@@ -71,6 +109,14 @@ func (r *postRepo) likesCollection() *mongo.Collection {
 	return r.mongoClient.Database(r.dbName).Collection("post_likes")
 }
 
+func (r *postRepo) revisionsCollection() *mongo.Collection {
+	return r.mongoClient.Database(r.dbName).Collection("post_revisions")
+}
+
+func (r *postRepo) bookmarksCollection() *mongo.Collection {
+	return r.mongoClient.Database(r.dbName).Collection("bookmarks")
+}
+
 func (r *postRepo) ensureIndexes(ctx context.Context) error {
 
 	postIndexes := []mongo.IndexModel{
@@ -78,6 +124,14 @@ func (r *postRepo) ensureIndexes(ctx context.Context) error {
 			Keys:    bson.M{"slug": 1},
 			Options: options.Index().SetUnique(true),
 		},
+		{
+			// старые slug после переименования, для редиректа
+			Keys: bson.M{"slug_aliases": 1},
+		},
+		{
+			// посты, где упомянут пользователь
+			Keys: bson.M{"mentions": 1},
+		},
 		{
 			Keys: bson.M{"tags": 1},
 		},
@@ -90,6 +144,21 @@ func (r *postRepo) ensureIndexes(ctx context.Context) error {
 		{
 			Keys: bson.M{"created_at": -1},
 		},
+		{
+			// фоновая публикация: запланированные посты по сроку
+			Keys: bson.D{
+				{Key: "status", Value: 1},
+				{Key: "publish_at", Value: 1},
+			},
+		},
+		{
+			// лента: посты авторов по убыванию (created_at, _id)
+			Keys: bson.D{
+				{Key: "author_id", Value: 1},
+				{Key: "created_at", Value: -1},
+				{Key: "_id", Value: -1},
+			},
+		},
 	}
 
 	likesIndexes := []mongo.IndexModel{
@@ -102,18 +171,56 @@ func (r *postRepo) ensureIndexes(ctx context.Context) error {
 		},
 	}
 
-	_, err := r.PostCollection().Indexes().CreateMany(ctx, postIndexes)
-	_, err = r.likesCollection().Indexes().CreateMany(ctx, likesIndexes)
+	revisionsIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "post_id", Value: 1},
+				{Key: "edited_at", Value: -1},
+			},
+		},
+	}
+
+	if _, err := r.PostCollection().Indexes().CreateMany(ctx, postIndexes); err != nil {
+		return err
+	}
+	if _, err := r.likesCollection().Indexes().CreateMany(ctx, likesIndexes); err != nil {
+		return err
+	}
+	_, err := r.revisionsCollection().Indexes().CreateMany(ctx, revisionsIndexes)
 
 	return err
 }
 
+// maxSlugAttempts - сколько раз пробуем вставить пост со сгенерированным slug,
+// если параллельный запрос занял тот же slug между проверкой и вставкой
+const maxSlugAttempts = 3
+
+// Create сохраняет пост. Если slug не задан, он генерируется из заголовка.
 func (r *postRepo) Create(ctx context.Context, post *model.Post) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
 	post.ID = primitive.NewObjectID()
 	post.CreatedAt = time.Now()
 	post.UpdatedAt = time.Now()
 
-	_, err := r.PostCollection().InsertOne(ctx, post)
+	generated := post.Slug == ""
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		if generated {
+			post.Slug, err = r.uniqueSlug(ctx, post.Title, post.ID)
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err = r.PostCollection().InsertOne(ctx, post)
+		if err == nil || !generated || !mongo.IsDuplicateKeyError(err) || attempt == maxSlugAttempts {
+			break
+		}
+	}
+
 	if err != nil {
 
 		if mongo.IsDuplicateKeyError(err) {
@@ -138,6 +245,8 @@ func (r *postRepo) Create(ctx context.Context, post *model.Post) error {
 }
 
 func (r *postRepo) GetByID(ctx context.Context, id string) (*model.Post, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
 
 	// 1️⃣ Конвертируем string → ObjectID
 	objectID, err := primitive.ObjectIDFromHex(id)
@@ -177,10 +286,17 @@ func (r *postRepo) GetByID(ctx context.Context, id string) (*model.Post, error)
 	return &post, nil
 }
 
+// GetBySlug ищет пост по текущему slug или по одному из старых.
+// Если post.Slug отличается от запрошенного, клиента стоит редиректнуть.
 func (r *postRepo) GetBySlug(ctx context.Context, slug string) (*model.Post, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
 
 	filter := bson.M{
-		"slug":       slug,
+		"$or": bson.A{
+			bson.M{"slug": slug},
+			bson.M{"slug_aliases": slug},
+		},
 		"deleted_at": bson.M{"$eq": nil},
 	}
 
@@ -206,7 +322,10 @@ func (r *postRepo) GetBySlug(ctx context.Context, slug string) (*model.Post, err
 	return &post, nil
 }
 
-func (r *postRepo) Update(ctx context.Context, post *model.Post) error {
+// Update сохраняет пост, а предыдущие title/content складывает в post_revisions
+func (r *postRepo) Update(ctx context.Context, post *model.Post, editorID string) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
 
 	if post.ID.IsZero() {
 		return ErrNotFound
@@ -228,13 +347,26 @@ func (r *postRepo) Update(ctx context.Context, post *model.Post) error {
 			"likes_count":    post.LikesCount,
 			"comments_count": post.CommentsCount,
 			"slug":           post.Slug,
+			"mentions":       post.Mentions,
 			"updated_at":     post.UpdatedAt,
 		},
 	}
 
-	result, err := r.PostCollection().UpdateOne(ctx, filter, update)
+	// Возвращаем документ ДО изменения - из него и делаем ревизию
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.Before)
+
+	var previous model.Post
+
+	err := r.PostCollection().FindOneAndUpdate(ctx, filter, update, opts).Decode(&previous)
 	if err != nil {
 
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			r.logger.Warn("post not found for update",
+				zap.String("post_id", post.ID.Hex()),
+			)
+			return ErrNotFound
+		}
+
 		if mongo.IsDuplicateKeyError(err) {
 			r.logger.Warn("slug already exists",
 				zap.String("slug", post.Slug),
@@ -250,21 +382,111 @@ func (r *postRepo) Update(ctx context.Context, post *model.Post) error {
 		return err
 	}
 
-	if result.MatchedCount == 0 {
-		r.logger.Warn("post not found for update",
-			zap.String("post_id", post.ID.Hex()),
-		)
-		return ErrNotFound
-	}
-
 	r.logger.Info("post updated",
 		zap.String("post_id", post.ID.Hex()),
 	)
 
+	if previous.Title != post.Title || previous.Content != post.Content {
+		// Пост уже обновлен, потеря ревизии не должна ломать запрос
+		if err := r.saveRevision(ctx, &previous, editorID, post.UpdatedAt); err != nil {
+			r.logger.Error("failed to save post revision",
+				zap.Error(err),
+				zap.String("post_id", post.ID.Hex()),
+			)
+		}
+	}
+
 	return nil
 }
 
+func (r *postRepo) saveRevision(ctx context.Context, previous *model.Post, editorID string, editedAt time.Time) error {
+	if r.maxRevisions <= 0 {
+		return nil
+	}
+
+	revision := &model.PostRevision{
+		ID:       primitive.NewObjectID(),
+		PostID:   previous.ID,
+		Title:    previous.Title,
+		Content:  previous.Content,
+		EditorID: editorID,
+		EditedAt: editedAt,
+	}
+
+	if _, err := r.revisionsCollection().InsertOne(ctx, revision); err != nil {
+		return err
+	}
+
+	return r.pruneRevisions(ctx, previous.ID)
+}
+
+// pruneRevisions удаляет все ревизии поста, кроме maxRevisions самых свежих
+func (r *postRepo) pruneRevisions(ctx context.Context, postID primitive.ObjectID) error {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "edited_at", Value: -1}}).
+		SetSkip(int64(r.maxRevisions)).
+		SetProjection(bson.M{"_id": 1})
+
+	cursor, err := r.revisionsCollection().Find(ctx, bson.M{"post_id": postID}, opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	var stale []model.PostRevision
+	if err := cursor.All(ctx, &stale); err != nil {
+		return err
+	}
+
+	if len(stale) == 0 {
+		return nil
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(stale))
+	for _, rev := range stale {
+		ids = append(ids, rev.ID)
+	}
+
+	_, err = r.revisionsCollection().DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	return err
+}
+
+// ListRevisions возвращает историю правок поста, новые первыми
+func (r *postRepo) ListRevisions(ctx context.Context, postID string) ([]*model.PostRevision, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(postID)
+	if err != nil {
+		r.logger.Warn("invalid post id format",
+			zap.String("post_id", postID),
+		)
+		return nil, ErrNotFound
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "edited_at", Value: -1}})
+
+	cursor, err := r.revisionsCollection().Find(ctx, bson.M{"post_id": objectID}, opts)
+	if err != nil {
+		r.logger.Error("failed to list post revisions",
+			zap.Error(err),
+			zap.String("post_id", postID),
+		)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	revisions := make([]*model.PostRevision, 0)
+	if err := cursor.All(ctx, &revisions); err != nil {
+		return nil, err
+	}
+
+	return revisions, nil
+}
+
 func (r *postRepo) MarkAsDeleted(ctx context.Context, id string) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
 
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
@@ -279,12 +501,15 @@ func (r *postRepo) MarkAsDeleted(ctx context.Context, id string) error {
 		"deleted_at": bson.M{"$eq": nil},
 	}
 
-	update := bson.M{
-		"$set": bson.M{
-			"deleted_at": time.Now(),
-			"status":     "deleted",
-			"updated_at": time.Now(),
-		},
+	// Pipeline-обновление: прежний статус запоминается из самого документа, чтобы restore его вернул
+	now := time.Now()
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"status_before_delete": "$status",
+			"deleted_at":           now,
+			"status":               model.PostStatusDeleted,
+			"updated_at":           now,
+		}}},
 	}
 
 	result, err := r.PostCollection().UpdateOne(ctx, filter, update)
@@ -310,7 +535,107 @@ func (r *postRepo) MarkAsDeleted(ctx context.Context, id string) error {
 	return nil
 }
 
+func (r *postRepo) Restore(ctx context.Context, id, authorID string) (*model.Post, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		r.logger.Warn("invalid post id format",
+			zap.String("post_id", id),
+		)
+		return nil, ErrNotFound
+	}
+
+	filter := bson.M{
+		"_id":        objectID,
+		"deleted_at": bson.M{"$ne": nil},
+	}
+	if authorID != "" {
+		filter["author_id"] = authorID
+	}
+
+	// Посты, удаленные до появления status_before_delete, возвращаются черновиками:
+	// прежний статус неизвестен, а молча опубликовать скрытый или черновик нельзя
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"status":     bson.M{"$ifNull": bson.A{"$status_before_delete", model.PostStatusDraft}},
+			"updated_at": time.Now(),
+		}}},
+		{{Key: "$unset", Value: bson.A{"deleted_at", "status_before_delete"}}},
+	}
+
+	var post model.Post
+	err = r.PostCollection().FindOneAndUpdate(ctx, filter, update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&post)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		r.logger.Warn("deleted post not found for restore",
+			zap.String("post_id", id),
+		)
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		r.logger.Error("failed to restore post",
+			zap.Error(err),
+			zap.String("post_id", id),
+		)
+		return nil, err
+	}
+
+	r.logger.Info("post restored",
+		zap.String("post_id", id),
+	)
+
+	return &post, nil
+}
+
+func (r *postRepo) Hide(ctx context.Context, id string) (*model.Post, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		r.logger.Warn("invalid post id format",
+			zap.String("post_id", id),
+		)
+		return nil, ErrNotFound
+	}
+
+	filter := bson.M{
+		"_id":        objectID,
+		"deleted_at": bson.M{"$eq": nil},
+	}
+	update := bson.M{"$set": bson.M{
+		"status":     model.PostStatusHidden,
+		"updated_at": time.Now(),
+	}}
+
+	var post model.Post
+	err = r.PostCollection().FindOneAndUpdate(ctx, filter, update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&post)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		r.logger.Error("failed to hide post",
+			zap.Error(err),
+			zap.String("post_id", id),
+		)
+		return nil, err
+	}
+
+	r.logger.Info("post hidden",
+		zap.String("post_id", id),
+	)
+
+	return &post, nil
+}
+
 func (r *postRepo) Delete(ctx context.Context, id string) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
 
 	// 1️⃣ Конвертация ID
 	objectID, err := primitive.ObjectIDFromHex(id)
@@ -351,6 +676,24 @@ func (r *postRepo) Delete(ctx context.Context, id string) error {
 		return err
 	}
 
+	_, err = r.bookmarksCollection().DeleteMany(ctx, bson.M{"post_id": objectID})
+	if err != nil {
+		r.logger.Error("failed to delete post bookmarks",
+			zap.Error(err),
+			zap.String("post_id", id),
+		)
+		return err
+	}
+
+	_, err = r.revisionsCollection().DeleteMany(ctx, bson.M{"post_id": objectID})
+	if err != nil {
+		r.logger.Error("failed to delete post revisions",
+			zap.Error(err),
+			zap.String("post_id", id),
+		)
+		return err
+	}
+
 	r.logger.Info("post deleted",
 		zap.String("post_id", id),
 	)
@@ -358,7 +701,121 @@ func (r *postRepo) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
+func (r *postRepo) Schedule(ctx context.Context, id string, publishAt time.Time) (*model.Post, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		r.logger.Warn("invalid post id format",
+			zap.String("post_id", id),
+		)
+		return nil, ErrNotFound
+	}
+
+	// Статус проверяется в том же запросе: параллельная публикация или удаление не перетрутся
+	filter := bson.M{
+		"_id":        objectID,
+		"deleted_at": bson.M{"$eq": nil},
+		"status":     bson.M{"$in": bson.A{model.PostStatusDraft, model.PostStatusScheduled}},
+	}
+	update := bson.M{"$set": bson.M{
+		"status":     model.PostStatusScheduled,
+		"publish_at": publishAt,
+		"updated_at": time.Now(),
+	}}
+
+	var post model.Post
+	err = r.PostCollection().FindOneAndUpdate(ctx, filter, update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&post)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotDraft
+	}
+	if err != nil {
+		r.logger.Error("failed to schedule post",
+			zap.Error(err),
+			zap.String("post_id", id),
+		)
+		return nil, err
+	}
+
+	r.logger.Info("post scheduled",
+		zap.String("post_id", id),
+		zap.Time("publish_at", publishAt),
+	)
+
+	return &post, nil
+}
+
+// PublishDue публикует каждый пост отдельным условным обновлением: пост, который за это время
+// перенесли, удалили или опубликовала другая реплика, просто пропускается
+func (r *postRepo) PublishDue(ctx context.Context, now time.Time, limit int64) ([]*model.Post, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	due := bson.M{
+		"status":     model.PostStatusScheduled,
+		"publish_at": bson.M{"$lte": now},
+		"deleted_at": bson.M{"$eq": nil},
+	}
+
+	cursor, err := r.PostCollection().Find(ctx, due,
+		options.Find().
+			SetSort(bson.D{{Key: "publish_at", Value: 1}}).
+			SetLimit(limit).
+			SetProjection(bson.M{"_id": 1}),
+	)
+	if err != nil {
+		r.logger.Error("failed to find scheduled posts",
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
+	var candidates []model.Post
+	if err := cursor.All(ctx, &candidates); err != nil {
+		return nil, err
+	}
+
+	published := make([]*model.Post, 0, len(candidates))
+	for _, candidate := range candidates {
+		filter := bson.M{"_id": candidate.ID}
+		for k, v := range due {
+			filter[k] = v
+		}
+		update := bson.M{"$set": bson.M{
+			"status":     model.PostStatusPublished,
+			"updated_at": time.Now(),
+		}}
+
+		var post model.Post
+		err := r.PostCollection().FindOneAndUpdate(ctx, filter, update,
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(&post)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			continue
+		}
+		if err != nil {
+			r.logger.Error("failed to publish scheduled post",
+				zap.Error(err),
+				zap.String("post_id", candidate.ID.Hex()),
+			)
+			return published, err
+		}
+
+		r.logger.Info("scheduled post published",
+			zap.String("post_id", post.ID.Hex()),
+		)
+		published = append(published, &post)
+	}
+
+	return published, nil
+}
+
 func (r *postRepo) IncrementViews(ctx context.Context, id string) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
 
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
@@ -399,6 +856,8 @@ func (r *postRepo) IncrementViews(ctx context.Context, id string) error {
 }
 
 func (r *postRepo) AddLike(ctx context.Context, id, userID string) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
 
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
@@ -451,6 +910,9 @@ func (r *postRepo) AddLike(ctx context.Context, id, userID string) error {
 }
 
 func (r *postRepo) RemoveLike(ctx context.Context, id, likeID string) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
 	postObjectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return ErrNotFound
@@ -528,6 +990,8 @@ func (r *postRepo) recalculateLikesCount(ctx context.Context, postID primitive.O
 }
 
 func (r *postRepo) IsLikedByUser(ctx context.Context, postID, userID string) (bool, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
 
 	objectID, err := primitive.ObjectIDFromHex(postID)
 	if err != nil {
@@ -561,6 +1025,8 @@ func (r *postRepo) GetPostWithLikeState(
 	postID string,
 	userID string,
 ) (*model.PostWithLikeState, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
 
 	objectID, err := primitive.ObjectIDFromHex(postID)
 	if err != nil {
@@ -605,6 +1071,25 @@ func (r *postRepo) GetPostWithLikeState(
 	}, nil
 }
 
+// listPostsFilter - публичный список: только опубликованные, не удаленные посты.
+// Черновики, запланированные и скрытые в общий список не попадают
+func listPostsFilter(topic, tag string) bson.M {
+	filter := bson.M{
+		"deleted_at": bson.M{"$eq": nil},
+		"status":     model.PostStatusPublished,
+	}
+
+	if topic != "" {
+		filter["topic"] = topic
+	}
+
+	if tag != "" {
+		filter["tags"] = tag
+	}
+
+	return filter
+}
+
 func (r *postRepo) ListPostsAdvanced(
 	ctx context.Context,
 	userID string,
@@ -614,6 +1099,8 @@ func (r *postRepo) ListPostsAdvanced(
 	sortOrder int,
 	page, limit int64,
 ) (*model.PaginatedPostsWithLikeState, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
 
 	if limit <= 0 || limit > 100 {
 		limit = 10
@@ -628,17 +1115,7 @@ func (r *postRepo) ListPostsAdvanced(
 	}
 
 	// 🔥 1️⃣ динамический фильтр
-	filter := bson.M{
-		"deleted_at": bson.M{"$eq": nil},
-	}
-
-	if topic != "" {
-		filter["topic"] = topic
-	}
-
-	if tag != "" {
-		filter["tags"] = tag
-	}
+	filter := listPostsFilter(topic, tag)
 
 	// 🔥 2️⃣ определяем поле сортировки
 	sortField := "created_at"
@@ -725,3 +1202,261 @@ func (r *postRepo) ListPostsAdvanced(
 		Limit: limit,
 	}, nil
 }
+
+// ListFeed возвращает опубликованные посты указанных авторов, новые первыми.
+// Пагинация по курсору (created_at, _id): в отличие от skip не съезжает,
+// когда в начало ленты добавляются новые посты.
+func (r *postRepo) ListFeed(
+	ctx context.Context,
+	authorIDs []string,
+	cursor *model.FeedCursor,
+	limit int64,
+) (*model.FeedPage, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	if limit <= 0 || limit > 100 {
+		limit = 10
+	}
+
+	if len(authorIDs) == 0 {
+		return &model.FeedPage{Items: []*model.Post{}}, nil
+	}
+
+	filter := bson.M{
+		"author_id":  bson.M{"$in": authorIDs},
+		"status":     model.PostStatusPublished,
+		"deleted_at": bson.M{"$eq": nil},
+	}
+
+	if cursor != nil {
+		filter["$or"] = bson.A{
+			bson.M{"created_at": bson.M{"$lt": cursor.CreatedAt}},
+			bson.M{"created_at": cursor.CreatedAt, "_id": bson.M{"$lt": cursor.ID}},
+		}
+	}
+
+	// Берем на один больше, чтобы понять, есть ли следующая страница
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetLimit(limit + 1)
+
+	cur, err := r.PostCollection().Find(ctx, filter, opts)
+	if err != nil {
+		r.logger.Error("failed to list feed",
+			zap.Error(err),
+			zap.Int("authors", len(authorIDs)),
+		)
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	posts := make([]*model.Post, 0, limit+1)
+	if err := cur.All(ctx, &posts); err != nil {
+		return nil, err
+	}
+
+	page := &model.FeedPage{Items: posts}
+
+	if int64(len(posts)) > limit {
+		page.Items = posts[:limit]
+		last := page.Items[limit-1]
+		page.NextCursor = model.FeedCursor{CreatedAt: last.CreatedAt, ID: last.ID}.Encode()
+	}
+
+	return page, nil
+}
+
+// ListRelated ищет посты через индекс по tags, а число общих тегов считает агрегацией.
+// При равном числе общих тегов выше популярные, затем новые.
+func (r *postRepo) ListRelated(ctx context.Context, post *model.Post, limit int64) ([]*model.Post, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	if len(post.Tags) == 0 || limit <= 0 {
+		return []*model.Post{}, nil
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"_id":        bson.M{"$ne": post.ID},
+			"tags":       bson.M{"$in": post.Tags},
+			"status":     model.PostStatusPublished,
+			"deleted_at": bson.M{"$eq": nil},
+		}}},
+		{{Key: "$addFields", Value: bson.M{
+			"overlap": bson.M{"$size": bson.M{"$setIntersection": bson.A{"$tags", post.Tags}}},
+		}}},
+		{{Key: "$sort", Value: bson.D{
+			{Key: "overlap", Value: -1},
+			{Key: "likes_count", Value: -1},
+			{Key: "created_at", Value: -1},
+		}}},
+		{{Key: "$limit", Value: limit}},
+	}
+
+	cursor, err := r.PostCollection().Aggregate(ctx, pipeline)
+	if err != nil {
+		r.logger.Error("failed to list related posts",
+			zap.Error(err),
+			zap.String("post_id", post.ID.Hex()),
+		)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	posts := make([]*model.Post, 0, limit)
+	if err := cursor.All(ctx, &posts); err != nil {
+		return nil, err
+	}
+
+	return posts, nil
+}
+
+// uniqueSlug строит slug из заголовка (см. slug.Unique); занятость проверяется в Mongo.
+// Если из заголовка не получилось ни одного латинского символа - используем ID поста.
+func (r *postRepo) uniqueSlug(ctx context.Context, title string, id primitive.ObjectID) (string, error) {
+	return slug.Unique(title, id.Hex(), func(candidate string) (bool, error) {
+		return r.slugTaken(ctx, candidate, id)
+	})
+}
+
+// slugTaken - slug занят другим постом (как текущий или как старый алиас)
+func (r *postRepo) slugTaken(ctx context.Context, candidate string, id primitive.ObjectID) (bool, error) {
+	filter := bson.M{
+		"_id": bson.M{"$ne": id},
+		"$or": bson.A{
+			bson.M{"slug": candidate},
+			bson.M{"slug_aliases": candidate},
+		},
+	}
+
+	count, err := r.PostCollection().CountDocuments(ctx, filter, options.Count().SetLimit(1))
+	if err != nil {
+		r.logger.Error("failed to check slug",
+			zap.Error(err),
+			zap.String("slug", candidate),
+		)
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// RegenerateSlug пересобирает slug из текущего заголовка.
+// Старый slug сохраняется в slug_aliases, чтобы старые ссылки продолжали работать.
+func (r *postRepo) RegenerateSlug(ctx context.Context, id string) (string, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	post, err := r.GetByID(ctx, id)
+	if err != nil {
+		return "", err
+	}
+
+	if slug.Make(post.Title) == post.Slug {
+		return post.Slug, nil
+	}
+
+	newSlug, err := r.uniqueSlug(ctx, post.Title, post.ID)
+	if err != nil {
+		return "", err
+	}
+
+	if newSlug == post.Slug {
+		return post.Slug, nil
+	}
+
+	filter := bson.M{
+		"_id":        post.ID,
+		"slug":       post.Slug,
+		"deleted_at": bson.M{"$eq": nil},
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"slug":       newSlug,
+			"updated_at": time.Now(),
+		},
+		"$addToSet": bson.M{"slug_aliases": post.Slug},
+	}
+
+	result, err := r.PostCollection().UpdateOne(ctx, filter, update)
+	if err != nil {
+
+		if mongo.IsDuplicateKeyError(err) {
+			return "", ErrSlugExists
+		}
+
+		r.logger.Error("failed to regenerate slug",
+			zap.Error(err),
+			zap.String("post_id", id),
+		)
+		return "", err
+	}
+
+	if result.MatchedCount == 0 {
+		// пост удалили или slug поменяли параллельно
+		return "", ErrNotFound
+	}
+
+	r.logger.Info("post slug regenerated",
+		zap.String("post_id", id),
+		zap.String("old_slug", post.Slug),
+		zap.String("new_slug", newSlug),
+	)
+
+	return newSlug, nil
+}
+
+func (r *postRepo) CountByAuthor(ctx context.Context, authorID string, includeDrafts bool) (int64, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	filter := bson.M{
+		"author_id":  authorID,
+		"deleted_at": bson.M{"$eq": nil},
+		"status":     model.PostStatusPublished,
+	}
+	if includeDrafts {
+		filter["status"] = bson.M{"$in": bson.A{model.PostStatusPublished, model.PostStatusDraft, model.PostStatusScheduled}}
+	}
+
+	// Индекс (author_id, created_at, _id) покрывает фильтр по автору
+	count, err := r.PostCollection().CountDocuments(ctx, filter)
+	if err != nil {
+		r.logger.Error("failed to count posts by author",
+			zap.Error(err),
+			zap.String("author_id", authorID),
+		)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (r *postRepo) StreamByAuthor(ctx context.Context, authorID string, fn func(*model.Post) error) error {
+	// Без opTimeout: выгрузка длится, сколько клиент читает, ограничивает ее контекст запроса
+	filter := bson.M{
+		"author_id":  authorID,
+		"deleted_at": bson.M{"$eq": nil},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}})
+
+	cursor, err := r.PostCollection().Find(ctx, filter, opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var post model.Post
+		if err := cursor.Decode(&post); err != nil {
+			return err
+		}
+		if err := fn(&post); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
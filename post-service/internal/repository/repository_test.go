@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/model"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestListPostsFilter(t *testing.T) {
+	assert.Equal(t, bson.M{
+		"deleted_at": bson.M{"$eq": nil},
+		"status":     model.PostStatusPublished,
+	}, listPostsFilter("", ""))
+
+	assert.Equal(t, bson.M{
+		"deleted_at": bson.M{"$eq": nil},
+		"status":     model.PostStatusPublished,
+		"topic":      "go",
+		"tags":       "generics",
+	}, listPostsFilter("go", "generics"))
+}
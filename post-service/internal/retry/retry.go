@@ -0,0 +1,72 @@
+package retry
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Policy - сколько раз и с какими паузами повторять попытку.
+// MaxAttempts <= 1 означает одну попытку без ожидания.
+type Policy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// Validate - при повторах пауза должна быть положительной: с нулевой попытки шли бы подряд,
+// а min(0*2, MaxBackoff) так и оставался бы нулем
+func (p Policy) Validate() error {
+	if p.MaxAttempts <= 1 {
+		return nil
+	}
+	if p.InitialBackoff <= 0 {
+		return fmt.Errorf("initial backoff must be positive")
+	}
+	if p.MaxBackoff < p.InitialBackoff {
+		return fmt.Errorf("max backoff must not be less than initial backoff")
+	}
+	return nil
+}
+
+// Do вызывает fn, пока она не вернет nil или не кончатся попытки.
+// Пауза между попытками удваивается, но не превышает MaxBackoff.
+// name попадает в логи и в итоговую ошибку, чтобы было видно, какая зависимость не поднялась.
+func Do(ctx context.Context, logger *zap.Logger, name string, policy Policy, fn func(ctx context.Context) error) error {
+	if err := policy.Validate(); err != nil {
+		return fmt.Errorf("%s: retry policy: %w", name, err)
+	}
+
+	attempts := max(policy.MaxAttempts, 1)
+	backoff := policy.InitialBackoff
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if err = fn(ctx); err == nil {
+			return nil
+		}
+
+		if attempt == attempts {
+			break
+		}
+
+		logger.Warn("dependency not ready, retrying",
+			zap.String("dependency", name),
+			zap.Int("attempt", attempt),
+			zap.Duration("backoff", backoff),
+			zap.Error(err),
+		)
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s: %w", name, ctx.Err())
+		case <-time.After(backoff):
+		}
+
+		backoff = min(backoff*2, policy.MaxBackoff)
+	}
+
+	return fmt.Errorf("%s unavailable after %d attempt(s): %w", name, attempts, err)
+}
@@ -0,0 +1,97 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestDo(t *testing.T) {
+	errDown := errors.New("connection refused")
+
+	// failing возвращает ошибку первые n вызовов
+	failing := func(n int) (func(ctx context.Context) error, *int) {
+		calls := 0
+		return func(ctx context.Context) error {
+			calls++
+			if calls <= n {
+				return errDown
+			}
+			return nil
+		}, &calls
+	}
+
+	t.Run("Succeeds after retries", func(t *testing.T) {
+		fn, calls := failing(2)
+		policy := Policy{MaxAttempts: 5, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+
+		require.NoError(t, Do(context.Background(), zap.NewNop(), "mongo", policy, fn))
+		assert.Equal(t, 3, *calls)
+	})
+
+	t.Run("Gives up after MaxAttempts", func(t *testing.T) {
+		fn, calls := failing(10)
+		policy := Policy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+
+		err := Do(context.Background(), zap.NewNop(), "mongo", policy, fn)
+		assert.ErrorIs(t, err, errDown)
+		assert.EqualError(t, err, "mongo unavailable after 3 attempt(s): connection refused")
+		assert.Equal(t, 3, *calls)
+	})
+
+	t.Run("Single attempt without backoff", func(t *testing.T) {
+		for _, attempts := range []int{0, 1} {
+			fn, calls := failing(10)
+			err := Do(context.Background(), zap.NewNop(), "redis", Policy{MaxAttempts: attempts}, fn)
+			assert.ErrorIs(t, err, errDown)
+			assert.Equal(t, 1, *calls)
+		}
+	})
+
+	t.Run("Backoff doubles up to MaxBackoff", func(t *testing.T) {
+		core, logs := observer.New(zap.WarnLevel)
+		fn, _ := failing(10)
+		policy := Policy{MaxAttempts: 5, InitialBackoff: time.Millisecond, MaxBackoff: 3 * time.Millisecond}
+
+		assert.Error(t, Do(context.Background(), zap.New(core), "auth grpc", policy, fn))
+
+		var backoffs []time.Duration
+		for _, entry := range logs.FilterMessage("dependency not ready, retrying").All() {
+			backoffs = append(backoffs, entry.ContextMap()["backoff"].(time.Duration))
+		}
+		// После последней попытки паузы нет
+		assert.Equal(t, []time.Duration{time.Millisecond, 2 * time.Millisecond, 3 * time.Millisecond, 3 * time.Millisecond}, backoffs)
+	})
+
+	t.Run("Canceled context stops waiting", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		calls := 0
+		fn := func(ctx context.Context) error {
+			calls++
+			cancel()
+			return errDown
+		}
+		policy := Policy{MaxAttempts: 5, InitialBackoff: time.Hour, MaxBackoff: time.Hour}
+
+		err := Do(ctx, zap.NewNop(), "mongo", policy, fn)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("Invalid policy", func(t *testing.T) {
+		fn, calls := failing(0)
+		for _, policy := range []Policy{
+			{MaxAttempts: 3},
+			{MaxAttempts: 3, InitialBackoff: time.Second, MaxBackoff: time.Millisecond},
+		} {
+			assert.Error(t, Do(context.Background(), zap.NewNop(), "mongo", policy, fn))
+		}
+		assert.Zero(t, *calls)
+	})
+}
@@ -0,0 +1,85 @@
+// Package router собирает gin.Engine целиком: middleware, аутентификацию и все маршруты.
+// Его используют и run(), и тесты, чтобы таблица маршрутов была одна.
+package router
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/config"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/handler"
+	"go.uber.org/zap"
+)
+
+// Handlers - обработчики, которые раскладываются по маршрутам
+type Handlers struct {
+	Post       *handler.PostHandler
+	Moderation *handler.ModerationHandler
+	Bookmark   *handler.BookmarkHandler
+	Comment    *handler.CommentHandler
+	Feed       *handler.FeedHandler
+	Health     gin.HandlerFunc
+	// PostCreateLimiter - сколько постов пользователь может создать (limits.posts_per_hour)
+	PostCreateLimiter handler.RateLimiter
+}
+
+// NewRouter - токены проверяет introspector (auth-service).
+// Ошибка только на невалидных server.trusted_proxies.
+func NewRouter(h Handlers, introspector handler.TokenIntrospector, cfg *config.Config, logger *zap.Logger) (*gin.Engine, error) {
+	r := gin.New()
+	// Неверный метод на существующем пути - 405 с Allow, а не 404
+	r.HandleMethodNotAllowed = true
+	r.NoMethod(handler.NoMethod)
+	r.NoRoute(handler.NoRoute)
+	// Без этого за балансировщиком ClientIP() в логах и лимитерах - это IP прокси
+	if err := r.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("invalid server.trusted_proxies: %w", err)
+	}
+	r.Use(gin.Recovery())
+	r.Use(handler.ZapLogger(logger))
+	r.Use(handler.BodyLimit(cfg.Server.MaxBodyBytes, cfg.Server.MaxMultipartBytes))
+
+	r.GET("/health", h.Health)
+
+	authRequired := handler.Authenticate(introspector, logger)
+	// Публичные маршруты: автор и админ видят через них и неопубликованное
+	public := r.Group("", handler.OptionalAuth(introspector, logger))
+	{
+		public.GET("/users/:id/posts/count", h.Post.CountByAuthor)
+		public.GET("/posts/:id", h.Post.Get)
+		public.GET("/posts/slug/:slug", h.Post.GetBySlug)
+		public.GET("/posts/:id/related", h.Post.Related)
+	}
+
+	auth := r.Group("", authRequired)
+	{
+		auth.POST("/posts", handler.RateLimitByUser(h.PostCreateLimiter, logger), h.Post.Create)
+		auth.PATCH("/posts/:id", h.Post.Update)
+		auth.DELETE("/posts/:id", h.Post.Delete)
+		auth.POST("/posts/:id/restore", h.Post.Restore)
+		auth.POST("/posts/:id/schedule", h.Post.Schedule)
+		auth.GET("/posts/:id/revisions", h.Post.Revisions)
+		auth.POST("/posts/:id/report", h.Moderation.Report)
+		auth.POST("/posts/:id/bookmark", h.Bookmark.Add)
+		auth.DELETE("/posts/:id/bookmark", h.Bookmark.Remove)
+		auth.POST("/posts/:id/comments", h.Comment.Create)
+		auth.POST("/users/:id/follow", h.Feed.Follow)
+		auth.DELETE("/users/:id/follow", h.Feed.Unfollow)
+		auth.GET("/feed", h.Feed.Feed)
+	}
+
+	// Свои данные текущего пользователя
+	user := r.Group("/user", authRequired)
+	{
+		user.GET("/posts/export", h.Post.ExportMine)
+		user.GET("/bookmarks", h.Bookmark.List)
+	}
+
+	moderation := r.Group("/moderation", authRequired, handler.RequireRole(handler.RoleAdmin))
+	{
+		moderation.GET("/reports", h.Moderation.ListReports)
+		moderation.POST("/reports/:id/resolve", h.Moderation.ResolveReport)
+	}
+
+	return r, nil
+}
@@ -0,0 +1,200 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/authclient"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/cache"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/config"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/handler"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/model"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/repository"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// introspector знает токены author (автор постов репозитория) и user; остальные неактивны
+type introspector struct{}
+
+func (introspector) Introspect(ctx context.Context, token string) (*authclient.Identity, error) {
+	switch token {
+	case "author":
+		return &authclient.Identity{UserID: authorID, Role: "user"}, nil
+	case "user":
+		return &authclient.Identity{UserID: "u2", Role: "user"}, nil
+	}
+	return nil, authclient.ErrInactiveToken
+}
+
+const (
+	authorID        = "u1"
+	scheduledPostID = "65f1a2b3c4d5e6f7a8b9c0d9"
+)
+
+// postsRepo отдает один запланированный пост автора authorID
+type postsRepo struct {
+	repository.PostRepository
+}
+
+func (postsRepo) GetByID(ctx context.Context, id string) (*model.Post, error) {
+	if id != scheduledPostID {
+		return nil, repository.ErrNotFound
+	}
+	postID, _ := primitive.ObjectIDFromHex(id)
+	return &model.Post{ID: postID, AuthorID: authorID, Title: "soon", Status: model.PostStatusScheduled}, nil
+}
+
+// limiter исчерпал лимит постов у пользователя u2
+type limiter struct{}
+
+func (limiter) Allow(ctx context.Context, key string) (bool, time.Duration, error) {
+	if key == "u2" {
+		return false, 30 * time.Minute, nil
+	}
+	return true, 0, nil
+}
+
+// GetBySlug знает опубликованный пост "hello-go", раньше называвшийся "hello-world"
+func (postsRepo) GetBySlug(ctx context.Context, slug string) (*model.Post, error) {
+	if slug != "hello-go" && slug != "hello-world" {
+		return nil, repository.ErrNotFound
+	}
+	return &model.Post{ID: primitive.NewObjectID(), AuthorID: authorID, Title: "Hello Go",
+		Slug: "hello-go", SlugAliases: []string{"hello-world"}, Status: model.PostStatusPublished}, nil
+}
+
+func serve(r *gin.Engine, method, path, token string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(method, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func testRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	conn := cache.NewConn(nil)
+	posts := service.NewPostService(postsRepo{}, nil,
+		cache.NewPostCache(conn, 0), cache.NewPostCountCache(conn, 0), cache.NewRelatedCache(conn, 0),
+		service.PostOptions{RelatedLimit: 5, PreviewLength: 10}, zap.NewNop())
+
+	h := Handlers{
+		Post:       handler.NewPostHandler(posts, zap.NewNop()),
+		Moderation: handler.NewModerationHandler(nil, handler.PaginationLimits{DefaultLimit: 20, MaxLimit: 100}, zap.NewNop()),
+		Bookmark:   handler.NewBookmarkHandler(nil, handler.PaginationLimits{DefaultLimit: 20, MaxLimit: 100}, zap.NewNop()),
+		Comment:    handler.NewCommentHandler(nil, zap.NewNop()),
+		Feed:       handler.NewFeedHandler(nil, handler.PaginationLimits{DefaultLimit: 20, MaxLimit: 100}, zap.NewNop()),
+		Health:     func(c *gin.Context) { c.Status(http.StatusOK) },
+
+		PostCreateLimiter: limiter{},
+	}
+	r, err := NewRouter(h, introspector{}, &config.Config{}, zap.NewNop())
+	require.NoError(t, err)
+	return r
+}
+
+func TestNewRouter_Routes(t *testing.T) {
+	r := testRouter(t)
+
+	var got []string
+	for _, route := range r.Routes() {
+		got = append(got, route.Method+" "+route.Path)
+	}
+
+	assert.ElementsMatch(t, []string{
+		"GET /health",
+		"POST /posts",
+		"GET /users/:id/posts/count",
+		"GET /posts/:id",
+		"GET /posts/slug/:slug",
+		"GET /posts/:id/related",
+		"PATCH /posts/:id",
+		"DELETE /posts/:id",
+		"POST /posts/:id/restore",
+		"POST /posts/:id/schedule",
+		"GET /posts/:id/revisions",
+		"GET /user/posts/export",
+		"POST /posts/:id/report",
+		"POST /posts/:id/bookmark",
+		"DELETE /posts/:id/bookmark",
+		"POST /posts/:id/comments",
+		"GET /user/bookmarks",
+		"POST /users/:id/follow",
+		"DELETE /users/:id/follow",
+		"GET /feed",
+		"GET /moderation/reports",
+		"POST /moderation/reports/:id/resolve",
+	}, got)
+}
+
+func TestNewRouter_ProtectedRoutes(t *testing.T) {
+	r := testRouter(t)
+
+	const postID = "65f1a2b3c4d5e6f7a8b9c0d1"
+	for _, route := range []struct{ method, path string }{
+		{http.MethodPost, "/posts"},
+		{http.MethodPatch, "/posts/" + postID},
+		{http.MethodDelete, "/posts/" + postID},
+		{http.MethodPost, "/posts/" + postID + "/restore"},
+		{http.MethodPost, "/posts/" + postID + "/schedule"},
+		{http.MethodGet, "/posts/" + postID + "/revisions"},
+		{http.MethodGet, "/user/posts/export"},
+		{http.MethodPost, "/posts/" + postID + "/report"},
+		{http.MethodPost, "/posts/" + postID + "/bookmark"},
+		{http.MethodDelete, "/posts/" + postID + "/bookmark"},
+		{http.MethodPost, "/posts/" + postID + "/comments"},
+		{http.MethodGet, "/user/bookmarks"},
+		{http.MethodPost, "/users/" + authorID + "/follow"},
+		{http.MethodDelete, "/users/" + authorID + "/follow"},
+		{http.MethodGet, "/feed"},
+		{http.MethodGet, "/moderation/reports"},
+		{http.MethodPost, "/moderation/reports/" + postID + "/resolve"},
+	} {
+		t.Run(route.method+" "+route.path, func(t *testing.T) {
+			assert.Equal(t, http.StatusUnauthorized, serve(r, route.method, route.path, "").Code)
+			assert.Equal(t, http.StatusUnauthorized, serve(r, route.method, route.path, "expired").Code)
+		})
+	}
+
+	t.Run("Moderation requires the admin role", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, serve(r, http.MethodGet, "/moderation/reports", "user").Code)
+	})
+
+	t.Run("Post creation is rate limited per user", func(t *testing.T) {
+		w := serve(r, http.MethodPost, "/posts", "user")
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "1800", w.Header().Get("Retry-After"))
+	})
+}
+
+func TestNewRouter_ScheduledPostVisibility(t *testing.T) {
+	r := testRouter(t)
+	path := "/posts/" + scheduledPostID
+
+	assert.Equal(t, http.StatusOK, serve(r, http.MethodGet, path, "author").Code)
+	assert.Equal(t, http.StatusNotFound, serve(r, http.MethodGet, path, "user").Code)
+	assert.Equal(t, http.StatusNotFound, serve(r, http.MethodGet, path, "").Code)
+}
+
+func TestNewRouter_OldSlugRedirects(t *testing.T) {
+	r := testRouter(t)
+
+	w := serve(r, http.MethodGet, "/posts/slug/hello-world", "")
+	assert.Equal(t, http.StatusMovedPermanently, w.Code)
+	assert.Equal(t, "/posts/slug/hello-go", w.Header().Get("Location"))
+
+	assert.Equal(t, http.StatusOK, serve(r, http.MethodGet, "/posts/slug/hello-go", "").Code)
+	assert.Equal(t, http.StatusNotFound, serve(r, http.MethodGet, "/posts/slug/unknown", "").Code)
+}
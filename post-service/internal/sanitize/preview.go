@@ -0,0 +1,108 @@
+package sanitize
+
+import (
+	"html"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	xhtml "golang.org/x/net/html"
+)
+
+// voidElements - теги без закрывающей пары; из разрешенных basicHTMLPolicy это только br
+var voidElements = map[string]bool{"br": true}
+
+// Preview - первые maxChars видимых символов тела поста для превью. Работает по токенам HTML,
+// а не по байтам, поэтому не режет тег или сущность (&amp; есть и в plain-режиме:
+// StrictPolicy экранирует спецсимволы), а оставшиеся открытыми теги закрывает.
+// Обрезка идет по границе слова, если в обрезанном фрагменте есть пробел.
+// length - число видимых символов всего тела; truncated=false - content возвращается как есть.
+func Preview(content string, maxChars int) (preview string, truncated bool, length int) {
+	var (
+		b    strings.Builder
+		open []string
+	)
+
+	z := xhtml.NewTokenizer(strings.NewReader(content))
+	for {
+		tt := z.Next()
+		if tt == xhtml.ErrorToken {
+			break
+		}
+
+		switch tt {
+		case xhtml.TextToken:
+			text := string(z.Text())
+			runes := utf8.RuneCountInString(text)
+
+			if !truncated {
+				if length+runes <= maxChars {
+					b.Write(z.Raw())
+				} else {
+					b.WriteString(html.EscapeString(cutAtWord(text, maxChars-length, b.Len() == 0)))
+					truncated = true
+				}
+			}
+			length += runes
+
+		case xhtml.StartTagToken:
+			if truncated {
+				continue
+			}
+			name, _ := z.TagName()
+			if !voidElements[string(name)] {
+				open = append(open, string(name))
+			}
+			b.Write(z.Raw())
+
+		case xhtml.EndTagToken:
+			if truncated {
+				continue
+			}
+			name, _ := z.TagName()
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] == string(name) {
+					open = open[:i]
+					break
+				}
+			}
+			b.Write(z.Raw())
+
+		default:
+			if !truncated {
+				b.Write(z.Raw())
+			}
+		}
+	}
+
+	if !truncated {
+		return content, false, length
+	}
+
+	for i := len(open) - 1; i >= 0; i-- {
+		b.WriteString("</" + open[i] + ">")
+	}
+	return b.String(), true, length
+}
+
+// cutAtWord - первые n символов text без недописанного последнего слова. Если слово одно,
+// а до него в превью ничего нет (onlyText), режется посреди слова - пустое превью хуже
+func cutAtWord(text string, n int, onlyText bool) string {
+	runes := []rune(text)
+	if n >= len(runes) {
+		return text
+	}
+
+	cut := runes[:n]
+	if !unicode.IsSpace(runes[n]) {
+		i := len(cut) - 1
+		for i >= 0 && !unicode.IsSpace(cut[i]) {
+			i--
+		}
+		if i >= 0 || !onlyText {
+			cut = cut[:i+1]
+		}
+	}
+
+	return strings.TrimRightFunc(string(cut), unicode.IsSpace)
+}
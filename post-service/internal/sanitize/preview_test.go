@@ -0,0 +1,38 @@
+package sanitize
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreview(t *testing.T) {
+	cases := []struct {
+		name      string
+		content   string
+		max       int
+		want      string
+		truncated bool
+		length    int
+	}{
+		{"short text as is", "hello world", 20, "hello world", false, 11},
+		{"exact length as is", "hello", 5, "hello", false, 5},
+		{"word boundary", "hello wonderful world", 12, "hello", true, 21},
+		{"cut on a space", "hello wonderful world", 15, "hello wonderful", true, 21},
+		{"single long word is cut", "abcdefghij", 4, "abcd", true, 10},
+		{"entity is not split", "tom &amp; jerry forever", 13, "tom &amp; jerry", true, 19},
+		{"open tags are closed", "<p>first <b>bold words here</b></p><p>second</p>", 12, "<p>first <b>bold</b></p>", true, 27},
+		{"tag is not split", "<p>one</p><p>two three</p>", 5, "<p>one</p><p></p>", true, 12},
+		{"void element", "one<br>two three", 7, "one<br>two", true, 12},
+		{"unicode counted in characters", "привет мир снова", 8, "привет", true, 16},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			preview, truncated, length := Preview(tc.content, tc.max)
+			assert.Equal(t, tc.want, preview)
+			assert.Equal(t, tc.truncated, truncated)
+			assert.Equal(t, tc.length, length)
+		})
+	}
+}
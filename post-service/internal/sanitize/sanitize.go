@@ -0,0 +1,136 @@
+package sanitize
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/microcosm-cc/bluemonday"
+)
+
+// Режимы обработки тела поста (config posts.content_mode)
+const (
+	ModePlain     = "plain"
+	ModeBasicHTML = "basic_html"
+)
+
+var (
+	ErrContentTooLong = errors.New("content is too long")
+	ErrEmptyContent   = errors.New("content is empty")
+	ErrTooManyTags    = errors.New("too many tags")
+	// ErrInvalidTag - в теге есть что-то кроме букв, цифр и дефиса
+	ErrInvalidTag = errors.New("tag may contain only letters, digits and hyphens")
+)
+
+// Sanitizer чистит пользовательский ввод перед сохранением,
+// чтобы все, что лежит в базе, было безопасно отдавать как есть
+type Sanitizer struct {
+	content   *bluemonday.Policy
+	plain     *bluemonday.Policy
+	maxLength int
+	maxTags   int
+}
+
+// New создает санитайзер. maxLength - ограничение тела поста в символах, maxTags - число
+// тегов у поста (0 - без ограничения).
+func New(mode string, maxLength, maxTags int) (*Sanitizer, error) {
+	s := &Sanitizer{
+		plain:     bluemonday.StrictPolicy(),
+		maxLength: maxLength,
+		maxTags:   maxTags,
+	}
+
+	switch mode {
+	case ModePlain:
+		s.content = s.plain
+	case ModeBasicHTML:
+		s.content = basicHTMLPolicy()
+	default:
+		return nil, fmt.Errorf("unsupported content mode %q", mode)
+	}
+
+	return s, nil
+}
+
+// basicHTMLPolicy - только форматирование текста и ссылки, без картинок, стилей и скриптов
+func basicHTMLPolicy() *bluemonday.Policy {
+	p := bluemonday.NewPolicy()
+
+	p.AllowElements("p", "br", "b", "strong", "i", "em", "u", "s", "blockquote",
+		"code", "pre", "ul", "ol", "li", "h2", "h3", "h4")
+
+	p.AllowAttrs("href").OnElements("a")
+	p.AllowURLSchemes("http", "https", "mailto")
+	p.RequireParseableURLs(true)
+	p.RequireNoFollowOnLinks(true)
+	p.AddTargetBlankToFullyQualifiedLinks(true)
+
+	return p
+}
+
+// Content чистит тело поста. Длина проверяется до очистки: вырезанная разметка
+// не должна позволять обойти лимит.
+func (s *Sanitizer) Content(body string) (string, error) {
+	if s.maxLength > 0 && utf8.RuneCountInString(body) > s.maxLength {
+		return "", ErrContentTooLong
+	}
+
+	clean := strings.TrimSpace(s.content.Sanitize(body))
+	if clean == "" {
+		return "", ErrEmptyContent
+	}
+
+	return clean, nil
+}
+
+// Title - заголовок всегда plain text: теги вырезаются, спецсимволы остаются экранированными.
+// Раскодировать их обратно нельзя - &lt;script&gt; из ввода снова стал бы разметкой.
+func (s *Sanitizer) Title(title string) string {
+	return strings.TrimSpace(s.plain.Sanitize(title))
+}
+
+// Tags приводит теги к виду, в котором они лежат в индексе: без пробелов по краям,
+// в нижнем регистре, без дублей (порядок первого вхождения сохраняется). Пустые теги
+// отбрасываются, теги с недопустимыми символами и превышение лимита - ошибка.
+func (s *Sanitizer) Tags(tags []string) ([]string, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+
+	clean := make([]string, 0, len(tags))
+	seen := make(map[string]struct{}, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" {
+			continue
+		}
+		if !validTag(tag) {
+			return nil, ErrInvalidTag
+		}
+		if _, ok := seen[tag]; ok {
+			continue
+		}
+		seen[tag] = struct{}{}
+		clean = append(clean, tag)
+	}
+
+	// Лимит считается после удаления дублей: ["go", "Go"] - это один тег
+	if s.maxTags > 0 && len(clean) > s.maxTags {
+		return nil, ErrTooManyTags
+	}
+	if len(clean) == 0 {
+		return nil, nil
+	}
+	return clean, nil
+}
+
+func validTag(tag string) bool {
+	for _, r := range tag {
+		if r != '-' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,72 @@
+package sanitize
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSanitizer_Tags(t *testing.T) {
+	s, err := New(ModePlain, 100, 3)
+	require.NoError(t, err)
+
+	t.Run("Duplicates collapse", func(t *testing.T) {
+		tags, err := s.Tags([]string{" Go ", "go", "GO", "backend", "", "  "})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"go", "backend"}, tags)
+	})
+
+	t.Run("Limit counts unique tags", func(t *testing.T) {
+		tags, err := s.Tags([]string{"a", "b", "c", "A", "B", "C"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a", "b", "c"}, tags)
+
+		_, err = s.Tags([]string{"a", "b", "c", "d"})
+		assert.ErrorIs(t, err, ErrTooManyTags)
+	})
+
+	t.Run("Invalid characters", func(t *testing.T) {
+		for _, tag := range []string{"c++", "two words", "tag_name", "#go", "<b>"} {
+			_, err := s.Tags([]string{tag})
+			assert.ErrorIs(t, err, ErrInvalidTag, tag)
+		}
+	})
+
+	t.Run("Hyphens and non-latin letters allowed", func(t *testing.T) {
+		tags, err := s.Tags([]string{"micro-blog", "Голанг", "web3"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"micro-blog", "голанг", "web3"}, tags)
+	})
+
+	t.Run("No tags", func(t *testing.T) {
+		tags, err := s.Tags([]string{" "})
+		require.NoError(t, err)
+		assert.Nil(t, tags)
+	})
+}
+
+func TestSanitizer_Title(t *testing.T) {
+	s, err := New(ModePlain, 100, 3)
+	require.NoError(t, err)
+
+	t.Run("Markup is stripped", func(t *testing.T) {
+		assert.Equal(t, "Hello", s.Title(" <b>Hello</b><script>alert(1)</script> "))
+	})
+
+	t.Run("Encoded markup stays encoded", func(t *testing.T) {
+		for _, title := range []string{
+			"&lt;img src=x onerror=alert(1)&gt;",
+			"&lt;script&gt;alert(1)&lt;/script&gt;",
+			"&#60;svg onload=alert(1)&#62;",
+		} {
+			clean := s.Title(title)
+			assert.NotContains(t, clean, "<", title)
+			assert.NotContains(t, clean, ">", title)
+		}
+	})
+
+	t.Run("Special characters are escaped", func(t *testing.T) {
+		assert.Equal(t, "Tom &amp; Jerry", s.Title("Tom & Jerry"))
+	})
+}
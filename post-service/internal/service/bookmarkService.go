@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/authclient"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/model"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/repository"
+	"go.uber.org/zap"
+)
+
+// UserLookup - данные пользователей из auth-service (см. authclient.UserDirectory)
+type UserLookup interface {
+	LookupUsers(ctx context.Context, ids []string) ([]*authclient.User, error)
+}
+
+// BookmarkService - личный список "прочитать позже"
+type BookmarkService interface {
+	// Add - закладка на пост, который пользователь может видеть; иначе repository.ErrNotFound.
+	// Повторная закладка - не ошибка
+	Add(ctx context.Context, postID, userID string) error
+	// Remove убирает закладку; если ее не было - не ошибка
+	Remove(ctx context.Context, postID, userID string) error
+	// List - закладки вместе с авторами постов. Если auth-service недоступен, список
+	// отдается без авторов: закладки важнее подписи
+	List(ctx context.Context, userID string, limit, offset int64) (*model.PaginatedBookmarks, error)
+}
+
+type bookmarkService struct {
+	bookmarks repository.BookmarkRepository
+	posts     repository.PostRepository
+	users     UserLookup
+	logger    *zap.Logger
+}
+
+func NewBookmarkService(bookmarks repository.BookmarkRepository, posts repository.PostRepository, users UserLookup, logger *zap.Logger) BookmarkService {
+	return &bookmarkService{
+		bookmarks: bookmarks,
+		posts:     posts,
+		users:     users,
+		logger:    logger,
+	}
+}
+
+func (s *bookmarkService) Add(ctx context.Context, postID, userID string) error {
+	post, err := s.posts.GetByID(ctx, postID)
+	if err != nil {
+		return err
+	}
+	// Чужой черновик для пользователя не существует - как в GetCached
+	if !canView(post.Status, post.AuthorID, userID, false) {
+		return repository.ErrNotFound
+	}
+
+	return s.bookmarks.AddBookmark(ctx, postID, userID)
+}
+
+func (s *bookmarkService) Remove(ctx context.Context, postID, userID string) error {
+	err := s.bookmarks.RemoveBookmark(ctx, postID, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+func (s *bookmarkService) List(ctx context.Context, userID string, limit, offset int64) (*model.PaginatedBookmarks, error) {
+	page, err := s.bookmarks.ListBookmarkedPosts(ctx, userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	if len(page.Items) == 0 {
+		return page, nil
+	}
+
+	seen := make(map[string]bool, len(page.Items))
+	ids := make([]string, 0, len(page.Items))
+	for _, item := range page.Items {
+		if !seen[item.Post.AuthorID] {
+			seen[item.Post.AuthorID] = true
+			ids = append(ids, item.Post.AuthorID)
+		}
+	}
+
+	users, err := s.users.LookupUsers(ctx, ids)
+	if err != nil {
+		s.logger.Warn("author lookup failed, serving bookmarks without authors", zap.String("user_id", userID), zap.Error(err))
+		return page, nil
+	}
+
+	authors := make(map[string]*model.Author, len(users))
+	for _, u := range users {
+		authors[u.ID] = &model.Author{ID: u.ID, Username: u.Username, DisplayName: u.DisplayName}
+	}
+	for _, item := range page.Items {
+		item.Author = authors[item.Post.AuthorID]
+	}
+
+	return page, nil
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/authclient"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/model"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// bookmarksRepo хранит закладки как множество (userID, postID), как уникальный индекс в Mongo
+type bookmarksRepo struct {
+	saved map[[2]string]bool
+	posts map[string]*model.Post
+}
+
+func (r *bookmarksRepo) AddBookmark(ctx context.Context, postID, userID string) error {
+	r.saved[[2]string{userID, postID}] = true
+	return nil
+}
+
+func (r *bookmarksRepo) RemoveBookmark(ctx context.Context, postID, userID string) error {
+	key := [2]string{userID, postID}
+	if !r.saved[key] {
+		return repository.ErrNotFound
+	}
+	delete(r.saved, key)
+	return nil
+}
+
+func (r *bookmarksRepo) ListBookmarkedPosts(ctx context.Context, userID string, limit, offset int64) (*model.PaginatedBookmarks, error) {
+	page := &model.PaginatedBookmarks{Items: []*model.BookmarkedPost{}, Limit: limit, Offset: offset}
+	for key := range r.saved {
+		if key[0] == userID {
+			page.Items = append(page.Items, &model.BookmarkedPost{Post: r.posts[key[1]]})
+		}
+	}
+	page.Total = int64(len(page.Items))
+	return page, nil
+}
+
+// userLookup знает только alice; down - auth-service недоступен
+type userLookup struct {
+	down bool
+}
+
+func (l userLookup) LookupUsers(ctx context.Context, ids []string) ([]*authclient.User, error) {
+	if l.down {
+		return nil, errors.New("connection refused")
+	}
+	var users []*authclient.User
+	for _, id := range ids {
+		if id == "alice-id" {
+			users = append(users, &authclient.User{ID: id, Username: "alice"})
+		}
+	}
+	return users, nil
+}
+
+func TestBookmarkService(t *testing.T) {
+	posts := map[string]*model.Post{
+		"published": {ID: primitive.NewObjectID(), AuthorID: "alice-id", Status: model.PostStatusPublished},
+		"draft":     {ID: primitive.NewObjectID(), AuthorID: "alice-id", Status: model.PostStatusDraft},
+	}
+	newService := func(users UserLookup) (BookmarkService, *bookmarksRepo) {
+		repo := &bookmarksRepo{saved: map[[2]string]bool{}, posts: posts}
+		return NewBookmarkService(repo, &postsRepo{posts: posts}, users, zap.NewNop()), repo
+	}
+	ctx := context.Background()
+
+	t.Run("Add is idempotent", func(t *testing.T) {
+		svc, repo := newService(userLookup{})
+		require.NoError(t, svc.Add(ctx, "published", "reader"))
+		require.NoError(t, svc.Add(ctx, "published", "reader"))
+		assert.Len(t, repo.saved, 1)
+	})
+
+	t.Run("Missing or unpublished post", func(t *testing.T) {
+		svc, repo := newService(userLookup{})
+		assert.ErrorIs(t, svc.Add(ctx, "missing", "reader"), repository.ErrNotFound)
+		assert.ErrorIs(t, svc.Add(ctx, "draft", "reader"), repository.ErrNotFound)
+		assert.Empty(t, repo.saved)
+
+		// Свой черновик сохранить можно
+		assert.NoError(t, svc.Add(ctx, "draft", "alice-id"))
+	})
+
+	t.Run("Remove is idempotent", func(t *testing.T) {
+		svc, repo := newService(userLookup{})
+		require.NoError(t, svc.Add(ctx, "published", "reader"))
+		require.NoError(t, svc.Remove(ctx, "published", "reader"))
+		require.NoError(t, svc.Remove(ctx, "published", "reader"))
+		assert.Empty(t, repo.saved)
+	})
+
+	t.Run("List is hydrated with authors", func(t *testing.T) {
+		svc, _ := newService(userLookup{})
+		require.NoError(t, svc.Add(ctx, "published", "reader"))
+
+		page, err := svc.List(ctx, "reader", 20, 0)
+		require.NoError(t, err)
+		require.Len(t, page.Items, 1)
+		assert.Equal(t, &model.Author{ID: "alice-id", Username: "alice"}, page.Items[0].Author)
+	})
+
+	t.Run("List without auth-service", func(t *testing.T) {
+		svc, _ := newService(userLookup{down: true})
+		require.NoError(t, svc.Add(ctx, "published", "reader"))
+
+		page, err := svc.List(ctx, "reader", 20, 0)
+		require.NoError(t, err)
+		require.Len(t, page.Items, 1)
+		assert.Nil(t, page.Items[0].Author)
+	})
+}
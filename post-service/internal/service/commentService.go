@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/model"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/repository"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/sanitize"
+	"go.uber.org/zap"
+)
+
+// CommentService - комментарии к постам. Тело проходит тот же санитайзер, что и посты,
+// упомянутые через @username получают событие в outbox
+type CommentService interface {
+	// Create - комментарий к посту, который пользователь может видеть; иначе repository.ErrNotFound.
+	// Ошибки санитайзера - 400, как у постов
+	Create(ctx context.Context, postID, authorID, content string, isAdmin bool) (*model.Comment, error)
+}
+
+type commentService struct {
+	comments  repository.CommentRepository
+	posts     repository.PostRepository
+	sanitizer *sanitize.Sanitizer
+	mentions  *Mentions
+	logger    *zap.Logger
+}
+
+func NewCommentService(
+	comments repository.CommentRepository,
+	posts repository.PostRepository,
+	sanitizer *sanitize.Sanitizer,
+	mentions *Mentions,
+	logger *zap.Logger,
+) CommentService {
+	return &commentService{
+		comments:  comments,
+		posts:     posts,
+		sanitizer: sanitizer,
+		mentions:  mentions,
+		logger:    logger,
+	}
+}
+
+func (s *commentService) Create(ctx context.Context, postID, authorID, content string, isAdmin bool) (*model.Comment, error) {
+	post, err := s.posts.GetByID(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+	// Чужой черновик для пользователя не существует - как в GetCached
+	if !canView(post.Status, post.AuthorID, authorID, isAdmin) {
+		return nil, repository.ErrNotFound
+	}
+
+	content, err = s.sanitizer.Content(content)
+	if err != nil {
+		s.logger.Warn("comment content rejected", zap.String("author_id", authorID), zap.Error(err))
+		return nil, err
+	}
+
+	comment := &model.Comment{PostID: post.ID, AuthorID: authorID, Content: content}
+	// Без auth-service комментарий сохраняется без упоминаний
+	comment.Mentions, _ = s.mentions.Resolve(ctx, content, authorID)
+
+	if err := s.comments.CreateComment(ctx, comment); err != nil {
+		return nil, err
+	}
+
+	commentID := comment.ID
+	s.mentions.Notify(ctx, authorID, post.ID, &commentID, comment.Mentions, nil)
+
+	return comment, nil
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/mention"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/model"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/repository"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/sanitize"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// commentsRepo запоминает созданные комментарии
+type commentsRepo struct {
+	repository.CommentRepository
+	created []*model.Comment
+}
+
+func (r *commentsRepo) CreateComment(ctx context.Context, comment *model.Comment) error {
+	comment.ID = primitive.NewObjectID()
+	r.created = append(r.created, comment)
+	return nil
+}
+
+func TestCommentService_Create(t *testing.T) {
+	publishedID, draftID := primitive.NewObjectID(), primitive.NewObjectID()
+	sanitizer, err := sanitize.New(sanitize.ModePlain, 1000, 10)
+	require.NoError(t, err)
+	parser, err := mention.NewParser("")
+	require.NoError(t, err)
+
+	newService := func(users UsernameLookup) (CommentService, *commentsRepo, *recordingOutbox) {
+		posts := &postsRepo{posts: map[string]*model.Post{
+			publishedID.Hex(): {ID: publishedID, AuthorID: "alice-id", Status: model.PostStatusPublished},
+			draftID.Hex():     {ID: draftID, AuthorID: "alice-id", Status: model.PostStatusDraft},
+		}}
+		comments, outbox := &commentsRepo{}, &recordingOutbox{}
+		return NewCommentService(comments, posts, sanitizer, NewMentions(parser, users, outbox, zap.NewNop()), zap.NewNop()),
+			comments, outbox
+	}
+	ctx := context.Background()
+
+	t.Run("Mentions in the comment are stored and recorded", func(t *testing.T) {
+		svc, comments, outbox := newService(usernameLookup{})
+
+		comment, err := svc.Create(ctx, publishedID.Hex(), "carol-id", "nice one @alice, cc @bob", false)
+		require.NoError(t, err)
+		require.Len(t, comments.created, 1)
+		assert.Equal(t, []string{"alice-id", "bob-id"}, comment.Mentions)
+
+		require.Len(t, outbox.events, 2)
+		assert.Equal(t, publishedID, outbox.events[0].PostID)
+		require.NotNil(t, outbox.events[0].CommentID)
+		assert.Equal(t, comment.ID, *outbox.events[0].CommentID)
+	})
+
+	t.Run("Auth service down saves the comment without mentions", func(t *testing.T) {
+		svc, comments, outbox := newService(usernameLookup{down: true})
+
+		_, err := svc.Create(ctx, publishedID.Hex(), "carol-id", "hi @alice", false)
+		require.NoError(t, err)
+		require.Len(t, comments.created, 1)
+		assert.Nil(t, comments.created[0].Mentions)
+		assert.Empty(t, outbox.events)
+	})
+
+	t.Run("Someone else's draft is not found", func(t *testing.T) {
+		svc, comments, _ := newService(usernameLookup{})
+
+		_, err := svc.Create(ctx, draftID.Hex(), "carol-id", "hi", false)
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+		assert.Empty(t, comments.created)
+	})
+
+	t.Run("Empty content is rejected", func(t *testing.T) {
+		svc, comments, _ := newService(usernameLookup{})
+
+		_, err := svc.Create(ctx, publishedID.Hex(), "carol-id", "   ", false)
+		assert.ErrorIs(t, err, sanitize.ErrEmptyContent)
+		assert.Empty(t, comments.created)
+	})
+}
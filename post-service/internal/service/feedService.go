@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/cache"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/model"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/repository"
+	"go.uber.org/zap"
+)
+
+type FeedService interface {
+	// Follow подписывает на автора; repository.ErrNotFound - auth-service такого пользователя не знает,
+	// repository.ErrSelfFollow - подписка на себя. Повторная подписка - не ошибка
+	Follow(ctx context.Context, followerID, authorID string) error
+	// Unfollow отменяет подписку; если ее не было - не ошибка
+	Unfollow(ctx context.Context, followerID, authorID string) error
+	// GetFeed - опубликованные посты авторов из подписок, новые первыми; cursor - NextCursor
+	// предыдущей страницы, model.ErrInvalidCursor - курсор битый. limit <= 0 - размер по умолчанию
+	GetFeed(ctx context.Context, userID, cursor string, limit int64) (*model.FeedPage, error)
+	// OnPostPublished сбрасывает закешированные ленты подписчиков автора
+	OnPostPublished(ctx context.Context, authorID string) error
+}
+
+type feedService struct {
+	posts    repository.PostRepository
+	follows  repository.FollowRepository
+	users    UserLookup
+	cache    *cache.FeedCache
+	pageSize int64
+	logger   *zap.Logger
+}
+
+// NewFeedService - pageSize - размер страницы по умолчанию; в кеш попадает только первая страница такого размера
+func NewFeedService(
+	posts repository.PostRepository,
+	follows repository.FollowRepository,
+	users UserLookup,
+	feedCache *cache.FeedCache,
+	pageSize int64,
+	logger *zap.Logger,
+) FeedService {
+	return &feedService{
+		posts:    posts,
+		follows:  follows,
+		users:    users,
+		cache:    feedCache,
+		pageSize: pageSize,
+		logger:   logger,
+	}
+}
+
+func (s *feedService) Follow(ctx context.Context, followerID, authorID string) error {
+	if followerID == authorID {
+		return repository.ErrSelfFollow
+	}
+
+	users, err := s.users.LookupUsers(ctx, []string{authorID})
+	if err != nil {
+		return err
+	}
+	if len(users) == 0 {
+		return repository.ErrNotFound
+	}
+
+	if err := s.follows.Follow(ctx, followerID, authorID); err != nil {
+		return err
+	}
+
+	// В закешированной первой странице нет постов нового автора
+	s.invalidate(ctx, followerID)
+	return nil
+}
+
+func (s *feedService) Unfollow(ctx context.Context, followerID, authorID string) error {
+	err := s.follows.Unfollow(ctx, followerID, authorID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	s.invalidate(ctx, followerID)
+	return nil
+}
+
+func (s *feedService) GetFeed(ctx context.Context, userID, cursor string, limit int64) (*model.FeedPage, error) {
+	if limit <= 0 {
+		limit = s.pageSize
+	}
+
+	// Кешируем только первую страницу стандартного размера
+	cacheable := cursor == "" && limit == s.pageSize
+
+	if cacheable {
+		page, ok, err := s.cache.Get(ctx, userID)
+		if err != nil {
+			// Redis недоступен - не страшно, идем в Mongo
+			s.logger.Warn("feed cache get failed", zap.String("user_id", userID), zap.Error(err))
+		}
+		if ok {
+			return page, nil
+		}
+	}
+
+	var after *model.FeedCursor
+	if cursor != "" {
+		parsed, err := model.ParseFeedCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		after = &parsed
+	}
+
+	authorIDs, err := s.follows.ListFollowing(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := s.posts.ListFeed(ctx, authorIDs, after, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	if cacheable {
+		if err := s.cache.Set(ctx, userID, page); err != nil {
+			s.logger.Warn("feed cache set failed", zap.String("user_id", userID), zap.Error(err))
+		}
+	}
+
+	return page, nil
+}
+
+func (s *feedService) OnPostPublished(ctx context.Context, authorID string) error {
+	followers, err := s.follows.ListFollowers(ctx, authorID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.cache.Invalidate(ctx, followers...); err != nil {
+		s.logger.Warn("feed cache invalidation failed", zap.String("author_id", authorID), zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// invalidate - ошибка Redis не ломает подписку: лента сама обновится через feed.cache_ttl
+func (s *feedService) invalidate(ctx context.Context, userID string) {
+	if err := s.cache.Invalidate(ctx, userID); err != nil {
+		s.logger.Warn("feed cache invalidation failed", zap.String("user_id", userID), zap.Error(err))
+	}
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/cache"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/model"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// followsRepo хранит подписки как множество (followerID, authorID)
+type followsRepo struct {
+	follows map[[2]string]bool
+}
+
+func (r *followsRepo) Follow(ctx context.Context, followerID, authorID string) error {
+	r.follows[[2]string{followerID, authorID}] = true
+	return nil
+}
+
+func (r *followsRepo) Unfollow(ctx context.Context, followerID, authorID string) error {
+	key := [2]string{followerID, authorID}
+	if !r.follows[key] {
+		return repository.ErrNotFound
+	}
+	delete(r.follows, key)
+	return nil
+}
+
+func (r *followsRepo) ListFollowing(ctx context.Context, followerID string) ([]string, error) {
+	var ids []string
+	for key := range r.follows {
+		if key[0] == followerID {
+			ids = append(ids, key[1])
+		}
+	}
+	return ids, nil
+}
+
+func (r *followsRepo) ListFollowers(ctx context.Context, authorID string) ([]string, error) {
+	var ids []string
+	for key := range r.follows {
+		if key[1] == authorID {
+			ids = append(ids, key[0])
+		}
+	}
+	return ids, nil
+}
+
+// feedPostsRepo отдает в ленту опубликованные посты запрошенных авторов
+type feedPostsRepo struct {
+	postsRepo
+	gotLimit int64
+}
+
+func (r *feedPostsRepo) ListFeed(ctx context.Context, authorIDs []string, cursor *model.FeedCursor, limit int64) (*model.FeedPage, error) {
+	r.gotLimit = limit
+	page := &model.FeedPage{Items: []*model.Post{}}
+	for _, post := range r.posts {
+		for _, id := range authorIDs {
+			if post.AuthorID == id && post.Status == model.PostStatusPublished {
+				page.Items = append(page.Items, post)
+			}
+		}
+	}
+	return page, nil
+}
+
+// feedRecorder запоминает, для каких авторов сбрасывались ленты
+type feedRecorder struct {
+	authors []string
+}
+
+func (f *feedRecorder) OnPostPublished(ctx context.Context, authorID string) error {
+	f.authors = append(f.authors, authorID)
+	return nil
+}
+
+func TestFeedService(t *testing.T) {
+	ctx := context.Background()
+	newService := func(users UserLookup) (FeedService, *followsRepo, *feedPostsRepo) {
+		follows := &followsRepo{follows: map[[2]string]bool{}}
+		posts := &feedPostsRepo{postsRepo: postsRepo{posts: map[string]*model.Post{
+			"published": {ID: primitive.NewObjectID(), AuthorID: "alice-id", Status: model.PostStatusPublished},
+			"draft":     {ID: primitive.NewObjectID(), AuthorID: "alice-id", Status: model.PostStatusDraft},
+		}}}
+		svc := NewFeedService(posts, follows, users, cache.NewFeedCache(cache.NewConn(nil), 0), 20, zap.NewNop())
+		return svc, follows, posts
+	}
+
+	t.Run("Follow a known author", func(t *testing.T) {
+		svc, follows, _ := newService(userLookup{})
+		require.NoError(t, svc.Follow(ctx, "reader", "alice-id"))
+		assert.True(t, follows.follows[[2]string{"reader", "alice-id"}])
+	})
+
+	t.Run("Follow yourself", func(t *testing.T) {
+		svc, follows, _ := newService(userLookup{})
+		assert.ErrorIs(t, svc.Follow(ctx, "alice-id", "alice-id"), repository.ErrSelfFollow)
+		assert.Empty(t, follows.follows)
+	})
+
+	t.Run("Follow an unknown user", func(t *testing.T) {
+		svc, follows, _ := newService(userLookup{})
+		assert.ErrorIs(t, svc.Follow(ctx, "reader", "ghost-id"), repository.ErrNotFound)
+		assert.Empty(t, follows.follows)
+	})
+
+	t.Run("Follow while auth-service is down", func(t *testing.T) {
+		svc, follows, _ := newService(userLookup{down: true})
+		assert.Error(t, svc.Follow(ctx, "reader", "alice-id"))
+		assert.Empty(t, follows.follows)
+	})
+
+	t.Run("Unfollow is idempotent", func(t *testing.T) {
+		svc, follows, _ := newService(userLookup{})
+		require.NoError(t, svc.Follow(ctx, "reader", "alice-id"))
+		require.NoError(t, svc.Unfollow(ctx, "reader", "alice-id"))
+		require.NoError(t, svc.Unfollow(ctx, "reader", "alice-id"))
+		assert.Empty(t, follows.follows)
+	})
+
+	t.Run("Feed contains published posts of followed authors", func(t *testing.T) {
+		svc, _, posts := newService(userLookup{})
+		require.NoError(t, svc.Follow(ctx, "reader", "alice-id"))
+
+		page, err := svc.GetFeed(ctx, "reader", "", 0)
+		require.NoError(t, err)
+		require.Len(t, page.Items, 1)
+		assert.Equal(t, model.PostStatusPublished, page.Items[0].Status)
+		assert.Equal(t, int64(20), posts.gotLimit, "limit <= 0 falls back to the page size")
+	})
+
+	t.Run("Feed without follows is empty", func(t *testing.T) {
+		svc, _, _ := newService(userLookup{})
+		page, err := svc.GetFeed(ctx, "reader", "", 10)
+		require.NoError(t, err)
+		assert.Empty(t, page.Items)
+	})
+
+	t.Run("Invalid cursor", func(t *testing.T) {
+		svc, _, _ := newService(userLookup{})
+		_, err := svc.GetFeed(ctx, "reader", "not-a-cursor", 10)
+		assert.ErrorIs(t, err, model.ErrInvalidCursor)
+	})
+}
+
+func TestPostService_InvalidatesFeeds(t *testing.T) {
+	const author = "author-1"
+	post := &model.Post{ID: primitive.NewObjectID(), AuthorID: author, Status: model.PostStatusPublished}
+	repo := &deletingRepo{postsRepo: postsRepo{posts: map[string]*model.Post{post.ID.Hex(): post}}}
+	feed := &feedRecorder{}
+
+	conn := cache.NewConn(nil)
+	svc := NewPostService(repo, nil,
+		cache.NewPostCache(conn, 0), cache.NewPostCountCache(conn, 0), cache.NewRelatedCache(conn, 0),
+		PostOptions{RelatedLimit: 5, PreviewLength: 10, Feed: feed}, zap.NewNop())
+
+	require.NoError(t, svc.Delete(context.Background(), post.ID.Hex(), author, false))
+	assert.Equal(t, []string{author}, feed.authors)
+}
+
+type deletingRepo struct {
+	postsRepo
+}
+
+func (r *deletingRepo) MarkAsDeleted(ctx context.Context, id string) error {
+	return nil
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/mention"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/model"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// Mentions - @-упоминания в постах и комментариях: разбор текста, поиск пользователей
+// в auth-service и события "mention" в outbox. nil *Mentions - упоминания выключены
+type Mentions struct {
+	parser *mention.Parser
+	users  UsernameLookup
+	outbox repository.OutboxRepository
+	logger *zap.Logger
+}
+
+func NewMentions(parser *mention.Parser, users UsernameLookup, outbox repository.OutboxRepository, logger *zap.Logger) *Mentions {
+	return &Mentions{parser: parser, users: users, outbox: outbox, logger: logger}
+}
+
+// Resolve возвращает id пользователей, упомянутых в уже очищенном тексте, в порядке появления.
+// Неизвестные handle и сам автор пропускаются. Ошибка - auth-service недоступен: тогда
+// вызывающий оставляет сохраненные упоминания как есть
+func (m *Mentions) Resolve(ctx context.Context, text, authorID string) ([]string, error) {
+	if m == nil {
+		return nil, nil
+	}
+
+	handles := m.parser.Parse(text)
+	if len(handles) == 0 {
+		return nil, nil
+	}
+
+	users, err := m.users.LookupUsernames(ctx, handles)
+	if err != nil {
+		m.logger.Warn("mention lookup failed", zap.String("author_id", authorID), zap.Error(err))
+		return nil, err
+	}
+
+	ids := make(map[string]string, len(users))
+	for _, u := range users {
+		ids[u.Username] = u.ID
+	}
+
+	var mentioned []string
+	for _, handle := range handles {
+		if id, ok := ids[handle]; ok && id != authorID {
+			mentioned = append(mentioned, id)
+		}
+	}
+	return mentioned, nil
+}
+
+// Notify пишет в outbox событие для каждого из mentioned, кого нет в previous, - при правке
+// текста уже упомянутые пользователи повторно не уведомляются. commentID nil - упоминание в посте.
+// Запись уже сохраненного текста не откатывается, поэтому ошибка только логируется
+func (m *Mentions) Notify(ctx context.Context, authorID string, postID primitive.ObjectID, commentID *primitive.ObjectID, mentioned, previous []string) {
+	if m == nil || len(mentioned) == 0 {
+		return
+	}
+
+	known := make(map[string]bool, len(previous))
+	for _, id := range previous {
+		known[id] = true
+	}
+
+	var events []*model.MentionEvent
+	for _, id := range mentioned {
+		if known[id] {
+			continue
+		}
+		events = append(events, &model.MentionEvent{
+			UserID:    id,
+			AuthorID:  authorID,
+			PostID:    postID,
+			CommentID: commentID,
+		})
+	}
+
+	if err := m.outbox.AddMentionEvents(ctx, events); err != nil {
+		m.logger.Error("failed to record mention events",
+			zap.String("post_id", postID.Hex()),
+			zap.Int("events", len(events)),
+			zap.Error(err),
+		)
+	}
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/mention"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// recordingOutbox запоминает записанные события; fail - запись не удается
+type recordingOutbox struct {
+	events []*model.MentionEvent
+	fail   bool
+}
+
+func (o *recordingOutbox) AddMentionEvents(ctx context.Context, events []*model.MentionEvent) error {
+	if o.fail {
+		return errors.New("write failed")
+	}
+	o.events = append(o.events, events...)
+	return nil
+}
+
+func TestMentions(t *testing.T) {
+	parser, err := mention.NewParser("")
+	require.NoError(t, err)
+	ctx := context.Background()
+	postID := primitive.NewObjectID()
+
+	t.Run("Resolve skips unknown handles and the author", func(t *testing.T) {
+		m := NewMentions(parser, usernameLookup{}, &recordingOutbox{}, zap.NewNop())
+
+		ids, err := m.Resolve(ctx, "@bob @ghost @alice @bob", "bob-id")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"alice-id"}, ids)
+	})
+
+	t.Run("Resolve reports the lookup failure", func(t *testing.T) {
+		m := NewMentions(parser, usernameLookup{down: true}, &recordingOutbox{}, zap.NewNop())
+
+		_, err := m.Resolve(ctx, "hi @alice", "bob-id")
+		assert.Error(t, err)
+	})
+
+	t.Run("Notify records comment mentions", func(t *testing.T) {
+		outbox := &recordingOutbox{}
+		m := NewMentions(parser, usernameLookup{}, outbox, zap.NewNop())
+		commentID := primitive.NewObjectID()
+
+		m.Notify(ctx, "carol-id", postID, &commentID, []string{"alice-id", "bob-id"}, []string{"bob-id"})
+		require.Len(t, outbox.events, 1)
+		assert.Equal(t, "alice-id", outbox.events[0].UserID)
+		assert.Equal(t, "carol-id", outbox.events[0].AuthorID)
+		assert.Equal(t, &commentID, outbox.events[0].CommentID)
+	})
+
+	t.Run("Outbox failure is not fatal", func(t *testing.T) {
+		m := NewMentions(parser, usernameLookup{}, &recordingOutbox{fail: true}, zap.NewNop())
+		assert.NotPanics(t, func() {
+			m.Notify(ctx, "carol-id", postID, nil, []string{"alice-id"}, nil)
+		})
+	})
+
+	t.Run("Nil Mentions is disabled", func(t *testing.T) {
+		var m *Mentions
+		ids, err := m.Resolve(ctx, "hi @alice", "bob-id")
+		assert.NoError(t, err)
+		assert.Nil(t, ids)
+		m.Notify(ctx, "bob-id", postID, nil, []string{"alice-id"}, nil)
+	})
+}
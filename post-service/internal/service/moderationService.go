@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/model"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// ModerationService - жалобы на посты и очередь модерации.
+// Права (жалуется авторизованный, разбирает администратор) проверяет хендлер.
+type ModerationService interface {
+	// Report - жалоба на пост; ErrInvalidReportReason - 400, repository.ErrNotFound - поста нет
+	Report(ctx context.Context, postID, reporterID, reason string) error
+	ListOpenReports(ctx context.Context, limit, offset int64) (*model.PaginatedReports, error)
+	// Resolve закрывает жалобу; hidePost дополнительно скрывает пост.
+	// repository.ErrReportNotFound - открытой жалобы нет
+	Resolve(ctx context.Context, reportID, resolverID string, hidePost bool) (*model.Report, error)
+}
+
+// MaxReportReasonLength - предел длины причины жалобы в символах
+const MaxReportReasonLength = 500
+
+// ErrInvalidReportReason - причина пустая или длиннее MaxReportReasonLength
+var ErrInvalidReportReason = errors.New("report reason must be 1-500 characters")
+
+type moderationService struct {
+	reports repository.ReportRepository
+	posts   PostService
+	logger  *zap.Logger
+}
+
+func NewModerationService(reports repository.ReportRepository, posts PostService, logger *zap.Logger) ModerationService {
+	return &moderationService{
+		reports: reports,
+		posts:   posts,
+		logger:  logger,
+	}
+}
+
+func (s *moderationService) Report(ctx context.Context, postID, reporterID, reason string) error {
+	reason = strings.TrimSpace(reason)
+	if reason == "" || utf8.RuneCountInString(reason) > MaxReportReasonLength {
+		return ErrInvalidReportReason
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(postID)
+	if err != nil {
+		return repository.ErrNotFound
+	}
+
+	return s.reports.CreateReport(ctx, &model.Report{
+		PostID:     objectID,
+		ReporterID: reporterID,
+		Reason:     reason,
+	})
+}
+
+func (s *moderationService) ListOpenReports(ctx context.Context, limit, offset int64) (*model.PaginatedReports, error) {
+	return s.reports.ListOpenReports(ctx, limit, offset)
+}
+
+func (s *moderationService) Resolve(ctx context.Context, reportID, resolverID string, hidePost bool) (*model.Report, error) {
+	report, err := s.reports.GetOpenReport(ctx, reportID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Сначала пост: если скрыть не удалось, жалоба остается в очереди
+	if hidePost {
+		err := s.posts.Hide(ctx, report.PostID.Hex())
+		// Пост уже удален - скрывать нечего, жалобу все равно закрываем
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return nil, err
+		}
+	}
+
+	return s.reports.ResolveReport(ctx, reportID, resolverID, hidePost)
+}
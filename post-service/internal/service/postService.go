@@ -0,0 +1,596 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/authclient"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/cache"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/model"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/repository"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/sanitize"
+	"go.uber.org/zap"
+)
+
+// PostService - запись постов. Все, что попадает в базу, проходит через санитайзер,
+// поэтому при чтении тело поста можно отдавать без дополнительной обработки.
+// Ошибки sanitize.ErrContentTooLong / sanitize.ErrEmptyContent / sanitize.ErrTooManyTags /
+// sanitize.ErrInvalidTag - это 400.
+type PostService interface {
+	Create(ctx context.Context, post *model.Post) error
+	Update(ctx context.Context, post *model.Post, editorID string) error
+	// Edit меняет заданные в changes поля поста id (PATCH /posts/:id) и возвращает пост после правки.
+	// Править может автор или администратор, иначе ErrForbidden; прежние title/content уходят в ревизии.
+	// С RegenerateSlug slug строится заново из заголовка, старый остается алиасом для редиректа
+	Edit(ctx context.Context, id, actorID string, isAdmin bool, changes PostChanges) (*model.Post, error)
+	// GetCached возвращает готовый JSON поста и его ETag (для GET /posts/:id). Неопубликованный пост
+	// (черновик, запланированный, скрытый) видят только автор и администратор, остальным - ErrNotFound
+	GetCached(ctx context.Context, id, viewerID string, isAdmin bool) (*cache.CachedPost, error)
+	// GetPreview - то же с телом, обрезанным до posts.preview_length символов (GET /posts/:id?preview=true)
+	GetPreview(ctx context.Context, id, viewerID string, isAdmin bool) (*cache.CachedPost, error)
+	// GetBySlug ищет пост по текущему или старому slug (GET /posts/slug/:slug); видимость - как у GetCached.
+	// Если post.Slug отличается от запрошенного, slug устарел и клиента стоит редиректнуть
+	GetBySlug(ctx context.Context, slug, viewerID string, isAdmin bool) (*model.Post, error)
+	// Delete - мягкое удаление (status=deleted) со сбросом кешей поста и счетчика автора.
+	// Удалить может автор или администратор, иначе ErrForbidden
+	Delete(ctx context.Context, id, actorID string, isAdmin bool) error
+	// Restore отменяет мягкое удаление; права те же, что у Delete
+	Restore(ctx context.Context, id, actorID string, isAdmin bool) error
+	// HardDelete удаляет пост навсегда - только для администратора, проверяет хендлер
+	HardDelete(ctx context.Context, id string) error
+	// Hide скрывает пост по решению модератора со сбросом кешей поста и счетчика автора
+	Hide(ctx context.Context, id string) error
+	// Schedule планирует публикацию черновика на publishAt; права те же, что у Delete.
+	// ErrPublishAtInPast - срок уже прошел, repository.ErrNotDraft - пост не черновик
+	Schedule(ctx context.Context, id, actorID string, isAdmin bool, publishAt time.Time) (*model.Post, error)
+	// PublishDue публикует запланированные посты, срок которых наступил; возвращает их число
+	PublishDue(ctx context.Context) (int, error)
+	// CountByAuthor - число постов автора; черновики учитываются, только если смотрит сам автор
+	CountByAuthor(ctx context.Context, authorID, viewerID string) (int64, error)
+	// ExportByAuthor - все посты автора, включая черновики, по одному (выгрузка своих данных)
+	ExportByAuthor(ctx context.Context, authorID string, fn func(*model.Post) error) error
+	// ListRevisions - история правок поста, новые первыми (хранится posts.max_revisions последних);
+	// только автору и администратору
+	ListRevisions(ctx context.Context, id, actorID string, isAdmin bool) ([]*model.PostRevision, error)
+	// Related - опубликованные посты с общими тегами для страницы опубликованного поста id, через кеш;
+	// у неопубликованного - ErrNotFound, даже если подборка для него еще лежит в кеше
+	Related(ctx context.Context, id string) ([]*model.Post, error)
+}
+
+var (
+	// ErrForbidden - пост чужой, а действующий пользователь не администратор
+	ErrForbidden = errors.New("not allowed to modify this post")
+	// ErrPublishAtInPast - publish_at должен быть в будущем
+	ErrPublishAtInPast = errors.New("publish_at must be in the future")
+)
+
+// PostChanges - правка поста; nil - поле остается прежним
+type PostChanges struct {
+	Title   *string
+	Content *string
+	Topic   *string
+	Tags    *[]string
+	// RegenerateSlug - пересобрать slug из (нового) заголовка
+	RegenerateSlug bool
+}
+
+// publishBatch - сколько запланированных постов публикуется за один проход PublishDue;
+// остальные дождутся следующего
+const publishBatch = 100
+
+type postService struct {
+	repo          repository.PostRepository
+	sanitizer     *sanitize.Sanitizer
+	cache         *cache.PostCache
+	countCache    *cache.PostCountCache
+	relatedCache  *cache.RelatedCache
+	relatedLimit  int64
+	previewLength int
+	feed          FeedInvalidator
+	mentions      *Mentions
+	logger        *zap.Logger
+}
+
+// FeedInvalidator сбрасывает закешированные ленты подписчиков автора (см. FeedService)
+type FeedInvalidator interface {
+	OnPostPublished(ctx context.Context, authorID string) error
+}
+
+// UsernameLookup находит пользователей auth-service по username (см. authclient.UserDirectory)
+type UsernameLookup interface {
+	LookupUsernames(ctx context.Context, usernames []string) ([]*authclient.User, error)
+}
+
+// PostOptions - настройки postService из конфига (posts.*)
+type PostOptions struct {
+	// RelatedLimit - сколько похожих постов отдавать на странице поста
+	RelatedLimit int
+	// PreviewLength - сколько символов тела оставлять в превью
+	PreviewLength int
+	// Feed узнает о каждом изменении публичных постов автора; nil - лент нет
+	Feed FeedInvalidator
+	// Mentions - разбор @username в теле поста и события для упомянутых; nil - упоминания не сохраняются
+	Mentions *Mentions
+}
+
+func NewPostService(
+	repo repository.PostRepository,
+	sanitizer *sanitize.Sanitizer,
+	postCache *cache.PostCache,
+	countCache *cache.PostCountCache,
+	relatedCache *cache.RelatedCache,
+	opts PostOptions,
+	logger *zap.Logger,
+) PostService {
+	return &postService{
+		repo:          repo,
+		sanitizer:     sanitizer,
+		cache:         postCache,
+		countCache:    countCache,
+		relatedCache:  relatedCache,
+		relatedLimit:  int64(opts.RelatedLimit),
+		previewLength: opts.PreviewLength,
+		feed:          opts.Feed,
+		mentions:      opts.Mentions,
+		logger:        logger,
+	}
+}
+
+func (s *postService) Create(ctx context.Context, post *model.Post) error {
+	if err := s.sanitize(post); err != nil {
+		return err
+	}
+	s.resolveMentions(ctx, post, nil)
+
+	if err := s.repo.Create(ctx, post); err != nil {
+		return err
+	}
+
+	s.mentions.Notify(ctx, post.AuthorID, post.ID, nil, post.Mentions, nil)
+	s.invalidateAuthor(ctx, post.AuthorID)
+	return nil
+}
+
+func (s *postService) Update(ctx context.Context, post *model.Post, editorID string) error {
+	// post может быть собран из запроса без упоминаний, а repo.Update перезаписывает их целиком:
+	// прежние берутся из базы, чтобы не потерять их при сбое auth-service и не уведомлять повторно
+	var previous []string
+	if s.mentions != nil {
+		stored, err := s.repo.GetByID(ctx, post.ID.Hex())
+		if err != nil {
+			return err
+		}
+		previous = stored.Mentions
+	}
+	return s.update(ctx, post, editorID, previous)
+}
+
+// update сохраняет правку; previous - упоминания сохраненной версии поста
+func (s *postService) update(ctx context.Context, post *model.Post, editorID string, previous []string) error {
+	if err := s.sanitize(post); err != nil {
+		return err
+	}
+	s.resolveMentions(ctx, post, previous)
+
+	if err := s.repo.Update(ctx, post, editorID); err != nil {
+		return err
+	}
+
+	s.mentions.Notify(ctx, post.AuthorID, post.ID, nil, post.Mentions, previous)
+
+	// Иначе клиенты будут получать старый ETag до истечения TTL
+	if err := s.cache.Invalidate(ctx, post.ID.Hex()); err != nil {
+		s.logger.Warn("post cache invalidation failed", zap.String("post_id", post.ID.Hex()), zap.Error(err))
+	}
+	// Публикация черновика меняет публичный счетчик
+	s.invalidateAuthor(ctx, post.AuthorID)
+
+	return nil
+}
+
+func (s *postService) Edit(ctx context.Context, id, actorID string, isAdmin bool, changes PostChanges) (*model.Post, error) {
+	// Правится сохраненный пост целиком: счетчики, slug и упоминания не затираются значениями из запроса
+	post, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !isAdmin && post.AuthorID != actorID {
+		return nil, ErrForbidden
+	}
+
+	previous := post.Mentions
+
+	if changes.Title != nil {
+		post.Title = *changes.Title
+	}
+	if changes.Content != nil {
+		post.Content = *changes.Content
+	}
+	if changes.Topic != nil {
+		post.Topic = *changes.Topic
+	}
+	if changes.Tags != nil {
+		post.Tags = *changes.Tags
+	}
+
+	if err := s.update(ctx, post, actorID, previous); err != nil {
+		return nil, err
+	}
+
+	if changes.RegenerateSlug {
+		newSlug, err := s.repo.RegenerateSlug(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if newSlug != post.Slug {
+			post.Slug = newSlug
+			// В закешированном JSON поста остался старый slug
+			if err := s.cache.Invalidate(ctx, id); err != nil {
+				s.logger.Warn("post cache invalidation failed", zap.String("post_id", id), zap.Error(err))
+			}
+		}
+	}
+
+	return post, nil
+}
+
+func (s *postService) Delete(ctx context.Context, id, actorID string, isAdmin bool) error {
+	// Автор нужен и для проверки прав, и чтобы сбросить его счетчик
+	post, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !isAdmin && post.AuthorID != actorID {
+		return ErrForbidden
+	}
+
+	if err := s.repo.MarkAsDeleted(ctx, id); err != nil {
+		return err
+	}
+
+	if err := s.cache.Invalidate(ctx, id); err != nil {
+		s.logger.Warn("post cache invalidation failed", zap.String("post_id", id), zap.Error(err))
+	}
+	s.invalidateAuthor(ctx, post.AuthorID)
+
+	return nil
+}
+
+func (s *postService) Restore(ctx context.Context, id, actorID string, isAdmin bool) error {
+	// Чужой удаленный пост для не-администратора неотличим от несуществующего (ErrNotFound)
+	authorFilter := actorID
+	if isAdmin {
+		authorFilter = ""
+	}
+
+	post, err := s.repo.Restore(ctx, id, authorFilter)
+	if err != nil {
+		return err
+	}
+
+	if err := s.cache.Invalidate(ctx, id); err != nil {
+		s.logger.Warn("post cache invalidation failed", zap.String("post_id", id), zap.Error(err))
+	}
+	s.invalidateAuthor(ctx, post.AuthorID)
+
+	return nil
+}
+
+func (s *postService) HardDelete(ctx context.Context, id string) error {
+	// Мягко удаленный пост GetByID не находит - но и в счетчике его уже нет
+	post, err := s.repo.GetByID(ctx, id)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return err
+	}
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	if err := s.cache.Invalidate(ctx, id); err != nil {
+		s.logger.Warn("post cache invalidation failed", zap.String("post_id", id), zap.Error(err))
+	}
+	if post != nil {
+		s.invalidateAuthor(ctx, post.AuthorID)
+	}
+
+	return nil
+}
+
+func (s *postService) Hide(ctx context.Context, id string) error {
+	post, err := s.repo.Hide(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.cache.Invalidate(ctx, id); err != nil {
+		s.logger.Warn("post cache invalidation failed", zap.String("post_id", id), zap.Error(err))
+	}
+	s.invalidateAuthor(ctx, post.AuthorID)
+
+	return nil
+}
+
+func (s *postService) Schedule(ctx context.Context, id, actorID string, isAdmin bool, publishAt time.Time) (*model.Post, error) {
+	if !publishAt.After(time.Now()) {
+		return nil, ErrPublishAtInPast
+	}
+
+	post, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !isAdmin && post.AuthorID != actorID {
+		return nil, ErrForbidden
+	}
+	if post.Status != model.PostStatusDraft && post.Status != model.PostStatusScheduled {
+		return nil, repository.ErrNotDraft
+	}
+
+	// Черновик и запланированный пост и так не видны публично - кеши сбрасывать незачем
+	return s.repo.Schedule(ctx, id, publishAt.UTC())
+}
+
+func (s *postService) PublishDue(ctx context.Context) (int, error) {
+	published, err := s.repo.PublishDue(ctx, time.Now(), publishBatch)
+
+	// Даже при ошибке часть постов уже опубликована - их кеши сбрасываем
+	for _, post := range published {
+		if err := s.cache.Invalidate(ctx, post.ID.Hex()); err != nil {
+			s.logger.Warn("post cache invalidation failed", zap.String("post_id", post.ID.Hex()), zap.Error(err))
+		}
+		s.invalidateAuthor(ctx, post.AuthorID)
+	}
+
+	return len(published), err
+}
+
+func (s *postService) CountByAuthor(ctx context.Context, authorID, viewerID string) (int64, error) {
+	includeDrafts := viewerID != "" && viewerID == authorID
+
+	count, ok, err := s.countCache.Get(ctx, authorID, includeDrafts)
+	if err != nil {
+		// Redis недоступен - не страшно, идем в Mongo
+		s.logger.Warn("post count cache get failed", zap.String("author_id", authorID), zap.Error(err))
+	}
+	if ok {
+		return count, nil
+	}
+
+	count, err = s.repo.CountByAuthor(ctx, authorID, includeDrafts)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := s.countCache.Set(ctx, authorID, includeDrafts, count); err != nil {
+		s.logger.Warn("post count cache set failed", zap.String("author_id", authorID), zap.Error(err))
+	}
+
+	return count, nil
+}
+
+// ExportByAuthor идет мимо кеша: постов может быть много, и нужны все, включая черновики
+func (s *postService) ExportByAuthor(ctx context.Context, authorID string, fn func(*model.Post) error) error {
+	return s.repo.StreamByAuthor(ctx, authorID, fn)
+}
+
+func (s *postService) ListRevisions(ctx context.Context, id, actorID string, isAdmin bool) ([]*model.PostRevision, error) {
+	post, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !isAdmin && post.AuthorID != actorID {
+		return nil, ErrForbidden
+	}
+
+	return s.repo.ListRevisions(ctx, id)
+}
+
+func (s *postService) Related(ctx context.Context, id string) ([]*model.Post, error) {
+	// Проверка статуса - до кеша похожих: пост могли скрыть или удалить после того,
+	// как его подборка попала в кеш
+	post, err := s.publishedPost(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	cached, ok, err := s.relatedCache.Get(ctx, id)
+	if err != nil {
+		// Redis недоступен - не страшно, идем в Mongo
+		s.logger.Warn("related posts cache get failed", zap.String("post_id", id), zap.Error(err))
+	}
+	if ok {
+		return cached, nil
+	}
+
+	if post == nil {
+		// Статус подтвердил кеш постов, а для подборки нужны теги
+		if post, err = s.repo.GetByID(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+
+	related, err := s.repo.ListRelated(ctx, post, s.relatedLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.relatedCache.Set(ctx, id, related); err != nil {
+		s.logger.Warn("related posts cache set failed", zap.String("post_id", id), zap.Error(err))
+	}
+
+	return related, nil
+}
+
+// publishedPost возвращает ErrNotFound, если пост id не опубликован. Попадание в кеш постов
+// статус подтверждает без Mongo - тогда post == nil; иначе возвращается пост из репозитория.
+func (s *postService) publishedPost(ctx context.Context, id string) (*model.Post, error) {
+	cached, ok, err := s.cache.Get(ctx, id)
+	if err != nil {
+		s.logger.Warn("post cache get failed", zap.String("post_id", id), zap.Error(err))
+	}
+	if ok && cached.Status == model.PostStatusPublished {
+		return nil, nil
+	}
+
+	post, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if post.Status != model.PostStatusPublished {
+		return nil, repository.ErrNotFound
+	}
+	return post, nil
+}
+
+// invalidateAuthor сбрасывает счетчик постов автора и ленты его подписчиков. Ошибка Redis
+// не ломает запись: счетчик и ленты сами устареют через count_cache_ttl и feed.cache_ttl
+func (s *postService) invalidateAuthor(ctx context.Context, authorID string) {
+	if authorID == "" {
+		return
+	}
+	if err := s.countCache.Invalidate(ctx, authorID); err != nil {
+		s.logger.Warn("post count cache invalidation failed", zap.String("author_id", authorID), zap.Error(err))
+	}
+	if s.feed == nil {
+		return
+	}
+	if err := s.feed.OnPostPublished(ctx, authorID); err != nil {
+		s.logger.Warn("feed invalidation failed", zap.String("author_id", authorID), zap.Error(err))
+	}
+}
+
+// canView - опубликованный пост виден всем; черновик, запланированный и скрытый -
+// только автору и администратору, для остальных его нет
+func canView(status model.PostStatus, authorID, viewerID string, isAdmin bool) bool {
+	return status == model.PostStatusPublished || isAdmin || (viewerID != "" && viewerID == authorID)
+}
+
+// GetCached кеширует только опубликованные посты, но права проверяются и на попадании в кеш:
+// запись без статуса или с другим статусом считается промахом
+func (s *postService) GetCached(ctx context.Context, id, viewerID string, isAdmin bool) (*cache.CachedPost, error) {
+	cached, ok, err := s.cache.Get(ctx, id)
+	if err != nil {
+		// Redis недоступен - не страшно, идем в Mongo
+		s.logger.Warn("post cache get failed", zap.String("post_id", id), zap.Error(err))
+	}
+	if ok && cached.Status == model.PostStatusPublished {
+		return cached, nil
+	}
+
+	post, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	// Неопубликованный пост для читателей не существует
+	if !canView(post.Status, post.AuthorID, viewerID, isAdmin) {
+		return nil, repository.ErrNotFound
+	}
+
+	body, err := json.Marshal(post)
+	if err != nil {
+		return nil, err
+	}
+
+	cached = &cache.CachedPost{ETag: post.ETag(), Body: body, Status: post.Status, AuthorID: post.AuthorID}
+
+	if post.Status == model.PostStatusPublished {
+		if err := s.cache.Set(ctx, id, cached); err != nil {
+			s.logger.Warn("post cache set failed", zap.String("post_id", id), zap.Error(err))
+		}
+	}
+
+	return cached, nil
+}
+
+func (s *postService) GetBySlug(ctx context.Context, slug, viewerID string, isAdmin bool) (*model.Post, error) {
+	post, err := s.repo.GetBySlug(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	// По slug черновик так же невидим, как по ID
+	if !canView(post.Status, post.AuthorID, viewerID, isAdmin) {
+		return nil, repository.ErrNotFound
+	}
+
+	return post, nil
+}
+
+// postPreview - JSON поста с обрезанным телом: поля поста плюс флаг обрезки и полная длина
+type postPreview struct {
+	*model.Post
+	Truncated  bool `json:"truncated"`
+	FullLength int  `json:"full_length"`
+}
+
+// GetPreview строится из закешированного полного поста: обрезка дешевая, отдельный ключ в Redis не нужен
+func (s *postService) GetPreview(ctx context.Context, id, viewerID string, isAdmin bool) (*cache.CachedPost, error) {
+	full, err := s.GetCached(ctx, id, viewerID, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	var post model.Post
+	if err := json.Unmarshal(full.Body, &post); err != nil {
+		return nil, err
+	}
+
+	preview := postPreview{Post: &post}
+	post.Content, preview.Truncated, preview.FullLength = sanitize.Preview(post.Content, s.previewLength)
+
+	body, err := json.Marshal(preview)
+	if err != nil {
+		return nil, err
+	}
+
+	// Превью - другое представление того же поста: ETag должен отличаться от полного,
+	// но меняться вместе с ним
+	etag := strings.TrimSuffix(full.ETag, `"`) + `-preview"`
+
+	return &cache.CachedPost{ETag: etag, Body: body, Status: full.Status, AuthorID: full.AuthorID}, nil
+}
+
+// resolveMentions заполняет post.Mentions по уже очищенному телу. Если auth-service
+// недоступен, остаются previous - упоминания сохраненной версии: сохранить пост важнее
+func (s *postService) resolveMentions(ctx context.Context, post *model.Post, previous []string) {
+	if s.mentions == nil {
+		return
+	}
+
+	mentioned, err := s.mentions.Resolve(ctx, post.Content, post.AuthorID)
+	if err != nil {
+		post.Mentions = previous
+		return
+	}
+	post.Mentions = mentioned
+}
+
+func (s *postService) sanitize(post *model.Post) error {
+	content, err := s.sanitizer.Content(post.Content)
+	if err != nil {
+		s.logger.Warn("post content rejected",
+			zap.String("author_id", post.AuthorID),
+			zap.Error(err),
+		)
+		return err
+	}
+
+	tags, err := s.sanitizer.Tags(post.Tags)
+	if err != nil {
+		s.logger.Warn("post tags rejected",
+			zap.String("author_id", post.AuthorID),
+			zap.Int("tags", len(post.Tags)),
+			zap.Error(err),
+		)
+		return err
+	}
+
+	post.Title = s.sanitizer.Title(post.Title)
+	post.Content = content
+	post.Tags = tags
+
+	return nil
+}
@@ -0,0 +1,386 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/authclient"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/cache"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/mention"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/model"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/repository"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/sanitize"
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/slug"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// postsRepo - PostRepository, в котором реализованы только чтения
+type postsRepo struct {
+	repository.PostRepository
+	posts map[string]*model.Post
+	// relatedFor - для какого поста запрашивали похожие
+	relatedFor []string
+}
+
+func (r *postsRepo) GetByID(ctx context.Context, id string) (*model.Post, error) {
+	post, ok := r.posts[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	copied := *post
+	return &copied, nil
+}
+
+// GetBySlug ищет по текущему slug и старым, как репозиторий
+func (r *postsRepo) GetBySlug(ctx context.Context, slug string) (*model.Post, error) {
+	for _, post := range r.posts {
+		if post.Slug == slug || slices.Contains(post.SlugAliases, slug) {
+			copied := *post
+			return &copied, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *postsRepo) ListRelated(ctx context.Context, post *model.Post, limit int64) ([]*model.Post, error) {
+	r.relatedFor = append(r.relatedFor, post.Title)
+	return []*model.Post{}, nil
+}
+
+func (r *postsRepo) ListRevisions(ctx context.Context, postID string) ([]*model.PostRevision, error) {
+	return []*model.PostRevision{{Title: "previous"}}, nil
+}
+
+// newTestPostService - сервис без Redis: кеши работают как no-op
+func newTestPostService(repo repository.PostRepository) PostService {
+	conn := cache.NewConn(nil)
+	return NewPostService(repo, nil,
+		cache.NewPostCache(conn, 0),
+		cache.NewPostCountCache(conn, 0),
+		cache.NewRelatedCache(conn, 0),
+		PostOptions{RelatedLimit: 5, PreviewLength: 10},
+		zap.NewNop(),
+	)
+}
+
+func TestPostService_GetCached_Visibility(t *testing.T) {
+	const author = "author-1"
+	posts := map[string]*model.Post{}
+	for _, status := range []model.PostStatus{
+		model.PostStatusPublished, model.PostStatusDraft, model.PostStatusScheduled, model.PostStatusHidden,
+	} {
+		posts[string(status)] = &model.Post{ID: primitive.NewObjectID(), AuthorID: author, Title: "t", Content: "some long content", Status: status}
+	}
+	svc := newTestPostService(&postsRepo{posts: posts})
+	ctx := context.Background()
+
+	t.Run("Published post is public", func(t *testing.T) {
+		cached, err := svc.GetCached(ctx, "published", "", false)
+		require.NoError(t, err)
+		assert.Equal(t, model.PostStatusPublished, cached.Status)
+		assert.Equal(t, author, cached.AuthorID)
+	})
+
+	for _, status := range []string{"draft", "scheduled", "hidden"} {
+		t.Run(status+" is hidden from readers", func(t *testing.T) {
+			_, err := svc.GetCached(ctx, status, "", false)
+			assert.ErrorIs(t, err, repository.ErrNotFound)
+
+			_, err = svc.GetCached(ctx, status, "someone-else", false)
+			assert.ErrorIs(t, err, repository.ErrNotFound)
+
+			_, err = svc.GetPreview(ctx, status, "", false)
+			assert.ErrorIs(t, err, repository.ErrNotFound)
+		})
+
+		t.Run(status+" is visible to the author and admins", func(t *testing.T) {
+			_, err := svc.GetCached(ctx, status, author, false)
+			assert.NoError(t, err)
+
+			_, err = svc.GetCached(ctx, status, "moderator", true)
+			assert.NoError(t, err)
+
+			preview, err := svc.GetPreview(ctx, status, author, false)
+			require.NoError(t, err)
+			assert.Contains(t, string(preview.Body), `"truncated":true`)
+		})
+	}
+}
+
+func TestPostService_Related_PublishedOnly(t *testing.T) {
+	repo := &postsRepo{posts: map[string]*model.Post{}}
+	for _, status := range []model.PostStatus{
+		model.PostStatusPublished, model.PostStatusDraft, model.PostStatusScheduled, model.PostStatusHidden,
+	} {
+		repo.posts[string(status)] = &model.Post{ID: primitive.NewObjectID(), Title: string(status), Status: status, Tags: []string{"go"}}
+	}
+	svc := newTestPostService(repo)
+	ctx := context.Background()
+
+	related, err := svc.Related(ctx, "published")
+	require.NoError(t, err)
+	assert.NotNil(t, related)
+
+	for _, status := range []string{"draft", "scheduled", "hidden", "missing"} {
+		_, err := svc.Related(ctx, status)
+		assert.ErrorIs(t, err, repository.ErrNotFound, status)
+	}
+	assert.Equal(t, []string{"published"}, repo.relatedFor)
+}
+
+func TestPostService_ListRevisions(t *testing.T) {
+	svc := newTestPostService(&postsRepo{posts: map[string]*model.Post{
+		"p1": {ID: primitive.NewObjectID(), AuthorID: "author-1", Status: model.PostStatusPublished},
+	}})
+	ctx := context.Background()
+
+	revisions, err := svc.ListRevisions(ctx, "p1", "author-1", false)
+	require.NoError(t, err)
+	assert.Len(t, revisions, 1)
+
+	_, err = svc.ListRevisions(ctx, "p1", "moderator", true)
+	assert.NoError(t, err)
+
+	_, err = svc.ListRevisions(ctx, "p1", "someone-else", false)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	_, err = svc.ListRevisions(ctx, "missing", "author-1", false)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+}
+
+func TestCanView(t *testing.T) {
+	assert.True(t, canView(model.PostStatusPublished, "a1", "", false))
+	assert.False(t, canView(model.PostStatusHidden, "a1", "", false))
+	// Пустой viewer не совпадает с пустым автором
+	assert.False(t, canView(model.PostStatusDraft, "", "", false))
+	assert.True(t, canView(model.PostStatusDraft, "a1", "a1", false))
+	assert.True(t, canView(model.PostStatusHidden, "a1", "u2", true))
+	// Старая запись кеша без статуса - не опубликованный пост
+	assert.False(t, canView("", "a1", "u2", false))
+}
+
+func TestPostService_GetBySlug(t *testing.T) {
+	const author = "author-1"
+	svc := newTestPostService(&postsRepo{posts: map[string]*model.Post{
+		"published": {ID: primitive.NewObjectID(), AuthorID: author, Status: model.PostStatusPublished,
+			Slug: "hello-go", SlugAliases: []string{"hello-world"}},
+		"draft": {ID: primitive.NewObjectID(), AuthorID: author, Status: model.PostStatusDraft, Slug: "secret-plan"},
+	}})
+	ctx := context.Background()
+
+	t.Run("Old slug finds the post", func(t *testing.T) {
+		post, err := svc.GetBySlug(ctx, "hello-world", "", false)
+		require.NoError(t, err)
+		assert.Equal(t, "hello-go", post.Slug)
+	})
+
+	t.Run("Draft is hidden from readers", func(t *testing.T) {
+		_, err := svc.GetBySlug(ctx, "secret-plan", "reader", false)
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+
+	t.Run("Draft is visible to the author and admins", func(t *testing.T) {
+		_, err := svc.GetBySlug(ctx, "secret-plan", author, false)
+		assert.NoError(t, err)
+		_, err = svc.GetBySlug(ctx, "secret-plan", "admin", true)
+		assert.NoError(t, err)
+	})
+}
+
+// writingRepo запоминает последний сохраненный пост
+type writingRepo struct {
+	postsRepo
+	saved *model.Post
+}
+
+func (r *writingRepo) Create(ctx context.Context, post *model.Post) error {
+	post.ID = primitive.NewObjectID()
+	r.saved = post
+	return nil
+}
+
+func (r *writingRepo) Update(ctx context.Context, post *model.Post, editorID string) error {
+	r.saved = post
+	if stored, ok := r.posts[post.ID.Hex()]; ok {
+		*stored = *post
+	}
+	return nil
+}
+
+// RegenerateSlug - как в репозитории: slug из заголовка, старый уходит в алиасы
+func (r *writingRepo) RegenerateSlug(ctx context.Context, id string) (string, error) {
+	post, ok := r.posts[id]
+	if !ok {
+		return "", repository.ErrNotFound
+	}
+	if fresh := slug.Make(post.Title); fresh != post.Slug {
+		post.SlugAliases = append(post.SlugAliases, post.Slug)
+		post.Slug = fresh
+	}
+	return post.Slug, nil
+}
+
+// usernameLookup знает alice и bob; down - auth-service недоступен
+type usernameLookup struct {
+	down bool
+}
+
+func (l usernameLookup) LookupUsernames(ctx context.Context, usernames []string) ([]*authclient.User, error) {
+	if l.down {
+		return nil, errors.New("connection refused")
+	}
+	var users []*authclient.User
+	for _, name := range usernames {
+		if name == "alice" || name == "bob" {
+			users = append(users, &authclient.User{ID: name + "-id", Username: name})
+		}
+	}
+	return users, nil
+}
+
+func TestPostService_Mentions(t *testing.T) {
+	const author = "bob-id"
+	storedID := primitive.NewObjectID()
+
+	newService := func(users UsernameLookup) (PostService, *writingRepo, *recordingOutbox) {
+		sanitizer, err := sanitize.New(sanitize.ModePlain, 1000, 10)
+		require.NoError(t, err)
+		parser, err := mention.NewParser("")
+		require.NoError(t, err)
+
+		repo := &writingRepo{postsRepo: postsRepo{posts: map[string]*model.Post{
+			storedID.Hex(): {ID: storedID, AuthorID: author, Title: "t", Content: "hi @alice", Mentions: []string{"alice-id"}},
+		}}}
+		outbox := &recordingOutbox{}
+		conn := cache.NewConn(nil)
+		return NewPostService(repo, sanitizer,
+			cache.NewPostCache(conn, 0), cache.NewPostCountCache(conn, 0), cache.NewRelatedCache(conn, 0),
+			PostOptions{RelatedLimit: 5, PreviewLength: 10, Mentions: NewMentions(parser, users, outbox, zap.NewNop())},
+			zap.NewNop(),
+		), repo, outbox
+	}
+	ctx := context.Background()
+
+	t.Run("Known users are stored in order of appearance", func(t *testing.T) {
+		svc, repo, outbox := newService(usernameLookup{})
+		post := &model.Post{AuthorID: author, Title: "t", Content: "thanks @alice, @ghost and @bob (me)"}
+
+		require.NoError(t, svc.Create(ctx, post))
+		assert.Equal(t, []string{"alice-id"}, repo.saved.Mentions, "unknown handles and the author are skipped")
+		require.Len(t, outbox.events, 1)
+		assert.Equal(t, "alice-id", outbox.events[0].UserID)
+		assert.Equal(t, post.ID, outbox.events[0].PostID)
+		assert.Nil(t, outbox.events[0].CommentID)
+	})
+
+	t.Run("Edit without mentions clears them", func(t *testing.T) {
+		svc, repo, outbox := newService(usernameLookup{})
+		post := &model.Post{ID: storedID, AuthorID: author, Title: "t", Content: "no one"}
+
+		require.NoError(t, svc.Update(ctx, post, author))
+		assert.Nil(t, repo.saved.Mentions)
+		assert.Empty(t, outbox.events)
+	})
+
+	t.Run("Only new mentions are notified", func(t *testing.T) {
+		svc, repo, outbox := newService(usernameLookup{})
+		post := &model.Post{ID: storedID, AuthorID: "carol-id", Title: "t", Content: "hi @alice and @bob"}
+
+		require.NoError(t, svc.Update(ctx, post, "carol-id"))
+		assert.Equal(t, []string{"alice-id", "bob-id"}, repo.saved.Mentions)
+		require.Len(t, outbox.events, 1, "alice was already mentioned before the edit")
+		assert.Equal(t, "bob-id", outbox.events[0].UserID)
+	})
+
+	t.Run("Auth service down keeps the stored mentions", func(t *testing.T) {
+		svc, repo, outbox := newService(usernameLookup{down: true})
+		// Пост собран из тела запроса: упоминаний в нем нет
+		post := &model.Post{ID: storedID, AuthorID: author, Title: "t2", Content: "hi @alice again"}
+
+		require.NoError(t, svc.Update(ctx, post, author))
+		assert.Equal(t, []string{"alice-id"}, repo.saved.Mentions)
+		assert.Equal(t, []string{"alice-id"}, repo.posts[storedID.Hex()].Mentions, "stored mentions survive the failed lookup")
+		assert.Empty(t, outbox.events)
+	})
+
+	t.Run("Auth service down on Edit keeps the stored mentions", func(t *testing.T) {
+		svc, repo, _ := newService(usernameLookup{down: true})
+		content := "now @bob"
+
+		_, err := svc.Edit(ctx, storedID.Hex(), author, false, PostChanges{Content: &content})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"alice-id"}, repo.posts[storedID.Hex()].Mentions)
+	})
+}
+
+func TestPostService_Edit(t *testing.T) {
+	const author = "author-1"
+	sanitizer, err := sanitize.New(sanitize.ModePlain, 1000, 10)
+	require.NoError(t, err)
+
+	newService := func() (PostService, *writingRepo, string) {
+		id := primitive.NewObjectID()
+		repo := &writingRepo{postsRepo: postsRepo{posts: map[string]*model.Post{
+			id.Hex(): {ID: id, AuthorID: author, Title: "Old", Content: "body", Tags: []string{"go"},
+				LikesCount: 7, Slug: "old", Mentions: []string{"alice-id"}, Status: model.PostStatusPublished},
+		}}}
+		conn := cache.NewConn(nil)
+		svc := NewPostService(repo, sanitizer,
+			cache.NewPostCache(conn, 0), cache.NewPostCountCache(conn, 0), cache.NewRelatedCache(conn, 0),
+			PostOptions{RelatedLimit: 5, PreviewLength: 10}, zap.NewNop())
+		return svc, repo, id.Hex()
+	}
+	ctx := context.Background()
+	title := "New"
+
+	t.Run("Author changes only the given fields", func(t *testing.T) {
+		svc, repo, id := newService()
+
+		post, err := svc.Edit(ctx, id, author, false, PostChanges{Title: &title})
+		require.NoError(t, err)
+		assert.Equal(t, "New", post.Title)
+		assert.Equal(t, "body", repo.saved.Content)
+		assert.Equal(t, []string{"go"}, repo.saved.Tags)
+		assert.Equal(t, int64(7), repo.saved.LikesCount, "stored counters survive the edit")
+		assert.Equal(t, "old", repo.saved.Slug)
+		assert.Equal(t, []string{"alice-id"}, repo.saved.Mentions)
+	})
+
+	t.Run("Admin edits someone else's post", func(t *testing.T) {
+		svc, repo, id := newService()
+		_, err := svc.Edit(ctx, id, "admin-1", true, PostChanges{Title: &title})
+		require.NoError(t, err)
+		assert.NotNil(t, repo.saved)
+	})
+
+	t.Run("Regenerated slug keeps the old one as an alias", func(t *testing.T) {
+		svc, _, id := newService()
+		renamed := "Brand New Title"
+
+		post, err := svc.Edit(ctx, id, author, false, PostChanges{Title: &renamed, RegenerateSlug: true})
+		require.NoError(t, err)
+		assert.Equal(t, "brand-new-title", post.Slug)
+
+		byOld, err := svc.GetBySlug(ctx, "old", "", false)
+		require.NoError(t, err)
+		assert.Equal(t, "brand-new-title", byOld.Slug, "the old slug resolves to the renamed post")
+	})
+
+	t.Run("Other users are forbidden", func(t *testing.T) {
+		svc, repo, id := newService()
+		_, err := svc.Edit(ctx, id, "someone", false, PostChanges{Title: &title})
+		assert.ErrorIs(t, err, ErrForbidden)
+		assert.Nil(t, repo.saved)
+	})
+
+	t.Run("Missing post", func(t *testing.T) {
+		svc, _, _ := newService()
+		_, err := svc.Edit(ctx, primitive.NewObjectID().Hex(), author, false, PostChanges{Title: &title})
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/cache"
+	"go.uber.org/zap"
+)
+
+// publishLockKey - блокировка фоновой публикации, общая для всех реплик
+const publishLockKey = "posts:publish-scheduled"
+
+// locker - то, что нужно от cache.Locker
+type locker interface {
+	Lock(ctx context.Context, key string, ttl time.Duration) (release func(), acquired bool, err error)
+}
+
+// ScheduledPublisher раз в interval публикует запланированные посты, срок которых наступил.
+// Проход делает одна реплика - та, что взяла блокировку в Redis.
+type ScheduledPublisher struct {
+	posts    PostService
+	locker   locker
+	interval time.Duration
+	logger   *zap.Logger
+}
+
+func NewScheduledPublisher(posts PostService, locker *cache.Locker, interval time.Duration, logger *zap.Logger) *ScheduledPublisher {
+	return &ScheduledPublisher{posts: posts, locker: locker, interval: interval, logger: logger}
+}
+
+// Run работает до отмены ctx
+func (p *ScheduledPublisher) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		p.tick(ctx)
+	}
+}
+
+// tick - один проход. Без Redis блокировку не получить, но публикацию не откладываем:
+// каждый пост переводится условным обновлением, и вторая реплика его просто не найдет
+func (p *ScheduledPublisher) tick(ctx context.Context) {
+	// ttl = interval: проход заведомо короче, а упавшая реплика не держит блокировку дольше тика
+	release, acquired, err := p.locker.Lock(ctx, publishLockKey, p.interval)
+	switch {
+	case errors.Is(err, cache.ErrUnavailable):
+		p.logger.Debug("redis unavailable, publishing scheduled posts without lock")
+	case err != nil:
+		p.logger.Warn("failed to take scheduled publish lock", zap.Error(err))
+		return
+	case !acquired:
+		return
+	}
+	defer release()
+
+	count, err := p.posts.PublishDue(ctx)
+	if err != nil {
+		p.logger.Error("failed to publish scheduled posts", zap.Int("published", count), zap.Error(err))
+		return
+	}
+	if count > 0 {
+		p.logger.Info("scheduled posts published", zap.Int("count", count))
+	}
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gsrlabs/micro-blog-hub/post-service/internal/cache"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+type fakeLocker struct {
+	acquired bool
+	err      error
+	released bool
+}
+
+func (l *fakeLocker) Lock(ctx context.Context, key string, ttl time.Duration) (func(), bool, error) {
+	return func() { l.released = true }, l.acquired, l.err
+}
+
+// publishCounter - PostService, в котором реализован только PublishDue
+type publishCounter struct {
+	PostService
+	calls int
+}
+
+func (s *publishCounter) PublishDue(ctx context.Context) (int, error) {
+	s.calls++
+	return 1, nil
+}
+
+func TestScheduledPublisher_Tick(t *testing.T) {
+	tick := func(l *fakeLocker) *publishCounter {
+		posts := &publishCounter{}
+		p := &ScheduledPublisher{posts: posts, locker: l, interval: time.Minute, logger: zap.NewNop()}
+		p.tick(context.Background())
+		return posts
+	}
+
+	t.Run("Lock taken - publishes and releases", func(t *testing.T) {
+		l := &fakeLocker{acquired: true}
+		assert.Equal(t, 1, tick(l).calls)
+		assert.True(t, l.released)
+	})
+
+	t.Run("Another replica holds the lock", func(t *testing.T) {
+		assert.Equal(t, 0, tick(&fakeLocker{}).calls)
+	})
+
+	t.Run("Redis error - skip the tick", func(t *testing.T) {
+		assert.Equal(t, 0, tick(&fakeLocker{err: errors.New("boom")}).calls)
+	})
+
+	t.Run("Without Redis - publishes anyway", func(t *testing.T) {
+		assert.Equal(t, 1, tick(&fakeLocker{err: cache.ErrUnavailable}).calls)
+	})
+}
@@ -0,0 +1,112 @@
+package slug
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// MaxLength - ограничение длины slug, чтобы URL оставались читаемыми
+const MaxLength = 80
+
+// Транслитерация кириллицы (упрощенный ГОСТ 7.79-2000, схема Б без диакритики)
+var translit = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e",
+	'ж': "zh", 'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m",
+	'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u",
+	'ф': "f", 'х': "h", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "sch", 'ъ': "",
+	'ы': "y", 'ь': "", 'э': "e", 'ю': "yu", 'я': "ya",
+	'і': "i", 'ї': "yi", 'є': "ye", 'ґ': "g",
+}
+
+// Make строит URL-safe slug из заголовка: нижний регистр, латиница, цифры и дефисы.
+// Символы, которые не удалось транслитерировать, становятся разделителями.
+// Пустой результат означает, что из заголовка ничего не получилось - вызывающий
+// код должен подставить запасной вариант (например, ID поста).
+func Make(title string) string {
+	var b strings.Builder
+	pendingHyphen := false
+
+	write := func(s string) {
+		if s == "" {
+			return
+		}
+		if pendingHyphen && b.Len() > 0 {
+			b.WriteByte('-')
+		}
+		pendingHyphen = false
+		b.WriteString(s)
+	}
+
+	// NFC для кириллицы (й, ё остаются одним символом), NFD для латиницы - отбросить диакритику (é -> e)
+	for _, r := range norm.NFC.String(strings.ToLower(title)) {
+		if translit[r] == "" && r > unicode.MaxASCII && unicode.Is(unicode.Latin, r) {
+			for _, d := range norm.NFD.String(string(r)) {
+				if d <= unicode.MaxASCII {
+					write(string(d))
+				}
+			}
+			continue
+		}
+
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			write(string(r))
+		case translit[r] != "":
+			write(translit[r])
+		case r == 'ъ' || r == 'ь' || r == '\'' || r == '’':
+			// внутри слова - просто пропускаем
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsSpace(r) || unicode.IsPunct(r) || unicode.IsSymbol(r):
+			pendingHyphen = true
+		}
+	}
+
+	return truncate(b.String(), MaxLength)
+}
+
+// maxCandidates - сколько вариантов (title, title-2, ... title-100) проверяет Unique
+const maxCandidates = 100
+
+// Unique строит slug из заголовка и добавляет числовой суффикс (-2, -3, ...), пока taken
+// говорит, что вариант занят. Если из заголовка ничего не получилось - возвращает fallback
+// (ID поста): он уникален сам по себе.
+func Unique(title, fallback string, taken func(candidate string) (bool, error)) (string, error) {
+	base := Make(title)
+	if base == "" {
+		return fallback, nil
+	}
+
+	for n := 1; n <= maxCandidates; n++ {
+		candidate := base
+		if n > 1 {
+			candidate = fmt.Sprintf("%s-%d", base, n)
+		}
+
+		busy, err := taken(candidate)
+		if err != nil {
+			return "", err
+		}
+		if !busy {
+			return candidate, nil
+		}
+	}
+
+	// Слишком популярный заголовок - fallback гарантированно уникален
+	return fmt.Sprintf("%s-%s", base, fallback), nil
+}
+
+// truncate обрезает slug по границе слова
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+
+	s = s[:max]
+	if i := strings.LastIndexByte(s, '-'); i > 0 {
+		s = s[:i]
+	}
+
+	return strings.Trim(s, "-")
+}
@@ -0,0 +1,102 @@
+package slug
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMake(t *testing.T) {
+	cases := []struct {
+		name  string
+		title string
+		want  string
+	}{
+		{"lowercase and hyphens", "Hello, World!", "hello-world"},
+		{"runs of separators", "  Go   --  is__fun  ", "go-is-fun"},
+		{"digits are kept", "Top 10 Tips for 2024", "top-10-tips-for-2024"},
+		{"latin diacritics", "Café Crème Brûlée", "cafe-creme-brulee"},
+		{"cyrillic", "Привет, мир", "privet-mir"},
+		{"cyrillic digraphs", "Щука и ёжик", "schuka-i-ezhik"},
+		{"hard and soft signs", "Объявление: мальчик", "obyavlenie-malchik"},
+		{"ukrainian", "Їжак", "yizhak"},
+		{"apostrophe inside a word", "Don't panic", "dont-panic"},
+		{"emoji is a separator", "go🚀rocket", "go-rocket"},
+		{"untransliterable", "你好世界", ""},
+		{"only punctuation", "!!! ???", ""},
+		{"empty", "", ""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, Make(tc.title))
+		})
+	}
+
+	t.Run("Truncated at a word boundary", func(t *testing.T) {
+		got := Make(strings.Repeat("word ", 30))
+		assert.LessOrEqual(t, len(got), MaxLength)
+		assert.True(t, strings.HasSuffix(got, "word"), got)
+		assert.False(t, strings.HasSuffix(got, "-"), got)
+	})
+}
+
+func TestUnique(t *testing.T) {
+	// taken занимает перечисленные slug и запоминает проверенные варианты
+	takenBy := func(busy ...string) (func(string) (bool, error), *[]string) {
+		var checked []string
+		set := map[string]bool{}
+		for _, s := range busy {
+			set[s] = true
+		}
+		return func(candidate string) (bool, error) {
+			checked = append(checked, candidate)
+			return set[candidate], nil
+		}, &checked
+	}
+
+	t.Run("Free slug", func(t *testing.T) {
+		taken, _ := takenBy()
+		got, err := Unique("Hello World", "65f1a2b3", taken)
+		require.NoError(t, err)
+		assert.Equal(t, "hello-world", got)
+	})
+
+	t.Run("Collision adds a numeric suffix", func(t *testing.T) {
+		taken, checked := takenBy("hello-world", "hello-world-2")
+		got, err := Unique("Hello World", "65f1a2b3", taken)
+		require.NoError(t, err)
+		assert.Equal(t, "hello-world-3", got)
+		assert.Equal(t, []string{"hello-world", "hello-world-2", "hello-world-3"}, *checked)
+	})
+
+	t.Run("Non-ASCII title falls back to the id", func(t *testing.T) {
+		taken, checked := takenBy()
+		got, err := Unique("你好世界", "65f1a2b3", taken)
+		require.NoError(t, err)
+		assert.Equal(t, "65f1a2b3", got)
+		assert.Empty(t, *checked, "the id is unique and is not checked")
+	})
+
+	t.Run("Too many collisions fall back to the id suffix", func(t *testing.T) {
+		busy := []string{"hello"}
+		for n := 2; n <= maxCandidates; n++ {
+			busy = append(busy, fmt.Sprintf("hello-%d", n))
+		}
+		taken, checked := takenBy(busy...)
+		got, err := Unique("Hello", "65f1a2b3", taken)
+		require.NoError(t, err)
+		assert.Equal(t, "hello-65f1a2b3", got)
+		assert.Len(t, *checked, maxCandidates)
+	})
+
+	t.Run("Lookup error", func(t *testing.T) {
+		lookupErr := errors.New("mongo down")
+		_, err := Unique("Hello", "65f1a2b3", func(string) (bool, error) { return false, lookupErr })
+		assert.ErrorIs(t, err, lookupErr)
+	})
+}